@@ -0,0 +1,73 @@
+// Package app wires the long-lived services shared by every handler
+package app
+
+import (
+	"context"
+	"log"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/services"
+	"github.com/Fairy-nn/MemoryCacheAI/tokenizer"
+	"github.com/Fairy-nn/MemoryCacheAI/tracing"
+)
+
+// App holds the services built once at startup and shared across handlers
+type App struct {
+	MemoryService    *services.MemoryService
+	Retention        *services.RetentionPolicies
+	SessionPolicies  *services.SessionPolicies
+	APIKeys          *services.APIKeys
+	Templates        *services.PromptTemplates
+	EmbeddingMonitor *clients.EmbeddingHealthMonitor
+}
+
+// New constructs the application's services
+func New() *App {
+	if path := config.AppConfig.TokenizerRanksFile; path != "" {
+		if err := tokenizer.Load(path); err != nil {
+			log.Fatalf("Failed to load TOKENIZER_RANKS_FILE: %v", err)
+		}
+	}
+
+	memoryService := services.NewMemoryService()
+
+	return &App{
+		MemoryService:    memoryService,
+		Retention:        memoryService.RetentionPolicies(),
+		SessionPolicies:  memoryService.SessionPolicies(),
+		APIKeys:          memoryService.APIKeys(),
+		Templates:        memoryService.PromptTemplates(),
+		EmbeddingMonitor: clients.GetEmbeddingHealthMonitor(),
+	}
+}
+
+// Start launches the background workers: embedding health probes, storage usage
+// sampling, write-ahead queue replay, the job workers and, in local scheduler mode or
+// when QStash is not configured, the internal cleanup scheduler
+func (a *App) Start() {
+	a.EmbeddingMonitor.Start()
+	a.MemoryService.StartInternalScheduler()
+	a.MemoryService.StartUsageSampler()
+	a.MemoryService.StartWriteQueueReplay()
+	a.MemoryService.StartJobWorkers()
+}
+
+// Drain waits until the writes requests left running in the background, such as jobs
+// and session condensing, finish or ctx ends. Call it after the HTTP server has drained
+// and before Close.
+func (a *App) Drain(ctx context.Context) error {
+	return services.DrainBackground(ctx)
+}
+
+// Close stops the background workers, releases client connections and exports the
+// spans still queued. Call it after the HTTP server has drained.
+func (a *App) Close() {
+	services.StopInternalScheduler()
+	services.StopUsageSampler()
+	services.StopWriteQueueReplay()
+	services.StopJobWorkers()
+	a.EmbeddingMonitor.Stop()
+	a.MemoryService.Close()
+	tracing.Shutdown()
+}
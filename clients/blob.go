@@ -0,0 +1,206 @@
+package clients
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// BlobStore holds immutable content keyed by its SHA-256 hash
+type BlobStore interface {
+	PutBlob(hash string, content string) error
+	GetBlob(hash string) (string, bool, error)
+	DeleteBlob(hash string) error
+}
+
+// blobRefPrefix starts the pointer kept in the metadata of memories stored as blobs
+const blobRefPrefix = "sha256:"
+
+// BlobContentStore keeps the full content of memories in a content-addressed blob store,
+// so identical bodies are stored once. Redis tracks which memories reference each blob;
+// a blob is deleted with the last memory referencing it.
+type BlobContentStore struct {
+	blobs BlobStore
+	refs  *RedisClient
+}
+
+func NewBlobContentStore(blobs BlobStore, refs *RedisClient) *BlobContentStore {
+	return &BlobContentStore{blobs: blobs, refs: refs}
+}
+
+// Close releases the connections of a blob store with its own client
+func (s *BlobContentStore) Close() {
+	if s3, ok := s.blobs.(*S3BlobStore); ok {
+		s3.Close()
+	}
+}
+
+// blobRefKey holds the blob pointer of one memory
+func blobRefKey(memoryID string) string {
+	return fmt.Sprintf("blob_ref:%s", memoryID)
+}
+
+// blobReferrersKey is the set of memories referencing one blob
+func blobReferrersKey(hash string) string {
+	return fmt.Sprintf("blob_refs:%s", hash)
+}
+
+// contentHash returns the hex SHA-256 of content
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// SaveMemoryContent stores content as a blob and references it from the memory, releasing
+// the blob of the memory's previous content
+func (s *BlobContentStore) SaveMemoryContent(memoryID string, content string) (string, error) {
+	hash := contentHash(content)
+	ref := blobRefPrefix + hash
+
+	previous, err := s.refs.getString(blobRefKey(memoryID))
+	if err != nil {
+		return "", fmt.Errorf("failed to get blob reference: %w", err)
+	}
+
+	// Uploading again is harmless: the same hash always names the same content
+	if err := s.blobs.PutBlob(hash, content); err != nil {
+		return "", err
+	}
+	if _, err := s.refs.executeCommand(RedisCommand{"SADD", blobReferrersKey(hash), memoryID}); err != nil {
+		return "", fmt.Errorf("failed to reference blob: %w", err)
+	}
+	if _, err := s.refs.executeCommand(RedisCommand{"SET", blobRefKey(memoryID), ref}); err != nil {
+		return "", fmt.Errorf("failed to reference blob: %w", err)
+	}
+
+	if previous != "" && previous != ref {
+		if err := s.release(memoryID, previous); err != nil {
+			fmt.Printf("Warning: failed to release previous content of memory %s: %v\n", memoryID, err)
+		}
+	}
+	return ref, nil
+}
+
+// GetMemoryContents loads memories' content from their blobs. Memories without a pointer
+// were truncated before the blob store was enabled and are read by memory ID.
+func (s *BlobContentStore) GetMemoryContents(refs map[string]string) (map[string]string, error) {
+	contents := make(map[string]string, len(refs))
+	legacy := make(map[string]string)
+	blobs := make(map[string]string) // hash -> content, so shared blobs are fetched once
+
+	for id, ref := range refs {
+		hash := strings.TrimPrefix(ref, blobRefPrefix)
+		if ref == "" || hash == ref {
+			legacy[id] = ref
+			continue
+		}
+
+		content, fetched := blobs[hash]
+		if !fetched {
+			blob, ok, err := s.blobs.GetBlob(hash)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			content = blob
+			blobs[hash] = blob
+		}
+		contents[id] = content
+	}
+
+	if len(legacy) > 0 {
+		legacyContents, err := s.refs.GetMemoryContents(legacy)
+		if err != nil {
+			return nil, err
+		}
+		for id, content := range legacyContents {
+			contents[id] = content
+		}
+	}
+	return contents, nil
+}
+
+// DeleteMemoryContents drops the memories' references, deleting blobs no longer referenced
+func (s *BlobContentStore) DeleteMemoryContents(memoryIDs ...string) error {
+	if len(memoryIDs) == 0 {
+		return nil
+	}
+
+	cmd := RedisCommand{"MGET"}
+	for _, id := range memoryIDs {
+		cmd = append(cmd, blobRefKey(id))
+	}
+	resp, err := s.refs.executeCommand(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to get blob references: %w", err)
+	}
+
+	values, _ := resp.Result.([]interface{})
+	for i, value := range values {
+		if ref, ok := value.(string); ok && i < len(memoryIDs) {
+			if err := s.release(memoryIDs[i], ref); err != nil {
+				return err
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(memoryIDs))
+	for _, id := range memoryIDs {
+		keys = append(keys, blobRefKey(id))
+	}
+	if _, err := s.refs.DeleteKeys(keys...); err != nil {
+		return err
+	}
+	return s.refs.DeleteMemoryContents(memoryIDs...)
+}
+
+// release removes a memory from a blob's referrers and deletes the blob once none remain
+func (s *BlobContentStore) release(memoryID string, ref string) error {
+	hash := strings.TrimPrefix(ref, blobRefPrefix)
+	if _, err := s.refs.executeCommand(RedisCommand{"SREM", blobReferrersKey(hash), memoryID}); err != nil {
+		return fmt.Errorf("failed to release blob: %w", err)
+	}
+
+	resp, err := s.refs.executeCommand(RedisCommand{"SCARD", blobReferrersKey(hash)})
+	if err != nil {
+		return fmt.Errorf("failed to count blob references: %w", err)
+	}
+	if count, _ := resp.Result.(float64); count > 0 {
+		return nil
+	}
+	return s.blobs.DeleteBlob(hash)
+}
+
+// blobKey is the Redis key of a blob when Redis is the blob store
+func blobKey(hash string) string {
+	return fmt.Sprintf("blob:%s", hash)
+}
+
+// PutBlob stores a blob in Redis
+func (r *RedisClient) PutBlob(hash string, content string) error {
+	if _, err := r.executeCommand(RedisCommand{"SET", blobKey(hash), content}); err != nil {
+		return fmt.Errorf("failed to put blob: %w", err)
+	}
+	return nil
+}
+
+// GetBlob returns a blob from Redis, reporting whether it exists
+func (r *RedisClient) GetBlob(hash string) (string, bool, error) {
+	resp, err := r.executeCommand(RedisCommand{"GET", blobKey(hash)})
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get blob: %w", err)
+	}
+	content, ok := resp.Result.(string)
+	return content, ok, nil
+}
+
+// DeleteBlob removes a blob from Redis
+func (r *RedisClient) DeleteBlob(hash string) error {
+	if _, err := r.DeleteKeys(blobKey(hash)); err != nil {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// coldObjectPrefix separates cold tier objects from content blobs under BLOB_S3_KEY_PREFIX
+const coldObjectPrefix = "cold/"
+
+// ColdStore keeps memories moved out of the vector index: one S3 object per memory holding
+// its content, metadata and embedding, and a Redis sorted set per user ordering the user's
+// cold memories by creation time
+type ColdStore struct {
+	objects *S3BlobStore
+	index   *RedisClient
+}
+
+// NewRegionColdStore creates the cold tier of a data region, or of the default instances
+// for "". It returns nil when tiering is disabled.
+func NewRegionColdStore(region string, index *RedisClient) *ColdStore {
+	if !config.AppConfig.ColdTierEnabled() {
+		return nil
+	}
+
+	bucket := config.AppConfig.ColdTierS3Bucket
+	if region != "" {
+		bucket = config.AppConfig.DataRegions[region].ColdBucket
+	}
+	objects := NewS3BlobStore(bucket)
+	objects.prefix += coldObjectPrefix
+	return &ColdStore{objects: objects, index: index}
+}
+
+// Close releases the object store's idle connections
+func (s *ColdStore) Close() {
+	s.objects.Close()
+}
+
+// coldIndexKey orders a user's cold memories by creation time
+func coldIndexKey(userID string) string {
+	return fmt.Sprintf("cold_memories:%s", userID)
+}
+
+// SaveColdMemory stores a memory, including its embedding, in the cold tier
+func (s *ColdStore) SaveColdMemory(memory *models.MemoryEntry) error {
+	data, err := json.Marshal(memory)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cold memory: %w", err)
+	}
+	if err := s.objects.PutBlob(memory.ID, string(data)); err != nil {
+		return fmt.Errorf("failed to store cold memory %s: %w", memory.ID, err)
+	}
+	if _, err := s.index.executeCommand(RedisCommand{"ZADD", coldIndexKey(memory.UserID), memory.Timestamp.Unix(), memory.ID}); err != nil {
+		return fmt.Errorf("failed to index cold memory %s: %w", memory.ID, err)
+	}
+	return nil
+}
+
+// ListColdMemories returns a user's most recent cold memories, newest first; a non-positive
+// limit returns all of them. Index entries whose object is gone are dropped.
+func (s *ColdStore) ListColdMemories(userID string, limit int) ([]models.MemoryEntry, error) {
+	stop := limit - 1
+	if limit <= 0 {
+		stop = -1
+	}
+	resp, err := s.index.executeCommand(RedisCommand{"ZREVRANGE", coldIndexKey(userID), 0, stop})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cold memories: %w", err)
+	}
+
+	items, _ := resp.Result.([]interface{})
+	memories := make([]models.MemoryEntry, 0, len(items))
+	var missing []string
+	for _, item := range items {
+		id, ok := item.(string)
+		if !ok {
+			continue
+		}
+		data, found, err := s.objects.GetBlob(id)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			missing = append(missing, id)
+			continue
+		}
+		var memory models.MemoryEntry
+		if err := json.Unmarshal([]byte(data), &memory); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal cold memory %s: %w", id, err)
+		}
+		memories = append(memories, memory)
+	}
+
+	if len(missing) > 0 {
+		if err := s.unindex(userID, missing...); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+	return memories, nil
+}
+
+// DeleteColdMemories removes memories from a user's cold tier
+func (s *ColdStore) DeleteColdMemories(userID string, ids ...string) error {
+	for _, id := range ids {
+		if err := s.objects.DeleteBlob(id); err != nil {
+			return err
+		}
+	}
+	return s.unindex(userID, ids...)
+}
+
+// ReassignColdMemory moves a cold memory to memory.UserID from the user it was stored for
+func (s *ColdStore) ReassignColdMemory(memory *models.MemoryEntry, fromUserID string) error {
+	if err := s.SaveColdMemory(memory); err != nil {
+		return err
+	}
+	return s.unindex(fromUserID, memory.ID)
+}
+
+// CountColdMemories returns how many memories a user has in the cold tier
+func (s *ColdStore) CountColdMemories(userID string) (int, error) {
+	resp, err := s.index.executeCommand(RedisCommand{"ZCARD", coldIndexKey(userID)})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count cold memories: %w", err)
+	}
+	count, _ := resp.Result.(float64)
+	return int(count), nil
+}
+
+// ColdUsers returns every user with memories in the cold tier
+func (s *ColdStore) ColdUsers() ([]string, error) {
+	keys, err := s.index.ScanKeys(coldIndexKey("*"))
+	if err != nil {
+		return nil, err
+	}
+	users := make([]string, len(keys))
+	for i, key := range keys {
+		users[i] = strings.TrimPrefix(key, coldIndexKey(""))
+	}
+	return users, nil
+}
+
+func (s *ColdStore) unindex(userID string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	cmd := RedisCommand{"ZREM", coldIndexKey(userID)}
+	for _, id := range ids {
+		cmd = append(cmd, id)
+	}
+	if _, err := s.index.executeCommand(cmd); err != nil {
+		return fmt.Errorf("failed to unindex cold memories: %w", err)
+	}
+	return nil
+}
+
+// ScoreColdMemories scores cold memories against a query vector by exact cosine similarity,
+// normalised like the vector stores' scores, and returns those scoring at least minScore
+func ScoreColdMemories(memories []models.MemoryEntry, queryVector []float64, minScore float64) []models.MemoryResult {
+	matches := make([]QueryMatch, 0, len(memories))
+	for i := range memories {
+		// Numbers take the float64 form metadata read back from a vector store has
+		metadata := memoryMetadata(&memories[i])
+		metadata["timestamp"] = float64(memories[i].Timestamp.Unix())
+		metadata["ttl"] = float64(memories[i].TTL)
+		matches = append(matches, QueryMatch{
+			ID:       memories[i].ID,
+			Score:    (1 + cosine(queryVector, memories[i].Embedding)) / 2,
+			Metadata: metadata,
+		})
+	}
+	return matchResults(matches, minScore)
+}
+
+func cosine(a []float64, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
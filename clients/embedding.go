@@ -2,9 +2,9 @@ package clients
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -18,15 +18,30 @@ type EmbeddingProvider string
 const (
 	ProviderJina   EmbeddingProvider = "jina"
 	ProviderOpenAI EmbeddingProvider = "openai"
+	ProviderLocal  EmbeddingProvider = "local" // hashed words, computed in process
 )
 
+// EmbeddingSource names the provider and model that produced an embedding
+type EmbeddingSource struct {
+	Provider EmbeddingProvider
+	Model    string
+}
+
 // EmbeddingClient interface for different embedding providers
 type EmbeddingClient interface {
 	GenerateEmbedding(text string) ([]float64, error)
+	// GenerateEmbeddingWithSource embeds text like GenerateEmbedding and reports the
+	// provider and model that actually produced the embedding, which differ from
+	// GetProvider and GetModel when a failover chain falls back
+	GenerateEmbeddingWithSource(text string) ([]float64, EmbeddingSource, error)
 	GenerateEmbeddings(texts []string) ([]float64, error)
 	GenerateBatchEmbeddings(texts []string) ([][]float64, error)
 	GetProvider() EmbeddingProvider
+	GetModel() string
 	GetDimensions() int
+	// WithContext returns the client bound to a request: its calls are abandoned once
+	// ctx is done
+	WithContext(ctx context.Context) EmbeddingClient
 }
 
 // UnifiedEmbeddingClient wraps different embedding providers
@@ -39,7 +54,8 @@ type UnifiedEmbeddingClient struct {
 type JinaClient struct {
 	apiKey  string
 	baseURL string
-	client  *http.Client
+	client  *httpClient
+	ctx     context.Context // request the client is bound to, see WithContext; nil otherwise
 }
 
 // OpenAIClient for OpenAI embeddings
@@ -47,7 +63,8 @@ type OpenAIClient struct {
 	apiKey  string
 	baseURL string
 	model   string
-	client  *http.Client
+	client  *httpClient
+	ctx     context.Context // request the client is bound to, see WithContext; nil otherwise
 }
 
 // Jina AI request/response structures
@@ -97,12 +114,22 @@ type OpenAIEmbeddingResponse struct {
 
 // NewEmbeddingClient creates a new embedding client based on configuration
 func NewEmbeddingClient() EmbeddingClient {
-	provider := strings.ToLower(config.AppConfig.EmbeddingProvider)
+	// Route through the health monitor when a failover chain is configured
+	if len(config.AppConfig.EmbeddingFailoverProviders) > 0 {
+		return NewFailoverEmbeddingClient(GetEmbeddingHealthMonitor())
+	}
+
+	return NewProviderClient(EmbeddingProvider(strings.ToLower(config.AppConfig.EmbeddingProvider)))
+}
 
+// NewProviderClient creates the client for a single embedding provider
+func NewProviderClient(provider EmbeddingProvider) EmbeddingClient {
 	switch provider {
-	case "openai":
+	case ProviderOpenAI:
 		return NewOpenAIClient()
-	case "jina", "":
+	case ProviderLocal:
+		return NewLocalEmbeddingClient()
+	case ProviderJina, "":
 		// Default to Jina if not specified
 		return NewJinaClient()
 	default:
@@ -126,16 +153,35 @@ func NewJinaClient() *JinaClient {
 	return &JinaClient{
 		apiKey:  config.AppConfig.JinaAPIKey,
 		baseURL: "https://api.jina.ai/v1",
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:  newHTTPClient(config.AppConfig.JinaClient).withFault(FaultEmbedding),
 	}
 }
 
+// Close releases the client's idle connections
+func (j *JinaClient) Close() {
+	j.client.Close()
+}
+
+// WithContext returns a copy of the client sharing its connections
+func (j *JinaClient) WithContext(ctx context.Context) EmbeddingClient {
+	bound := *j
+	bound.ctx = ctx
+	return &bound
+}
+
+func (j *JinaClient) GenerateEmbeddingWithSource(text string) ([]float64, EmbeddingSource, error) {
+	embedding, err := j.GenerateEmbedding(text)
+	return embedding, EmbeddingSource{Provider: j.GetProvider(), Model: j.GetModel()}, err
+}
+
 func (j *JinaClient) GetProvider() EmbeddingProvider {
 	return ProviderJina
 }
 
+func (j *JinaClient) GetModel() string {
+	return "jina-embeddings-v3"
+}
+
 func (j *JinaClient) GetDimensions() int {
 	return 1024 // Jina v3 default dimensions
 }
@@ -149,66 +195,37 @@ func (j *JinaClient) GenerateEmbedding(text string) ([]float64, error) {
 }
 
 func (j *JinaClient) GenerateEmbeddings(texts []string) ([]float64, error) {
-	if len(texts) == 0 {
-		return nil, fmt.Errorf("no texts provided")
-	}
-
-	reqBody := JinaEmbeddingRequest{
-		Input:         texts,
-		Model:         "jina-embeddings-v3",
-		Normalized:    true,
-		EmbeddingType: "float",
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", j.baseURL+"/embeddings", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+j.apiKey)
-
-	resp, err := j.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	embeddings, err := j.requestEmbeddings(texts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Jina API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var response JinaEmbeddingResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, err
 	}
 
-	if len(response.Data) == 0 {
+	if len(embeddings) == 0 {
 		return nil, fmt.Errorf("no embeddings returned")
 	}
 
 	// Return the first embedding (for single text input)
-	return response.Data[0].Embedding, nil
+	return embeddings[0], nil
 }
 
 func (j *JinaClient) GenerateBatchEmbeddings(texts []string) ([][]float64, error) {
+	return generateInBatches(texts, j.client.settings.MaxBatchSize, j.requestEmbeddings)
+}
+
+func (j *JinaClient) requestEmbeddings(texts []string) (embeddings [][]float64, err error) {
 	if len(texts) == 0 {
 		return nil, fmt.Errorf("no texts provided")
 	}
 
+	start := time.Now()
+	tokens := 0
+	defer func() {
+		recordEmbeddingCall(j.GetProvider(), j.GetModel(), len(texts), tokens, time.Since(start), err)
+	}()
+
 	reqBody := JinaEmbeddingRequest{
 		Input:         texts,
-		Model:         "jina-embeddings-v3",
+		Model:         j.GetModel(),
 		Normalized:    true,
 		EmbeddingType: "float",
 	}
@@ -218,35 +235,31 @@ func (j *JinaClient) GenerateBatchEmbeddings(texts []string) ([][]float64, error
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", j.baseURL+"/embeddings", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+j.apiKey)
-
-	resp, err := j.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
+	statusCode, body, err := j.client.Do(requestContext(j.ctx), func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", j.baseURL+"/embeddings", bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
 
-	body, err := io.ReadAll(resp.Body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+j.apiKey)
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Jina API request failed with status %d: %s", resp.StatusCode, string(body))
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("Jina API request failed with status %d: %s", statusCode, string(body))
 	}
 
 	var response JinaEmbeddingResponse
 	if err := json.Unmarshal(body, &response); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	tokens = response.Usage.TotalTokens
 
-	embeddings := make([][]float64, len(response.Data))
+	embeddings = make([][]float64, len(response.Data))
 	for i, data := range response.Data {
 		embeddings[i] = data.Embedding
 	}
@@ -266,16 +279,35 @@ func NewOpenAIClient() *OpenAIClient {
 		apiKey:  config.AppConfig.OpenAIAPIKey,
 		baseURL: "https://api.openai.com/v1",
 		model:   model,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:  newHTTPClient(config.AppConfig.OpenAIClient).withFault(FaultEmbedding),
 	}
 }
 
+// Close releases the client's idle connections
+func (o *OpenAIClient) Close() {
+	o.client.Close()
+}
+
+// WithContext returns a copy of the client sharing its connections
+func (o *OpenAIClient) WithContext(ctx context.Context) EmbeddingClient {
+	bound := *o
+	bound.ctx = ctx
+	return &bound
+}
+
+func (o *OpenAIClient) GenerateEmbeddingWithSource(text string) ([]float64, EmbeddingSource, error) {
+	embedding, err := o.GenerateEmbedding(text)
+	return embedding, EmbeddingSource{Provider: o.GetProvider(), Model: o.GetModel()}, err
+}
+
 func (o *OpenAIClient) GetProvider() EmbeddingProvider {
 	return ProviderOpenAI
 }
 
+func (o *OpenAIClient) GetModel() string {
+	return o.model
+}
+
 func (o *OpenAIClient) GetDimensions() int {
 	// Return dimensions based on model
 	switch o.model {
@@ -299,10 +331,34 @@ func (o *OpenAIClient) GenerateEmbedding(text string) ([]float64, error) {
 }
 
 func (o *OpenAIClient) GenerateEmbeddings(texts []string) ([]float64, error) {
+	embeddings, err := o.requestEmbeddings(texts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("no embeddings returned")
+	}
+
+	// Return the first embedding (for single text input)
+	return embeddings[0], nil
+}
+
+func (o *OpenAIClient) GenerateBatchEmbeddings(texts []string) ([][]float64, error) {
+	return generateInBatches(texts, o.client.settings.MaxBatchSize, o.requestEmbeddings)
+}
+
+func (o *OpenAIClient) requestEmbeddings(texts []string) (embeddings [][]float64, err error) {
 	if len(texts) == 0 {
 		return nil, fmt.Errorf("no texts provided")
 	}
 
+	start := time.Now()
+	tokens := 0
+	defer func() {
+		recordEmbeddingCall(o.GetProvider(), o.GetModel(), len(texts), tokens, time.Since(start), err)
+	}()
+
 	// For single text, pass as string; for multiple, pass as array
 	var input interface{}
 	if len(texts) == 1 {
@@ -322,89 +378,61 @@ func (o *OpenAIClient) GenerateEmbeddings(texts []string) ([]float64, error) {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", o.baseURL+"/embeddings", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	statusCode, body, err := o.client.Do(requestContext(o.ctx), func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", o.baseURL+"/embeddings", bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
 
-	resp, err := o.client.Do(req)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("OpenAI API request failed with status %d: %s", resp.StatusCode, string(body))
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("OpenAI API request failed with status %d: %s", statusCode, string(body))
 	}
 
 	var response OpenAIEmbeddingResponse
 	if err := json.Unmarshal(body, &response); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	tokens = response.Usage.TotalTokens
 
-	if len(response.Data) == 0 {
-		return nil, fmt.Errorf("no embeddings returned")
+	embeddings = make([][]float64, len(response.Data))
+	for _, data := range response.Data {
+		if data.Index >= 0 && data.Index < len(embeddings) {
+			embeddings[data.Index] = data.Embedding
+		}
 	}
 
-	// Return the first embedding (for single text input)
-	return response.Data[0].Embedding, nil
+	return embeddings, nil
 }
 
-func (o *OpenAIClient) GenerateBatchEmbeddings(texts []string) ([][]float64, error) {
+// generateInBatches splits texts into chunks of at most batchSize and concatenates the results
+func generateInBatches(texts []string, batchSize int, request func([]string) ([][]float64, error)) ([][]float64, error) {
 	if len(texts) == 0 {
 		return nil, fmt.Errorf("no texts provided")
 	}
-
-	reqBody := OpenAIEmbeddingRequest{
-		Input:          texts,
-		Model:          o.model,
-		EncodingFormat: "float",
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", o.baseURL+"/embeddings", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+o.apiKey)
-
-	resp, err := o.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("OpenAI API request failed with status %d: %s", resp.StatusCode, string(body))
+	if batchSize <= 0 {
+		batchSize = len(texts)
 	}
 
-	var response OpenAIEmbeddingResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-	}
+	embeddings := make([][]float64, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		end := start + batchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
 
-	embeddings := make([][]float64, len(response.Data))
-	for i, data := range response.Data {
-		embeddings[i] = data.Embedding
+		batch, err := request(texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, batch...)
 	}
 
 	return embeddings, nil
@@ -416,6 +444,10 @@ func (u *UnifiedEmbeddingClient) GenerateEmbedding(text string) ([]float64, erro
 	return u.client.GenerateEmbedding(text)
 }
 
+func (u *UnifiedEmbeddingClient) GenerateEmbeddingWithSource(text string) ([]float64, EmbeddingSource, error) {
+	return u.client.GenerateEmbeddingWithSource(text)
+}
+
 func (u *UnifiedEmbeddingClient) GenerateEmbeddings(texts []string) ([]float64, error) {
 	return u.client.GenerateEmbeddings(texts)
 }
@@ -424,10 +456,18 @@ func (u *UnifiedEmbeddingClient) GenerateBatchEmbeddings(texts []string) ([][]fl
 	return u.client.GenerateBatchEmbeddings(texts)
 }
 
+func (u *UnifiedEmbeddingClient) WithContext(ctx context.Context) EmbeddingClient {
+	return &UnifiedEmbeddingClient{provider: u.provider, client: u.client.WithContext(ctx)}
+}
+
 func (u *UnifiedEmbeddingClient) GetProvider() EmbeddingProvider {
 	return u.provider
 }
 
+func (u *UnifiedEmbeddingClient) GetModel() string {
+	return u.client.GetModel()
+}
+
 func (u *UnifiedEmbeddingClient) GetDimensions() int {
 	return u.client.GetDimensions()
 }
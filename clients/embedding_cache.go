@@ -0,0 +1,187 @@
+package clients
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/metrics"
+)
+
+// EmbeddingCache keeps embeddings in Redis so repeated texts (such as the same query sent
+// on every request) are not embedded by a paid API again. Entries are keyed by the
+// SHA-256 of the provider, model, EMBEDDING_VERSION and text, so changing any of them
+// misses the cache.
+type EmbeddingCache struct {
+	redis      *RedisClient
+	ttlSeconds int64
+}
+
+// NewEmbeddingCache returns a cache stored in redis, or nil when EMBEDDING_CACHE_TTL is 0
+func NewEmbeddingCache(redis *RedisClient) *EmbeddingCache {
+	ttl := int64(config.AppConfig.EmbeddingCacheTTL.Seconds())
+	if ttl <= 0 {
+		return nil
+	}
+	return &EmbeddingCache{redis: redis, ttlSeconds: ttl}
+}
+
+// WithEmbeddingCache returns client with its embeddings served from cache where possible.
+// A nil cache returns client unchanged.
+func WithEmbeddingCache(client EmbeddingClient, cache *EmbeddingCache) EmbeddingClient {
+	if cache == nil {
+		return client
+	}
+	if failover, ok := client.(*FailoverEmbeddingClient); ok {
+		// Cache behind each provider in the chain rather than the one currently active
+		return &FailoverEmbeddingClient{monitor: failover.monitor, cache: cache}
+	}
+	return &cachedEmbeddingClient{client: client, cache: cache}
+}
+
+// WithoutEmbeddingCache returns the client behind any cache, for checks such as
+// credential validation that must reach the provider
+func WithoutEmbeddingCache(client EmbeddingClient) EmbeddingClient {
+	switch c := client.(type) {
+	case *cachedEmbeddingClient:
+		return c.client
+	case *FailoverEmbeddingClient:
+		return &FailoverEmbeddingClient{monitor: c.monitor}
+	}
+	return client
+}
+
+// embeddingDigest identifies a text embedded by one provider and model
+func embeddingDigest(provider EmbeddingProvider, model string, text string) string {
+	h := sha256.New()
+	for _, part := range []string{string(provider), model, config.AppConfig.EmbeddingVersion, text} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// lookup returns the cached embeddings of texts, nil for misses. Cache errors are logged
+// and reported as misses so that an unavailable cache never fails an embedding.
+func (c *EmbeddingCache) lookup(provider EmbeddingProvider, model string, texts []string) ([]string, [][]float64) {
+	digests := make([]string, len(texts))
+	for i, text := range texts {
+		digests[i] = embeddingDigest(provider, model, text)
+	}
+
+	embeddings, err := c.redis.GetCachedEmbeddings(digests)
+	if err != nil {
+		log.Printf("Warning: embedding cache lookup failed: %v", err)
+		embeddings = make([][]float64, len(texts))
+	}
+
+	hits := 0
+	for _, embedding := range embeddings {
+		if embedding != nil {
+			hits++
+		}
+	}
+	labels := map[string]string{"provider": string(provider)}
+	metrics.AddCounter("memorycache_embedding_cache_hits_total", "Embeddings served from the embedding cache", labels, float64(hits))
+	metrics.AddCounter("memorycache_embedding_cache_misses_total", "Embeddings not found in the embedding cache", labels, float64(len(texts)-hits))
+
+	return digests, embeddings
+}
+
+// store caches a freshly generated embedding, logging failures
+func (c *EmbeddingCache) store(digest string, embedding []float64) {
+	if err := c.redis.CacheEmbedding(digest, embedding, c.ttlSeconds); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+// cachedEmbeddingClient consults the cache before calling a single provider
+type cachedEmbeddingClient struct {
+	client EmbeddingClient
+	cache  *EmbeddingCache
+}
+
+func (c *cachedEmbeddingClient) GenerateEmbedding(text string) ([]float64, error) {
+	digests, cached := c.cache.lookup(c.client.GetProvider(), c.client.GetModel(), []string{text})
+	if cached[0] != nil {
+		return cached[0], nil
+	}
+
+	embedding, err := c.client.GenerateEmbedding(text)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.store(digests[0], embedding)
+	return embedding, nil
+}
+
+func (c *cachedEmbeddingClient) GenerateEmbeddingWithSource(text string) ([]float64, EmbeddingSource, error) {
+	embedding, err := c.GenerateEmbedding(text)
+	return embedding, EmbeddingSource{Provider: c.client.GetProvider(), Model: c.client.GetModel()}, err
+}
+
+// GenerateEmbeddings embeds every text but returns only the first embedding, so it is
+// passed through uncached
+func (c *cachedEmbeddingClient) GenerateEmbeddings(texts []string) ([]float64, error) {
+	return c.client.GenerateEmbeddings(texts)
+}
+
+// GenerateBatchEmbeddings sends only the texts missing from the cache to the provider
+func (c *cachedEmbeddingClient) GenerateBatchEmbeddings(texts []string) ([][]float64, error) {
+	if len(texts) == 0 {
+		return c.client.GenerateBatchEmbeddings(texts)
+	}
+
+	digests, embeddings := c.cache.lookup(c.client.GetProvider(), c.client.GetModel(), texts)
+	var missing []string
+	var missingIdx []int
+	for i, embedding := range embeddings {
+		if embedding == nil {
+			missing = append(missing, texts[i])
+			missingIdx = append(missingIdx, i)
+		}
+	}
+	if len(missing) == 0 {
+		return embeddings, nil
+	}
+
+	generated, err := c.client.GenerateBatchEmbeddings(missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(generated) != len(missing) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(missing), len(generated))
+	}
+	for j, i := range missingIdx {
+		embeddings[i] = generated[j]
+		c.cache.store(digests[i], generated[j])
+	}
+	return embeddings, nil
+}
+
+// WithContext binds the provider client; cache lookups are not abandoned
+func (c *cachedEmbeddingClient) WithContext(ctx context.Context) EmbeddingClient {
+	return &cachedEmbeddingClient{client: c.client.WithContext(ctx), cache: c.cache}
+}
+
+func (c *cachedEmbeddingClient) GetProvider() EmbeddingProvider {
+	return c.client.GetProvider()
+}
+
+func (c *cachedEmbeddingClient) GetModel() string {
+	return c.client.GetModel()
+}
+
+func (c *cachedEmbeddingClient) GetDimensions() int {
+	return c.client.GetDimensions()
+}
+
+// Close releases the wrapped client's connections
+func (c *cachedEmbeddingClient) Close() {
+	if closer, ok := c.client.(interface{ Close() }); ok {
+		closer.Close()
+	}
+}
@@ -0,0 +1,364 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/metrics"
+)
+
+// canaryText is embedded by every health probe
+const canaryText = "MemoryCacheAI embedding health check"
+
+// ProviderHealth captures the latest probe result for an embedding provider
+type ProviderHealth struct {
+	Provider            EmbeddingProvider `json:"provider"`
+	Healthy             bool              `json:"healthy"`
+	Checked             bool              `json:"checked"`
+	LatencyMs           int64             `json:"latency_ms"`
+	LastChecked         time.Time         `json:"last_checked"`
+	LastError           string            `json:"last_error,omitempty"`
+	ConsecutiveFailures int               `json:"consecutive_failures"`
+	TotalProbes         int64             `json:"total_probes"`
+	TotalFailures       int64             `json:"total_failures"`
+}
+
+// EmbeddingHealthMonitor periodically probes every provider in the failover chain
+type EmbeddingHealthMonitor struct {
+	chain    []EmbeddingProvider
+	clients  map[EmbeddingProvider]EmbeddingClient
+	interval time.Duration
+	autoPin  bool
+
+	mu     sync.RWMutex
+	status map[EmbeddingProvider]*ProviderHealth
+	pinned EmbeddingProvider
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+}
+
+var (
+	healthMonitor     *EmbeddingHealthMonitor
+	healthMonitorOnce sync.Once
+)
+
+// GetEmbeddingHealthMonitor returns the process-wide embedding health monitor
+func GetEmbeddingHealthMonitor() *EmbeddingHealthMonitor {
+	healthMonitorOnce.Do(func() {
+		healthMonitor = NewEmbeddingHealthMonitor()
+	})
+	return healthMonitor
+}
+
+// NewEmbeddingHealthMonitor creates a monitor for the primary provider and its failover chain
+func NewEmbeddingHealthMonitor() *EmbeddingHealthMonitor {
+	chain := []EmbeddingProvider{EmbeddingProvider(strings.ToLower(config.AppConfig.EmbeddingProvider))}
+	for _, provider := range config.AppConfig.EmbeddingFailoverProviders {
+		chain = append(chain, EmbeddingProvider(provider))
+	}
+
+	m := &EmbeddingHealthMonitor{
+		chain:    chain,
+		clients:  make(map[EmbeddingProvider]EmbeddingClient, len(chain)),
+		interval: time.Duration(config.AppConfig.EmbeddingHealthInterval) * time.Second,
+		autoPin:  config.AppConfig.EmbeddingAutoPin,
+		status:   make(map[EmbeddingProvider]*ProviderHealth, len(chain)),
+		stop:     make(chan struct{}),
+	}
+
+	for _, provider := range chain {
+		m.clients[provider] = NewProviderClient(provider)
+		m.status[provider] = &ProviderHealth{Provider: provider}
+	}
+
+	return m
+}
+
+// Enabled reports whether periodic probing is configured
+func (m *EmbeddingHealthMonitor) Enabled() bool {
+	return m.interval > 0
+}
+
+// Start launches the background prober; it is a no-op when probing is disabled
+func (m *EmbeddingHealthMonitor) Start() {
+	if !m.Enabled() {
+		return
+	}
+
+	m.startOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(m.interval)
+			defer ticker.Stop()
+
+			m.ProbeAll()
+			for {
+				select {
+				case <-ticker.C:
+					m.ProbeAll()
+				case <-m.stop:
+					return
+				}
+			}
+		}()
+	})
+}
+
+// Stop terminates the background prober
+func (m *EmbeddingHealthMonitor) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+}
+
+// ProbeAll embeds the canary string with every provider and updates the pin
+func (m *EmbeddingHealthMonitor) ProbeAll() {
+	for _, provider := range m.chain {
+		m.probe(provider)
+	}
+
+	if m.autoPin {
+		m.updatePin()
+	}
+}
+
+func (m *EmbeddingHealthMonitor) probe(provider EmbeddingProvider) {
+	start := time.Now()
+	embedding, err := m.clients[provider].GenerateEmbedding(canaryText)
+	latency := time.Since(start)
+
+	if err == nil && len(embedding) == 0 {
+		err = fmt.Errorf("empty embedding returned")
+	}
+
+	m.mu.Lock()
+	status := m.status[provider]
+	status.Checked = true
+	status.LatencyMs = latency.Milliseconds()
+	status.LastChecked = start
+	status.TotalProbes++
+	if err != nil {
+		status.Healthy = false
+		status.LastError = err.Error()
+		status.ConsecutiveFailures++
+		status.TotalFailures++
+	} else {
+		status.Healthy = true
+		status.LastError = ""
+		status.ConsecutiveFailures = 0
+	}
+	m.mu.Unlock()
+
+	if err != nil {
+		log.Printf("Warning: embedding provider %s failed health probe: %v", provider, err)
+	}
+
+	labels := map[string]string{"provider": string(provider)}
+	healthy := 0.0
+	if err == nil {
+		healthy = 1
+	}
+	metrics.SetGauge("memorycache_embedding_provider_up", "Whether the last canary embedding succeeded", labels, healthy)
+	metrics.SetGauge("memorycache_embedding_probe_latency_seconds", "Latency of the last canary embedding", labels, latency.Seconds())
+	metrics.AddCounter("memorycache_embedding_probes_total", "Canary embeddings attempted", labels, 1)
+	if err != nil {
+		metrics.AddCounter("memorycache_embedding_probe_failures_total", "Canary embeddings that failed", labels, 1)
+	}
+}
+
+// updatePin routes traffic to the first healthy provider in the chain
+func (m *EmbeddingHealthMonitor) updatePin() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, provider := range m.chain {
+		if m.status[provider].Healthy {
+			if m.pinned != provider {
+				log.Printf("📌 Pinning embedding traffic to %s", provider)
+				m.pinned = provider
+			}
+			return
+		}
+	}
+	// Nothing is healthy: keep the current pin rather than flapping
+}
+
+// ActiveProvider returns the provider that receives traffic first
+func (m *EmbeddingHealthMonitor) ActiveProvider() EmbeddingProvider {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.activeProviderLocked()
+}
+
+// RoutingOrder returns the chain with the active provider moved to the front
+func (m *EmbeddingHealthMonitor) RoutingOrder() []EmbeddingProvider {
+	active := m.ActiveProvider()
+
+	order := []EmbeddingProvider{active}
+	for _, provider := range m.chain {
+		if provider != active {
+			order = append(order, provider)
+		}
+	}
+	return order
+}
+
+// Client returns the client used for a provider in the chain
+func (m *EmbeddingHealthMonitor) Client(provider EmbeddingProvider) EmbeddingClient {
+	return m.clients[provider]
+}
+
+// Ready reports whether at least one provider in the chain is usable.
+// Providers that have not been probed yet are assumed to be usable.
+func (m *EmbeddingHealthMonitor) Ready() bool {
+	if !m.Enabled() {
+		return true
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, provider := range m.chain {
+		status := m.status[provider]
+		if !status.Checked || status.Healthy {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshot returns a copy of the current health of every provider
+func (m *EmbeddingHealthMonitor) Snapshot() map[string]interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	providers := make([]ProviderHealth, 0, len(m.chain))
+	for _, provider := range m.chain {
+		providers = append(providers, *m.status[provider])
+	}
+
+	return map[string]interface{}{
+		"enabled":          m.Enabled(),
+		"auto_pin":         m.autoPin,
+		"active_provider":  m.activeProviderLocked(),
+		"interval_seconds": int(m.interval.Seconds()),
+		"providers":        providers,
+	}
+}
+
+func (m *EmbeddingHealthMonitor) activeProviderLocked() EmbeddingProvider {
+	if m.pinned != "" {
+		return m.pinned
+	}
+	return m.chain[0]
+}
+
+// FailoverEmbeddingClient tries each provider in the monitor's routing order until one succeeds
+type FailoverEmbeddingClient struct {
+	monitor *EmbeddingHealthMonitor
+	cache   *EmbeddingCache // nil when embeddings are not cached
+	ctx     context.Context // request the client is bound to, see WithContext; nil otherwise
+}
+
+// NewFailoverEmbeddingClient creates a client that follows the monitor's routing decisions
+func NewFailoverEmbeddingClient(monitor *EmbeddingHealthMonitor) *FailoverEmbeddingClient {
+	return &FailoverEmbeddingClient{monitor: monitor}
+}
+
+func (f *FailoverEmbeddingClient) GenerateEmbedding(text string) ([]float64, error) {
+	embedding, _, err := f.GenerateEmbeddingWithSource(text)
+	return embedding, err
+}
+
+// GenerateEmbeddingWithSource reports the provider in the chain that answered, which may
+// not be the active provider when it failed during the call
+func (f *FailoverEmbeddingClient) GenerateEmbeddingWithSource(text string) ([]float64, EmbeddingSource, error) {
+	var lastErr error
+	for _, provider := range f.monitor.RoutingOrder() {
+		embedding, source, err := f.providerClient(provider).GenerateEmbeddingWithSource(text)
+		if err == nil {
+			return embedding, source, nil
+		}
+		if f.abandoned() {
+			return nil, EmbeddingSource{}, err
+		}
+		log.Printf("Warning: embedding provider %s failed, trying next in chain: %v", provider, err)
+		lastErr = err
+	}
+	return nil, EmbeddingSource{}, fmt.Errorf("all embedding providers failed: %w", lastErr)
+}
+
+func (f *FailoverEmbeddingClient) GenerateEmbeddings(texts []string) ([]float64, error) {
+	var lastErr error
+	for _, provider := range f.monitor.RoutingOrder() {
+		embedding, err := f.providerClient(provider).GenerateEmbeddings(texts)
+		if err == nil {
+			return embedding, nil
+		}
+		if f.abandoned() {
+			return nil, err
+		}
+		log.Printf("Warning: embedding provider %s failed, trying next in chain: %v", provider, err)
+		lastErr = err
+	}
+	return nil, fmt.Errorf("all embedding providers failed: %w", lastErr)
+}
+
+func (f *FailoverEmbeddingClient) GenerateBatchEmbeddings(texts []string) ([][]float64, error) {
+	var lastErr error
+	for _, provider := range f.monitor.RoutingOrder() {
+		embeddings, err := f.providerClient(provider).GenerateBatchEmbeddings(texts)
+		if err == nil {
+			return embeddings, nil
+		}
+		if f.abandoned() {
+			return nil, err
+		}
+		log.Printf("Warning: embedding provider %s failed, trying next in chain: %v", provider, err)
+		lastErr = err
+	}
+	return nil, fmt.Errorf("all embedding providers failed: %w", lastErr)
+}
+
+// providerClient returns the client of a provider in the chain, behind the cache when one
+// is set so that each provider's embeddings are cached under its own name and model
+func (f *FailoverEmbeddingClient) providerClient(provider EmbeddingProvider) EmbeddingClient {
+	client := f.monitor.Client(provider)
+	if f.ctx != nil {
+		client = client.WithContext(f.ctx)
+	}
+	if f.cache == nil {
+		return client
+	}
+	return &cachedEmbeddingClient{client: client, cache: f.cache}
+}
+
+// abandoned reports whether the request the client is bound to is done, in which case
+// the next provider in the chain would fail the same way
+func (f *FailoverEmbeddingClient) abandoned() bool {
+	return f.ctx != nil && f.ctx.Err() != nil
+}
+
+// WithContext returns a copy of the client whose provider calls are abandoned once ctx is done
+func (f *FailoverEmbeddingClient) WithContext(ctx context.Context) EmbeddingClient {
+	return &FailoverEmbeddingClient{monitor: f.monitor, cache: f.cache, ctx: ctx}
+}
+
+func (f *FailoverEmbeddingClient) GetProvider() EmbeddingProvider {
+	return f.monitor.ActiveProvider()
+}
+
+func (f *FailoverEmbeddingClient) GetModel() string {
+	return f.monitor.Client(f.monitor.ActiveProvider()).GetModel()
+}
+
+func (f *FailoverEmbeddingClient) GetDimensions() int {
+	return f.monitor.Client(f.monitor.ActiveProvider()).GetDimensions()
+}
@@ -0,0 +1,105 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+)
+
+// LocalEmbeddingClient embeds text in process without an API: each word is hashed into
+// one of LOCAL_EMBEDDING_DIMENSIONS signed buckets and the counts are normalised. Texts
+// sharing words score as similar, which is enough for embedding and tests but carries no
+// semantics; use a real provider for production recall.
+type LocalEmbeddingClient struct {
+	dimensions int
+}
+
+func NewLocalEmbeddingClient() *LocalEmbeddingClient {
+	return &LocalEmbeddingClient{dimensions: config.GetProviderDimensions(string(ProviderLocal))}
+}
+
+// WithContext returns the client; embedding never waits, so ctx is unused
+func (l *LocalEmbeddingClient) WithContext(ctx context.Context) EmbeddingClient {
+	return l
+}
+
+func (l *LocalEmbeddingClient) GenerateEmbeddingWithSource(text string) ([]float64, EmbeddingSource, error) {
+	embedding, err := l.GenerateEmbedding(text)
+	return embedding, EmbeddingSource{Provider: l.GetProvider(), Model: l.GetModel()}, err
+}
+
+func (l *LocalEmbeddingClient) GetProvider() EmbeddingProvider {
+	return ProviderLocal
+}
+
+func (l *LocalEmbeddingClient) GetModel() string {
+	return "hashed-words-v1"
+}
+
+func (l *LocalEmbeddingClient) GetDimensions() int {
+	return l.dimensions
+}
+
+func (l *LocalEmbeddingClient) GenerateEmbedding(text string) ([]float64, error) {
+	return l.GenerateEmbeddings([]string{text})
+}
+
+// GenerateEmbeddings embeds the first text, like the other providers' single-text calls
+func (l *LocalEmbeddingClient) GenerateEmbeddings(texts []string) ([]float64, error) {
+	embeddings, err := l.GenerateBatchEmbeddings(texts)
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+func (l *LocalEmbeddingClient) GenerateBatchEmbeddings(texts []string) (embeddings [][]float64, err error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("no texts provided")
+	}
+
+	start := time.Now()
+	tokens := 0
+	defer func() {
+		recordEmbeddingCall(l.GetProvider(), l.GetModel(), len(texts), tokens, time.Since(start), err)
+	}()
+
+	embeddings = make([][]float64, len(texts))
+	for i, text := range texts {
+		words := tokenize(text)
+		tokens += len(words)
+		embeddings[i] = l.embed(words)
+	}
+	return embeddings, nil
+}
+
+// embed hashes words into a unit vector; a text without words embeds to the zero vector
+func (l *LocalEmbeddingClient) embed(words []string) []float64 {
+	vector := make([]float64, l.dimensions)
+	for _, word := range words {
+		hash := fnv.New64a()
+		hash.Write([]byte(word))
+		sum := hash.Sum64()
+		sign := 1.0
+		if sum>>63 == 1 {
+			sign = -1
+		}
+		vector[sum%uint64(l.dimensions)] += sign
+	}
+
+	var norm float64
+	for _, value := range vector {
+		norm += value * value
+	}
+	if norm > 0 {
+		norm = math.Sqrt(norm)
+		for i := range vector {
+			vector[i] /= norm
+		}
+	}
+	return vector
+}
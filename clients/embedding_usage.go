@@ -0,0 +1,87 @@
+package clients
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/metrics"
+)
+
+// ProviderUsage aggregates the embedding API calls made to one provider and model since
+// the process started
+type ProviderUsage struct {
+	Provider     EmbeddingProvider `json:"provider"`
+	Model        string            `json:"model"`
+	Calls        int64             `json:"calls"`
+	Errors       int64             `json:"errors"`
+	ErrorRate    float64           `json:"error_rate"`
+	Texts        int64             `json:"texts"`
+	Tokens       int64             `json:"tokens"` // as reported by the provider's API responses
+	AvgLatencyMs float64           `json:"avg_latency_ms"`
+	LastCall     time.Time         `json:"last_call"`
+
+	totalLatency time.Duration
+}
+
+type usageKey struct {
+	provider EmbeddingProvider
+	model    string
+}
+
+var (
+	usageMu sync.Mutex
+	usage   = make(map[usageKey]*ProviderUsage)
+)
+
+// recordEmbeddingCall adds one embedding API call to its provider's usage
+func recordEmbeddingCall(provider EmbeddingProvider, model string, texts int, tokens int, latency time.Duration, err error) {
+	usageMu.Lock()
+	stats, ok := usage[usageKey{provider, model}]
+	if !ok {
+		stats = &ProviderUsage{Provider: provider, Model: model}
+		usage[usageKey{provider, model}] = stats
+	}
+	stats.Calls++
+	stats.Texts += int64(texts)
+	stats.Tokens += int64(tokens)
+	stats.totalLatency += latency
+	stats.LastCall = time.Now()
+	if err != nil {
+		stats.Errors++
+	}
+	usageMu.Unlock()
+
+	labels := map[string]string{"provider": string(provider), "model": model}
+	metrics.AddCounter("memorycache_embedding_requests_total", "Embedding API calls", labels, 1)
+	metrics.AddCounter("memorycache_embedding_request_seconds_total", "Time spent in embedding API calls", labels, latency.Seconds())
+	metrics.AddCounter("memorycache_embedding_tokens_total", "Tokens reported by embedding API responses", labels, float64(tokens))
+	if err != nil {
+		metrics.AddCounter("memorycache_embedding_request_failures_total", "Embedding API calls that failed", labels, 1)
+	}
+}
+
+// EmbeddingUsage returns the usage of every provider and model called so far, health
+// probes included, ordered by provider and model
+func EmbeddingUsage() []ProviderUsage {
+	usageMu.Lock()
+	defer usageMu.Unlock()
+
+	snapshot := make([]ProviderUsage, 0, len(usage))
+	for _, stats := range usage {
+		entry := *stats
+		if entry.Calls > 0 {
+			entry.ErrorRate = float64(entry.Errors) / float64(entry.Calls)
+			entry.AvgLatencyMs = float64(entry.totalLatency.Microseconds()) / 1000 / float64(entry.Calls)
+		}
+		snapshot = append(snapshot, entry)
+	}
+
+	sort.Slice(snapshot, func(i, j int) bool {
+		if snapshot[i].Provider != snapshot[j].Provider {
+			return snapshot[i].Provider < snapshot[j].Provider
+		}
+		return snapshot[i].Model < snapshot[j].Model
+	})
+	return snapshot
+}
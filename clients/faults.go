@@ -0,0 +1,79 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+)
+
+// Fault injection targets, as named in FAULT_INJECTION
+const (
+	FaultRedis     = "redis"
+	FaultVector    = "vector"
+	FaultEmbedding = "embedding"
+)
+
+// ErrInjectedFault is returned by client calls failed by a FAULT_INJECTION rule
+var ErrInjectedFault = errors.New("injected fault")
+
+// Client calls carry no request context, so a rule scoped to a route applies to every
+// call its client makes while a request on that route is in flight
+var (
+	faultRoutesMu sync.Mutex
+	faultRoutes   = make(map[string]int) // route template -> in-flight requests
+)
+
+// BeginFaultRoute marks a request on route as in flight, arming the FAULT_INJECTION rules
+// scoped to it, and returns the function that ends it
+func BeginFaultRoute(route string) func() {
+	faultRoutesMu.Lock()
+	faultRoutes[route]++
+	faultRoutesMu.Unlock()
+
+	return func() {
+		faultRoutesMu.Lock()
+		defer faultRoutesMu.Unlock()
+		if faultRoutes[route]--; faultRoutes[route] <= 0 {
+			delete(faultRoutes, route)
+		}
+	}
+}
+
+// injectFault applies the armed rules for target: it sleeps for their latency and returns
+// ErrInjectedFault for the fraction of calls they fail. It is called once per attempt so
+// injected errors go through the client's retries.
+func injectFault(target string) error {
+	if target == "" || len(config.AppConfig.FaultRules) == 0 {
+		return nil
+	}
+
+	latency, fail := armedFaults(target)
+	time.Sleep(latency)
+	if fail {
+		return fmt.Errorf("%w: %s", ErrInjectedFault, target)
+	}
+	return nil
+}
+
+// armedFaults sums the latency of the rules armed for target and rolls their error rates
+func armedFaults(target string) (time.Duration, bool) {
+	faultRoutesMu.Lock()
+	defer faultRoutesMu.Unlock()
+
+	var latency time.Duration
+	fail := false
+	for _, rule := range config.AppConfig.FaultRules {
+		if rule.Target != target || (rule.Route != "*" && faultRoutes[rule.Route] == 0) {
+			continue
+		}
+		latency += rule.Latency
+		if rule.ErrorRate > 0 && rand.Float64() < rule.ErrorRate {
+			fail = true
+		}
+	}
+	return latency, fail
+}
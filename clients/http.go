@@ -0,0 +1,207 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+)
+
+// httpClient wraps http.Client with the retry and concurrency limits from config
+type httpClient struct {
+	client   *http.Client
+	settings config.ClientSettings
+	slots    chan struct{} // nil when concurrency is unlimited
+	fault    string        // FAULT_INJECTION target, empty for clients without one
+}
+
+func newHTTPClient(settings config.ClientSettings) *httpClient {
+	c := &httpClient{
+		client: &http.Client{
+			Timeout: settings.Timeout,
+		},
+		settings: settings,
+	}
+	if settings.Concurrency > 0 {
+		c.slots = make(chan struct{}, settings.Concurrency)
+	}
+	return c
+}
+
+// withFault makes the client subject to the FAULT_INJECTION rules for target
+func (c *httpClient) withFault(target string) *httpClient {
+	c.fault = target
+	return c
+}
+
+// Close releases idle keep-alive connections
+func (c *httpClient) Close() {
+	c.client.CloseIdleConnections()
+}
+
+// Do sends the request built by newRequest and returns the status code and body.
+// Transport errors, 429s and 5xx responses are retried with exponential backoff.
+// newRequest is called once per attempt so request bodies can be replayed. Once ctx is
+// done the request is abandoned, including while it waits for a slot or a retry.
+func (c *httpClient) Do(ctx context.Context, newRequest func() (*http.Request, error)) (int, []byte, error) {
+	return c.do(ctx, c.settings.MaxRetries, newRequest)
+}
+
+// DoOnce is Do without retries, for requests that must not be applied twice: a request
+// that failed in transit may still have reached the server
+func (c *httpClient) DoOnce(ctx context.Context, newRequest func() (*http.Request, error)) (int, []byte, error) {
+	return c.do(ctx, 0, newRequest)
+}
+
+func (c *httpClient) do(ctx context.Context, maxRetries int, newRequest func() (*http.Request, error)) (int, []byte, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer release()
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, retryBackoff(attempt)); err != nil {
+				return 0, nil, err
+			}
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req = req.WithContext(ctx)
+
+		if err := injectFault(c.fault); err != nil {
+			lastErr = err
+			continue
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to send request: %w", err)
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("failed to read response: %w", err)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			if attempt < maxRetries {
+				continue
+			}
+		}
+
+		return resp.StatusCode, body, nil
+	}
+
+	return 0, nil, lastErr
+}
+
+// Stream is Do for streamed responses: once a 200 arrives its body is passed to handle
+// as it is received instead of being buffered. Failures before that are retried like Do;
+// other statuses return their body. Errors after streaming starts are not retried.
+func (c *httpClient) Stream(ctx context.Context, newRequest func() (*http.Request, error), handle func(io.Reader) error) (int, []byte, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer release()
+
+	var lastErr error
+	for attempt := 0; attempt <= c.settings.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, retryBackoff(attempt)); err != nil {
+				return 0, nil, err
+			}
+		}
+
+		req, err := newRequest()
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req = req.WithContext(ctx)
+
+		if err := injectFault(c.fault); err != nil {
+			lastErr = err
+			continue
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to send request: %w", err)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			err := handle(resp.Body)
+			resp.Body.Close()
+			return resp.StatusCode, nil, err
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("failed to read response: %w", err)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			if attempt < c.settings.MaxRetries {
+				continue
+			}
+		}
+
+		return resp.StatusCode, body, nil
+	}
+
+	return 0, nil, lastErr
+}
+
+// acquire takes one of the client's concurrency slots, giving up when ctx is done first.
+// The returned func releases the slot.
+func (c *httpClient) acquire(ctx context.Context) (func(), error) {
+	if c.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case c.slots <- struct{}{}:
+		return func() { <-c.slots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("request abandoned: %w", ctx.Err())
+	}
+}
+
+// sleepContext waits for d, returning early with an error when ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("request abandoned: %w", ctx.Err())
+	}
+}
+
+// requestContext returns ctx, or the background context for clients not bound to a request
+func requestContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+// retryBackoff returns the delay before the given retry attempt (200ms, 400ms, 800ms, ...)
+func retryBackoff(attempt int) time.Duration {
+	return time.Duration(100<<attempt) * time.Millisecond
+}
@@ -0,0 +1,637 @@
+package clients
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+)
+
+// LLMProvider represents the text generation service provider
+type LLMProvider string
+
+const (
+	LLMProviderOpenAI           LLMProvider = "openai"
+	LLMProviderAnthropic        LLMProvider = "anthropic"
+	LLMProviderOllama           LLMProvider = "ollama"
+	LLMProviderOpenAICompatible LLMProvider = "openai_compatible"
+)
+
+// LLMMessage is one turn of a conversation ("system", "user" or "assistant")
+type LLMMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// LLMRequest is a generation request: a conversation, a single prompt, or both (the
+// prompt is then appended as the final user turn)
+type LLMRequest struct {
+	Model     string // empty uses the client's default model
+	System    string
+	Messages  []LLMMessage
+	Prompt    string
+	MaxTokens int // 0 uses LLM_MAX_TOKENS
+}
+
+// systemPrompt joins the request's system prompt with any system turns of the conversation
+func (r LLMRequest) systemPrompt() string {
+	parts := make([]string, 0, 1)
+	if r.System != "" {
+		parts = append(parts, r.System)
+	}
+	for _, message := range r.Messages {
+		if message.Role == "system" && message.Content != "" {
+			parts = append(parts, message.Content)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// conversation returns the request's non-system turns followed by the prompt
+func (r LLMRequest) conversation() []LLMMessage {
+	turns := make([]LLMMessage, 0, len(r.Messages)+1)
+	for _, message := range r.Messages {
+		if message.Role != "system" {
+			turns = append(turns, message)
+		}
+	}
+	if r.Prompt != "" {
+		turns = append(turns, LLMMessage{Role: "user", Content: r.Prompt})
+	}
+	return turns
+}
+
+// chatMessages returns the conversation with the system prompt as a leading system turn,
+// as chat-style APIs expect
+func (r LLMRequest) chatMessages() []LLMMessage {
+	messages := r.conversation()
+	if system := r.systemPrompt(); system != "" {
+		messages = append([]LLMMessage{{Role: "system", Content: system}}, messages...)
+	}
+	return messages
+}
+
+// LLMResponse is the generated text and the tokens it cost
+type LLMResponse struct {
+	Text             string `json:"text"`
+	Model            string `json:"model"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+}
+
+// LLMClient interface for different text generation providers
+type LLMClient interface {
+	Generate(req LLMRequest) (*LLMResponse, error)
+	// GenerateStream calls onDelta with each piece of text as it is generated and
+	// returns the whole response once generation ends. An error from onDelta stops it.
+	GenerateStream(req LLMRequest, onDelta func(string) error) (*LLMResponse, error)
+	GetProvider() LLMProvider
+	GetModel() string
+	Close()
+}
+
+// NewLLMClient creates the configured generation client, or nil when LLM_PROVIDER is empty
+func NewLLMClient() LLMClient {
+	provider := LLMProvider(config.AppConfig.LLMProvider)
+	switch provider {
+	case LLMProviderOpenAI:
+		apiKey := config.AppConfig.LLMAPIKey
+		if apiKey == "" {
+			apiKey = config.AppConfig.OpenAIAPIKey
+		}
+		return newChatCompletionsClient(provider, "https://api.openai.com/v1", "gpt-4o-mini", apiKey)
+	case LLMProviderOpenAICompatible:
+		return newChatCompletionsClient(provider, "", "", config.AppConfig.LLMAPIKey)
+	case LLMProviderAnthropic:
+		return NewAnthropicClient()
+	case LLMProviderOllama:
+		return NewOllamaClient()
+	default:
+		return nil
+	}
+}
+
+// llmSettings resolves the model and base URL of a provider, preferring configuration
+func llmSettings(defaultBaseURL, defaultModel string) (string, string) {
+	baseURL := config.AppConfig.LLMBaseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	model := config.AppConfig.LLMModel
+	if model == "" {
+		model = defaultModel
+	}
+	return baseURL, model
+}
+
+// llmMaxTokens returns the request's completion limit, defaulting to LLM_MAX_TOKENS
+func llmMaxTokens(req LLMRequest) int {
+	if req.MaxTokens > 0 {
+		return req.MaxTokens
+	}
+	return config.AppConfig.LLMMaxTokens
+}
+
+// newLLMRequest returns a request builder posting body as JSON with the given headers
+func newLLMRequest(url string, headers map[string]string, body interface{}) (func() (*http.Request, error), error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	return func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		return req, nil
+	}, nil
+}
+
+// postLLMJSON sends a JSON request to a generation API and decodes a 200 response into out
+func postLLMJSON(client *httpClient, url string, headers map[string]string, body interface{}, out interface{}) error {
+	newRequest, err := newLLMRequest(url, headers, body)
+	if err != nil {
+		return err
+	}
+
+	statusCode, respBody, err := client.Do(context.Background(), newRequest)
+	if err != nil {
+		return err
+	}
+
+	if statusCode != http.StatusOK {
+		return fmt.Errorf("LLM request failed with status %d: %s", statusCode, string(respBody))
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// streamLLMLines sends a JSON request to a generation API and calls onLine with each
+// non-empty line of a 200 response. Server-sent events are passed with their "data: "
+// prefix removed; other lines (such as Ollama's NDJSON) are passed as they are.
+func streamLLMLines(client *httpClient, url string, headers map[string]string, body interface{}, onLine func(string) error) error {
+	newRequest, err := newLLMRequest(url, headers, body)
+	if err != nil {
+		return err
+	}
+
+	statusCode, respBody, err := client.Stream(context.Background(), newRequest, func(r io.Reader) error {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "event:") || strings.HasPrefix(line, ":") {
+				continue
+			}
+			if err := onLine(strings.TrimSpace(strings.TrimPrefix(line, "data:"))); err != nil {
+				return err
+			}
+		}
+		return scanner.Err()
+	})
+	if err != nil {
+		return err
+	}
+
+	if statusCode != http.StatusOK {
+		return fmt.Errorf("LLM request failed with status %d: %s", statusCode, string(respBody))
+	}
+	return nil
+}
+
+// errStreamDone stops reading a stream once its end marker arrives
+var errStreamDone = errors.New("stream done")
+
+// ChatCompletionsClient talks to OpenAI and gateways implementing its chat completions API
+type ChatCompletionsClient struct {
+	provider LLMProvider
+	apiKey   string
+	baseURL  string
+	model    string
+	client   *httpClient
+}
+
+type chatCompletionsRequest struct {
+	Model         string       `json:"model"`
+	Messages      []LLMMessage `json:"messages"`
+	MaxTokens     int          `json:"max_tokens,omitempty"`
+	Stream        bool         `json:"stream,omitempty"`
+	StreamOptions *struct {
+		IncludeUsage bool `json:"include_usage"`
+	} `json:"stream_options,omitempty"`
+}
+
+type chatCompletionsUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type chatCompletionsResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message LLMMessage `json:"message"`
+	} `json:"choices"`
+	Usage chatCompletionsUsage `json:"usage"`
+}
+
+type chatCompletionsChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *chatCompletionsUsage `json:"usage"`
+}
+
+func newChatCompletionsClient(provider LLMProvider, defaultBaseURL, defaultModel, apiKey string) *ChatCompletionsClient {
+	baseURL, model := llmSettings(defaultBaseURL, defaultModel)
+	return &ChatCompletionsClient{
+		provider: provider,
+		apiKey:   apiKey,
+		baseURL:  baseURL,
+		model:    model,
+		client:   newHTTPClient(config.AppConfig.LLMClient),
+	}
+}
+
+// Close releases the client's idle connections
+func (c *ChatCompletionsClient) Close() {
+	c.client.Close()
+}
+
+func (c *ChatCompletionsClient) GetProvider() LLMProvider {
+	return c.provider
+}
+
+func (c *ChatCompletionsClient) GetModel() string {
+	return c.model
+}
+
+// request builds the chat completions body and headers for req
+func (c *ChatCompletionsClient) request(req LLMRequest, stream bool) (chatCompletionsRequest, map[string]string, error) {
+	body := chatCompletionsRequest{
+		Model:     req.Model,
+		Messages:  req.chatMessages(),
+		MaxTokens: llmMaxTokens(req),
+		Stream:    stream,
+	}
+	if body.Model == "" {
+		body.Model = c.model
+	}
+	if body.Model == "" {
+		return body, nil, fmt.Errorf("no model configured for %s; set LLM_MODEL", c.provider)
+	}
+	// Only OpenAI itself is known to accept stream_options; gateways may reject it
+	if stream && c.provider == LLMProviderOpenAI {
+		body.StreamOptions = &struct {
+			IncludeUsage bool `json:"include_usage"`
+		}{IncludeUsage: true}
+	}
+
+	headers := map[string]string{}
+	if c.apiKey != "" {
+		headers["Authorization"] = "Bearer " + c.apiKey
+	}
+	return body, headers, nil
+}
+
+func (c *ChatCompletionsClient) Generate(req LLMRequest) (*LLMResponse, error) {
+	body, headers, err := c.request(req, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var response chatCompletionsResponse
+	if err := postLLMJSON(c.client, c.baseURL+"/chat/completions", headers, body, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("no completion returned")
+	}
+
+	return &LLMResponse{
+		Text:             strings.TrimSpace(response.Choices[0].Message.Content),
+		Model:            response.Model,
+		PromptTokens:     response.Usage.PromptTokens,
+		CompletionTokens: response.Usage.CompletionTokens,
+	}, nil
+}
+
+func (c *ChatCompletionsClient) GenerateStream(req LLMRequest, onDelta func(string) error) (*LLMResponse, error) {
+	body, headers, err := c.request(req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &LLMResponse{Model: body.Model}
+	var text strings.Builder
+	err = streamLLMLines(c.client, c.baseURL+"/chat/completions", headers, body, func(line string) error {
+		if line == "[DONE]" {
+			return errStreamDone
+		}
+
+		var chunk chatCompletionsChunk
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		if chunk.Model != "" {
+			response.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			response.PromptTokens = chunk.Usage.PromptTokens
+			response.CompletionTokens = chunk.Usage.CompletionTokens
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			return nil
+		}
+		text.WriteString(chunk.Choices[0].Delta.Content)
+		return onDelta(chunk.Choices[0].Delta.Content)
+	})
+	if err != nil && err != errStreamDone {
+		return nil, err
+	}
+
+	response.Text = strings.TrimSpace(text.String())
+	return response, nil
+}
+
+// AnthropicClient talks to the Anthropic messages API
+type AnthropicClient struct {
+	apiKey  string
+	baseURL string
+	model   string
+	client  *httpClient
+}
+
+type anthropicRequest struct {
+	Model     string       `json:"model"`
+	System    string       `json:"system,omitempty"`
+	Messages  []LLMMessage `json:"messages"`
+	MaxTokens int          `json:"max_tokens"`
+	Stream    bool         `json:"stream,omitempty"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage anthropicUsage `json:"usage"`
+}
+
+// anthropicEvent covers the stream events carrying text and usage
+type anthropicEvent struct {
+	Type    string `json:"type"`
+	Message *struct {
+		Model string         `json:"model"`
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	Delta *struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Usage *anthropicUsage `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func NewAnthropicClient() *AnthropicClient {
+	baseURL, model := llmSettings("https://api.anthropic.com/v1", "claude-3-5-haiku-latest")
+	return &AnthropicClient{
+		apiKey:  config.AppConfig.LLMAPIKey,
+		baseURL: baseURL,
+		model:   model,
+		client:  newHTTPClient(config.AppConfig.LLMClient),
+	}
+}
+
+// Close releases the client's idle connections
+func (a *AnthropicClient) Close() {
+	a.client.Close()
+}
+
+func (a *AnthropicClient) GetProvider() LLMProvider {
+	return LLMProviderAnthropic
+}
+
+func (a *AnthropicClient) GetModel() string {
+	return a.model
+}
+
+// request builds the messages API body and headers for req
+func (a *AnthropicClient) request(req LLMRequest, stream bool) (anthropicRequest, map[string]string) {
+	body := anthropicRequest{
+		Model:     req.Model,
+		System:    req.systemPrompt(),
+		Messages:  req.conversation(),
+		MaxTokens: llmMaxTokens(req),
+		Stream:    stream,
+	}
+	if body.Model == "" {
+		body.Model = a.model
+	}
+
+	return body, map[string]string{
+		"x-api-key":         a.apiKey,
+		"anthropic-version": "2023-06-01",
+	}
+}
+
+func (a *AnthropicClient) Generate(req LLMRequest) (*LLMResponse, error) {
+	body, headers := a.request(req, false)
+
+	var response anthropicResponse
+	if err := postLLMJSON(a.client, a.baseURL+"/messages", headers, body, &response); err != nil {
+		return nil, err
+	}
+
+	var text strings.Builder
+	for _, block := range response.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+
+	return &LLMResponse{
+		Text:             strings.TrimSpace(text.String()),
+		Model:            response.Model,
+		PromptTokens:     response.Usage.InputTokens,
+		CompletionTokens: response.Usage.OutputTokens,
+	}, nil
+}
+
+func (a *AnthropicClient) GenerateStream(req LLMRequest, onDelta func(string) error) (*LLMResponse, error) {
+	body, headers := a.request(req, true)
+
+	response := &LLMResponse{Model: body.Model}
+	var text strings.Builder
+	err := streamLLMLines(a.client, a.baseURL+"/messages", headers, body, func(line string) error {
+		var event anthropicEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return fmt.Errorf("failed to unmarshal stream event: %w", err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			if event.Message != nil {
+				response.Model = event.Message.Model
+				response.PromptTokens = event.Message.Usage.InputTokens
+			}
+		case "content_block_delta":
+			if event.Delta != nil && event.Delta.Type == "text_delta" && event.Delta.Text != "" {
+				text.WriteString(event.Delta.Text)
+				return onDelta(event.Delta.Text)
+			}
+		case "message_delta":
+			if event.Usage != nil {
+				response.CompletionTokens = event.Usage.OutputTokens
+			}
+		case "message_stop":
+			return errStreamDone
+		case "error":
+			if event.Error != nil {
+				return fmt.Errorf("LLM stream failed: %s", event.Error.Message)
+			}
+			return fmt.Errorf("LLM stream failed")
+		}
+		return nil
+	})
+	if err != nil && err != errStreamDone {
+		return nil, err
+	}
+
+	response.Text = strings.TrimSpace(text.String())
+	return response, nil
+}
+
+// OllamaClient talks to a local Ollama server's chat API
+type OllamaClient struct {
+	baseURL string
+	model   string
+	client  *httpClient
+}
+
+type ollamaRequest struct {
+	Model    string       `json:"model"`
+	Messages []LLMMessage `json:"messages"`
+	Stream   bool         `json:"stream"`
+	Options  struct {
+		NumPredict int `json:"num_predict,omitempty"`
+	} `json:"options"`
+}
+
+// ollamaResponse is both the whole response and, when streaming, each NDJSON chunk
+type ollamaResponse struct {
+	Model           string     `json:"model"`
+	Message         LLMMessage `json:"message"`
+	Done            bool       `json:"done"`
+	PromptEvalCount int        `json:"prompt_eval_count"`
+	EvalCount       int        `json:"eval_count"`
+	Error           string     `json:"error"`
+}
+
+func NewOllamaClient() *OllamaClient {
+	baseURL, model := llmSettings("http://localhost:11434", "llama3.1")
+	return &OllamaClient{
+		baseURL: baseURL,
+		model:   model,
+		client:  newHTTPClient(config.AppConfig.LLMClient),
+	}
+}
+
+// Close releases the client's idle connections
+func (o *OllamaClient) Close() {
+	o.client.Close()
+}
+
+func (o *OllamaClient) GetProvider() LLMProvider {
+	return LLMProviderOllama
+}
+
+func (o *OllamaClient) GetModel() string {
+	return o.model
+}
+
+// request builds the chat API body for req
+func (o *OllamaClient) request(req LLMRequest, stream bool) ollamaRequest {
+	body := ollamaRequest{
+		Model:    req.Model,
+		Messages: req.chatMessages(),
+		Stream:   stream,
+	}
+	if body.Model == "" {
+		body.Model = o.model
+	}
+	body.Options.NumPredict = llmMaxTokens(req)
+	return body
+}
+
+func (o *OllamaClient) Generate(req LLMRequest) (*LLMResponse, error) {
+	var response ollamaResponse
+	if err := postLLMJSON(o.client, o.baseURL+"/api/chat", nil, o.request(req, false), &response); err != nil {
+		return nil, err
+	}
+
+	return &LLMResponse{
+		Text:             strings.TrimSpace(response.Message.Content),
+		Model:            response.Model,
+		PromptTokens:     response.PromptEvalCount,
+		CompletionTokens: response.EvalCount,
+	}, nil
+}
+
+func (o *OllamaClient) GenerateStream(req LLMRequest, onDelta func(string) error) (*LLMResponse, error) {
+	body := o.request(req, true)
+
+	response := &LLMResponse{Model: body.Model}
+	var text strings.Builder
+	err := streamLLMLines(o.client, o.baseURL+"/api/chat", nil, body, func(line string) error {
+		var chunk ollamaResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return fmt.Errorf("LLM stream failed: %s", chunk.Error)
+		}
+		if chunk.Done {
+			response.PromptTokens = chunk.PromptEvalCount
+			response.CompletionTokens = chunk.EvalCount
+			return errStreamDone
+		}
+		if chunk.Message.Content == "" {
+			return nil
+		}
+		text.WriteString(chunk.Message.Content)
+		return onDelta(chunk.Message.Content)
+	})
+	if err != nil && err != errStreamDone {
+		return nil, err
+	}
+
+	response.Text = strings.TrimSpace(text.String())
+	return response, nil
+}
@@ -0,0 +1,55 @@
+package clients
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+)
+
+// Mailer sends plain-text email through the configured SMTP server
+type Mailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewMailer() *Mailer {
+	cfg := config.AppConfig
+	mailer := &Mailer{
+		addr: net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort)),
+		from: cfg.SMTPFrom,
+	}
+	if cfg.SMTPUsername != "" {
+		mailer.auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+	return mailer
+}
+
+// Send delivers a plain-text message to one recipient
+func (m *Mailer) Send(to string, subject string, body string) error {
+	if config.AppConfig.SMTPHost == "" {
+		return fmt.Errorf("SMTP is not configured")
+	}
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("invalid email recipient or subject")
+	}
+
+	message := strings.Join([]string{
+		"From: " + m.from,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{to}, []byte(message)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+)
+
+// WebhookNotifier delivers event payloads to external callback URLs. Its connections
+// may only reach public addresses.
+type WebhookNotifier struct {
+	client *httpClient
+}
+
+func NewWebhookNotifier() *WebhookNotifier {
+	return &WebhookNotifier{
+		client: newOutboundHTTPClient(config.AppConfig.QStashClient),
+	}
+}
+
+// Close releases the notifier's idle connections
+func (n *WebhookNotifier) Close() {
+	n.client.Close()
+}
+
+// Send posts the JSON-encoded payload to url, signed with the tenant's webhook secret
+// when one is configured
+func (n *WebhookNotifier) Send(url string, tenantID string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	secret := WebhookSecret(tenantID)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	statusCode, respBody, err := n.client.Do(context.Background(), func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "MemoryCacheAI-Webhook/1.0")
+		if secret != "" {
+			req.Header.Set("X-MemoryCache-Timestamp", timestamp)
+			req.Header.Set("X-MemoryCache-Signature", "sha256="+SignWebhook(secret, timestamp, body))
+		}
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to deliver webhook: %w", err)
+	}
+
+	if statusCode < 200 || statusCode >= 300 {
+		return fmt.Errorf("webhook delivery failed with status %d: %s", statusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// WebhookSecret returns the outbound signing secret of a tenant, falling back to the default
+func WebhookSecret(tenantID string) string {
+	if secret, ok := config.AppConfig.WebhookTenantSecrets[tenantID]; ok {
+		return secret
+	}
+	return config.AppConfig.WebhookSigningSecret
+}
+
+// SignWebhook computes the hex HMAC-SHA256 of "<timestamp>.<body>". Covering the
+// timestamp lets receivers reject replayed deliveries.
+func SignWebhook(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
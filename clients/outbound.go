@@ -0,0 +1,150 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+)
+
+// ErrBlockedDestination is returned for outbound URLs that are malformed or resolve to
+// addresses callers may not reach through the service (private, loopback, link-local,
+// metadata and other non-public ranges)
+var ErrBlockedDestination = errors.New("outbound destination blocked")
+
+// maxOutboundRedirects bounds the redirects followed by guarded clients
+const maxOutboundRedirects = 5
+
+// outboundResolveTimeout bounds the DNS lookup of CheckOutboundURL
+const outboundResolveTimeout = 5 * time.Second
+
+// blockedNetworks lists the non-public ranges not covered by the net.IP predicates
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",       // "this" network
+	"100.64.0.0/10",   // carrier-grade NAT
+	"192.0.0.0/24",    // IETF protocol assignments
+	"198.18.0.0/15",   // benchmarking
+	"240.0.0.0/4",     // reserved
+	"64:ff9b::/96",    // NAT64, which can embed an internal IPv4 address
+	"2001:db8::/32",   // documentation
+	"fd00:ec2::/32",   // AWS IPv6 metadata endpoint (also within fc00::/7)
+	"100::/64",        // discard-only
+	"2001:10::/28",    // ORCHID
+	"192.88.99.0/24",  // 6to4 relay anycast
+	"203.0.113.0/24",  // documentation
+	"198.51.100.0/24", // documentation
+	"192.0.2.0/24",    // documentation
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// blockedIP reports whether ip is outside the public internet
+func blockedIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOutboundURL validates a URL the service (or QStash on its behalf) will call: it
+// must be an absolute http or https URL without credentials whose host resolves only to
+// public addresses. OUTBOUND_ALLOW_PRIVATE_NETWORKS skips the address check.
+func CheckOutboundURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return fmt.Errorf("%w: %q must be an absolute http or https URL", ErrBlockedDestination, rawURL)
+	}
+	if parsed.User != nil {
+		return fmt.Errorf("%w: %q must not carry credentials", ErrBlockedDestination, rawURL)
+	}
+	if config.AppConfig.OutboundAllowPrivateNetworks {
+		return nil
+	}
+
+	host := parsed.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if blockedIP(ip) {
+			return fmt.Errorf("%w: %s is not a public address", ErrBlockedDestination, host)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), outboundResolveTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("%w: cannot resolve %q: %v", ErrBlockedDestination, host, err)
+	}
+	for _, addr := range addrs {
+		if blockedIP(addr.IP) {
+			return fmt.Errorf("%w: %q resolves to non-public address %s", ErrBlockedDestination, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// newOutboundHTTPClient is newHTTPClient for user-supplied destinations. Every connection
+// is checked after DNS resolution, so redirects and rebinding cannot reach internal hosts,
+// and at most maxOutboundRedirects redirects to http or https URLs are followed. Proxies
+// from the environment are not used because they would hide the real destination.
+func newOutboundHTTPClient(settings config.ClientSettings) *httpClient {
+	c := newHTTPClient(settings)
+	if config.AppConfig.OutboundAllowPrivateNetworks {
+		return c
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || blockedIP(ip) {
+				return fmt.Errorf("%w: %s is not a public address", ErrBlockedDestination, host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	c.client.Transport = transport
+	c.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxOutboundRedirects {
+			return fmt.Errorf("%w: stopped after %d redirects", ErrBlockedDestination, len(via))
+		}
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+			return fmt.Errorf("%w: redirect to %q", ErrBlockedDestination, req.URL.Redacted())
+		}
+		return nil
+	}
+	return c
+}
@@ -0,0 +1,634 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+
+	"github.com/google/uuid"
+)
+
+// qdrantIDField holds the memory ID in each point's payload. Qdrant point IDs must be
+// UUIDs or integers, so points are keyed by a UUID derived from the memory ID.
+const qdrantIDField = "memory_id"
+
+// qdrantPointNamespace derives point IDs from memory IDs
+var qdrantPointNamespace = uuid.MustParse("6f1c3f0e-4b8a-5d2e-9a61-2c7d8e4b1f35")
+
+// QdrantVectorStore is the VectorStore backed by a Qdrant collection. Hybrid search is
+// not supported; the collection holds one unnamed dense vector per memory.
+type QdrantVectorStore struct {
+	vectorContent
+
+	url          string
+	apiKey       string
+	collection   string
+	quantization string // "none", "scalar" or "binary"; applied to the collection on first use
+	client       *httpClient
+	ctx          context.Context  // request the store is bound to, see WithContext; nil otherwise
+	setup        *collectionSetup // shared with bound copies
+}
+
+// collectionSetup records whether a Qdrant collection is known to exist
+type collectionSetup struct {
+	mu    sync.Mutex
+	ready bool
+}
+
+type qdrantPoint struct {
+	ID      interface{}            `json:"id"`
+	Score   float64                `json:"score,omitempty"`
+	Vector  []float64              `json:"vector,omitempty"`
+	Payload map[string]interface{} `json:"payload,omitempty"`
+}
+
+type qdrantSearchRequest struct {
+	Vector      []float64              `json:"vector"`
+	Limit       int                    `json:"limit"`
+	Filter      map[string]interface{} `json:"filter,omitempty"`
+	Params      map[string]interface{} `json:"params,omitempty"`
+	WithPayload bool                   `json:"with_payload"`
+}
+
+type qdrantScrollRequest struct {
+	Limit       int                    `json:"limit"`
+	Offset      string                 `json:"offset,omitempty"`
+	Filter      map[string]interface{} `json:"filter,omitempty"`
+	WithPayload bool                   `json:"with_payload"`
+	WithVector  bool                   `json:"with_vector"`
+}
+
+type qdrantScrollResponse struct {
+	Result struct {
+		Points         []qdrantPoint `json:"points"`
+		NextPageOffset interface{}   `json:"next_page_offset"`
+	} `json:"result"`
+}
+
+func NewQdrantVectorStore(url string, apiKey string, collection string, quantization string) *QdrantVectorStore {
+	return &QdrantVectorStore{
+		url:          url,
+		apiKey:       apiKey,
+		collection:   collection,
+		quantization: quantization,
+		client:       newHTTPClient(config.AppConfig.VectorClient).withFault(FaultVector),
+		setup:        &collectionSetup{},
+	}
+}
+
+// WithContext returns a copy of the store whose requests are abandoned once ctx is done.
+// The copy shares the connections of q.
+func (q *QdrantVectorStore) WithContext(ctx context.Context) VectorStore {
+	bound := *q
+	bound.ctx = ctx
+	return &bound
+}
+
+// Close releases the client's idle connections
+func (q *QdrantVectorStore) Close() {
+	q.client.Close()
+}
+
+func (q *QdrantVectorStore) send(method, path string, body interface{}) (int, []byte, error) {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		reqBody, err = json.Marshal(body)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+	}
+
+	return q.client.Do(requestContext(q.ctx), func() (*http.Request, error) {
+		req, err := http.NewRequest(method, q.url+path, bytes.NewReader(reqBody))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		if q.apiKey != "" {
+			req.Header.Set("api-key", q.apiKey)
+		}
+		return req, nil
+	})
+}
+
+// makeRequest calls an endpoint of the collection, creating the collection first if needed
+func (q *QdrantVectorStore) makeRequest(method, endpoint string, body interface{}) ([]byte, error) {
+	if err := q.ensureCollection(); err != nil {
+		return nil, err
+	}
+
+	statusCode, respBody, err := q.send(method, "/collections/"+q.collection+endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode < 200 || statusCode >= 300 {
+		return nil, fmt.Errorf("API request failed with status %d: %s", statusCode, string(respBody))
+	}
+
+	return respBody, nil
+}
+
+// ensureCollection creates the collection with the embedding dimension and a user_id
+// index when it does not exist yet, and brings the quantization of an existing
+// collection in line with the store's
+func (q *QdrantVectorStore) ensureCollection() error {
+	q.setup.mu.Lock()
+	defer q.setup.mu.Unlock()
+	if q.setup.ready {
+		return nil
+	}
+
+	path := "/collections/" + q.collection
+	statusCode, respBody, err := q.send("GET", path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get Qdrant collection: %w", err)
+	}
+	switch {
+	case statusCode == http.StatusNotFound:
+		fmt.Printf("📦 Creating Qdrant collection %s\n", q.collection)
+		create := map[string]interface{}{
+			"vectors": map[string]interface{}{
+				"size":     config.GetEmbeddingDimensions(),
+				"distance": "Cosine",
+			},
+		}
+		if quantization := qdrantQuantization(q.quantization); quantization != nil {
+			create["vectors"].(map[string]interface{})["on_disk"] = true
+			create["quantization_config"] = quantization
+		}
+		if statusCode, respBody, err = q.send("PUT", path, create); err != nil || statusCode >= 300 {
+			return fmt.Errorf("failed to create Qdrant collection: status %d: %s %v", statusCode, string(respBody), err)
+		}
+		index := map[string]interface{}{"field_name": "user_id", "field_schema": "keyword"}
+		if statusCode, respBody, err = q.send("PUT", path+"/index?wait=true", index); err != nil || statusCode >= 300 {
+			return fmt.Errorf("failed to index user_id in Qdrant collection: status %d: %s %v", statusCode, string(respBody), err)
+		}
+	case statusCode < 200 || statusCode >= 300:
+		return fmt.Errorf("failed to get Qdrant collection: status %d: %s", statusCode, string(respBody))
+	default:
+		if err := q.updateQuantization(path, respBody); err != nil {
+			return err
+		}
+	}
+
+	q.setup.ready = true
+	return nil
+}
+
+// updateQuantization changes the quantization of an existing collection when it differs
+// from the store's. Qdrant rebuilds the quantized vectors in the background; searches
+// keep working meanwhile.
+func (q *QdrantVectorStore) updateQuantization(path string, collection []byte) error {
+	var info struct {
+		Result struct {
+			Config struct {
+				QuantizationConfig map[string]interface{} `json:"quantization_config"`
+			} `json:"config"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(collection, &info); err != nil {
+		return fmt.Errorf("failed to unmarshal Qdrant collection: %w", err)
+	}
+	current := "none"
+	for mode := range info.Result.Config.QuantizationConfig {
+		current = mode
+	}
+	if current == q.quantization {
+		return nil
+	}
+
+	fmt.Printf("📦 Changing the quantization of Qdrant collection %s from %s to %s\n", q.collection, current, q.quantization)
+	var quantization interface{} = "Disabled"
+	if settings := qdrantQuantization(q.quantization); settings != nil {
+		quantization = settings
+	}
+	update := map[string]interface{}{
+		// The unnamed vector is addressed by the empty name
+		"vectors":             map[string]interface{}{"": map[string]interface{}{"on_disk": quantization != "Disabled"}},
+		"quantization_config": quantization,
+	}
+	statusCode, respBody, err := q.send("PATCH", path, update)
+	if err != nil || statusCode >= 300 {
+		return fmt.Errorf("failed to update Qdrant collection quantization: status %d: %s %v", statusCode, string(respBody), err)
+	}
+	return nil
+}
+
+// qdrantQuantization returns the quantization config of a collection, or nil for none. The quantized vectors are kept in RAM and the originals on disk for rescoring.
+func qdrantQuantization(mode string) map[string]interface{} {
+	switch mode {
+	case "scalar":
+		return map[string]interface{}{
+			"scalar": map[string]interface{}{"type": "int8", "quantile": 0.99, "always_ram": true},
+		}
+	case "binary":
+		return map[string]interface{}{
+			"binary": map[string]interface{}{"always_ram": true},
+		}
+	}
+	return nil
+}
+
+// qdrantSearchParams returns the search params of a quantization mode: candidates are
+// found on the quantized vectors, oversampled and rescored with the originals so scores
+// stay exact
+func qdrantSearchParams(mode string) map[string]interface{} {
+	oversampling := quantizationOversampling(mode)
+	if oversampling == 0 {
+		return nil
+	}
+	return map[string]interface{}{
+		"quantization": map[string]interface{}{"rescore": true, "oversampling": oversampling},
+	}
+}
+
+// pointID returns the Qdrant point ID of a memory
+func pointID(id string) string {
+	return uuid.NewSHA1(qdrantPointNamespace, []byte(id)).String()
+}
+
+func pointIDs(ids []string) []string {
+	points := make([]string, len(ids))
+	for i, id := range ids {
+		points[i] = pointID(id)
+	}
+	return points
+}
+
+// toMatch converts a point back to a match keyed by memory ID
+func (p qdrantPoint) toMatch() QueryMatch {
+	id, _ := p.Payload[qdrantIDField].(string)
+	delete(p.Payload, qdrantIDField)
+	return QueryMatch{ID: id, Score: p.Score, Vector: p.Vector, Metadata: p.Payload}
+}
+
+func toMatches(points []qdrantPoint) []QueryMatch {
+	matches := make([]QueryMatch, len(points))
+	for i, point := range points {
+		matches[i] = point.toMatch()
+	}
+	return matches
+}
+
+// withMemoryID returns the payload stored for a memory's metadata
+func withMemoryID(id string, metadata map[string]interface{}) map[string]interface{} {
+	payload := copyMetadata(metadata)
+	payload[qdrantIDField] = id
+	return payload
+}
+
+func (q *QdrantVectorStore) UpsertMemory(memory *models.MemoryEntry) error {
+	metadata := memoryMetadata(memory)
+	if err := q.fitContent(memory.ID, metadata, true); err != nil {
+		return fmt.Errorf("failed to upsert memory: %w", err)
+	}
+
+	request := map[string]interface{}{
+		"points": []qdrantPoint{{
+			ID:      pointID(memory.ID),
+			Vector:  memory.Embedding,
+			Payload: withMemoryID(memory.ID, metadata),
+		}},
+	}
+	if _, err := q.makeRequest("PUT", "/points?wait=true", request); err != nil {
+		return fmt.Errorf("failed to upsert memory: %w", err)
+	}
+
+	return nil
+}
+
+// QueryMemories finds a user's memories closest to the query. Qdrant's cosine similarity
+// is mapped to (1 + cos) / 2 to match Upstash scores; queryText is unused.
+func (q *QdrantVectorStore) QueryMemories(userID string, filter string, queryText string, queryVector []float64, limit int, minScore float64) ([]models.MemoryResult, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	userFilter := fmt.Sprintf("user_id = '%s'", userID)
+	if filter != "" {
+		userFilter += " AND " + filter
+	}
+	qfilter, err := qdrantFilter(userFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query memories: %w", err)
+	}
+	request := qdrantSearchRequest{
+		Vector:      queryVector,
+		Limit:       limit,
+		Filter:      qfilter,
+		Params:      qdrantSearchParams(q.quantization),
+		WithPayload: true,
+	}
+
+	respBody, err := q.makeRequest("POST", "/points/search", request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query memories: %w", err)
+	}
+
+	var response struct {
+		Result []qdrantPoint `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal query response: %w", err)
+	}
+
+	matches := toMatches(response.Result)
+	for i := range matches {
+		matches[i].Score = (1 + matches[i].Score) / 2
+	}
+	q.hydrateContent(matches)
+
+	return matchResults(matches, minScore), nil
+}
+
+// SearchKeywords ranks up to HYBRID_KEYWORD_POOL of a user's memories selected by the
+// filter against the query terms with BM25. A non-empty filter is ANDed with the user filter.
+func (q *QdrantVectorStore) SearchKeywords(userID string, filter string, queryText string, limit int) ([]models.MemoryResult, error) {
+	userFilter := fmt.Sprintf("user_id = '%s'", userID)
+	if filter != "" {
+		userFilter += " AND " + filter
+	}
+	matches, err := q.listMemories(userFilter, config.AppConfig.HybridKeywordPool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search keywords: %w", err)
+	}
+	return rankBM25(queryText, matches, limit), nil
+}
+
+// UpdateMetadata overwrites the payload of a stored memory without touching its vector.
+// Content that was hydrated on read is truncated again.
+func (q *QdrantVectorStore) UpdateMetadata(id string, metadata map[string]interface{}) error {
+	metadata = copyMetadata(metadata)
+	if err := q.fitContent(id, metadata, false); err != nil {
+		return fmt.Errorf("failed to update memory metadata: %w", err)
+	}
+
+	request := map[string]interface{}{
+		"payload": withMemoryID(id, metadata),
+		"points":  []string{pointID(id)},
+	}
+	if _, err := q.makeRequest("PUT", "/points/payload?wait=true", request); err != nil {
+		return fmt.Errorf("failed to update memory metadata: %w", err)
+	}
+
+	return nil
+}
+
+func (q *QdrantVectorStore) fetch(ids []string, withPayload bool, withVector bool) ([]QueryMatch, error) {
+	request := map[string]interface{}{
+		"ids":          pointIDs(ids),
+		"with_payload": withPayload,
+		"with_vector":  withVector,
+	}
+
+	respBody, err := q.makeRequest("POST", "/points", request)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Result []qdrantPoint `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal fetch response: %w", err)
+	}
+	return toMatches(response.Result), nil
+}
+
+// FetchMemory returns a stored memory with its metadata, or nil if it does not exist
+func (q *QdrantVectorStore) FetchMemory(id string) (*QueryMatch, error) {
+	matches, err := q.fetch([]string{id}, true, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch memory: %w", err)
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	q.hydrateContent(matches)
+	return &matches[0], nil
+}
+
+// FetchVectors returns the vectors of stored memories without their metadata, omitting
+// IDs that do not exist
+func (q *QdrantVectorStore) FetchVectors(ids []string) ([]QueryMatch, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	// The payload is needed to map points back to memory IDs
+	matches, err := q.fetch(ids, true, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch vectors: %w", err)
+	}
+	for i := range matches {
+		matches[i].Metadata = nil
+	}
+	return matches, nil
+}
+
+// scroll returns one page of the points a filter selects and the offset of the next page
+func (q *QdrantVectorStore) scroll(filter string, offset string, limit int, withVector bool) ([]QueryMatch, string, error) {
+	qfilter, err := qdrantFilter(filter)
+	if err != nil {
+		return nil, "", err
+	}
+	if offset == "0" {
+		offset = ""
+	}
+	request := qdrantScrollRequest{
+		Limit:       limit,
+		Offset:      offset,
+		Filter:      qfilter,
+		WithPayload: true,
+		WithVector:  withVector,
+	}
+
+	respBody, err := q.makeRequest("POST", "/points/scroll", request)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var response qdrantScrollResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal scroll response: %w", err)
+	}
+
+	next := ""
+	if response.Result.NextPageOffset != nil {
+		next = fmt.Sprint(response.Result.NextPageOffset)
+	}
+	return toMatches(response.Result.Points), next, nil
+}
+
+// RangeMemories returns one page of memories with their metadata and the cursor for the
+// next page; an empty cursor means the scan is complete
+func (q *QdrantVectorStore) RangeMemories(cursor string, limit int) ([]QueryMatch, string, error) {
+	return q.rangeMatches(cursor, limit, false)
+}
+
+// RangeVectors pages through every stored memory like RangeMemories, including vectors
+func (q *QdrantVectorStore) RangeVectors(cursor string, limit int) ([]QueryMatch, string, error) {
+	return q.rangeMatches(cursor, limit, true)
+}
+
+func (q *QdrantVectorStore) rangeMatches(cursor string, limit int, includeVectors bool) ([]QueryMatch, string, error) {
+	matches, next, err := q.scroll("", cursor, limit, includeVectors)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to range memories: %w", err)
+	}
+	q.hydrateContent(matches)
+	return matches, next, nil
+}
+
+// ListUserMemories returns up to limit memories of a user with their metadata,
+// in no particular order
+func (q *QdrantVectorStore) ListUserMemories(userID string, limit int) ([]QueryMatch, error) {
+	return q.listMemories(fmt.Sprintf("user_id = '%s'", userID), limit)
+}
+
+// ListUserSummaries returns up to limit of a user's rollup summaries at one granularity
+func (q *QdrantVectorStore) ListUserSummaries(userID string, granularity string, limit int) ([]QueryMatch, error) {
+	return q.listMemories(fmt.Sprintf("user_id = '%s' AND granularity = '%s'", userID, granularity), limit)
+}
+
+// ListUserInstructions returns up to limit of a user's instruction memories
+func (q *QdrantVectorStore) ListUserInstructions(userID string, limit int) ([]QueryMatch, error) {
+	return q.listMemories(fmt.Sprintf("user_id = '%s' AND memory_type = '%s' AND HAS NOT FIELD quarantine_reason", userID, models.MemoryTypeInstruction), limit)
+}
+
+// ListQuarantinedMemories returns up to limit memories the write guard quarantined
+func (q *QdrantVectorStore) ListQuarantinedMemories(limit int) ([]QueryMatch, error) {
+	return q.listMemories("HAS FIELD quarantine_reason", limit)
+}
+
+// ListTaskMemories returns up to limit of the memories saved for a task of a tenant
+func (q *QdrantVectorStore) ListTaskMemories(tenantID string, taskID string, limit int) ([]QueryMatch, error) {
+	return q.listMemories(fmt.Sprintf("tenant_id = '%s' AND task_id = '%s'", tenantID, taskID), limit)
+}
+
+// ListAllMemories returns up to limit memories across all users
+func (q *QdrantVectorStore) ListAllMemories(limit int) ([]QueryMatch, error) {
+	return q.listMemories("", limit)
+}
+
+func (q *QdrantVectorStore) listMemories(filter string, limit int) ([]QueryMatch, error) {
+	matches, err := q.queryMetadata(filter, limit)
+	if err != nil {
+		return nil, err
+	}
+	q.hydrateContent(matches)
+	return matches, nil
+}
+
+// queryMetadata lists the memories a filter selects with their metadata as stored
+func (q *QdrantVectorStore) queryMetadata(filter string, limit int) ([]QueryMatch, error) {
+	matches, _, err := q.scroll(filter, "", limit, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memories: %w", err)
+	}
+	return matches, nil
+}
+
+func (q *QdrantVectorStore) DeleteMemory(id string) error {
+	return q.DeleteMemories([]string{id})
+}
+
+// DeleteMemories removes several memories by ID, split into batches of the configured size
+func (q *QdrantVectorStore) DeleteMemories(ids []string) error {
+	batchSize := q.client.settings.MaxBatchSize
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		request := map[string]interface{}{"points": pointIDs(ids[start:end])}
+		if _, err := q.makeRequest("POST", "/points/delete?wait=true", request); err != nil {
+			return fmt.Errorf("failed to delete memories: %w", err)
+		}
+		q.deleteContent(ids[start:end]...)
+	}
+
+	return nil
+}
+
+func (q *QdrantVectorStore) DeleteUserMemories(userID string) error {
+	return q.deleteByFilter(fmt.Sprintf("user_id = '%s'", userID))
+}
+
+// DeleteUserMemoriesCreatedBefore removes a user's memories whose timestamp is older than cutoff
+func (q *QdrantVectorStore) DeleteUserMemoriesCreatedBefore(userID string, cutoff int64) error {
+	return q.deleteByFilter(fmt.Sprintf("user_id = '%s' AND timestamp < %d", userID, cutoff))
+}
+
+func (q *QdrantVectorStore) deleteByFilter(filter string) error {
+	truncated, err := q.truncatedMemoryIDs(filter, q.queryMetadata)
+	if err != nil {
+		return fmt.Errorf("failed to delete user memories: %w", err)
+	}
+	qfilter, err := qdrantFilter(filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete user memories: %w", err)
+	}
+
+	request := map[string]interface{}{"filter": qfilter}
+	if _, err := q.makeRequest("POST", "/points/delete?wait=true", request); err != nil {
+		return fmt.Errorf("failed to delete user memories: %w", err)
+	}
+	q.deleteContent(truncated...)
+
+	return nil
+}
+
+// GetStats returns the collection info reported by Qdrant
+func (q *QdrantVectorStore) GetStats() (map[string]interface{}, error) {
+	respBody, err := q.makeRequest("GET", "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vector stats: %w", err)
+	}
+
+	var stats map[string]interface{}
+	if err := json.Unmarshal(respBody, &stats); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal stats response: %w", err)
+	}
+
+	return stats, nil
+}
+
+// GetDimensions returns the vector size of the collection (with caching)
+func (q *QdrantVectorStore) GetDimensions() (int, error) {
+	cacheKey := q.url + "/collections/" + q.collection
+	if cached, ok := dimensionCache.Load(cacheKey); ok {
+		return cached.(int), nil
+	}
+
+	stats, err := q.GetStats()
+	if err != nil {
+		return 0, err
+	}
+
+	var dimensions int
+	result, _ := stats["result"].(map[string]interface{})
+	configMap, _ := result["config"].(map[string]interface{})
+	params, _ := configMap["params"].(map[string]interface{})
+	if vectors, ok := params["vectors"].(map[string]interface{}); ok {
+		if size, ok := vectors["size"].(float64); ok {
+			dimensions = int(size)
+		}
+	}
+
+	if dimensions == 0 {
+		return 0, fmt.Errorf("could not determine vector dimensions from Qdrant collection %s", q.collection)
+	}
+
+	dimensionCache.Store(cacheKey, dimensions)
+	return dimensions, nil
+}
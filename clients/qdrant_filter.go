@@ -0,0 +1,323 @@
+package clients
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// qdrantFilter translates an Upstash Vector filter into a Qdrant filter. It supports the
+// subset the service writes: comparisons (=, !=, <, <=, >, >=), IN and NOT IN lists,
+// CONTAINS on array fields, HAS FIELD and HAS NOT FIELD, AND, OR and parentheses. An empty filter returns nil.
+func qdrantFilter(filter string) (map[string]interface{}, error) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, nil
+	}
+
+	tokens, err := tokenizeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	parser := &filterParser{tokens: tokens}
+	condition, err := parser.or()
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos < len(parser.tokens) {
+		return nil, fmt.Errorf("unexpected %q in filter %q", parser.tokens[parser.pos].text, filter)
+	}
+
+	// The top level of a Qdrant filter must be a clause, not a field condition
+	_, must := condition["must"]
+	_, should := condition["should"]
+	_, mustNot := condition["must_not"]
+	if !must && !should && !mustNot {
+		condition = map[string]interface{}{"must": []interface{}{condition}}
+	}
+	return condition, nil
+}
+
+type filterTokenKind int
+
+const (
+	tokenWord filterTokenKind = iota
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+}
+
+func tokenizeFilter(filter string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(filter)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == ',':
+			tokens = append(tokens, filterToken{tokenSymbol, string(r)})
+			i++
+		case r == '=' || r == '!' || r == '<' || r == '>':
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, fmt.Errorf("unexpected '!' in filter %q", filter)
+			}
+			tokens = append(tokens, filterToken{tokenSymbol, op})
+			i += len(op)
+		case r == '\'' || r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated string in filter %q", filter)
+			}
+			tokens = append(tokens, filterToken{tokenString, string(runes[i+1 : end])})
+			i = end + 1
+		case r == '-' || unicode.IsDigit(r):
+			end := i + 1
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, filterToken{tokenNumber, string(runes[i:end])})
+			i = end
+		case unicode.IsLetter(r) || r == '_':
+			end := i + 1
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_' || runes[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, filterToken{tokenWord, string(runes[i:end])})
+			i = end
+		default:
+			return nil, fmt.Errorf("unexpected %q in filter %q", r, filter)
+		}
+	}
+	return tokens, nil
+}
+
+// filterParser is a recursive descent parser where AND binds tighter than OR
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() (filterToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return filterToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// keyword consumes the next token if it is the given keyword or symbol
+func (p *filterParser) keyword(word string) bool {
+	token, ok := p.peek()
+	if ok && (token.kind == tokenWord || token.kind == tokenSymbol) && strings.EqualFold(token.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) expect(word string) error {
+	if !p.keyword(word) {
+		return p.unexpected(word)
+	}
+	return nil
+}
+
+func (p *filterParser) unexpected(want string) error {
+	token, ok := p.peek()
+	if !ok {
+		return fmt.Errorf("filter ended, expected %s", want)
+	}
+	return fmt.Errorf("unexpected %q in filter, expected %s", token.text, want)
+}
+
+func (p *filterParser) or() (map[string]interface{}, error) {
+	return p.clause("OR", "should", p.and)
+}
+
+func (p *filterParser) and() (map[string]interface{}, error) {
+	return p.clause("AND", "must", p.term)
+}
+
+// clause parses operands joined by op into one Qdrant clause, or returns a lone operand
+func (p *filterParser) clause(op string, clause string, operand func() (map[string]interface{}, error)) (map[string]interface{}, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	conditions := []interface{}{first}
+	for p.keyword(op) {
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, next)
+	}
+	if len(conditions) == 1 {
+		return first, nil
+	}
+	return map[string]interface{}{clause: conditions}, nil
+}
+
+func (p *filterParser) term() (map[string]interface{}, error) {
+	if p.keyword("(") {
+		condition, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return condition, p.expect(")")
+	}
+
+	if p.keyword("HAS") {
+		negated := p.keyword("NOT")
+		if err := p.expect("FIELD"); err != nil {
+			return nil, err
+		}
+		field, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		empty := map[string]interface{}{"is_empty": map[string]interface{}{"key": field}}
+		if negated {
+			return empty, nil
+		}
+		return map[string]interface{}{"must_not": []interface{}{empty}}, nil
+	}
+
+	field, err := p.field()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.keyword("NOT") {
+		if err := p.expect("IN"); err != nil {
+			return nil, err
+		}
+		values, err := p.list()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"key": field, "match": map[string]interface{}{"except": values}}, nil
+	}
+	if p.keyword("IN") {
+		values, err := p.list()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"key": field, "match": map[string]interface{}{"any": values}}, nil
+	}
+	if p.keyword("CONTAINS") {
+		// Qdrant matches an array field when any of its elements matches
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		return matchCondition(field, value), nil
+	}
+
+	token, ok := p.peek()
+	if !ok || token.kind != tokenSymbol {
+		return nil, p.unexpected("an operator")
+	}
+	p.pos++
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token.text {
+	case "=":
+		return matchCondition(field, value), nil
+	case "!=":
+		return map[string]interface{}{"must_not": []interface{}{matchCondition(field, value)}}, nil
+	case "<", "<=", ">", ">=":
+		number, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("%s %s needs a number in filter", field, token.text)
+		}
+		bound := map[string]string{"<": "lt", "<=": "lte", ">": "gt", ">=": "gte"}[token.text]
+		return map[string]interface{}{"key": field, "range": map[string]interface{}{bound: number}}, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q in filter, expected an operator", token.text)
+	}
+}
+
+// matchCondition matches a value exactly. Qdrant only matches integers, so other numbers
+// become a closed range.
+func matchCondition(field string, value interface{}) map[string]interface{} {
+	if number, ok := value.(float64); ok {
+		if number != float64(int64(number)) {
+			return map[string]interface{}{"key": field, "range": map[string]interface{}{"gte": number, "lte": number}}
+		}
+		value = int64(number)
+	}
+	return map[string]interface{}{"key": field, "match": map[string]interface{}{"value": value}}
+}
+
+func (p *filterParser) field() (string, error) {
+	token, ok := p.peek()
+	if !ok || token.kind != tokenWord {
+		return "", p.unexpected("a field name")
+	}
+	p.pos++
+	return token.text, nil
+}
+
+// value parses a string, number or boolean literal
+func (p *filterParser) value() (interface{}, error) {
+	token, ok := p.peek()
+	if !ok {
+		return nil, p.unexpected("a value")
+	}
+	p.pos++
+
+	switch {
+	case token.kind == tokenString:
+		return token.text, nil
+	case token.kind == tokenNumber:
+		number, err := strconv.ParseFloat(token.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q in filter", token.text)
+		}
+		return number, nil
+	case token.kind == tokenWord && strings.EqualFold(token.text, "true"):
+		return true, nil
+	case token.kind == tokenWord && strings.EqualFold(token.text, "false"):
+		return false, nil
+	}
+	p.pos--
+	return nil, p.unexpected("a value")
+}
+
+// list parses a parenthesised, comma-separated list of values
+func (p *filterParser) list() ([]interface{}, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var values []interface{}
+	for {
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if number, ok := value.(float64); ok {
+			value = int64(number)
+		}
+		values = append(values, value)
+		if !p.keyword(",") {
+			break
+		}
+	}
+	return values, p.expect(")")
+}
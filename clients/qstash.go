@@ -2,20 +2,26 @@ package clients
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/Fairy-nn/MemoryCacheAI/config"
 	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/Fairy-nn/MemoryCacheAI/tracing"
+
+	"github.com/google/uuid"
 )
 
 type QStashClient struct {
 	url    string
 	token  string
-	client *http.Client
+	client *httpClient
+	ctx    context.Context // request the client is bound to, see WithContext; nil otherwise
 }
 
 type PublishRequest struct {
@@ -24,9 +30,10 @@ type PublishRequest struct {
 	Headers         map[string]string `json:"headers,omitempty"`
 	Delay           int               `json:"delay,omitempty"`           // Delay in seconds
 	NotBefore       int64             `json:"notBefore,omitempty"`       // Unix timestamp
-	Retries         int               `json:"retries,omitempty"`         // Number of retries
+	Retries         *int              `json:"retries,omitempty"`         // Number of retries
 	Callback        string            `json:"callback,omitempty"`        // Callback URL
 	FailureCallback string            `json:"failureCallback,omitempty"` // Failure callback URL
+	DeduplicationID string            `json:"deduplicationId,omitempty"` // Drops repeated publishes
 }
 
 type PublishResponse struct {
@@ -34,12 +41,14 @@ type PublishResponse struct {
 }
 
 type ScheduleRequest struct {
-	Destination string            `json:"destination"`
-	Body        string            `json:"body,omitempty"`
-	Headers     map[string]string `json:"headers,omitempty"`
-	Cron        string            `json:"cron,omitempty"`    // Cron expression
-	Delay       int               `json:"delay,omitempty"`   // Delay in seconds
-	Retries     int               `json:"retries,omitempty"` // Number of retries
+	Destination     string            `json:"destination"`
+	Body            string            `json:"body,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Cron            string            `json:"cron,omitempty"`            // Cron expression
+	Delay           int               `json:"delay,omitempty"`           // Delay in seconds
+	Retries         *int              `json:"retries,omitempty"`         // Number of retries
+	FailureCallback string            `json:"failureCallback,omitempty"` // Failure callback URL
+	DeduplicationID string            `json:"deduplicationId,omitempty"` // Drops repeated schedule creation
 }
 
 type ScheduleResponse struct {
@@ -48,17 +57,36 @@ type ScheduleResponse struct {
 
 func NewQStashClient() *QStashClient {
 	return &QStashClient{
-		url:   config.AppConfig.QStashURL,
-		token: config.AppConfig.QStashToken,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		url:    config.AppConfig.QStashURL,
+		token:  config.AppConfig.QStashToken,
+		client: newHTTPClient(config.AppConfig.QStashClient),
 	}
 }
 
-func (q *QStashClient) makeRequest(method, endpoint string, body interface{}) ([]byte, error) {
+// Close releases the client's idle connections
+func (q *QStashClient) Close() {
+	q.client.Close()
+}
+
+// WithContext returns a copy of the client bound to ctx, recording a span per QStash
+// call when ctx carries a trace
+func (q *QStashClient) WithContext(ctx context.Context) *QStashClient {
+	bound := *q
+	bound.ctx = ctx
+	return &bound
+}
+
+func (q *QStashClient) makeRequest(method, endpoint string, body interface{}) (respBody []byte, err error) {
+	if span := tracing.Start(q.ctx, "qstash "+method+" "+qstashOperation(endpoint), tracing.KindClient); span != nil {
+		span.SetAttribute("messaging.system", "qstash")
+		span.SetAttribute("http.request.method", method)
+		defer func() {
+			span.SetError(err)
+			span.End()
+		}()
+	}
+
 	var reqBody []byte
-	var err error
 
 	if body != nil {
 		reqBody, err = json.Marshal(body)
@@ -67,33 +95,45 @@ func (q *QStashClient) makeRequest(method, endpoint string, body interface{}) ([
 		}
 	}
 
-	req, err := http.NewRequest(method, q.url+endpoint, bytes.NewBuffer(reqBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+q.token)
-
-	resp, err := q.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
+	statusCode, respBody, err := q.client.Do(requestContext(q.ctx), func() (*http.Request, error) {
+		req, err := http.NewRequest(method, q.url+endpoint, bytes.NewReader(reqBody))
+		if err != nil {
+			return nil, err
+		}
 
-	respBody, err := io.ReadAll(resp.Body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+q.token)
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("QStash request failed with status %d: %s", resp.StatusCode, string(respBody))
+	if statusCode < 200 || statusCode >= 300 {
+		return nil, fmt.Errorf("QStash request failed with status %d: %s", statusCode, string(respBody))
 	}
 
 	return respBody, nil
 }
 
-func (q *QStashClient) PublishCleanupTask(callbackURL string, task models.CleanupTask, delay int) (string, error) {
+// qstashOperation names the API an endpoint belongs to, without IDs or query strings,
+// so spans of one kind share a name
+func qstashOperation(endpoint string) string {
+	endpoint = strings.SplitN(endpoint, "?", 2)[0]
+	parts := strings.SplitN(strings.TrimPrefix(endpoint, "/"), "/", 3)
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// PublishCleanupTask publishes a one-off cleanup task, assigning it a task ID so
+// retried deliveries can be recognised
+func (q *QStashClient) PublishCleanupTask(callbackURL string, task models.CleanupTask, delay int, opts models.DeliveryOptions) (string, error) {
+	if task.TaskID == "" {
+		task.TaskID = uuid.New().String()
+	}
+
 	taskJSON, err := json.Marshal(task)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal cleanup task: %w", err)
@@ -105,8 +145,10 @@ func (q *QStashClient) PublishCleanupTask(callbackURL string, task models.Cleanu
 		Headers: map[string]string{
 			"Content-Type": "application/json",
 		},
-		Delay:   delay,
-		Retries: 3,
+		Delay:           delay,
+		Retries:         deliveryRetries(opts),
+		FailureCallback: deliveryFailureCallback(opts),
+		DeduplicationID: opts.DeduplicationID,
 	}
 
 	respBody, err := q.makeRequest("POST", "/v2/publish", request)
@@ -122,15 +164,24 @@ func (q *QStashClient) PublishCleanupTask(callbackURL string, task models.Cleanu
 	return response.MessageID, nil
 }
 
-func (q *QStashClient) ScheduleCleanupTask(callbackURL string, cronExpression string) (string, error) {
+// ScheduleCleanupTask creates a recurring expired-memory cleanup for a tenant, or for
+// every tenant when tenantID is empty. Scheduled tasks carry no task ID because every
+// run shares the same body; they are deduplicated by QStash message ID instead.
+func (q *QStashClient) ScheduleCleanupTask(callbackURL string, cronExpression string, tenantID string, opts models.DeliveryOptions) (string, error) {
 	task := models.CleanupTask{
 		TaskType:  "cleanup_expired_memories",
+		TenantID:  tenantID,
 		Timestamp: time.Now(),
 	}
 
+	return q.ScheduleTask(callbackURL, cronExpression, task, opts)
+}
+
+// ScheduleTask creates a QStash schedule delivering task to callbackURL on every cron tick
+func (q *QStashClient) ScheduleTask(callbackURL string, cronExpression string, task models.CleanupTask, opts models.DeliveryOptions) (string, error) {
 	taskJSON, err := json.Marshal(task)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal cleanup task: %w", err)
+		return "", fmt.Errorf("failed to marshal %s task: %w", task.TaskType, err)
 	}
 
 	request := ScheduleRequest{
@@ -139,13 +190,15 @@ func (q *QStashClient) ScheduleCleanupTask(callbackURL string, cronExpression st
 		Headers: map[string]string{
 			"Content-Type": "application/json",
 		},
-		Cron:    cronExpression,
-		Retries: 3,
+		Cron:            cronExpression,
+		Retries:         deliveryRetries(opts),
+		FailureCallback: deliveryFailureCallback(opts),
+		DeduplicationID: opts.DeduplicationID,
 	}
 
 	respBody, err := q.makeRequest("POST", "/v2/schedules", request)
 	if err != nil {
-		return "", fmt.Errorf("failed to schedule cleanup task: %w", err)
+		return "", fmt.Errorf("failed to schedule %s task: %w", task.TaskType, err)
 	}
 
 	var response ScheduleResponse
@@ -156,7 +209,7 @@ func (q *QStashClient) ScheduleCleanupTask(callbackURL string, cronExpression st
 	return response.ScheduleID, nil
 }
 
-func (q *QStashClient) PublishDelayedMemoryCleanup(callbackURL string, userID string, delaySeconds int) (string, error) {
+func (q *QStashClient) PublishDelayedMemoryCleanup(callbackURL string, userID string, delaySeconds int, opts models.DeliveryOptions) (string, error) {
 	task := models.CleanupTask{
 		TaskType:  "cleanup_user_memories",
 		UserID:    userID,
@@ -164,18 +217,36 @@ func (q *QStashClient) PublishDelayedMemoryCleanup(callbackURL string, userID st
 		TTL:       int64(delaySeconds),
 	}
 
-	return q.PublishCleanupTask(callbackURL, task, delaySeconds)
+	return q.PublishCleanupTask(callbackURL, task, delaySeconds, opts)
 }
 
-func (q *QStashClient) PublishSessionCleanup(callbackURL string, sessionID string, delaySeconds int) (string, error) {
+func (q *QStashClient) PublishSessionCleanup(callbackURL string, sessionID string, tenantID string, delaySeconds int, opts models.DeliveryOptions) (string, error) {
 	task := models.CleanupTask{
 		TaskType:  "cleanup_session",
-		UserID:    sessionID, // Reusing UserID field for session ID
+		TenantID:  tenantID,
+		SessionID: sessionID,
 		Timestamp: time.Now(),
 		TTL:       int64(delaySeconds),
 	}
 
-	return q.PublishCleanupTask(callbackURL, task, delaySeconds)
+	return q.PublishCleanupTask(callbackURL, task, delaySeconds, opts)
+}
+
+// deliveryRetries returns the retry count requested in opts or the configured default
+func deliveryRetries(opts models.DeliveryOptions) *int {
+	if opts.Retries != nil {
+		return opts.Retries
+	}
+	retries := config.AppConfig.QStashRetries
+	return &retries
+}
+
+// deliveryFailureCallback returns the failure callback requested in opts or the configured default
+func deliveryFailureCallback(opts models.DeliveryOptions) string {
+	if opts.FailureCallback != "" {
+		return opts.FailureCallback
+	}
+	return config.AppConfig.QStashFailureCallback
 }
 
 func (q *QStashClient) CancelSchedule(scheduleID string) error {
@@ -214,3 +285,47 @@ func (q *QStashClient) GetMessages() ([]map[string]interface{}, error) {
 
 	return messages, nil
 }
+
+// EventFilter narrows the QStash delivery event log; empty fields are not filtered on
+type EventFilter struct {
+	MessageID  string
+	ScheduleID string
+	State      string // CREATED, ACTIVE, DELIVERED, ERROR, RETRY or FAILED
+	URL        string
+}
+
+// GetEvents returns the delivery events of QStash messages matching filter, newest first
+func (q *QStashClient) GetEvents(filter EventFilter) ([]map[string]interface{}, error) {
+	query := url.Values{}
+	if filter.MessageID != "" {
+		query.Set("messageId", filter.MessageID)
+	}
+	if filter.ScheduleID != "" {
+		query.Set("scheduleId", filter.ScheduleID)
+	}
+	if filter.State != "" {
+		query.Set("state", strings.ToUpper(filter.State))
+	}
+	if filter.URL != "" {
+		query.Set("url", filter.URL)
+	}
+
+	endpoint := "/v2/events"
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	respBody, err := q.makeRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+
+	var eventsResp struct {
+		Events []map[string]interface{} `json:"events"`
+	}
+	if err := json.Unmarshal(respBody, &eventsResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal events response: %w", err)
+	}
+
+	return eventsResp.Events, nil
+}
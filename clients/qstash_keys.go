@@ -0,0 +1,214 @@
+package clients
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+)
+
+// ErrInvalidSignature is returned when a webhook delivery does not carry a valid QStash signature
+var ErrInvalidSignature = errors.New("invalid QStash signature")
+
+// signingKeyRefreshInterval limits how often a failed verification may trigger a key refresh
+const signingKeyRefreshInterval = time.Minute
+
+// SigningKeys holds the QStash current and next signing keys. Upstash promotes the
+// next key to current on rotation, so accepting both keeps verification working
+// until the keys are refreshed.
+type SigningKeys struct {
+	mu          sync.RWMutex
+	current     string
+	next        string
+	refreshedAt time.Time
+}
+
+// SigningKeyStatus describes the loaded keys without exposing them
+type SigningKeyStatus struct {
+	CurrentConfigured bool      `json:"current_configured"`
+	NextConfigured    bool      `json:"next_configured"`
+	RefreshedAt       time.Time `json:"refreshed_at,omitempty"`
+}
+
+var (
+	signingKeys     *SigningKeys
+	signingKeysOnce sync.Once
+)
+
+// GetSigningKeys returns the process-wide QStash signing keys, seeded from config
+func GetSigningKeys() *SigningKeys {
+	signingKeysOnce.Do(func() {
+		signingKeys = &SigningKeys{
+			current: config.AppConfig.QStashCurrentSigningKey,
+			next:    config.AppConfig.QStashNextSigningKey,
+		}
+	})
+	return signingKeys
+}
+
+// Configured reports whether any signing key is loaded
+func (k *SigningKeys) Configured() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.current != "" || k.next != ""
+}
+
+// Status returns which keys are loaded and when they were last refreshed
+func (k *SigningKeys) Status() SigningKeyStatus {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return SigningKeyStatus{
+		CurrentConfigured: k.current != "",
+		NextConfigured:    k.next != "",
+		RefreshedAt:       k.refreshedAt,
+	}
+}
+
+// Set replaces both keys
+func (k *SigningKeys) Set(current string, next string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.current = current
+	k.next = next
+	k.refreshedAt = time.Now()
+}
+
+// Refresh loads the latest keys from the QStash API
+func (k *SigningKeys) Refresh() error {
+	respBody, err := NewQStashClient().makeRequest("GET", "/v2/keys", nil)
+	if err != nil {
+		return fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+
+	var keys struct {
+		Current string `json:"current"`
+		Next    string `json:"next"`
+	}
+	if err := json.Unmarshal(respBody, &keys); err != nil {
+		return fmt.Errorf("failed to unmarshal signing keys: %w", err)
+	}
+	if keys.Current == "" && keys.Next == "" {
+		return fmt.Errorf("QStash returned no signing keys")
+	}
+
+	k.Set(keys.Current, keys.Next)
+	return nil
+}
+
+// Verify checks an Upstash-Signature JWT against the request body and the URL it was
+// delivered to with the current key and then the next key. If both fail, the keys are
+// refreshed from QStash at most once per interval and verification is retried.
+func (k *SigningKeys) Verify(token string, body []byte, url string) error {
+	err := k.verifyWithLoadedKeys(token, body, url)
+	if err == nil || !config.AppConfig.QStashConfigured() {
+		return err
+	}
+
+	k.mu.RLock()
+	recentlyRefreshed := time.Since(k.refreshedAt) < signingKeyRefreshInterval
+	k.mu.RUnlock()
+	if recentlyRefreshed {
+		return err
+	}
+
+	if refreshErr := k.Refresh(); refreshErr != nil {
+		fmt.Printf("Warning: %v\n", refreshErr)
+		return err
+	}
+	return k.verifyWithLoadedKeys(token, body, url)
+}
+
+func (k *SigningKeys) verifyWithLoadedKeys(token string, body []byte, url string) error {
+	k.mu.RLock()
+	keys := []string{k.current, k.next}
+	k.mu.RUnlock()
+
+	err := fmt.Errorf("%w: no signing key configured", ErrInvalidSignature)
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err = verifySignature(token, body, url, key, time.Now()); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// verifySignature validates a QStash HS256 JWT: its algorithm, signature, issuer,
+// validity window, the destination URL (sub) and the SHA-256 hash of the body it was
+// issued for. Checking the destination stops a delivery from being replayed to
+// another endpoint.
+func verifySignature(token string, body []byte, url string, key string, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("%w: malformed token", ErrInvalidSignature)
+	}
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return fmt.Errorf("%w: malformed header", ErrInvalidSignature)
+	}
+	var alg struct {
+		Algorithm string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &alg); err != nil {
+		return fmt.Errorf("%w: malformed header", ErrInvalidSignature)
+	}
+	if alg.Algorithm != "HS256" {
+		return fmt.Errorf("%w: unexpected algorithm %q", ErrInvalidSignature, alg.Algorithm)
+	}
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, mac.Sum(nil)) {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidSignature)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("%w: malformed claims", ErrInvalidSignature)
+	}
+
+	var claims struct {
+		Issuer    string `json:"iss"`
+		Subject   string `json:"sub"`
+		ExpiresAt int64  `json:"exp"`
+		NotBefore int64  `json:"nbf"`
+		Body      string `json:"body"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return fmt.Errorf("%w: malformed claims", ErrInvalidSignature)
+	}
+
+	if claims.Issuer != "Upstash" {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidSignature, claims.Issuer)
+	}
+	if claims.Subject != url {
+		return fmt.Errorf("%w: token was issued for %q", ErrInvalidSignature, claims.Subject)
+	}
+	if claims.ExpiresAt != 0 && now.Unix() > claims.ExpiresAt {
+		return fmt.Errorf("%w: token expired", ErrInvalidSignature)
+	}
+	if claims.NotBefore != 0 && now.Unix() < claims.NotBefore {
+		return fmt.Errorf("%w: token not yet valid", ErrInvalidSignature)
+	}
+
+	bodyHash := sha256.Sum256(body)
+	if strings.TrimRight(claims.Body, "=") != base64.RawURLEncoding.EncodeToString(bodyHash[:]) {
+		return fmt.Errorf("%w: body hash mismatch", ErrInvalidSignature)
+	}
+
+	return nil
+}
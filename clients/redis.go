@@ -2,20 +2,25 @@ package clients
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
 
 	"github.com/Fairy-nn/MemoryCacheAI/config"
 	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/Fairy-nn/MemoryCacheAI/tracing"
 )
 
 type RedisClient struct {
 	url    string
 	token  string
-	client *http.Client
+	prefix string       // namespace prepended to every key, see redis_prefix.go
+	native *nativeRedis // set when REDIS_ADDR selects native Redis over the REST API
+	memory *memoryRedis // set when REDIS_PROVIDER=memory keeps the data in process
+	client *httpClient
+	ctx    context.Context // request the client is bound to, see WithContext; nil otherwise
 }
 
 type RedisCommand []interface{}
@@ -26,16 +31,75 @@ type RedisResponse struct {
 }
 
 func NewRedisClient() *RedisClient {
-	return &RedisClient{
-		url:   config.AppConfig.UpstashRedisURL,
-		token: config.AppConfig.UpstashRedisToken,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+	return newRedisClient(config.AppConfig.UpstashRedisURL, config.AppConfig.UpstashRedisToken,
+		config.AppConfig.RedisAddr, config.AppConfig.RedisPassword)
+}
+
+// newRedisClient talks to native Redis at addr when it is set, or to the Upstash REST API at url.
+// With REDIS_PROVIDER=memory every client shares the in-process Redis instead.
+func newRedisClient(url, token, addr, password string) *RedisClient {
+	client := &RedisClient{
+		url:    url,
+		token:  token,
+		prefix: config.AppConfig.RedisKeyPrefix,
+		client: newHTTPClient(config.AppConfig.RedisClient).withFault(FaultRedis),
+	}
+	if config.AppConfig.RedisProvider == "memory" {
+		client.url = "memory://"
+		client.memory = sharedMemoryRedis
+		return client
 	}
+	if addr != "" {
+		// Caches keyed by instance URL need a distinct key per native address too
+		client.url = "redis://" + addr
+		client.native = newNativeRedis(addr, password)
+	}
+	return client
+}
+
+// Close releases the client's idle connections
+func (r *RedisClient) Close() {
+	r.client.Close()
+	if r.native != nil {
+		r.native.Close()
+	}
+}
+
+// WithContext returns a copy of the client bound to ctx, recording a span per command
+// when ctx carries a trace. The copy shares the connections of r.
+func (r *RedisClient) WithContext(ctx context.Context) *RedisClient {
+	bound := *r
+	bound.ctx = ctx
+	return &bound
 }
 
 func (r *RedisClient) executeCommand(cmd RedisCommand) (*RedisResponse, error) {
+	cmd, err := r.prefixCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return r.send(cmd)
+}
+
+// send runs a command exactly as given, without applying the key prefix. Only the
+// command name is recorded on spans; keys and arguments may hold user data.
+func (r *RedisClient) send(cmd RedisCommand) (reply *RedisResponse, err error) {
+	if span := tracing.Start(r.ctx, "redis "+commandName(cmd), tracing.KindClient); span != nil {
+		span.SetAttribute("db.system", "redis")
+		span.SetAttribute("db.operation", commandName(cmd))
+		defer func() {
+			span.SetError(err)
+			span.End()
+		}()
+	}
+
+	if r.memory != nil {
+		return r.sendMemory(cmd)
+	}
+	if r.native != nil {
+		return r.sendNative(cmd)
+	}
+
 	jsonData, err := json.Marshal(cmd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal command: %w", err)
@@ -47,27 +111,26 @@ func (r *RedisClient) executeCommand(cmd RedisCommand) (*RedisResponse, error) {
 		url += "/"
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+r.token)
-
-	resp, err := r.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+	do := r.client.Do
+	if !retryable(cmd) {
+		do = r.client.DoOnce
 	}
-	defer resp.Body.Close()
+	statusCode, body, err := do(requestContext(r.ctx), func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
 
-	body, err := io.ReadAll(resp.Body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+r.token)
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Redis request failed with status %d: %s", resp.StatusCode, string(body))
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("Redis request failed with status %d: %s", statusCode, string(body))
 	}
 
 	var response RedisResponse
@@ -82,21 +145,44 @@ func (r *RedisClient) executeCommand(cmd RedisCommand) (*RedisResponse, error) {
 	return &response, nil
 }
 
+// SaveSession stores a session, rewriting its whole message list. Use AppendSessionMessage
+// to add a message.
 func (r *RedisClient) SaveSession(sessionData *models.SessionData) error {
+	if err := r.replaceSessionMessages(sessionData); err != nil {
+		return err
+	}
+	return r.saveSessionHeader(sessionData)
+}
+
+// saveSessionHeader stores everything but a session's messages, which are kept in their
+// own list, and extends the expiry of the session's keys
+func (r *RedisClient) saveSessionHeader(sessionData *models.SessionData) error {
 	key := fmt.Sprintf("session:%s", sessionData.SessionID)
 
-	jsonData, err := json.Marshal(sessionData)
+	header := *sessionData
+	header.Messages = nil
+	header.Page = nil
+	jsonData, err := json.Marshal(header)
 	if err != nil {
 		return fmt.Errorf("failed to marshal session data: %w", err)
 	}
 
-	// Set with TTL of 24 hours
-	cmd := RedisCommand{"SETEX", key, 86400, string(jsonData)}
+	// Expire after the session's idle TTL, SESSION_TTL unless a session policy set one
+	ttl := sessionData.TTLSeconds
+	if ttl <= 0 {
+		ttl = int64(config.AppConfig.SessionTTL / time.Second)
+	}
+	cmd := RedisCommand{"SETEX", key, ttl, string(jsonData)}
 
 	_, err = r.executeCommand(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to save session: %w", err)
 	}
+	if _, err := r.executeCommand(RedisCommand{"EXPIRE", sessionMessagesKey(sessionData.SessionID), ttl}); err != nil {
+		return fmt.Errorf("failed to set session messages TTL: %w", err)
+	}
+
+	cacheSession(r.url, sessionData)
 
 	// Also save user session mapping
 	userKey := fmt.Sprintf("user_sessions:%s", sessionData.UserID)
@@ -108,7 +194,7 @@ func (r *RedisClient) SaveSession(sessionData *models.SessionData) error {
 	}
 
 	// Set TTL for user sessions set
-	cmd = RedisCommand{"EXPIRE", userKey, 86400}
+	cmd = RedisCommand{"EXPIRE", userKey, ttl}
 
 	_, err = r.executeCommand(cmd)
 	return err
@@ -138,6 +224,15 @@ func (r *RedisClient) GetSession(sessionID string) (*models.SessionData, error)
 		return nil, fmt.Errorf("failed to unmarshal session data: %w", err)
 	}
 
+	// Sessions saved before messages moved to their own list still carry them inline
+	if sessionData.Messages == nil {
+		messages, err := r.getSessionMessages(sessionID)
+		if err != nil {
+			return nil, err
+		}
+		sessionData.Messages = messages
+	}
+
 	return &sessionData, nil
 }
 
@@ -174,12 +269,13 @@ func (r *RedisClient) GetUserSessions(userID string) ([]string, error) {
 func (r *RedisClient) DeleteSession(sessionID string) error {
 	key := fmt.Sprintf("session:%s", sessionID)
 
-	cmd := RedisCommand{"DEL", key}
+	cmd := RedisCommand{"DEL", key, sessionMessagesKey(sessionID), sessionSummaryKey(sessionID)}
 
 	_, err := r.executeCommand(cmd)
 	if err != nil {
 		return fmt.Errorf("failed to delete session: %w", err)
 	}
+	evictSession(r.url, sessionID)
 
 	return nil
 }
@@ -195,7 +291,7 @@ func (r *RedisClient) UpdateSessionActivity(sessionID string) error {
 	session.LastActivity = time.Now()
 
 	// Save back
-	return r.SaveSession(session)
+	return r.saveSessionHeader(session)
 }
 
 func (r *RedisClient) AddMessageToSession(sessionID string, message models.Message) error {
@@ -204,10 +300,9 @@ func (r *RedisClient) AddMessageToSession(sessionID string, message models.Messa
 		return err
 	}
 
-	session.Messages = append(session.Messages, message)
 	session.LastActivity = time.Now()
 
-	return r.SaveSession(session)
+	return r.AppendSessionMessage(session, message)
 }
 
 func (r *RedisClient) SetSessionContext(sessionID string, context map[string]interface{}) error {
@@ -226,5 +321,492 @@ func (r *RedisClient) SetSessionContext(sessionID string, context map[string]int
 
 	session.LastActivity = time.Now()
 
-	return r.SaveSession(session)
+	return r.saveSessionHeader(session)
+}
+
+// IncrementCounter adds delta to a counter key and refreshes its TTL
+func (r *RedisClient) IncrementCounter(key string, delta int64, ttlSeconds int) (int64, error) {
+	resp, err := r.executeCommand(RedisCommand{"INCRBY", key, delta})
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment counter: %w", err)
+	}
+
+	if _, err := r.executeCommand(RedisCommand{"EXPIRE", key, ttlSeconds}); err != nil {
+		return 0, fmt.Errorf("failed to set counter TTL: %w", err)
+	}
+
+	value, ok := resp.Result.(float64)
+	if !ok {
+		return 0, fmt.Errorf("invalid counter value format")
+	}
+
+	return int64(value), nil
+}
+
+// GetCounter returns the current value of a counter key, or 0 if it does not exist
+func (r *RedisClient) GetCounter(key string) (int64, error) {
+	resp, err := r.executeCommand(RedisCommand{"GET", key})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get counter: %w", err)
+	}
+
+	if resp.Result == nil {
+		return 0, nil
+	}
+
+	str, ok := resp.Result.(string)
+	if !ok {
+		return 0, fmt.Errorf("invalid counter value format")
+	}
+
+	var value int64
+	if _, err := fmt.Sscan(str, &value); err != nil {
+		return 0, fmt.Errorf("invalid counter value: %w", err)
+	}
+
+	return value, nil
+}
+
+// SaveKeywordMemory stores a memory that has no vector so it can still be found by keyword search
+func (r *RedisClient) SaveKeywordMemory(memory *models.MemoryEntry) error {
+	key := fmt.Sprintf("keyword_memories:%s", memory.UserID)
+
+	jsonData, err := json.Marshal(memory)
+	if err != nil {
+		return fmt.Errorf("failed to marshal keyword memory: %w", err)
+	}
+
+	if _, err := r.executeCommand(RedisCommand{"RPUSH", key, string(jsonData)}); err != nil {
+		return fmt.Errorf("failed to save keyword memory: %w", err)
+	}
+
+	_, err = r.executeCommand(RedisCommand{"EXPIRE", key, memory.TTL})
+	return err
+}
+
+// GetKeywordMemories returns every keyword-only memory stored for a user
+func (r *RedisClient) GetKeywordMemories(userID string) ([]models.MemoryEntry, error) {
+	key := fmt.Sprintf("keyword_memories:%s", userID)
+
+	resp, err := r.executeCommand(RedisCommand{"LRANGE", key, 0, -1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keyword memories: %w", err)
+	}
+
+	resultSlice, ok := resp.Result.([]interface{})
+	if !ok {
+		return []models.MemoryEntry{}, nil
+	}
+
+	memories := make([]models.MemoryEntry, 0, len(resultSlice))
+	for _, v := range resultSlice {
+		str, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		var memory models.MemoryEntry
+		if err := json.Unmarshal([]byte(str), &memory); err != nil {
+			continue
+		}
+		memories = append(memories, memory)
+	}
+
+	return memories, nil
+}
+
+// DeleteKeywordMemories removes every keyword-only memory stored for a user
+func (r *RedisClient) DeleteKeywordMemories(userID string) error {
+	key := fmt.Sprintf("keyword_memories:%s", userID)
+
+	if _, err := r.executeCommand(RedisCommand{"DEL", key}); err != nil {
+		return fmt.Errorf("failed to delete keyword memories: %w", err)
+	}
+
+	return nil
+}
+
+// SaveJob stores the state of a background job for a week
+func (r *RedisClient) SaveJob(job *models.Job) error {
+	key := fmt.Sprintf("job:%s", job.ID)
+
+	jsonData, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	if _, err := r.executeCommand(RedisCommand{"SETEX", key, jobTTLSeconds, string(jsonData)}); err != nil {
+		return fmt.Errorf("failed to save job: %w", err)
+	}
+
+	return nil
+}
+
+// GetJob retrieves the state of a background job
+func (r *RedisClient) GetJob(jobID string) (*models.Job, error) {
+	key := fmt.Sprintf("job:%s", jobID)
+
+	resp, err := r.executeCommand(RedisCommand{"GET", key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	if resp.Result == nil {
+		return nil, ErrJobNotFound
+	}
+
+	jsonStr, ok := resp.Result.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid job data format")
+	}
+
+	var job models.Job
+	if err := json.Unmarshal([]byte(jsonStr), &job); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// GetValue returns the string stored under key, or "" if it does not exist
+func (r *RedisClient) GetValue(key string) (string, error) {
+	value, err := r.getString(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// SetIfAbsent sets key to value only if it does not exist yet, reporting whether
+// the key was set. A non-positive TTL stores the key without expiry.
+func (r *RedisClient) SetIfAbsent(key string, value string, ttlSeconds int64) (bool, error) {
+	cmd := RedisCommand{"SET", key, value, "NX"}
+	if ttlSeconds > 0 {
+		cmd = append(cmd, "EX", ttlSeconds)
+	}
+
+	resp, err := r.executeCommand(cmd)
+	if err != nil {
+		return false, fmt.Errorf("failed to set key: %w", err)
+	}
+
+	return resp.Result != nil, nil
+}
+
+// setJSON stores v as JSON under key; a ttlSeconds of 0 keeps it forever
+func (r *RedisClient) setJSON(key string, v interface{}, ttlSeconds int64) error {
+	jsonData, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", key, err)
+	}
+
+	cmd := RedisCommand{"SET", key, string(jsonData)}
+	if ttlSeconds > 0 {
+		cmd = append(cmd, "EX", ttlSeconds)
+	}
+
+	_, err = r.executeCommand(cmd)
+	return err
+}
+
+// getString returns the string stored under key, or "" if it does not exist
+func (r *RedisClient) getString(key string) (string, error) {
+	resp, err := r.executeCommand(RedisCommand{"GET", key})
+	if err != nil {
+		return "", err
+	}
+	value, _ := resp.Result.(string)
+	return value, nil
+}
+
+// getJSON loads the JSON stored under key into v, reporting whether the key existed
+func (r *RedisClient) getJSON(key string, v interface{}) (bool, error) {
+	resp, err := r.executeCommand(RedisCommand{"GET", key})
+	if err != nil {
+		return false, err
+	}
+
+	if resp.Result == nil {
+		return false, nil
+	}
+
+	jsonStr, ok := resp.Result.(string)
+	if !ok {
+		return false, fmt.Errorf("invalid data format for %s", key)
+	}
+
+	if err := json.Unmarshal([]byte(jsonStr), v); err != nil {
+		return false, fmt.Errorf("failed to unmarshal %s: %w", key, err)
+	}
+
+	return true, nil
+}
+
+// getSetMembers returns the members of a Redis set
+func (r *RedisClient) getSetMembers(key string) ([]string, error) {
+	resp, err := r.executeCommand(RedisCommand{"SMEMBERS", key})
+	if err != nil {
+		return nil, err
+	}
+
+	resultSlice, ok := resp.Result.([]interface{})
+	if !ok {
+		return []string{}, nil
+	}
+
+	members := make([]string, 0, len(resultSlice))
+	for _, v := range resultSlice {
+		if str, ok := v.(string); ok {
+			members = append(members, str)
+		}
+	}
+
+	return members, nil
+}
+
+// KeyExists reports whether a key is present
+func (r *RedisClient) KeyExists(key string) (bool, error) {
+	resp, err := r.executeCommand(RedisCommand{"EXISTS", key})
+	if err != nil {
+		return false, fmt.Errorf("failed to check key: %w", err)
+	}
+
+	count, _ := resp.Result.(float64)
+	return count > 0, nil
+}
+
+// DeleteKeys removes keys and returns how many existed
+func (r *RedisClient) DeleteKeys(keys ...string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	cmd := RedisCommand{"DEL"}
+	for _, key := range keys {
+		cmd = append(cmd, key)
+	}
+
+	resp, err := r.executeCommand(cmd)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete keys: %w", err)
+	}
+
+	count, _ := resp.Result.(float64)
+	return int(count), nil
+}
+
+// SaveErasureReport stores a signed erasure report for a year
+func (r *RedisClient) SaveErasureReport(report *models.ErasureReport) error {
+	if err := r.setJSON(fmt.Sprintf("erasure_report:%s", report.JobID), report, 365*86400); err != nil {
+		return fmt.Errorf("failed to save erasure report: %w", err)
+	}
+	return nil
+}
+
+// GetErasureReport retrieves the erasure report produced by a job, or nil if there is none
+func (r *RedisClient) GetErasureReport(jobID string) (*models.ErasureReport, error) {
+	var report models.ErasureReport
+	found, err := r.getJSON(fmt.Sprintf("erasure_report:%s", jobID), &report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get erasure report: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &report, nil
+}
+
+// RecordUserActivity bumps a user's score in the activity ranking used for prewarming
+func (r *RedisClient) RecordUserActivity(userID string) error {
+	if _, err := r.executeCommand(RedisCommand{"ZINCRBY", "user_activity", 1, userID}); err != nil {
+		return fmt.Errorf("failed to record user activity: %w", err)
+	}
+	return nil
+}
+
+// TopActiveUsers returns up to limit users with the most recorded activity
+func (r *RedisClient) TopActiveUsers(limit int) ([]string, error) {
+	resp, err := r.executeCommand(RedisCommand{"ZREVRANGE", "user_activity", 0, limit - 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active users: %w", err)
+	}
+
+	items, _ := resp.Result.([]interface{})
+	users := make([]string, 0, len(items))
+	for _, item := range items {
+		if userID, ok := item.(string); ok {
+			users = append(users, userID)
+		}
+	}
+	return users, nil
+}
+
+// Ping verifies the Redis credentials
+func (r *RedisClient) Ping() error {
+	if _, err := r.executeCommand(RedisCommand{"PING"}); err != nil {
+		return fmt.Errorf("failed to ping redis: %w", err)
+	}
+	return nil
+}
+
+// Task lease states stored under task_lease:<id>
+const (
+	TaskLeaseRunning = "running"
+	TaskLeaseDone    = "done"
+)
+
+// AcquireTaskLease claims a task for execution. When the claim fails it returns the
+// state recorded by the delivery holding it.
+func (r *RedisClient) AcquireTaskLease(taskID string, leaseSeconds int64) (bool, string, error) {
+	key := fmt.Sprintf("task_lease:%s", taskID)
+	acquired, err := r.SetIfAbsent(key, TaskLeaseRunning, leaseSeconds)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to acquire task lease: %w", err)
+	}
+	if acquired {
+		return true, TaskLeaseRunning, nil
+	}
+
+	resp, err := r.executeCommand(RedisCommand{"GET", key})
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get task lease: %w", err)
+	}
+	state, _ := resp.Result.(string)
+	return false, state, nil
+}
+
+// CompleteTaskLease records that a task finished so later deliveries are acknowledged
+func (r *RedisClient) CompleteTaskLease(taskID string, ttlSeconds int64) error {
+	cmd := RedisCommand{"SET", fmt.Sprintf("task_lease:%s", taskID), TaskLeaseDone, "EX", ttlSeconds}
+	if _, err := r.executeCommand(cmd); err != nil {
+		return fmt.Errorf("failed to complete task lease: %w", err)
+	}
+	return nil
+}
+
+// ReleaseTaskLease drops a lease so the task can be retried
+func (r *RedisClient) ReleaseTaskLease(taskID string) error {
+	if _, err := r.DeleteKeys(fmt.Sprintf("task_lease:%s", taskID)); err != nil {
+		return fmt.Errorf("failed to release task lease: %w", err)
+	}
+	return nil
+}
+
+// ScanKeys returns every key matching a glob pattern
+func (r *RedisClient) ScanKeys(pattern string) ([]string, error) {
+	var keys []string
+	cursor := "0"
+	for {
+		resp, err := r.executeCommand(RedisCommand{"SCAN", cursor, "MATCH", pattern, "COUNT", 1000})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan keys: %w", err)
+		}
+
+		reply, ok := resp.Result.([]interface{})
+		if !ok || len(reply) != 2 {
+			return nil, fmt.Errorf("invalid scan response format")
+		}
+		cursor, _ = reply[0].(string)
+		items, _ := reply[1].([]interface{})
+		for _, item := range items {
+			if key, ok := item.(string); ok {
+				keys = append(keys, r.unprefix(key))
+			}
+		}
+
+		if cursor == "0" || cursor == "" {
+			return keys, nil
+		}
+	}
+}
+
+// ArchiveSession moves a session out of the live keyspace into the archive
+func (r *RedisClient) ArchiveSession(session *models.SessionData, ttlSeconds int64) error {
+	if err := r.setJSON(fmt.Sprintf("session_archive:%s", session.SessionID), session, ttlSeconds); err != nil {
+		return fmt.Errorf("failed to archive session: %w", err)
+	}
+
+	archiveKey := fmt.Sprintf("user_session_archive:%s", session.UserID)
+	if _, err := r.executeCommand(RedisCommand{"SADD", archiveKey, session.SessionID}); err != nil {
+		return fmt.Errorf("failed to index archived session: %w", err)
+	}
+	if _, err := r.executeCommand(RedisCommand{"EXPIRE", archiveKey, ttlSeconds}); err != nil {
+		return fmt.Errorf("failed to set archive index TTL: %w", err)
+	}
+
+	if err := r.DeleteSession(session.SessionID); err != nil {
+		return err
+	}
+	if _, err := r.executeCommand(RedisCommand{"SREM", fmt.Sprintf("user_sessions:%s", session.UserID), session.SessionID}); err != nil {
+		return fmt.Errorf("failed to unindex archived session: %w", err)
+	}
+
+	return nil
+}
+
+// GetArchivedSessions returns the IDs of a user's archived sessions
+func (r *RedisClient) GetArchivedSessions(userID string) ([]string, error) {
+	sessions, err := r.getSetMembers(fmt.Sprintf("user_session_archive:%s", userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archived sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// SaveUserProfile stores a computed user profile
+func (r *RedisClient) SaveUserProfile(profile *models.UserProfile) error {
+	if err := r.setJSON(fmt.Sprintf("user_profile:%s", profile.UserID), profile, 0); err != nil {
+		return fmt.Errorf("failed to save user profile: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserProfile removes a user's stored profile
+func (r *RedisClient) DeleteUserProfile(userID string) error {
+	if _, err := r.DeleteKeys(fmt.Sprintf("user_profile:%s", userID)); err != nil {
+		return fmt.Errorf("failed to delete user profile: %w", err)
+	}
+	return nil
+}
+
+// SaveUserPreferences stores a user's preferences
+func (r *RedisClient) SaveUserPreferences(preferences *models.UserPreferences) error {
+	if err := r.setJSON(fmt.Sprintf("user_preferences:%s", preferences.UserID), preferences, 0); err != nil {
+		return fmt.Errorf("failed to save user preferences: %w", err)
+	}
+	return nil
+}
+
+// GetUserPreferences returns a user's preferences, or nil if none are set
+func (r *RedisClient) GetUserPreferences(userID string) (*models.UserPreferences, error) {
+	var preferences models.UserPreferences
+	found, err := r.getJSON(fmt.Sprintf("user_preferences:%s", userID), &preferences)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user preferences: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &preferences, nil
+}
+
+// DeleteUserPreferences removes a user's preferences
+func (r *RedisClient) DeleteUserPreferences(userID string) error {
+	if _, err := r.DeleteKeys(fmt.Sprintf("user_preferences:%s", userID)); err != nil {
+		return fmt.Errorf("failed to delete user preferences: %w", err)
+	}
+	return nil
+}
+
+// GetUserProfile returns a user's stored profile, or nil if it has not been computed
+func (r *RedisClient) GetUserProfile(userID string) (*models.UserProfile, error) {
+	var profile models.UserProfile
+	found, err := r.getJSON(fmt.Sprintf("user_profile:%s", userID), &profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user profile: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &profile, nil
 }
@@ -0,0 +1,74 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+const (
+	// writeAnomaliesKey lists anomalous writes, newest first
+	writeAnomaliesKey = "write_anomalies"
+	// writeAnomaliesMax bounds the anomaly list
+	writeAnomaliesMax = 1000
+)
+
+// RecordWriteAnomaly adds an anomalous write to the anomaly list
+func (r *RedisClient) RecordWriteAnomaly(anomaly *models.WriteAnomaly) error {
+	jsonData, err := json.Marshal(anomaly)
+	if err != nil {
+		return fmt.Errorf("failed to marshal write anomaly: %w", err)
+	}
+
+	if _, err := r.executeCommand(RedisCommand{"LPUSH", writeAnomaliesKey, string(jsonData)}); err != nil {
+		return fmt.Errorf("failed to record write anomaly: %w", err)
+	}
+	if _, err := r.executeCommand(RedisCommand{"LTRIM", writeAnomaliesKey, 0, writeAnomaliesMax - 1}); err != nil {
+		return fmt.Errorf("failed to trim write anomalies: %w", err)
+	}
+
+	return nil
+}
+
+// ListWriteAnomalies returns up to limit recorded write anomalies, newest first
+func (r *RedisClient) ListWriteAnomalies(limit int) ([]models.WriteAnomaly, error) {
+	resp, err := r.executeCommand(RedisCommand{"LRANGE", writeAnomaliesKey, 0, limit - 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list write anomalies: %w", err)
+	}
+
+	items, _ := resp.Result.([]interface{})
+	anomalies := make([]models.WriteAnomaly, 0, len(items))
+	for _, item := range items {
+		jsonStr, ok := item.(string)
+		if !ok {
+			continue
+		}
+
+		var anomaly models.WriteAnomaly
+		if err := json.Unmarshal([]byte(jsonStr), &anomaly); err != nil {
+			continue
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+
+	return anomalies, nil
+}
+
+// AddToSet adds a member to a set that expires after ttlSeconds, returning the set's size
+func (r *RedisClient) AddToSet(key string, member string, ttlSeconds int64) (int64, error) {
+	if _, err := r.executeCommand(RedisCommand{"SADD", key, member}); err != nil {
+		return 0, fmt.Errorf("failed to add set member: %w", err)
+	}
+	if _, err := r.executeCommand(RedisCommand{"EXPIRE", key, ttlSeconds}); err != nil {
+		return 0, fmt.Errorf("failed to set set TTL: %w", err)
+	}
+
+	resp, err := r.executeCommand(RedisCommand{"SCARD", key})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count set members: %w", err)
+	}
+	count, _ := resp.Result.(float64)
+	return int64(count), nil
+}
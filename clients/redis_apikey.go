@@ -0,0 +1,71 @@
+package clients
+
+import (
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+const apiKeysKey = "api_keys"
+
+// SaveAPIKey stores an API key record
+func (r *RedisClient) SaveAPIKey(key *models.APIKey) error {
+	if err := r.setJSON(fmt.Sprintf("api_key:%s", key.ID), key, 0); err != nil {
+		return fmt.Errorf("failed to save API key: %w", err)
+	}
+
+	if _, err := r.executeCommand(RedisCommand{"SADD", apiKeysKey, key.ID}); err != nil {
+		return fmt.Errorf("failed to index API key: %w", err)
+	}
+
+	return nil
+}
+
+// GetAPIKey returns an API key record, or nil if none has the ID
+func (r *RedisClient) GetAPIKey(id string) (*models.APIKey, error) {
+	var key models.APIKey
+	found, err := r.getJSON(fmt.Sprintf("api_key:%s", id), &key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &key, nil
+}
+
+// ListAPIKeys returns every stored API key record
+func (r *RedisClient) ListAPIKeys() ([]models.APIKey, error) {
+	ids, err := r.getSetMembers(apiKeysKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	keys := make([]models.APIKey, 0, len(ids))
+	for _, id := range ids {
+		key, err := r.GetAPIKey(id)
+		if err != nil {
+			return nil, err
+		}
+		if key != nil {
+			keys = append(keys, *key)
+		}
+	}
+
+	return keys, nil
+}
+
+// DeleteAPIKey removes an API key record, reporting whether it existed
+func (r *RedisClient) DeleteAPIKey(id string) (bool, error) {
+	deleted, err := r.DeleteKeys(fmt.Sprintf("api_key:%s", id))
+	if err != nil {
+		return false, fmt.Errorf("failed to delete API key: %w", err)
+	}
+
+	if _, err := r.executeCommand(RedisCommand{"SREM", apiKeysKey, id}); err != nil {
+		return false, fmt.Errorf("failed to unindex API key: %w", err)
+	}
+
+	return deleted > 0, nil
+}
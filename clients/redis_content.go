@@ -0,0 +1,65 @@
+package clients
+
+import "fmt"
+
+// ContentStore keeps the full text of memories whose content is too long for vector metadata
+type ContentStore interface {
+	// SaveMemoryContent stores a memory's full content and returns the pointer to keep in its
+	// metadata, or "" when the content is found by memory ID alone
+	SaveMemoryContent(memoryID string, content string) (string, error)
+	// GetMemoryContents loads full content keyed by memory ID; refs maps each memory ID to
+	// its stored pointer, "" if it has none
+	GetMemoryContents(refs map[string]string) (map[string]string, error)
+	DeleteMemoryContents(memoryIDs ...string) error
+}
+
+// memoryContentKey is the key holding the full content of a truncated memory
+func memoryContentKey(memoryID string) string {
+	return fmt.Sprintf("memory_content:%s", memoryID)
+}
+
+// SaveMemoryContent stores the full content of a memory under its ID
+func (r *RedisClient) SaveMemoryContent(memoryID string, content string) (string, error) {
+	if _, err := r.executeCommand(RedisCommand{"SET", memoryContentKey(memoryID), content}); err != nil {
+		return "", fmt.Errorf("failed to save memory content: %w", err)
+	}
+	return "", nil
+}
+
+// GetMemoryContents returns the stored full content of memories, omitting those without
+// any. Content is keyed by memory ID, so the pointers in refs are not used.
+func (r *RedisClient) GetMemoryContents(refs map[string]string) (map[string]string, error) {
+	contents := make(map[string]string, len(refs))
+	if len(refs) == 0 {
+		return contents, nil
+	}
+
+	ids := make([]string, 0, len(refs))
+	cmd := RedisCommand{"MGET"}
+	for id := range refs {
+		ids = append(ids, id)
+		cmd = append(cmd, memoryContentKey(id))
+	}
+	resp, err := r.executeCommand(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get memory contents: %w", err)
+	}
+
+	values, _ := resp.Result.([]interface{})
+	for i, value := range values {
+		if content, ok := value.(string); ok && i < len(ids) {
+			contents[ids[i]] = content
+		}
+	}
+	return contents, nil
+}
+
+// DeleteMemoryContents removes the stored full content of memories
+func (r *RedisClient) DeleteMemoryContents(memoryIDs ...string) error {
+	keys := make([]string, len(memoryIDs))
+	for i, id := range memoryIDs {
+		keys[i] = memoryContentKey(id)
+	}
+	_, err := r.DeleteKeys(keys...)
+	return err
+}
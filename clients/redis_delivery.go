@@ -0,0 +1,46 @@
+package clients
+
+import (
+	"fmt"
+)
+
+// deliveryLinkSeconds matches how long job records are kept
+const deliveryLinkSeconds = 7 * 86400
+
+// LinkDeliveryJob records which job a QStash message delivery ran, and for scheduled
+// deliveries which job the schedule fired last
+func (r *RedisClient) LinkDeliveryJob(messageID string, scheduleID string, jobID string) error {
+	if messageID != "" {
+		key := fmt.Sprintf("qstash_message_job:%s", messageID)
+		if _, err := r.executeCommand(RedisCommand{"SETEX", key, deliveryLinkSeconds, jobID}); err != nil {
+			return fmt.Errorf("failed to link message %s to job: %w", messageID, err)
+		}
+	}
+
+	if scheduleID != "" {
+		key := fmt.Sprintf("schedule_last_job:%s", scheduleID)
+		if _, err := r.executeCommand(RedisCommand{"SETEX", key, deliveryLinkSeconds, jobID}); err != nil {
+			return fmt.Errorf("failed to link schedule %s to job: %w", scheduleID, err)
+		}
+	}
+
+	return nil
+}
+
+// GetMessageJobID returns the job run by a QStash message, or "" if none was recorded
+func (r *RedisClient) GetMessageJobID(messageID string) (string, error) {
+	jobID, err := r.getString(fmt.Sprintf("qstash_message_job:%s", messageID))
+	if err != nil {
+		return "", fmt.Errorf("failed to get job of message %s: %w", messageID, err)
+	}
+	return jobID, nil
+}
+
+// GetScheduleLastJobID returns the job run by a schedule's latest delivery, or "" if none was recorded
+func (r *RedisClient) GetScheduleLastJobID(scheduleID string) (string, error) {
+	jobID, err := r.getString(fmt.Sprintf("schedule_last_job:%s", scheduleID))
+	if err != nil {
+		return "", fmt.Errorf("failed to get last job of schedule %s: %w", scheduleID, err)
+	}
+	return jobID, nil
+}
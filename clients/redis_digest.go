@@ -0,0 +1,36 @@
+package clients
+
+import (
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// SaveDigestSubscription stores a user's digest subscription
+func (r *RedisClient) SaveDigestSubscription(subscription *models.DigestSubscription) error {
+	if err := r.setJSON(fmt.Sprintf("digest_subscription:%s", subscription.UserID), subscription, 0); err != nil {
+		return fmt.Errorf("failed to save digest subscription: %w", err)
+	}
+	return nil
+}
+
+// GetDigestSubscription returns a user's digest subscription, or nil if there is none
+func (r *RedisClient) GetDigestSubscription(userID string) (*models.DigestSubscription, error) {
+	var subscription models.DigestSubscription
+	found, err := r.getJSON(fmt.Sprintf("digest_subscription:%s", userID), &subscription)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get digest subscription: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &subscription, nil
+}
+
+// DeleteDigestSubscription removes a user's digest subscription
+func (r *RedisClient) DeleteDigestSubscription(userID string) error {
+	if _, err := r.DeleteKeys(fmt.Sprintf("digest_subscription:%s", userID)); err != nil {
+		return fmt.Errorf("failed to delete digest subscription: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+const (
+	// publishFailuresKey lists tasks that could not be handed to QStash, newest first
+	publishFailuresKey = "qstash_dlq"
+	// publishFailuresMax bounds the dead-letter list
+	publishFailuresMax = 1000
+)
+
+// RecordPublishFailure adds a failed publish to the dead-letter list
+func (r *RedisClient) RecordPublishFailure(failure *models.PublishFailure) error {
+	jsonData, err := json.Marshal(failure)
+	if err != nil {
+		return fmt.Errorf("failed to marshal publish failure: %w", err)
+	}
+
+	if _, err := r.executeCommand(RedisCommand{"LPUSH", publishFailuresKey, string(jsonData)}); err != nil {
+		return fmt.Errorf("failed to record publish failure: %w", err)
+	}
+	if _, err := r.executeCommand(RedisCommand{"LTRIM", publishFailuresKey, 0, publishFailuresMax - 1}); err != nil {
+		return fmt.Errorf("failed to trim publish failures: %w", err)
+	}
+
+	return nil
+}
+
+// ListPublishFailures returns up to limit recorded publish failures, newest first
+func (r *RedisClient) ListPublishFailures(limit int) ([]models.PublishFailure, error) {
+	resp, err := r.executeCommand(RedisCommand{"LRANGE", publishFailuresKey, 0, limit - 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list publish failures: %w", err)
+	}
+
+	items, _ := resp.Result.([]interface{})
+	failures := make([]models.PublishFailure, 0, len(items))
+	for _, item := range items {
+		jsonStr, ok := item.(string)
+		if !ok {
+			continue
+		}
+
+		var failure models.PublishFailure
+		if err := json.Unmarshal([]byte(jsonStr), &failure); err != nil {
+			continue
+		}
+		failures = append(failures, failure)
+	}
+
+	return failures, nil
+}
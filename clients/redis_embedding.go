@@ -0,0 +1,50 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// embeddingCacheKey holds the cached embedding whose provider, model and text hash to digest
+func embeddingCacheKey(digest string) string {
+	return "embedding_cache:" + digest
+}
+
+// GetCachedEmbeddings returns the embeddings cached under digests, with nil for misses
+func (r *RedisClient) GetCachedEmbeddings(digests []string) ([][]float64, error) {
+	cmd := RedisCommand{"MGET"}
+	for _, digest := range digests {
+		cmd = append(cmd, embeddingCacheKey(digest))
+	}
+
+	resp, err := r.executeCommand(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached embeddings: %w", err)
+	}
+
+	values, _ := resp.Result.([]interface{})
+	embeddings := make([][]float64, len(digests))
+	for i := range digests {
+		if i >= len(values) {
+			break
+		}
+		raw, ok := values[i].(string)
+		if !ok {
+			continue
+		}
+		var embedding []float64
+		if err := json.Unmarshal([]byte(raw), &embedding); err != nil || len(embedding) == 0 {
+			continue // treat unreadable entries as misses; they are overwritten on the next call
+		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
+
+// CacheEmbedding stores an embedding under digest for ttlSeconds
+func (r *RedisClient) CacheEmbedding(digest string, embedding []float64, ttlSeconds int64) error {
+	if err := r.setJSON(embeddingCacheKey(digest), embedding, ttlSeconds); err != nil {
+		return fmt.Errorf("failed to cache embedding: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// factsKey maps a user's fact keys to the facts as JSON
+func factsKey(userID string) string {
+	return fmt.Sprintf("user_facts:%s", userID)
+}
+
+// SaveFact stores a user's fact, replacing any with the same key
+func (r *RedisClient) SaveFact(fact *models.Fact) error {
+	data, err := json.Marshal(fact)
+	if err != nil {
+		return fmt.Errorf("failed to marshal fact: %w", err)
+	}
+	if _, err := r.executeCommand(RedisCommand{"HSET", factsKey(fact.UserID), fact.Key, string(data)}); err != nil {
+		return fmt.Errorf("failed to save fact: %w", err)
+	}
+	return nil
+}
+
+// GetFact returns a user's fact by key, or nil if it is not set
+func (r *RedisClient) GetFact(userID string, key string) (*models.Fact, error) {
+	resp, err := r.executeCommand(RedisCommand{"HGET", factsKey(userID), key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fact: %w", err)
+	}
+	jsonStr, _ := resp.Result.(string)
+	if jsonStr == "" {
+		return nil, nil
+	}
+	var fact models.Fact
+	if err := json.Unmarshal([]byte(jsonStr), &fact); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal fact %s: %w", key, err)
+	}
+	return &fact, nil
+}
+
+// GetFacts returns all of a user's facts, ordered by key
+func (r *RedisClient) GetFacts(userID string) ([]models.Fact, error) {
+	resp, err := r.executeCommand(RedisCommand{"HGETALL", factsKey(userID)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get facts: %w", err)
+	}
+
+	// Upstash returns hashes as a flat field, value, field, value... array
+	items, _ := resp.Result.([]interface{})
+	facts := make([]models.Fact, 0, len(items)/2)
+	for i := 0; i+1 < len(items); i += 2 {
+		jsonStr, _ := items[i+1].(string)
+		var fact models.Fact
+		if err := json.Unmarshal([]byte(jsonStr), &fact); err != nil {
+			continue
+		}
+		facts = append(facts, fact)
+	}
+	sort.Slice(facts, func(i, j int) bool { return facts[i].Key < facts[j].Key })
+	return facts, nil
+}
+
+// CountFacts returns how many facts a user has
+func (r *RedisClient) CountFacts(userID string) (int, error) {
+	resp, err := r.executeCommand(RedisCommand{"HLEN", factsKey(userID)})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count facts: %w", err)
+	}
+	count, _ := resp.Result.(float64)
+	return int(count), nil
+}
+
+// DeleteFact removes a user's fact, reporting whether it was set
+func (r *RedisClient) DeleteFact(userID string, key string) (bool, error) {
+	resp, err := r.executeCommand(RedisCommand{"HDEL", factsKey(userID), key})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete fact: %w", err)
+	}
+	removed, _ := resp.Result.(float64)
+	return removed > 0, nil
+}
@@ -0,0 +1,153 @@
+package clients
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// userAliasKey maps one external identifier of a tenant to its alias record
+func userAliasKey(tenantID string, kind string, value string) string {
+	return fmt.Sprintf("user_alias:%s:%s:%s", tenantID, kind, value)
+}
+
+// userAliasesKey indexes a user's aliases as kind:value members
+func userAliasesKey(tenantID string, userID string) string {
+	return fmt.Sprintf("user_aliases:%s:%s", tenantID, userID)
+}
+
+// SaveUserAlias stores an alias and indexes it under its user
+func (r *RedisClient) SaveUserAlias(tenantID string, alias *models.UserAlias) error {
+	if err := r.setJSON(userAliasKey(tenantID, alias.Kind, alias.Value), alias, 0); err != nil {
+		return fmt.Errorf("failed to save user alias: %w", err)
+	}
+	if _, err := r.executeCommand(RedisCommand{"SADD", userAliasesKey(tenantID, alias.UserID), alias.Kind + ":" + alias.Value}); err != nil {
+		return fmt.Errorf("failed to index user alias: %w", err)
+	}
+	return nil
+}
+
+// GetUserAlias returns the alias of an external identifier, or nil if it is not linked
+func (r *RedisClient) GetUserAlias(tenantID string, kind string, value string) (*models.UserAlias, error) {
+	var alias models.UserAlias
+	found, err := r.getJSON(userAliasKey(tenantID, kind, value), &alias)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user alias: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &alias, nil
+}
+
+// ListUserAliases returns every alias linked to a user
+func (r *RedisClient) ListUserAliases(tenantID string, userID string) ([]models.UserAlias, error) {
+	members, err := r.getSetMembers(userAliasesKey(tenantID, userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user aliases: %w", err)
+	}
+
+	aliases := make([]models.UserAlias, 0, len(members))
+	for _, member := range members {
+		parts := strings.SplitN(member, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		alias, err := r.GetUserAlias(tenantID, parts[0], parts[1])
+		if err != nil {
+			return nil, err
+		}
+		if alias != nil && alias.UserID == userID {
+			aliases = append(aliases, *alias)
+		}
+	}
+	return aliases, nil
+}
+
+// DeleteUserAlias unlinks an alias from its user
+func (r *RedisClient) DeleteUserAlias(tenantID string, alias *models.UserAlias) error {
+	if _, err := r.executeCommand(RedisCommand{"DEL", userAliasKey(tenantID, alias.Kind, alias.Value)}); err != nil {
+		return fmt.Errorf("failed to delete user alias: %w", err)
+	}
+	if _, err := r.executeCommand(RedisCommand{"SREM", userAliasesKey(tenantID, alias.UserID), alias.Kind + ":" + alias.Value}); err != nil {
+		return fmt.Errorf("failed to unindex user alias: %w", err)
+	}
+	return nil
+}
+
+// UserAliasKeys returns the keys holding a user's aliases, including their index
+func (r *RedisClient) UserAliasKeys(tenantID string, userID string) ([]string, error) {
+	aliases, err := r.ListUserAliases(tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(aliases)+1)
+	for _, alias := range aliases {
+		keys = append(keys, userAliasKey(tenantID, alias.Kind, alias.Value))
+	}
+	return append(keys, userAliasesKey(tenantID, userID)), nil
+}
+
+// ReassignKeywordMemories moves a user's keyword-only memories to another user
+func (r *RedisClient) ReassignKeywordMemories(fromUserID string, toUserID string) (int, error) {
+	memories, err := r.GetKeywordMemories(fromUserID)
+	if err != nil {
+		return 0, err
+	}
+	for i := range memories {
+		memories[i].UserID = toUserID
+		if memories[i].Metadata != nil {
+			memories[i].Metadata["user_id"] = toUserID
+		}
+		if err := r.SaveKeywordMemory(&memories[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(memories), r.DeleteKeywordMemories(fromUserID)
+}
+
+// ReassignArchivedSession moves an archived session to another user, keeping its expiry.
+// It reports false when the archive has already expired.
+func (r *RedisClient) ReassignArchivedSession(sessionID string, fromUserID string, toUserID string) (bool, error) {
+	key := fmt.Sprintf("session_archive:%s", sessionID)
+	session, err := r.GetArchivedSession(sessionID)
+	if err != nil || session == nil {
+		return false, err
+	}
+	resp, err := r.executeCommand(RedisCommand{"TTL", key})
+	if err != nil {
+		return false, fmt.Errorf("failed to get archived session TTL: %w", err)
+	}
+	ttl, _ := resp.Result.(float64)
+	if ttl == -2 {
+		return false, nil
+	}
+
+	// A TTL of -1 means the archive does not expire, which setJSON expresses as 0
+	session.UserID = toUserID
+	if ttl < 0 {
+		ttl = 0
+	}
+	if err := r.setJSON(key, session, int64(ttl)); err != nil {
+		return false, fmt.Errorf("failed to reassign archived session: %w", err)
+	}
+
+	if _, err := r.executeCommand(RedisCommand{"SADD", fmt.Sprintf("user_session_archive:%s", toUserID), sessionID}); err != nil {
+		return false, fmt.Errorf("failed to index archived session: %w", err)
+	}
+	if _, err := r.executeCommand(RedisCommand{"SREM", fmt.Sprintf("user_session_archive:%s", fromUserID), sessionID}); err != nil {
+		return false, fmt.Errorf("failed to unindex archived session: %w", err)
+	}
+	return true, nil
+}
+
+// ReassignIndexedMemories points full-text index entries at another user
+func (r *RedisClient) ReassignIndexedMemories(keys []string, toUserID string) error {
+	for _, key := range keys {
+		if _, err := r.executeCommand(RedisCommand{"HSET", key, "user_id", toUserID}); err != nil {
+			return fmt.Errorf("failed to reassign indexed memory: %w", err)
+		}
+	}
+	return nil
+}
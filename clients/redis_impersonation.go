@@ -0,0 +1,88 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+const (
+	// impersonationAuditKey lists impersonation audit entries, newest first
+	impersonationAuditKey = "impersonation_audit"
+	// impersonationAuditMax bounds the audit list
+	impersonationAuditMax = 10000
+)
+
+// SaveImpersonation stores an impersonation until it expires
+func (r *RedisClient) SaveImpersonation(impersonation *models.Impersonation, ttlSeconds int64) error {
+	if err := r.setJSON(fmt.Sprintf("impersonation:%s", impersonation.ID), impersonation, ttlSeconds); err != nil {
+		return fmt.Errorf("failed to save impersonation: %w", err)
+	}
+	return nil
+}
+
+// GetImpersonation returns an active impersonation, or nil if it expired or was revoked
+func (r *RedisClient) GetImpersonation(id string) (*models.Impersonation, error) {
+	var impersonation models.Impersonation
+	found, err := r.getJSON(fmt.Sprintf("impersonation:%s", id), &impersonation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get impersonation: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &impersonation, nil
+}
+
+// DeleteImpersonation ends an impersonation, reporting whether it was active
+func (r *RedisClient) DeleteImpersonation(id string) (bool, error) {
+	deleted, err := r.DeleteKeys(fmt.Sprintf("impersonation:%s", id))
+	if err != nil {
+		return false, fmt.Errorf("failed to delete impersonation: %w", err)
+	}
+	return deleted > 0, nil
+}
+
+// RecordImpersonationAudit adds an entry to the impersonation audit trail
+func (r *RedisClient) RecordImpersonationAudit(entry *models.ImpersonationAuditEntry) error {
+	jsonData, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal impersonation audit entry: %w", err)
+	}
+
+	if _, err := r.executeCommand(RedisCommand{"LPUSH", impersonationAuditKey, string(jsonData)}); err != nil {
+		return fmt.Errorf("failed to record impersonation audit entry: %w", err)
+	}
+	if _, err := r.executeCommand(RedisCommand{"LTRIM", impersonationAuditKey, 0, impersonationAuditMax - 1}); err != nil {
+		return fmt.Errorf("failed to trim impersonation audit: %w", err)
+	}
+
+	return nil
+}
+
+// ListImpersonationAudit returns the audit trail, newest first
+func (r *RedisClient) ListImpersonationAudit() ([]models.ImpersonationAuditEntry, error) {
+	resp, err := r.executeCommand(RedisCommand{"LRANGE", impersonationAuditKey, 0, -1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list impersonation audit: %w", err)
+	}
+
+	items, _ := resp.Result.([]interface{})
+	entries := make([]models.ImpersonationAuditEntry, 0, len(items))
+	for _, item := range items {
+		jsonStr, ok := item.(string)
+		if !ok {
+			continue
+		}
+
+		var entry models.ImpersonationAuditEntry
+		if err := json.Unmarshal([]byte(jsonStr), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,272 @@
+package clients
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+const (
+	// jobQueueKey lists the IDs of jobs ready to run, oldest first
+	jobQueueKey = "job_queue"
+	// jobDelayedKey holds the IDs of failed jobs waiting to be retried, scored by due time
+	jobDelayedKey = "job_queue:delayed"
+	// jobRunningKey holds the IDs of claimed jobs, scored by when their lease runs out
+	jobRunningKey = "job_queue:running"
+	// jobDeadKey lists the IDs of jobs given up on, newest first
+	jobDeadKey = "job_queue:dead"
+
+	// jobTTLSeconds is how long jobs, their payloads and index entries are kept
+	jobTTLSeconds = 7 * 86400
+	// deadJobsMax bounds the dead-letter list
+	deadJobsMax = 1000
+)
+
+var (
+	// ErrJobNotFound is returned for a job that does not exist or has expired
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobQueueFull is returned when the job queue holds JOB_QUEUE_MAX jobs
+	ErrJobQueueFull = errors.New("job queue is full")
+)
+
+// IndexJob adds a new job to its tenant's job listing, dropping entries older than jobs
+// are kept
+func (r *RedisClient) IndexJob(job *models.Job) error {
+	key := jobIndexKey(job.TenantID)
+	if _, err := r.executeCommand(RedisCommand{"ZADD", key, job.CreatedAt.UnixNano(), job.ID}); err != nil {
+		return fmt.Errorf("failed to index job: %w", err)
+	}
+	cutoff := time.Now().Add(-jobTTLSeconds * time.Second).UnixNano()
+	if _, err := r.executeCommand(RedisCommand{"ZREMRANGEBYSCORE", key, "-inf", cutoff}); err != nil {
+		return fmt.Errorf("failed to trim job index: %w", err)
+	}
+	_, err := r.executeCommand(RedisCommand{"EXPIRE", key, jobTTLSeconds})
+	return err
+}
+
+// ListJobIDs returns count of a tenant's job IDs from offset in the listing, newest first
+func (r *RedisClient) ListJobIDs(tenantID string, offset int, count int) ([]string, error) {
+	resp, err := r.executeCommand(RedisCommand{"ZREVRANGE", jobIndexKey(tenantID), offset, offset + count - 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	return stringItems(resp.Result), nil
+}
+
+// ListDeadJobIDs returns up to limit dead-lettered job IDs, newest first
+func (r *RedisClient) ListDeadJobIDs(limit int) ([]string, error) {
+	resp, err := r.executeCommand(RedisCommand{"LRANGE", jobDeadKey, 0, limit - 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead jobs: %w", err)
+	}
+	return stringItems(resp.Result), nil
+}
+
+// GetJobs returns the jobs with the given IDs, skipping those that expired
+func (r *RedisClient) GetJobs(jobIDs []string) ([]*models.Job, error) {
+	if len(jobIDs) == 0 {
+		return nil, nil
+	}
+	cmd := RedisCommand{"MGET"}
+	for _, id := range jobIDs {
+		cmd = append(cmd, fmt.Sprintf("job:%s", id))
+	}
+	resp, err := r.executeCommand(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jobs: %w", err)
+	}
+
+	items, _ := resp.Result.([]interface{})
+	jobs := make([]*models.Job, 0, len(items))
+	for _, item := range items {
+		jsonStr, ok := item.(string)
+		if !ok {
+			continue
+		}
+		var job models.Job
+		if err := json.Unmarshal([]byte(jsonStr), &job); err != nil {
+			continue
+		}
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
+
+// EnqueueJob stores a job's payload, if any, and appends it to the job queue, unless the
+// queue already holds max jobs
+func (r *RedisClient) EnqueueJob(jobID string, payload []byte, max int) error {
+	length, err := r.JobQueueLength()
+	if err != nil {
+		return err
+	}
+	if length >= max {
+		return fmt.Errorf("%w (%d jobs)", ErrJobQueueFull, length)
+	}
+
+	if payload != nil {
+		if _, err := r.executeCommand(RedisCommand{"SETEX", jobPayloadKey(jobID), jobTTLSeconds, string(payload)}); err != nil {
+			return fmt.Errorf("failed to save job payload: %w", err)
+		}
+	}
+	if _, err := r.executeCommand(RedisCommand{"RPUSH", jobQueueKey, jobID}); err != nil {
+		return fmt.Errorf("failed to queue job: %w", err)
+	}
+	return nil
+}
+
+// JobQueueLength returns how many jobs are ready to run
+func (r *RedisClient) JobQueueLength() (int, error) {
+	resp, err := r.executeCommand(RedisCommand{"LLEN", jobQueueKey})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get job queue length: %w", err)
+	}
+	length, _ := resp.Result.(float64)
+	return int(length), nil
+}
+
+// ClaimJob takes the oldest ready job, holding it for lease, and returns its ID, or ""
+// when the queue is empty. A job whose lease runs out is queued again.
+func (r *RedisClient) ClaimJob(lease time.Duration) (string, error) {
+	resp, err := r.executeCommand(RedisCommand{"LPOP", jobQueueKey})
+	if err != nil {
+		return "", fmt.Errorf("failed to claim job: %w", err)
+	}
+	jobID, _ := resp.Result.(string)
+	if jobID == "" {
+		return "", nil
+	}
+	if _, err := r.executeCommand(RedisCommand{"ZADD", jobRunningKey, time.Now().Add(lease).Unix(), jobID}); err != nil {
+		return "", fmt.Errorf("failed to lease job %s: %w", jobID, err)
+	}
+	return jobID, nil
+}
+
+// RenewJobLease extends the lease of a running job
+func (r *RedisClient) RenewJobLease(jobID string, lease time.Duration) error {
+	if _, err := r.executeCommand(RedisCommand{"ZADD", jobRunningKey, "XX", time.Now().Add(lease).Unix(), jobID}); err != nil {
+		return fmt.Errorf("failed to renew lease of job %s: %w", jobID, err)
+	}
+	return nil
+}
+
+// FinishJob releases a job that will not run again and drops its payload
+func (r *RedisClient) FinishJob(jobID string) error {
+	if _, err := r.executeCommand(RedisCommand{"ZREM", jobRunningKey, jobID}); err != nil {
+		return fmt.Errorf("failed to release job %s: %w", jobID, err)
+	}
+	if _, err := r.DeleteKeys(jobPayloadKey(jobID)); err != nil {
+		return fmt.Errorf("failed to delete payload of job %s: %w", jobID, err)
+	}
+	return nil
+}
+
+// RetryJobAt releases a failed job and queues it again once at has passed
+func (r *RedisClient) RetryJobAt(jobID string, at time.Time) error {
+	if _, err := r.executeCommand(RedisCommand{"ZADD", jobDelayedKey, at.Unix(), jobID}); err != nil {
+		return fmt.Errorf("failed to schedule retry of job %s: %w", jobID, err)
+	}
+	if _, err := r.executeCommand(RedisCommand{"ZREM", jobRunningKey, jobID}); err != nil {
+		return fmt.Errorf("failed to release job %s: %w", jobID, err)
+	}
+	return nil
+}
+
+// DeadLetterJob releases a job given up on and adds it to the dead-letter list. Its
+// payload is kept so the job can be retried.
+func (r *RedisClient) DeadLetterJob(jobID string) error {
+	if _, err := r.executeCommand(RedisCommand{"LPUSH", jobDeadKey, jobID}); err != nil {
+		return fmt.Errorf("failed to dead-letter job %s: %w", jobID, err)
+	}
+	if _, err := r.executeCommand(RedisCommand{"LTRIM", jobDeadKey, 0, deadJobsMax - 1}); err != nil {
+		return fmt.Errorf("failed to trim dead jobs: %w", err)
+	}
+	if _, err := r.executeCommand(RedisCommand{"ZREM", jobRunningKey, jobID}); err != nil {
+		return fmt.Errorf("failed to release job %s: %w", jobID, err)
+	}
+	return nil
+}
+
+// RemoveDeadJob takes a job off the dead-letter list, reporting whether it was on it
+func (r *RedisClient) RemoveDeadJob(jobID string) (bool, error) {
+	resp, err := r.executeCommand(RedisCommand{"LREM", jobDeadKey, 0, jobID})
+	if err != nil {
+		return false, fmt.Errorf("failed to take job %s from the dead-letter list: %w", jobID, err)
+	}
+	removed, _ := resp.Result.(float64)
+	return removed > 0, nil
+}
+
+// RequeueJob appends a job whose payload is already stored to the job queue
+func (r *RedisClient) RequeueJob(jobID string) error {
+	if _, err := r.executeCommand(RedisCommand{"RPUSH", jobQueueKey, jobID}); err != nil {
+		return fmt.Errorf("failed to queue job %s: %w", jobID, err)
+	}
+	return nil
+}
+
+// PromoteJobs queues again up to limit jobs whose retry is due or whose lease ran out,
+// and returns how many it queued. Replicas may promote concurrently: only the one that
+// removes a job from its set queues it.
+func (r *RedisClient) PromoteJobs(now time.Time, limit int) (int, error) {
+	promoted := 0
+	for _, key := range []string{jobDelayedKey, jobRunningKey} {
+		resp, err := r.executeCommand(RedisCommand{"ZRANGEBYSCORE", key, "-inf", now.Unix(), "LIMIT", 0, limit})
+		if err != nil {
+			return promoted, fmt.Errorf("failed to read due jobs: %w", err)
+		}
+		for _, jobID := range stringItems(resp.Result) {
+			resp, err := r.executeCommand(RedisCommand{"ZREM", key, jobID})
+			if err != nil {
+				return promoted, fmt.Errorf("failed to take due job %s: %w", jobID, err)
+			}
+			if removed, _ := resp.Result.(float64); removed == 0 {
+				continue
+			}
+			if _, err := r.executeCommand(RedisCommand{"RPUSH", jobQueueKey, jobID}); err != nil {
+				return promoted, fmt.Errorf("failed to queue job %s: %w", jobID, err)
+			}
+			promoted++
+		}
+	}
+	return promoted, nil
+}
+
+// GetJobPayload returns the payload a job was queued with, or nil if it had none
+func (r *RedisClient) GetJobPayload(jobID string) ([]byte, error) {
+	payload, err := r.getString(jobPayloadKey(jobID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payload of job %s: %w", jobID, err)
+	}
+	if payload == "" {
+		return nil, nil
+	}
+	return []byte(payload), nil
+}
+
+func jobPayloadKey(jobID string) string {
+	return fmt.Sprintf("job_payload:%s", jobID)
+}
+
+// jobIndexKey is the sorted set holding a tenant's job IDs, scored by creation time.
+// Jobs without a tenant are listed under the default tenant.
+func jobIndexKey(tenantID string) string {
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	return "jobs:" + tenantID
+}
+
+// stringItems returns the strings of an array reply
+func stringItems(result interface{}) []string {
+	items, _ := result.([]interface{})
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if value, ok := item.(string); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,1002 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryRedis is the Redis that REDIS_PROVIDER=memory keeps in process memory, for
+// embedding and tests. It runs the commands in redisCommandKeys plus SCAN and replies as
+// the REST API would: integers as float64, arrays as []interface{}, missing values as
+// nil. RediSearch (FT.*) is not available. Every client in the process shares one
+// instance, as clients of one Redis server share its data; nothing is persisted.
+type memoryRedis struct {
+	mu   sync.Mutex
+	data map[string]*memoryEntry
+}
+
+// memoryEntry is a key's value: a string, map[string]string (hash), []string (list),
+// map[string]struct{} (set) or map[string]float64 (sorted set)
+type memoryEntry struct {
+	value   interface{}
+	expires time.Time // zero without a TTL
+}
+
+var (
+	errMemoryWrongType  = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+	errMemoryNotInteger = errors.New("ERR value is not an integer or out of range")
+	errMemoryNotFloat   = errors.New("ERR value is not a valid float")
+	errMemorySyntax     = errors.New("ERR syntax error")
+)
+
+var sharedMemoryRedis = &memoryRedis{data: make(map[string]*memoryEntry)}
+
+// reset drops every key
+func (m *memoryRedis) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = make(map[string]*memoryEntry)
+}
+
+// sendMemory runs a command against the in-process Redis
+func (r *RedisClient) sendMemory(cmd RedisCommand) (*RedisResponse, error) {
+	if err := injectFault(FaultRedis); err != nil {
+		return nil, err
+	}
+
+	result, err := r.memory.do(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("Redis error: %s", err.Error())
+	}
+	return &RedisResponse{Result: result}, nil
+}
+
+// memoryArg renders a command argument as Redis receives it
+func memoryArg(arg interface{}) string {
+	switch v := arg.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (m *memoryRedis) do(cmd RedisCommand) (interface{}, error) {
+	if len(cmd) == 0 {
+		return nil, errors.New("ERR empty command")
+	}
+	args := make([]string, len(cmd))
+	for i, arg := range cmd {
+		args[i] = memoryArg(arg)
+	}
+	name := strings.ToUpper(args[0])
+	args = args[1:]
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	arity := map[string]int{
+		"PING": 0, "GET": 1, "SET": 2, "SETEX": 3, "INCR": 1, "INCRBY": 2, "EXPIRE": 2, "TTL": 1,
+		"HSET": 3, "HGET": 2, "HDEL": 2, "HGETALL": 1, "HLEN": 1,
+		"LPUSH": 2, "RPUSH": 2, "LPOP": 1, "LRANGE": 3, "LTRIM": 3, "LLEN": 1, "LSET": 3, "LREM": 3,
+		"SADD": 2, "SREM": 2, "SMEMBERS": 1, "SCARD": 1,
+		"ZADD": 3, "ZREM": 2, "ZCARD": 1, "ZINCRBY": 3, "ZREVRANGE": 3, "ZRANGEBYSCORE": 3, "ZREMRANGEBYSCORE": 3,
+		"DEL": 1, "EXISTS": 1, "MGET": 1, "RENAMENX": 2, "SCAN": 1,
+	}
+	minArgs, ok := arity[name]
+	if !ok {
+		return nil, fmt.Errorf("ERR unknown command '%s'", name)
+	}
+	if len(args) < minArgs {
+		return nil, fmt.Errorf("ERR wrong number of arguments for '%s' command", strings.ToLower(name))
+	}
+
+	switch name {
+	case "PING":
+		return "PONG", nil
+	case "GET":
+		return m.get(args[0])
+	case "SET":
+		return m.setString(args[0], args[1], args[2:])
+	case "SETEX":
+		seconds, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || seconds <= 0 {
+			return nil, errors.New("ERR invalid expire time in 'setex' command")
+		}
+		m.data[args[0]] = &memoryEntry{value: args[2], expires: time.Now().Add(time.Duration(seconds) * time.Second)}
+		return "OK", nil
+	case "INCR":
+		return m.incrBy(args[0], "1")
+	case "INCRBY":
+		return m.incrBy(args[0], args[1])
+	case "EXPIRE":
+		return m.expire(args[0], args[1])
+	case "TTL":
+		return m.ttl(args[0]), nil
+
+	case "HSET":
+		return m.hset(args[0], args[1:])
+	case "HGET":
+		hash, err := m.hash(args[0], false)
+		if err != nil || hash == nil {
+			return nil, err
+		}
+		if value, ok := hash[args[1]]; ok {
+			return value, nil
+		}
+		return nil, nil
+	case "HDEL":
+		hash, err := m.hash(args[0], false)
+		if err != nil || hash == nil {
+			return float64(0), err
+		}
+		removed := 0
+		for _, field := range args[1:] {
+			if _, ok := hash[field]; ok {
+				delete(hash, field)
+				removed++
+			}
+		}
+		m.dropIfEmpty(args[0], len(hash))
+		return float64(removed), nil
+	case "HGETALL":
+		hash, err := m.hash(args[0], false)
+		if err != nil {
+			return nil, err
+		}
+		fields := make([]string, 0, len(hash))
+		for field := range hash {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		reply := make([]interface{}, 0, 2*len(fields))
+		for _, field := range fields {
+			reply = append(reply, field, hash[field])
+		}
+		return reply, nil
+	case "HLEN":
+		hash, err := m.hash(args[0], false)
+		return float64(len(hash)), err
+
+	case "LPUSH", "RPUSH":
+		return m.push(args[0], args[1:], name == "LPUSH")
+	case "LPOP":
+		return m.lpop(args[0], args[1:])
+	case "LRANGE":
+		list, err := m.list(args[0])
+		if err != nil {
+			return nil, err
+		}
+		start, stop, err := memoryRange(args[1], args[2], len(list))
+		if err != nil {
+			return nil, err
+		}
+		reply := make([]interface{}, 0)
+		for i := start; i <= stop; i++ {
+			reply = append(reply, list[i])
+		}
+		return reply, nil
+	case "LTRIM":
+		list, err := m.list(args[0])
+		if err != nil || list == nil {
+			return "OK", err
+		}
+		start, stop, err := memoryRange(args[1], args[2], len(list))
+		if err != nil {
+			return nil, err
+		}
+		if start > stop {
+			delete(m.data, args[0])
+			return "OK", nil
+		}
+		m.data[args[0]].value = append([]string(nil), list[start:stop+1]...)
+		return "OK", nil
+	case "LLEN":
+		list, err := m.list(args[0])
+		return float64(len(list)), err
+	case "LSET":
+		list, err := m.list(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if list == nil {
+			return nil, errors.New("ERR no such key")
+		}
+		index, err := strconv.Atoi(args[1])
+		if err != nil {
+			return nil, errMemoryNotInteger
+		}
+		if index < 0 {
+			index += len(list)
+		}
+		if index < 0 || index >= len(list) {
+			return nil, errors.New("ERR index out of range")
+		}
+		list[index] = args[2]
+		return "OK", nil
+	case "LREM":
+		return m.lrem(args[0], args[1], args[2])
+
+	case "SADD":
+		set, err := m.set(args[0], true)
+		if err != nil {
+			return nil, err
+		}
+		added := 0
+		for _, member := range args[1:] {
+			if _, ok := set[member]; !ok {
+				set[member] = struct{}{}
+				added++
+			}
+		}
+		return float64(added), nil
+	case "SREM":
+		set, err := m.set(args[0], false)
+		if err != nil || set == nil {
+			return float64(0), err
+		}
+		removed := 0
+		for _, member := range args[1:] {
+			if _, ok := set[member]; ok {
+				delete(set, member)
+				removed++
+			}
+		}
+		m.dropIfEmpty(args[0], len(set))
+		return float64(removed), nil
+	case "SMEMBERS":
+		set, err := m.set(args[0], false)
+		if err != nil {
+			return nil, err
+		}
+		members := make([]string, 0, len(set))
+		for member := range set {
+			members = append(members, member)
+		}
+		sort.Strings(members)
+		reply := make([]interface{}, len(members))
+		for i, member := range members {
+			reply[i] = member
+		}
+		return reply, nil
+	case "SCARD":
+		set, err := m.set(args[0], false)
+		return float64(len(set)), err
+
+	case "ZADD":
+		return m.zadd(args[0], args[1:])
+	case "ZREM":
+		zset, err := m.zset(args[0], false)
+		if err != nil || zset == nil {
+			return float64(0), err
+		}
+		removed := 0
+		for _, member := range args[1:] {
+			if _, ok := zset[member]; ok {
+				delete(zset, member)
+				removed++
+			}
+		}
+		m.dropIfEmpty(args[0], len(zset))
+		return float64(removed), nil
+	case "ZCARD":
+		zset, err := m.zset(args[0], false)
+		return float64(len(zset)), err
+	case "ZINCRBY":
+		increment, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			return nil, errMemoryNotFloat
+		}
+		zset, err := m.zset(args[0], true)
+		if err != nil {
+			return nil, err
+		}
+		zset[args[2]] += increment
+		return formatScore(zset[args[2]]), nil
+	case "ZREVRANGE":
+		return m.zrevrange(args[0], args[1], args[2], args[3:])
+	case "ZRANGEBYSCORE":
+		return m.zrangeByScore(args[0], args[1], args[2], args[3:])
+	case "ZREMRANGEBYSCORE":
+		zset, err := m.zset(args[0], false)
+		if err != nil || zset == nil {
+			return float64(0), err
+		}
+		members, err := scoreRange(zset, args[1], args[2])
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			delete(zset, member)
+		}
+		m.dropIfEmpty(args[0], len(zset))
+		return float64(len(members)), nil
+
+	case "DEL":
+		removed := 0
+		for _, key := range args {
+			if m.lookup(key) != nil {
+				delete(m.data, key)
+				removed++
+			}
+		}
+		return float64(removed), nil
+	case "EXISTS":
+		found := 0
+		for _, key := range args {
+			if m.lookup(key) != nil {
+				found++
+			}
+		}
+		return float64(found), nil
+	case "MGET":
+		reply := make([]interface{}, len(args))
+		for i, key := range args {
+			if entry := m.lookup(key); entry != nil {
+				if value, ok := entry.value.(string); ok {
+					reply[i] = value
+				}
+			}
+		}
+		return reply, nil
+	case "RENAMENX":
+		entry := m.lookup(args[0])
+		if entry == nil {
+			return nil, errors.New("ERR no such key")
+		}
+		if m.lookup(args[1]) != nil {
+			return float64(0), nil
+		}
+		delete(m.data, args[0])
+		m.data[args[1]] = entry
+		return float64(1), nil
+	case "SCAN":
+		return m.scan(args[1:])
+	}
+	return nil, fmt.Errorf("ERR unknown command '%s'", name)
+}
+
+// lookup returns a key's entry, dropping it once its TTL has passed
+func (m *memoryRedis) lookup(key string) *memoryEntry {
+	entry, ok := m.data[key]
+	if !ok {
+		return nil
+	}
+	if !entry.expires.IsZero() && !time.Now().Before(entry.expires) {
+		delete(m.data, key)
+		return nil
+	}
+	return entry
+}
+
+// dropIfEmpty deletes a collection once its last element is removed, as Redis does
+func (m *memoryRedis) dropIfEmpty(key string, size int) {
+	if size == 0 {
+		delete(m.data, key)
+	}
+}
+
+func (m *memoryRedis) get(key string) (interface{}, error) {
+	entry := m.lookup(key)
+	if entry == nil {
+		return nil, nil
+	}
+	value, ok := entry.value.(string)
+	if !ok {
+		return nil, errMemoryWrongType
+	}
+	return value, nil
+}
+
+// setString handles SET key value [NX|XX] [EX seconds|PX milliseconds|KEEPTTL]
+func (m *memoryRedis) setString(key string, value string, options []string) (interface{}, error) {
+	var nx, xx, keepTTL bool
+	var ttl time.Duration
+	for i := 0; i < len(options); i++ {
+		switch strings.ToUpper(options[i]) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "KEEPTTL":
+			keepTTL = true
+		case "EX", "PX":
+			if i+1 >= len(options) {
+				return nil, errMemorySyntax
+			}
+			amount, err := strconv.ParseInt(options[i+1], 10, 64)
+			if err != nil || amount <= 0 {
+				return nil, errors.New("ERR invalid expire time in 'set' command")
+			}
+			unit := time.Second
+			if strings.EqualFold(options[i], "PX") {
+				unit = time.Millisecond
+			}
+			ttl = time.Duration(amount) * unit
+			i++
+		default:
+			return nil, errMemorySyntax
+		}
+	}
+
+	existing := m.lookup(key)
+	if (nx && existing != nil) || (xx && existing == nil) {
+		return nil, nil
+	}
+	entry := &memoryEntry{value: value}
+	switch {
+	case ttl > 0:
+		entry.expires = time.Now().Add(ttl)
+	case keepTTL && existing != nil:
+		entry.expires = existing.expires
+	}
+	m.data[key] = entry
+	return "OK", nil
+}
+
+func (m *memoryRedis) incrBy(key string, by string) (interface{}, error) {
+	delta, err := strconv.ParseInt(by, 10, 64)
+	if err != nil {
+		return nil, errMemoryNotInteger
+	}
+	entry := m.lookup(key)
+	if entry == nil {
+		entry = &memoryEntry{value: "0"}
+		m.data[key] = entry
+	}
+	current, ok := entry.value.(string)
+	if !ok {
+		return nil, errMemoryWrongType
+	}
+	value, err := strconv.ParseInt(current, 10, 64)
+	if err != nil {
+		return nil, errMemoryNotInteger
+	}
+	value += delta
+	entry.value = strconv.FormatInt(value, 10)
+	return float64(value), nil
+}
+
+func (m *memoryRedis) expire(key string, seconds string) (interface{}, error) {
+	ttl, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return nil, errMemoryNotInteger
+	}
+	entry := m.lookup(key)
+	if entry == nil {
+		return float64(0), nil
+	}
+	if ttl <= 0 {
+		delete(m.data, key)
+		return float64(1), nil
+	}
+	entry.expires = time.Now().Add(time.Duration(ttl) * time.Second)
+	return float64(1), nil
+}
+
+// ttl returns the seconds a key has left, -1 without a TTL and -2 for missing keys
+func (m *memoryRedis) ttl(key string) interface{} {
+	entry := m.lookup(key)
+	switch {
+	case entry == nil:
+		return float64(-2)
+	case entry.expires.IsZero():
+		return float64(-1)
+	}
+	return math.Round(time.Until(entry.expires).Seconds())
+}
+
+// hash returns the hash at key, nil when it does not exist unless create is set
+func (m *memoryRedis) hash(key string, create bool) (map[string]string, error) {
+	entry := m.lookup(key)
+	if entry == nil {
+		if !create {
+			return nil, nil
+		}
+		hash := make(map[string]string)
+		m.data[key] = &memoryEntry{value: hash}
+		return hash, nil
+	}
+	hash, ok := entry.value.(map[string]string)
+	if !ok {
+		return nil, errMemoryWrongType
+	}
+	return hash, nil
+}
+
+func (m *memoryRedis) hset(key string, pairs []string) (interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("ERR wrong number of arguments for 'hset' command")
+	}
+	hash, err := m.hash(key, true)
+	if err != nil {
+		return nil, err
+	}
+	added := 0
+	for i := 0; i < len(pairs); i += 2 {
+		if _, ok := hash[pairs[i]]; !ok {
+			added++
+		}
+		hash[pairs[i]] = pairs[i+1]
+	}
+	return float64(added), nil
+}
+
+// list returns the list at key, nil when it does not exist
+func (m *memoryRedis) list(key string) ([]string, error) {
+	entry := m.lookup(key)
+	if entry == nil {
+		return nil, nil
+	}
+	list, ok := entry.value.([]string)
+	if !ok {
+		return nil, errMemoryWrongType
+	}
+	return list, nil
+}
+
+func (m *memoryRedis) push(key string, values []string, head bool) (interface{}, error) {
+	list, err := m.list(key)
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		if head {
+			list = append([]string{value}, list...)
+		} else {
+			list = append(list, value)
+		}
+	}
+	if entry := m.lookup(key); entry != nil {
+		entry.value = list
+	} else {
+		m.data[key] = &memoryEntry{value: list}
+	}
+	return float64(len(list)), nil
+}
+
+// lpop handles LPOP key [count]: one element, or an array of up to count elements
+func (m *memoryRedis) lpop(key string, options []string) (interface{}, error) {
+	list, err := m.list(key)
+	if err != nil || list == nil {
+		return nil, err
+	}
+	count := 1
+	if len(options) > 0 {
+		if count, err = strconv.Atoi(options[0]); err != nil || count < 0 {
+			return nil, errMemoryNotInteger
+		}
+	}
+	if count > len(list) {
+		count = len(list)
+	}
+	popped := list[:count]
+	if rest := list[count:]; len(rest) > 0 {
+		m.data[key].value = append([]string(nil), rest...)
+	} else {
+		delete(m.data, key)
+	}
+
+	if len(options) == 0 {
+		return popped[0], nil
+	}
+	reply := make([]interface{}, len(popped))
+	for i, value := range popped {
+		reply[i] = value
+	}
+	return reply, nil
+}
+
+// lrem removes count occurrences of value: from the head when count is positive, from
+// the tail when negative, every one when zero
+func (m *memoryRedis) lrem(key string, countArg string, value string) (interface{}, error) {
+	count, err := strconv.Atoi(countArg)
+	if err != nil {
+		return nil, errMemoryNotInteger
+	}
+	list, err := m.list(key)
+	if err != nil || list == nil {
+		return float64(0), err
+	}
+
+	limit := count
+	if limit < 0 {
+		limit = -limit
+	}
+	remove := make(map[int]bool)
+	for i := range list {
+		index := i
+		if count < 0 {
+			index = len(list) - 1 - i
+		}
+		if list[index] == value {
+			remove[index] = true
+			if limit > 0 && len(remove) == limit {
+				break
+			}
+		}
+	}
+
+	kept := make([]string, 0, len(list)-len(remove))
+	for i, item := range list {
+		if !remove[i] {
+			kept = append(kept, item)
+		}
+	}
+	if len(kept) == 0 {
+		delete(m.data, key)
+	} else {
+		m.data[key].value = kept
+	}
+	return float64(len(remove)), nil
+}
+
+// memoryRange resolves LRANGE-style start and stop indexes, which may count from the
+// end, to bounds within a sequence of length n; start > stop selects nothing
+func memoryRange(startArg, stopArg string, n int) (int, int, error) {
+	start, err := strconv.Atoi(startArg)
+	if err != nil {
+		return 0, 0, errMemoryNotInteger
+	}
+	stop, err := strconv.Atoi(stopArg)
+	if err != nil {
+		return 0, 0, errMemoryNotInteger
+	}
+	if start < 0 {
+		start += n
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	return start, stop, nil
+}
+
+// set returns the set at key, nil when it does not exist unless create is set
+func (m *memoryRedis) set(key string, create bool) (map[string]struct{}, error) {
+	entry := m.lookup(key)
+	if entry == nil {
+		if !create {
+			return nil, nil
+		}
+		set := make(map[string]struct{})
+		m.data[key] = &memoryEntry{value: set}
+		return set, nil
+	}
+	set, ok := entry.value.(map[string]struct{})
+	if !ok {
+		return nil, errMemoryWrongType
+	}
+	return set, nil
+}
+
+// zset returns the sorted set at key, nil when it does not exist unless create is set
+func (m *memoryRedis) zset(key string, create bool) (map[string]float64, error) {
+	entry := m.lookup(key)
+	if entry == nil {
+		if !create {
+			return nil, nil
+		}
+		zset := make(map[string]float64)
+		m.data[key] = &memoryEntry{value: zset}
+		return zset, nil
+	}
+	zset, ok := entry.value.(map[string]float64)
+	if !ok {
+		return nil, errMemoryWrongType
+	}
+	return zset, nil
+}
+
+// zadd handles ZADD key [NX|XX] [GT|LT] [CH] score member [score member ...]
+func (m *memoryRedis) zadd(key string, args []string) (interface{}, error) {
+	flags := make(map[string]bool)
+	for len(args) > 0 {
+		flag := strings.ToUpper(args[0])
+		if flag != "NX" && flag != "XX" && flag != "GT" && flag != "LT" && flag != "CH" {
+			break
+		}
+		flags[flag] = true
+		args = args[1:]
+	}
+	nx, xx, gt, lt := flags["NX"], flags["XX"], flags["GT"], flags["LT"]
+	if len(args) == 0 || len(args)%2 != 0 {
+		return nil, errMemorySyntax
+	}
+	scores := make([]float64, len(args)/2)
+	for i := range scores {
+		score, err := parseScore(args[2*i])
+		if err != nil {
+			return nil, err
+		}
+		scores[i] = score
+	}
+
+	zset, err := m.zset(key, !xx)
+	if err != nil || zset == nil {
+		return float64(0), err
+	}
+	added, changed := 0, 0
+	for i, score := range scores {
+		member := args[2*i+1]
+		current, exists := zset[member]
+		switch {
+		case exists && nx, !exists && xx:
+			continue
+		case exists && ((gt && score <= current) || (lt && score >= current)):
+			continue
+		case !exists:
+			added++
+		case score != current:
+			changed++
+		}
+		zset[member] = score
+	}
+	m.dropIfEmpty(key, len(zset))
+	if flags["CH"] {
+		return float64(added + changed), nil
+	}
+	return float64(added), nil
+}
+
+// sortedMembers returns a sorted set's members by ascending score, ties by member
+func sortedMembers(zset map[string]float64) []string {
+	members := make([]string, 0, len(zset))
+	for member := range zset {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if zset[members[i]] != zset[members[j]] {
+			return zset[members[i]] < zset[members[j]]
+		}
+		return members[i] < members[j]
+	})
+	return members
+}
+
+func (m *memoryRedis) zrevrange(key, startArg, stopArg string, options []string) (interface{}, error) {
+	withScores := len(options) > 0 && strings.EqualFold(options[0], "WITHSCORES")
+	zset, err := m.zset(key, false)
+	if err != nil {
+		return nil, err
+	}
+	members := sortedMembers(zset)
+	for i, j := 0, len(members)-1; i < j; i, j = i+1, j-1 {
+		members[i], members[j] = members[j], members[i]
+	}
+	start, stop, err := memoryRange(startArg, stopArg, len(members))
+	if err != nil {
+		return nil, err
+	}
+	reply := make([]interface{}, 0)
+	for i := start; i <= stop; i++ {
+		reply = append(reply, members[i])
+		if withScores {
+			reply = append(reply, formatScore(zset[members[i]]))
+		}
+	}
+	return reply, nil
+}
+
+// zrangeByScore handles ZRANGEBYSCORE key min max [WITHSCORES] [LIMIT offset count]
+func (m *memoryRedis) zrangeByScore(key, min, max string, options []string) (interface{}, error) {
+	withScores := false
+	offset, count := 0, -1
+	for i := 0; i < len(options); i++ {
+		switch strings.ToUpper(options[i]) {
+		case "WITHSCORES":
+			withScores = true
+		case "LIMIT":
+			if i+2 >= len(options) {
+				return nil, errMemorySyntax
+			}
+			var err1, err2 error
+			offset, err1 = strconv.Atoi(options[i+1])
+			count, err2 = strconv.Atoi(options[i+2])
+			if err1 != nil || err2 != nil {
+				return nil, errMemoryNotInteger
+			}
+			i += 2
+		default:
+			return nil, errMemorySyntax
+		}
+	}
+
+	zset, err := m.zset(key, false)
+	if err != nil {
+		return nil, err
+	}
+	members, err := scoreRange(zset, min, max)
+	if err != nil {
+		return nil, err
+	}
+	if offset > len(members) || offset < 0 {
+		offset = len(members)
+	}
+	members = members[offset:]
+	if count >= 0 && count < len(members) {
+		members = members[:count]
+	}
+
+	reply := make([]interface{}, 0, len(members))
+	for _, member := range members {
+		reply = append(reply, member)
+		if withScores {
+			reply = append(reply, formatScore(zset[member]))
+		}
+	}
+	return reply, nil
+}
+
+// scoreRange returns the members scored between min and max in ascending order. Bounds
+// may be -inf or +inf and are exclusive when prefixed with "(".
+func scoreRange(zset map[string]float64, min, max string) ([]string, error) {
+	low, lowExclusive, err := parseScoreBound(min)
+	if err != nil {
+		return nil, err
+	}
+	high, highExclusive, err := parseScoreBound(max)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []string
+	for _, member := range sortedMembers(zset) {
+		score := zset[member]
+		if score < low || (lowExclusive && score == low) || score > high || (highExclusive && score == high) {
+			continue
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+func parseScoreBound(bound string) (float64, bool, error) {
+	exclusive := strings.HasPrefix(bound, "(")
+	score, err := parseScore(strings.TrimPrefix(bound, "("))
+	if err != nil {
+		return 0, false, errors.New("ERR min or max is not a float")
+	}
+	return score, exclusive, nil
+}
+
+func parseScore(score string) (float64, error) {
+	switch strings.ToLower(score) {
+	case "-inf":
+		return math.Inf(-1), nil
+	case "+inf", "inf":
+		return math.Inf(1), nil
+	}
+	value, err := strconv.ParseFloat(score, 64)
+	if err != nil || math.IsNaN(value) {
+		return 0, errMemoryNotFloat
+	}
+	return value, nil
+}
+
+// formatScore renders a score as Redis replies with it
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(score, 'f', -1, 64)
+}
+
+// scan handles SCAN cursor [MATCH pattern] [COUNT count], returning every matching key
+// in one page
+func (m *memoryRedis) scan(options []string) (interface{}, error) {
+	pattern := "*"
+	for i := 0; i+1 < len(options); i += 2 {
+		switch strings.ToUpper(options[i]) {
+		case "MATCH":
+			pattern = options[i+1]
+		case "COUNT":
+		default:
+			return nil, errMemorySyntax
+		}
+	}
+
+	var keys []string
+	for key := range m.data {
+		if m.lookup(key) != nil && globMatch(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	reply := make([]interface{}, len(keys))
+	for i, key := range keys {
+		reply[i] = key
+	}
+	return []interface{}{"0", reply}, nil
+}
+
+// globMatch reports whether s matches a Redis glob pattern: * and ? wildcards, [...]
+// character classes (with ^ negation and a-z ranges) and \ escapes
+func globMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if globMatch(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			end := strings.IndexByte(pattern[1:], ']')
+			if end < 0 {
+				// An unclosed class matches the bracket literally
+				if s[0] != '[' {
+					return false
+				}
+				pattern, s = pattern[1:], s[1:]
+				continue
+			}
+			class := pattern[1 : end+1]
+			negated := strings.HasPrefix(class, "^")
+			if negated {
+				class = class[1:]
+			}
+			matched := false
+			for i := 0; i < len(class); i++ {
+				if i+2 < len(class) && class[i+1] == '-' {
+					if class[i] <= s[0] && s[0] <= class[i+2] {
+						matched = true
+					}
+					i += 2
+				} else if class[i] == s[0] {
+					matched = true
+				}
+			}
+			if matched == negated {
+				return false
+			}
+			pattern, s = pattern[end+2:], s[1:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		}
+	}
+	return len(s) == 0
+}
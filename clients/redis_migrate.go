@@ -0,0 +1,95 @@
+package clients
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// MigrateKeyPrefix renames every key under the from prefix to the client's own prefix,
+// so data written before REDIS_KEY_PREFIX changed stays reachable. Keys already under the
+// target prefix or any of the excluded prefixes are left alone, and RENAMENX never
+// overwrites a key that exists under the new name. TTLs move with the keys. The old
+// RediSearch index is dropped without its documents; the next search rebuilds it under
+// the new name from the renamed hashes.
+func (r *RedisClient) MigrateKeyPrefix(from string, exclude []string, dryRun bool) (*models.KeyMigrationReport, error) {
+	report := &models.KeyMigrationReport{From: from, To: r.prefix, DryRun: dryRun}
+	if from == r.prefix {
+		return report, fmt.Errorf("keys already use prefix %q", from)
+	}
+
+	cursor := "0"
+	for {
+		resp, err := r.send(RedisCommand{"SCAN", cursor, "MATCH", from + "*", "COUNT", 1000})
+		if err != nil {
+			return report, fmt.Errorf("failed to scan keys: %w", err)
+		}
+
+		reply, ok := resp.Result.([]interface{})
+		if !ok || len(reply) != 2 {
+			return report, fmt.Errorf("invalid scan response format")
+		}
+		cursor, _ = reply[0].(string)
+		items, _ := reply[1].([]interface{})
+		for _, item := range items {
+			key, ok := item.(string)
+			if !ok {
+				continue
+			}
+			report.Scanned++
+
+			if skipKeyMigration(key, from, r.prefix, exclude) {
+				report.Skipped++
+				continue
+			}
+			if dryRun {
+				report.Renamed++
+				continue
+			}
+
+			target := r.prefix + strings.TrimPrefix(key, from)
+			renamed, err := r.send(RedisCommand{"RENAMENX", key, target})
+			if err != nil {
+				return report, fmt.Errorf("failed to rename %s: %w", key, err)
+			}
+			if n, _ := renamed.Result.(float64); n == 1 {
+				report.Renamed++
+			} else {
+				report.Conflicts = append(report.Conflicts, key)
+			}
+		}
+
+		if cursor == "0" || cursor == "" {
+			break
+		}
+	}
+
+	if !dryRun {
+		_, err := r.send(RedisCommand{"FT.DROPINDEX", from + searchIndexName})
+		if err != nil && !isUnknownIndex(err) {
+			fmt.Printf("Warning: failed to drop search index %s%s: %v\n", from, searchIndexName, err)
+		}
+	}
+	return report, nil
+}
+
+// skipKeyMigration reports whether a key matching the from prefix must stay where it is
+func skipKeyMigration(key, from, to string, exclude []string) bool {
+	// Moving to a longer prefix that extends from ("" to "staging:"): the key already moved
+	if len(to) > len(from) && strings.HasPrefix(key, to) {
+		return true
+	}
+	for _, prefix := range exclude {
+		if prefix != "" && strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnknownIndex reports whether a RediSearch error means the index does not exist
+func isUnknownIndex(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "unknown index") || strings.Contains(message, "no such index")
+}
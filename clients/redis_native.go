@@ -0,0 +1,112 @@
+//go:build !lite
+
+package clients
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// nativeRedis is the RESP client; lite builds leave it out
+type nativeRedis = redis.Client
+
+// newNativeRedis connects to self-hosted Redis over RESP. Timeouts and the pool size come
+// from the REDIS_* client settings shared with the REST client; retries are left to
+// sendNative, which knows which commands are safe to resend. RESP2 is used so replies
+// have the same shape as the Upstash REST API's.
+func newNativeRedis(addr, password string) *redis.Client {
+	settings := config.AppConfig.RedisClient
+	options := &redis.Options{
+		Addr:         addr,
+		Username:     config.AppConfig.RedisUsername,
+		Password:     password,
+		DB:           config.AppConfig.RedisDB,
+		Protocol:     2,
+		DialTimeout:  settings.Timeout,
+		ReadTimeout:  settings.Timeout,
+		WriteTimeout: settings.Timeout,
+		MaxRetries:   -1, // go-redis retries every command, so sendNative does it instead
+	}
+	if settings.Concurrency > 0 {
+		options.PoolSize = settings.Concurrency
+	}
+	if config.AppConfig.RedisTLS {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		options.TLSConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	}
+	return redis.NewClient(options)
+}
+
+// sendNative runs a command over RESP and returns the reply as the REST API would.
+// Connection failures and timeouts are retried up to REDIS_MAX_RETRIES times with
+// backoff, but only for commands that are safe to run twice (see retryable); errors
+// from Redis itself are not retried.
+func (r *RedisClient) sendNative(cmd RedisCommand) (*RedisResponse, error) {
+	maxRetries := 0
+	if retryable(cmd) {
+		maxRetries = config.AppConfig.RedisClient.MaxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(requestContext(r.ctx), retryBackoff(attempt)); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := injectFault(FaultRedis); err != nil {
+			lastErr = err
+			continue
+		}
+
+		result, err := r.doNative(cmd)
+		if errors.Is(err, redis.Nil) {
+			return &RedisResponse{}, nil
+		}
+		if err != nil {
+			var redisErr redis.Error
+			if errors.As(err, &redisErr) {
+				return nil, fmt.Errorf("Redis error: %s", redisErr.Error())
+			}
+			lastErr = fmt.Errorf("Redis request failed: %w", err)
+			continue
+		}
+		return &RedisResponse{Result: restReply(result)}, nil
+	}
+	return nil, lastErr
+}
+
+// doNative sends one attempt of a command, bounded by REDIS_TIMEOUT_SECONDS
+func (r *RedisClient) doNative(cmd RedisCommand) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(requestContext(r.ctx), config.AppConfig.RedisClient.Timeout)
+	defer cancel()
+	return r.native.Do(ctx, cmd...).Result()
+}
+
+// restReply converts a RESP reply to its JSON-decoded REST form: integers become
+// float64 and arrays are converted element by element
+func restReply(reply interface{}) interface{} {
+	switch v := reply.(type) {
+	case int64:
+		return float64(v)
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, item := range v {
+			converted[i] = restReply(item)
+		}
+		return converted
+	default:
+		return v
+	}
+}
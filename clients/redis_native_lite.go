@@ -0,0 +1,23 @@
+//go:build lite
+
+package clients
+
+import "errors"
+
+var errNativeRedisUnavailable = errors.New("native Redis is not available in lite builds")
+
+// nativeRedis stands in for the RESP client, which lite builds leave out. The lite
+// configuration rejects REDIS_ADDR, so none is ever created.
+type nativeRedis struct{}
+
+func newNativeRedis(addr, password string) *nativeRedis {
+	panic(errNativeRedisUnavailable)
+}
+
+func (n *nativeRedis) Close() error {
+	return nil
+}
+
+func (r *RedisClient) sendNative(cmd RedisCommand) (*RedisResponse, error) {
+	return nil, errNativeRedisUnavailable
+}
@@ -0,0 +1,127 @@
+package clients
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Where each command keeps its keys, so REDIS_KEY_PREFIX can be applied to them
+const (
+	keysNone  = iota // no keys, e.g. PING
+	keysFirst        // the first argument is the only key
+	keysAll          // every argument is a key
+)
+
+var redisCommandKeys = map[string]int{
+	"PING": keysNone,
+
+	"GET": keysFirst, "SET": keysFirst, "SETEX": keysFirst, "INCR": keysFirst, "INCRBY": keysFirst,
+	"EXPIRE": keysFirst, "TTL": keysFirst,
+	"HSET": keysFirst, "HGET": keysFirst, "HDEL": keysFirst, "HGETALL": keysFirst, "HLEN": keysFirst,
+	"LPUSH": keysFirst, "RPUSH": keysFirst, "LPOP": keysFirst, "LRANGE": keysFirst, "LTRIM": keysFirst, "LLEN": keysFirst,
+	"LSET": keysFirst, "LREM": keysFirst,
+	"SADD": keysFirst, "SREM": keysFirst, "SMEMBERS": keysFirst, "SCARD": keysFirst,
+	"ZADD": keysFirst, "ZREM": keysFirst, "ZCARD": keysFirst, "ZINCRBY": keysFirst, "ZREVRANGE": keysFirst,
+	"ZRANGEBYSCORE": keysFirst, "ZREMRANGEBYSCORE": keysFirst,
+
+	"DEL": keysAll, "EXISTS": keysAll, "MGET": keysAll, "RENAMENX": keysAll,
+}
+
+// prefixCommand returns a copy of cmd with the client's key prefix applied to every key,
+// including SCAN patterns and the RediSearch index and the hashes it covers. Commands
+// the client does not know are rejected rather than sent outside the namespace.
+func (r *RedisClient) prefixCommand(cmd RedisCommand) (RedisCommand, error) {
+	if r.prefix == "" || len(cmd) == 0 {
+		return cmd, nil
+	}
+
+	name := commandName(cmd)
+	prefixed := make(RedisCommand, len(cmd))
+	copy(prefixed, cmd)
+
+	switch name {
+	case "SCAN":
+		for i := 1; i+1 < len(prefixed); i++ {
+			if option, _ := prefixed[i].(string); strings.EqualFold(option, "MATCH") {
+				prefixed[i+1] = r.prefixKey(prefixed[i+1])
+			}
+		}
+		return prefixed, nil
+	case "FT.CREATE":
+		// FT.CREATE index ... PREFIX count prefix... SCHEMA ...
+		prefixed[1] = r.prefixKey(prefixed[1])
+		for i := 2; i+1 < len(prefixed); i++ {
+			if option, _ := prefixed[i].(string); strings.EqualFold(option, "PREFIX") {
+				count, _ := prefixed[i+1].(int)
+				for j := i + 2; j < i+2+count && j < len(prefixed); j++ {
+					prefixed[j] = r.prefixKey(prefixed[j])
+				}
+				break
+			}
+		}
+		return prefixed, nil
+	case "FT.SEARCH", "FT.DROPINDEX":
+		prefixed[1] = r.prefixKey(prefixed[1])
+		return prefixed, nil
+	}
+
+	positions, ok := redisCommandKeys[name]
+	if !ok {
+		return nil, fmt.Errorf("Redis command %s has no known key positions for REDIS_KEY_PREFIX", name)
+	}
+	switch positions {
+	case keysFirst:
+		if len(prefixed) > 1 {
+			prefixed[1] = r.prefixKey(prefixed[1])
+		}
+	case keysAll:
+		for i := 1; i < len(prefixed); i++ {
+			prefixed[i] = r.prefixKey(prefixed[i])
+		}
+	}
+	return prefixed, nil
+}
+
+func (r *RedisClient) prefixKey(key interface{}) interface{} {
+	return r.prefix + fmt.Sprint(key)
+}
+
+// unprefix strips the key prefix from a key returned by Redis, such as a SCAN or FT.SEARCH result
+func (r *RedisClient) unprefix(key string) string {
+	return strings.TrimPrefix(key, r.prefix)
+}
+
+// Commands whose effect or reply changes when they run twice
+var redisNonIdempotent = map[string]bool{
+	"INCR": true, "INCRBY": true, "ZINCRBY": true,
+	"LPUSH": true, "RPUSH": true, "LPOP": true, "LREM": true,
+	"RENAMENX": true,
+}
+
+// retryable reports whether cmd may be sent again after an attempt that failed. A
+// command that timed out or lost its reply may still have run, so counters, list
+// pushes and pops, and conditional writes such as SET NX are sent only once.
+func retryable(cmd RedisCommand) bool {
+	name := commandName(cmd)
+	if redisNonIdempotent[name] {
+		return false
+	}
+	if name == "SET" || name == "ZADD" {
+		for _, arg := range cmd[1:] {
+			option, _ := arg.(string)
+			if strings.EqualFold(option, "NX") || strings.EqualFold(option, "INCR") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// commandName returns the upper-cased name of a command, such as "SET"
+func commandName(cmd RedisCommand) string {
+	if len(cmd) == 0 {
+		return ""
+	}
+	name, _ := cmd[0].(string)
+	return strings.ToUpper(name)
+}
@@ -0,0 +1,36 @@
+package clients
+
+import (
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// SaveRedaction stores a previewed redaction until it is confirmed or ttlSeconds pass
+func (r *RedisClient) SaveRedaction(redaction *models.PendingRedaction, ttlSeconds int64) error {
+	if err := r.setJSON(fmt.Sprintf("redaction:%s", redaction.ID), redaction, ttlSeconds); err != nil {
+		return fmt.Errorf("failed to save redaction: %w", err)
+	}
+	return nil
+}
+
+// GetRedaction returns a previewed redaction, or nil if it expired or never existed
+func (r *RedisClient) GetRedaction(redactionID string) (*models.PendingRedaction, error) {
+	var redaction models.PendingRedaction
+	found, err := r.getJSON(fmt.Sprintf("redaction:%s", redactionID), &redaction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get redaction: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &redaction, nil
+}
+
+// DeleteRedaction removes a previewed redaction once it has been confirmed
+func (r *RedisClient) DeleteRedaction(redactionID string) error {
+	if _, err := r.DeleteKeys(fmt.Sprintf("redaction:%s", redactionID)); err != nil {
+		return fmt.Errorf("failed to delete redaction: %w", err)
+	}
+	return nil
+}
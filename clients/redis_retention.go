@@ -0,0 +1,71 @@
+package clients
+
+import (
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// retentionPoliciesKey indexes the tenants that have a policy
+const retentionPoliciesKey = "retention_policies"
+
+// SaveRetentionPolicy stores a tenant's retention policy
+func (r *RedisClient) SaveRetentionPolicy(policy *models.RetentionPolicy) error {
+	if err := r.setJSON(fmt.Sprintf("retention_policy:%s", policy.TenantID), policy, 0); err != nil {
+		return fmt.Errorf("failed to save retention policy: %w", err)
+	}
+
+	if _, err := r.executeCommand(RedisCommand{"SADD", retentionPoliciesKey, policy.TenantID}); err != nil {
+		return fmt.Errorf("failed to index retention policy: %w", err)
+	}
+
+	return nil
+}
+
+// GetRetentionPolicy returns a tenant's retention policy, or nil if none is set
+func (r *RedisClient) GetRetentionPolicy(tenantID string) (*models.RetentionPolicy, error) {
+	var policy models.RetentionPolicy
+	found, err := r.getJSON(fmt.Sprintf("retention_policy:%s", tenantID), &policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retention policy: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &policy, nil
+}
+
+// ListRetentionPolicies returns every stored retention policy
+func (r *RedisClient) ListRetentionPolicies() ([]models.RetentionPolicy, error) {
+	tenants, err := r.getSetMembers(retentionPoliciesKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list retention policies: %w", err)
+	}
+
+	policies := make([]models.RetentionPolicy, 0, len(tenants))
+	for _, tenantID := range tenants {
+		policy, err := r.GetRetentionPolicy(tenantID)
+		if err != nil {
+			return nil, err
+		}
+		if policy != nil {
+			policies = append(policies, *policy)
+		}
+	}
+
+	return policies, nil
+}
+
+// DeleteRetentionPolicy removes a tenant's retention policy
+func (r *RedisClient) DeleteRetentionPolicy(tenantID string) error {
+	if _, err := r.executeCommand(RedisCommand{"DEL", fmt.Sprintf("retention_policy:%s", tenantID)}); err != nil {
+		return fmt.Errorf("failed to delete retention policy: %w", err)
+	}
+
+	if _, err := r.executeCommand(RedisCommand{"SREM", retentionPoliciesKey, tenantID}); err != nil {
+		return fmt.Errorf("failed to unindex retention policy: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package clients
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// retrievalsKey maps a user's memory IDs to when a query last returned them
+func retrievalsKey(userID string) string {
+	return fmt.Sprintf("memory_retrievals:%s", userID)
+}
+
+// RecordRetrievals stamps memories returned by a query with the retrieval time
+func (r *RedisClient) RecordRetrievals(userID string, memoryIDs []string, at int64) error {
+	if len(memoryIDs) == 0 {
+		return nil
+	}
+
+	cmd := RedisCommand{"HSET", retrievalsKey(userID)}
+	for _, id := range memoryIDs {
+		cmd = append(cmd, id, at)
+	}
+	if _, err := r.executeCommand(cmd); err != nil {
+		return fmt.Errorf("failed to record memory retrievals: %w", err)
+	}
+	return nil
+}
+
+// GetRetrievals returns when each of a user's retrieved memories was last returned by a query
+func (r *RedisClient) GetRetrievals(userID string) (map[string]int64, error) {
+	resp, err := r.executeCommand(RedisCommand{"HGETALL", retrievalsKey(userID)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get memory retrievals: %w", err)
+	}
+
+	// Upstash returns hashes as a flat field, value, field, value... array
+	items, _ := resp.Result.([]interface{})
+	retrievals := make(map[string]int64, len(items)/2)
+	for i := 0; i+1 < len(items); i += 2 {
+		id, _ := items[i].(string)
+		value, _ := items[i+1].(string)
+		if at, err := strconv.ParseInt(value, 10, 64); err == nil && id != "" {
+			retrievals[id] = at
+		}
+	}
+	return retrievals, nil
+}
+
+// ForgetRetrievals drops the retrieval records of deleted memories
+func (r *RedisClient) ForgetRetrievals(userID string, memoryIDs []string) error {
+	if len(memoryIDs) == 0 {
+		return nil
+	}
+
+	cmd := RedisCommand{"HDEL", retrievalsKey(userID)}
+	for _, id := range memoryIDs {
+		cmd = append(cmd, id)
+	}
+	if _, err := r.executeCommand(cmd); err != nil {
+		return fmt.Errorf("failed to forget memory retrievals: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package clients
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// cleanupSchedulesKey indexes the IDs of stored cleanup schedules
+const cleanupSchedulesKey = "cleanup_schedules"
+
+// cleanupScheduleTargetKey maps a tenant and callback URL to the schedule serving it
+func cleanupScheduleTargetKey(tenantID string, callbackURL string) string {
+	return fmt.Sprintf("cleanup_schedule_target:%s:%s", tenantID, callbackURL)
+}
+
+// ReserveCleanupScheduleTarget claims a tenant and callback URL for a new schedule.
+// It returns the ID already holding the claim when there is one.
+func (r *RedisClient) ReserveCleanupScheduleTarget(tenantID string, callbackURL string, placeholder string) (string, error) {
+	key := cleanupScheduleTargetKey(tenantID, callbackURL)
+	reserved, err := r.SetIfAbsent(key, placeholder, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to reserve cleanup schedule: %w", err)
+	}
+	if reserved {
+		return "", nil
+	}
+
+	resp, err := r.executeCommand(RedisCommand{"GET", key})
+	if err != nil {
+		return "", fmt.Errorf("failed to get existing cleanup schedule: %w", err)
+	}
+	existing, _ := resp.Result.(string)
+	return existing, nil
+}
+
+// ReleaseCleanupScheduleTarget drops a tenant and callback URL claim
+func (r *RedisClient) ReleaseCleanupScheduleTarget(tenantID string, callbackURL string) error {
+	if _, err := r.DeleteKeys(cleanupScheduleTargetKey(tenantID, callbackURL)); err != nil {
+		return fmt.Errorf("failed to release cleanup schedule: %w", err)
+	}
+	return nil
+}
+
+// SaveCleanupSchedule stores schedule metadata and points its target at it
+func (r *RedisClient) SaveCleanupSchedule(schedule *models.CleanupSchedule) error {
+	if err := r.setJSON(fmt.Sprintf("cleanup_schedule:%s", schedule.ScheduleID), schedule, 0); err != nil {
+		return fmt.Errorf("failed to save cleanup schedule: %w", err)
+	}
+
+	key := cleanupScheduleTargetKey(schedule.TenantID, schedule.CallbackURL)
+	if _, err := r.executeCommand(RedisCommand{"SET", key, schedule.ScheduleID}); err != nil {
+		return fmt.Errorf("failed to save cleanup schedule target: %w", err)
+	}
+
+	if _, err := r.executeCommand(RedisCommand{"SADD", cleanupSchedulesKey, schedule.ScheduleID}); err != nil {
+		return fmt.Errorf("failed to index cleanup schedule: %w", err)
+	}
+
+	return nil
+}
+
+// GetCleanupSchedule returns schedule metadata, or nil if the schedule is unknown
+func (r *RedisClient) GetCleanupSchedule(scheduleID string) (*models.CleanupSchedule, error) {
+	var schedule models.CleanupSchedule
+	found, err := r.getJSON(fmt.Sprintf("cleanup_schedule:%s", scheduleID), &schedule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cleanup schedule: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &schedule, nil
+}
+
+// ListCleanupSchedules returns the metadata of every stored cleanup schedule
+func (r *RedisClient) ListCleanupSchedules() ([]models.CleanupSchedule, error) {
+	ids, err := r.getSetMembers(cleanupSchedulesKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cleanup schedules: %w", err)
+	}
+
+	schedules := make([]models.CleanupSchedule, 0, len(ids))
+	for _, id := range ids {
+		schedule, err := r.GetCleanupSchedule(id)
+		if err != nil {
+			return nil, err
+		}
+		if schedule != nil {
+			schedules = append(schedules, *schedule)
+		}
+	}
+
+	return schedules, nil
+}
+
+// DeleteCleanupSchedule removes schedule metadata and frees its target
+func (r *RedisClient) DeleteCleanupSchedule(schedule *models.CleanupSchedule) error {
+	keys := []string{
+		fmt.Sprintf("cleanup_schedule:%s", schedule.ScheduleID),
+		cleanupScheduleTargetKey(schedule.TenantID, schedule.CallbackURL),
+	}
+	if _, err := r.DeleteKeys(keys...); err != nil {
+		return fmt.Errorf("failed to delete cleanup schedule: %w", err)
+	}
+
+	if _, err := r.executeCommand(RedisCommand{"SREM", cleanupSchedulesKey, schedule.ScheduleID}); err != nil {
+		return fmt.Errorf("failed to unindex cleanup schedule: %w", err)
+	}
+
+	return nil
+}
+
+// scheduledRunTTLSeconds is how long a claimed scheduled run is remembered, well past
+// the clock skew between replicas
+const scheduledRunTTLSeconds = 3600
+
+// ClaimScheduledRun claims the run of a task the local scheduler fires at a given time.
+// Every replica fires the same schedule; only the first to claim a run queues it.
+func (r *RedisClient) ClaimScheduledRun(taskType string, at time.Time) (bool, error) {
+	key := fmt.Sprintf("scheduled_run:%s:%d", taskType, at.Unix())
+	claimed, err := r.SetIfAbsent(key, time.Now().UTC().Format(time.RFC3339), scheduledRunTTLSeconds)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim scheduled %s: %w", taskType, err)
+	}
+	return claimed, nil
+}
@@ -0,0 +1,234 @@
+package clients
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// searchIndexName is the RediSearch index over memory hashes
+const searchIndexName = "idx:memories"
+
+// Search modes supported by FT.SEARCH queries
+const (
+	SearchModeKeyword = "keyword"
+	SearchModePrefix  = "prefix"
+	SearchModeFuzzy   = "fuzzy"
+)
+
+var (
+	searchIndexMu    sync.Mutex
+	searchIndexReady = make(map[string]bool) // keyed by Redis URL and key prefix, one index per namespace
+)
+
+// EnsureSearchIndex creates the RediSearch index if it does not exist yet
+func (r *RedisClient) EnsureSearchIndex() error {
+	searchIndexMu.Lock()
+	defer searchIndexMu.Unlock()
+
+	if searchIndexReady[r.url+" "+r.prefix] {
+		return nil
+	}
+
+	cmd := RedisCommand{
+		"FT.CREATE", searchIndexName, "ON", "HASH", "PREFIX", 1, "memory:",
+		"SCHEMA",
+		"user_id", "TAG",
+		"session_id", "TAG",
+		"role", "TAG",
+		"content", "TEXT",
+		"timestamp", "NUMERIC", "SORTABLE",
+	}
+
+	_, err := r.executeCommand(cmd)
+	if err != nil && !strings.Contains(strings.ToLower(err.Error()), "index already exists") {
+		return fmt.Errorf("failed to create search index: %w", err)
+	}
+
+	searchIndexReady[r.url+" "+r.prefix] = true
+	return nil
+}
+
+// IndexMemory stores a memory as a hash so RediSearch can index its content
+func (r *RedisClient) IndexMemory(memory *models.MemoryEntry) error {
+	if err := r.EnsureSearchIndex(); err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("memory:%s", memory.ID)
+	sessionID, _ := memory.Metadata["session_id"].(string)
+	role, _ := memory.Metadata["role"].(string)
+
+	cmd := RedisCommand{
+		"HSET", key,
+		"user_id", memory.UserID,
+		"session_id", sessionID,
+		"role", role,
+		"content", memory.Content,
+		"timestamp", memory.Timestamp.Unix(),
+	}
+	if _, err := r.executeCommand(cmd); err != nil {
+		return fmt.Errorf("failed to index memory: %w", err)
+	}
+
+	_, err := r.executeCommand(RedisCommand{"EXPIRE", key, memory.TTL})
+	return err
+}
+
+// SearchMemories runs a full-text search over a user's indexed memories
+func (r *RedisClient) SearchMemories(userID string, text string, mode string, limit int) ([]models.MemoryResult, error) {
+	if err := r.EnsureSearchIndex(); err != nil {
+		return nil, err
+	}
+
+	terms := tokenize(text)
+	if len(terms) == 0 {
+		return []models.MemoryResult{}, nil
+	}
+
+	for i, term := range terms {
+		switch mode {
+		case SearchModePrefix:
+			terms[i] = term + "*"
+		case SearchModeFuzzy:
+			terms[i] = "%" + term + "%"
+		}
+	}
+
+	query := fmt.Sprintf("@user_id:{%s} @content:(%s)", escapeTag(userID), strings.Join(terms, " "))
+	cmd := RedisCommand{"FT.SEARCH", searchIndexName, query, "WITHSCORES", "LIMIT", 0, limit}
+
+	resp, err := r.executeCommand(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search memories: %w", err)
+	}
+
+	return parseSearchResults(resp.Result, r.unprefix)
+}
+
+// DeleteIndexedMemory removes a memory hash from the search index
+func (r *RedisClient) DeleteIndexedMemory(memoryID string) error {
+	_, err := r.executeCommand(RedisCommand{"DEL", fmt.Sprintf("memory:%s", memoryID)})
+	if err != nil {
+		return fmt.Errorf("failed to delete indexed memory: %w", err)
+	}
+	return nil
+}
+
+// searchPageSize is how many keys one FT.SEARCH page or DEL covers when listing or
+// deleting all of a user's indexed memories
+const searchPageSize = 1000
+
+// DeleteUserIndexedMemories removes every indexed memory hash belonging to a user
+func (r *RedisClient) DeleteUserIndexedMemories(userID string) error {
+	keys, err := r.ListUserIndexedKeys(userID)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(keys); start += searchPageSize {
+		end := start + searchPageSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		if _, err := r.DeleteKeys(keys[start:end]...); err != nil {
+			return fmt.Errorf("failed to delete indexed memories: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListUserIndexedKeys returns the keys of every indexed memory hash belonging to a user,
+// paging through the search results until they run out
+func (r *RedisClient) ListUserIndexedKeys(userID string) ([]string, error) {
+	if err := r.EnsureSearchIndex(); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("@user_id:{%s}", escapeTag(userID))
+	var keys []string
+	for offset := 0; ; offset += searchPageSize {
+		resp, err := r.executeCommand(RedisCommand{"FT.SEARCH", searchIndexName, query, "NOCONTENT", "LIMIT", offset, searchPageSize})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find indexed memories: %w", err)
+		}
+
+		// [total, key, key, ...]
+		items, ok := resp.Result.([]interface{})
+		if !ok || len(items) < 2 {
+			return keys, nil
+		}
+		for _, item := range items[1:] {
+			if key, ok := item.(string); ok {
+				keys = append(keys, r.unprefix(key))
+			}
+		}
+		if len(items)-1 < searchPageSize {
+			return keys, nil
+		}
+	}
+}
+
+// parseSearchResults converts a WITHSCORES FT.SEARCH reply: [total, key, score, [field, value, ...], ...].
+// unprefix strips the key namespace from each returned key.
+func parseSearchResults(result interface{}, unprefix func(string) string) ([]models.MemoryResult, error) {
+	items, ok := result.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid search response format")
+	}
+
+	results := make([]models.MemoryResult, 0, len(items)/3)
+	for i := 1; i+2 < len(items); i += 3 {
+		key, _ := items[i].(string)
+		fields, _ := items[i+2].([]interface{})
+
+		memory := models.MemoryResult{
+			ID:       strings.TrimPrefix(unprefix(key), "memory:"),
+			Metadata: map[string]interface{}{"storage": "search_index"},
+		}
+
+		switch score := items[i+1].(type) {
+		case string:
+			memory.Score, _ = strconv.ParseFloat(score, 64)
+		case float64:
+			memory.Score = score
+		}
+
+		for j := 0; j+1 < len(fields); j += 2 {
+			name, _ := fields[j].(string)
+			value, _ := fields[j+1].(string)
+
+			switch name {
+			case "content":
+				memory.Content = value
+			case "timestamp":
+				if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+					memory.Timestamp = time.Unix(ts, 0)
+				}
+			default:
+				memory.Metadata[name] = value
+			}
+		}
+		memory.Metadata["id"] = memory.ID
+
+		results = append(results, memory)
+	}
+
+	return results, nil
+}
+
+// escapeTag escapes punctuation so a value can be used inside a TAG query
+func escapeTag(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
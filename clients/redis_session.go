@@ -0,0 +1,170 @@
+package clients
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// sessionSummaryKey holds the rolling summary of a session
+func sessionSummaryKey(sessionID string) string {
+	return fmt.Sprintf("session_summary:%s", sessionID)
+}
+
+// SaveSessionSummary stores a session's rolling summary, expiring with the session
+func (r *RedisClient) SaveSessionSummary(sessionID string, summary *models.RollingSummary) error {
+	if err := r.setJSON(sessionSummaryKey(sessionID), summary, 86400); err != nil {
+		return fmt.Errorf("failed to save session summary: %w", err)
+	}
+	return nil
+}
+
+// GetSessionSummary returns a session's rolling summary, or nil if there is none
+func (r *RedisClient) GetSessionSummary(sessionID string) (*models.RollingSummary, error) {
+	var summary models.RollingSummary
+	found, err := r.getJSON(sessionSummaryKey(sessionID), &summary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session summary: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &summary, nil
+}
+
+// sessionMessagesKey holds a session's messages, oldest first, capped at SESSION_MAX_MESSAGES
+func sessionMessagesKey(sessionID string) string {
+	return fmt.Sprintf("session_messages:%s", sessionID)
+}
+
+// AppendSessionMessage adds a message to a session and saves it. The message is pushed
+// onto the session's message list, so only the session header is rewritten; the oldest
+// messages are trimmed once the list outgrows SESSION_MAX_MESSAGES.
+func (r *RedisClient) AppendSessionMessage(session *models.SessionData, message models.Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	key := sessionMessagesKey(session.SessionID)
+	resp, err := r.executeCommand(RedisCommand{"RPUSH", key, string(data)})
+	if err != nil {
+		return fmt.Errorf("failed to append session message: %w", err)
+	}
+	length, _ := resp.Result.(float64)
+	session.Messages = append(session.Messages, message)
+
+	// A session saved before messages had their own list moves them there now
+	if int(length) == 1 && len(session.Messages) > 1 {
+		return r.SaveSession(session)
+	}
+
+	if excess := int(length) - config.AppConfig.SessionMaxMessages; excess > 0 {
+		if _, err := r.executeCommand(RedisCommand{"LTRIM", key, -config.AppConfig.SessionMaxMessages, -1}); err != nil {
+			return fmt.Errorf("failed to trim session messages: %w", err)
+		}
+		session.TrimmedMessages += excess
+		if excess > len(session.Messages) {
+			excess = len(session.Messages)
+		}
+		session.Messages = session.Messages[excess:]
+	}
+	return r.saveSessionHeader(session)
+}
+
+// replaceSessionMessages rewrites a session's message list, trimming the oldest messages
+// beyond SESSION_MAX_MESSAGES
+func (r *RedisClient) replaceSessionMessages(session *models.SessionData) error {
+	if excess := len(session.Messages) - config.AppConfig.SessionMaxMessages; excess > 0 {
+		session.TrimmedMessages += excess
+		session.Messages = session.Messages[excess:]
+	}
+
+	key := sessionMessagesKey(session.SessionID)
+	if _, err := r.executeCommand(RedisCommand{"DEL", key}); err != nil {
+		return fmt.Errorf("failed to replace session messages: %w", err)
+	}
+	if len(session.Messages) == 0 {
+		return nil
+	}
+
+	cmd := RedisCommand{"RPUSH", key}
+	for _, message := range session.Messages {
+		data, err := json.Marshal(message)
+		if err != nil {
+			return fmt.Errorf("failed to marshal message: %w", err)
+		}
+		cmd = append(cmd, string(data))
+	}
+	if _, err := r.executeCommand(cmd); err != nil {
+		return fmt.Errorf("failed to save session messages: %w", err)
+	}
+	return nil
+}
+
+// getSessionMessages returns a session's stored messages, oldest first
+func (r *RedisClient) getSessionMessages(sessionID string) ([]models.Message, error) {
+	resp, err := r.executeCommand(RedisCommand{"LRANGE", sessionMessagesKey(sessionID), 0, -1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session messages: %w", err)
+	}
+
+	items, _ := resp.Result.([]interface{})
+	messages := make([]models.Message, 0, len(items))
+	for _, item := range items {
+		data, ok := item.(string)
+		if !ok {
+			continue
+		}
+		var message models.Message
+		if err := json.Unmarshal([]byte(data), &message); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal session message: %w", err)
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// ClaimSessionCondensing reports whether this instance may condense a session now, so that
+// concurrent saves do not fold the same messages into its summary twice
+func (r *RedisClient) ClaimSessionCondensing(sessionID string, ttlSeconds int64) (bool, error) {
+	claimed, err := r.SetIfAbsent(fmt.Sprintf("session_condensing:%s", sessionID), "1", ttlSeconds)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim session condensing: %w", err)
+	}
+	return claimed, nil
+}
+
+// ReleaseSessionCondensing lets the next save condense the session again
+func (r *RedisClient) ReleaseSessionCondensing(sessionID string) error {
+	_, err := r.DeleteKeys(fmt.Sprintf("session_condensing:%s", sessionID))
+	return err
+}
+
+// DropOldestSessionMessages removes a session's first count messages and saves it
+func (r *RedisClient) DropOldestSessionMessages(session *models.SessionData, count int) error {
+	if count > len(session.Messages) {
+		count = len(session.Messages)
+	}
+
+	key := sessionMessagesKey(session.SessionID)
+	resp, err := r.executeCommand(RedisCommand{"LLEN", key})
+	if err != nil {
+		return fmt.Errorf("failed to count session messages: %w", err)
+	}
+	session.Messages = session.Messages[count:]
+	session.TrimmedMessages += count
+
+	// A session saved before messages had their own list moves them there now
+	if length, _ := resp.Result.(float64); length == 0 {
+		return r.SaveSession(session)
+	}
+	if count > 0 {
+		if _, err := r.executeCommand(RedisCommand{"LTRIM", key, count, -1}); err != nil {
+			return fmt.Errorf("failed to trim session messages: %w", err)
+		}
+	}
+	return r.saveSessionHeader(session)
+}
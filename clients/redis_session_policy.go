@@ -0,0 +1,105 @@
+package clients
+
+import (
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+const sessionPoliciesKey = "session_policies"
+
+// SaveSessionPolicy stores a tenant's session policy
+func (r *RedisClient) SaveSessionPolicy(policy *models.SessionPolicy) error {
+	if err := r.setJSON(fmt.Sprintf("session_policy:%s", policy.TenantID), policy, 0); err != nil {
+		return fmt.Errorf("failed to save session policy: %w", err)
+	}
+
+	if _, err := r.executeCommand(RedisCommand{"SADD", sessionPoliciesKey, policy.TenantID}); err != nil {
+		return fmt.Errorf("failed to index session policy: %w", err)
+	}
+
+	return nil
+}
+
+// GetSessionPolicy returns a tenant's session policy, or nil if none is set
+func (r *RedisClient) GetSessionPolicy(tenantID string) (*models.SessionPolicy, error) {
+	var policy models.SessionPolicy
+	found, err := r.getJSON(fmt.Sprintf("session_policy:%s", tenantID), &policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session policy: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return &policy, nil
+}
+
+// ListSessionPolicies returns every stored session policy
+func (r *RedisClient) ListSessionPolicies() ([]models.SessionPolicy, error) {
+	tenants, err := r.getSetMembers(sessionPoliciesKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session policies: %w", err)
+	}
+
+	policies := make([]models.SessionPolicy, 0, len(tenants))
+	for _, tenantID := range tenants {
+		policy, err := r.GetSessionPolicy(tenantID)
+		if err != nil {
+			return nil, err
+		}
+		if policy != nil {
+			policies = append(policies, *policy)
+		}
+	}
+
+	return policies, nil
+}
+
+// DeleteSessionPolicy removes a tenant's session policy
+func (r *RedisClient) DeleteSessionPolicy(tenantID string) error {
+	if _, err := r.executeCommand(RedisCommand{"DEL", fmt.Sprintf("session_policy:%s", tenantID)}); err != nil {
+		return fmt.Errorf("failed to delete session policy: %w", err)
+	}
+
+	if _, err := r.executeCommand(RedisCommand{"SREM", sessionPoliciesKey, tenantID}); err != nil {
+		return fmt.Errorf("failed to unindex session policy: %w", err)
+	}
+
+	return nil
+}
+
+// GetArchivedSession returns an archived session, or nil if it is not archived
+func (r *RedisClient) GetArchivedSession(sessionID string) (*models.SessionData, error) {
+	var session models.SessionData
+	found, err := r.getJSON(fmt.Sprintf("session_archive:%s", sessionID), &session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archived session: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+// PurgeSession deletes a live session and removes it from its user's session list
+func (r *RedisClient) PurgeSession(session *models.SessionData) error {
+	if err := r.DeleteSession(session.SessionID); err != nil {
+		return err
+	}
+	if _, err := r.executeCommand(RedisCommand{"SREM", fmt.Sprintf("user_sessions:%s", session.UserID), session.SessionID}); err != nil {
+		return fmt.Errorf("failed to unindex session: %w", err)
+	}
+	return nil
+}
+
+// PurgeArchivedSession deletes an archived session and removes it from its user's archive
+func (r *RedisClient) PurgeArchivedSession(session *models.SessionData) error {
+	if _, err := r.executeCommand(RedisCommand{"DEL", fmt.Sprintf("session_archive:%s", session.SessionID)}); err != nil {
+		return fmt.Errorf("failed to delete archived session: %w", err)
+	}
+	if _, err := r.executeCommand(RedisCommand{"SREM", fmt.Sprintf("user_session_archive:%s", session.UserID), session.SessionID}); err != nil {
+		return fmt.Errorf("failed to unindex archived session: %w", err)
+	}
+	return nil
+}
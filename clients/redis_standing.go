@@ -0,0 +1,24 @@
+package clients
+
+import (
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// SaveStandingQueries replaces a user's standing queries
+func (r *RedisClient) SaveStandingQueries(userID string, queries []models.StandingQuery) error {
+	if err := r.setJSON(fmt.Sprintf("standing_queries:%s", userID), queries, 0); err != nil {
+		return fmt.Errorf("failed to save standing queries: %w", err)
+	}
+	return nil
+}
+
+// GetStandingQueries returns a user's standing queries
+func (r *RedisClient) GetStandingQueries(userID string) ([]models.StandingQuery, error) {
+	var queries []models.StandingQuery
+	if _, err := r.getJSON(fmt.Sprintf("standing_queries:%s", userID), &queries); err != nil {
+		return nil, fmt.Errorf("failed to get standing queries: %w", err)
+	}
+	return queries, nil
+}
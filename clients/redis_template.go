@@ -0,0 +1,111 @@
+package clients
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// Each stored version lives under its own key; a counter hands out version numbers
+// and a pointer names the active one (absent or 0 for the built-in default).
+func promptTemplateKey(name string, version int) string {
+	return fmt.Sprintf("prompt_template:%s:v%d", name, version)
+}
+
+func promptTemplateCounterKey(name string) string {
+	return fmt.Sprintf("prompt_template_version:%s", name)
+}
+
+func promptTemplateActiveKey(name string) string {
+	return fmt.Sprintf("prompt_template_active:%s", name)
+}
+
+// SavePromptTemplate stores a new version of a prompt template and returns its number
+func (r *RedisClient) SavePromptTemplate(template *models.PromptTemplate) (int, error) {
+	resp, err := r.executeCommand(RedisCommand{"INCR", promptTemplateCounterKey(template.Name)})
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate prompt template version: %w", err)
+	}
+	version, ok := resp.Result.(float64)
+	if !ok {
+		return 0, fmt.Errorf("invalid prompt template version format")
+	}
+
+	template.Version = int(version)
+	template.Active = false // derived from the active pointer on read
+	if err := r.setJSON(promptTemplateKey(template.Name, template.Version), template, 0); err != nil {
+		return 0, fmt.Errorf("failed to save prompt template: %w", err)
+	}
+	return template.Version, nil
+}
+
+// GetPromptTemplate returns one stored version of a prompt template, or nil if it does not exist
+func (r *RedisClient) GetPromptTemplate(name string, version int) (*models.PromptTemplate, error) {
+	var template models.PromptTemplate
+	found, err := r.getJSON(promptTemplateKey(name, version), &template)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt template: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &template, nil
+}
+
+// ListPromptTemplateVersions returns every stored version of a prompt template, oldest first
+func (r *RedisClient) ListPromptTemplateVersions(name string) ([]models.PromptTemplate, error) {
+	latest, err := r.getString(promptTemplateCounterKey(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt template versions: %w", err)
+	}
+	count, _ := strconv.Atoi(latest)
+
+	versions := make([]models.PromptTemplate, 0, count)
+	for version := 1; version <= count; version++ {
+		template, err := r.GetPromptTemplate(name, version)
+		if err != nil {
+			return nil, err
+		}
+		if template != nil {
+			versions = append(versions, *template)
+		}
+	}
+	return versions, nil
+}
+
+// GetActivePromptTemplateVersion returns the active version of a prompt template, 0 for the default
+func (r *RedisClient) GetActivePromptTemplateVersion(name string) (int, error) {
+	value, err := r.getString(promptTemplateActiveKey(name))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get active prompt template: %w", err)
+	}
+	version, _ := strconv.Atoi(value)
+	return version, nil
+}
+
+// SetActivePromptTemplateVersion makes a version of a prompt template the active one
+func (r *RedisClient) SetActivePromptTemplateVersion(name string, version int) error {
+	if _, err := r.executeCommand(RedisCommand{"SET", promptTemplateActiveKey(name), version}); err != nil {
+		return fmt.Errorf("failed to activate prompt template: %w", err)
+	}
+	return nil
+}
+
+// DeletePromptTemplates removes every stored version of a prompt template
+func (r *RedisClient) DeletePromptTemplates(name string) error {
+	latest, err := r.getString(promptTemplateCounterKey(name))
+	if err != nil {
+		return fmt.Errorf("failed to get prompt template versions: %w", err)
+	}
+	count, _ := strconv.Atoi(latest)
+
+	keys := []string{promptTemplateCounterKey(name), promptTemplateActiveKey(name)}
+	for version := 1; version <= count; version++ {
+		keys = append(keys, promptTemplateKey(name, version))
+	}
+	if _, err := r.DeleteKeys(keys...); err != nil {
+		return fmt.Errorf("failed to delete prompt templates: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package clients
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+const (
+	storageUsageKey      = "storage_usage"
+	storageUsageLeaseKey = "storage_usage_lease"
+)
+
+// SaveStorageUsage stores the latest storage usage sample
+func (r *RedisClient) SaveStorageUsage(report *models.StorageUsageReport) error {
+	if err := r.setJSON(storageUsageKey, report, 0); err != nil {
+		return fmt.Errorf("failed to save storage usage: %w", err)
+	}
+	return nil
+}
+
+// GetStorageUsage returns the latest storage usage sample, or nil if none was taken yet
+func (r *RedisClient) GetStorageUsage() (*models.StorageUsageReport, error) {
+	var report models.StorageUsageReport
+	found, err := r.getJSON(storageUsageKey, &report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage usage: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &report, nil
+}
+
+// ClaimStorageUsageSample reports whether this instance should take the periodic sample,
+// so that only one of several replicas scans storage per interval
+func (r *RedisClient) ClaimStorageUsageSample(interval time.Duration) (bool, error) {
+	ttl := int64(interval.Seconds())
+	if ttl < 1 {
+		ttl = 1
+	}
+	claimed, err := r.SetIfAbsent(storageUsageLeaseKey, time.Now().UTC().Format(time.RFC3339), ttl)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim storage usage sample: %w", err)
+	}
+	return claimed, nil
+}
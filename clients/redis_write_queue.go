@@ -0,0 +1,133 @@
+package clients
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+const (
+	// vectorWriteQueueKey lists memories waiting for the vector store, oldest first
+	vectorWriteQueueKey = "vector_write_queue"
+	// vectorWriteFailedKey lists queued memories the vector store kept rejecting
+	vectorWriteFailedKey = "vector_write_queue:failed"
+	// vectorWriteReplayLeaseKey lets one replica replay the queue at a time
+	vectorWriteReplayLeaseKey = "vector_write_queue:lease"
+)
+
+// ErrWriteQueueFull is returned when the write-ahead queue holds WRITE_AHEAD_QUEUE_MAX entries
+var ErrWriteQueueFull = errors.New("write-ahead queue is full")
+
+// EnqueueVectorWrite appends a memory the vector store could not take to the write-ahead
+// queue, unless the queue already holds max entries
+func (r *RedisClient) EnqueueVectorWrite(write *models.QueuedVectorWrite, max int) error {
+	length, err := r.VectorWriteQueueLength()
+	if err != nil {
+		return err
+	}
+	if length >= max {
+		return fmt.Errorf("%w (%d entries)", ErrWriteQueueFull, length)
+	}
+
+	jsonData, err := json.Marshal(write)
+	if err != nil {
+		return fmt.Errorf("failed to marshal queued write: %w", err)
+	}
+	if _, err := r.executeCommand(RedisCommand{"RPUSH", vectorWriteQueueKey, string(jsonData)}); err != nil {
+		return fmt.Errorf("failed to queue vector write: %w", err)
+	}
+	return nil
+}
+
+// VectorWriteQueueLength returns how many memories wait for the vector store
+func (r *RedisClient) VectorWriteQueueLength() (int, error) {
+	resp, err := r.executeCommand(RedisCommand{"LLEN", vectorWriteQueueKey})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get write-ahead queue length: %w", err)
+	}
+	length, _ := resp.Result.(float64)
+	return int(length), nil
+}
+
+// PeekVectorWrites returns up to limit of the oldest queued writes without removing them.
+// Entries that cannot be decoded are returned as nil so positions stay aligned.
+func (r *RedisClient) PeekVectorWrites(limit int) ([]*models.QueuedVectorWrite, error) {
+	resp, err := r.executeCommand(RedisCommand{"LRANGE", vectorWriteQueueKey, 0, limit - 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read write-ahead queue: %w", err)
+	}
+
+	items, _ := resp.Result.([]interface{})
+	writes := make([]*models.QueuedVectorWrite, len(items))
+	for i, item := range items {
+		jsonStr, ok := item.(string)
+		if !ok {
+			continue
+		}
+		var write models.QueuedVectorWrite
+		if err := json.Unmarshal([]byte(jsonStr), &write); err != nil || write.Memory == nil {
+			continue
+		}
+		writes[i] = &write
+	}
+	return writes, nil
+}
+
+// DropVectorWrites removes the count oldest queued writes
+func (r *RedisClient) DropVectorWrites(count int) error {
+	if count <= 0 {
+		return nil
+	}
+	if _, err := r.executeCommand(RedisCommand{"LTRIM", vectorWriteQueueKey, count, -1}); err != nil {
+		return fmt.Errorf("failed to trim write-ahead queue: %w", err)
+	}
+	return nil
+}
+
+// UpdateOldestVectorWrite rewrites the oldest queued write, e.g. to count a failed attempt
+func (r *RedisClient) UpdateOldestVectorWrite(write *models.QueuedVectorWrite) error {
+	jsonData, err := json.Marshal(write)
+	if err != nil {
+		return fmt.Errorf("failed to marshal queued write: %w", err)
+	}
+	if _, err := r.executeCommand(RedisCommand{"LSET", vectorWriteQueueKey, 0, string(jsonData)}); err != nil {
+		return fmt.Errorf("failed to update queued write: %w", err)
+	}
+	return nil
+}
+
+// RecordFailedVectorWrite keeps a queued write that was given up on for inspection
+func (r *RedisClient) RecordFailedVectorWrite(write *models.QueuedVectorWrite) error {
+	jsonData, err := json.Marshal(write)
+	if err != nil {
+		return fmt.Errorf("failed to marshal queued write: %w", err)
+	}
+	if _, err := r.executeCommand(RedisCommand{"RPUSH", vectorWriteFailedKey, string(jsonData)}); err != nil {
+		return fmt.Errorf("failed to record failed vector write: %w", err)
+	}
+	return nil
+}
+
+// ClaimVectorWriteReplay reports whether this instance should replay the queue now
+func (r *RedisClient) ClaimVectorWriteReplay(lease time.Duration) (bool, error) {
+	ttl := int64(lease.Seconds())
+	if ttl < 1 {
+		ttl = 1
+	}
+	claimed, err := r.SetIfAbsent(vectorWriteReplayLeaseKey, time.Now().UTC().Format(time.RFC3339), ttl)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim write-ahead replay: %w", err)
+	}
+	return claimed, nil
+}
+
+// ReleaseVectorWriteReplay lets the next tick replay without waiting for the lease to lapse
+func (r *RedisClient) ReleaseVectorWriteReplay() error {
+	if _, err := r.DeleteKeys(vectorWriteReplayLeaseKey); err != nil {
+		return fmt.Errorf("failed to release write-ahead replay: %w", err)
+	}
+	return nil
+}
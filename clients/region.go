@@ -0,0 +1,47 @@
+package clients
+
+import (
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+)
+
+// TenantRegion returns the data region a tenant is pinned to, or "" for the default instances
+func TenantRegion(tenantID string) string {
+	return config.AppConfig.TenantRegions[tenantID]
+}
+
+// NewRegionRedisClient creates a Redis client for a data region, or the default instance for ""
+func NewRegionRedisClient(region string) *RedisClient {
+	if endpoints, ok := config.AppConfig.DataRegions[region]; ok {
+		return newRedisClient(endpoints.RedisURL, endpoints.RedisToken, endpoints.RedisAddr, endpoints.RedisPassword)
+	}
+	return NewRedisClient()
+}
+
+// NewRegionVectorStore creates the vector store of a data region, or the default one for ""
+func NewRegionVectorStore(region string) VectorStore {
+	if endpoints, ok := config.AppConfig.DataRegions[region]; ok {
+		return newVectorStore(endpoints.VectorURL, endpoints.VectorToken, endpoints.Quantization)
+	}
+	return NewVectorStore()
+}
+
+// NewRegionContentStore creates where a data region keeps the full text of long memories:
+// the configured blob store, or the region's Redis keyed by memory ID. Regions without
+// their own bucket keep S3 blobs in their Redis so content never leaves the region.
+func NewRegionContentStore(region string, redisClient *RedisClient) ContentStore {
+	switch config.AppConfig.BlobStore {
+	case "s3":
+		bucket := config.AppConfig.BlobS3Bucket
+		if region != "" {
+			bucket = config.AppConfig.DataRegions[region].BlobBucket
+		}
+		if bucket != "" {
+			return NewBlobContentStore(NewS3BlobStore(bucket), redisClient)
+		}
+		return NewBlobContentStore(redisClient, redisClient)
+	case "redis":
+		return NewBlobContentStore(redisClient, redisClient)
+	default:
+		return redisClient
+	}
+}
@@ -0,0 +1,103 @@
+package clients
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// sessionCacheEntry is a session snapshot and the time it stops being served
+type sessionCacheEntry struct {
+	session   models.SessionData
+	expiresAt time.Time
+}
+
+// sessionCache is a process-local read cache of sessions, keyed by Redis URL and
+// session ID. It is only consulted by read-only paths; writers always go to Redis.
+var sessionCache = struct {
+	mu      sync.RWMutex
+	entries map[string]sessionCacheEntry
+}{entries: make(map[string]sessionCacheEntry)}
+
+func sessionCacheKey(url string, sessionID string) string {
+	return url + "|" + sessionID
+}
+
+// cacheSession stores a copy of a session when the cache is enabled
+func cacheSession(url string, session *models.SessionData) {
+	ttl := config.AppConfig.SessionCacheTTL
+	if ttl <= 0 {
+		return
+	}
+
+	sessionCache.mu.Lock()
+	defer sessionCache.mu.Unlock()
+
+	// Drop expired entries opportunistically so the map does not grow unbounded
+	now := time.Now()
+	if len(sessionCache.entries) >= config.AppConfig.SessionCacheSize {
+		for key, entry := range sessionCache.entries {
+			if now.After(entry.expiresAt) {
+				delete(sessionCache.entries, key)
+			}
+		}
+		if len(sessionCache.entries) >= config.AppConfig.SessionCacheSize {
+			return
+		}
+	}
+
+	sessionCache.entries[sessionCacheKey(url, session.SessionID)] = sessionCacheEntry{
+		session:   *session,
+		expiresAt: now.Add(ttl),
+	}
+}
+
+// cachedSession returns a fresh cached session, if any
+func cachedSession(url string, sessionID string) (*models.SessionData, bool) {
+	sessionCache.mu.RLock()
+	defer sessionCache.mu.RUnlock()
+
+	entry, ok := sessionCache.entries[sessionCacheKey(url, sessionID)]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	session := entry.session
+	return &session, true
+}
+
+// evictSession removes a session from the cache
+func evictSession(url string, sessionID string) {
+	sessionCache.mu.Lock()
+	defer sessionCache.mu.Unlock()
+
+	delete(sessionCache.entries, sessionCacheKey(url, sessionID))
+}
+
+// CachedSessionCount reports how many sessions are currently cached
+func CachedSessionCount() int {
+	sessionCache.mu.RLock()
+	defer sessionCache.mu.RUnlock()
+
+	return len(sessionCache.entries)
+}
+
+// GetSessionCached returns a session from the local cache, loading it from Redis on a miss.
+// Use it for reads only: a cached session may lag writes made by other instances.
+func (r *RedisClient) GetSessionCached(sessionID string) (*models.SessionData, error) {
+	if session, ok := cachedSession(r.url, sessionID); ok {
+		return session, nil
+	}
+	return r.WarmSession(sessionID)
+}
+
+// WarmSession loads a session from Redis into the local cache
+func (r *RedisClient) WarmSession(sessionID string) (*models.SessionData, error) {
+	session, err := r.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	cacheSession(r.url, session)
+	return session, nil
+}
@@ -0,0 +1,149 @@
+package clients
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// BM25 parameters used when weighting term frequencies on the document side.
+// IDF is applied by Upstash at query time via the IDF weighting strategy.
+const (
+	bm25K1        = 1.2
+	bm25B         = 0.75
+	bm25AvgDocLen = 32.0
+)
+
+// SparseVector is the sparse component of a hybrid Upstash Vector index
+type SparseVector struct {
+	Indices []uint32  `json:"indices"`
+	Values  []float64 `json:"values"`
+}
+
+// GenerateDocumentSparseVector builds BM25 term weights for stored content
+func GenerateDocumentSparseVector(text string) *SparseVector {
+	terms := tokenize(text)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	counts := make(map[uint32]float64)
+	for _, term := range terms {
+		counts[termIndex(term)]++
+	}
+
+	docLen := float64(len(terms))
+	weights := make(map[uint32]float64, len(counts))
+	for index, tf := range counts {
+		weights[index] = tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*docLen/bm25AvgDocLen))
+	}
+
+	return newSparseVector(weights)
+}
+
+// GenerateQuerySparseVector builds a sparse vector with unit weight per distinct query term
+func GenerateQuerySparseVector(text string) *SparseVector {
+	terms := tokenize(text)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	weights := make(map[uint32]float64)
+	for _, term := range terms {
+		weights[termIndex(term)] = 1
+	}
+
+	return newSparseVector(weights)
+}
+
+// rankBM25 scores matches against the query terms with BM25, using the matches
+// themselves as the corpus, and returns up to limit of those containing a query term
+// ranked by score
+func rankBM25(queryText string, matches []QueryMatch, limit int) []models.MemoryResult {
+	queryTerms := make(map[string]bool)
+	for _, term := range tokenize(queryText) {
+		queryTerms[term] = true
+	}
+	if len(queryTerms) == 0 || len(matches) == 0 {
+		return []models.MemoryResult{}
+	}
+
+	// Term frequencies of the query terms per document, and document frequencies
+	frequencies := make([]map[string]float64, len(matches))
+	lengths := make([]float64, len(matches))
+	documents := make(map[string]float64)
+	totalLength := 0.0
+	for i, match := range matches {
+		content, _ := match.Metadata["content"].(string)
+		terms := tokenize(content)
+		frequencies[i] = make(map[string]float64)
+		for _, term := range terms {
+			if queryTerms[term] {
+				if frequencies[i][term] == 0 {
+					documents[term]++
+				}
+				frequencies[i][term]++
+			}
+		}
+		lengths[i] = float64(len(terms))
+		totalLength += lengths[i]
+	}
+	avgLength := totalLength / float64(len(matches))
+	if avgLength == 0 {
+		avgLength = 1
+	}
+
+	n := float64(len(matches))
+	scored := make([]QueryMatch, 0, len(matches))
+	for i, match := range matches {
+		score := 0.0
+		for term, tf := range frequencies[i] {
+			idf := math.Log(1 + (n-documents[term]+0.5)/(documents[term]+0.5))
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*lengths[i]/avgLength))
+		}
+		if score > 0 {
+			match.Score = score
+			scored = append(scored, match)
+		}
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+	if len(scored) > limit {
+		scored = scored[:limit]
+	}
+	return matchResults(scored, 0)
+}
+
+// tokenize lowercases text and splits it on anything that is not a letter or digit
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// termIndex hashes a term into the sparse index space
+func termIndex(term string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(term))
+	return h.Sum32()
+}
+
+// newSparseVector orders entries by index as expected by the Upstash API
+func newSparseVector(weights map[uint32]float64) *SparseVector {
+	indices := make([]uint32, 0, len(weights))
+	for index := range weights {
+		indices = append(indices, index)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+
+	values := make([]float64, len(indices))
+	for i, index := range indices {
+		values[i] = weights[index]
+	}
+
+	return &SparseVector{Indices: indices, Values: values}
+}
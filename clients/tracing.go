@@ -0,0 +1,196 @@
+package clients
+
+import (
+	"context"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/Fairy-nn/MemoryCacheAI/tracing"
+)
+
+// TraceVectorStore returns store recording a span per vector call made for the request
+// in ctx, or store itself without a trace in ctx. It does not bind store to ctx; see
+// VectorStore.WithContext.
+func TraceVectorStore(store VectorStore, ctx context.Context) VectorStore {
+	if tracing.FromContext(ctx) == nil {
+		return store
+	}
+	return &tracedVectorStore{VectorStore: store, ctx: ctx}
+}
+
+// tracedVectorStore records data calls; administrative methods such as GetStats and
+// Close pass through untraced
+type tracedVectorStore struct {
+	VectorStore
+	ctx context.Context
+}
+
+// WithContext rebinds the store, tracing it under the span in ctx
+func (t *tracedVectorStore) WithContext(ctx context.Context) VectorStore {
+	return TraceVectorStore(t.VectorStore.WithContext(ctx), ctx)
+}
+
+func (t *tracedVectorStore) start(operation string) *tracing.Span {
+	span := tracing.Start(t.ctx, "vector "+operation, tracing.KindClient)
+	span.SetAttribute("db.system", config.AppConfig.VectorProvider)
+	span.SetAttribute("db.operation", operation)
+	return span
+}
+
+func endSpan(span *tracing.Span, err error) {
+	span.SetError(err)
+	span.End()
+}
+
+func (t *tracedVectorStore) UpsertMemory(memory *models.MemoryEntry) (err error) {
+	span := t.start("upsert")
+	defer func() { endSpan(span, err) }()
+	return t.VectorStore.UpsertMemory(memory)
+}
+
+func (t *tracedVectorStore) QueryMemories(userID string, filter string, queryText string, queryVector []float64, limit int, minScore float64) (results []models.MemoryResult, err error) {
+	span := t.start("query")
+	span.SetAttribute("vector.limit", limit)
+	defer func() {
+		span.SetAttribute("vector.results", len(results))
+		endSpan(span, err)
+	}()
+	return t.VectorStore.QueryMemories(userID, filter, queryText, queryVector, limit, minScore)
+}
+
+func (t *tracedVectorStore) SearchKeywords(userID string, filter string, queryText string, limit int) (results []models.MemoryResult, err error) {
+	span := t.start("search_keywords")
+	span.SetAttribute("vector.limit", limit)
+	defer func() {
+		span.SetAttribute("vector.results", len(results))
+		endSpan(span, err)
+	}()
+	return t.VectorStore.SearchKeywords(userID, filter, queryText, limit)
+}
+
+func (t *tracedVectorStore) UpdateMetadata(id string, metadata map[string]interface{}) (err error) {
+	span := t.start("update_metadata")
+	defer func() { endSpan(span, err) }()
+	return t.VectorStore.UpdateMetadata(id, metadata)
+}
+
+func (t *tracedVectorStore) FetchMemory(id string) (match *QueryMatch, err error) {
+	span := t.start("fetch")
+	defer func() { endSpan(span, err) }()
+	return t.VectorStore.FetchMemory(id)
+}
+
+func (t *tracedVectorStore) FetchVectors(ids []string) (matches []QueryMatch, err error) {
+	span := t.start("fetch_vectors")
+	span.SetAttribute("vector.ids", len(ids))
+	defer func() { endSpan(span, err) }()
+	return t.VectorStore.FetchVectors(ids)
+}
+
+func (t *tracedVectorStore) ListUserMemories(userID string, limit int) (matches []QueryMatch, err error) {
+	span := t.start("list_user_memories")
+	defer func() {
+		span.SetAttribute("vector.results", len(matches))
+		endSpan(span, err)
+	}()
+	return t.VectorStore.ListUserMemories(userID, limit)
+}
+
+func (t *tracedVectorStore) ListUserSummaries(userID string, granularity string, limit int) (matches []QueryMatch, err error) {
+	span := t.start("list_user_summaries")
+	defer func() {
+		span.SetAttribute("vector.results", len(matches))
+		endSpan(span, err)
+	}()
+	return t.VectorStore.ListUserSummaries(userID, granularity, limit)
+}
+
+func (t *tracedVectorStore) ListUserInstructions(userID string, limit int) (matches []QueryMatch, err error) {
+	span := t.start("list_user_instructions")
+	defer func() {
+		span.SetAttribute("vector.results", len(matches))
+		endSpan(span, err)
+	}()
+	return t.VectorStore.ListUserInstructions(userID, limit)
+}
+
+func (t *tracedVectorStore) ListTaskMemories(tenantID string, taskID string, limit int) (matches []QueryMatch, err error) {
+	span := t.start("list_task_memories")
+	defer func() {
+		span.SetAttribute("vector.results", len(matches))
+		endSpan(span, err)
+	}()
+	return t.VectorStore.ListTaskMemories(tenantID, taskID, limit)
+}
+
+func (t *tracedVectorStore) DeleteMemory(id string) (err error) {
+	span := t.start("delete")
+	defer func() { endSpan(span, err) }()
+	return t.VectorStore.DeleteMemory(id)
+}
+
+func (t *tracedVectorStore) DeleteMemories(ids []string) (err error) {
+	span := t.start("delete")
+	span.SetAttribute("vector.ids", len(ids))
+	defer func() { endSpan(span, err) }()
+	return t.VectorStore.DeleteMemories(ids)
+}
+
+func (t *tracedVectorStore) DeleteUserMemories(userID string) (err error) {
+	span := t.start("delete_user_memories")
+	defer func() { endSpan(span, err) }()
+	return t.VectorStore.DeleteUserMemories(userID)
+}
+
+// TraceEmbeddings returns client recording a span per embedding call made for the
+// request in ctx, or client itself without a trace in ctx
+func TraceEmbeddings(client EmbeddingClient, ctx context.Context) EmbeddingClient {
+	if tracing.FromContext(ctx) == nil {
+		return client
+	}
+	return &tracedEmbeddingClient{EmbeddingClient: client, ctx: ctx}
+}
+
+// tracedEmbeddingClient wraps the cached client, so its spans include cache lookups;
+// embedding.texts counts the texts asked for, not those sent to the provider
+type tracedEmbeddingClient struct {
+	EmbeddingClient
+	ctx context.Context
+}
+
+// WithContext rebinds the client, tracing it under the span in ctx
+func (t *tracedEmbeddingClient) WithContext(ctx context.Context) EmbeddingClient {
+	return TraceEmbeddings(t.EmbeddingClient.WithContext(ctx), ctx)
+}
+
+func (t *tracedEmbeddingClient) start(texts int) *tracing.Span {
+	span := tracing.Start(t.ctx, "embedding generate", tracing.KindClient)
+	span.SetAttribute("embedding.provider", string(t.GetProvider()))
+	span.SetAttribute("embedding.model", t.GetModel())
+	span.SetAttribute("embedding.texts", texts)
+	return span
+}
+
+func (t *tracedEmbeddingClient) GenerateEmbedding(text string) (embedding []float64, err error) {
+	span := t.start(1)
+	defer func() { endSpan(span, err) }()
+	return t.EmbeddingClient.GenerateEmbedding(text)
+}
+
+func (t *tracedEmbeddingClient) GenerateEmbeddingWithSource(text string) (embedding []float64, source EmbeddingSource, err error) {
+	span := t.start(1)
+	defer func() { endSpan(span, err) }()
+	return t.EmbeddingClient.GenerateEmbeddingWithSource(text)
+}
+
+func (t *tracedEmbeddingClient) GenerateEmbeddings(texts []string) (embedding []float64, err error) {
+	span := t.start(len(texts))
+	defer func() { endSpan(span, err) }()
+	return t.EmbeddingClient.GenerateEmbeddings(texts)
+}
+
+func (t *tracedEmbeddingClient) GenerateBatchEmbeddings(texts []string) (embeddings [][]float64, err error) {
+	span := t.start(len(texts))
+	defer func() { endSpan(span, err) }()
+	return t.EmbeddingClient.GenerateBatchEmbeddings(texts)
+}
@@ -2,35 +2,50 @@ package clients
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
+	"math"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/Fairy-nn/MemoryCacheAI/config"
 	"github.com/Fairy-nn/MemoryCacheAI/models"
 )
 
-type VectorClient struct {
-	url        string
-	token      string
-	client     *http.Client
-	dimensions int // cached dimensions
+// UpstashVectorStore is the VectorStore backed by an Upstash Vector index
+type UpstashVectorStore struct {
+	vectorContent
+
+	url          string
+	token        string
+	client       *httpClient
+	hybrid       bool            // index stores sparse vectors alongside dense ones
+	fusion       string          // fusion algorithm for hybrid queries
+	quantization string          // "none", "scalar" or "binary", applied by the client
+	ctx          context.Context // request the store is bound to, see WithContext; nil otherwise
 }
 
+// dimensionCache holds the dimension of each index, keyed by index URL
+var dimensionCache sync.Map
+
 type UpsertRequest struct {
-	ID       string                 `json:"id"`
-	Vector   []float64              `json:"vector"`
-	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	ID           string                 `json:"id"`
+	Vector       []float64              `json:"vector"`
+	SparseVector *SparseVector          `json:"sparseVector,omitempty"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
 }
 
 type QueryRequest struct {
-	Vector          []float64 `json:"vector"`
-	TopK            int       `json:"topK"`
-	IncludeMetadata bool      `json:"includeMetadata"`
-	IncludeVectors  bool      `json:"includeVectors"`
-	Filter          string    `json:"filter,omitempty"`
+	Vector            []float64     `json:"vector"`
+	SparseVector      *SparseVector `json:"sparseVector,omitempty"`
+	TopK              int           `json:"topK"`
+	IncludeMetadata   bool          `json:"includeMetadata"`
+	IncludeVectors    bool          `json:"includeVectors"`
+	Filter            string        `json:"filter,omitempty"`
+	FusionAlgorithm   string        `json:"fusionAlgorithm,omitempty"`
+	WeightingStrategy string        `json:"weightingStrategy,omitempty"`
 }
 
 type QueryResponse struct {
@@ -49,22 +64,72 @@ type DeleteByIDRequest struct {
 	IDs []string `json:"ids"`
 }
 
+// FetchRequest retrieves vectors by ID
+type FetchRequest struct {
+	IDs             []string `json:"ids"`
+	IncludeMetadata bool     `json:"includeMetadata"`
+	IncludeVectors  bool     `json:"includeVectors"`
+}
+
+type FetchResponse struct {
+	Result []*QueryMatch `json:"result"`
+}
+
 // DeleteByFilterRequest represents a delete request using a filter
 type DeleteByFilterRequest struct {
 	Filter string `json:"filter"`
 }
 
-func NewVectorClient() *VectorClient {
-	return &VectorClient{
-		url:   config.AppConfig.UpstashVectorURL,
-		token: config.AppConfig.UpstashVectorToken,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+// RangeRequest pages through every vector in the index
+type RangeRequest struct {
+	Cursor          string `json:"cursor"`
+	Limit           int    `json:"limit"`
+	IncludeMetadata bool   `json:"includeMetadata"`
+	IncludeVectors  bool   `json:"includeVectors"`
+}
+
+type RangeResponse struct {
+	Result struct {
+		NextCursor string       `json:"nextCursor"`
+		Vectors    []QueryMatch `json:"vectors"`
+	} `json:"result"`
+}
+
+// UpdateRequest replaces the metadata of an existing vector
+type UpdateRequest struct {
+	ID                 string                 `json:"id"`
+	Metadata           map[string]interface{} `json:"metadata"`
+	MetadataUpdateMode string                 `json:"metadataUpdateMode,omitempty"`
+}
+
+// NewUpstashVectorStore creates a store for an Upstash Vector index. With a quantization
+// mode other than "none" the index holds quantized vectors and the originals are kept in
+// metadata for rescoring, see quantizeVector.
+func NewUpstashVectorStore(url string, token string, quantization string) *UpstashVectorStore {
+	return &UpstashVectorStore{
+		url:          url,
+		token:        token,
+		client:       newHTTPClient(config.AppConfig.VectorClient).withFault(FaultVector),
+		hybrid:       config.AppConfig.VectorIndexType == "hybrid",
+		fusion:       config.AppConfig.VectorFusion,
+		quantization: quantization,
 	}
 }
 
-func (v *VectorClient) makeRequest(method, endpoint string, body interface{}) ([]byte, error) {
+// Close releases the client's idle connections
+func (v *UpstashVectorStore) Close() {
+	v.client.Close()
+}
+
+// WithContext returns a copy of the store whose requests are abandoned once ctx is done.
+// The copy shares the connections of v.
+func (v *UpstashVectorStore) WithContext(ctx context.Context) VectorStore {
+	bound := *v
+	bound.ctx = ctx
+	return &bound
+}
+
+func (v *UpstashVectorStore) makeRequest(method, endpoint string, body interface{}) ([]byte, error) {
 	var reqBody []byte
 	var err error
 
@@ -75,43 +140,31 @@ func (v *VectorClient) makeRequest(method, endpoint string, body interface{}) ([
 		}
 	}
 
-	req, err := http.NewRequest(method, v.url+endpoint, bytes.NewBuffer(reqBody))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+v.token)
-
-	resp, err := v.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
+	statusCode, respBody, err := v.client.Do(requestContext(v.ctx), func() (*http.Request, error) {
+		req, err := http.NewRequest(method, v.url+endpoint, bytes.NewReader(reqBody))
+		if err != nil {
+			return nil, err
+		}
 
-	respBody, err := io.ReadAll(resp.Body)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+v.token)
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+	if statusCode < 200 || statusCode >= 300 {
+		return nil, fmt.Errorf("API request failed with status %d: %s", statusCode, string(respBody))
 	}
 
 	return respBody, nil
 }
 
-func (v *VectorClient) UpsertMemory(memory *models.MemoryEntry) error {
-	metadata := map[string]interface{}{
-		"user_id":   memory.UserID,
-		"content":   memory.Content,
-		"timestamp": memory.Timestamp.Unix(),
-		"ttl":       memory.TTL,
-	}
-
-	// Add custom metadata
-	for k, val := range memory.Metadata {
-		metadata[k] = val
+func (v *UpstashVectorStore) UpsertMemory(memory *models.MemoryEntry) error {
+	metadata := memoryMetadata(memory)
+	if err := v.fitContent(memory.ID, metadata, true); err != nil {
+		return fmt.Errorf("failed to upsert memory: %w", err)
 	}
 
 	request := UpsertRequest{
@@ -119,6 +172,13 @@ func (v *VectorClient) UpsertMemory(memory *models.MemoryEntry) error {
 		Vector:   memory.Embedding,
 		Metadata: metadata,
 	}
+	if v.hybrid {
+		request.SparseVector = GenerateDocumentSparseVector(memory.Content)
+	}
+	if v.quantization != "none" {
+		request.Vector = quantizeVector(memory.Embedding, v.quantization)
+		metadata[originalVectorField] = encodeVector(memory.Embedding)
+	}
 
 	_, err := v.makeRequest("POST", "/upsert", request)
 	if err != nil {
@@ -128,7 +188,12 @@ func (v *VectorClient) UpsertMemory(memory *models.MemoryEntry) error {
 	return nil
 }
 
-func (v *VectorClient) QueryMemories(userID string, queryVector []float64, limit int, minScore float64) ([]models.MemoryResult, error) {
+// QueryMemories finds a user's memories closest to the query. On hybrid indexes the
+// query text is also matched lexically and the two rankings are fused; fused scores
+// are not cosine similarities, so minScore only applies to dense indexes. A non-empty
+// filter is ANDed with the user filter. Quantized indexes are searched for more
+// candidates, which are rescored with their original vectors.
+func (v *UpstashVectorStore) QueryMemories(userID string, filter string, queryText string, queryVector []float64, limit int, minScore float64) ([]models.MemoryResult, error) {
 	if limit <= 0 {
 		limit = 10
 	}
@@ -140,59 +205,57 @@ func (v *VectorClient) QueryMemories(userID string, queryVector []float64, limit
 		IncludeVectors:  false,
 		Filter:          fmt.Sprintf("user_id = '%s'", userID),
 	}
-	fmt.Printf("🔍 Vector query: UserID=%s, VectorDim=%d, TopK=%d, Filter=%s\n", userID, len(queryVector), limit, request.Filter)
+	if filter != "" {
+		request.Filter += " AND " + filter
+	}
+	oversampling := quantizationOversampling(v.quantization)
+	if oversampling > 0 {
+		request.TopK = int(math.Ceil(float64(limit) * oversampling))
+		// Memories saved before quantization was enabled have no original in metadata
+		request.IncludeVectors = true
+	}
+	if v.hybrid {
+		request.SparseVector = GenerateQuerySparseVector(queryText)
+		if request.SparseVector != nil {
+			request.FusionAlgorithm = v.fusion
+			request.WeightingStrategy = "IDF"
+			minScore = 0
+		}
+	}
 
 	respBody, err := v.makeRequest("POST", "/query", request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query memories: %w", err)
 	}
-	fmt.Printf("📡 Vector API response: %s\n", string(respBody))
 
 	var response QueryResponse
 	if err := json.Unmarshal(respBody, &response); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal query response: %w", err)
 	}
-	fmt.Printf("📊 Raw matches from vector DB: %d\n", len(response.Result))
-
-	results := make([]models.MemoryResult, 0, len(response.Result))
-	for i, match := range response.Result {
-		fmt.Printf("  Match %d: ID=%s, Score=%f\n", i, match.ID, match.Score)
-		if match.Score < minScore {
-			fmt.Printf("    ❌ Filtered out (score %f < minScore %f)\n", match.Score, minScore)
-			continue
-		}
-
-		result := models.MemoryResult{
-			ID:       match.ID,
-			Score:    match.Score,
-			Metadata: match.Metadata,
-		}
-
-		// Add memory ID to metadata as well for backwards compatibility
-		if result.Metadata == nil {
-			result.Metadata = make(map[string]interface{})
-		}
-		result.Metadata["id"] = match.ID
-
-		// Extract content from metadata
-		if content, ok := match.Metadata["content"].(string); ok {
-			result.Content = content
-		}
+	if oversampling > 0 {
+		response.Result = rescoreMatches(response.Result, queryVector, limit)
+	}
+	restoreOriginalVectors(response.Result)
+	v.hydrateContent(response.Result)
 
-		// Extract timestamp from metadata
-		if timestampFloat, ok := match.Metadata["timestamp"].(float64); ok {
-			result.Timestamp = time.Unix(int64(timestampFloat), 0)
-		}
+	return matchResults(response.Result, minScore), nil
+}
 
-		results = append(results, result)
-		fmt.Printf("    ✅ Added to results\n")
+// SearchKeywords ranks up to HYBRID_KEYWORD_POOL of a user's memories selected by the
+// filter against the query terms with BM25. A non-empty filter is ANDed with the user filter.
+func (v *UpstashVectorStore) SearchKeywords(userID string, filter string, queryText string, limit int) ([]models.MemoryResult, error) {
+	userFilter := fmt.Sprintf("user_id = '%s'", userID)
+	if filter != "" {
+		userFilter += " AND " + filter
 	}
-	fmt.Printf("📋 Final filtered results: %d\n", len(results))
-
-	return results, nil
+	matches, err := v.listMemories(userFilter, config.AppConfig.HybridKeywordPool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search keywords: %w", err)
+	}
+	return rankBM25(queryText, matches, limit), nil
 }
 
-func (v *VectorClient) DeleteMemory(id string) error {
+func (v *UpstashVectorStore) DeleteMemory(id string) error {
 	fmt.Printf("🗑️ DeleteMemory: Deleting memory with ID=%s\n", id)
 
 	request := DeleteByIDRequest{
@@ -204,76 +267,288 @@ func (v *VectorClient) DeleteMemory(id string) error {
 		fmt.Printf("❌ Delete request failed: %v\n", err)
 		return fmt.Errorf("failed to delete memory: %w", err)
 	}
+	v.deleteContent(id)
 
 	fmt.Printf("✅ Delete request successful: %s\n", string(respBody))
 	return nil
 }
 
-func (v *VectorClient) DeleteUserMemories(userID string) error {
+// RangeMemories returns one page of vectors with their metadata and the cursor for the
+// next page; an empty cursor means the scan is complete
+func (v *UpstashVectorStore) RangeMemories(cursor string, limit int) ([]QueryMatch, string, error) {
+	return v.rangeMatches(cursor, limit, false)
+}
+
+// RangeVectors pages through every stored memory like RangeMemories, including vectors
+func (v *UpstashVectorStore) RangeVectors(cursor string, limit int) ([]QueryMatch, string, error) {
+	return v.rangeMatches(cursor, limit, true)
+}
+
+func (v *UpstashVectorStore) rangeMatches(cursor string, limit int, includeVectors bool) ([]QueryMatch, string, error) {
+	request := RangeRequest{
+		Cursor:          cursor,
+		Limit:           limit,
+		IncludeMetadata: true,
+		IncludeVectors:  includeVectors,
+	}
+
+	respBody, err := v.makeRequest("POST", "/range", request)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to range memories: %w", err)
+	}
+
+	var response RangeResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal range response: %w", err)
+	}
+	restoreOriginalVectors(response.Result.Vectors)
+	v.hydrateContent(response.Result.Vectors)
+
+	return response.Result.Vectors, response.Result.NextCursor, nil
+}
+
+// UpdateMetadata overwrites the metadata of a stored memory without touching its vector.
+// Content that was hydrated on read is truncated again, and the original vector of a
+// quantized memory is kept.
+func (v *UpstashVectorStore) UpdateMetadata(id string, metadata map[string]interface{}) error {
+	metadata = copyMetadata(metadata)
+	if err := v.fitContent(id, metadata, false); err != nil {
+		return fmt.Errorf("failed to update memory metadata: %w", err)
+	}
+	if v.quantization != "none" {
+		stored, err := v.fetchMatch(id, true, false)
+		if err != nil {
+			return fmt.Errorf("failed to update memory metadata: %w", err)
+		}
+		if stored != nil {
+			if original, ok := stored.Metadata[originalVectorField]; ok {
+				metadata[originalVectorField] = original
+			}
+		}
+	}
+
+	request := UpdateRequest{
+		ID:                 id,
+		Metadata:           metadata,
+		MetadataUpdateMode: "OVERWRITE",
+	}
+
+	if _, err := v.makeRequest("POST", "/update", request); err != nil {
+		return fmt.Errorf("failed to update memory metadata: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteMemories removes several memories by ID, split into batches of the configured size
+func (v *UpstashVectorStore) DeleteMemories(ids []string) error {
+	batchSize := v.client.settings.MaxBatchSize
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		request := DeleteByIDRequest{
+			IDs: ids[start:end],
+		}
+
+		if _, err := v.makeRequest("DELETE", "/delete", request); err != nil {
+			return fmt.Errorf("failed to delete memories: %w", err)
+		}
+		v.deleteContent(request.IDs...)
+	}
+
+	return nil
+}
+
+func (v *UpstashVectorStore) DeleteUserMemories(userID string) error {
 	fmt.Printf("🗑️ DeleteUserMemories: Deleting all memories for userID=%s\n", userID)
 
 	// Use filter to delete all memories for the user at once
 	request := DeleteByFilterRequest{
 		Filter: fmt.Sprintf("user_id = '%s'", userID),
 	}
+	truncated, err := v.truncatedMemoryIDs(request.Filter, v.queryMetadata)
+	if err != nil {
+		return fmt.Errorf("failed to delete user memories: %w", err)
+	}
 
 	respBody, err := v.makeRequest("DELETE", "/delete", request)
 	if err != nil {
 		fmt.Printf("❌ Delete user memories request failed: %v\n", err)
 		return fmt.Errorf("failed to delete user memories: %w", err)
 	}
+	v.deleteContent(truncated...)
 
 	fmt.Printf("✅ Delete user memories request successful: %s\n", string(respBody))
 	return nil
 }
 
-func (v *VectorClient) DeleteExpiredMemories() error {
-	now := time.Now().Unix()
+// FetchVectors returns the vectors of stored memories without their metadata, omitting
+// IDs that do not exist. Quantized memories return their original vectors.
+func (v *UpstashVectorStore) FetchVectors(ids []string) ([]QueryMatch, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	request := FetchRequest{
+		IDs:             ids,
+		IncludeMetadata: v.quantization != "none",
+		IncludeVectors:  true,
+	}
+
+	respBody, err := v.makeRequest("POST", "/fetch", request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch vectors: %w", err)
+	}
+
+	var response FetchResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal fetch response: %w", err)
+	}
+
+	matches := make([]QueryMatch, 0, len(response.Result))
+	for _, match := range response.Result {
+		if match != nil {
+			matches = append(matches, *match)
+		}
+	}
+	restoreOriginalVectors(matches)
+	for i := range matches {
+		matches[i].Metadata = nil
+	}
+	return matches, nil
+}
+
+// FetchMemory returns a stored memory with its metadata, or nil if it does not exist
+func (v *UpstashVectorStore) FetchMemory(id string) (*QueryMatch, error) {
+	match, err := v.fetchMatch(id, true, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch memory: %w", err)
+	}
+	if match == nil {
+		return nil, nil
+	}
+	matches := []QueryMatch{*match}
+	restoreOriginalVectors(matches)
+	v.hydrateContent(matches)
+	return &matches[0], nil
+}
+
+// fetchMatch returns a stored memory as the index holds it, or nil if it does not exist
+func (v *UpstashVectorStore) fetchMatch(id string, includeMetadata bool, includeVectors bool) (*QueryMatch, error) {
+	request := FetchRequest{
+		IDs:             []string{id},
+		IncludeMetadata: includeMetadata,
+		IncludeVectors:  includeVectors,
+	}
+
+	respBody, err := v.makeRequest("POST", "/fetch", request)
+	if err != nil {
+		return nil, err
+	}
+
+	var response FetchResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal fetch response: %w", err)
+	}
+
+	if len(response.Result) == 0 {
+		return nil, nil
+	}
+	return response.Result[0], nil
+}
+
+// ListUserMemories returns up to limit memories of a user with their metadata,
+// in no particular order
+func (v *UpstashVectorStore) ListUserMemories(userID string, limit int) ([]QueryMatch, error) {
+	return v.listMemories(fmt.Sprintf("user_id = '%s'", userID), limit)
+}
+
+// ListUserSummaries returns up to limit of a user's rollup summaries at one granularity
+func (v *UpstashVectorStore) ListUserSummaries(userID string, granularity string, limit int) ([]QueryMatch, error) {
+	return v.listMemories(fmt.Sprintf("user_id = '%s' AND granularity = '%s'", userID, granularity), limit)
+}
+
+// ListUserInstructions returns up to limit of a user's instruction memories
+func (v *UpstashVectorStore) ListUserInstructions(userID string, limit int) ([]QueryMatch, error) {
+	return v.listMemories(fmt.Sprintf("user_id = '%s' AND memory_type = '%s' AND HAS NOT FIELD quarantine_reason", userID, models.MemoryTypeInstruction), limit)
+}
+
+// ListQuarantinedMemories returns up to limit memories the write guard quarantined
+func (v *UpstashVectorStore) ListQuarantinedMemories(limit int) ([]QueryMatch, error) {
+	return v.listMemories("HAS FIELD quarantine_reason", limit)
+}
+
+// ListTaskMemories returns up to limit of the memories saved for a task of a tenant
+func (v *UpstashVectorStore) ListTaskMemories(tenantID string, taskID string, limit int) ([]QueryMatch, error) {
+	return v.listMemories(fmt.Sprintf("tenant_id = '%s' AND task_id = '%s'", tenantID, taskID), limit)
+}
+
+// ListAllMemories returns up to limit memories across all users
+func (v *UpstashVectorStore) ListAllMemories(limit int) ([]QueryMatch, error) {
+	return v.listMemories("", limit)
+}
+
+// listMemories queries with a zero vector so only the filter decides what is returned
+func (v *UpstashVectorStore) listMemories(filter string, limit int) ([]QueryMatch, error) {
+	matches, err := v.queryMetadata(filter, limit)
+	if err != nil {
+		return nil, err
+	}
+	restoreOriginalVectors(matches)
+	v.hydrateContent(matches)
+	return matches, nil
+}
 
-	// Get vector dimensions dynamically
+// queryMetadata lists the memories a filter selects with their metadata as stored
+func (v *UpstashVectorStore) queryMetadata(filter string, limit int) ([]QueryMatch, error) {
 	dimensions, err := v.GetDimensions()
 	if err != nil {
-		// Fallback to configured dimensions if we can't get them from the database
 		dimensions = config.GetEmbeddingDimensions()
-		fmt.Printf("Warning: Could not get dimensions from database, using configured dimensions %d: %v\n", dimensions, err)
 	}
 
-	// Query all memories (this is a simplified approach)
 	queryRequest := QueryRequest{
-		Vector:          make([]float64, dimensions), // Dynamic vector dimensions
-		TopK:            10000,                       // Large number
+		Vector:          make([]float64, dimensions),
+		TopK:            limit,
 		IncludeMetadata: true,
 		IncludeVectors:  false,
+		Filter:          filter,
 	}
 
 	respBody, err := v.makeRequest("POST", "/query", queryRequest)
 	if err != nil {
-		return fmt.Errorf("failed to query memories for cleanup: %w", err)
+		return nil, fmt.Errorf("failed to list memories: %w", err)
 	}
 
 	var response QueryResponse
 	if err := json.Unmarshal(respBody, &response); err != nil {
-		return fmt.Errorf("failed to unmarshal query response: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal query response: %w", err)
 	}
 
-	// Check each memory for expiration
-	for _, match := range response.Result {
-		if timestampFloat, ok := match.Metadata["timestamp"].(float64); ok {
-			if ttlFloat, ok := match.Metadata["ttl"].(float64); ok {
-				expirationTime := int64(timestampFloat) + int64(ttlFloat)
-				if now > expirationTime {
-					if err := v.DeleteMemory(match.ID); err != nil {
-						fmt.Printf("Failed to delete expired memory %s: %v\n", match.ID, err)
-					}
-				}
-			}
-		}
+	return response.Result, nil
+}
+
+// DeleteUserMemoriesCreatedBefore removes a user's memories whose timestamp is older than cutoff
+func (v *UpstashVectorStore) DeleteUserMemoriesCreatedBefore(userID string, cutoff int64) error {
+	request := DeleteByFilterRequest{
+		Filter: fmt.Sprintf("user_id = '%s' AND timestamp < %d", userID, cutoff),
+	}
+	truncated, err := v.truncatedMemoryIDs(request.Filter, v.queryMetadata)
+	if err != nil {
+		return fmt.Errorf("failed to delete user memories: %w", err)
+	}
+
+	if _, err := v.makeRequest("DELETE", "/delete", request); err != nil {
+		return fmt.Errorf("failed to delete user memories: %w", err)
 	}
+	v.deleteContent(truncated...)
 
 	return nil
 }
 
-func (v *VectorClient) GetStats() (map[string]interface{}, error) {
+func (v *UpstashVectorStore) GetStats() (map[string]interface{}, error) {
 	respBody, err := v.makeRequest("GET", "/info", nil)
 
 	if err != nil {
@@ -291,10 +566,10 @@ func (v *VectorClient) GetStats() (map[string]interface{}, error) {
 }
 
 // GetDimensions returns the vector dimensions from the database (with caching)
-func (v *VectorClient) GetDimensions() (int, error) {
+func (v *UpstashVectorStore) GetDimensions() (int, error) {
 	// Return cached dimensions if available
-	if v.dimensions > 0 {
-		return v.dimensions, nil
+	if cached, ok := dimensionCache.Load(v.url); ok {
+		return cached.(int), nil
 	}
 
 	// Fetch dimensions from database
@@ -314,7 +589,72 @@ func (v *VectorClient) GetDimensions() (int, error) {
 		return 0, fmt.Errorf("could not determine vector dimensions from database")
 	}
 
-	// Cache the dimensions for future use
-	v.dimensions = dimensions
+	// Cache the dimensions for future use; an index's dimension never changes
+	dimensionCache.Store(v.url, dimensions)
 	return dimensions, nil
 }
+
+// memoryMetadata returns the metadata stored with a memory's vector
+func memoryMetadata(memory *models.MemoryEntry) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"user_id":   memory.UserID,
+		"content":   memory.Content,
+		"timestamp": memory.Timestamp.Unix(),
+		"ttl":       memory.TTL,
+	}
+	if len(memory.Tags) > 0 {
+		metadata["tags"] = memory.Tags
+	}
+
+	// Add custom metadata; tags already in the metadata, e.g. from a patch, take precedence
+	for k, val := range memory.Metadata {
+		metadata[k] = val
+	}
+	return metadata
+}
+
+// matchResults converts query matches scoring at least minScore into memory results
+func matchResults(matches []QueryMatch, minScore float64) []models.MemoryResult {
+	results := make([]models.MemoryResult, 0, len(matches))
+	for _, match := range matches {
+		if match.Score < minScore {
+			continue
+		}
+
+		result := models.MemoryResult{
+			ID:       match.ID,
+			Score:    match.Score,
+			Metadata: match.Metadata,
+		}
+
+		// Add memory ID to metadata as well for backwards compatibility
+		if result.Metadata == nil {
+			result.Metadata = make(map[string]interface{})
+		}
+		result.Metadata["id"] = match.ID
+
+		// Extract content from metadata
+		if content, ok := match.Metadata["content"].(string); ok {
+			result.Content = content
+		}
+
+		// Extract timestamp from metadata
+		if timestampFloat, ok := match.Metadata["timestamp"].(float64); ok {
+			result.Timestamp = time.Unix(int64(timestampFloat), 0)
+		}
+
+		// Extract embedding provenance from metadata
+		provider, _ := match.Metadata["embedding_provider"].(string)
+		model, _ := match.Metadata["embedding_model"].(string)
+		version, _ := match.Metadata["embedding_version"].(string)
+		result.Provenance = &models.EmbeddingProvenance{
+			Provider: provider,
+			Model:    model,
+			Version:  version,
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
@@ -0,0 +1,146 @@
+package clients
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+)
+
+// contentTruncatedField marks memories whose metadata holds only the start of their content;
+// contentRefField points to the full content when the store returned a pointer for it
+const (
+	contentTruncatedField = "content_truncated"
+	contentRefField       = "content_ref"
+)
+
+// vectorContent keeps the full text of memories too long for a vector store's metadata.
+// Stores embed it so every backend offloads and hydrates content the same way.
+type vectorContent struct {
+	content ContentStore // full text of memories too long for metadata, nil keeps it in metadata
+}
+
+// SetContentStore sets where the full text of memories too long for metadata is kept.
+// Without a store all content is stored in metadata.
+func (v *vectorContent) SetContentStore(store ContentStore) {
+	v.content = store
+}
+
+// contentTruncated reports whether a memory's metadata holds truncated content
+func contentTruncated(metadata map[string]interface{}) bool {
+	truncated, _ := metadata[contentTruncatedField].(bool)
+	return truncated
+}
+
+// fitContent keeps a memory's metadata within the content limit, moving the full text of
+// longer content to the content store. Metadata keeps the start of the content, or only a
+// snippet when the store returns a pointer. When authoritative, metadata["content"] is the
+// memory's whole content, so a full text stored for an earlier, longer version is dropped.
+func (v *vectorContent) fitContent(id string, metadata map[string]interface{}, authoritative bool) error {
+	content, _ := metadata["content"].(string)
+	limit := config.AppConfig.VectorMetadataContentLimit
+
+	if v.content == nil || limit <= 0 || len(content) <= limit {
+		if authoritative && contentTruncated(metadata) {
+			delete(metadata, contentTruncatedField)
+			delete(metadata, contentRefField)
+			if v.content != nil {
+				if err := v.content.DeleteMemoryContents(id); err != nil {
+					fmt.Printf("Warning: failed to delete full content of memory %s: %v\n", id, err)
+				}
+			}
+		}
+		return nil
+	}
+
+	ref, err := v.content.SaveMemoryContent(id, content)
+	if err != nil {
+		return err
+	}
+	if ref != "" {
+		metadata[contentRefField] = ref
+		limit = config.AppConfig.BlobSnippetSize
+	} else {
+		delete(metadata, contentRefField)
+	}
+	metadata["content"] = truncateUTF8(content, limit)
+	metadata[contentTruncatedField] = true
+	return nil
+}
+
+// hydrateContent replaces truncated content in matches with the full text. If the store
+// cannot be read the truncated content is returned rather than failing the read.
+func (v *vectorContent) hydrateContent(matches []QueryMatch) {
+	if v.content == nil {
+		return
+	}
+
+	refs := make(map[string]string)
+	for _, match := range matches {
+		if contentTruncated(match.Metadata) {
+			refs[match.ID], _ = match.Metadata[contentRefField].(string)
+		}
+	}
+	if len(refs) == 0 {
+		return
+	}
+
+	contents, err := v.content.GetMemoryContents(refs)
+	if err != nil {
+		fmt.Printf("Warning: returning truncated content of %d memories: %v\n", len(refs), err)
+		return
+	}
+	for _, match := range matches {
+		if content, ok := contents[match.ID]; ok {
+			match.Metadata["content"] = content
+		}
+	}
+}
+
+// deleteContent drops the full text kept for deleted memories
+func (v *vectorContent) deleteContent(ids ...string) {
+	if v.content == nil || len(ids) == 0 {
+		return
+	}
+	if err := v.content.DeleteMemoryContents(ids...); err != nil {
+		fmt.Printf("Warning: failed to delete full content of %d memories: %v\n", len(ids), err)
+	}
+}
+
+// truncatedMemoryIDs returns the truncated memories a filter selects, listed with the
+// store's list function, so their full text can be dropped when the filter deletes them
+func (v *vectorContent) truncatedMemoryIDs(filter string, list func(filter string, limit int) ([]QueryMatch, error)) ([]string, error) {
+	if v.content == nil {
+		return nil, nil
+	}
+
+	matches, err := list(filter+" AND "+contentTruncatedField+" = true", 10000)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(matches))
+	for i, match := range matches {
+		ids[i] = match.ID
+	}
+	return ids, nil
+}
+
+// copyMetadata returns a shallow copy so fitting content does not modify the caller's map
+func copyMetadata(metadata map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(metadata)+1)
+	for k, v := range metadata {
+		copied[k] = v
+	}
+	return copied
+}
+
+// truncateUTF8 cuts s to at most limit bytes without splitting a character
+func truncateUTF8(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit]
+}
@@ -0,0 +1,549 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// MemoryVectorStore is the VectorStore VECTOR_PROVIDER=memory keeps in process memory,
+// for embedding and tests. Queries compare the query vector with every stored one; scores
+// are cosine similarity mapped to (1 + cos) / 2 as for Qdrant. Filters are evaluated on
+// their Qdrant translation. Every store in the process shares the same points.
+type MemoryVectorStore struct {
+	vectorContent
+
+	points *memoryPoints
+}
+
+// memoryPoints holds each memory's vector and its metadata as JSON, so reads return
+// fresh maps with the types a remote store's responses decode to
+type memoryPoints struct {
+	mu     sync.RWMutex
+	points map[string]memoryPoint
+}
+
+type memoryPoint struct {
+	vector   []float64
+	metadata []byte
+}
+
+var sharedMemoryPoints = &memoryPoints{points: make(map[string]memoryPoint)}
+
+func (p *memoryPoints) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.points = make(map[string]memoryPoint)
+}
+
+// ResetMemoryBackends drops everything held by the in-process Redis and vector store
+// (REDIS_PROVIDER=memory and VECTOR_PROVIDER=memory), so the next user starts empty
+func ResetMemoryBackends() {
+	sharedMemoryRedis.reset()
+	sharedMemoryPoints.reset()
+}
+
+func NewMemoryVectorStore() *MemoryVectorStore {
+	return &MemoryVectorStore{points: sharedMemoryPoints}
+}
+
+// WithContext returns a copy of the store; calls never wait on the network, so ctx is unused
+func (s *MemoryVectorStore) WithContext(ctx context.Context) VectorStore {
+	bound := *s
+	return &bound
+}
+
+// Close is a no-op; the points outlive the store until ResetMemoryBackends
+func (s *MemoryVectorStore) Close() {}
+
+// match decodes a point into a match without its vector
+func (p memoryPoint) match(id string) QueryMatch {
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(p.metadata, &metadata); err != nil {
+		metadata = make(map[string]interface{})
+	}
+	return QueryMatch{ID: id, Metadata: metadata}
+}
+
+func (s *MemoryVectorStore) UpsertMemory(memory *models.MemoryEntry) error {
+	if dimensions := config.GetEmbeddingDimensions(); len(memory.Embedding) != dimensions {
+		return fmt.Errorf("failed to upsert memory: vector has %d dimensions, expected %d", len(memory.Embedding), dimensions)
+	}
+	metadata := memoryMetadata(memory)
+	if err := s.fitContent(memory.ID, metadata, true); err != nil {
+		return fmt.Errorf("failed to upsert memory: %w", err)
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to upsert memory: %w", err)
+	}
+
+	s.points.mu.Lock()
+	defer s.points.mu.Unlock()
+	s.points.points[memory.ID] = memoryPoint{vector: append([]float64(nil), memory.Embedding...), metadata: encoded}
+	return nil
+}
+
+// QueryMemories finds a user's memories closest to the query; queryText is unused
+func (s *MemoryVectorStore) QueryMemories(userID string, filter string, queryText string, queryVector []float64, limit int, minScore float64) ([]models.MemoryResult, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	userFilter := fmt.Sprintf("user_id = '%s'", userID)
+	if filter != "" {
+		userFilter += " AND " + filter
+	}
+	selected, err := s.selectPoints(userFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query memories: %w", err)
+	}
+
+	matches := make([]QueryMatch, 0, len(selected))
+	for _, id := range selected.ids() {
+		point := selected[id]
+		if len(point.vector) != len(queryVector) {
+			return nil, fmt.Errorf("failed to query memories: query vector has %d dimensions, expected %d", len(queryVector), len(point.vector))
+		}
+		match := point.match(id)
+		match.Score = (1 + cosineSimilarity(queryVector, point.vector)) / 2
+		matches = append(matches, match)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	s.hydrateContent(matches)
+
+	return matchResults(matches, minScore), nil
+}
+
+// cosineSimilarity returns the cosine of the angle between two vectors, 0 when either is zero
+func cosineSimilarity(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// SearchKeywords ranks up to HYBRID_KEYWORD_POOL of a user's memories selected by the
+// filter against the query terms with BM25. A non-empty filter is ANDed with the user filter.
+func (s *MemoryVectorStore) SearchKeywords(userID string, filter string, queryText string, limit int) ([]models.MemoryResult, error) {
+	userFilter := fmt.Sprintf("user_id = '%s'", userID)
+	if filter != "" {
+		userFilter += " AND " + filter
+	}
+	matches, err := s.listMemories(userFilter, config.AppConfig.HybridKeywordPool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search keywords: %w", err)
+	}
+	return rankBM25(queryText, matches, limit), nil
+}
+
+// UpdateMetadata overwrites the metadata of a stored memory without touching its vector.
+// Content that was hydrated on read is truncated again.
+func (s *MemoryVectorStore) UpdateMetadata(id string, metadata map[string]interface{}) error {
+	metadata = copyMetadata(metadata)
+	if err := s.fitContent(id, metadata, false); err != nil {
+		return fmt.Errorf("failed to update memory metadata: %w", err)
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to update memory metadata: %w", err)
+	}
+
+	s.points.mu.Lock()
+	defer s.points.mu.Unlock()
+	point, ok := s.points.points[id]
+	if !ok {
+		return fmt.Errorf("failed to update memory metadata: memory %s not found", id)
+	}
+	point.metadata = encoded
+	s.points.points[id] = point
+	return nil
+}
+
+// FetchMemory returns a stored memory with its metadata, or nil if it does not exist
+func (s *MemoryVectorStore) FetchMemory(id string) (*QueryMatch, error) {
+	s.points.mu.RLock()
+	point, ok := s.points.points[id]
+	s.points.mu.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+
+	matches := []QueryMatch{point.match(id)}
+	s.hydrateContent(matches)
+	return &matches[0], nil
+}
+
+// FetchVectors returns the vectors of stored memories without their metadata, omitting
+// IDs that do not exist
+func (s *MemoryVectorStore) FetchVectors(ids []string) ([]QueryMatch, error) {
+	s.points.mu.RLock()
+	defer s.points.mu.RUnlock()
+
+	var matches []QueryMatch
+	for _, id := range ids {
+		if point, ok := s.points.points[id]; ok {
+			matches = append(matches, QueryMatch{ID: id, Vector: append([]float64(nil), point.vector...)})
+		}
+	}
+	return matches, nil
+}
+
+// RangeMemories returns one page of memories with their metadata and the cursor for the
+// next page; an empty cursor means the scan is complete
+func (s *MemoryVectorStore) RangeMemories(cursor string, limit int) ([]QueryMatch, string, error) {
+	return s.rangeMatches(cursor, limit, false)
+}
+
+// RangeVectors pages through every stored memory like RangeMemories, including vectors
+func (s *MemoryVectorStore) RangeVectors(cursor string, limit int) ([]QueryMatch, string, error) {
+	return s.rangeMatches(cursor, limit, true)
+}
+
+// rangeMatches pages through the memories in ID order; the cursor is the offset of the page
+func (s *MemoryVectorStore) rangeMatches(cursor string, limit int, includeVectors bool) ([]QueryMatch, string, error) {
+	offset, err := strconv.Atoi(cursor)
+	if err != nil || offset < 0 {
+		return nil, "", fmt.Errorf("failed to range memories: invalid cursor %q", cursor)
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+
+	selected, err := s.selectPoints("")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to range memories: %w", err)
+	}
+	ids := selected.ids()
+	if offset > len(ids) {
+		offset = len(ids)
+	}
+	end := offset + limit
+	next := strconv.Itoa(end)
+	if end >= len(ids) {
+		end = len(ids)
+		next = ""
+	}
+
+	matches := make([]QueryMatch, 0, end-offset)
+	for _, id := range ids[offset:end] {
+		match := selected[id].match(id)
+		if includeVectors {
+			match.Vector = append([]float64(nil), selected[id].vector...)
+		}
+		matches = append(matches, match)
+	}
+	s.hydrateContent(matches)
+	return matches, next, nil
+}
+
+// ListUserMemories returns up to limit memories of a user with their metadata
+func (s *MemoryVectorStore) ListUserMemories(userID string, limit int) ([]QueryMatch, error) {
+	return s.listMemories(fmt.Sprintf("user_id = '%s'", userID), limit)
+}
+
+// ListUserSummaries returns up to limit of a user's rollup summaries at one granularity
+func (s *MemoryVectorStore) ListUserSummaries(userID string, granularity string, limit int) ([]QueryMatch, error) {
+	return s.listMemories(fmt.Sprintf("user_id = '%s' AND granularity = '%s'", userID, granularity), limit)
+}
+
+// ListUserInstructions returns up to limit of a user's instruction memories
+func (s *MemoryVectorStore) ListUserInstructions(userID string, limit int) ([]QueryMatch, error) {
+	return s.listMemories(fmt.Sprintf("user_id = '%s' AND memory_type = '%s' AND HAS NOT FIELD quarantine_reason", userID, models.MemoryTypeInstruction), limit)
+}
+
+// ListQuarantinedMemories returns up to limit memories the write guard quarantined
+func (s *MemoryVectorStore) ListQuarantinedMemories(limit int) ([]QueryMatch, error) {
+	return s.listMemories("HAS FIELD quarantine_reason", limit)
+}
+
+// ListTaskMemories returns up to limit of the memories saved for a task of a tenant
+func (s *MemoryVectorStore) ListTaskMemories(tenantID string, taskID string, limit int) ([]QueryMatch, error) {
+	return s.listMemories(fmt.Sprintf("tenant_id = '%s' AND task_id = '%s'", tenantID, taskID), limit)
+}
+
+// ListAllMemories returns up to limit memories across all users
+func (s *MemoryVectorStore) ListAllMemories(limit int) ([]QueryMatch, error) {
+	return s.listMemories("", limit)
+}
+
+func (s *MemoryVectorStore) listMemories(filter string, limit int) ([]QueryMatch, error) {
+	matches, err := s.queryMetadata(filter, limit)
+	if err != nil {
+		return nil, err
+	}
+	s.hydrateContent(matches)
+	return matches, nil
+}
+
+// queryMetadata lists the memories a filter selects in ID order with their metadata as stored
+func (s *MemoryVectorStore) queryMetadata(filter string, limit int) ([]QueryMatch, error) {
+	selected, err := s.selectPoints(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memories: %w", err)
+	}
+
+	var matches []QueryMatch
+	for _, id := range selected.ids() {
+		if len(matches) == limit {
+			break
+		}
+		matches = append(matches, selected[id].match(id))
+	}
+	return matches, nil
+}
+
+func (s *MemoryVectorStore) DeleteMemory(id string) error {
+	return s.DeleteMemories([]string{id})
+}
+
+// DeleteMemories removes several memories by ID
+func (s *MemoryVectorStore) DeleteMemories(ids []string) error {
+	s.points.mu.Lock()
+	for _, id := range ids {
+		delete(s.points.points, id)
+	}
+	s.points.mu.Unlock()
+
+	s.deleteContent(ids...)
+	return nil
+}
+
+func (s *MemoryVectorStore) DeleteUserMemories(userID string) error {
+	return s.deleteByFilter(fmt.Sprintf("user_id = '%s'", userID))
+}
+
+// DeleteUserMemoriesCreatedBefore removes a user's memories whose timestamp is older than cutoff
+func (s *MemoryVectorStore) DeleteUserMemoriesCreatedBefore(userID string, cutoff int64) error {
+	return s.deleteByFilter(fmt.Sprintf("user_id = '%s' AND timestamp < %d", userID, cutoff))
+}
+
+func (s *MemoryVectorStore) deleteByFilter(filter string) error {
+	truncated, err := s.truncatedMemoryIDs(filter, s.queryMetadata)
+	if err != nil {
+		return fmt.Errorf("failed to delete user memories: %w", err)
+	}
+	selected, err := s.selectPoints(filter)
+	if err != nil {
+		return fmt.Errorf("failed to delete user memories: %w", err)
+	}
+
+	s.points.mu.Lock()
+	for id := range selected {
+		delete(s.points.points, id)
+	}
+	s.points.mu.Unlock()
+	s.deleteContent(truncated...)
+
+	return nil
+}
+
+// GetStats reports the store in the shape of Upstash Vector's info response
+func (s *MemoryVectorStore) GetStats() (map[string]interface{}, error) {
+	s.points.mu.RLock()
+	count := len(s.points.points)
+	s.points.mu.RUnlock()
+
+	return map[string]interface{}{
+		"result": map[string]interface{}{
+			"vectorCount":        count,
+			"dimension":          config.GetEmbeddingDimensions(),
+			"similarityFunction": "COSINE",
+		},
+	}, nil
+}
+
+// GetDimensions returns the dimensions of the configured embedding provider, which every
+// stored vector has
+func (s *MemoryVectorStore) GetDimensions() (int, error) {
+	return config.GetEmbeddingDimensions(), nil
+}
+
+// pointSet is a snapshot of the points a filter selected, keyed by memory ID
+type pointSet map[string]memoryPoint
+
+// ids returns the memory IDs of the set in order
+func (p pointSet) ids() []string {
+	ids := make([]string, 0, len(p))
+	for id := range p {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// selectPoints returns the points whose metadata matches an Upstash filter; an empty
+// filter selects every point
+func (s *MemoryVectorStore) selectPoints(filter string) (pointSet, error) {
+	condition, err := qdrantFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	s.points.mu.RLock()
+	defer s.points.mu.RUnlock()
+	selected := make(pointSet)
+	for id, point := range s.points.points {
+		if condition != nil {
+			var metadata map[string]interface{}
+			if err := json.Unmarshal(point.metadata, &metadata); err != nil || !conditionMatches(condition, metadata) {
+				continue
+			}
+		}
+		selected[id] = point
+	}
+	return selected, nil
+}
+
+// conditionMatches evaluates a Qdrant filter condition, as built by qdrantFilter, against
+// a memory's metadata
+func conditionMatches(condition map[string]interface{}, metadata map[string]interface{}) bool {
+	if must, ok := condition["must"].([]interface{}); ok {
+		for _, c := range must {
+			if sub, _ := c.(map[string]interface{}); !conditionMatches(sub, metadata) {
+				return false
+			}
+		}
+	}
+	if should, ok := condition["should"].([]interface{}); ok {
+		matched := false
+		for _, c := range should {
+			if sub, _ := c.(map[string]interface{}); conditionMatches(sub, metadata) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if mustNot, ok := condition["must_not"].([]interface{}); ok {
+		for _, c := range mustNot {
+			if sub, _ := c.(map[string]interface{}); conditionMatches(sub, metadata) {
+				return false
+			}
+		}
+	}
+
+	if empty, ok := condition["is_empty"].(map[string]interface{}); ok {
+		key, _ := empty["key"].(string)
+		value, found := fieldValue(metadata, key)
+		if list, isList := value.([]interface{}); isList {
+			return len(list) == 0
+		}
+		return !found || value == nil
+	}
+
+	key, ok := condition["key"].(string)
+	if !ok {
+		return true
+	}
+	value, found := fieldValue(metadata, key)
+	if !found || value == nil {
+		return false
+	}
+	// An array field matches when any of its elements does
+	values := []interface{}{value}
+	if list, isList := value.([]interface{}); isList {
+		values = list
+	}
+
+	if match, ok := condition["match"].(map[string]interface{}); ok {
+		if expected, ok := match["value"]; ok {
+			return anyValue(values, func(v interface{}) bool { return sameValue(v, expected) })
+		}
+		if options, ok := match["any"].([]interface{}); ok {
+			return anyValue(values, func(v interface{}) bool {
+				return anyValue(options, func(o interface{}) bool { return sameValue(v, o) })
+			})
+		}
+		if excluded, ok := match["except"].([]interface{}); ok {
+			return anyValue(values, func(v interface{}) bool {
+				return !anyValue(excluded, func(o interface{}) bool { return sameValue(v, o) })
+			})
+		}
+		return false
+	}
+	if bounds, ok := condition["range"].(map[string]interface{}); ok {
+		return anyValue(values, func(v interface{}) bool {
+			number, ok := v.(float64)
+			if !ok {
+				return false
+			}
+			for bound, limit := range bounds {
+				limitNumber, _ := limit.(float64)
+				switch bound {
+				case "lt":
+					ok = number < limitNumber
+				case "lte":
+					ok = number <= limitNumber
+				case "gt":
+					ok = number > limitNumber
+				case "gte":
+					ok = number >= limitNumber
+				}
+				if !ok {
+					return false
+				}
+			}
+			return true
+		})
+	}
+	return false
+}
+
+// fieldValue looks up a metadata field; dotted keys reach into nested objects
+func fieldValue(metadata map[string]interface{}, key string) (interface{}, bool) {
+	if value, ok := metadata[key]; ok {
+		return value, true
+	}
+	parts := strings.SplitN(key, ".", 2)
+	if len(parts) < 2 {
+		return nil, false
+	}
+	nested, ok := metadata[parts[0]].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return fieldValue(nested, parts[1])
+}
+
+func anyValue(values []interface{}, matches func(interface{}) bool) bool {
+	for _, value := range values {
+		if matches(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameValue compares a decoded metadata value with a filter value, whose integers are int64
+func sameValue(value interface{}, expected interface{}) bool {
+	if number, ok := expected.(int64); ok {
+		expected = float64(number)
+	}
+	switch expected.(type) {
+	case string, float64, bool:
+		return value == expected
+	}
+	return false
+}
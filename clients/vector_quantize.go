@@ -0,0 +1,118 @@
+package clients
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"sort"
+)
+
+// originalVectorField holds the full-precision vector of a memory whose index vector is
+// quantized by the client, as base64 little-endian float32s. Upstash has no native
+// quantization, so queries rescore the quantized candidates with it and reads return it.
+const originalVectorField = "original_vector"
+
+// quantizationOversampling returns how many candidates per requested result a quantized
+// search rescores, or 0 for none. Binary vectors lose more, so more are rescored.
+func quantizationOversampling(mode string) float64 {
+	switch mode {
+	case "scalar":
+		return 1.5
+	case "binary":
+		return 3
+	}
+	return 0
+}
+
+// quantizeVector returns the vector stored in the index for a quantization mode: scalar
+// rounds every dimension to one of 255 levels of the vector's largest magnitude, binary
+// keeps only the sign of each dimension. Both keep cosine distance meaningful.
+func quantizeVector(vector []float64, mode string) []float64 {
+	quantized := make([]float64, len(vector))
+	switch mode {
+	case "scalar":
+		var maxAbs float64
+		for _, x := range vector {
+			maxAbs = math.Max(maxAbs, math.Abs(x))
+		}
+		if maxAbs == 0 {
+			return quantized
+		}
+		step := maxAbs / 127
+		for i, x := range vector {
+			quantized[i] = math.Round(x/step) * step
+		}
+	case "binary":
+		unit := 1 / math.Sqrt(float64(len(vector)))
+		for i, x := range vector {
+			quantized[i] = unit
+			if x < 0 {
+				quantized[i] = -unit
+			}
+		}
+	default:
+		copy(quantized, vector)
+	}
+	return quantized
+}
+
+// encodeVector packs a vector for originalVectorField
+func encodeVector(vector []float64) string {
+	packed := make([]byte, 4*len(vector))
+	for i, x := range vector {
+		binary.LittleEndian.PutUint32(packed[4*i:], math.Float32bits(float32(x)))
+	}
+	return base64.StdEncoding.EncodeToString(packed)
+}
+
+// decodeVector unpacks a vector of originalVectorField, or returns nil if it is malformed
+func decodeVector(encoded string) []float64 {
+	packed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(packed)%4 != 0 {
+		return nil
+	}
+	vector := make([]float64, len(packed)/4)
+	for i := range vector {
+		vector[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(packed[4*i:])))
+	}
+	return vector
+}
+
+// restoreOriginalVectors removes the original vector from the metadata of matches and,
+// where vectors were read, puts it back in place of the quantized one
+func restoreOriginalVectors(matches []QueryMatch) {
+	for i := range matches {
+		encoded, ok := matches[i].Metadata[originalVectorField].(string)
+		if !ok {
+			continue
+		}
+		delete(matches[i].Metadata, originalVectorField)
+		if matches[i].Vector != nil {
+			if original := decodeVector(encoded); original != nil {
+				matches[i].Vector = original
+			}
+		}
+	}
+}
+
+// rescoreMatches scores quantized candidates by the cosine of their original vectors with
+// the query, mapped to (1 + cos) / 2 like the index scores, and keeps the best limit.
+// Candidates saved before quantization was enabled are scored with their index vector.
+func rescoreMatches(matches []QueryMatch, queryVector []float64, limit int) []QueryMatch {
+	for i := range matches {
+		vector := matches[i].Vector
+		if encoded, ok := matches[i].Metadata[originalVectorField].(string); ok {
+			if original := decodeVector(encoded); original != nil {
+				vector = original
+			}
+		}
+		matches[i].Score = (1 + cosine(queryVector, vector)) / 2
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
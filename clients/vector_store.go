@@ -0,0 +1,65 @@
+package clients
+
+import (
+	"context"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// VectorStore stores memory embeddings with their metadata. Filters are written in the
+// Upstash Vector filter syntax (=, !=, <, <=, >, >=, IN, HAS [NOT] FIELD, AND, OR and
+// parentheses); other backends translate them. Scores are normalised to [0, 1], with 1
+// for identical vectors, so thresholds mean the same on every backend. Range cursors
+// start at "0" and an empty next cursor ends the scan.
+type VectorStore interface {
+	UpsertMemory(memory *models.MemoryEntry) error
+	QueryMemories(userID string, filter string, queryText string, queryVector []float64, limit int, minScore float64) ([]models.MemoryResult, error)
+	SearchKeywords(userID string, filter string, queryText string, limit int) ([]models.MemoryResult, error)
+	UpdateMetadata(id string, metadata map[string]interface{}) error
+
+	FetchMemory(id string) (*QueryMatch, error)
+	FetchVectors(ids []string) ([]QueryMatch, error)
+	RangeMemories(cursor string, limit int) ([]QueryMatch, string, error)
+	RangeVectors(cursor string, limit int) ([]QueryMatch, string, error)
+
+	ListUserMemories(userID string, limit int) ([]QueryMatch, error)
+	ListUserSummaries(userID string, granularity string, limit int) ([]QueryMatch, error)
+	ListUserInstructions(userID string, limit int) ([]QueryMatch, error)
+	ListQuarantinedMemories(limit int) ([]QueryMatch, error)
+	ListTaskMemories(tenantID string, taskID string, limit int) ([]QueryMatch, error)
+	ListAllMemories(limit int) ([]QueryMatch, error)
+
+	DeleteMemory(id string) error
+	DeleteMemories(ids []string) error
+	DeleteUserMemories(userID string) error
+	DeleteUserMemoriesCreatedBefore(userID string, cutoff int64) error
+
+	GetStats() (map[string]interface{}, error)
+	GetDimensions() (int, error)
+	SetContentStore(store ContentStore)
+	// WithContext returns the store bound to a request: its calls are abandoned once
+	// ctx is done
+	WithContext(ctx context.Context) VectorStore
+	Close()
+}
+
+// NewVectorStore creates the vector store selected by VECTOR_PROVIDER
+func NewVectorStore() VectorStore {
+	if config.AppConfig.VectorProvider == "memory" {
+		return NewMemoryVectorStore()
+	}
+	if config.AppConfig.VectorProvider == "qdrant" {
+		return newVectorStore(config.AppConfig.QdrantURL, config.AppConfig.QdrantAPIKey, config.AppConfig.VectorQuantization)
+	}
+	return newVectorStore(config.AppConfig.UpstashVectorURL, config.AppConfig.UpstashVectorToken, config.AppConfig.VectorQuantization)
+}
+
+// newVectorStore creates a store of the configured provider at the given endpoint,
+// quantizing its vectors with the given mode
+func newVectorStore(url string, token string, quantization string) VectorStore {
+	if config.AppConfig.VectorProvider == "qdrant" {
+		return NewQdrantVectorStore(url, token, config.AppConfig.QdrantCollection, quantization)
+	}
+	return NewUpstashVectorStore(url, token, quantization)
+}
@@ -0,0 +1,322 @@
+// Command membench replays a captured query workload against a running MemoryCacheAI
+// instance and reports latency percentiles and throughput per concurrency level. With
+// -trace, queries are sent with ?trace=true and the server's own stage timings
+// (embedding, index, reinforcement, ...) are reported too; traced queries bypass the
+// query cache, so leave -trace off to measure the effect of caching.
+//
+// The workload is a JSON lines file holding one /memory/query request body per line:
+//
+//	{"user_id": "user123", "query": "what is my cat called", "limit": 5}
+//
+// Usage:
+//
+//	go run ./cmd/membench -workload queries.jsonl -concurrency 1,4,16 -requests 500
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// totalStage labels the client-side latency of whole requests
+const totalStage = "request"
+
+// levelResult is what one concurrency level measured
+type levelResult struct {
+	Concurrency int                           `json:"concurrency"`
+	Requests    int                           `json:"requests"`
+	Errors      int                           `json:"errors"`
+	Degraded    int                           `json:"degraded"`
+	DurationMs  float64                       `json:"duration_ms"`
+	Throughput  float64                       `json:"throughput_rps"`
+	Stages      map[string]map[string]float64 `json:"stages"` // stage -> p50/p95/p99/mean in ms
+	stageOrder  []string
+}
+
+func main() {
+	target := flag.String("target", "http://localhost:8080", "base URL of the instance under test")
+	path := flag.String("path", "/memory/query", "endpoint the workload is sent to")
+	workloadFile := flag.String("workload", "", "JSON lines file of captured request bodies (required)")
+	levels := flag.String("concurrency", "1,4,16", "comma-separated concurrency levels to run")
+	requests := flag.Int("requests", 0, "requests per level; defaults to the workload size")
+	warmup := flag.Int("warmup", 0, "requests sent before each level and not measured")
+	trace := flag.Bool("trace", false, "request server-side stage timings (bypasses the query cache)")
+	apiKey := flag.String("api-key", os.Getenv("MEMBENCH_API_KEY"), "API key sent as a bearer token")
+	tenant := flag.String("tenant", "", "X-Tenant-ID header sent with every request")
+	timeout := flag.Duration("timeout", 30*time.Second, "timeout of each request")
+	jsonOutput := flag.Bool("json", false, "print results as JSON instead of tables")
+	flag.Parse()
+
+	if *workloadFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	workload, err := loadWorkload(*workloadFile)
+	if err != nil {
+		log.Fatalf("Failed to load workload: %v", err)
+	}
+	concurrency, err := parseLevels(*levels)
+	if err != nil {
+		log.Fatalf("Invalid -concurrency: %v", err)
+	}
+	count := *requests
+	if count <= 0 {
+		count = len(workload)
+	}
+
+	url := strings.TrimRight(*target, "/") + *path
+	if *trace {
+		url += "?trace=true"
+	}
+	bench := &benchmark{
+		client:   &http.Client{Timeout: *timeout},
+		url:      url,
+		apiKey:   *apiKey,
+		tenant:   *tenant,
+		workload: workload,
+	}
+
+	var results []*levelResult
+	for _, level := range concurrency {
+		if *warmup > 0 {
+			bench.run(level, *warmup)
+		}
+		result := bench.run(level, count)
+		results = append(results, result)
+		if !*jsonOutput {
+			printResult(result)
+		}
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			log.Fatalf("Failed to write results: %v", err)
+		}
+	}
+}
+
+// loadWorkload reads one JSON request body per non-empty line
+func loadWorkload(file string) ([]json.RawMessage, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var workload []json.RawMessage
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024) // bodies may carry embeddings
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if !json.Valid(text) {
+			return nil, fmt.Errorf("line %d is not valid JSON", line)
+		}
+		workload = append(workload, json.RawMessage(append([]byte(nil), text...)))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(workload) == 0 {
+		return nil, fmt.Errorf("%s holds no requests", file)
+	}
+	return workload, nil
+}
+
+func parseLevels(levels string) ([]int, error) {
+	var parsed []int
+	for _, part := range strings.Split(levels, ",") {
+		level, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || level <= 0 {
+			return nil, fmt.Errorf("%q is not a positive number", part)
+		}
+		parsed = append(parsed, level)
+	}
+	return parsed, nil
+}
+
+type benchmark struct {
+	client   *http.Client
+	url      string
+	apiKey   string
+	tenant   string
+	workload []json.RawMessage
+}
+
+// sample is the latency of one request and, when traced, of each server stage
+type sample struct {
+	stages   map[string]float64
+	order    []string // stages in pipeline order
+	failed   bool
+	degraded bool
+}
+
+func (s *sample) record(stage string, ms float64) {
+	if s.stages == nil {
+		s.stages = make(map[string]float64)
+	}
+	if _, seen := s.stages[stage]; !seen {
+		s.order = append(s.order, stage)
+	}
+	s.stages[stage] = ms
+}
+
+// run sends count requests from concurrency workers, cycling through the workload
+func (b *benchmark) run(concurrency int, count int) *levelResult {
+	samples := make([]sample, count)
+	var next int64 = -1
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= count {
+					return
+				}
+				samples[i] = b.send(b.workload[i%len(b.workload)])
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	result := &levelResult{
+		Concurrency: concurrency,
+		Requests:    count,
+		DurationMs:  float64(elapsed.Microseconds()) / 1000,
+		Throughput:  float64(count) / elapsed.Seconds(),
+		Stages:      make(map[string]map[string]float64),
+	}
+	byStage := make(map[string][]float64)
+	for _, s := range samples {
+		if s.failed {
+			result.Errors++
+			continue
+		}
+		if s.degraded {
+			result.Degraded++
+		}
+		for _, stage := range s.order {
+			if _, seen := byStage[stage]; !seen {
+				result.stageOrder = append(result.stageOrder, stage)
+			}
+			byStage[stage] = append(byStage[stage], s.stages[stage])
+		}
+	}
+	for stage, latencies := range byStage {
+		result.Stages[stage] = summarize(latencies)
+	}
+	return result
+}
+
+// send posts one request body and records its latencies
+func (b *benchmark) send(body json.RawMessage) sample {
+	req, err := http.NewRequest(http.MethodPost, b.url, bytes.NewReader(body))
+	if err != nil {
+		return sample{failed: true}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+b.apiKey)
+	}
+	if b.tenant != "" {
+		req.Header.Set("X-Tenant-ID", b.tenant)
+	}
+
+	start := time.Now()
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return sample{failed: true}
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	elapsed := float64(time.Since(start).Microseconds()) / 1000
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return sample{failed: true}
+	}
+
+	var s sample
+	s.record(totalStage, elapsed)
+	var response models.QueryMemoryResponse
+	if json.Unmarshal(data, &response) != nil {
+		return s
+	}
+	s.degraded = response.Degraded
+	if response.Trace != nil {
+		s.record("embedding", response.Trace.Embedding.DurationMs)
+		for _, stage := range response.Trace.Stages {
+			s.record(stage.Name, stage.DurationMs)
+		}
+		s.record("server_total", response.Trace.TotalMs)
+	}
+	return s
+}
+
+// summarize returns the p50, p95, p99 and mean of latencies in milliseconds
+func summarize(latencies []float64) map[string]float64 {
+	sort.Float64s(latencies)
+	sum := 0.0
+	for _, ms := range latencies {
+		sum += ms
+	}
+	return map[string]float64{
+		"p50":  percentile(latencies, 50),
+		"p95":  percentile(latencies, 95),
+		"p99":  percentile(latencies, 99),
+		"mean": sum / float64(len(latencies)),
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+func printResult(result *levelResult) {
+	fmt.Printf("\nConcurrency %d: %d requests in %.0f ms, %.1f req/s, %d errors",
+		result.Concurrency, result.Requests, result.DurationMs, result.Throughput, result.Errors)
+	if result.Degraded > 0 {
+		fmt.Printf(", %d degraded", result.Degraded)
+	}
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "stage\tp50 ms\tp95 ms\tp99 ms\tmean ms\t")
+	for _, stage := range result.stageOrder {
+		summary := result.Stages[stage]
+		fmt.Fprintf(w, "%s\t%.1f\t%.1f\t%.1f\t%.1f\t\n", stage, summary["p50"], summary["p95"], summary["p99"], summary["mean"])
+	}
+	w.Flush()
+}
@@ -1,31 +1,182 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/cron"
 
 	"github.com/joho/godotenv"
 )
 
+// ClientSettings holds the tuning knobs for an outbound API client
+type ClientSettings struct {
+	Timeout      time.Duration
+	MaxRetries   int
+	MaxBatchSize int
+	Concurrency  int // 0 means unlimited
+}
+
+// BulkheadSettings bound the in-flight requests of one expensive route
+type BulkheadSettings struct {
+	Concurrency  int           // 0 disables the limit
+	QueueSize    int           // requests allowed to wait for a slot
+	QueueTimeout time.Duration // longest a queued request waits before 503
+}
+
+// FaultRule injects latency or errors into the calls one client makes while a route is
+// being served
+type FaultRule struct {
+	Target    string        // redis, vector or embedding
+	Route     string        // gin route template such as /memory/query, or * for every call
+	Latency   time.Duration // delay added to each call
+	ErrorRate float64       // fraction of calls failed, 0 to 1
+}
+
 type Config struct {
 	// Server
-	Port    string
-	GinMode string
+	Port               string
+	GinMode            string
+	CompressionMinSize int // gzip responses of at least this many bytes, 0 disables compression
+
+	// Logging
+	LogFormat            string   // "text" or "json"
+	RequestLogSampleRate float64  // share of requests logged (0-1); server errors are always logged
+	RequestLogSkipPaths  []string // paths never logged unless they fail, such as health checks
+
+	// Request deadlines; upstream calls made for a request are abandoned once it passes
+	RequestTimeout time.Duration            // 0 disables the default deadline
+	RouteTimeouts  map[string]time.Duration // by route pattern such as /memory/query; 0 exempts a route
+
+	// Shutdown on SIGTERM: readiness fails for ShutdownDelay, then in-flight requests and
+	// background writes get ShutdownTimeout to finish
+	ShutdownDelay   time.Duration
+	ShutdownTimeout time.Duration
+
+	// MCP tools act for MCPUserID when a call names no user, as single-user clients such
+	// as desktop assistants have no user ID to pass
+	MCPUserID string
+
+	// Tracing, exported over OTLP/HTTP when an endpoint is set
+	TracingEndpoint    string            // full URL of the collector's traces endpoint
+	TracingHeaders     map[string]string // sent with every export, such as an API key
+	TracingServiceName string
+	TracingSampleRate  float64 // share of new traces recorded (0-1); callers' sampling decisions are kept
+
+	// Redis: "upstash" (default; native Redis when RedisAddr is set) or "memory", which
+	// keeps the data in process and loses it on exit
+	RedisProvider string
 
 	// Upstash Redis
-	UpstashRedisURL   string
-	UpstashRedisToken string
+	UpstashRedisURL    string
+	UpstashRedisToken  string
+	RedisSearchEnabled bool   // index memory content with RediSearch (FT.*) for full-text search
+	RedisKeyPrefix     string // prepended to every key so several environments can share one database
+
+	// Native Redis over RESP; when RedisAddr is set it replaces the Upstash REST API
+	RedisAddr     string
+	RedisUsername string
+	RedisPassword string
+	RedisDB       int
+	RedisTLS      bool
+
+	// Vector store: "upstash" (default), "qdrant" or "memory" (in process, lost on exit)
+	VectorProvider   string
+	QdrantURL        string
+	QdrantAPIKey     string // sent as the api-key header; empty for unauthenticated instances
+	QdrantCollection string // created with cosine distance on first use if missing
+	// VectorQuantization ("none", "scalar" or "binary") compresses the stored vectors of
+	// Qdrant and Upstash; a data region overrides it with VECTOR_QUANTIZATION_<REGION>
+	VectorQuantization string
 
 	// Upstash Vector
 	UpstashVectorURL   string
 	UpstashVectorToken string
+	VectorIndexType    string // "dense" or "hybrid"
+	VectorFusion       string // "RRF" or "DBSF", used by hybrid queries
+	// Memories matching a query's filters that "mode": "hybrid" scores by keyword
+	HybridKeywordPool int
+	// Longest content in bytes kept in vector metadata; longer content is truncated there
+	// and stored in full outside the index. 0 stores all content in metadata.
+	VectorMetadataContentLimit int
+
+	// Content-addressed blob store for content over the metadata limit. Metadata then keeps
+	// only a pointer and a snippet instead of a truncated copy.
+	BlobStore       string // "redis" or "s3"; empty keeps full content in Redis by memory ID
+	BlobSnippetSize int    // bytes of content kept in metadata next to the blob pointer
+	BlobS3Endpoint  string // S3 or MinIO endpoint; objects are addressed path-style
+	BlobS3Region    string
+	BlobS3Bucket    string // default bucket; data regions use BLOB_S3_BUCKET_<REGION>
+	BlobS3AccessKey string
+	BlobS3SecretKey string
+	BlobS3KeyPrefix string
+
+	// Cold tier: memories older than ColdTierAfterDays move from the vector index to S3
+	ColdTierAfterDays     int    // 0 disables tiering
+	ColdTierS3Bucket      string // defaults to BLOB_S3_BUCKET; data regions use COLD_TIER_S3_BUCKET_<REGION>
+	ColdRecallMaxMemories int    // most recent cold memories a deep recall query scores per user
 
 	// Upstash QStash
 	QStashURL   string
 	QStashToken string
+	// Webhook signing keys; both are accepted so deliveries keep verifying across rotations
+	QStashCurrentSigningKey string
+	QStashNextSigningKey    string
+	// Delivery defaults for published and scheduled tasks; requests may override them
+	QStashRetries         int    // delivery attempts after the first failure
+	QStashFailureCallback string // receives messages whose retries are exhausted, empty disables
+	// Hosts that callback_url and failure_callback may point at; "*.example.com" also
+	// matches subdomains. Empty accepts any host.
+	CallbackAllowedHosts []string
+
+	// Internal scheduler, used instead of QStash schedules when QSTASH_TOKEN is empty
+	InternalSchedulerEnabled bool
+	InternalCleanupInterval  time.Duration
+	// SchedulerMode is "qstash", leaving schedules to QStash or the interval above, or
+	// "local", queueing each maintenance task in-process on its SchedulerCrons expression
+	SchedulerMode     string
+	SchedulerCrons    map[string]string // task type -> cron expression, for the tasks local mode runs
+	SchedulerTimezone string            // IANA zone the cron expressions are read in
+
+	// Write-ahead queue: saves the vector store rejects are queued in Redis and replayed
+	WriteAheadQueueEnabled   bool
+	WriteAheadQueueMax       int           // queued memories per region before saves fail again
+	WriteAheadReplayInterval time.Duration // how often queued memories are retried
+
+	// Job queue: async saves and maintenance jobs wait in Redis for a pool of workers on
+	// any replica. Failed runs are retried with exponential backoff, then dead-lettered.
+	JobWorkers      int
+	JobQueueMax     int           // waiting jobs before new ones are refused and async saves run synchronously
+	JobMaxAttempts  int           // runs of a job before it is moved to the dead-letter list
+	JobRetryBackoff time.Duration // delay before the first retry, doubled for each further one
+	JobPollInterval time.Duration // how often idle workers look for jobs and due retries
+
+	// Async saves: the session is written before answering 202 and the memory is embedded
+	// and stored by a job
+	SaveAsync bool // default of saves that do not pass ?async=
+
+	// Conversation turns: an assistant message saved right after a user message is linked
+	// to it as its answer, without naming it in reply_to
+	TurnPairing bool
+
+	// Per-tenant storage usage sampled for /admin/usage
+	UsageSampleInterval time.Duration // 0 disables periodic sampling
 
 	// Embedding Services
-	EmbeddingProvider string // "jina" or "openai"
+	EmbeddingProvider          string        // "jina", "openai" or "local" (hashed words, no API)
+	EmbeddingFailoverProviders []string      // providers tried in order when the primary fails
+	EmbeddingHealthInterval    int           // seconds between canary probes, 0 disables the monitor; 60 by default with failover providers, 0 otherwise
+	EmbeddingAutoPin           bool          // route traffic to the first healthy provider in the chain
+	EmbeddingVersion           string        // bumped by operators whenever stored vectors should be re-embedded
+	EmbeddingCanaryProvider    string        // second provider compared with the primary on sampled traffic, empty disables
+	EmbeddingCanarySampleRate  float64       // share of saves and queries compared, 0-1
+	EmbeddingCanaryTopK        int           // results whose ranking is compared per sample
+	EmbeddingCacheTTL          time.Duration // how long embeddings of identical texts are reused, 0 disables the cache
 
 	// Jina AI
 	JinaAPIKey string
@@ -33,70 +184,1530 @@ type Config struct {
 	// OpenAI
 	OpenAIAPIKey         string
 	OpenAIEmbeddingModel string
+
+	// Local embeddings
+	LocalEmbeddingDimensions int
+
+	// Embedding budgets
+	EmbeddingDailyTokenBudget int64            // default per-tenant daily budget, 0 disables enforcement
+	EmbeddingTenantBudgets    map[string]int64 // per-tenant overrides
+	EmbeddingBudgetPolicy     string           // "reject" or "keyword_only"
+
+	// Assistant personas: each assistant reads its own memories and those of the assistants
+	// it is granted here; memories saved without an assistant are visible to all of them
+	AssistantSharing map[string][]string // reading assistant -> assistants whose memories it may read
+
+	// Reinforcement of repeatedly stated memories
+	ReinforcementThreshold      float64 // similarity at which a new memory reinforces an existing one, 0 disables
+	ReinforcementSkipDuplicates bool    // keep only the reinforced memory instead of also storing the new one
+	ReinforcementWeight         float64 // query score multiplier per ln(1+reinforcement count)
+
+	// Confidence of derived (extracted, imported, summary) memories
+	ConfidenceDefault     float64       // assigned when a derived memory is saved without one
+	ConfidenceHalfLife    time.Duration // time for confidence to halve since last confirmation, 0 disables decay
+	ConfidenceVerifyBoost float64       // share of the remaining doubt removed by each confirmation
+
+	// Memory rollups (day summaries from raw memories, week and month from day summaries)
+	RollupEnabled      bool // run rollups on every internal scheduler tick
+	RollupLookbackDays int  // how many closed days each rollup revisits
+
+	// Expiry notifications
+	ExpiryWebhookURL   string        // receives memories.expiring events, empty disables notifications
+	ExpiryNoticeWindow time.Duration // how long before expiry memories are announced
+
+	// Task memories (scratchpad memories deleted when their task completes)
+	TaskMemoryTTL time.Duration // expiry of task memories whose task is never completed
+
+	// Instruction memories (always included when context is assembled)
+	InstructionMaxPerUser int // instructions a user may store
+	InstructionMaxTokens  int // instruction tokens included in assembled context, by priority
+
+	// Write guard (anomaly detection on saves and session contexts)
+	WriteGuardEnabled          bool
+	WriteGuardAction           string        // "throttle" rejects anomalous saves, "quarantine" hides them from queries
+	WriteGuardBurstLimit       int           // saves per user per minute
+	WriteGuardFanoutLimit      int           // users saving identical content within the fanout window
+	WriteGuardFanoutWindow     time.Duration // window over which identical content is counted
+	WriteGuardMaxContentTokens int           // tokens in one saved memory
+	WriteGuardMaxContextBytes  int           // JSON size of one session context
+
+	// Poisoning guard (instructions embedded in saved content)
+	PoisoningGuardMode    string // "off", "tag" (flag as untrusted) or "strip" (remove the instructions)
+	PoisoningExtraPattern string // regular expression flagged on top of the built-in patterns
+
+	// Retrieved memories placed in LLM prompts: "off", "markup" (neutralise chat-template
+	// markup) or "strict" (also remove sentences carrying instructions)
+	ContextSanitizer string
+
+	// Source trust (query score multipliers; user-authored memories weigh 1)
+	SourceTrustWeightAssistant  float64
+	SourceTrustWeightThirdParty float64
+
+	// Outbound webhook signing
+	WebhookSigningSecret string            // default HMAC secret for outbound callbacks, empty sends them unsigned
+	WebhookTenantSecrets map[string]string // per-tenant overrides
+	// Let webhooks and callbacks target private, loopback and link-local addresses
+	// (development only; otherwise callers could reach internal hosts through the service)
+	OutboundAllowPrivateNetworks bool
+
+	// SMTP, used to email memory digests
+	SMTPHost     string // empty disables email delivery
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// LLM used by generation features (summarization, extraction, titling, ask, chat)
+	LLMProvider      string            // "openai", "anthropic", "ollama" or "openai_compatible"; empty disables generation
+	LLMModel         string            // default model; each provider has its own default when empty
+	LLMBaseURL       string            // API base URL, required for openai_compatible
+	LLMAPIKey        string            // falls back to OPENAI_API_KEY for openai
+	LLMFeatureModels map[string]string // per-feature model overrides, e.g. ask:gpt-4o
+	LLMMaxTokens     int               // completion limit per request
+	LLMContextTokens int               // token budget for memories placed in a prompt
+
+	// Tokenizer
+	TokenizerRanksFile string // tiktoken rank file (e.g. cl100k_base.tiktoken) for exact counts; empty estimates
+
+	// Admin API
+	AdminAPIToken string // bearer token required by /admin endpoints; empty leaves them open
+
+	// Support impersonation with the admin token
+	ImpersonationDefaultTTL time.Duration // lifetime of an impersonation opened without ttl_seconds
+	ImpersonationMaxTTL     time.Duration // longest impersonation an admin may open
+
+	// API key authentication
+	APIKeys            []string          // keys accepted on the data endpoints, in addition to stored keys
+	APIKeyTenants      map[string]string // env keys bound to the only tenant they may act on
+	APIKeyStoreEnabled bool              // also accept keys issued through /admin/api-keys and stored in Redis
+	APIKeyCacheTTL     time.Duration     // how long a stored key lookup is cached locally, which bounds revocation delay
+
+	// Erasure reports
+	ErasureSigningKey string // HMAC key for signing erasure completion reports
+	AnonymizationSalt string // HMAC key for pseudonyms; random per run when empty
+
+	// Sessions
+	SessionTTL         time.Duration // idle time before a session expires when its tenant has no session policy
+	SessionMaxMessages int           // messages kept per session; older ones are trimmed
+	// Sessions holding more than SessionSummarizeAfter messages (0 disables) have all but
+	// the latest SessionSummaryKeep condensed into a rolling summary
+	SessionSummarizeAfter int
+	SessionSummaryKeep    int
+
+	// Startup prewarming and local caches
+	PrewarmEnabled   bool          // hold readiness until credentials are validated and caches are warm
+	PrewarmTopUsers  int           // how many of the most active users' sessions to preload
+	SessionCacheTTL  time.Duration // how long sessions are served from the local cache, 0 disables it
+	SessionCacheSize int           // maximum number of locally cached sessions
+	QueryCacheTTL    time.Duration // how long query results are served from the local cache, 0 disables it
+	QueryCacheSize   int           // maximum number of locally cached query results
+
+	// Data residency: tenants pinned to a region use that region's Upstash instances
+	DataRegions   map[string]RegionEndpoints
+	TenantRegions map[string]string // tenant ID -> region name
+
+	// Per-client timeouts, retries and budgets
+	RedisClient  ClientSettings
+	VectorClient ClientSettings
+	QStashClient ClientSettings
+	JinaClient   ClientSettings
+	OpenAIClient ClientSettings
+	LLMClient    ClientSettings
+	BlobClient   ClientSettings
+
+	// Per-route concurrency limits, keyed by route name (query, save, search, patch)
+	Bulkheads map[string]BulkheadSettings
+
+	// Dev-only faults injected into the Redis, vector and embedding clients
+	FaultRules []FaultRule
+}
+
+// RegionEndpoints holds the Redis and vector instances of one data region
+type RegionEndpoints struct {
+	RedisURL      string
+	RedisToken    string
+	RedisAddr     string // native Redis address; replaces RedisURL and RedisToken when set
+	RedisPassword string
+	VectorURL     string
+	VectorToken   string
+	Quantization  string // quantization of the region's vectors; defaults to VECTOR_QUANTIZATION
+	BlobBucket    string // S3 bucket for the region's blobs; empty keeps them in the region's Redis
+	ColdBucket    string // S3 bucket for the region's cold tier
 }
 
 var AppConfig *Config
 
+// lookupEnv reads a setting; LoadConfigFrom swaps it to read settings given in code
+var lookupEnv = os.LookupEnv
+
+// collectErrors makes fatalf panic with a configError instead of exiting, for
+// LoadConfigFrom to recover
+var collectErrors bool
+
+// configError is an invalid setting found while collectErrors is set
+type configError struct{ err error }
+
+// fatalf reports an invalid setting: LoadConfig exits, LoadConfigFrom returns it
+func fatalf(format string, args ...interface{}) {
+	err := fmt.Errorf(format, args...)
+	if collectErrors {
+		panic(configError{err})
+	}
+	log.Fatal(err)
+}
+
 func LoadConfig() {
 	// Load .env file if exists
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
 	}
+	loadConfig()
+}
+
+// LoadConfigFrom loads the configuration for programs embedding the service: settings
+// are keyed by environment variable name and override the environment, and no .env
+// file is read. Invalid settings are returned rather than ending the process.
+func LoadConfigFrom(settings map[string]string) (err error) {
+	lookupEnv = func(key string) (string, bool) {
+		if value, ok := settings[key]; ok {
+			return value, true
+		}
+		return os.LookupEnv(key)
+	}
+	collectErrors = true
+	previous := AppConfig
+	defer func() {
+		lookupEnv = os.LookupEnv
+		collectErrors = false
+		if recovered := recover(); recovered != nil {
+			invalid, ok := recovered.(configError)
+			if !ok {
+				panic(recovered)
+			}
+			AppConfig = previous
+			err = invalid.err
+		}
+	}()
 
+	loadConfig()
+	return nil
+}
+
+func loadConfig() {
 	AppConfig = &Config{
-		Port:    getEnv("PORT", "8080"),
-		GinMode: getEnv("GIN_MODE", "debug"),
+		Port:               getEnv("PORT", "8080"),
+		GinMode:            getEnv("GIN_MODE", "debug"),
+		CompressionMinSize: getEnvInt("COMPRESSION_MIN_SIZE", 1024),
+
+		RedisProvider: strings.ToLower(getEnv("REDIS_PROVIDER", "upstash")),
 
-		UpstashRedisURL:   getEnv("UPSTASH_REDIS_URL", ""),
-		UpstashRedisToken: getEnv("UPSTASH_REDIS_TOKEN", ""),
+		UpstashRedisURL:    getEnv("UPSTASH_REDIS_URL", ""),
+		UpstashRedisToken:  getEnv("UPSTASH_REDIS_TOKEN", ""),
+		RedisSearchEnabled: getEnvBool("REDIS_SEARCH_ENABLED", false),
+		RedisKeyPrefix:     getEnv("REDIS_KEY_PREFIX", ""),
+
+		RedisAddr:     getEnv("REDIS_ADDR", ""),
+		RedisUsername: getEnv("REDIS_USERNAME", ""),
+		RedisPassword: getEnv("REDIS_PASSWORD", ""),
+		RedisDB:       getEnvInt("REDIS_DB", 0),
+		RedisTLS:      getEnvBool("REDIS_TLS", false),
+
+		VectorProvider:     strings.ToLower(getEnv("VECTOR_PROVIDER", "upstash")),
+		QdrantURL:          strings.TrimSuffix(getEnv("QDRANT_URL", "http://localhost:6333"), "/"),
+		QdrantAPIKey:       getEnv("QDRANT_API_KEY", ""),
+		QdrantCollection:   getEnv("QDRANT_COLLECTION", "memories"),
+		VectorQuantization: strings.ToLower(getEnv("VECTOR_QUANTIZATION", "none")),
 
 		UpstashVectorURL:   getEnv("UPSTASH_VECTOR_URL", ""),
 		UpstashVectorToken: getEnv("UPSTASH_VECTOR_TOKEN", ""),
+		VectorIndexType:    strings.ToLower(getEnv("VECTOR_INDEX_TYPE", "dense")),
+		VectorFusion:       strings.ToUpper(getEnv("VECTOR_FUSION_ALGORITHM", "RRF")),
+		HybridKeywordPool:  getEnvInt("HYBRID_KEYWORD_POOL", 1000),
+
+		VectorMetadataContentLimit: getEnvInt("VECTOR_METADATA_CONTENT_LIMIT", 8192),
+
+		BlobStore:       strings.ToLower(getEnv("BLOB_STORE", "")),
+		BlobSnippetSize: getEnvInt("BLOB_SNIPPET_SIZE", 512),
+		BlobS3Endpoint:  strings.TrimRight(getEnv("BLOB_S3_ENDPOINT", ""), "/"),
+		BlobS3Region:    getEnv("BLOB_S3_REGION", "us-east-1"),
+		BlobS3Bucket:    getEnv("BLOB_S3_BUCKET", ""),
+		BlobS3AccessKey: getEnv("BLOB_S3_ACCESS_KEY", ""),
+		BlobS3SecretKey: getEnv("BLOB_S3_SECRET_KEY", ""),
+		BlobS3KeyPrefix: getEnv("BLOB_S3_KEY_PREFIX", "memories/"),
+
+		ColdTierAfterDays:     getEnvInt("COLD_TIER_AFTER_DAYS", 0),
+		ColdTierS3Bucket:      getEnv("COLD_TIER_S3_BUCKET", getEnv("BLOB_S3_BUCKET", "")),
+		ColdRecallMaxMemories: getEnvInt("COLD_RECALL_MAX_MEMORIES", 2000),
 
 		QStashURL:   getEnv("QSTASH_URL", "https://qstash.upstash.io"),
 		QStashToken: getEnv("QSTASH_TOKEN", ""),
 
-		EmbeddingProvider: getEnv("EMBEDDING_PROVIDER", "jina"),
+		QStashCurrentSigningKey: getEnv("QSTASH_CURRENT_SIGNING_KEY", ""),
+		QStashNextSigningKey:    getEnv("QSTASH_NEXT_SIGNING_KEY", ""),
+		QStashRetries:           getEnvInt("QSTASH_RETRIES", 3),
+		QStashFailureCallback:   getEnv("QSTASH_FAILURE_CALLBACK_URL", ""),
+		CallbackAllowedHosts:    getEnvList("CALLBACK_ALLOWED_HOSTS"),
+
+		InternalSchedulerEnabled: getEnvBool("INTERNAL_SCHEDULER_ENABLED", false),
+		InternalCleanupInterval:  getEnvDuration("INTERNAL_CLEANUP_INTERVAL", 24*time.Hour),
+		SchedulerMode:            strings.ToLower(getEnv("SCHEDULER_MODE", "qstash")),
+		SchedulerTimezone:        getEnv("SCHEDULER_TIMEZONE", "UTC"),
+
+		WriteAheadQueueEnabled:   getEnvBool("WRITE_AHEAD_QUEUE_ENABLED", false),
+		WriteAheadQueueMax:       getEnvInt("WRITE_AHEAD_QUEUE_MAX", 100000),
+		WriteAheadReplayInterval: getEnvDuration("WRITE_AHEAD_REPLAY_INTERVAL", 30*time.Second),
+
+		JobWorkers:      getEnvInt("JOB_WORKERS", 4),
+		JobQueueMax:     getEnvInt("JOB_QUEUE_MAX", 10000),
+		JobMaxAttempts:  getEnvInt("JOB_MAX_ATTEMPTS", 5),
+		JobRetryBackoff: getEnvDuration("JOB_RETRY_BACKOFF", 30*time.Second),
+		JobPollInterval: getEnvDuration("JOB_POLL_INTERVAL", time.Second),
+
+		SaveAsync: getEnvBool("SAVE_ASYNC", false),
+
+		TurnPairing: getEnvBool("TURN_PAIRING", true),
+
+		UsageSampleInterval: getEnvDuration("USAGE_SAMPLE_INTERVAL", 6*time.Hour),
+
+		EmbeddingProvider:          getEnv("EMBEDDING_PROVIDER", "jina"),
+		EmbeddingFailoverProviders: getEnvList("EMBEDDING_FAILOVER_PROVIDERS"),
+		EmbeddingAutoPin:           getEnvBool("EMBEDDING_AUTO_PIN", false),
+		EmbeddingVersion:           getEnv("EMBEDDING_VERSION", "v1"),
+		EmbeddingCanaryProvider:    strings.ToLower(getEnv("EMBEDDING_CANARY_PROVIDER", "")),
+		EmbeddingCanarySampleRate:  getEnvFloat("EMBEDDING_CANARY_SAMPLE_RATE", 0.01),
+		EmbeddingCanaryTopK:        getEnvInt("EMBEDDING_CANARY_TOP_K", 10),
+		EmbeddingCacheTTL:          getEnvDuration("EMBEDDING_CACHE_TTL", 24*time.Hour),
 
 		JinaAPIKey: getEnv("JINA_API_KEY", ""),
 
 		OpenAIAPIKey:         getEnv("OPENAI_API_KEY", ""),
 		OpenAIEmbeddingModel: getEnv("OPENAI_EMBEDDING_MODEL", "text-embedding-3-small"),
+
+		LocalEmbeddingDimensions: getEnvInt("LOCAL_EMBEDDING_DIMENSIONS", 384),
+
+		EmbeddingDailyTokenBudget: int64(getEnvInt("EMBEDDING_DAILY_TOKEN_BUDGET", 0)),
+		EmbeddingTenantBudgets:    getEnvInt64Map("EMBEDDING_TENANT_BUDGETS"),
+		EmbeddingBudgetPolicy:     getEnv("EMBEDDING_BUDGET_POLICY", "reject"),
+
+		AssistantSharing: getEnvListMap("ASSISTANT_SHARING"),
+
+		ReinforcementThreshold:      getEnvFloat("REINFORCEMENT_THRESHOLD", 0.95),
+		ReinforcementSkipDuplicates: getEnvBool("REINFORCEMENT_SKIP_DUPLICATES", false),
+		ReinforcementWeight:         getEnvFloat("REINFORCEMENT_WEIGHT", 0.1),
+
+		ConfidenceDefault:     getEnvFloat("CONFIDENCE_DEFAULT", 0.7),
+		ConfidenceHalfLife:    getEnvDuration("CONFIDENCE_HALF_LIFE", 90*24*time.Hour),
+		ConfidenceVerifyBoost: getEnvFloat("CONFIDENCE_VERIFY_BOOST", 0.5),
+
+		RollupEnabled:      getEnvBool("ROLLUP_ENABLED", false),
+		RollupLookbackDays: getEnvInt("ROLLUP_LOOKBACK_DAYS", 35),
+
+		ExpiryWebhookURL:   getEnv("EXPIRY_WEBHOOK_URL", ""),
+		ExpiryNoticeWindow: getEnvDuration("EXPIRY_NOTICE_WINDOW", 3*24*time.Hour),
+
+		TaskMemoryTTL: getEnvDuration("TASK_MEMORY_TTL", 24*time.Hour),
+
+		InstructionMaxPerUser: getEnvInt("INSTRUCTION_MAX_PER_USER", 20),
+		InstructionMaxTokens:  getEnvInt("INSTRUCTION_MAX_TOKENS", 500),
+
+		WriteGuardEnabled:          getEnvBool("WRITE_GUARD_ENABLED", false),
+		WriteGuardAction:           getEnv("WRITE_GUARD_ACTION", "throttle"),
+		WriteGuardBurstLimit:       getEnvInt("WRITE_GUARD_BURST_LIMIT", 60),
+		WriteGuardFanoutLimit:      getEnvInt("WRITE_GUARD_FANOUT_LIMIT", 20),
+		WriteGuardFanoutWindow:     getEnvDuration("WRITE_GUARD_FANOUT_WINDOW", 10*time.Minute),
+		WriteGuardMaxContentTokens: getEnvInt("WRITE_GUARD_MAX_CONTENT_TOKENS", 8000),
+		WriteGuardMaxContextBytes:  getEnvInt("WRITE_GUARD_MAX_CONTEXT_BYTES", 64*1024),
+
+		PoisoningGuardMode:    getEnv("POISONING_GUARD_MODE", "off"),
+		PoisoningExtraPattern: getEnv("POISONING_EXTRA_PATTERN", ""),
+		ContextSanitizer:      getEnv("CONTEXT_SANITIZER", "off"),
+
+		SourceTrustWeightAssistant:  getEnvFloat("SOURCE_TRUST_WEIGHT_ASSISTANT", 1.0),
+		SourceTrustWeightThirdParty: getEnvFloat("SOURCE_TRUST_WEIGHT_THIRD_PARTY", 0.8),
+
+		WebhookSigningSecret: getEnv("WEBHOOK_SIGNING_SECRET", ""),
+		WebhookTenantSecrets: getEnvPairs("WEBHOOK_TENANT_SECRETS"),
+
+		OutboundAllowPrivateNetworks: getEnvBool("OUTBOUND_ALLOW_PRIVATE_NETWORKS", false),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", "memorycache@localhost"),
+
+		LLMProvider:      strings.ToLower(getEnv("LLM_PROVIDER", "")),
+		LLMModel:         getEnv("LLM_MODEL", ""),
+		LLMBaseURL:       strings.TrimRight(getEnv("LLM_BASE_URL", ""), "/"),
+		LLMAPIKey:        getEnv("LLM_API_KEY", ""),
+		LLMFeatureModels: getEnvPairs("LLM_FEATURE_MODELS"),
+		LLMMaxTokens:     getEnvInt("LLM_MAX_TOKENS", 1024),
+		LLMContextTokens: getEnvInt("LLM_CONTEXT_TOKENS", 2000),
+
+		TokenizerRanksFile: getEnv("TOKENIZER_RANKS_FILE", ""),
+
+		AdminAPIToken: getEnv("ADMIN_API_TOKEN", ""),
+
+		ImpersonationDefaultTTL: getEnvDuration("IMPERSONATION_DEFAULT_TTL", 15*time.Minute),
+		ImpersonationMaxTTL:     getEnvDuration("IMPERSONATION_MAX_TTL", time.Hour),
+
+		APIKeyStoreEnabled: getEnvBool("API_KEY_STORE_ENABLED", false),
+		APIKeyCacheTTL:     getEnvDuration("API_KEY_CACHE_TTL", time.Minute),
+
+		ErasureSigningKey: getEnv("ERASURE_SIGNING_KEY", ""),
+		AnonymizationSalt: getEnv("ANONYMIZATION_SALT", ""),
+
+		SessionTTL:            getEnvDuration("SESSION_TTL", 24*time.Hour),
+		SessionMaxMessages:    getEnvInt("SESSION_MAX_MESSAGES", 1000),
+		SessionSummarizeAfter: getEnvInt("SESSION_SUMMARIZE_AFTER", 0),
+		SessionSummaryKeep:    getEnvInt("SESSION_SUMMARY_KEEP", 10),
+
+		PrewarmEnabled:   getEnvBool("PREWARM_ENABLED", false),
+		PrewarmTopUsers:  getEnvInt("PREWARM_TOP_USERS", 50),
+		SessionCacheTTL:  getEnvDuration("SESSION_CACHE_TTL", 30*time.Second),
+		SessionCacheSize: getEnvInt("SESSION_CACHE_SIZE", 10000),
+		QueryCacheTTL:    getEnvDuration("QUERY_CACHE_TTL", 0),
+		QueryCacheSize:   getEnvInt("QUERY_CACHE_SIZE", 10000),
+
+		TenantRegions: getEnvStringMap("TENANT_REGIONS"),
+
+		RedisClient:  loadClientSettings("REDIS", 10, 2, 100),
+		VectorClient: loadClientSettings("VECTOR", 30, 2, 1000),
+		QStashClient: loadClientSettings("QSTASH", 30, 0, 100), // publishes are not idempotent
+		JinaClient:   loadClientSettings("JINA", 30, 2, 100),
+		OpenAIClient: loadClientSettings("OPENAI", 30, 2, 100),
+		LLMClient:    loadClientSettings("LLM", 120, 1, 1), // generations are slow and costly to retry
+		BlobClient:   loadClientSettings("BLOB", 30, 2, 100),
+
+		Bulkheads: map[string]BulkheadSettings{
+			"query":  loadBulkheadSettings("QUERY", 32, 64),
+			"save":   loadBulkheadSettings("SAVE", 32, 64),
+			"search": loadBulkheadSettings("SEARCH", 16, 32),
+			"patch":  loadBulkheadSettings("PATCH", 4, 8),
+		},
+	}
+
+	if (AppConfig.RedisProvider == "memory" || AppConfig.VectorProvider == "memory") && len(getEnvList("DATA_REGIONS")) > 0 {
+		fatalf("DATA_REGIONS cannot be used with REDIS_PROVIDER=memory or VECTOR_PROVIDER=memory")
+	}
+	AppConfig.DataRegions = loadDataRegions(AppConfig.VectorProvider)
+	AppConfig.FaultRules = loadFaultRules()
+
+	// Probing only pays off when there is a failover provider to route to
+	healthInterval := 0
+	if len(AppConfig.EmbeddingFailoverProviders) > 0 {
+		healthInterval = 60
+	}
+	AppConfig.EmbeddingHealthInterval = getEnvInt("EMBEDDING_HEALTH_INTERVAL", healthInterval)
+
+	// Production logs are meant for log pipelines: JSON, with routine requests sampled
+	logFormat, sampleRate := "text", 1.0
+	if AppConfig.GinMode == "release" {
+		logFormat, sampleRate = "json", 0.1
+	}
+	AppConfig.LogFormat = strings.ToLower(getEnv("LOG_FORMAT", logFormat))
+	AppConfig.RequestLogSampleRate = getEnvFloat("REQUEST_LOG_SAMPLE_RATE", sampleRate)
+	AppConfig.RequestLogSkipPaths = []string{"/health", "/health/ready", "/metrics"}
+	if _, set := lookupEnv("REQUEST_LOG_SKIP_PATHS"); set {
+		AppConfig.RequestLogSkipPaths = getEnvList("REQUEST_LOG_SKIP_PATHS")
+	}
+
+	AppConfig.APIKeys, AppConfig.APIKeyTenants = loadAPIKeys()
+
+	// Streaming, LLM-backed and export routes run as long as their clients allow
+	AppConfig.RequestTimeout = getEnvDuration("REQUEST_TIMEOUT", 30*time.Second)
+	AppConfig.RouteTimeouts = map[string]time.Duration{
+		"/chat/completions":                 0,
+		"/memory/ask":                       0,
+		"/session/:id/export":               0,
+		"/user/:id/memories/export":         0,
+		"/user/:id/standing-queries/events": 0,
+		"/mcp/sse":                          0,
+	}
+	for route, value := range getEnvAssignments("ROUTE_TIMEOUTS") {
+		timeout, err := ParseDuration(value)
+		if err != nil || timeout < 0 {
+			fatalf("Invalid timeout for %s in ROUTE_TIMEOUTS: %q", route, value)
+		}
+		AppConfig.RouteTimeouts[route] = timeout
+	}
+
+	AppConfig.ShutdownDelay = getEnvDuration("SHUTDOWN_DELAY", 0)
+	AppConfig.ShutdownTimeout = getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second)
+
+	AppConfig.MCPUserID = getEnv("MCP_USER_ID", "")
+
+	// Tracing uses the standard OpenTelemetry variables so collectors' docs apply as-is
+	AppConfig.TracingEndpoint = getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	if endpoint := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""); AppConfig.TracingEndpoint == "" && endpoint != "" {
+		AppConfig.TracingEndpoint = strings.TrimRight(endpoint, "/") + "/v1/traces"
 	}
+	AppConfig.TracingHeaders = getEnvAssignments("OTEL_EXPORTER_OTLP_HEADERS")
+	AppConfig.TracingServiceName = getEnv("OTEL_SERVICE_NAME", "memorycache-ai")
+	AppConfig.TracingSampleRate = getEnvFloat("OTEL_TRACES_SAMPLER_ARG", 1.0)
 
 	// Validate required configs
-	if AppConfig.UpstashRedisURL == "" || AppConfig.UpstashRedisToken == "" {
-		log.Fatal("Upstash Redis configuration is required")
+	switch AppConfig.RedisProvider {
+	case "upstash":
+		if AppConfig.RedisAddr == "" && (AppConfig.UpstashRedisURL == "" || AppConfig.UpstashRedisToken == "") {
+			fatalf("Redis configuration is required: set REDIS_ADDR or UPSTASH_REDIS_URL and UPSTASH_REDIS_TOKEN")
+		}
+	case "memory":
+		if AppConfig.RedisSearchEnabled {
+			fatalf("REDIS_SEARCH_ENABLED is not supported with REDIS_PROVIDER=memory")
+		}
+	default:
+		fatalf("Invalid REDIS_PROVIDER. Must be 'upstash' or 'memory'")
+	}
+	switch AppConfig.VectorProvider {
+	case "upstash":
+		if AppConfig.UpstashVectorURL == "" || AppConfig.UpstashVectorToken == "" {
+			fatalf("Upstash Vector configuration is required")
+		}
+	case "qdrant":
+		if AppConfig.QdrantURL == "" || AppConfig.QdrantCollection == "" {
+			fatalf("VECTOR_PROVIDER=qdrant requires QDRANT_URL and QDRANT_COLLECTION")
+		}
+		if AppConfig.VectorIndexType == "hybrid" {
+			fatalf("VECTOR_INDEX_TYPE=hybrid is only supported with VECTOR_PROVIDER=upstash")
+		}
+	case "memory":
+		if AppConfig.VectorIndexType == "hybrid" {
+			fatalf("VECTOR_INDEX_TYPE=hybrid is only supported with VECTOR_PROVIDER=upstash")
+		}
+	default:
+		fatalf("Invalid VECTOR_PROVIDER. Must be 'upstash', 'qdrant' or 'memory'")
+	}
+
+	switch AppConfig.VectorIndexType {
+	case "dense", "hybrid":
+	default:
+		fatalf("Invalid VECTOR_INDEX_TYPE. Must be 'dense' or 'hybrid'")
 	}
-	if AppConfig.UpstashVectorURL == "" || AppConfig.UpstashVectorToken == "" {
-		log.Fatal("Upstash Vector configuration is required")
+	validateQuantization("VECTOR_QUANTIZATION", AppConfig.VectorQuantization)
+	for name, endpoints := range AppConfig.DataRegions {
+		validateQuantization("VECTOR_QUANTIZATION_"+strings.ToUpper(name), endpoints.Quantization)
+	}
+	switch AppConfig.VectorFusion {
+	case "RRF", "DBSF":
+	default:
+		fatalf("Invalid VECTOR_FUSION_ALGORITHM. Must be 'RRF' or 'DBSF'")
+	}
+	if AppConfig.HybridKeywordPool <= 0 || AppConfig.HybridKeywordPool > 1000 {
+		fatalf("HYBRID_KEYWORD_POOL must be between 1 and 1000")
+	}
+	if AppConfig.VectorMetadataContentLimit < 0 {
+		fatalf("VECTOR_METADATA_CONTENT_LIMIT must not be negative")
+	}
+
+	// Validate blob store configuration
+	switch AppConfig.BlobStore {
+	case "", "redis":
+	case "s3":
+		if AppConfig.BlobS3Endpoint == "" || AppConfig.BlobS3Bucket == "" || AppConfig.BlobS3AccessKey == "" || AppConfig.BlobS3SecretKey == "" {
+			fatalf("BLOB_STORE=s3 requires BLOB_S3_ENDPOINT, BLOB_S3_BUCKET, BLOB_S3_ACCESS_KEY and BLOB_S3_SECRET_KEY")
+		}
+	default:
+		fatalf("Invalid BLOB_STORE. Must be 'redis' or 's3'")
+	}
+	if AppConfig.BlobStore != "" {
+		if AppConfig.VectorMetadataContentLimit == 0 {
+			fatalf("BLOB_STORE requires a positive VECTOR_METADATA_CONTENT_LIMIT")
+		}
+		if AppConfig.BlobSnippetSize < 0 || AppConfig.BlobSnippetSize > AppConfig.VectorMetadataContentLimit {
+			fatalf("BLOB_SNIPPET_SIZE must be between 0 and VECTOR_METADATA_CONTENT_LIMIT")
+		}
+	}
+
+	// Validate cold tier configuration
+	if AppConfig.ColdTierAfterDays < 0 {
+		fatalf("COLD_TIER_AFTER_DAYS must not be negative")
+	}
+	if AppConfig.ColdTierEnabled() {
+		if AppConfig.BlobS3Endpoint == "" || AppConfig.ColdTierS3Bucket == "" || AppConfig.BlobS3AccessKey == "" || AppConfig.BlobS3SecretKey == "" {
+			fatalf("COLD_TIER_AFTER_DAYS requires BLOB_S3_ENDPOINT, COLD_TIER_S3_BUCKET (or BLOB_S3_BUCKET), BLOB_S3_ACCESS_KEY and BLOB_S3_SECRET_KEY")
+		}
+		if AppConfig.ColdRecallMaxMemories <= 0 {
+			fatalf("COLD_RECALL_MAX_MEMORIES must be positive")
+		}
+		for name, endpoints := range AppConfig.DataRegions {
+			if endpoints.ColdBucket == "" {
+				fatalf("COLD_TIER_AFTER_DAYS requires COLD_TIER_S3_BUCKET_%s (or BLOB_S3_BUCKET_%s) for data region %q",
+					strings.ToUpper(name), strings.ToUpper(name), name)
+			}
+		}
 	}
 
 	// Validate embedding provider configuration
 	switch AppConfig.EmbeddingProvider {
+	case "jina", "openai":
+		validateEmbeddingProvider(AppConfig.EmbeddingProvider)
+	case "local":
+		if AppConfig.LocalEmbeddingDimensions <= 0 {
+			fatalf("LOCAL_EMBEDDING_DIMENSIONS must be positive")
+		}
+	default:
+		fatalf("Invalid embedding provider. Must be 'jina', 'openai' or 'local'")
+	}
+
+	// Validate the failover chain
+	for i, provider := range AppConfig.EmbeddingFailoverProviders {
+		provider = strings.ToLower(provider)
+		AppConfig.EmbeddingFailoverProviders[i] = provider
+		if provider != "jina" && provider != "openai" {
+			fatalf("Invalid failover embedding provider %q. Must be 'jina' or 'openai'", provider)
+		}
+		if provider == AppConfig.EmbeddingProvider {
+			fatalf("Failover embedding provider %q duplicates the primary provider", provider)
+		}
+		validateEmbeddingProvider(provider)
+		if GetProviderDimensions(provider) != GetEmbeddingDimensions() {
+			log.Printf("Warning: failover provider %s produces %d dimensions but the primary produces %d; vectors will not be comparable",
+				provider, GetProviderDimensions(provider), GetEmbeddingDimensions())
+		}
+	}
+	if AppConfig.EmbeddingHealthInterval < 0 {
+		fatalf("EMBEDDING_HEALTH_INTERVAL must not be negative")
+	}
+	if AppConfig.EmbeddingCacheTTL < 0 {
+		fatalf("EMBEDDING_CACHE_TTL must not be negative")
+	}
+
+	// Validate the canary comparison; it ranks with both providers, so dimensions may differ
+	if provider := AppConfig.EmbeddingCanaryProvider; provider != "" {
+		if provider != "jina" && provider != "openai" {
+			fatalf("Invalid canary embedding provider %q. Must be 'jina' or 'openai'", provider)
+		}
+		if provider == AppConfig.EmbeddingProvider {
+			fatalf("Canary embedding provider %q duplicates the primary provider", provider)
+		}
+		validateEmbeddingProvider(provider)
+	}
+	if AppConfig.EmbeddingCanarySampleRate < 0 || AppConfig.EmbeddingCanarySampleRate > 1 {
+		fatalf("EMBEDDING_CANARY_SAMPLE_RATE must be between 0 and 1")
+	}
+	if AppConfig.EmbeddingCanaryTopK < 2 {
+		fatalf("EMBEDDING_CANARY_TOP_K must be at least 2")
+	}
+
+	if AppConfig.CompressionMinSize < 0 {
+		fatalf("COMPRESSION_MIN_SIZE must not be negative")
+	}
+	if AppConfig.QStashRetries < 0 {
+		fatalf("QSTASH_RETRIES must not be negative")
+	}
+	for i, host := range AppConfig.CallbackAllowedHosts {
+		host = strings.ToLower(host)
+		AppConfig.CallbackAllowedHosts[i] = host
+		if strings.Contains(host, "/") || strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+			fatalf("Invalid CALLBACK_ALLOWED_HOSTS entry %q, expected a host name or *.domain", host)
+		}
+	}
+	if AppConfig.InternalSchedulerEnabled && AppConfig.InternalCleanupInterval <= 0 {
+		fatalf("INTERNAL_CLEANUP_INTERVAL must be positive")
+	}
+	switch AppConfig.SchedulerMode {
+	case "qstash", "local":
+	default:
+		fatalf("SCHEDULER_MODE must be qstash or local, got %q", AppConfig.SchedulerMode)
+	}
+	if _, err := time.LoadLocation(AppConfig.SchedulerTimezone); err != nil {
+		fatalf("Invalid SCHEDULER_TIMEZONE %q: %v", AppConfig.SchedulerTimezone, err)
+	}
+	if AppConfig.SchedulerMode == "local" {
+		AppConfig.SchedulerCrons = loadSchedulerCrons()
+	}
+	if AppConfig.WriteAheadQueueEnabled {
+		if AppConfig.WriteAheadQueueMax <= 0 {
+			fatalf("WRITE_AHEAD_QUEUE_MAX must be positive")
+		}
+		if AppConfig.WriteAheadReplayInterval < time.Second {
+			fatalf("WRITE_AHEAD_REPLAY_INTERVAL must be at least 1s")
+		}
+	}
+	if AppConfig.JobWorkers < 0 {
+		fatalf("JOB_WORKERS must not be negative")
+	}
+	// Local schedules only queue maintenance jobs, so without workers nothing would run
+	// them; lite builds turn both off
+	if AppConfig.SchedulerMode == "local" && AppConfig.JobWorkers == 0 && !Lite {
+		fatalf("SCHEDULER_MODE=local requires JOB_WORKERS > 0")
+	}
+	if AppConfig.JobQueueMax <= 0 {
+		fatalf("JOB_QUEUE_MAX must be positive")
+	}
+	if AppConfig.JobMaxAttempts <= 0 {
+		fatalf("JOB_MAX_ATTEMPTS must be positive")
+	}
+	if AppConfig.JobRetryBackoff <= 0 {
+		fatalf("JOB_RETRY_BACKOFF must be positive")
+	}
+	if AppConfig.JobPollInterval < 100*time.Millisecond {
+		fatalf("JOB_POLL_INTERVAL must be at least 100ms")
+	}
+	if AppConfig.UsageSampleInterval < 0 {
+		fatalf("USAGE_SAMPLE_INTERVAL must not be negative")
+	}
+	if AppConfig.ReinforcementThreshold < 0 || AppConfig.ReinforcementThreshold > 1 {
+		fatalf("REINFORCEMENT_THRESHOLD must be between 0 and 1")
+	}
+	if AppConfig.ReinforcementWeight < 0 {
+		fatalf("REINFORCEMENT_WEIGHT must not be negative")
+	}
+	if AppConfig.ConfidenceDefault < 0 || AppConfig.ConfidenceDefault > 1 ||
+		AppConfig.ConfidenceVerifyBoost < 0 || AppConfig.ConfidenceVerifyBoost > 1 {
+		fatalf("CONFIDENCE_DEFAULT and CONFIDENCE_VERIFY_BOOST must be between 0 and 1")
+	}
+	if AppConfig.ConfidenceHalfLife < 0 {
+		fatalf("CONFIDENCE_HALF_LIFE must not be negative")
+	}
+	if AppConfig.RollupLookbackDays <= 0 {
+		fatalf("ROLLUP_LOOKBACK_DAYS must be positive")
+	}
+	if AppConfig.TaskMemoryTTL < time.Second {
+		fatalf("TASK_MEMORY_TTL must be at least 1s")
+	}
+	if AppConfig.InstructionMaxPerUser <= 0 || AppConfig.InstructionMaxTokens <= 0 {
+		fatalf("INSTRUCTION_MAX_PER_USER and INSTRUCTION_MAX_TOKENS must be positive")
+	}
+	if AppConfig.WriteGuardEnabled {
+		if AppConfig.WriteGuardAction != "throttle" && AppConfig.WriteGuardAction != "quarantine" {
+			fatalf("Invalid WRITE_GUARD_ACTION. Must be 'throttle' or 'quarantine'")
+		}
+		if AppConfig.WriteGuardBurstLimit <= 0 || AppConfig.WriteGuardFanoutLimit <= 0 ||
+			AppConfig.WriteGuardMaxContentTokens <= 0 || AppConfig.WriteGuardMaxContextBytes <= 0 {
+			fatalf("WRITE_GUARD limits must be positive")
+		}
+		if AppConfig.WriteGuardFanoutWindow < time.Second {
+			fatalf("WRITE_GUARD_FANOUT_WINDOW must be at least 1s")
+		}
+	}
+	switch AppConfig.PoisoningGuardMode {
+	case "off", "tag", "strip":
+	default:
+		fatalf("Invalid POISONING_GUARD_MODE. Must be 'off', 'tag' or 'strip'")
+	}
+	if AppConfig.PoisoningExtraPattern != "" {
+		if _, err := regexp.Compile(AppConfig.PoisoningExtraPattern); err != nil {
+			fatalf("Invalid POISONING_EXTRA_PATTERN: %v", err)
+		}
+	}
+	switch AppConfig.ContextSanitizer {
+	case "off", "markup", "strict":
+	default:
+		fatalf("Invalid CONTEXT_SANITIZER. Must be 'off', 'markup' or 'strict'")
+	}
+	if AppConfig.SourceTrustWeightAssistant < 0 || AppConfig.SourceTrustWeightThirdParty < 0 {
+		fatalf("SOURCE_TRUST_WEIGHT_ASSISTANT and SOURCE_TRUST_WEIGHT_THIRD_PARTY must not be negative")
+	}
+
+	// Validate generation settings
+	switch AppConfig.LLMProvider {
+	case "", "openai", "anthropic", "ollama":
+	case "openai_compatible":
+		if AppConfig.LLMBaseURL == "" {
+			fatalf("LLM_BASE_URL is required for the openai_compatible LLM provider")
+		}
+	default:
+		fatalf("Unsupported LLM_PROVIDER %q (use openai, anthropic, ollama or openai_compatible)", AppConfig.LLMProvider)
+	}
+	for feature := range AppConfig.LLMFeatureModels {
+		switch feature {
+		case "summarization", "extraction", "titling", "ask", "chat":
+		default:
+			fatalf("Unknown feature %q in LLM_FEATURE_MODELS (use summarization, extraction, titling, ask or chat)", feature)
+		}
+	}
+	if AppConfig.LLMMaxTokens <= 0 || AppConfig.LLMContextTokens <= 0 {
+		fatalf("LLM_MAX_TOKENS and LLM_CONTEXT_TOKENS must be positive")
+	}
+
+	if AppConfig.SessionTTL < time.Minute {
+		fatalf("SESSION_TTL must be at least 1m")
+	}
+	if AppConfig.SessionMaxMessages <= 0 {
+		fatalf("SESSION_MAX_MESSAGES must be positive")
+	}
+	if AppConfig.SessionSummarizeAfter < 0 {
+		fatalf("SESSION_SUMMARIZE_AFTER must not be negative")
+	}
+	if AppConfig.SessionSummarizeAfter > 0 {
+		if AppConfig.SessionSummaryKeep <= 0 || AppConfig.SessionSummaryKeep >= AppConfig.SessionSummarizeAfter {
+			fatalf("SESSION_SUMMARY_KEEP must be positive and less than SESSION_SUMMARIZE_AFTER")
+		}
+		if AppConfig.SessionSummarizeAfter >= AppConfig.SessionMaxMessages {
+			fatalf("SESSION_SUMMARIZE_AFTER must be less than SESSION_MAX_MESSAGES")
+		}
+	}
+	if AppConfig.APIKeyCacheTTL < 0 {
+		fatalf("API_KEY_CACHE_TTL must not be negative")
+	}
+	if AppConfig.ImpersonationDefaultTTL < time.Second || AppConfig.ImpersonationMaxTTL < AppConfig.ImpersonationDefaultTTL {
+		fatalf("IMPERSONATION_DEFAULT_TTL must be at least 1s and IMPERSONATION_MAX_TTL at least IMPERSONATION_DEFAULT_TTL")
+	}
+	if AppConfig.PrewarmTopUsers < 0 || AppConfig.SessionCacheSize < 0 || AppConfig.QueryCacheSize < 0 {
+		fatalf("PREWARM_TOP_USERS, SESSION_CACHE_SIZE and QUERY_CACHE_SIZE must not be negative")
+	}
+
+	// Validate data residency routing
+	for tenantID, region := range AppConfig.TenantRegions {
+		if _, ok := AppConfig.DataRegions[region]; !ok {
+			fatalf("Tenant %q is pinned to unknown data region %q; add it to DATA_REGIONS", tenantID, region)
+		}
+	}
+
+	// Validate embedding budgets
+	if AppConfig.EmbeddingDailyTokenBudget < 0 {
+		fatalf("EMBEDDING_DAILY_TOKEN_BUDGET must not be negative")
+	}
+	switch AppConfig.EmbeddingBudgetPolicy {
+	case "reject", "keyword_only":
+	default:
+		fatalf("Invalid EMBEDDING_BUDGET_POLICY. Must be 'reject' or 'keyword_only'")
+	}
+
+	// Validate client settings
+	validateClientSettings("REDIS", AppConfig.RedisClient)
+	validateClientSettings("VECTOR", AppConfig.VectorClient)
+	validateClientSettings("QSTASH", AppConfig.QStashClient)
+	validateClientSettings("JINA", AppConfig.JinaClient)
+	validateClientSettings("OPENAI", AppConfig.OpenAIClient)
+	validateClientSettings("LLM", AppConfig.LLMClient)
+	validateClientSettings("BLOB", AppConfig.BlobClient)
+
+	for route, settings := range AppConfig.Bulkheads {
+		if settings.Concurrency < 0 || settings.QueueSize < 0 || settings.QueueTimeout < 0 {
+			fatalf("BULKHEAD_%s_* settings must not be negative", strings.ToUpper(route))
+		}
+	}
+
+	if AppConfig.LogFormat != "text" && AppConfig.LogFormat != "json" {
+		fatalf("LOG_FORMAT must be text or json, got %q", AppConfig.LogFormat)
+	}
+	if AppConfig.RequestLogSampleRate < 0 || AppConfig.RequestLogSampleRate > 1 {
+		fatalf("REQUEST_LOG_SAMPLE_RATE must be between 0 and 1")
+	}
+	if AppConfig.RequestTimeout < 0 {
+		fatalf("REQUEST_TIMEOUT must not be negative")
+	}
+	if AppConfig.ShutdownDelay < 0 {
+		fatalf("SHUTDOWN_DELAY must not be negative")
+	}
+	if AppConfig.ShutdownTimeout <= 0 {
+		fatalf("SHUTDOWN_TIMEOUT must be positive")
+	}
+	if AppConfig.TracingEndpoint != "" {
+		if !strings.HasPrefix(AppConfig.TracingEndpoint, "http://") && !strings.HasPrefix(AppConfig.TracingEndpoint, "https://") {
+			fatalf("OTEL_EXPORTER_OTLP_ENDPOINT must be an http(s) URL, got %q", AppConfig.TracingEndpoint)
+		}
+		if AppConfig.TracingSampleRate < 0 || AppConfig.TracingSampleRate > 1 {
+			fatalf("OTEL_TRACES_SAMPLER_ARG must be between 0 and 1")
+		}
+	}
+
+	if len(AppConfig.FaultRules) > 0 && AppConfig.GinMode == "release" {
+		fatalf("FAULT_INJECTION is for development and testing and cannot be used with GIN_MODE=release")
+	}
+
+	if Lite {
+		applyLite()
+	}
+}
+
+// applyLite rejects the backends a lite build cannot use and switches off what would run
+// in the background: QStash, the internal scheduler, the write-ahead queue, usage sampling,
+// embedding health probes and canaries, session condensing, prewarming and span export
+func applyLite() {
+	if AppConfig.RedisProvider != "upstash" {
+		fatalf("REDIS_PROVIDER must be upstash in lite builds, got %q", AppConfig.RedisProvider)
+	}
+	if AppConfig.RedisAddr != "" {
+		fatalf("REDIS_ADDR is not supported in lite builds; set UPSTASH_REDIS_URL and UPSTASH_REDIS_TOKEN")
+	}
+	for region, endpoints := range AppConfig.DataRegions {
+		if endpoints.RedisAddr != "" {
+			fatalf("REDIS_ADDR_%s is not supported in lite builds", strings.ToUpper(region))
+		}
+	}
+	if AppConfig.VectorProvider != "upstash" {
+		fatalf("VECTOR_PROVIDER must be upstash in lite builds, got %q", AppConfig.VectorProvider)
+	}
+	if AppConfig.BlobStore == "s3" {
+		fatalf("BLOB_STORE=s3 is not supported in lite builds; use BLOB_STORE=redis")
+	}
+	if AppConfig.ColdTierEnabled() {
+		fatalf("COLD_TIER_AFTER_DAYS is not supported in lite builds")
+	}
+
+	AppConfig.QStashToken = ""
+	AppConfig.InternalSchedulerEnabled = false
+	AppConfig.SchedulerMode = "qstash"
+	AppConfig.SchedulerCrons = nil
+	AppConfig.WriteAheadQueueEnabled = false
+	AppConfig.SaveAsync = false
+	AppConfig.JobWorkers = 0
+	AppConfig.UsageSampleInterval = 0
+	AppConfig.EmbeddingHealthInterval = 0
+	AppConfig.EmbeddingCanaryProvider = ""
+	AppConfig.SessionSummarizeAfter = 0
+	AppConfig.PrewarmEnabled = false
+	AppConfig.TracingEndpoint = ""
+}
+
+// loadBulkheadSettings reads BULKHEAD_<ROUTE>_CONCURRENCY, BULKHEAD_<ROUTE>_QUEUE_SIZE
+// and BULKHEAD_<ROUTE>_QUEUE_TIMEOUT
+func loadBulkheadSettings(route string, concurrency, queueSize int) BulkheadSettings {
+	prefix := "BULKHEAD_" + route
+	return BulkheadSettings{
+		Concurrency:  getEnvInt(prefix+"_CONCURRENCY", concurrency),
+		QueueSize:    getEnvInt(prefix+"_QUEUE_SIZE", queueSize),
+		QueueTimeout: getEnvDuration(prefix+"_QUEUE_TIMEOUT", 5*time.Second),
+	}
+}
+
+// loadClientSettings reads the <PREFIX>_TIMEOUT_SECONDS, <PREFIX>_MAX_RETRIES,
+// <PREFIX>_MAX_BATCH_SIZE and <PREFIX>_CONCURRENCY variables
+func loadClientSettings(prefix string, timeoutSeconds, maxRetries, maxBatchSize int) ClientSettings {
+	return ClientSettings{
+		Timeout:      time.Duration(getEnvInt(prefix+"_TIMEOUT_SECONDS", timeoutSeconds)) * time.Second,
+		MaxRetries:   getEnvInt(prefix+"_MAX_RETRIES", maxRetries),
+		MaxBatchSize: getEnvInt(prefix+"_MAX_BATCH_SIZE", maxBatchSize),
+		Concurrency:  getEnvInt(prefix+"_CONCURRENCY", 0),
+	}
+}
+
+func validateClientSettings(prefix string, settings ClientSettings) {
+	if settings.Timeout <= 0 {
+		fatalf("%s_TIMEOUT_SECONDS must be positive", prefix)
+	}
+	if settings.MaxRetries < 0 || settings.MaxRetries > 10 {
+		fatalf("%s_MAX_RETRIES must be between 0 and 10", prefix)
+	}
+	if settings.MaxBatchSize <= 0 {
+		fatalf("%s_MAX_BATCH_SIZE must be positive", prefix)
+	}
+	if settings.Concurrency < 0 {
+		fatalf("%s_CONCURRENCY must not be negative", prefix)
+	}
+}
+
+// QStashConfigured reports whether QStash can be used for scheduling
+func (c *Config) QStashConfigured() bool {
+	return c.QStashToken != ""
+}
+
+// APIAuthEnabled reports whether the data endpoints require an API key
+func (c *Config) APIAuthEnabled() bool {
+	return len(c.APIKeys) > 0 || c.APIKeyStoreEnabled
+}
+
+// ColdTierEnabled reports whether old memories are moved to the cold tier
+func (c *Config) ColdTierEnabled() bool {
+	return c.ColdTierAfterDays > 0
+}
+
+// RouteTimeout returns the deadline of requests to a route pattern, 0 for none
+func (c *Config) RouteTimeout(route string) time.Duration {
+	if timeout, ok := c.RouteTimeouts[route]; ok {
+		return timeout
+	}
+	return c.RequestTimeout
+}
+
+// InternalSchedulerActive reports whether the internal scheduler runs maintenance tasks:
+// always in local mode, otherwise when enabled and QStash is not configured
+func (c *Config) InternalSchedulerActive() bool {
+	return c.SchedulerMode == "local" || (c.InternalSchedulerEnabled && !c.QStashConfigured())
+}
+
+// Summary returns the effective configuration with credentials reduced to presence flags
+func (c *Config) Summary() map[string]interface{} {
+	return map[string]interface{}{
+		"server": map[string]interface{}{
+			"port":                 c.Port,
+			"gin_mode":             c.GinMode,
+			"compression_min_size": c.CompressionMinSize,
+			"lite":                 Lite,
+		},
+		"logging": map[string]interface{}{
+			"format":                  c.LogFormat,
+			"request_log_sample_rate": c.RequestLogSampleRate,
+			"request_log_skip_paths":  c.RequestLogSkipPaths,
+		},
+		"timeouts": map[string]interface{}{
+			"request": c.RequestTimeout.String(),
+			"routes":  durationStrings(c.RouteTimeouts),
+		},
+		"shutdown": map[string]interface{}{
+			"delay":   c.ShutdownDelay.String(),
+			"timeout": c.ShutdownTimeout.String(),
+		},
+		"mcp": map[string]interface{}{
+			"default_user": c.MCPUserID != "",
+		},
+		"tracing": map[string]interface{}{
+			"endpoint":           c.TracingEndpoint,
+			"headers_configured": len(c.TracingHeaders) > 0,
+			"service_name":       c.TracingServiceName,
+			"sample_rate":        c.TracingSampleRate,
+		},
+		"redis": map[string]interface{}{
+			"provider":         c.RedisProvider,
+			"url":              c.UpstashRedisURL,
+			"token_configured": c.UpstashRedisToken != "",
+			"search_enabled":   c.RedisSearchEnabled,
+			"key_prefix":       c.RedisKeyPrefix,
+			"native": map[string]interface{}{
+				"addr":                c.RedisAddr,
+				"password_configured": c.RedisPassword != "",
+				"db":                  c.RedisDB,
+				"tls":                 c.RedisTLS,
+			},
+			"client": c.RedisClient.summary(),
+		},
+		"vector": map[string]interface{}{
+			"provider":         c.VectorProvider,
+			"url":              c.UpstashVectorURL,
+			"token_configured": c.UpstashVectorToken != "",
+			"qdrant": map[string]interface{}{
+				"url":                c.QdrantURL,
+				"api_key_configured": c.QdrantAPIKey != "",
+				"collection":         c.QdrantCollection,
+			},
+			"quantization":     c.VectorQuantization,
+			"index_type":       c.VectorIndexType,
+			"fusion_algorithm": c.VectorFusion,
+			"keyword_pool":     c.HybridKeywordPool,
+			"content_limit":    c.VectorMetadataContentLimit,
+			"client":           c.VectorClient.summary(),
+		},
+		"blob_store": map[string]interface{}{
+			"store":                    c.BlobStore,
+			"snippet_size":             c.BlobSnippetSize,
+			"s3_endpoint":              c.BlobS3Endpoint,
+			"s3_region":                c.BlobS3Region,
+			"s3_bucket":                c.BlobS3Bucket,
+			"s3_key_prefix":            c.BlobS3KeyPrefix,
+			"s3_access_key_configured": c.BlobS3AccessKey != "",
+			"client":                   c.BlobClient.summary(),
+		},
+		"cold_tier": map[string]interface{}{
+			"enabled":             c.ColdTierEnabled(),
+			"after_days":          c.ColdTierAfterDays,
+			"s3_bucket":           c.ColdTierS3Bucket,
+			"recall_max_memories": c.ColdRecallMaxMemories,
+		},
+		"qstash": map[string]interface{}{
+			"url":                            c.QStashURL,
+			"token_configured":               c.QStashConfigured(),
+			"current_signing_key_configured": c.QStashCurrentSigningKey != "",
+			"next_signing_key_configured":    c.QStashNextSigningKey != "",
+			"retries":                        c.QStashRetries,
+			"failure_callback_configured":    c.QStashFailureCallback != "",
+			"callback_allowed_hosts":         c.CallbackAllowedHosts,
+			"client":                         c.QStashClient.summary(),
+			"internal_scheduler": map[string]interface{}{
+				"enabled":          c.InternalSchedulerEnabled,
+				"active":           c.InternalSchedulerActive(),
+				"cleanup_interval": c.InternalCleanupInterval.String(),
+				"mode":             c.SchedulerMode,
+				"crons":            c.SchedulerCrons,
+				"timezone":         c.SchedulerTimezone,
+			},
+		},
+		"write_ahead_queue": map[string]interface{}{
+			"enabled":         c.WriteAheadQueueEnabled,
+			"max":             c.WriteAheadQueueMax,
+			"replay_interval": c.WriteAheadReplayInterval.String(),
+		},
+		"job_queue": map[string]interface{}{
+			"workers":       c.JobWorkers,
+			"max":           c.JobQueueMax,
+			"max_attempts":  c.JobMaxAttempts,
+			"retry_backoff": c.JobRetryBackoff.String(),
+			"poll_interval": c.JobPollInterval.String(),
+		},
+		"async_saves": map[string]interface{}{
+			"default": c.SaveAsync,
+		},
+		"turn_pairing": c.TurnPairing,
+		"usage": map[string]interface{}{
+			"sample_interval": c.UsageSampleInterval.String(),
+		},
+		"embedding": map[string]interface{}{
+			"provider":           c.EmbeddingProvider,
+			"failover_providers": c.EmbeddingFailoverProviders,
+			"health_interval":    c.EmbeddingHealthInterval,
+			"auto_pin":           c.EmbeddingAutoPin,
+			"canary": map[string]interface{}{
+				"provider":    c.EmbeddingCanaryProvider,
+				"sample_rate": c.EmbeddingCanarySampleRate,
+				"top_k":       c.EmbeddingCanaryTopK,
+			},
+			"cache_ttl":         c.EmbeddingCacheTTL.String(),
+			"openai_model":      c.OpenAIEmbeddingModel,
+			"local_dimensions":  c.LocalEmbeddingDimensions,
+			"version":           c.EmbeddingVersion,
+			"jina_configured":   c.JinaAPIKey != "",
+			"openai_configured": c.OpenAIAPIKey != "",
+			"jina_client":       c.JinaClient.summary(),
+			"openai_client":     c.OpenAIClient.summary(),
+		},
+		"llm": map[string]interface{}{
+			"provider":       c.LLMProvider,
+			"model":          c.LLMModel,
+			"base_url":       c.LLMBaseURL,
+			"feature_models": c.LLMFeatureModels,
+			"max_tokens":     c.LLMMaxTokens,
+			"context_tokens": c.LLMContextTokens,
+			"key_configured": c.LLMAPIKey != "",
+			"client":         c.LLMClient.summary(),
+		},
+		"tokenizer": map[string]interface{}{
+			"ranks_file": c.TokenizerRanksFile,
+		},
+		"assistant_sharing": c.AssistantSharing,
+		"reinforcement": map[string]interface{}{
+			"threshold":       c.ReinforcementThreshold,
+			"skip_duplicates": c.ReinforcementSkipDuplicates,
+			"weight":          c.ReinforcementWeight,
+		},
+		"confidence": map[string]interface{}{
+			"default":      c.ConfidenceDefault,
+			"half_life":    c.ConfidenceHalfLife.String(),
+			"verify_boost": c.ConfidenceVerifyBoost,
+		},
+		"rollups": map[string]interface{}{
+			"enabled":       c.RollupEnabled,
+			"lookback_days": c.RollupLookbackDays,
+		},
+		"expiry_notifications": map[string]interface{}{
+			"enabled":       c.ExpiryWebhookURL != "",
+			"notice_window": c.ExpiryNoticeWindow.String(),
+		},
+		"task_memories": map[string]interface{}{
+			"ttl": c.TaskMemoryTTL.String(),
+		},
+		"instructions": map[string]interface{}{
+			"max_per_user": c.InstructionMaxPerUser,
+			"max_tokens":   c.InstructionMaxTokens,
+		},
+		"write_guard": map[string]interface{}{
+			"enabled":            c.WriteGuardEnabled,
+			"action":             c.WriteGuardAction,
+			"burst_limit":        c.WriteGuardBurstLimit,
+			"fanout_limit":       c.WriteGuardFanoutLimit,
+			"fanout_window":      c.WriteGuardFanoutWindow.String(),
+			"max_content_tokens": c.WriteGuardMaxContentTokens,
+			"max_context_bytes":  c.WriteGuardMaxContextBytes,
+		},
+		"poisoning_guard": map[string]interface{}{
+			"mode":          c.PoisoningGuardMode,
+			"extra_pattern": c.PoisoningExtraPattern != "",
+		},
+		"context_sanitizer": c.ContextSanitizer,
+		"source_trust_weights": map[string]interface{}{
+			"user":        1.0,
+			"assistant":   c.SourceTrustWeightAssistant,
+			"third_party": c.SourceTrustWeightThirdParty,
+		},
+		"outbound_webhooks": map[string]interface{}{
+			"signing_secret_configured": c.WebhookSigningSecret != "",
+			"tenant_secrets":            len(c.WebhookTenantSecrets),
+			"allow_private_networks":    c.OutboundAllowPrivateNetworks,
+		},
+		"sessions": map[string]interface{}{
+			"ttl":             c.SessionTTL.String(),
+			"max_messages":    c.SessionMaxMessages,
+			"summarize_after": c.SessionSummarizeAfter,
+			"summary_keep":    c.SessionSummaryKeep,
+		},
+		"prewarm": map[string]interface{}{
+			"enabled":            c.PrewarmEnabled,
+			"top_users":          c.PrewarmTopUsers,
+			"session_cache_ttl":  c.SessionCacheTTL.String(),
+			"session_cache_size": c.SessionCacheSize,
+			"query_cache_ttl":    c.QueryCacheTTL.String(),
+			"query_cache_size":   c.QueryCacheSize,
+		},
+		"data_residency": c.dataResidencySummary(),
+		"bulkheads":      c.bulkheadSummary(),
+		"fault_rules":    len(c.FaultRules),
+		"smtp": map[string]interface{}{
+			"host":                c.SMTPHost,
+			"port":                c.SMTPPort,
+			"from":                c.SMTPFrom,
+			"username_configured": c.SMTPUsername != "",
+		},
+		"admin": map[string]interface{}{
+			"token_configured":          c.AdminAPIToken != "",
+			"impersonation_default_ttl": c.ImpersonationDefaultTTL.String(),
+			"impersonation_max_ttl":     c.ImpersonationMaxTTL.String(),
+		},
+		"api_auth": map[string]interface{}{
+			"enabled":        c.APIAuthEnabled(),
+			"env_keys":       len(c.APIKeys),
+			"bound_env_keys": len(c.APIKeyTenants),
+			"store_enabled":  c.APIKeyStoreEnabled,
+			"cache_ttl":      c.APIKeyCacheTTL.String(),
+		},
+		"erasure": map[string]interface{}{
+			"signing_key_configured":        c.ErasureSigningKey != "",
+			"anonymization_salt_configured": c.AnonymizationSalt != "",
+		},
+		"embedding_budget": map[string]interface{}{
+			"daily_token_budget": c.EmbeddingDailyTokenBudget,
+			"tenant_budgets":     c.EmbeddingTenantBudgets,
+			"policy":             c.EmbeddingBudgetPolicy,
+		},
+	}
+}
+
+func (s ClientSettings) summary() map[string]interface{} {
+	return map[string]interface{}{
+		"timeout_seconds": s.Timeout.Seconds(),
+		"max_retries":     s.MaxRetries,
+		"max_batch_size":  s.MaxBatchSize,
+		"concurrency":     s.Concurrency,
+	}
+}
+
+// validateEmbeddingProvider checks that the credentials for a provider are present
+func validateEmbeddingProvider(provider string) {
+	switch provider {
 	case "jina":
 		if AppConfig.JinaAPIKey == "" {
-			log.Fatal("Jina API key is required when using Jina provider")
+			fatalf("Jina API key is required when using Jina provider")
 		}
 	case "openai":
 		if AppConfig.OpenAIAPIKey == "" {
-			log.Fatal("OpenAI API key is required when using OpenAI provider")
+			fatalf("OpenAI API key is required when using OpenAI provider")
 		}
-	default:
-		log.Fatal("Invalid embedding provider. Must be 'jina' or 'openai'")
 	}
 }
 
+// getenv returns a setting, empty when it is not set
+func getenv(key string) string {
+	value, _ := lookupEnv(key)
+	return value
+}
+
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
+func getEnvInt(key string, defaultValue int) int {
+	value := getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		fatalf("Invalid integer value for %s: %q", key, value)
+	}
+	return parsed
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		fatalf("Invalid number value for %s: %q", key, value)
+	}
+	return parsed
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value := getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		fatalf("Invalid boolean value for %s: %q", key, value)
+	}
+	return parsed
+}
+
+// getEnvList parses a comma-separated variable, dropping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, item := range strings.Split(getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// getEnvDuration parses durations such as "36h" or "7d"
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := ParseDuration(value)
+	if err != nil {
+		fatalf("Invalid duration for %s: %q", key, value)
+	}
+	return parsed
+}
+
+// ParseDuration extends time.ParseDuration with a "d" (day) unit, e.g. "7d"
+func ParseDuration(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// getEnvInt64Map parses "key:value,key:value" pairs such as per-tenant budgets
+func getEnvInt64Map(key string) map[string]int64 {
+	values := make(map[string]int64)
+	for _, item := range getEnvList(key) {
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 {
+			fatalf("Invalid entry %q in %s, expected key:value", item, key)
+		}
+		parsed, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+		if err != nil || parsed < 0 {
+			fatalf("Invalid value in %s for %q", key, parts[0])
+		}
+		values[strings.TrimSpace(parts[0])] = parsed
+	}
+	return values
+}
+
+// getEnvStringMap parses a comma-separated list of key:value pairs, lowercasing the values
+func getEnvStringMap(key string) map[string]string {
+	values := getEnvPairs(key)
+	for k, v := range values {
+		values[k] = strings.ToLower(v)
+	}
+	return values
+}
+
+// getEnvPairs parses a comma-separated list of key:value pairs, keeping values as given
+func getEnvPairs(key string) map[string]string {
+	values := make(map[string]string)
+	for _, item := range getEnvList(key) {
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+			fatalf("Invalid entry %q in %s, expected key:value", item, key)
+		}
+		values[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return values
+}
+
+// durationStrings renders durations for the configuration summary
+func durationStrings(durations map[string]time.Duration) map[string]string {
+	rendered := make(map[string]string, len(durations))
+	for key, d := range durations {
+		rendered[key] = d.String()
+	}
+	return rendered
+}
+
+// getEnvAssignments parses a comma-separated list of key=value pairs, the format of the
+// OpenTelemetry header variables
+func getEnvAssignments(key string) map[string]string {
+	values := make(map[string]string)
+	for _, item := range getEnvList(key) {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			fatalf("Invalid entry in %s, expected key=value", key)
+		}
+		values[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return values
+}
+
+// getEnvListMap parses "key:a|b,key:c" pairs into lists of values
+func getEnvListMap(key string) map[string][]string {
+	values := make(map[string][]string)
+	for k, v := range getEnvPairs(key) {
+		for _, item := range strings.Split(v, "|") {
+			if item = strings.TrimSpace(item); item != "" {
+				values[k] = append(values[k], item)
+			}
+		}
+	}
+	return values
+}
+
+// loadAPIKeys parses API_KEYS, a comma-separated list of keys. An entry "tenant:key"
+// binds the key to that tenant.
+func loadAPIKeys() ([]string, map[string]string) {
+	var keys []string
+	tenants := make(map[string]string)
+	for _, entry := range getEnvList("API_KEYS") {
+		key := entry
+		if i := strings.Index(entry, ":"); i >= 0 {
+			tenant := strings.TrimSpace(entry[:i])
+			key = strings.TrimSpace(entry[i+1:])
+			if tenant == "" || key == "" {
+				fatalf("Invalid API_KEYS entry, expected key or tenant:key")
+			}
+			tenants[key] = tenant
+		}
+		keys = append(keys, key)
+	}
+	return keys, tenants
+}
+
+// localSchedulerTasks are the maintenance tasks SCHEDULER_MODE=local can run
+var localSchedulerTasks = []string{
+	"cleanup_expired_memories",
+	"notify_expiring_memories",
+	"apply_session_policies",
+	"rollup_memories",
+	"tier_cold_memories",
+}
+
+// loadSchedulerCrons reads the cron expression of each task local mode runs:
+// SCHEDULER_CRON_<TASK> (e.g. SCHEDULER_CRON_ROLLUP_MEMORIES), defaulting to
+// SCHEDULER_CRON. "off" leaves a task out.
+func loadSchedulerCrons() map[string]string {
+	defaultCron := getEnv("SCHEDULER_CRON", "0 2 * * *")
+	crons := make(map[string]string, len(localSchedulerTasks))
+	for _, task := range localSchedulerTasks {
+		key := "SCHEDULER_CRON_" + strings.ToUpper(task)
+		expr := strings.TrimSpace(getEnv(key, ""))
+		if expr == "" {
+			key, expr = "SCHEDULER_CRON", defaultCron
+		}
+		if strings.EqualFold(expr, "off") {
+			continue
+		}
+		if _, err := cron.Parse(expr); err != nil {
+			fatalf("Invalid %s: %v", key, err)
+		}
+		crons[task] = expr
+	}
+	return crons
+}
+
+// loadFaultRules parses FAULT_INJECTION, a comma-separated list of
+// <target>@<route>=<effect> rules such as "vector@/memory/query=error" or
+// "redis@*=latency:300ms". Effects are latency:<duration>, error (every call) and
+// error:<rate> (a fraction of calls).
+func loadFaultRules() []FaultRule {
+	var rules []FaultRule
+	for _, item := range getEnvList("FAULT_INJECTION") {
+		scope, effect, ok := strings.Cut(item, "=")
+		target, route, ok2 := strings.Cut(scope, "@")
+		if !ok || !ok2 || route == "" {
+			fatalf("Invalid entry %q in FAULT_INJECTION, expected <target>@<route>=<effect>", item)
+		}
+		rule := FaultRule{Target: strings.ToLower(strings.TrimSpace(target)), Route: strings.TrimSpace(route)}
+		switch rule.Target {
+		case "redis", "vector", "embedding":
+		default:
+			fatalf("Invalid FAULT_INJECTION target %q. Must be 'redis', 'vector' or 'embedding'", target)
+		}
+
+		kind, value, hasValue := strings.Cut(strings.TrimSpace(effect), ":")
+		switch {
+		case kind == "latency" && hasValue:
+			latency, err := ParseDuration(value)
+			if err != nil || latency <= 0 {
+				fatalf("Invalid FAULT_INJECTION latency in %q", item)
+			}
+			rule.Latency = latency
+		case kind == "error" && !hasValue:
+			rule.ErrorRate = 1
+		case kind == "error":
+			rate, err := strconv.ParseFloat(value, 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				fatalf("Invalid FAULT_INJECTION error rate in %q, must be in (0, 1]", item)
+			}
+			rule.ErrorRate = rate
+		default:
+			fatalf("Invalid FAULT_INJECTION effect in %q. Must be latency:<duration>, error or error:<rate>", item)
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// validateQuantization stops the service on an unknown quantization mode, or on one that
+// cannot apply: Upstash hybrid indexes keep sparse vectors the service does not quantize
+func validateQuantization(setting string, mode string) {
+	switch mode {
+	case "none":
+		return
+	case "scalar", "binary":
+	default:
+		fatalf("Invalid %s. Must be 'none', 'scalar' or 'binary'", setting)
+	}
+	if AppConfig.VectorProvider == "memory" {
+		fatalf("%s is only supported with VECTOR_PROVIDER=upstash or qdrant", setting)
+	}
+	if AppConfig.VectorIndexType == "hybrid" {
+		fatalf("%s is only supported with VECTOR_INDEX_TYPE=dense", setting)
+	}
+}
+
+// loadDataRegions reads the Upstash endpoints of every region listed in DATA_REGIONS
+// from UPSTASH_{REDIS,VECTOR}_{URL,TOKEN}_<REGION>. With VECTOR_PROVIDER=qdrant the
+// region's vector store is QDRANT_URL_<REGION> (with QDRANT_API_KEY_<REGION>) instead,
+// and REDIS_ADDR_<REGION> (with REDIS_PASSWORD_<REGION>) replaces the Upstash Redis.
+// VECTOR_QUANTIZATION_<REGION> overrides the quantization of the region's vectors.
+func loadDataRegions(vectorProvider string) map[string]RegionEndpoints {
+	regions := make(map[string]RegionEndpoints)
+	for _, name := range getEnvList("DATA_REGIONS") {
+		name = strings.ToLower(name)
+		suffix := "_" + strings.ToUpper(name)
+		endpoints := RegionEndpoints{
+			RedisURL:      getEnv("UPSTASH_REDIS_URL"+suffix, ""),
+			RedisToken:    getEnv("UPSTASH_REDIS_TOKEN"+suffix, ""),
+			RedisAddr:     getEnv("REDIS_ADDR"+suffix, ""),
+			RedisPassword: getEnv("REDIS_PASSWORD"+suffix, ""),
+			VectorURL:     getEnv("UPSTASH_VECTOR_URL"+suffix, ""),
+			VectorToken:   getEnv("UPSTASH_VECTOR_TOKEN"+suffix, ""),
+			Quantization:  strings.ToLower(getEnv("VECTOR_QUANTIZATION"+suffix, AppConfig.VectorQuantization)),
+			BlobBucket:    getEnv("BLOB_S3_BUCKET"+suffix, ""),
+			ColdBucket:    getEnv("COLD_TIER_S3_BUCKET"+suffix, getEnv("BLOB_S3_BUCKET"+suffix, "")),
+		}
+		redisConfigured := endpoints.RedisAddr != "" || (endpoints.RedisURL != "" && endpoints.RedisToken != "")
+		if vectorProvider == "qdrant" {
+			endpoints.VectorURL = strings.TrimSuffix(getEnv("QDRANT_URL"+suffix, ""), "/")
+			endpoints.VectorToken = getEnv("QDRANT_API_KEY"+suffix, "")
+			if !redisConfigured || endpoints.VectorURL == "" {
+				fatalf("Data region %q requires REDIS_ADDR%s (or UPSTASH_REDIS_URL%s and UPSTASH_REDIS_TOKEN%s) and QDRANT_URL%s",
+					name, suffix, suffix, suffix, suffix)
+			}
+			regions[name] = endpoints
+			continue
+		}
+		if !redisConfigured || endpoints.VectorURL == "" || endpoints.VectorToken == "" {
+			fatalf("Data region %q requires REDIS_ADDR%s (or UPSTASH_REDIS_URL%s and UPSTASH_REDIS_TOKEN%s), UPSTASH_VECTOR_URL%s and UPSTASH_VECTOR_TOKEN%s",
+				name, suffix, suffix, suffix, suffix, suffix)
+		}
+		regions[name] = endpoints
+	}
+	return regions
+}
+
 // GetEmbeddingDimensions returns the expected dimensions for the current embedding provider
 func GetEmbeddingDimensions() int {
-	switch AppConfig.EmbeddingProvider {
+	return GetProviderDimensions(AppConfig.EmbeddingProvider)
+}
+
+// GetProviderDimensions returns the expected dimensions for the given embedding provider
+func GetProviderDimensions(provider string) int {
+	switch provider {
 	case "jina":
 		return 1024 // Jina v3 dimensions
 	case "openai":
@@ -110,7 +1721,39 @@ func GetEmbeddingDimensions() int {
 		default:
 			return 1536 // default for OpenAI
 		}
+	case "local":
+		return AppConfig.LocalEmbeddingDimensions
 	default:
 		return 1024 // default fallback
 	}
 }
+
+func (c *Config) dataResidencySummary() map[string]interface{} {
+	regions := make(map[string]interface{}, len(c.DataRegions))
+	for name, endpoints := range c.DataRegions {
+		regions[name] = map[string]interface{}{
+			"redis_url":    endpoints.RedisURL,
+			"redis_addr":   endpoints.RedisAddr,
+			"vector_url":   endpoints.VectorURL,
+			"quantization": endpoints.Quantization,
+			"blob_bucket":  endpoints.BlobBucket,
+			"cold_bucket":  endpoints.ColdBucket,
+		}
+	}
+	return map[string]interface{}{
+		"regions":        regions,
+		"tenant_regions": c.TenantRegions,
+	}
+}
+
+func (c *Config) bulkheadSummary() map[string]interface{} {
+	summary := make(map[string]interface{}, len(c.Bulkheads))
+	for route, settings := range c.Bulkheads {
+		summary[route] = map[string]interface{}{
+			"concurrency":   settings.Concurrency,
+			"queue_size":    settings.QueueSize,
+			"queue_timeout": settings.QueueTimeout.String(),
+		}
+	}
+	return summary
+}
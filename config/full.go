@@ -0,0 +1,6 @@
+//go:build !lite
+
+package config
+
+// Lite reports a build with the lite tag; see lite.go
+const Lite = false
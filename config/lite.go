@@ -0,0 +1,8 @@
+//go:build lite
+
+package config
+
+// Lite reports a build with the lite tag. Lite builds keep all state in the Upstash REST
+// APIs and run nothing outside a request, so the save and query path can be compiled for
+// runtimes such as edge functions (GOOS=js GOARCH=wasm).
+const Lite = true
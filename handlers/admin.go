@@ -0,0 +1,893 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/Fairy-nn/MemoryCacheAI/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	retention       *services.RetentionPolicies
+	sessionPolicies *services.SessionPolicies
+	templates       *services.PromptTemplates
+	memoryService   *services.MemoryService
+}
+
+func NewAdminHandler(memoryService *services.MemoryService, retention *services.RetentionPolicies, sessionPolicies *services.SessionPolicies, templates *services.PromptTemplates) *AdminHandler {
+	return &AdminHandler{
+		retention:       retention,
+		sessionPolicies: sessionPolicies,
+		templates:       templates,
+		memoryService:   memoryService,
+	}
+}
+
+// service returns the memory service bound to the request's trace
+func (h *AdminHandler) service(c *gin.Context) *services.MemoryService {
+	return h.memoryService.WithContext(c.Request.Context())
+}
+
+// RequireAdminToken rejects requests without the configured admin bearer token.
+// Admin endpoints stay open when no token is configured.
+func (h *AdminHandler) RequireAdminToken(c *gin.Context) {
+	token := config.AppConfig.AdminAPIToken
+	if token == "" {
+		c.Next()
+		return
+	}
+
+	provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid or missing admin token",
+		})
+		return
+	}
+
+	c.Next()
+}
+
+// GetConfig handles GET /admin/config
+func (h *AdminHandler) GetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, config.AppConfig.Summary())
+}
+
+// ListRetentionPolicies handles GET /admin/retention-policies
+func (h *AdminHandler) ListRetentionPolicies(c *gin.Context) {
+	policies, err := h.retention.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list retention policies",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policies": policies,
+		"total":    len(policies),
+	})
+}
+
+// GetRetentionPolicy handles GET /admin/retention-policies/:tenant
+func (h *AdminHandler) GetRetentionPolicy(c *gin.Context) {
+	policy, err := h.retention.Get(c.Param("tenant"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get retention policy",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// PutRetentionPolicy handles PUT /admin/retention-policies/:tenant
+func (h *AdminHandler) PutRetentionPolicy(c *gin.Context) {
+	var policy models.RetentionPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	policy.TenantID = c.Param("tenant")
+
+	if err := h.retention.Put(&policy); err != nil {
+		if errors.Is(err, services.ErrInvalidRetentionPolicy) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid retention policy",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save retention policy",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeleteRetentionPolicy handles DELETE /admin/retention-policies/:tenant
+func (h *AdminHandler) DeleteRetentionPolicy(c *gin.Context) {
+	tenantID := c.Param("tenant")
+
+	if err := h.retention.Delete(tenantID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete retention policy",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Retention policy deleted successfully",
+		"tenant_id": tenantID,
+	})
+}
+
+// ListSessionPolicies handles GET /admin/session-policies
+func (h *AdminHandler) ListSessionPolicies(c *gin.Context) {
+	policies, err := h.sessionPolicies.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list session policies",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policies": policies,
+		"total":    len(policies),
+	})
+}
+
+// GetSessionPolicy handles GET /admin/session-policies/:tenant
+func (h *AdminHandler) GetSessionPolicy(c *gin.Context) {
+	policy, err := h.sessionPolicies.Get(c.Param("tenant"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get session policy",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// PutSessionPolicy handles PUT /admin/session-policies/:tenant
+func (h *AdminHandler) PutSessionPolicy(c *gin.Context) {
+	var policy models.SessionPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	policy.TenantID = c.Param("tenant")
+
+	if err := h.sessionPolicies.Put(&policy); err != nil {
+		if errors.Is(err, services.ErrInvalidSessionPolicy) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid session policy",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to save session policy",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeleteSessionPolicy handles DELETE /admin/session-policies/:tenant
+func (h *AdminHandler) DeleteSessionPolicy(c *gin.Context) {
+	tenantID := c.Param("tenant")
+
+	if err := h.sessionPolicies.Delete(tenantID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete session policy",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Session policy deleted successfully",
+		"tenant_id": tenantID,
+	})
+}
+
+// ApplySessionPolicies handles POST /admin/session-policies/apply, running the sweep the
+// scheduler otherwise runs on its own cadence
+func (h *AdminHandler) ApplySessionPolicies(c *gin.Context) {
+	results, err := h.service(c).ApplySessionPolicies()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to apply session policies",
+			"details": err.Error(),
+			"results": results,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+	})
+}
+
+// CreateAPIKey handles POST /admin/api-keys, returning the new key once
+func (h *AdminHandler) CreateAPIKey(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	key, err := h.service(c).APIKeys().Create(req.Name, req.TenantID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrAPIKeyStoreDisabled) {
+			status = http.StatusNotImplemented
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to create API key",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// ListAPIKeys handles GET /admin/api-keys
+func (h *AdminHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.service(c).APIKeys().List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list API keys",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keys":     keys,
+		"env_keys": len(config.AppConfig.APIKeys),
+	})
+}
+
+// RevokeAPIKey handles DELETE /admin/api-keys/:id
+func (h *AdminHandler) RevokeAPIKey(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.service(c).APIKeys().Revoke(id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to revoke API key",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API key revoked",
+		"id":      id,
+	})
+}
+
+// StartImpersonation handles POST /admin/impersonations, opening a time-limited session in
+// which the admin token acts as one user
+func (h *AdminHandler) StartImpersonation(c *gin.Context) {
+	var req models.StartImpersonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	impersonation, err := h.service(c).StartImpersonation(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrInvalidImpersonation):
+			status = http.StatusBadRequest
+		case errors.Is(err, services.ErrImpersonationDisabled):
+			status = http.StatusNotImplemented
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to start impersonation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, impersonation)
+}
+
+// GetImpersonation handles GET /admin/impersonations/:id
+func (h *AdminHandler) GetImpersonation(c *gin.Context) {
+	impersonation, err := h.service(c).GetImpersonation(c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrImpersonationNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to get impersonation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, impersonation)
+}
+
+// RevokeImpersonation handles DELETE /admin/impersonations/:id
+func (h *AdminHandler) RevokeImpersonation(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.service(c).RevokeImpersonation(id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrImpersonationNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to revoke impersonation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Impersonation revoked",
+		"id":      id,
+	})
+}
+
+// ListImpersonationAudit handles GET /admin/impersonations/audit, optionally filtered with
+// ?impersonation_id= or ?user_id=
+func (h *AdminHandler) ListImpersonationAudit(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	entries, err := h.service(c).ListImpersonationAudit(c.Query("impersonation_id"), c.Query("user_id"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list impersonation audit",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
+
+// ListPromptTemplates handles GET /admin/prompt-templates, returning each feature's active template
+func (h *AdminHandler) ListPromptTemplates(c *gin.Context) {
+	templates, err := h.templates.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list prompt templates",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"templates": templates,
+		"total":     len(templates),
+	})
+}
+
+// GetPromptTemplate handles GET /admin/prompt-templates/:name
+func (h *AdminHandler) GetPromptTemplate(c *gin.Context) {
+	template, err := h.templates.Get(c.Param("name"))
+	if err != nil {
+		respondPromptTemplateError(c, "Failed to get prompt template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// ListPromptTemplateVersions handles GET /admin/prompt-templates/:name/versions
+func (h *AdminHandler) ListPromptTemplateVersions(c *gin.Context) {
+	versions, err := h.templates.Versions(c.Param("name"))
+	if err != nil {
+		respondPromptTemplateError(c, "Failed to list prompt template versions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"versions": versions,
+		"total":    len(versions),
+	})
+}
+
+// PutPromptTemplate handles PUT /admin/prompt-templates/:name, storing a new version
+func (h *AdminHandler) PutPromptTemplate(c *gin.Context) {
+	var req models.PromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	template, err := h.templates.Put(c.Param("name"), req)
+	if err != nil {
+		respondPromptTemplateError(c, "Failed to save prompt template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// ActivatePromptTemplate handles POST /admin/prompt-templates/:name/activate, rolling a
+// template forward or back to a stored version (0 for the default)
+func (h *AdminHandler) ActivatePromptTemplate(c *gin.Context) {
+	var req models.PromptTemplateActivation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	template, err := h.templates.Activate(c.Param("name"), *req.Version)
+	if err != nil {
+		respondPromptTemplateError(c, "Failed to activate prompt template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeletePromptTemplate handles DELETE /admin/prompt-templates/:name, dropping every stored
+// version so the feature uses its default again
+func (h *AdminHandler) DeletePromptTemplate(c *gin.Context) {
+	name := c.Param("name")
+
+	if err := h.templates.Reset(name); err != nil {
+		respondPromptTemplateError(c, "Failed to delete prompt template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Prompt template reset to default",
+		"name":    name,
+	})
+}
+
+// respondPromptTemplateError maps prompt template errors to status codes
+func respondPromptTemplateError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrUnknownPromptTemplate):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Unknown prompt template",
+			"details": err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidPromptTemplate):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid prompt template",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
+
+// GetSigningKeys handles GET /admin/qstash/signing-keys
+func (h *AdminHandler) GetSigningKeys(c *gin.Context) {
+	c.JSON(http.StatusOK, clients.GetSigningKeys().Status())
+}
+
+// RefreshSigningKeys handles POST /admin/qstash/signing-keys/refresh. Keys in the
+// body are installed as given; with no body they are fetched from QStash.
+func (h *AdminHandler) RefreshSigningKeys(c *gin.Context) {
+	var req struct {
+		Current string `json:"current"`
+		Next    string `json:"next"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request format",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	keys := clients.GetSigningKeys()
+	if req.Current != "" || req.Next != "" {
+		keys.Set(req.Current, req.Next)
+	} else if err := keys.Refresh(); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Failed to refresh signing keys",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Signing keys refreshed",
+		"status":  keys.Status(),
+	})
+}
+
+// ListQStashMessages handles GET /admin/qstash/messages, listing delivery events
+// filtered by ?destination= and ?status= together with the jobs they ran
+func (h *AdminHandler) ListQStashMessages(c *gin.Context) {
+	events, err := h.service(c).ListDeliveryEvents(clients.EventFilter{
+		URL:   c.Query("destination"),
+		State: c.Query("status"),
+	})
+	if errors.Is(err, services.ErrSchedulerUnavailable) {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error":   "QStash is not configured",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Failed to get QStash messages",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"count":  len(events),
+	})
+}
+
+// GetQStashMessage handles GET /admin/qstash/messages/:id
+func (h *AdminHandler) GetQStashMessage(c *gin.Context) {
+	status, err := h.service(c).GetDeliveryStatus(c.Param("id"))
+	if errors.Is(err, services.ErrSchedulerUnavailable) {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error":   "QStash is not configured",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Failed to get QStash message",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// ListQStashSchedules handles GET /admin/qstash/schedules, optionally filtered by ?destination=
+func (h *AdminHandler) ListQStashSchedules(c *gin.Context) {
+	schedules, err := h.service(c).ListQStashSchedules(c.Query("destination"))
+	if errors.Is(err, services.ErrSchedulerUnavailable) {
+		c.JSON(http.StatusNotImplemented, gin.H{
+			"error":   "QStash is not configured",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Failed to get QStash schedules",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"schedules": schedules,
+		"count":     len(schedules),
+	})
+}
+
+// ListPublishFailures handles GET /admin/qstash/dlq, listing tasks QStash rejected (?limit=, default 100)
+func (h *AdminHandler) ListPublishFailures(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	failures, err := h.service(c).ListPublishFailures(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list publish failures",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"failures": failures,
+		"count":    len(failures),
+	})
+}
+
+// ListWriteAnomalies handles GET /admin/anomalies, listing the anomalous writes the write
+// guard detected, newest first
+func (h *AdminHandler) ListWriteAnomalies(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	anomalies, err := h.service(c).ListWriteAnomalies(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list write anomalies",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"anomalies": anomalies,
+		"count":     len(anomalies),
+	})
+}
+
+// ListQuarantinedMemories handles GET /admin/quarantine, listing the tenant's memories the
+// write guard hid from queries
+func (h *AdminHandler) ListQuarantinedMemories(c *gin.Context) {
+	tenantID := tenantFromRequest(c, c.Query("tenant_id"))
+	memories, err := h.service(c).ListQuarantinedMemories(tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list quarantined memories",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tenant_id": tenantID,
+		"memories":  memories,
+		"count":     len(memories),
+	})
+}
+
+// ReleaseQuarantinedMemory handles POST /admin/quarantine/:id/release, making a
+// quarantined memory visible to queries again
+func (h *AdminHandler) ReleaseQuarantinedMemory(c *gin.Context) {
+	memoryID := c.Param("id")
+	released, err := h.service(c).ReleaseQuarantinedMemory(tenantFromRequest(c, c.Query("tenant_id")), memoryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to release quarantined memory",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !released {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Quarantined memory not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Memory released from quarantine",
+		"memory_id": memoryID,
+	})
+}
+
+// DeleteQuarantinedMemory handles DELETE /admin/quarantine/:id
+func (h *AdminHandler) DeleteQuarantinedMemory(c *gin.Context) {
+	memoryID := c.Param("id")
+	deleted, err := h.service(c).DeleteQuarantinedMemory(tenantFromRequest(c, c.Query("tenant_id")), memoryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete quarantined memory",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Quarantined memory not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Quarantined memory deleted",
+		"memory_id": memoryID,
+	})
+}
+
+// SelfTest handles POST /admin/selftest, exercising every configured dependency with
+// canary data. It responds 503 when any check fails.
+func (h *AdminHandler) SelfTest(c *gin.Context) {
+	report := h.service(c).SelfTest()
+
+	status := http.StatusOK
+	if !report.OK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+// CheckRecall handles POST /admin/recall-check, comparing a query's index results with an
+// exact search over the user's memories
+func (h *AdminHandler) CheckRecall(c *gin.Context) {
+	var req models.QueryMemoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	req.TenantID = tenantFromRequest(c, req.TenantID)
+
+	report, err := h.service(c).CheckRecall(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidContentFilter),
+			errors.Is(err, services.ErrInvalidGranularity),
+			errors.Is(err, services.ErrInvalidQueryMode),
+			errors.Is(err, services.ErrInvalidAssistant),
+			errors.Is(err, services.ErrInvalidTask),
+			errors.Is(err, services.ErrInvalidConfidence),
+			errors.Is(err, services.ErrInvalidSourceTrust),
+			errors.Is(err, services.ErrInvalidTags),
+			errors.Is(err, services.ErrInvalidDedupe),
+			errors.Is(err, services.ErrColdTierDisabled),
+			errors.Is(err, services.ErrInvalidEmbedding):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request",
+				"details": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to check recall",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetStorageUsage handles GET /admin/usage, returning the latest per-tenant storage sample.
+// The X-Tenant-ID header or tenant_id query narrows it to one tenant.
+func (h *AdminHandler) GetStorageUsage(c *gin.Context) {
+	tenantID := c.GetHeader("X-Tenant-ID")
+	if tenantID == "" {
+		tenantID = c.Query("tenant_id")
+	}
+
+	report, err := h.service(c).GetStorageUsage(tenantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get storage usage",
+			"details": err.Error(),
+		})
+		return
+	}
+	if report == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Storage usage has not been sampled yet",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// SampleStorageUsage handles POST /admin/usage/sample, measuring storage usage now
+// instead of waiting for the periodic sample
+func (h *AdminHandler) SampleStorageUsage(c *gin.Context) {
+	report, err := h.service(c).SampleStorageUsage()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to sample storage usage",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetWriteQueue handles GET /admin/write-queue, reporting the saves waiting in each
+// region's write-ahead queue for the vector store
+func (h *AdminHandler) GetWriteQueue(c *gin.Context) {
+	status, err := h.service(c).WriteQueueStatus()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get write-ahead queue",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": config.AppConfig.WriteAheadQueueEnabled,
+		"regions": status,
+	})
+}
+
+// ReplayWriteQueue handles POST /admin/write-queue/replay, writing queued saves to the
+// vector store now instead of waiting for the next replay tick
+func (h *AdminHandler) ReplayWriteQueue(c *gin.Context) {
+	replays, err := h.service(c).ReplayWriteQueue()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "Failed to replay write-ahead queue",
+			"details": err.Error(),
+			"regions": replays,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"regions": replays})
+}
+
+// TierColdMemories handles POST /admin/cold-tier/run, starting a job that moves old memories
+// of one tenant (tenant_id query parameter) or of every tenant to the cold tier
+func (h *AdminHandler) TierColdMemories(c *gin.Context) {
+	job, err := h.service(c).TierColdMemories(c.Query("tenant_id"))
+	if err != nil {
+		if errors.Is(err, services.ErrColdTierDisabled) {
+			c.JSON(http.StatusNotImplemented, gin.H{
+				"error":   "Cold tier is disabled",
+				"details": "Set COLD_TIER_AFTER_DAYS to enable tiering",
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to start cold tiering",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":    "Cold tiering started",
+		"job_id":     job.ID,
+		"status":     job.Status,
+		"status_url": "/jobs/" + job.ID,
+	})
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/Fairy-nn/MemoryCacheAI/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// impersonationHeader names the impersonation an admin-token request acts under
+const impersonationHeader = "X-Impersonation-ID"
+
+// keyTenantContextKey holds the tenant the request's API key is bound to, if any
+const keyTenantContextKey = "api_key_tenant"
+
+type AuthHandler struct {
+	apiKeys       *services.APIKeys
+	memoryService *services.MemoryService
+}
+
+func NewAuthHandler(memoryService *services.MemoryService, apiKeys *services.APIKeys) *AuthHandler {
+	return &AuthHandler{
+		apiKeys:       apiKeys,
+		memoryService: memoryService,
+	}
+}
+
+// service returns the memory service bound to the request's trace
+func (h *AuthHandler) service(c *gin.Context) *services.MemoryService {
+	return h.memoryService.WithContext(c.Request.Context())
+}
+
+// RequireAPIKey rejects requests without a valid "Authorization: Bearer <key>" header.
+// Requests pass through while neither API_KEYS nor the key store is configured.
+// Requests carrying X-Impersonation-ID are authenticated as an impersonation instead.
+// A key bound to a tenant may only name that tenant, which then overrides X-Tenant-ID.
+// Embedding usage is charged to the key's tenant; requests not bound to a tenant share
+// the default tenant's budget, whichever tenant they name.
+func (h *AuthHandler) RequireAPIKey(c *gin.Context) {
+	if id := c.GetHeader(impersonationHeader); id != "" {
+		h.impersonate(c, id)
+		return
+	}
+
+	if !config.AppConfig.APIAuthEnabled() {
+		chargeBudget(c, "")
+		c.Next()
+		return
+	}
+
+	tenantID, valid, err := h.apiKeys.Authenticate(bearerToken(c))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to verify API key",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !valid {
+		c.Header("WWW-Authenticate", `Bearer realm="MemoryCacheAI"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid or missing API key",
+		})
+		return
+	}
+
+	if tenantID != "" {
+		if reason := outsideTenant(c, tenantID); reason != "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "Request is outside the API key's tenant",
+				"details": reason,
+			})
+			return
+		}
+		c.Request.Header.Set("X-Tenant-ID", tenantID)
+		c.Set(keyTenantContextKey, tenantID)
+	}
+	chargeBudget(c, tenantID)
+
+	c.Next()
+}
+
+// chargeBudget charges the request's embedding usage to the tenant it is authenticated
+// as, or to the default tenant when it is not bound to one
+func chargeBudget(c *gin.Context, tenantID string) {
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	c.Request = c.Request.WithContext(services.WithBudgetTenant(c.Request.Context(), tenantID))
+}
+
+// rejectCrossTenantTask refuses a job or cleanup task that sweeps every tenant when the
+// request's API key is bound to one tenant, and reports whether it did
+func rejectCrossTenantTask(c *gin.Context, taskType string) bool {
+	if _, bound := c.Get(keyTenantContextKey); !bound || !services.SweepsAllTenants(taskType) {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":   "Request is outside the API key's tenant",
+		"details": "the " + taskType + " task acts on every tenant",
+	})
+	return true
+}
+
+// outsideTenant explains why a request may not run under a key bound to tenantID, or
+// returns "" when every tenant it names (X-Tenant-ID, tenant_id query or body fields)
+// is tenantID
+func outsideTenant(c *gin.Context, tenantID string) string {
+	named := []string{c.GetHeader("X-Tenant-ID"), c.Query("tenant_id")}
+
+	objects, err := bodyObjects(c)
+	if err != nil {
+		return err.Error()
+	}
+	for _, fields := range objects {
+		if value, ok := fields["tenant_id"].(string); ok {
+			named = append(named, value)
+		}
+	}
+
+	for _, name := range named {
+		if name != "" && name != tenantID {
+			return "the request acts on tenant " + name
+		}
+	}
+	return ""
+}
+
+// bodyObjects returns the top-level JSON objects of the request body, a single object or
+// an array of them, leaving the body readable by the handler. The body is read whatever
+// its Content-Type, since the handlers bind JSON regardless; a non-empty body that is
+// not JSON objects is an error.
+func bodyObjects(c *gin.Context) ([]map[string]interface{}, error) {
+	if c.Request.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, errors.New("the request body could not be read")
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, nil
+	}
+	var object map[string]interface{}
+	if json.Unmarshal(body, &object) == nil {
+		return []map[string]interface{}{object}, nil
+	}
+	var objects []map[string]interface{}
+	if json.Unmarshal(body, &objects) == nil {
+		return objects, nil
+	}
+	return nil, errors.New("the request body is not a JSON object")
+}
+
+// bearerToken returns the token of the Authorization header, or "" without one
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	token := strings.TrimPrefix(header, "Bearer ")
+	if token == header {
+		return ""
+	}
+	return token
+}
+
+// impersonate runs a request made with the admin token on behalf of an impersonated
+// user. The request must name that user and no other; every outcome is audited.
+func (h *AuthHandler) impersonate(c *gin.Context, id string) {
+	token := config.AppConfig.AdminAPIToken
+	if token == "" || subtle.ConstantTimeCompare([]byte(bearerToken(c)), []byte(token)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "Impersonation requires the admin token",
+		})
+		return
+	}
+
+	impersonation, err := h.service(c).GetImpersonation(id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrImpersonationNotFound) {
+			status = http.StatusUnauthorized
+		}
+		c.AbortWithStatusJSON(status, gin.H{
+			"error":   "Invalid impersonation",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// The impersonation's tenant overrides whatever the request names
+	if impersonation.TenantID != "" {
+		c.Request.Header.Set("X-Tenant-ID", impersonation.TenantID)
+	}
+	chargeBudget(c, impersonation.TenantID)
+
+	if reason := h.outsideImpersonation(c, impersonation.UserID); reason != "" {
+		h.service(c).AuditImpersonation(impersonation, models.ImpersonationDenied, c.Request.Method, c.Request.URL.Path, http.StatusForbidden, reason)
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":   "Request is outside the impersonated user",
+			"details": reason,
+		})
+		return
+	}
+
+	c.Header("X-Impersonated-User", impersonation.UserID)
+	c.Next()
+	h.service(c).AuditImpersonation(impersonation, models.ImpersonationRequest, c.Request.Method, c.Request.URL.Path, c.Writer.Status(), "")
+}
+
+// outsideImpersonation explains why a request may not run as userID, or returns "" when
+// every user it names (path, session owner, user_id query or body fields, read whatever
+// the Content-Type as in outsideTenant) is userID
+func (h *AuthHandler) outsideImpersonation(c *gin.Context, userID string) string {
+	var named []string
+
+	route := c.FullPath()
+	switch {
+	case strings.HasPrefix(route, "/user/:id"):
+		named = append(named, c.Param("id"))
+	case strings.HasPrefix(route, "/session/:id"):
+		owner, err := h.service(c).ForTenant(tenantFromRequest(c, c.Query("tenant_id"))).SessionOwner(c.Param("id"))
+		if err != nil {
+			return "the session's owner could not be determined: " + err.Error()
+		}
+		named = append(named, owner)
+	}
+	if queryUser := c.Query("user_id"); queryUser != "" {
+		named = append(named, queryUser)
+	}
+
+	objects, err := bodyObjects(c)
+	if err != nil {
+		return err.Error()
+	}
+	for _, fields := range objects {
+		for _, key := range []string{"user_id", "user", "source_user_id", "target_user_id"} {
+			if value, ok := fields[key].(string); ok && value != "" {
+				named = append(named, value)
+			}
+		}
+	}
+
+	if len(named) == 0 {
+		return "the request does not name a user"
+	}
+	for _, name := range named {
+		if name != userID {
+			return "the request acts on user " + name
+		}
+	}
+	return ""
+}
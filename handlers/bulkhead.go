@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Bulkhead limits the concurrent in-flight requests of one route class, and is shared
+// by every route of the class so they draw on the same slots. Requests beyond the
+// limit wait in a bounded queue; when the queue is full or the wait times out they are
+// rejected with 503 and Retry-After instead of piling onto the embedding provider.
+type Bulkhead struct {
+	route   string
+	slots   chan struct{}
+	queue   chan struct{}
+	timeout time.Duration
+}
+
+// NewBulkhead creates the bulkhead configured for route; a route without a
+// concurrency limit gets a pass-through bulkhead. Create it once per class: every
+// bulkhead has its own slots and sets the class's in-flight gauge.
+func NewBulkhead(route string) *Bulkhead {
+	settings := config.AppConfig.Bulkheads[route]
+
+	b := &Bulkhead{route: route, timeout: settings.QueueTimeout}
+	if settings.Concurrency > 0 {
+		b.slots = make(chan struct{}, settings.Concurrency)
+		b.queue = make(chan struct{}, settings.QueueSize)
+	}
+	return b
+}
+
+// Limit is the gin middleware enforcing the bulkhead
+func (b *Bulkhead) Limit(c *gin.Context) {
+	if b.slots == nil {
+		c.Next()
+		return
+	}
+
+	if !b.acquire(c) {
+		metrics.AddCounter("memorycache_bulkhead_rejected_total", "Requests rejected by a route bulkhead", b.labels(), 1)
+		c.Header("Retry-After", strconv.Itoa(b.retryAfterSeconds()))
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "Server is busy, retry later",
+			"details": "too many concurrent " + b.route + " requests",
+		})
+		return
+	}
+	b.reportInFlight()
+	defer func() {
+		<-b.slots
+		b.reportInFlight()
+	}()
+
+	c.Next()
+}
+
+// acquire takes a slot, queueing for one while there is room in the queue
+func (b *Bulkhead) acquire(c *gin.Context) bool {
+	select {
+	case b.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	select {
+	case b.queue <- struct{}{}:
+	default:
+		return false
+	}
+	defer func() { <-b.queue }()
+
+	timer := time.NewTimer(b.timeout)
+	defer timer.Stop()
+
+	select {
+	case b.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-c.Request.Context().Done():
+		return false
+	}
+}
+
+func (b *Bulkhead) reportInFlight() {
+	metrics.SetGauge("memorycache_bulkhead_in_flight", "Requests holding a route bulkhead slot", b.labels(), float64(len(b.slots)))
+}
+
+func (b *Bulkhead) labels() map[string]string {
+	return map[string]string{"route": b.route}
+}
+
+// retryAfterSeconds suggests waiting one queue timeout, at least a second
+func (b *Bulkhead) retryAfterSeconds() int {
+	return int(math.Max(1, math.Ceil(b.timeout.Seconds())))
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/Fairy-nn/MemoryCacheAI/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ChatCompletions handles POST /chat/completions, an OpenAI-compatible endpoint that adds
+// the memories of the user named in "user" to the conversation. Errors use OpenAI's
+// {"error": {"message", "type"}} shape so existing client libraries can report them.
+func (h *MemoryHandler) ChatCompletions(c *gin.Context) {
+	var req models.ChatCompletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondChatError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	req.TenantID = tenantFromRequest(c, req.TenantID)
+
+	model := req.Model
+	if model == "" {
+		model = h.service(c).FeatureModel(models.PromptChat)
+	}
+	id := "chatcmpl-" + uuid.New().String()
+	created := time.Now().Unix()
+	chunk := func(model string, delta *models.ChatMessage, finishReason *string) models.ChatCompletionChunk {
+		return models.ChatCompletionChunk{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   model,
+			Choices: []models.ChatCompletionChoice{{Delta: delta, FinishReason: finishReason}},
+		}
+	}
+
+	var onDelta func(string) error
+	stream := newSSEStream(c)
+	if req.Stream {
+		first := true
+		onDelta = func(delta string) error {
+			message := &models.ChatMessage{Content: delta}
+			if first {
+				message.Role = "assistant"
+				first = false
+			}
+			return stream.Send(chunk(model, message, nil))
+		}
+	}
+
+	response, memories, err := h.service(c).ChatCompletion(req, onDelta)
+	if err != nil {
+		switch {
+		case stream.Started():
+			stream.Send(gin.H{"error": gin.H{"message": err.Error(), "type": "server_error"}})
+			stream.Done()
+		case errors.Is(err, services.ErrLLMUnavailable):
+			respondChatError(c, http.StatusNotImplemented, "not_implemented", "Chat completions require an LLM provider; set LLM_PROVIDER")
+		case errors.Is(err, services.ErrInvalidChat),
+			errors.Is(err, services.ErrInvalidAssistant):
+			respondChatError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		default:
+			respondChatError(c, http.StatusBadGateway, "server_error", err.Error())
+		}
+		return
+	}
+
+	stop := "stop"
+	usage := models.ChatCompletionUsage{
+		PromptTokens:     response.PromptTokens,
+		CompletionTokens: response.CompletionTokens,
+		TotalTokens:      response.PromptTokens + response.CompletionTokens,
+	}
+	if req.Stream {
+		final := chunk(response.Model, &models.ChatMessage{}, &stop)
+		final.Usage = &usage
+		stream.Send(final)
+		stream.Done()
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ChatCompletionResponse{
+		ID:      id,
+		Object:  "chat.completion",
+		Created: created,
+		Model:   response.Model,
+		Choices: []models.ChatCompletionChoice{{
+			Message:      &models.ChatMessage{Role: "assistant", Content: response.Text},
+			FinishReason: &stop,
+		}},
+		Usage:    usage,
+		Memories: memories,
+	})
+}
+
+// respondChatError writes an OpenAI-style error
+func respondChatError(c *gin.Context, status int, errorType string, message string) {
+	c.JSON(status, gin.H{
+		"error": gin.H{
+			"message": message,
+			"type":    errorType,
+		},
+	})
+}
@@ -0,0 +1,402 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"mime"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/app"
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Re-record the contracts after an intended API change with
+//
+//	go test ./handlers -run TestContracts -update
+//
+// and review the diff of testdata/contracts like any other API change.
+var updateContracts = flag.Bool("update", false, "rewrite the recorded statuses and shapes in testdata/contracts")
+
+// contract is a canonical request with the status and response shape clients depend on.
+// A shape maps each field to its JSON type ("string", "number", "boolean", "object",
+// "array" or "null"); arrays hold the shape of the fields all their elements share, or
+// nothing when any elements are accepted. New fields pass, since clients ignore them.
+//
+// A response that is not JSON, such as an event stream or a transcript, records only its
+// status and content type. An event stream is read until the request is cancelled shortly
+// after it starts.
+//
+// In a request, "$USER_ID", "$SESSION_ID" and "$EMBEDDING" are replaced with a test user,
+// session and unit vector, and "capture" saves response fields (dotted paths such as
+// ".job_id") as $NAME for the paths of later contracts. Contracts after the user cleanup
+// use their own user and session, which the cleanup job running meanwhile leaves alone.
+type contract struct {
+	Name        string            `json:"name"`
+	Method      string            `json:"method"`
+	Path        string            `json:"path"`
+	Body        interface{}       `json:"body,omitempty"`
+	Capture     map[string]string `json:"capture,omitempty"`
+	Status      int               `json:"status"`
+	ContentType string            `json:"content_type,omitempty"`
+	Response    interface{}       `json:"response,omitempty"`
+}
+
+// streamDuration is how long an event stream contract reads before cancelling its request
+const streamDuration = 100 * time.Millisecond
+
+// TestContracts replays testdata/contracts in file name order against the handlers,
+// backed by the in-memory Redis, vector store and local embeddings, and fails when a
+// status changes, a recorded field disappears or changes type, or a registered route
+// has no contract
+func TestContracts(t *testing.T) {
+	router, _ := testRouter(t, map[string]string{
+		"REDIS_PROVIDER":     "memory",
+		"VECTOR_PROVIDER":    "memory",
+		"VECTOR_INDEX_TYPE":  "dense",
+		"EMBEDDING_PROVIDER": "local",
+	})
+	clients.ResetMemoryBackends()
+
+	files, err := filepath.Glob(filepath.Join("testdata", "contracts", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	if len(files) == 0 {
+		t.Fatal("no contracts in testdata/contracts")
+	}
+
+	embedding := make([]interface{}, config.GetEmbeddingDimensions())
+	for i := range embedding {
+		embedding[i] = 0.0
+	}
+	embedding[0] = 1.0
+	placeholders := map[string]interface{}{
+		"$USER_ID":    "contract_user",
+		"$SESSION_ID": "contract_session",
+		"$EMBEDDING":  embedding,
+	}
+
+	covered := make(map[string]bool)
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var c contract
+		if err := json.Unmarshal(raw, &c); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+
+		path := c.Path
+		for name, value := range placeholders {
+			if text, ok := value.(string); ok {
+				path = strings.ReplaceAll(path, name, text)
+			}
+		}
+
+		var body []byte
+		if c.Body != nil {
+			if body, err = json.Marshal(substitute(c.Body, placeholders)); err != nil {
+				t.Fatalf("%s: %v", file, err)
+			}
+		}
+		if route := routeOf(router.Routes(), c.Method, path); route != "" {
+			covered[c.Method+" "+route] = true
+		}
+
+		req := httptest.NewRequest(c.Method, path, bytes.NewReader(body))
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		recorder := httptest.NewRecorder()
+		if c.ContentType == "text/event-stream" {
+			ctx, cancel := context.WithTimeout(req.Context(), streamDuration)
+			router.ServeHTTP(recorder, req.WithContext(ctx))
+			cancel()
+		} else {
+			router.ServeHTTP(recorder, req)
+		}
+
+		if contentType, _, _ := mime.ParseMediaType(recorder.Header().Get("Content-Type")); contentType != "application/json" {
+			if *updateContracts {
+				c.Status = recorder.Code
+				c.ContentType = contentType
+				c.Response = nil
+				record(t, file, c)
+				continue
+			}
+			if recorder.Code != c.Status || contentType != c.ContentType {
+				t.Errorf("%s: expected status %d and content type %q, got status %d and content type %q",
+					c.Name, c.Status, c.ContentType, recorder.Code, contentType)
+			}
+			continue
+		}
+
+		var response interface{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Errorf("%s: response is not JSON: %s", c.Name, recorder.Body.String())
+			continue
+		}
+		for name, field := range c.Capture {
+			placeholders["$"+name] = capture(response, field)
+		}
+
+		if *updateContracts {
+			c.Status = recorder.Code
+			c.ContentType = ""
+			c.Response = shape(response)
+			record(t, file, c)
+			continue
+		}
+
+		if recorder.Code != c.Status || c.ContentType != "" || !conforms(response, c.Response) {
+			got, _ := json.MarshalIndent(shape(response), "", "  ")
+			want, _ := json.MarshalIndent(c.Response, "", "  ")
+			t.Errorf("%s: expected status %d and shape\n%s\ngot status %d and shape\n%s\n(body %s)",
+				c.Name, c.Status, want, recorder.Code, got, recorder.Body.String())
+		}
+	}
+
+	// Every route main.go serves needs a contract, so none can change unnoticed
+	for _, route := range router.Routes() {
+		if !covered[route.Method+" "+route.Path] {
+			t.Errorf("no contract in testdata/contracts for %s %s", route.Method, route.Path)
+		}
+	}
+}
+
+// record rewrites a contract file with the recorded status and shape
+func record(t *testing.T, file string, c contract) {
+	t.Helper()
+	recorded, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, append(recorded, '\n'), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// routeOf returns the registered route serving a request path, or "" when none does.
+// Like gin, a static segment takes precedence over a parameter.
+func routeOf(routes gin.RoutesInfo, method string, path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.Split(path, "/")
+
+	best, bestStatic := "", -1
+	for _, route := range routes {
+		if route.Method != method {
+			continue
+		}
+		pattern := strings.Split(route.Path, "/")
+		if len(pattern) != len(segments) {
+			continue
+		}
+		static := 0
+		for i, segment := range pattern {
+			if strings.HasPrefix(segment, ":") && segments[i] != "" {
+				continue
+			}
+			if segment != segments[i] {
+				static = -1
+				break
+			}
+			static++
+		}
+		if static > bestStatic {
+			best, bestStatic = route.Path, static
+		}
+	}
+	return best
+}
+
+// testRouter serves every route as main.go does, on the backends the settings select. Settings the environment may hold that would change the
+// outcome, such as API keys, QStash and fault injection, are cleared.
+func testRouter(t *testing.T, settings map[string]string) (*gin.Engine, *services.MemoryService) {
+	t.Helper()
+	merged := map[string]string{
+		"REDIS_SEARCH_ENABLED": "false",
+		"DATA_REGIONS":         "",
+		"API_KEYS":             "",
+		"LLM_PROVIDER":         "",
+		"QSTASH_TOKEN":         "",
+		"FAULT_INJECTION":      "",
+		"GIN_MODE":             gin.TestMode,
+	}
+	for key, value := range settings {
+		merged[key] = value
+	}
+	if err := config.LoadConfigFrom(merged); err != nil {
+		t.Fatalf("invalid test configuration: %v", err)
+	}
+	gin.SetMode(gin.TestMode)
+
+	application := app.New()
+	t.Cleanup(application.MemoryService.Close)
+	return NewRouter(application), application.MemoryService
+}
+
+// substitute replaces placeholder strings anywhere in a request body
+func substitute(value interface{}, placeholders map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if replacement, ok := placeholders[v]; ok {
+			return replacement
+		}
+		return v
+	case map[string]interface{}:
+		replaced := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			replaced[key] = substitute(item, placeholders)
+		}
+		return replaced
+	case []interface{}:
+		replaced := make([]interface{}, len(v))
+		for i, item := range v {
+			replaced[i] = substitute(item, placeholders)
+		}
+		return replaced
+	default:
+		return v
+	}
+}
+
+// capture returns the string at a dotted path of a response, or "" when there is none
+func capture(response interface{}, path string) string {
+	value := response
+	for _, key := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = object[key]
+	}
+	text, _ := value.(string)
+	return text
+}
+
+// shape reduces a decoded JSON value to its field types
+func shape(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		shaped := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			shaped[key] = shape(item)
+		}
+		return shaped
+	case []interface{}:
+		if len(v) == 0 {
+			return []interface{}{}
+		}
+		// Elements may differ in optional fields, so only the fields they share are recorded
+		element := shape(v[0])
+		for _, item := range v[1:] {
+			element = common(element, shape(item))
+		}
+		return []interface{}{element}
+	default:
+		return jsonType(v)
+	}
+}
+
+// common returns the part of two shapes they agree on, or nil when they do not agree
+func common(a interface{}, b interface{}) interface{} {
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		shared := make(map[string]interface{})
+		for key, fieldShape := range x {
+			if other, ok := y[key]; ok {
+				if agreed := common(fieldShape, other); agreed != nil {
+					shared[key] = agreed
+				}
+			}
+		}
+		return shared
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok {
+			return nil
+		}
+		if len(x) == 0 || len(y) == 0 {
+			return []interface{}{}
+		}
+		if agreed := common(x[0], y[0]); agreed != nil {
+			return []interface{}{agreed}
+		}
+		return []interface{}{}
+	default:
+		if a != b {
+			return nil
+		}
+		return a
+	}
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// conforms reports whether a decoded JSON value has every field of a recorded shape,
+// with the recorded types
+func conforms(value interface{}, recorded interface{}) bool {
+	switch r := recorded.(type) {
+	case map[string]interface{}:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, fieldShape := range r {
+			field, ok := object[key]
+			if !ok || !conforms(field, fieldShape) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		items, ok := value.([]interface{})
+		if !ok {
+			return false
+		}
+		if len(r) == 0 {
+			return true
+		}
+		for _, item := range items {
+			if !conforms(item, r[0]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return jsonType(value) == recorded
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/Fairy-nn/MemoryCacheAI/services"
+	"github.com/gin-gonic/gin"
+)
+
+// SubscribeDigest handles PUT /user/:id/digest, scheduling a recurring digest of the
+// user's new memories delivered to a webhook and/or email
+func (h *MemoryHandler) SubscribeDigest(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	var req models.DigestSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.TenantID = tenantFromRequest(c, req.TenantID)
+
+	subscription, err := h.service(c).SubscribeDigest(userID, req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidDigest) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid digest subscription",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrCallbackNotAllowed) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Callback URL not allowed",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to subscribe to digests",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// GetDigestSubscription handles GET /user/:id/digest
+func (h *MemoryHandler) GetDigestSubscription(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	subscription, err := h.tenantService(c).GetDigestSubscription(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get digest subscription",
+			"details": err.Error(),
+		})
+		return
+	}
+	if subscription == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User has no digest subscription",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// UnsubscribeDigest handles DELETE /user/:id/digest
+func (h *MemoryHandler) UnsubscribeDigest(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	found, err := h.service(c).UnsubscribeDigest(userID, tenantFromRequest(c, c.Query("tenant_id")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to unsubscribe from digests",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User has no digest subscription",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Digest subscription removed",
+		"user_id": userID,
+	})
+}
+
+// SendDigest handles POST /user/:id/digest/send, delivering a digest immediately
+func (h *MemoryHandler) SendDigest(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	digest, err := h.service(c).SendMemoryDigest(userID, tenantFromRequest(c, c.Query("tenant_id")))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidDigest) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "User has no digest subscription",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Failed to deliver digest",
+			"details": err.Error(),
+			"digest":  digest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, digest)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// versionETag builds a strong ETag from values that change whenever a resource
+// changes, such as its last update time
+func versionETag(parts ...interface{}) string {
+	sum := sha256.Sum256([]byte(fmt.Sprint(parts...)))
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// sessionETag versions a session by its content. last_activity is left out because
+// reading a session touches it, which would change the ETag on every poll.
+func sessionETag(session *models.SessionData) string {
+	unversioned := *session
+	unversioned.LastActivity = time.Time{}
+
+	content, err := json.Marshal(unversioned)
+	if err != nil {
+		return versionETag(session.SessionID, len(session.Messages), session.LastActivity.UnixNano())
+	}
+	return versionETag(string(content))
+}
+
+// respondWithETag answers 304 Not Modified when the client already holds the current
+// version of the resource, and the resource as JSON otherwise
+func respondWithETag(c *gin.Context, etag string, body interface{}) {
+	// A response shaped by SelectFields is a different representation with its own ETag
+	if fields, omit := c.Query("fields"), c.Query("omit"); fields != "" || omit != "" {
+		etag = versionETag(etag, fields, omit)
+	}
+	c.Header("ETag", etag)
+	// Clients may cache the response but must revalidate it before reuse
+	c.Header("Cache-Control", "no-cache")
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(http.StatusOK, body)
+}
+
+// etagMatches applies the weak comparison If-None-Match requires
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InjectFaults is the gin middleware arming the FAULT_INJECTION rules scoped to the
+// matched route for as long as the request is served
+func InjectFaults(c *gin.Context) {
+	route := c.FullPath()
+	if route == "" {
+		c.Next()
+		return
+	}
+
+	end := clients.BeginFaultRoute(route)
+	defer end()
+	c.Next()
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxFieldPaths bounds the paths one request may select or omit
+const maxFieldPaths = 50
+
+// SelectFields shapes JSON responses for clients that need only part of them, such as
+// mobile apps leaving out metadata or message arrays. ?fields= keeps only the listed
+// fields and ?omit= drops them; both take comma-separated dotted paths such as
+// results.content, and a path through an array applies to each of its elements.
+// Unknown fields are ignored, and error responses are always sent whole.
+func SelectFields(c *gin.Context) {
+	selection, err := parseFieldSelection(c.Query("fields"), c.Query("omit"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid field selection",
+			"details": err.Error(),
+		})
+		return
+	}
+	if selection == nil {
+		c.Next()
+		return
+	}
+
+	w := &fieldsResponseWriter{ResponseWriter: c.Writer}
+	c.Writer = w
+	c.Next()
+	c.Writer = w.ResponseWriter
+
+	body := w.buf.Bytes()
+	if w.Status() >= 200 && w.Status() < 300 && strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		if shaped, err := selection.shape(body); err == nil {
+			body = shaped
+		}
+	}
+	if len(body) > 0 {
+		w.ResponseWriter.Write(body)
+	}
+}
+
+// fieldSelection is a parsed ?fields= and ?omit= pair
+type fieldSelection struct {
+	keep fieldTree // nil keeps every field
+	omit fieldTree
+}
+
+// fieldTree maps a field name to the selection within it; an empty subtree means the
+// whole field
+type fieldTree map[string]fieldTree
+
+// parseFieldSelection returns nil when neither parameter is set
+func parseFieldSelection(fields string, omit string) (*fieldSelection, error) {
+	if fields == "" && omit == "" {
+		return nil, nil
+	}
+
+	selection := &fieldSelection{}
+	var err error
+	if fields != "" {
+		if selection.keep, err = parseFieldPaths("fields", fields); err != nil {
+			return nil, err
+		}
+	}
+	if omit != "" {
+		if selection.omit, err = parseFieldPaths("omit", omit); err != nil {
+			return nil, err
+		}
+	}
+	return selection, nil
+}
+
+func parseFieldPaths(param string, value string) (fieldTree, error) {
+	paths := strings.Split(value, ",")
+	if len(paths) > maxFieldPaths {
+		return nil, fmt.Errorf("%s lists more than %d paths", param, maxFieldPaths)
+	}
+
+	tree := fieldTree{}
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		node := tree
+		for _, name := range strings.Split(path, ".") {
+			if name == "" {
+				return nil, fmt.Errorf("%s has an empty field name in %q", param, path)
+			}
+			child, ok := node[name]
+			if !ok {
+				child = fieldTree{}
+				node[name] = child
+			}
+			node = child
+		}
+	}
+	return tree, nil
+}
+
+// shape re-encodes a JSON body with the selection applied. Numbers are kept as written.
+func (s *fieldSelection) shape(body []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("response holds more than one JSON value")
+	}
+
+	if s.keep != nil {
+		value = keepFields(value, s.keep)
+	}
+	if s.omit != nil {
+		omitFields(value, s.omit)
+	}
+	return json.Marshal(value)
+}
+
+// keepFields returns value with only the fields in tree
+func keepFields(value interface{}, tree fieldTree) interface{} {
+	if len(tree) == 0 {
+		return value
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		kept := make(map[string]interface{}, len(tree))
+		for name, subtree := range tree {
+			if field, ok := v[name]; ok {
+				kept[name] = keepFields(field, subtree)
+			}
+		}
+		return kept
+	case []interface{}:
+		for i, item := range v {
+			v[i] = keepFields(item, tree)
+		}
+		return v
+	}
+	return value
+}
+
+// omitFields deletes the fields in tree from value in place
+func omitFields(value interface{}, tree fieldTree) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name, subtree := range tree {
+			if len(subtree) == 0 {
+				delete(v, name)
+			} else if field, ok := v[name]; ok {
+				omitFields(field, subtree)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			omitFields(item, tree)
+		}
+	}
+}
+
+// fieldsResponseWriter holds the response body back until it has been shaped. The
+// status and headers pass through, as gin sends them with the first body write.
+type fieldsResponseWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *fieldsResponseWriter) Write(data []byte) (int, error) {
+	return w.buf.Write(data)
+}
+
+func (w *fieldsResponseWriter) WriteString(s string) (int, error) {
+	return w.buf.WriteString(s)
+}
+
+func (w *fieldsResponseWriter) Size() int {
+	return w.buf.Len()
+}
+
+func (w *fieldsResponseWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
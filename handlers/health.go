@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/metrics"
+	"github.com/Fairy-nn/MemoryCacheAI/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	embeddingMonitor *clients.EmbeddingHealthMonitor
+}
+
+func NewHealthHandler(embeddingMonitor *clients.EmbeddingHealthMonitor) *HealthHandler {
+	return &HealthHandler{
+		embeddingMonitor: embeddingMonitor,
+	}
+}
+
+// Health handles GET /health
+func (h *HealthHandler) Health(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "healthy",
+		"service": "MemoryCacheAI",
+		"version": "1.0.0",
+	})
+}
+
+// Ready handles GET /health/ready
+func (h *HealthHandler) Ready(c *gin.Context) {
+	ready := h.embeddingMonitor.Ready() && services.PrewarmReady()
+
+	status := http.StatusOK
+	state := "ready"
+	switch {
+	case services.ShuttingDown():
+		status = http.StatusServiceUnavailable
+		state = "shutting_down"
+	case !ready:
+		status = http.StatusServiceUnavailable
+		state = "not_ready"
+	}
+
+	c.JSON(status, gin.H{
+		"status": state,
+		"checks": gin.H{
+			"embedding": h.embeddingMonitor.Snapshot(),
+			"prewarm":   services.GetPrewarmStatus(),
+			"cache": gin.H{
+				"sessions": clients.CachedSessionCount(),
+			},
+		},
+	})
+}
+
+// Metrics handles GET /metrics
+func (h *HealthHandler) Metrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4")
+	c.Status(http.StatusOK)
+	if err := metrics.WriteText(c.Writer); err != nil {
+		c.Error(err)
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/Fairy-nn/MemoryCacheAI/services"
+	"github.com/gin-gonic/gin"
+)
+
+// LinkAlias handles POST /user/:id/aliases, linking an email, device ID, OAuth subject
+// or other external ID to the user
+func (h *MemoryHandler) LinkAlias(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	var req models.AliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tenantID := tenantFromRequest(c, c.Query("tenant_id"))
+	alias, err := h.service(c).ForTenant(tenantID).LinkAlias(tenantID, userID, req)
+	if err != nil {
+		respondAliasError(c, err, "Failed to link alias")
+		return
+	}
+
+	c.JSON(http.StatusCreated, alias)
+}
+
+// ListAliases handles GET /user/:id/aliases
+func (h *MemoryHandler) ListAliases(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	tenantID := tenantFromRequest(c, c.Query("tenant_id"))
+	aliases, err := h.service(c).ForTenant(tenantID).ListAliases(tenantID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list aliases",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id": userID,
+		"aliases": aliases,
+		"total":   len(aliases),
+	})
+}
+
+// UnlinkAlias handles DELETE /user/:id/aliases?kind=&value=
+func (h *MemoryHandler) UnlinkAlias(c *gin.Context) {
+	var req models.AliasRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tenantID := tenantFromRequest(c, c.Query("tenant_id"))
+	if err := h.service(c).ForTenant(tenantID).UnlinkAlias(tenantID, c.Param("id"), req); err != nil {
+		respondAliasError(c, err, "Failed to unlink alias")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Alias unlinked successfully",
+		"user_id": c.Param("id"),
+	})
+}
+
+// ResolveAlias handles GET /user/resolve?kind=&value=, returning the canonical user an
+// external ID is linked to
+func (h *MemoryHandler) ResolveAlias(c *gin.Context) {
+	var req models.AliasRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tenantID := tenantFromRequest(c, c.Query("tenant_id"))
+	alias, err := h.service(c).ForTenant(tenantID).ResolveAlias(tenantID, req)
+	if err != nil {
+		respondAliasError(c, err, "Failed to resolve alias")
+		return
+	}
+	if alias == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Alias not linked to any user",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, alias)
+}
+
+// MergeUsers handles POST /user/merge, folding the source user's memories, sessions and
+// aliases into the target user
+func (h *MemoryHandler) MergeUsers(c *gin.Context) {
+	var req models.MergeUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.TenantID = tenantFromRequest(c, req.TenantID)
+
+	report, err := h.service(c).MergeUsers(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidMerge):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid merge",
+				"details": err.Error(),
+			})
+		case errors.Is(err, services.ErrRetentionBlocked):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Merge blocked by retention policy",
+				"details": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to merge users",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// respondAliasError maps alias errors to their status codes
+func respondAliasError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAlias):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid alias",
+			"details": err.Error(),
+		})
+	case errors.Is(err, services.ErrAliasTaken):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Alias already linked",
+			"details": err.Error(),
+		})
+	case errors.Is(err, services.ErrAliasNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Alias not found",
+			"details": err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   message,
+			"details": err.Error(),
+		})
+	}
+}
@@ -0,0 +1,300 @@
+//go:build integration
+
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/services"
+)
+
+// The integration test runs the save, query, degradation and cleanup flows through the
+// HTTP handlers against real Redis and Qdrant containers:
+//
+//	go test -tags integration ./handlers -run TestIntegration
+//
+// It needs a Docker daemon and the docker CLI, and is skipped without them. Every request
+// carries a precomputed embedding, so no cloud credentials are needed.
+
+const (
+	integrationRedisImage  = "redis:7-alpine"
+	integrationQdrantImage = "qdrant/qdrant:v1.9.7"
+	integrationDimensions  = 1024 // Jina v3, the configured (never called) provider
+)
+
+// testContainer is a container started for a test and removed when it ends
+type testContainer struct {
+	id string
+}
+
+// startContainer runs an image with its port published on a free local port and
+// returns the container with the published address
+func startContainer(t *testing.T, image string, port string) (*testContainer, string) {
+	t.Helper()
+	id, err := docker("run", "-d", "--rm", "-p", "127.0.0.1::"+port, image)
+	if err != nil {
+		t.Fatalf("failed to start %s: %v", image, err)
+	}
+	container := &testContainer{id: id}
+	t.Cleanup(func() {
+		docker("rm", "-f", container.id)
+	})
+
+	mapping, err := docker("port", id, port+"/tcp")
+	if err != nil {
+		t.Fatalf("failed to read the published port of %s: %v", image, err)
+	}
+	// One line per published address, e.g. "127.0.0.1:49153"
+	address, _, _ := strings.Cut(mapping, "\n")
+	return container, strings.TrimSpace(address)
+}
+
+func (c *testContainer) pause(t *testing.T) {
+	t.Helper()
+	if _, err := docker("pause", c.id); err != nil {
+		t.Fatalf("failed to pause container: %v", err)
+	}
+}
+
+func (c *testContainer) unpause(t *testing.T) {
+	t.Helper()
+	if _, err := docker("unpause", c.id); err != nil {
+		t.Fatalf("failed to unpause container: %v", err)
+	}
+}
+
+func docker(args ...string) (string, error) {
+	output, err := exec.Command("docker", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, output)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// waitFor polls ready until it holds or the timeout passes
+func waitFor(t *testing.T, what string, timeout time.Duration, ready func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !ready() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// redisReady reports whether Redis at address answers PING
+func redisReady(address string) bool {
+	conn, err := net.DialTimeout("tcp", address, time.Second)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Write([]byte("PING\r\n")); err != nil {
+		return false
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	return err == nil && strings.HasPrefix(reply, "+PONG")
+}
+
+// qdrantReady reports whether Qdrant at address serves its REST API
+func qdrantReady(address string) bool {
+	client := http.Client{Timeout: time.Second}
+	resp, err := client.Get("http://" + address + "/")
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// integrationClient sends JSON requests to the test server and decodes the responses
+type integrationClient struct {
+	t       *testing.T
+	baseURL string
+}
+
+func (c *integrationClient) do(method, path string, body interface{}) map[string]interface{} {
+	c.t.Helper()
+	var encoded []byte
+	if body != nil {
+		var err error
+		if encoded, err = json.Marshal(body); err != nil {
+			c.t.Fatal(err)
+		}
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, bytes.NewReader(encoded))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	var decoded map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		c.t.Fatalf("%s %s: response is not a JSON object: %v", method, path, err)
+	}
+	return decoded
+}
+
+// vector returns a unit vector along one axis, so memories on different axes are unrelated
+func vector(axis int) []float64 {
+	v := make([]float64, integrationDimensions)
+	v[axis] = 1
+	return v
+}
+
+// firstContent returns the content of a query response's top result, or ""
+func firstContent(response map[string]interface{}) string {
+	results, _ := response["results"].([]interface{})
+	if len(results) == 0 {
+		return ""
+	}
+	first, _ := results[0].(map[string]interface{})
+	content, _ := first["content"].(string)
+	return content
+}
+
+func TestIntegration(t *testing.T) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker is not available")
+	}
+	if _, err := docker("info"); err != nil {
+		t.Skipf("the Docker daemon is not reachable: %v", err)
+	}
+
+	_, redisAddr := startContainer(t, integrationRedisImage, "6379")
+	qdrant, qdrantAddr := startContainer(t, integrationQdrantImage, "6333")
+	waitFor(t, "Redis", time.Minute, func() bool { return redisReady(redisAddr) })
+	waitFor(t, "Qdrant", time.Minute, func() bool { return qdrantReady(qdrantAddr) })
+
+	router, memoryService := testRouter(t, map[string]string{
+		"REDIS_PROVIDER":    "upstash",
+		"REDIS_ADDR":        redisAddr,
+		"VECTOR_PROVIDER":   "qdrant",
+		"VECTOR_INDEX_TYPE": "dense",
+		"QDRANT_URL":        "http://" + qdrantAddr,
+		"QDRANT_COLLECTION": "integration",
+		// Never called: every save and query carries its own embedding
+		"EMBEDDING_PROVIDER": "jina",
+		"JINA_API_KEY":       "integration-test-unused",
+		// Every query reaches the stores instead of the local caches
+		"QUERY_CACHE_TTL":        "0",
+		"SESSION_CACHE_TTL":      "0",
+		"VECTOR_MAX_RETRIES":     "0",
+		"VECTOR_TIMEOUT_SECONDS": "2",
+		// Run the flows under injected faults, e.g. FAULT_INJECTION=redis@*=latency:200ms
+		"FAULT_INJECTION": os.Getenv("FAULT_INJECTION"),
+	})
+	memoryService.StartJobWorkers()
+	t.Cleanup(services.StopJobWorkers)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	client := &integrationClient{t: t, baseURL: server.URL}
+
+	const userID, sessionID = "it_user", "it_session"
+	save := func(content string, axis int) map[string]interface{} {
+		return client.do("POST", "/memory/save", map[string]interface{}{
+			"user_id":    userID,
+			"session_id": sessionID,
+			"content":    content,
+			"role":       "user",
+			"embedding":  vector(axis),
+		})
+	}
+	query := func(text string, axis int, mode string) map[string]interface{} {
+		body := map[string]interface{}{"user_id": userID, "query": text, "embedding": vector(axis)}
+		if mode != "" {
+			body["mode"] = mode
+		}
+		return client.do("POST", "/memory/query", body)
+	}
+
+	if health := client.do("GET", "/health", nil); health["status"] != "healthy" {
+		t.Fatalf("service is not healthy: %v", health)
+	}
+
+	// Saving: the first save creates the Qdrant collection; retry while Qdrant finishes starting
+	var saved map[string]interface{}
+	waitFor(t, "the first save", 15*time.Second, func() bool {
+		saved = save("I have an orange cat named Marmalade", 0)
+		return saved["memory_id"] != nil
+	})
+	if saved["storage"] != "vector" {
+		t.Fatalf("memory not saved to the vector store: %v", saved)
+	}
+	if saved = save("My favourite programming language is Go", 1); saved["storage"] != "vector" {
+		t.Fatalf("second memory not saved to the vector store: %v", saved)
+	}
+
+	// Querying
+	if got := firstContent(query("what is my cat called", 0, "")); !strings.Contains(got, "Marmalade") {
+		t.Errorf("semantic query should find the cat first, got %q", got)
+	}
+	// The query vector is unrelated to both memories, so only the keyword ranking tells them apart
+	if got := firstContent(query("programming language", 2, "hybrid")); !strings.Contains(got, "Go") {
+		t.Errorf("hybrid query should rank the keyword match first, got %q", got)
+	}
+	if rejected := query("cat", 0, "fuzzy"); rejected["error"] != "Invalid query mode" {
+		t.Errorf("invalid mode should be rejected, got %v", rejected)
+	}
+
+	// Reading the session
+	if session := client.do("GET", "/session/"+sessionID, nil); session["message_count"] != float64(2) {
+		t.Errorf("session should hold both messages, got %v", session["message_count"])
+	}
+
+	// Degrading while Qdrant does not answer
+	qdrant.pause(t)
+	degraded := query("orange cat", 0, "")
+	qdrant.unpause(t)
+	if degraded["degraded"] != true || !strings.Contains(firstContent(degraded), "Marmalade") {
+		t.Errorf("query should degrade to the session window, got %v", degraded)
+	}
+	waitFor(t, "Qdrant to recover", 30*time.Second, func() bool {
+		response := query("cat", 0, "")
+		results, _ := response["results"].([]interface{})
+		return response["degraded"] != true && len(results) > 0
+	})
+
+	// Cleaning up
+	if deleted := client.do("DELETE", "/session/"+sessionID, nil); deleted["error"] != nil {
+		t.Errorf("session not deleted: %v", deleted)
+	}
+	cleanup := client.do("DELETE", "/user/"+userID+"/memories", nil)
+	jobID, _ := cleanup["job_id"].(string)
+	if jobID == "" {
+		t.Fatalf("cleanup did not start a job: %v", cleanup)
+	}
+	var status interface{}
+	waitFor(t, "the cleanup job", 30*time.Second, func() bool {
+		status = client.do("GET", "/jobs/"+jobID, nil)["status"]
+		return status == "completed" || status == "failed"
+	})
+	if status != "completed" {
+		t.Errorf("cleanup job should complete, got %v", status)
+	}
+	if got := firstContent(query("what is my cat called", 0, "")); got != "" {
+		t.Errorf("no memories should remain, got %q", got)
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Fairy-nn/MemoryCacheAI/mcp"
+	"github.com/Fairy-nn/MemoryCacheAI/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MCPHandler carries MCP messages over the HTTP+SSE transport: a client opens an event
+// stream, is told where to POST its messages, and reads the responses from the stream
+type MCPHandler struct {
+	server *mcp.Server
+
+	mu       sync.Mutex
+	sessions map[string]*mcpSession
+}
+
+// mcpSession is one open event stream
+type mcpSession struct {
+	tenantID  string
+	responses chan []byte
+	closed    chan struct{}
+}
+
+func NewMCPHandler(memoryService *services.MemoryService) *MCPHandler {
+	return &MCPHandler{
+		server:   mcp.NewServer(memoryService),
+		sessions: make(map[string]*mcpSession),
+	}
+}
+
+// OpenStream handles GET /mcp/sse. The first event, endpoint, holds the URL the client
+// posts its messages to; each response then arrives as a message event.
+func (h *MCPHandler) OpenStream(c *gin.Context) {
+	id := uuid.New().String()
+	session := &mcpSession{
+		tenantID:  tenantFromRequest(c, c.Query("tenant_id")),
+		responses: make(chan []byte, 16),
+		closed:    make(chan struct{}),
+	}
+	h.mu.Lock()
+	h.sessions[id] = session
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.sessions, id)
+		h.mu.Unlock()
+		close(session.closed)
+	}()
+
+	stream := newSSEStream(c)
+	endpoint := strings.TrimSuffix(c.Request.URL.Path, "/sse") + "/message?session_id=" + id
+	if err := stream.Event("endpoint", endpoint); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-services.ShutdownStarted():
+			return
+		case response := <-session.responses:
+			if err := stream.Event("message", string(response)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// PostMessage handles POST /mcp/message, answering the message on the session's stream.
+// The request is acknowledged with 202 once the response has been queued.
+func (h *MCPHandler) PostMessage(c *gin.Context) {
+	h.mu.Lock()
+	session := h.sessions[c.Query("session_id")]
+	h.mu.Unlock()
+	// A key bound to a tenant may only post to that tenant's sessions
+	if tenantID, bound := c.Get(keyTenantContextKey); bound && session != nil && session.tenantID != tenantID {
+		session = nil
+	}
+	if session == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Unknown or closed MCP session",
+		})
+		return
+	}
+
+	message, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to read message",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response := h.server.Handle(c.Request.Context(), session.tenantID, message)
+	if response != nil {
+		select {
+		case session.responses <- response:
+		case <-session.closed:
+			c.JSON(http.StatusGone, gin.H{
+				"error": "MCP session closed before the response was sent",
+			})
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+	c.Status(http.StatusAccepted)
+}
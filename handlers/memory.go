@@ -1,9 +1,16 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
 	"github.com/Fairy-nn/MemoryCacheAI/models"
 	"github.com/Fairy-nn/MemoryCacheAI/services"
 
@@ -14,9 +21,9 @@ type MemoryHandler struct {
 	memoryService *services.MemoryService
 }
 
-func NewMemoryHandler() *MemoryHandler {
+func NewMemoryHandler(memoryService *services.MemoryService) *MemoryHandler {
 	return &MemoryHandler{
-		memoryService: services.NewMemoryService(),
+		memoryService: memoryService,
 	}
 }
 
@@ -31,7 +38,114 @@ func (h *MemoryHandler) SaveMemory(c *gin.Context) {
 		return
 	}
 
-	if err := h.memoryService.SaveMemory(req); err != nil {
+	req.TenantID = tenantFromRequest(c, req.TenantID)
+	if value := c.Query("async"); value != "" {
+		async, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid async parameter",
+				"details": "async must be true or false",
+			})
+			return
+		}
+		req.Async = &async
+	}
+
+	result, err := h.service(c).SaveMemory(req)
+	if err != nil {
+		if errors.Is(err, services.ErrEmbeddingBudgetExceeded) {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":     "Embedding budget exceeded",
+				"details":   "The daily embedding token budget for this tenant has been used up",
+				"tenant_id": req.TenantID,
+			})
+			return
+		}
+		if errors.Is(err, services.ErrWriteBlocked) {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":   "Write blocked",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrSessionClosed) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Session closed",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrPoisonedContent) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Content rejected",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidAssistant) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid assistant ID",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidTask) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid task",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidInstruction) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid instruction",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidProvenance) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid provenance",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidConfidence) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid confidence",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidSourceTrust) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid source trust",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidTags) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid tags",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidReplyTo) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid reply_to",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidEmbedding) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid embedding",
+				"details": err.Error(),
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to save memory",
 			"details": err.Error(),
@@ -39,11 +153,35 @@ func (h *MemoryHandler) SaveMemory(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"message":    "Memory saved successfully",
 		"user_id":    req.UserID,
 		"session_id": req.SessionID,
-	})
+		"memory_id":  result.MemoryID,
+		"storage":    result.Storage,
+	}
+	if result.ReinforcedID != "" {
+		response["reinforced_id"] = result.ReinforcedID
+	}
+	if result.Storage == models.StorageQueued {
+		// Saved to the session; the long-term memory is written once the vector store is back
+		response["message"] = "Memory accepted and queued"
+		response["degraded"] = true
+		response["degraded_reason"] = result.DegradedReason
+		c.JSON(http.StatusAccepted, response)
+		return
+	}
+	if result.Storage == models.StoragePending {
+		// Saved to the session; the job embeds and stores the long-term memory
+		response["message"] = "Memory accepted"
+		response["job_id"] = result.JobID
+		if result.Quarantined {
+			response["quarantined"] = true
+		}
+		c.JSON(http.StatusAccepted, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // QueryMemory handles POST /memory/query
@@ -57,8 +195,97 @@ func (h *MemoryHandler) QueryMemory(c *gin.Context) {
 		return
 	}
 
-	response, err := h.memoryService.QueryMemory(req)
+	req.TenantID = tenantFromRequest(c, req.TenantID)
+	req.Trace, _ = strconv.ParseBool(c.Query("trace"))
+
+	response, err := h.service(c).QueryMemory(req)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidContentFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid content filter",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidGranularity) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid granularity",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidQueryMode) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid query mode",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidAssistant) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid assistant ID",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidTask) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid task",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		if errors.Is(err, services.ErrInvalidConfidence) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid confidence",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidSourceTrust) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid source trust",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidTags) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid tags",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidDedupe) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid dedupe",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidEmbedding) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid embedding",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrColdTierDisabled) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Deep recall unavailable",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrVectorUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "Memory search unavailable",
+				"details": err.Error(),
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to query memory",
 			"details": err.Error(),
@@ -69,7 +296,169 @@ func (h *MemoryHandler) QueryMemory(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// GetSession handles GET /session/:id
+// RetrieveMemories handles POST /memory/retrieve, searching the session's latest messages
+// and the user's long-term memories together
+func (h *MemoryHandler) RetrieveMemories(c *gin.Context) {
+	var req models.RetrieveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	req.TenantID = tenantFromRequest(c, req.TenantID)
+
+	response, err := h.service(c).RetrieveMemories(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidContentFilter),
+			errors.Is(err, services.ErrInvalidGranularity),
+			errors.Is(err, services.ErrInvalidQueryMode),
+			errors.Is(err, services.ErrInvalidAssistant),
+			errors.Is(err, services.ErrInvalidTask),
+			errors.Is(err, services.ErrInvalidConfidence),
+			errors.Is(err, services.ErrInvalidSourceTrust),
+			errors.Is(err, services.ErrInvalidTags),
+			errors.Is(err, services.ErrInvalidDedupe),
+			errors.Is(err, services.ErrColdTierDisabled),
+			errors.Is(err, services.ErrInvalidEmbedding):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request",
+				"details": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to retrieve memories",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SearchSession handles POST /session/:id/search, semantically searching only the
+// memories saved in the session
+func (h *MemoryHandler) SearchSession(c *gin.Context) {
+	var req models.QueryMemoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	req.TenantID = tenantFromRequest(c, req.TenantID)
+
+	response, err := h.service(c).SearchSession(c.Param("id"), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSession),
+			errors.Is(err, services.ErrInvalidContentFilter),
+			errors.Is(err, services.ErrInvalidGranularity),
+			errors.Is(err, services.ErrInvalidQueryMode),
+			errors.Is(err, services.ErrInvalidAssistant),
+			errors.Is(err, services.ErrInvalidTask),
+			errors.Is(err, services.ErrInvalidConfidence),
+			errors.Is(err, services.ErrInvalidSourceTrust),
+			errors.Is(err, services.ErrInvalidTags),
+			errors.Is(err, services.ErrInvalidDedupe),
+			errors.Is(err, services.ErrColdTierDisabled),
+			errors.Is(err, services.ErrInvalidEmbedding):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request",
+				"details": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to search session",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AskMemory handles POST /memory/ask, answering a question from the user's memories.
+// With "stream": true the answer arrives as server-sent {"delta": ...} events, followed
+// by the complete response with "done": true and a final [DONE].
+func (h *MemoryHandler) AskMemory(c *gin.Context) {
+	var req models.AskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	req.TenantID = tenantFromRequest(c, req.TenantID)
+
+	var onDelta func(string) error
+	stream := newSSEStream(c)
+	if req.Stream {
+		onDelta = func(delta string) error {
+			return stream.Send(gin.H{"delta": delta})
+		}
+	}
+
+	response, err := h.service(c).Ask(req, onDelta)
+	if err != nil {
+		if stream.Started() {
+			// The status has been sent; report the failure in-band
+			stream.Send(gin.H{
+				"error":   "Failed to answer question",
+				"details": err.Error(),
+			})
+			stream.Done()
+			return
+		}
+
+		switch {
+		case errors.Is(err, services.ErrLLMUnavailable):
+			c.JSON(http.StatusNotImplemented, gin.H{
+				"error":   "Answering questions requires an LLM provider",
+				"details": "Set LLM_PROVIDER to enable /memory/ask",
+			})
+		case errors.Is(err, services.ErrInvalidGranularity),
+			errors.Is(err, services.ErrInvalidQueryMode),
+			errors.Is(err, services.ErrInvalidAssistant),
+			errors.Is(err, services.ErrInvalidTask),
+			errors.Is(err, services.ErrInvalidConfidence),
+			errors.Is(err, services.ErrInvalidSourceTrust):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid request",
+				"details": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusBadGateway, gin.H{
+				"error":   "Failed to answer question",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	if req.Stream {
+		stream.Send(struct {
+			*models.AskResponse
+			Done bool `json:"done"`
+		}{response, true})
+		stream.Done()
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSession handles GET /session/:id?offset=&limit=&since=. Responses carry an ETag;
+// polling clients sending If-None-Match get 304 until the session changes.
 func (h *MemoryHandler) GetSession(c *gin.Context) {
 	sessionID := c.Param("id")
 	if sessionID == "" {
@@ -79,7 +468,162 @@ func (h *MemoryHandler) GetSession(c *gin.Context) {
 		return
 	}
 
-	session, err := h.memoryService.GetSession(sessionID)
+	window, err := messageWindow(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid message window",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	session, err := h.tenantService(c).GetSession(sessionID, window)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Session not found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	respondWithETag(c, sessionETag(session), session)
+}
+
+// messageWindow parses the ?offset, ?limit and ?since parameters of GET /session/:id, or
+// returns nil when none is set
+func messageWindow(c *gin.Context) (*models.MessageWindow, error) {
+	offsetStr, limitStr, sinceStr := c.Query("offset"), c.Query("limit"), c.Query("since")
+	if offsetStr == "" && limitStr == "" && sinceStr == "" {
+		return nil, nil
+	}
+
+	window := &models.MessageWindow{}
+	if offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return nil, errors.New("offset must be a non-negative integer")
+		}
+		window.Offset = offset
+	}
+	if limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return nil, errors.New("limit must be a positive integer")
+		}
+		window.Limit = limit
+	}
+	if sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			return nil, fmt.Errorf("since must be an RFC 3339 time: %w", err)
+		}
+		window.Since = since
+	}
+	return window, nil
+}
+
+// ExportSession handles GET /session/:id/export?format=markdown|html|json, streaming the
+// transcript and flushing as it goes. Once streaming has started the status can no longer
+// change, so a failure simply ends the download early.
+func (h *MemoryHandler) ExportSession(c *gin.Context) {
+	format := c.DefaultQuery("format", services.ExportFormatMarkdown)
+	if err := services.ValidateExportFormat(format); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	export, err := h.tenantService(c).ExportSession(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Session not found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	contentType, extension := "text/markdown; charset=utf-8", "md"
+	switch format {
+	case services.ExportFormatHTML:
+		contentType, extension = "text/html; charset=utf-8", "html"
+	case services.ExportFormatJSON:
+		contentType, extension = "application/json", "json"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.SessionID+"-transcript."+extension))
+	c.Status(http.StatusOK)
+
+	services.WriteSessionExport(c.Writer, export, format, func() error {
+		c.Writer.Flush()
+		return c.Request.Context().Err()
+	})
+}
+
+// CloseSession handles POST /session/:id/close
+func (h *MemoryHandler) CloseSession(c *gin.Context) {
+	closed, err := h.tenantService(c).CloseSession(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Session not found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, closed)
+}
+
+// ResumeSession handles POST /session/resume
+func (h *MemoryHandler) ResumeSession(c *gin.Context) {
+	var req models.ResumeSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.TenantID = tenantFromRequest(c, req.TenantID)
+
+	session, err := h.service(c).ForTenant(req.TenantID).ResumeSession(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrSessionNotClosed),
+			errors.Is(err, services.ErrSessionExists):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Cannot resume session",
+				"details": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Session not found",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+// GetSessionSummary handles GET /session/:id/summary?messages=5&context=key1,key2
+func (h *MemoryHandler) GetSessionSummary(c *gin.Context) {
+	recent, err := strconv.Atoi(c.DefaultQuery("messages", "5"))
+	if err != nil || recent <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid messages parameter, expected a positive integer",
+		})
+		return
+	}
+
+	var contextKeys []string
+	if keys := c.Query("context"); keys != "" {
+		contextKeys = strings.Split(keys, ",")
+	}
+
+	summary, err := h.tenantService(c).SummarizeSession(c.Param("id"), recent, contextKeys)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "Session not found",
@@ -88,7 +632,7 @@ func (h *MemoryHandler) GetSession(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, session)
+	c.JSON(http.StatusOK, summary)
 }
 
 // GetUserSessions handles GET /user/:id/sessions
@@ -101,7 +645,7 @@ func (h *MemoryHandler) GetUserSessions(c *gin.Context) {
 		return
 	}
 
-	sessions, err := h.memoryService.GetUserSessions(userID)
+	sessions, err := h.tenantService(c).GetUserSessions(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to get user sessions",
@@ -131,7 +675,15 @@ func (h *MemoryHandler) DeleteSession(c *gin.Context) {
 	deleteMemoriesStr := c.Query("delete_memories")
 	deleteMemories := deleteMemoriesStr == "true"
 
-	if err := h.memoryService.DeleteSession(sessionID, deleteMemories); err != nil {
+	if err := h.service(c).DeleteSession(sessionID, deleteMemories, tenantFromRequest(c, c.Query("tenant_id"))); err != nil {
+		if errors.Is(err, services.ErrRetentionBlocked) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Deletion blocked by retention policy",
+				"details": err.Error(),
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to delete session",
 			"details": err.Error(),
@@ -165,7 +717,22 @@ func (h *MemoryHandler) SetSessionContext(c *gin.Context) {
 		return
 	}
 
-	if err := h.memoryService.SetSessionContext(sessionID, context); err != nil {
+	if err := h.tenantService(c).SetSessionContext(sessionID, context); err != nil {
+		if errors.Is(err, services.ErrWriteBlocked) {
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":   "Write blocked",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrSessionClosed) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Session closed",
+				"details": err.Error(),
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to set session context",
 			"details": err.Error(),
@@ -181,7 +748,7 @@ func (h *MemoryHandler) SetSessionContext(c *gin.Context) {
 
 // GetMemoryStats handles GET /memory/stats
 func (h *MemoryHandler) GetMemoryStats(c *gin.Context) {
-	stats, err := h.memoryService.GetMemoryStats()
+	stats, err := h.service(c).GetMemoryStats()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to get memory stats",
@@ -211,8 +778,25 @@ func (h *MemoryHandler) GetRecentMemories(c *gin.Context) {
 		}
 	}
 
-	memories, err := h.memoryService.GetRecentMemories(userID, limit)
+	var filter models.ContentFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid content filter",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	memories, err := h.tenantService(c).GetRecentMemories(userID, limit, filter)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidContentFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid content filter",
+				"details": err.Error(),
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to get recent memories",
 			"details": err.Error(),
@@ -253,8 +837,35 @@ func (h *MemoryHandler) SearchMemories(c *gin.Context) {
 		}
 	}
 
-	memories, err := h.memoryService.SearchMemoriesByKeyword(userID, keyword, limit)
+	mode := c.DefaultQuery("mode", clients.SearchModeKeyword)
+	switch mode {
+	case clients.SearchModeKeyword, clients.SearchModePrefix, clients.SearchModeFuzzy:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid search mode. Must be 'keyword', 'prefix' or 'fuzzy'",
+		})
+		return
+	}
+
+	var filter models.ContentFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid content filter",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	memories, err := h.tenantService(c).SearchMemoriesByKeyword(userID, keyword, mode, limit, filter)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidContentFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid content filter",
+				"details": err.Error(),
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to search memories",
 			"details": err.Error(),
@@ -265,12 +876,13 @@ func (h *MemoryHandler) SearchMemories(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"user_id":  userID,
 		"query":    keyword,
+		"mode":     mode,
 		"memories": memories,
 		"total":    len(memories),
 	})
 }
 
-// CleanupUserMemories handles DELETE /user/:id/memories
+// CleanupUserMemories handles DELETE /user/:id/memories?mode=erase|anonymize
 func (h *MemoryHandler) CleanupUserMemories(c *gin.Context) {
 	userID := c.Param("id")
 	if userID == "" {
@@ -280,53 +892,906 @@ func (h *MemoryHandler) CleanupUserMemories(c *gin.Context) {
 		return
 	}
 
-	if err := h.memoryService.CleanupUserMemories(userID); err != nil {
+	tenantID := tenantFromRequest(c, c.Query("tenant_id"))
+
+	var job *models.Job
+	var err error
+	switch mode := c.DefaultQuery("mode", "erase"); mode {
+	case "erase":
+		job, err = h.service(c).EraseUser(userID, tenantID)
+	case "anonymize":
+		job, err = h.service(c).AnonymizeUser(userID, tenantID)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "mode must be one of: erase, anonymize",
+		})
+		return
+	}
+	if err != nil {
+		if errors.Is(err, services.ErrRetentionBlocked) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Deletion blocked by retention policy",
+				"details": err.Error(),
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to cleanup user memories",
+			"error":   "Failed to start user memory cleanup",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "User memories cleaned up successfully",
-		"user_id": userID,
-	})
+	response := gin.H{
+		"message":    "User memory cleanup started",
+		"user_id":    userID,
+		"job_id":     job.ID,
+		"status":     job.Status,
+		"status_url": "/jobs/" + job.ID,
+	}
+	if job.Type == "user_erasure" {
+		response["report_url"] = "/jobs/" + job.ID + "/report"
+	}
+	c.JSON(http.StatusAccepted, response)
 }
 
-// GetEmbeddingInfo handles GET /memory/embedding-info
-func (h *MemoryHandler) GetEmbeddingInfo(c *gin.Context) {
-	info, err := h.memoryService.GetEmbeddingInfo()
+// GetUserProfile handles GET /user/:id/profile, honoring If-None-Match like GetSession
+func (h *MemoryHandler) GetUserProfile(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	profile, err := h.service(c).GetUserProfile(userID, tenantFromRequest(c, c.Query("tenant_id")))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get embedding info",
+			"error":   "Failed to get user profile",
 			"details": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, info)
+	respondWithETag(c, versionETag(profile.UpdatedAt.UnixNano()), profile)
 }
 
-// DeleteMemory handles DELETE /memory/:id
-func (h *MemoryHandler) DeleteMemory(c *gin.Context) {
-	memoryID := c.Param("id")
-	if memoryID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Memory ID is required",
+// GetUserPreferences handles GET /user/:id/preferences
+func (h *MemoryHandler) GetUserPreferences(c *gin.Context) {
+	preferences, err := h.tenantService(c).GetUserPreferences(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get preferences",
+			"details": err.Error(),
+		})
+		return
+	}
+	if preferences == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "No preferences set",
 		})
 		return
 	}
 
-	userID := c.Query("user_id")
-	if userID == "" {
+	c.JSON(http.StatusOK, preferences)
+}
+
+// SetUserPreferences handles PUT /user/:id/preferences
+func (h *MemoryHandler) SetUserPreferences(c *gin.Context) {
+	var preferences models.UserPreferences
+	if err := c.ShouldBindJSON(&preferences); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "User ID is required",
+			"error":   "Invalid request format",
+			"details": err.Error(),
 		})
 		return
 	}
 
-	if err := h.memoryService.DeleteMemory(memoryID, userID); err != nil {
+	if err := h.tenantService(c).SetUserPreferences(c.Param("id"), &preferences); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidPreferences) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to set preferences",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// DeleteUserPreferences handles DELETE /user/:id/preferences
+func (h *MemoryHandler) DeleteUserPreferences(c *gin.Context) {
+	if err := h.tenantService(c).DeleteUserPreferences(c.Param("id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete preferences",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Preferences deleted",
+		"user_id": c.Param("id"),
+	})
+}
+
+// ListFacts handles GET /user/:id/facts
+func (h *MemoryHandler) ListFacts(c *gin.Context) {
+	facts, err := h.tenantService(c).ListFacts(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list facts",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id": c.Param("id"),
+		"facts":   facts,
+		"total":   len(facts),
+	})
+}
+
+// GetFact handles GET /user/:id/facts/:key
+func (h *MemoryHandler) GetFact(c *gin.Context) {
+	fact, err := h.tenantService(c).GetFact(c.Param("id"), c.Param("key"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidFact) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to get fact",
+			"details": err.Error(),
+		})
+		return
+	}
+	if fact == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Fact not set",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, fact)
+}
+
+// SetFact handles PUT /user/:id/facts/:key
+func (h *MemoryHandler) SetFact(c *gin.Context) {
+	var req models.SetFactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	fact, err := h.tenantService(c).SetFact(c.Param("id"), c.Param("key"), req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidFact) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to set fact",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, fact)
+}
+
+// DeleteFact handles DELETE /user/:id/facts/:key
+func (h *MemoryHandler) DeleteFact(c *gin.Context) {
+	deleted, err := h.tenantService(c).DeleteFact(c.Param("id"), c.Param("key"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidFact) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error":   "Failed to delete fact",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Fact not set",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Fact deleted",
+		"user_id": c.Param("id"),
+		"key":     c.Param("key"),
+	})
+}
+
+// GetUserSummaries handles GET /user/:id/summaries?granularity=day|week|month, optionally
+// limited to what one assistant may read with ?assistant_id=
+func (h *MemoryHandler) GetUserSummaries(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	granularity := c.DefaultQuery("granularity", models.GranularityDay)
+	summaries, err := h.service(c).ListUserSummaries(userID, granularity, tenantFromRequest(c, c.Query("tenant_id")), c.Query("assistant_id"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidGranularity) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid granularity",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidAssistant) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid assistant ID",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list summaries",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":     userID,
+		"granularity": granularity,
+		"summaries":   summaries,
+		"total":       len(summaries),
+	})
+}
+
+// ExportUserMemories handles GET /user/:id/memories/export, streaming one JSON memory per
+// line (NDJSON) and flushing after every page. Once streaming has started the status can
+// no longer change, so a failure is reported as a final {"error": ...} line.
+func (h *MemoryHandler) ExportUserMemories(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", userID+"-memories.ndjson"))
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	err := h.service(c).ExportUserMemories(userID, tenantFromRequest(c, c.Query("tenant_id")), func(page []models.ExportedMemory) error {
+		for _, memory := range page {
+			if err := encoder.Encode(memory); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return c.Request.Context().Err()
+	})
+	if err != nil && c.Request.Context().Err() == nil {
+		encoder.Encode(gin.H{
+			"error":   "Export failed",
+			"details": err.Error(),
+		})
+		c.Writer.Flush()
+	}
+}
+
+// GetExpiringMemories handles GET /user/:id/memories/expiring
+func (h *MemoryHandler) GetExpiringMemories(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	withinStr := c.DefaultQuery("within", "7d")
+	within, err := config.ParseDuration(withinStr)
+	if err != nil || within <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid within parameter, expected a duration such as 7d or 12h",
+		})
+		return
+	}
+
+	memories, err := h.tenantService(c).GetExpiringMemories(userID, within)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get expiring memories",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":  userID,
+		"within":   withinStr,
+		"memories": memories,
+		"total":    len(memories),
+	})
+}
+
+// ListUserTags handles GET /user/:id/memories/tags, listing the tags on the user's
+// memories with how many memories carry each
+func (h *MemoryHandler) ListUserTags(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	tags, err := h.tenantService(c).ListUserTags(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list tags",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id": userID,
+		"tags":    tags,
+		"total":   len(tags),
+	})
+}
+
+// DiffMemories handles GET /user/:id/memories/diff?from=&to=, reporting the memories
+// added, superseded and expired in the window. Both bounds take an RFC 3339 time or a
+// duration before now such as 7d; the window defaults to the last 7 days.
+func (h *MemoryHandler) DiffMemories(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	now := time.Now()
+	from, err := parseTimeParam(c.DefaultQuery("from", "7d"), now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid from parameter, expected an RFC 3339 time or a duration such as 7d",
+			"details": err.Error(),
+		})
+		return
+	}
+	to := now
+	if toStr := c.Query("to"); toStr != "" {
+		if to, err = parseTimeParam(toStr, now); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid to parameter, expected an RFC 3339 time or a duration such as 1d",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "from must be before to",
+		})
+		return
+	}
+
+	diff, err := h.tenantService(c).DiffMemories(userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to diff memories",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// parseTimeParam parses an RFC 3339 time, or a duration counted back from now
+func parseTimeParam(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	ago, err := config.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if ago < 0 {
+		return time.Time{}, fmt.Errorf("duration %s is negative", value)
+	}
+	return now.Add(-ago), nil
+}
+
+// GetStaleMemories handles GET /user/:id/memories/stale, the review queue of old,
+// never-retrieved, low-importance memories
+func (h *MemoryHandler) GetStaleMemories(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	olderThanStr := c.DefaultQuery("older_than", "90d")
+	olderThan, err := config.ParseDuration(olderThanStr)
+	if err != nil || olderThan <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid older_than parameter, expected a duration such as 90d or 720h",
+		})
+		return
+	}
+
+	maxImportance, err := strconv.ParseFloat(c.DefaultQuery("max_importance", "0.3"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid max_importance parameter, expected a number",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid limit parameter, expected a positive integer",
+		})
+		return
+	}
+
+	memories, err := h.service(c).ListStaleMemories(userID, tenantFromRequest(c, c.Query("tenant_id")), olderThan, maxImportance, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list stale memories",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":        userID,
+		"older_than":     olderThanStr,
+		"max_importance": maxImportance,
+		"memories":       memories,
+		"total":          len(memories),
+	})
+}
+
+// ReviewMemories handles POST /user/:id/memories/review, approving or forgetting a batch
+// of memories from the stale queue
+func (h *MemoryHandler) ReviewMemories(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	var req models.MemoryReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.service(c).ReviewMemories(userID, tenantFromRequest(c, c.Query("tenant_id")), req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidReview) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid review",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrRetentionBlocked) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Deletion blocked by retention policy",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to review memories",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// PatchUserMemories handles PATCH /user/:id/memories
+func (h *MemoryHandler) PatchUserMemories(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	var req models.MemoryPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	job, err := h.tenantService(c).PatchUserMemories(userID, tenantFromRequest(c, c.Query("tenant_id")), req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPatch) || errors.Is(err, services.ErrInvalidContentFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid patch",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to start memory patch",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":    "Memory patch started",
+		"job_id":     job.ID,
+		"status":     job.Status,
+		"status_url": "/jobs/" + job.ID,
+	})
+}
+
+// GetJob handles GET /jobs/:id
+func (h *MemoryHandler) GetJob(c *gin.Context) {
+	jobID := c.Param("id")
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Job ID is required",
+		})
+		return
+	}
+
+	job, err := h.service(c).GetJob(tenantFromRequest(c, c.Query("tenant_id")), jobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "Job not found",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// ListJobs handles GET /jobs, listing the newest jobs filtered by ?status=, ?type= and
+// ?user_id=. ?status=dead lists the dead-letter list.
+func (h *MemoryHandler) ListJobs(c *gin.Context) {
+	var filter models.JobFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid job filter",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	filter.TenantID = tenantFromRequest(c, filter.TenantID)
+
+	jobs, err := h.service(c).ListJobs(filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidJobFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid job filter",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list jobs",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":  jobs,
+		"count": len(jobs),
+	})
+}
+
+// EnqueueJob handles POST /jobs, queueing a maintenance job such as a cleanup or a
+// re-embedding for the job workers
+func (h *MemoryHandler) EnqueueJob(c *gin.Context) {
+	var req models.EnqueueJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.TenantID = tenantFromRequest(c, req.TenantID)
+	if rejectCrossTenantTask(c, req.Type) {
+		return
+	}
+
+	job, err := h.service(c).EnqueueJob(req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidJob) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid job",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, clients.ErrJobQueueFull) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "Job queue full",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to queue job",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":    "Job queued",
+		"job_id":     job.ID,
+		"type":       job.Type,
+		"status":     job.Status,
+		"status_url": "/jobs/" + job.ID,
+	})
+}
+
+// RetryJob handles POST /jobs/:id/retry, queueing a dead-lettered job again
+func (h *MemoryHandler) RetryJob(c *gin.Context) {
+	job, err := h.service(c).RetryJob(tenantFromRequest(c, c.Query("tenant_id")), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, clients.ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Job not found",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrJobNotDead) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Job is not dead-lettered",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to retry job",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":    "Job queued",
+		"job_id":     job.ID,
+		"status":     job.Status,
+		"status_url": "/jobs/" + job.ID,
+	})
+}
+
+// GetErasureReport handles GET /jobs/:id/report
+func (h *MemoryHandler) GetErasureReport(c *gin.Context) {
+	jobID := c.Param("id")
+	if jobID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Job ID is required",
+		})
+		return
+	}
+
+	report, err := h.service(c).GetErasureReport(tenantFromRequest(c, c.Query("tenant_id")), jobID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get erasure report",
+			"details": err.Error(),
+		})
+		return
+	}
+	if report == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Erasure report not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetEmbeddingInfo handles GET /memory/embedding-info
+func (h *MemoryHandler) GetEmbeddingInfo(c *gin.Context) {
+	info, err := h.service(c).GetEmbeddingInfo()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get embedding info",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// GetEmbeddingBudget handles GET /memory/budget
+func (h *MemoryHandler) GetEmbeddingBudget(c *gin.Context) {
+	usage, err := h.service(c).GetEmbeddingBudget(tenantFromRequest(c, c.Query("tenant_id")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get embedding budget",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
+
+// service returns the memory service bound to the request, so its upstream calls join
+// the request's trace
+func (h *MemoryHandler) service(c *gin.Context) *services.MemoryService {
+	return h.memoryService.WithContext(c.Request.Context())
+}
+
+// tenantService returns the memory service routed to the requesting tenant's data region
+func (h *MemoryHandler) tenantService(c *gin.Context) *services.MemoryService {
+	return h.service(c).ForTenant(tenantFromRequest(c, c.Query("tenant_id")))
+}
+
+// tenantFromRequest returns the tenant from the X-Tenant-ID header, falling back to the given value
+func tenantFromRequest(c *gin.Context, fallback string) string {
+	if tenantID := c.GetHeader("X-Tenant-ID"); tenantID != "" {
+		return tenantID
+	}
+	if fallback != "" {
+		return fallback
+	}
+	return models.DefaultTenant
+}
+
+// GetMemoryProvenance handles GET /memory/:id/provenance, returning the chain of memories
+// the memory was derived from
+func (h *MemoryHandler) GetMemoryProvenance(c *gin.Context) {
+	memoryID := c.Param("id")
+	if memoryID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Memory ID is required",
+		})
+		return
+	}
+
+	userID := c.Query("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	provenance, err := h.service(c).GetMemoryProvenance(memoryID, userID, tenantFromRequest(c, c.Query("tenant_id")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to get memory provenance",
+			"details": err.Error(),
+		})
+		return
+	}
+	if provenance == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Memory not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, provenance)
+}
+
+// VerifyMemory handles POST /memory/:id/verify, confirming (the default) or refuting a
+// memory so its confidence is boosted or lowered and its decay restarts
+func (h *MemoryHandler) VerifyMemory(c *gin.Context) {
+	memoryID := c.Param("id")
+	if memoryID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Memory ID is required",
+		})
+		return
+	}
+
+	var req models.MemoryVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	confirmed := req.Confirmed == nil || *req.Confirmed
+
+	verification, err := h.service(c).VerifyMemory(memoryID, req.UserID, tenantFromRequest(c, c.Query("tenant_id")), confirmed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to verify memory",
+			"details": err.Error(),
+		})
+		return
+	}
+	if verification == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Memory not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, verification)
+}
+
+// DeleteMemory handles DELETE /memory/:id
+func (h *MemoryHandler) DeleteMemory(c *gin.Context) {
+	memoryID := c.Param("id")
+	if memoryID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Memory ID is required",
+		})
+		return
+	}
+
+	userID := c.Query("user_id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	if err := h.service(c).DeleteMemory(memoryID, userID, tenantFromRequest(c, c.Query("tenant_id"))); err != nil {
+		if errors.Is(err, services.ErrRetentionBlocked) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Deletion blocked by retention policy",
+				"details": err.Error(),
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to delete memory",
 			"details": err.Error(),
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/logging"
+	"github.com/Fairy-nn/MemoryCacheAI/metrics"
+	"github.com/Fairy-nn/MemoryCacheAI/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// requestIDHeader carries the ID that ties a response to its log lines
+const requestIDHeader = "X-Request-ID"
+
+// Middlewares returns the stack every request passes through first, replacing
+// gin.Default(): request IDs, tracing, sampled request logging, panic recovery and
+// request deadlines. Recovery runs inside the logger and the trace so that panicked
+// requests are logged and traced with their 500.
+func Middlewares() []gin.HandlerFunc {
+	return []gin.HandlerFunc{RequestID, Trace, LogRequests, Recover, Timeout}
+}
+
+// RequestID keeps the caller's X-Request-ID, or assigns one, and echoes it in the response
+func RequestID(c *gin.Context) {
+	id := c.GetHeader(requestIDHeader)
+	if id == "" || len(id) > 128 || strings.ContainsAny(id, " \t\r\n") {
+		id = uuid.New().String()
+	}
+	c.Set("request_id", id)
+	c.Header(requestIDHeader, id)
+	c.Next()
+}
+
+// Trace records the request as a server span when tracing is enabled, continuing the
+// caller's trace from its traceparent header. The span travels in the request context,
+// where services bound with WithContext find it.
+func Trace(c *gin.Context) {
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	ctx, span := tracing.StartServer(c.Request.Context(), c.Request.Method+" "+route, c.GetHeader("traceparent"))
+	if span == nil {
+		c.Next()
+		return
+	}
+	c.Request = c.Request.WithContext(ctx)
+	span.SetAttribute("http.request.method", c.Request.Method)
+	span.SetAttribute("http.route", route)
+	span.SetAttribute("url.path", c.Request.URL.Path)
+	span.SetAttribute("request_id", c.GetString("request_id"))
+	if tenantID := c.GetHeader("X-Tenant-ID"); tenantID != "" {
+		span.SetAttribute("tenant", tenantID)
+	}
+
+	c.Next()
+
+	status := c.Writer.Status()
+	span.SetAttribute("http.response.status_code", status)
+	if status >= http.StatusInternalServerError {
+		span.Fail(http.StatusText(status))
+	}
+	span.End()
+}
+
+// Recover turns a panic in a handler into a 500 with the usual error body, logging the
+// panic with its stack trace. The panic value is only returned outside release mode.
+func Recover(c *gin.Context) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		if brokenConnection(recovered) {
+			// The client went away; there is nobody to answer
+			logging.Warn("client connection lost", logging.Fields{
+				"request_id": c.GetString("request_id"),
+				"route":      route,
+				"error":      fmt.Sprint(recovered),
+			})
+			c.Abort()
+			return
+		}
+
+		logging.Error("panic serving request", logging.Fields{
+			"request_id": c.GetString("request_id"),
+			"method":     c.Request.Method,
+			"route":      route,
+			"panic":      fmt.Sprint(recovered),
+			"stack":      string(debug.Stack()),
+		})
+		metrics.AddCounter("memorycache_panics_total", "Requests that panicked",
+			map[string]string{"route": route}, 1)
+
+		details := "The server hit an unexpected error"
+		if config.AppConfig.GinMode != gin.ReleaseMode {
+			details = fmt.Sprint(recovered)
+		}
+		if c.Writer.Written() {
+			c.Abort()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error":      "Internal server error",
+			"details":    details,
+			"request_id": c.GetString("request_id"),
+		})
+	}()
+	c.Next()
+}
+
+// brokenConnection reports whether a panic came from writing to a closed connection
+func brokenConnection(recovered interface{}) bool {
+	err, ok := recovered.(error)
+	if !ok {
+		return false
+	}
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	var syscallErr *os.SyscallError
+	if errors.As(opErr, &syscallErr) {
+		return errors.Is(syscallErr.Err, syscall.EPIPE) || errors.Is(syscallErr.Err, syscall.ECONNRESET)
+	}
+	return false
+}
+
+// LogRequests logs a sample of requests (REQUEST_LOG_SAMPLE_RATE) with their status and
+// latency. Server errors are always logged; REQUEST_LOG_SKIP_PATHS are otherwise skipped.
+func LogRequests(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	status := c.Writer.Status()
+	if status < http.StatusInternalServerError {
+		if skipRequestLog(c.Request.URL.Path) || rand.Float64() >= config.AppConfig.RequestLogSampleRate {
+			return
+		}
+	}
+
+	fields := logging.Fields{
+		"request_id": c.GetString("request_id"),
+		"method":     c.Request.Method,
+		"path":       c.Request.URL.Path,
+		"route":      c.FullPath(),
+		"status":     status,
+		"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+		"bytes":      c.Writer.Size(),
+		"client_ip":  c.ClientIP(),
+	}
+	if tenantID := c.GetHeader("X-Tenant-ID"); tenantID != "" {
+		fields["tenant"] = tenantID
+	}
+	if traceID := tracing.FromContext(c.Request.Context()).TraceID(); traceID != "" {
+		fields["trace_id"] = traceID
+	}
+	if len(c.Errors) > 0 {
+		fields["errors"] = c.Errors.String()
+	}
+
+	if status >= http.StatusInternalServerError {
+		logging.Error("request failed", fields)
+		return
+	}
+	logging.Info("request", fields)
+}
+
+func skipRequestLog(path string) bool {
+	for _, skip := range config.AppConfig.RequestLogSkipPaths {
+		if path == skip {
+			return true
+		}
+	}
+	return false
+}
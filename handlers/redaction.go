@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/Fairy-nn/MemoryCacheAI/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RedactMemories handles POST /user/:id/memories/redact. A description of what to forget
+// previews the matching memories; the returned redaction_id with the approved memory IDs
+// deletes them.
+func (h *MemoryHandler) RedactMemories(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	var req models.RedactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.TenantID = tenantFromRequest(c, req.TenantID)
+
+	var response interface{}
+	var err error
+	if req.RedactionID == "" {
+		response, err = h.service(c).PreviewRedaction(userID, req)
+	} else {
+		response, err = h.service(c).ConfirmRedaction(userID, req)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidRedaction):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid redaction",
+				"details": err.Error(),
+			})
+		case errors.Is(err, services.ErrRedactionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "Redaction not found",
+				"details": err.Error(),
+			})
+		case errors.Is(err, services.ErrRetentionBlocked):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Deletion blocked by retention policy",
+				"details": err.Error(),
+			})
+		case errors.Is(err, services.ErrVectorUnavailable):
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "Memory search unavailable",
+				"details": err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to redact memories",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Fairy-nn/MemoryCacheAI/app"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewRouter builds the router serving every API route from the application's services.
+// main.go and the contract tests both serve it, so the tests cover the routes as deployed.
+func NewRouter(application *app.App) *gin.Engine {
+	// Create Gin router with the request ID, recovery and request logging stack
+	router := gin.New()
+	router.Use(Middlewares()...)
+
+	// Add CORS middleware
+	router.Use(func(c *gin.Context) {
+		c.Header("Access-Control-Allow-Origin", "*")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Tenant-ID, X-Impersonation-ID, X-Request-ID, If-None-Match")
+		c.Header("Access-Control-Expose-Headers", "ETag, Retry-After, X-Impersonated-User, X-Request-ID")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	})
+
+	// Gzip large responses such as session transcripts and exports
+	if config.AppConfig.CompressionMinSize > 0 {
+		router.Use(Compress(config.AppConfig.CompressionMinSize))
+	}
+
+	// Fail or slow down client calls per route to exercise retries and degradation
+	if len(config.AppConfig.FaultRules) > 0 {
+		log.Printf("Warning: fault injection is enabled with %d rules", len(config.AppConfig.FaultRules))
+		router.Use(InjectFaults)
+	}
+
+	// Initialize handlers
+	memoryHandler := NewMemoryHandler(application.MemoryService)
+	webhookHandler := NewWebhookHandler(application.MemoryService)
+	healthHandler := NewHealthHandler(application.EmbeddingMonitor)
+	adminHandler := NewAdminHandler(application.MemoryService, application.Retention, application.SessionPolicies, application.Templates)
+	authHandler := NewAuthHandler(application.MemoryService, application.APIKeys)
+	mcpHandler := NewMCPHandler(application.MemoryService)
+
+	// Health check endpoints
+	router.GET("/health", healthHandler.Health)
+	router.GET("/health/ready", healthHandler.Ready)
+	router.GET("/metrics", healthHandler.Metrics)
+
+	// API info endpoint
+	router.GET("/", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"service":     "MemoryCacheAI",
+			"description": "AI Assistant Memory Cache Service",
+			"version":     "1.0.0",
+			"endpoints": map[string]interface{}{
+				"health": map[string]string{
+					"liveness":  "GET /health",
+					"readiness": "GET /health/ready",
+					"metrics":   "GET /metrics",
+				},
+				"tokens": map[string]string{
+					"count": "GET /tokens/count?text=...&max_tokens=N",
+				},
+				"chat": map[string]string{
+					"completions": "POST /chat/completions (OpenAI-compatible, memories of \"user\" added)",
+				},
+				"memory": map[string]string{
+					"save":           "POST /memory/save",
+					"query":          "POST /memory/query?trace=true",
+					"retrieve":       "POST /memory/retrieve (session window and long-term memories)",
+					"ask":            "POST /memory/ask",
+					"stats":          "GET /memory/stats",
+					"embedding_info": "GET /memory/embedding-info",
+					"budget":         "GET /memory/budget?tenant_id=tenant-id",
+					"provenance":     "GET /memory/:id/provenance?user_id=user-id",
+					"verify":         "POST /memory/:id/verify",
+					"delete":         "DELETE /memory/:id?user_id=user-id",
+				},
+				"sessions": map[string]string{
+					"get":     "GET /session/:id",
+					"delete":  "DELETE /session/:id",
+					"context": "PUT /session/:id/context",
+					"search":  "POST /session/:id/search",
+					"summary": "GET /session/:id/summary?messages=5&context=key1,key2",
+					"export":  "GET /session/:id/export?format=markdown|html|json",
+					"close":   "POST /session/:id/close",
+					"resume":  "POST /session/resume",
+				},
+				"users": map[string]string{
+					"sessions":        "GET /user/:id/sessions",
+					"recent_memories": "GET /user/:id/memories/recent",
+					"search_memories": "GET /user/:id/memories/search?q=keyword&mode=keyword|prefix|fuzzy",
+					"expiring":        "GET /user/:id/memories/expiring?within=7d",
+					"tags":            "GET /user/:id/memories/tags",
+					"diff":            "GET /user/:id/memories/diff?from=7d&to=2024-01-31T00:00:00Z",
+					"export":          "GET /user/:id/memories/export",
+					"stale":           "GET /user/:id/memories/stale?older_than=90d&max_importance=0.3",
+					"review":          "POST /user/:id/memories/review",
+					"redact":          "POST /user/:id/memories/redact",
+					"profile":         "GET /user/:id/profile",
+					"preferences":     "PUT|GET|DELETE /user/:id/preferences",
+					"facts":           "GET /user/:id/facts, PUT|GET|DELETE /user/:id/facts/:key",
+					"summaries":       "GET /user/:id/summaries?granularity=day|week|month",
+					"aliases":         "GET|POST /user/:id/aliases, DELETE /user/:id/aliases?kind=email&value=...",
+					"resolve":         "GET /user/resolve?kind=email&value=...",
+					"merge":           "POST /user/merge",
+					"digest":          "PUT|GET|DELETE /user/:id/digest, POST /user/:id/digest/send",
+					"standing":        "POST|GET /user/:id/standing-queries, DELETE /user/:id/standing-queries/:query_id, GET /user/:id/standing-queries/events",
+					"cleanup":         "DELETE /user/:id/memories?mode=erase|anonymize",
+					"patch":           "PATCH /user/:id/memories",
+				},
+				"mcp": map[string]string{
+					"stream":  "GET /mcp/sse",
+					"message": "POST /mcp/message?session_id=...",
+				},
+				"tools": map[string]string{
+					"openai": "GET /tools/openai",
+					"invoke": "POST /tools/invoke",
+				},
+				"jobs": map[string]string{
+					"list":    "GET /jobs?status=pending|running|completed|failed|dead&type=...&user_id=...",
+					"enqueue": "POST /jobs",
+					"get":     "GET /jobs/:id",
+					"report":  "GET /jobs/:id/report",
+					"retry":   "POST /jobs/:id/retry",
+				},
+				"tasks": map[string]string{
+					"complete": "POST /task/:id/complete",
+				},
+				"webhooks": map[string]string{
+					"cleanup":                  "POST /webhook/cleanup",
+					"schedule_cleanup":         "POST /webhook/schedule-cleanup",
+					"schedule_user_cleanup":    "POST /webhook/schedule-user-cleanup",
+					"schedule_session_cleanup": "POST /webhook/schedule-session-cleanup",
+					"list_schedules":           "GET /webhook/schedules",
+					"delete_schedule":          "DELETE /webhook/schedules/:id",
+					"test":                     "POST /webhook/test",
+					"info":                     "GET /webhook/info",
+				},
+				"admin": map[string]string{
+					"config":                  "GET /admin/config",
+					"list_retention_policies": "GET /admin/retention-policies",
+					"get_retention_policy":    "GET /admin/retention-policies/:tenant",
+					"put_retention_policy":    "PUT /admin/retention-policies/:tenant",
+					"delete_retention_policy": "DELETE /admin/retention-policies/:tenant",
+					"session_policies":        "GET /admin/session-policies",
+					"session_policy":          "GET|PUT|DELETE /admin/session-policies/:tenant",
+					"apply_session_policies":  "POST /admin/session-policies/apply",
+					"prompt_templates":        "GET /admin/prompt-templates",
+					"prompt_template":         "GET|PUT|DELETE /admin/prompt-templates/:name",
+					"prompt_template_history": "GET /admin/prompt-templates/:name/versions",
+					"activate_prompt":         "POST /admin/prompt-templates/:name/activate",
+					"signing_keys":            "GET /admin/qstash/signing-keys",
+					"refresh_signing_keys":    "POST /admin/qstash/signing-keys/refresh",
+					"qstash_messages":         "GET /admin/qstash/messages",
+					"qstash_message":          "GET /admin/qstash/messages/:id",
+					"qstash_schedules":        "GET /admin/qstash/schedules",
+					"qstash_dlq":              "GET /admin/qstash/dlq",
+					"selftest":                "POST /admin/selftest",
+					"recall_check":            "POST /admin/recall-check",
+					"anomalies":               "GET /admin/anomalies",
+					"quarantine":              "GET /admin/quarantine",
+					"quarantined_memory":      "POST /admin/quarantine/:id/release, DELETE /admin/quarantine/:id",
+					"api_keys":                "GET|POST /admin/api-keys, DELETE /admin/api-keys/:id",
+					"impersonations":          "POST /admin/impersonations, GET|DELETE /admin/impersonations/:id",
+					"impersonation_audit":     "GET /admin/impersonations/audit?impersonation_id=&user_id=",
+					"storage_usage":           "GET /admin/usage?tenant_id=",
+					"sample_storage_usage":    "POST /admin/usage/sample",
+					"cold_tier_run":           "POST /admin/cold-tier/run?tenant_id=",
+					"write_queue":             "GET /admin/write-queue",
+					"replay_write_queue":      "POST /admin/write-queue/replay",
+				},
+			},
+		})
+	})
+
+	// Data routes require an API key once API_KEYS or the key store is configured
+	if config.AppConfig.APIAuthEnabled() {
+		log.Printf("🔑 API key authentication enabled (%d configured keys, key store %t)", len(config.AppConfig.APIKeys), config.AppConfig.APIKeyStoreEnabled)
+	} else {
+		log.Println("⚠️ API_KEYS is not set and the key store is disabled; data endpoints are unauthenticated")
+	}
+
+	// One bulkhead per route class, shared by all its routes so the class limit holds across them
+	saveBulkhead := NewBulkhead("save")
+	queryBulkhead := NewBulkhead("query")
+	searchBulkhead := NewBulkhead("search")
+	patchBulkhead := NewBulkhead("patch")
+
+	// Memory routes
+	router.GET("/tokens/count", CountTokens)
+	router.POST("/chat/completions", authHandler.RequireAPIKey, queryBulkhead.Limit, memoryHandler.ChatCompletions)
+
+	memoryRoutes := router.Group("/memory", authHandler.RequireAPIKey)
+	{
+		memoryRoutes.POST("/save", saveBulkhead.Limit, memoryHandler.SaveMemory)
+		memoryRoutes.POST("/query", SelectFields, queryBulkhead.Limit, memoryHandler.QueryMemory)
+		memoryRoutes.POST("/retrieve", SelectFields, queryBulkhead.Limit, memoryHandler.RetrieveMemories)
+		memoryRoutes.POST("/ask", queryBulkhead.Limit, memoryHandler.AskMemory)
+		memoryRoutes.GET("/stats", memoryHandler.GetMemoryStats)
+		memoryRoutes.GET("/embedding-info", memoryHandler.GetEmbeddingInfo)
+		memoryRoutes.GET("/budget", memoryHandler.GetEmbeddingBudget)
+		memoryRoutes.GET("/:id/provenance", memoryHandler.GetMemoryProvenance)
+		memoryRoutes.POST("/:id/verify", memoryHandler.VerifyMemory)
+		memoryRoutes.DELETE("/:id", memoryHandler.DeleteMemory)
+	}
+
+	// Session routes
+	sessionRoutes := router.Group("/session", authHandler.RequireAPIKey)
+	{
+		sessionRoutes.GET("/:id", SelectFields, memoryHandler.GetSession)
+		sessionRoutes.DELETE("/:id", memoryHandler.DeleteSession)
+		sessionRoutes.PUT("/:id/context", memoryHandler.SetSessionContext)
+		sessionRoutes.POST("/:id/search", SelectFields, queryBulkhead.Limit, memoryHandler.SearchSession)
+		sessionRoutes.GET("/:id/summary", SelectFields, memoryHandler.GetSessionSummary)
+		sessionRoutes.GET("/:id/export", memoryHandler.ExportSession)
+		sessionRoutes.POST("/:id/close", memoryHandler.CloseSession)
+		sessionRoutes.POST("/resume", memoryHandler.ResumeSession)
+	}
+
+	// User routes
+	userRoutes := router.Group("/user", authHandler.RequireAPIKey)
+	{
+		userRoutes.GET("/:id/sessions", SelectFields, memoryHandler.GetUserSessions)
+		userRoutes.GET("/resolve", memoryHandler.ResolveAlias)
+		userRoutes.POST("/merge", memoryHandler.MergeUsers)
+		userRoutes.POST("/:id/aliases", memoryHandler.LinkAlias)
+		userRoutes.GET("/:id/aliases", memoryHandler.ListAliases)
+		userRoutes.DELETE("/:id/aliases", memoryHandler.UnlinkAlias)
+		userRoutes.GET("/:id/memories/recent", SelectFields, memoryHandler.GetRecentMemories)
+		userRoutes.GET("/:id/memories/search", SelectFields, searchBulkhead.Limit, memoryHandler.SearchMemories)
+		userRoutes.GET("/:id/memories/expiring", memoryHandler.GetExpiringMemories)
+		userRoutes.GET("/:id/memories/tags", memoryHandler.ListUserTags)
+		userRoutes.GET("/:id/memories/diff", memoryHandler.DiffMemories)
+		userRoutes.GET("/:id/memories/export", memoryHandler.ExportUserMemories)
+		userRoutes.GET("/:id/memories/stale", memoryHandler.GetStaleMemories)
+		userRoutes.POST("/:id/memories/review", memoryHandler.ReviewMemories)
+		userRoutes.POST("/:id/memories/redact", queryBulkhead.Limit, memoryHandler.RedactMemories)
+		userRoutes.GET("/:id/profile", memoryHandler.GetUserProfile)
+		userRoutes.PUT("/:id/preferences", memoryHandler.SetUserPreferences)
+		userRoutes.GET("/:id/preferences", memoryHandler.GetUserPreferences)
+		userRoutes.DELETE("/:id/preferences", memoryHandler.DeleteUserPreferences)
+		userRoutes.GET("/:id/facts", memoryHandler.ListFacts)
+		userRoutes.PUT("/:id/facts/:key", memoryHandler.SetFact)
+		userRoutes.GET("/:id/facts/:key", memoryHandler.GetFact)
+		userRoutes.DELETE("/:id/facts/:key", memoryHandler.DeleteFact)
+		userRoutes.GET("/:id/summaries", memoryHandler.GetUserSummaries)
+		userRoutes.PUT("/:id/digest", webhookHandler.RequireScheduler, memoryHandler.SubscribeDigest)
+		userRoutes.GET("/:id/digest", memoryHandler.GetDigestSubscription)
+		userRoutes.DELETE("/:id/digest", memoryHandler.UnsubscribeDigest)
+		userRoutes.POST("/:id/digest/send", memoryHandler.SendDigest)
+		userRoutes.POST("/:id/standing-queries", memoryHandler.AddStandingQuery)
+		userRoutes.GET("/:id/standing-queries", memoryHandler.ListStandingQueries)
+		userRoutes.DELETE("/:id/standing-queries/:query_id", memoryHandler.DeleteStandingQuery)
+		userRoutes.GET("/:id/standing-queries/events", memoryHandler.StreamStandingQueryAlerts)
+		userRoutes.DELETE("/:id/memories", memoryHandler.CleanupUserMemories)
+		userRoutes.PATCH("/:id/memories", patchBulkhead.Limit, memoryHandler.PatchUserMemories)
+	}
+
+	// MCP clients reach the memory tools over server-sent events
+	mcpRoutes := router.Group("/mcp", authHandler.RequireAPIKey)
+	{
+		mcpRoutes.GET("/sse", mcpHandler.OpenStream)
+		mcpRoutes.POST("/message", mcpHandler.PostMessage)
+	}
+
+	// OpenAI-compatible agent loops fetch the memory tools' schemas and hand back their calls
+	router.GET("/tools/openai", OpenAITools)
+	router.POST("/tools/invoke", authHandler.RequireAPIKey, queryBulkhead.Limit, memoryHandler.InvokeTools)
+
+	// Job routes
+	jobRoutes := router.Group("/jobs", authHandler.RequireAPIKey)
+	{
+		jobRoutes.GET("", memoryHandler.ListJobs)
+		jobRoutes.POST("", memoryHandler.EnqueueJob)
+		jobRoutes.GET("/:id", memoryHandler.GetJob)
+		jobRoutes.GET("/:id/report", memoryHandler.GetErasureReport)
+		jobRoutes.POST("/:id/retry", memoryHandler.RetryJob)
+	}
+
+	// Task routes
+	taskRoutes := router.Group("/task", authHandler.RequireAPIKey)
+	{
+		taskRoutes.POST("/:id/complete", memoryHandler.CompleteTask)
+	}
+
+	// Webhook routes
+	// QStash cannot send an API key, so deliveries are authenticated by their signature instead
+	webhookRoutes := router.Group("/webhook")
+	webhookRoutes.POST("/cleanup", webhookHandler.RequireSignature(authHandler.RequireAPIKey), webhookHandler.HandleCleanupWebhook)
+	webhookRoutes = webhookRoutes.Group("", authHandler.RequireAPIKey)
+	{
+		webhookRoutes.POST("/schedule-cleanup", webhookHandler.RequireScheduler, webhookHandler.ScheduleCleanup)
+		webhookRoutes.POST("/schedule-user-cleanup", webhookHandler.RequireScheduler, webhookHandler.ScheduleUserCleanup)
+		webhookRoutes.POST("/schedule-session-cleanup", webhookHandler.RequireScheduler, webhookHandler.ScheduleSessionCleanup)
+		webhookRoutes.GET("/schedules", webhookHandler.ListSchedules)
+		webhookRoutes.DELETE("/schedules/:id", webhookHandler.RequireScheduler, webhookHandler.DeleteSchedule)
+		webhookRoutes.POST("/test", webhookHandler.TestWebhook)
+		webhookRoutes.GET("/info", webhookHandler.GetWebhookInfo)
+		webhookRoutes.GET("/validate", webhookHandler.ValidateWebhook)
+	}
+
+	// Admin routes
+	if config.AppConfig.AdminAPIToken == "" {
+		log.Println("⚠️ ADMIN_API_TOKEN is not set; admin endpoints are unauthenticated")
+	}
+	adminRoutes := router.Group("/admin", adminHandler.RequireAdminToken)
+	{
+		adminRoutes.GET("/config", adminHandler.GetConfig)
+		adminRoutes.GET("/retention-policies", adminHandler.ListRetentionPolicies)
+		adminRoutes.GET("/retention-policies/:tenant", adminHandler.GetRetentionPolicy)
+		adminRoutes.PUT("/retention-policies/:tenant", adminHandler.PutRetentionPolicy)
+		adminRoutes.DELETE("/retention-policies/:tenant", adminHandler.DeleteRetentionPolicy)
+		adminRoutes.GET("/session-policies", adminHandler.ListSessionPolicies)
+		adminRoutes.GET("/session-policies/:tenant", adminHandler.GetSessionPolicy)
+		adminRoutes.PUT("/session-policies/:tenant", adminHandler.PutSessionPolicy)
+		adminRoutes.DELETE("/session-policies/:tenant", adminHandler.DeleteSessionPolicy)
+		adminRoutes.POST("/session-policies/apply", adminHandler.ApplySessionPolicies)
+		adminRoutes.GET("/prompt-templates", adminHandler.ListPromptTemplates)
+		adminRoutes.GET("/prompt-templates/:name", adminHandler.GetPromptTemplate)
+		adminRoutes.GET("/prompt-templates/:name/versions", adminHandler.ListPromptTemplateVersions)
+		adminRoutes.PUT("/prompt-templates/:name", adminHandler.PutPromptTemplate)
+		adminRoutes.POST("/prompt-templates/:name/activate", adminHandler.ActivatePromptTemplate)
+		adminRoutes.DELETE("/prompt-templates/:name", adminHandler.DeletePromptTemplate)
+		adminRoutes.GET("/qstash/signing-keys", adminHandler.GetSigningKeys)
+		adminRoutes.POST("/qstash/signing-keys/refresh", adminHandler.RefreshSigningKeys)
+		adminRoutes.GET("/qstash/messages", adminHandler.ListQStashMessages)
+		adminRoutes.GET("/qstash/messages/:id", adminHandler.GetQStashMessage)
+		adminRoutes.GET("/qstash/schedules", adminHandler.ListQStashSchedules)
+		adminRoutes.GET("/qstash/dlq", adminHandler.ListPublishFailures)
+		adminRoutes.POST("/selftest", adminHandler.SelfTest)
+		adminRoutes.POST("/recall-check", queryBulkhead.Limit, adminHandler.CheckRecall)
+		adminRoutes.GET("/anomalies", adminHandler.ListWriteAnomalies)
+		adminRoutes.GET("/quarantine", adminHandler.ListQuarantinedMemories)
+		adminRoutes.POST("/quarantine/:id/release", adminHandler.ReleaseQuarantinedMemory)
+		adminRoutes.DELETE("/quarantine/:id", adminHandler.DeleteQuarantinedMemory)
+		adminRoutes.GET("/api-keys", adminHandler.ListAPIKeys)
+		adminRoutes.POST("/api-keys", adminHandler.CreateAPIKey)
+		adminRoutes.DELETE("/api-keys/:id", adminHandler.RevokeAPIKey)
+		adminRoutes.POST("/impersonations", adminHandler.StartImpersonation)
+		adminRoutes.GET("/impersonations/audit", adminHandler.ListImpersonationAudit)
+		adminRoutes.GET("/impersonations/:id", adminHandler.GetImpersonation)
+		adminRoutes.DELETE("/impersonations/:id", adminHandler.RevokeImpersonation)
+		adminRoutes.GET("/usage", adminHandler.GetStorageUsage)
+		adminRoutes.POST("/usage/sample", adminHandler.SampleStorageUsage)
+		adminRoutes.POST("/cold-tier/run", adminHandler.TierColdMemories)
+		adminRoutes.GET("/write-queue", adminHandler.GetWriteQueue)
+		adminRoutes.POST("/write-queue/replay", adminHandler.ReplayWriteQueue)
+	}
+
+	return router
+}
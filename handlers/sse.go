@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sseStream writes server-sent events. Headers go out with the first event, so a request
+// that fails before generating anything can still be answered with an error status.
+type sseStream struct {
+	c       *gin.Context
+	started bool
+}
+
+func newSSEStream(c *gin.Context) *sseStream {
+	return &sseStream{c: c}
+}
+
+// Send writes v as a JSON data event and flushes it. It fails once the client is gone
+// so generation stops instead of running to completion for nobody.
+func (s *sseStream) Send(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.write(string(data))
+}
+
+// Done writes the OpenAI-style [DONE] terminator
+func (s *sseStream) Done() {
+	s.write("[DONE]")
+}
+
+// Started reports whether any event has been sent
+func (s *sseStream) Started() bool {
+	return s.started
+}
+
+func (s *sseStream) write(data string) error {
+	return s.Event("", data)
+}
+
+// Event writes data as an event of the given name, or an unnamed one for "", and flushes it
+func (s *sseStream) Event(name string, data string) error {
+	if !s.started {
+		s.c.Header("Content-Type", "text/event-stream")
+		s.c.Header("Cache-Control", "no-cache")
+		s.c.Header("Connection", "keep-alive")
+		s.c.Header("X-Accel-Buffering", "no") // keep reverse proxies from buffering tokens
+		s.c.Status(http.StatusOK)
+		s.started = true
+	}
+
+	if name != "" {
+		if _, err := fmt.Fprintf(s.c.Writer, "event: %s\n", name); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(s.c.Writer, "data: %s\n\n", data); err != nil {
+		return err
+	}
+	s.c.Writer.Flush()
+	return s.c.Request.Context().Err()
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/Fairy-nn/MemoryCacheAI/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AddStandingQuery handles POST /user/:id/standing-queries, registering a query that
+// alerts when the user saves a matching memory
+func (h *MemoryHandler) AddStandingQuery(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	var req models.StandingQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	req.TenantID = tenantFromRequest(c, req.TenantID)
+
+	query, err := h.service(c).AddStandingQuery(userID, req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidStandingQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid standing query",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to add standing query",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, query)
+}
+
+// ListStandingQueries handles GET /user/:id/standing-queries
+func (h *MemoryHandler) ListStandingQueries(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	queries, err := h.tenantService(c).ListStandingQueries(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list standing queries",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id": userID,
+		"queries": queries,
+		"total":   len(queries),
+	})
+}
+
+// DeleteStandingQuery handles DELETE /user/:id/standing-queries/:query_id
+func (h *MemoryHandler) DeleteStandingQuery(c *gin.Context) {
+	userID := c.Param("id")
+	queryID := c.Param("query_id")
+
+	deleted, err := h.tenantService(c).DeleteStandingQuery(userID, queryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete standing query",
+			"details": err.Error(),
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Standing query not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Standing query deleted",
+		"query_id": queryID,
+	})
+}
+
+// StreamStandingQueryAlerts handles GET /user/:id/standing-queries/events, streaming the
+// user's standing query alerts as server-sent events until the client disconnects or
+// the server shuts down
+func (h *MemoryHandler) StreamStandingQueryAlerts(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "User ID is required",
+		})
+		return
+	}
+
+	alerts, cancel := h.service(c).SubscribeAlerts(tenantFromRequest(c, c.Query("tenant_id")), userID)
+	defer cancel()
+
+	// Open the stream straight away rather than on the first alert
+	stream := newSSEStream(c)
+	if err := stream.Send(gin.H{"event": "subscribed", "user_id": userID}); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-services.ShutdownStarted():
+			stream.Send(gin.H{"event": "shutdown"})
+			return
+		case alert := <-alerts:
+			if err := stream.Send(alert); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Fairy-nn/MemoryCacheAI/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CompleteTask handles POST /task/:id/complete, deleting the task's scratchpad memories
+func (h *MemoryHandler) CompleteTask(c *gin.Context) {
+	taskID := c.Param("id")
+
+	deleted, retained, err := h.service(c).CompleteTaskMemories(tenantFromRequest(c, c.Query("tenant_id")), taskID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTask) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid task",
+				"details": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, services.ErrRetentionBlocked) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Deletion blocked by retention policy",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to complete task",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Task completed",
+		"task_id":           taskID,
+		"deleted_memories":  deleted,
+		"retained_memories": retained,
+	})
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout gives each request the deadline of its route (ROUTE_TIMEOUTS, otherwise
+// REQUEST_TIMEOUT). Services bound to the request abandon their upstream calls once it
+// passes, and the failure is answered with 504 rather than 500. A client that goes away
+// cancels the request the same way, without the deadline.
+func Timeout(c *gin.Context) {
+	timeout := config.AppConfig.RouteTimeout(c.FullPath())
+	if timeout <= 0 {
+		c.Next()
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+	c.Request = c.Request.WithContext(ctx)
+	c.Writer = &deadlineWriter{ResponseWriter: c.Writer, ctx: ctx}
+
+	c.Next()
+
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return
+	}
+	metrics.AddCounter("memorycache_request_timeouts_total", "Requests that ran past their route's deadline",
+		map[string]string{"route": c.FullPath()}, 1)
+	if !c.Writer.Written() {
+		c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "Request timed out",
+			"details": "The request did not complete within " + timeout.String(),
+		})
+	}
+}
+
+// deadlineWriter reports server errors written after the request's deadline passed as
+// 504s, since they are almost always upstream calls abandoned at the deadline
+type deadlineWriter struct {
+	gin.ResponseWriter
+	ctx context.Context
+}
+
+func (w *deadlineWriter) WriteHeader(code int) {
+	if code >= http.StatusInternalServerError && errors.Is(w.ctx.Err(), context.DeadlineExceeded) {
+		code = http.StatusGatewayTimeout
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/Fairy-nn/MemoryCacheAI/tokenizer"
+	"github.com/gin-gonic/gin"
+)
+
+// CountTokens handles GET /tokens/count?text=...&max_tokens=N. With max_tokens the
+// response also carries the longest prefix of the text that fits, cut between words.
+func CountTokens(c *gin.Context) {
+	text, ok := c.GetQuery("text")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "text is required",
+		})
+		return
+	}
+
+	response := gin.H{
+		"tokens":     tokenizer.Count(text),
+		"characters": utf8.RuneCountInString(text),
+		"encoding":   tokenizer.Encoding(),
+		"exact":      tokenizer.Exact(),
+	}
+
+	if maxStr := c.Query("max_tokens"); maxStr != "" {
+		maxTokens, err := strconv.Atoi(maxStr)
+		if err != nil || maxTokens < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid max_tokens",
+				"details": "max_tokens must be a non-negative integer",
+			})
+			return
+		}
+
+		truncated, tokens := tokenizer.Truncate(text, maxTokens)
+		response["max_tokens"] = maxTokens
+		response["truncated"] = truncated
+		response["truncated_tokens"] = tokens
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/Fairy-nn/MemoryCacheAI/tools"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAITools handles GET /tools/openai, describing the memory tools in the OpenAI
+// function-calling format so they can be passed as the tools of a chat completion. The
+// tools take no user_id; POST /tools/invoke fixes the user.
+func OpenAITools(c *gin.Context) {
+	listed := tools.List(false)
+	openAITools := make([]models.OpenAITool, len(listed))
+	for i, t := range listed {
+		openAITools[i] = models.OpenAITool{
+			Type: "function",
+			Function: models.OpenAIFunction{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Parameters,
+			},
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tools": openAITools,
+	})
+}
+
+// InvokeTools handles POST /tools/invoke, running the tool calls of an assistant message
+// in order and answering one tool message per call. A failed call is answered with an
+// error in its message's content, so the agent loop can hand it back to the model.
+func (h *MemoryHandler) InvokeTools(c *gin.Context) {
+	var req models.ToolInvokeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	tenantID := tenantFromRequest(c, req.TenantID)
+	memory := h.service(c)
+
+	response := models.ToolInvokeResponse{Messages: make([]models.ToolMessage, len(req.ToolCalls))}
+	for i, call := range req.ToolCalls {
+		output, err := tools.Invoke(memory, tools.Call{
+			Name:      call.Function.Name,
+			Arguments: toolArguments(call.Function.Arguments),
+			TenantID:  tenantID,
+			UserID:    req.UserID,
+		})
+		if err != nil {
+			output = gin.H{"error": err.Error()}
+		}
+
+		content, err := json.Marshal(output)
+		if err != nil {
+			content, _ = json.Marshal(gin.H{"error": err.Error()})
+		}
+		response.Messages[i] = models.ToolMessage{
+			Role:       "tool",
+			ToolCallID: call.ID,
+			Content:    string(content),
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// toolArguments unwraps arguments sent as a JSON string, as OpenAI sends them
+func toolArguments(raw json.RawMessage) json.RawMessage {
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err == nil {
+		return json.RawMessage(encoded)
+	}
+	return raw
+}
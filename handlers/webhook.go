@@ -1,8 +1,15 @@
 package handlers
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
 	"github.com/Fairy-nn/MemoryCacheAI/models"
 	"github.com/Fairy-nn/MemoryCacheAI/services"
 
@@ -13,13 +20,21 @@ type WebhookHandler struct {
 	memoryService *services.MemoryService
 }
 
-func NewWebhookHandler() *WebhookHandler {
+func NewWebhookHandler(memoryService *services.MemoryService) *WebhookHandler {
 	return &WebhookHandler{
-		memoryService: services.NewMemoryService(),
+		memoryService: memoryService,
 	}
 }
 
-// HandleCleanupWebhook handles QStash cleanup webhooks
+// service returns the memory service bound to the request's trace
+func (h *WebhookHandler) service(c *gin.Context) *services.MemoryService {
+	return h.memoryService.WithContext(c.Request.Context())
+}
+
+// HandleCleanupWebhook handles QStash cleanup webhooks. Deliveries are deduplicated by
+// task ID, or by QStash message ID for scheduled tasks, so a retried or concurrent
+// delivery of the same task never runs the work twice. Each run is recorded as a job
+// linked to its QStash message and schedule.
 func (h *WebhookHandler) HandleCleanupWebhook(c *gin.Context) {
 	// Parse the cleanup task from request body
 	var task models.CleanupTask
@@ -30,71 +45,392 @@ func (h *WebhookHandler) HandleCleanupWebhook(c *gin.Context) {
 		})
 		return
 	}
+	// A key bound to a tenant sets X-Tenant-ID, which scopes the task to that tenant
+	if tenantID := c.GetHeader("X-Tenant-ID"); tenantID != "" {
+		task.TenantID = tenantID
+	}
+	if rejectCrossTenantTask(c, task.TaskType) {
+		return
+	}
+
+	deliveryID := task.TaskID
+	if deliveryID == "" {
+		deliveryID = c.GetHeader("Upstash-Message-Id")
+	}
 
+	if deliveryID != "" {
+		state, err := h.service(c).BeginTask(deliveryID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to acquire task lease",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		switch state {
+		case services.TaskCompleted:
+			c.JSON(http.StatusOK, gin.H{
+				"message":   "Duplicate delivery acknowledged",
+				"task_id":   deliveryID,
+				"task_type": task.TaskType,
+			})
+			return
+		case services.TaskInProgress:
+			// Non-2xx so QStash retries and finds the outcome of the running delivery
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "Task is already being processed",
+				"task_id": deliveryID,
+			})
+			return
+		}
+	}
+
+	// Record the run as a job so operators can trace a QStash message to its outcome
+	messageID := c.GetHeader("Upstash-Message-Id")
+	job := h.service(c).StartDelivery(task.TaskType, task.TenantID, task.UserID, messageID, c.GetHeader("Upstash-Schedule-Id"))
+
+	status, response := h.runCleanupTask(h.service(c), task)
+
+	failure := ""
+	if status >= http.StatusBadRequest {
+		failure, _ = response["error"].(string)
+		if details, ok := response["details"].(string); ok {
+			failure += ": " + details
+		}
+	}
+	h.service(c).FinishDelivery(job, failure)
+	response["job_id"] = job.ID
+
+	if deliveryID != "" {
+		// Server errors release the lease so the retry can run the task again
+		if status >= http.StatusInternalServerError {
+			h.service(c).AbandonTask(deliveryID)
+		} else {
+			h.service(c).CompleteTask(deliveryID)
+		}
+	}
+
+	c.JSON(status, response)
+}
+
+// runCleanupTask executes a cleanup task and returns the webhook response
+func (h *WebhookHandler) runCleanupTask(service *services.MemoryService, task models.CleanupTask) (int, gin.H) {
 	// Process the cleanup task based on type
 	switch task.TaskType {
 	case "cleanup_expired_memories":
-		if err := h.memoryService.CleanupExpiredMemories(); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
+		if err := service.CleanupExpiredMemories(task.TenantID); err != nil {
+			return http.StatusInternalServerError, gin.H{
 				"error":   "Failed to cleanup expired memories",
 				"details": err.Error(),
-			})
-			return
+			}
+		}
+
+	case "notify_expiring_memories":
+		if err := service.NotifyExpiringMemories(); err != nil {
+			return http.StatusInternalServerError, gin.H{
+				"error":   "Failed to notify about expiring memories",
+				"details": err.Error(),
+			}
 		}
 
 	case "cleanup_user_memories":
 		if task.UserID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{
+			return http.StatusBadRequest, gin.H{
 				"error": "User ID is required for user memory cleanup",
-			})
-			return
+			}
 		}
 
-		if err := h.memoryService.CleanupUserMemories(task.UserID); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
+		if err := service.CleanupUserMemories(task.UserID, task.TenantID); err != nil {
+			// Acknowledge blocked deletions so QStash does not keep retrying them
+			if errors.Is(err, services.ErrRetentionBlocked) {
+				return http.StatusOK, gin.H{
+					"message":   "Cleanup task skipped",
+					"task_type": task.TaskType,
+					"reason":    err.Error(),
+				}
+			}
+
+			return http.StatusInternalServerError, gin.H{
 				"error":   "Failed to cleanup user memories",
 				"details": err.Error(),
-			})
-			return
+			}
 		}
 
 	case "cleanup_session":
-		if task.UserID == "" { // UserID field is reused for session ID
-			c.JSON(http.StatusBadRequest, gin.H{
+		sessionID := task.SessionID
+		if sessionID == "" {
+			// Tasks published before session_id existed carried it in user_id
+			sessionID = task.UserID
+		}
+		if sessionID == "" {
+			return http.StatusBadRequest, gin.H{
 				"error": "Session ID is required for session cleanup",
-			})
-			return
+			}
 		}
 
-		if err := h.memoryService.DeleteSession(task.UserID, false); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
+		if err := service.DeleteSession(sessionID, false, task.TenantID); err != nil {
+			// Acknowledge blocked deletions so QStash does not keep retrying them
+			if errors.Is(err, services.ErrRetentionBlocked) {
+				return http.StatusOK, gin.H{
+					"message":   "Cleanup task skipped",
+					"task_type": task.TaskType,
+					"reason":    err.Error(),
+				}
+			}
+
+			return http.StatusInternalServerError, gin.H{
 				"error":   "Failed to cleanup session",
 				"details": err.Error(),
-			})
-			return
+			}
+		}
+
+	case "consolidate_user_memories":
+		if task.UserID == "" {
+			return http.StatusBadRequest, gin.H{
+				"error": "User ID is required for memory consolidation",
+			}
+		}
+
+		result, err := service.ConsolidateUserMemories(task.UserID, task.TenantID)
+		if err != nil {
+			if errors.Is(err, services.ErrRetentionBlocked) {
+				return http.StatusOK, gin.H{
+					"message":   "Cleanup task skipped",
+					"task_type": task.TaskType,
+					"reason":    err.Error(),
+				}
+			}
+
+			return http.StatusInternalServerError, gin.H{
+				"error":   "Failed to consolidate user memories",
+				"details": err.Error(),
+			}
+		}
+		return taskCompleted(task, result)
+
+	case "reembed_namespace":
+		result, err := service.ReembedNamespace(task.TenantID)
+		if err != nil {
+			return http.StatusInternalServerError, gin.H{
+				"error":   "Failed to re-embed namespace",
+				"details": err.Error(),
+			}
+		}
+		return taskCompleted(task, result)
+
+	case "archive_expired_sessions":
+		// TTL is the idle time in seconds after which a session is archived
+		result, err := service.ArchiveExpiredSessions(task.TenantID, time.Duration(task.TTL)*time.Second)
+		if err != nil {
+			return http.StatusInternalServerError, gin.H{
+				"error":   "Failed to archive expired sessions",
+				"details": err.Error(),
+			}
+		}
+		return taskCompleted(task, result)
+
+	case "apply_session_policies":
+		// Every tenant with a session policy is swept, whatever the task's tenant
+		result, err := service.ApplySessionPolicies()
+		if err != nil {
+			return http.StatusInternalServerError, gin.H{
+				"error":   "Failed to apply session policies",
+				"details": err.Error(),
+			}
 		}
+		return taskCompleted(task, result)
+
+	case "rollup_memories":
+		// Without a user ID every user with memories in the lookback window is rolled up
+		result, err := service.RollupMemories(task.UserID, task.TenantID)
+		if err != nil {
+			return http.StatusInternalServerError, gin.H{
+				"error":   "Failed to roll up memories",
+				"details": err.Error(),
+			}
+		}
+		return taskCompleted(task, result)
+
+	case "tier_cold_memories":
+		// Without a tenant ID every tenant's old memories are tiered
+		result, err := service.RunColdTiering(task.TenantID)
+		if err != nil {
+			if errors.Is(err, services.ErrColdTierDisabled) {
+				return http.StatusOK, gin.H{
+					"message":   "Cleanup task skipped",
+					"task_type": task.TaskType,
+					"reason":    err.Error(),
+				}
+			}
+
+			return http.StatusInternalServerError, gin.H{
+				"error":   "Failed to tier cold memories",
+				"details": err.Error(),
+			}
+		}
+		return taskCompleted(task, result)
+
+	case "send_memory_digest":
+		if task.UserID == "" {
+			return http.StatusBadRequest, gin.H{
+				"error": "User ID is required for memory digests",
+			}
+		}
+
+		digest, err := service.SendMemoryDigest(task.UserID, task.TenantID)
+		if err != nil {
+			// Acknowledge tasks of removed subscriptions so QStash does not keep retrying them
+			if errors.Is(err, services.ErrInvalidDigest) {
+				return http.StatusOK, gin.H{
+					"message":   "Digest task skipped",
+					"task_type": task.TaskType,
+					"reason":    err.Error(),
+				}
+			}
+
+			return http.StatusInternalServerError, gin.H{
+				"error":   "Failed to send memory digest",
+				"details": err.Error(),
+			}
+		}
+		return taskCompleted(task, digest)
+
+	case "recompute_user_profile":
+		if task.UserID == "" {
+			return http.StatusBadRequest, gin.H{
+				"error": "User ID is required for profile recomputation",
+			}
+		}
+
+		profile, err := service.RecomputeUserProfile(task.UserID, task.TenantID)
+		if err != nil {
+			return http.StatusInternalServerError, gin.H{
+				"error":   "Failed to recompute user profile",
+				"details": err.Error(),
+			}
+		}
+		return taskCompleted(task, profile)
 
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{
+		return http.StatusBadRequest, gin.H{
 			"error": "Unknown task type: " + task.TaskType,
-		})
-		return
+		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	return http.StatusOK, gin.H{
 		"message":   "Cleanup task completed successfully",
 		"task_type": task.TaskType,
 		"timestamp": task.Timestamp,
-	})
+	}
+}
+
+// taskCompleted is the response for a task that reports what it did
+func taskCompleted(task models.CleanupTask, result interface{}) (int, gin.H) {
+	return http.StatusOK, gin.H{
+		"message":   "Cleanup task completed successfully",
+		"task_type": task.TaskType,
+		"timestamp": task.Timestamp,
+		"result":    result,
+	}
 }
 
 // ScheduleCleanup handles POST /webhook/schedule-cleanup
 func (h *WebhookHandler) ScheduleCleanup(c *gin.Context) {
-	type ScheduleRequest struct {
-		CallbackURL string `json:"callback_url" binding:"required"`
+	var req models.ScheduleCleanupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"details": err.Error(),
+		})
+		return
+	}
+	// Without a tenant the schedule cleans up every tenant
+	if tenantID := c.GetHeader("X-Tenant-ID"); tenantID != "" {
+		req.TenantID = tenantID
 	}
 
-	var req ScheduleRequest
+	schedule, err := h.service(c).ScheduleCleanup(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSchedule):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid schedule",
+				"details": err.Error(),
+			})
+		case errors.Is(err, services.ErrCallbackNotAllowed):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Callback URL not allowed",
+				"details": err.Error(),
+			})
+		case errors.Is(err, services.ErrDuplicateSchedule):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    "Cleanup already scheduled for this callback URL",
+				"details":  err.Error(),
+				"schedule": schedule,
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to schedule cleanup",
+				"details": err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Cleanup scheduled successfully",
+		"schedule_id":  schedule.ScheduleID,
+		"callback_url": schedule.CallbackURL,
+		"schedule":     schedule,
+	})
+}
+
+// ListSchedules handles GET /webhook/schedules
+func (h *WebhookHandler) ListSchedules(c *gin.Context) {
+	schedules, err := h.service(c).ListCleanupSchedules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to list schedules",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"schedules": schedules,
+		"total":     len(schedules),
+	})
+}
+
+// DeleteSchedule handles DELETE /webhook/schedules/:id
+func (h *WebhookHandler) DeleteSchedule(c *gin.Context) {
+	scheduleID := c.Param("id")
+	if err := h.service(c).DeleteCleanupSchedule(scheduleID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to delete schedule",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Schedule deleted successfully",
+		"schedule_id": scheduleID,
+	})
+}
+
+// ScheduleSessionCleanup handles POST /webhook/schedule-session-cleanup
+func (h *WebhookHandler) ScheduleSessionCleanup(c *gin.Context) {
+	type ScheduleSessionCleanupRequest struct {
+		CallbackURL  string `json:"callback_url" binding:"required"`
+		SessionID    string `json:"session_id" binding:"required"`
+		TenantID     string `json:"tenant_id"`
+		DelaySeconds int    `json:"delay_seconds"`
+		models.DeliveryOptions
+	}
+
+	var req ScheduleSessionCleanupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid request format",
@@ -103,19 +439,33 @@ func (h *WebhookHandler) ScheduleCleanup(c *gin.Context) {
 		return
 	}
 
-	scheduleID, err := h.memoryService.ScheduleCleanup(req.CallbackURL)
+	// Default delay of 1 hour if not specified
+	if req.DelaySeconds <= 0 {
+		req.DelaySeconds = 3600
+	}
+
+	messageID, err := h.service(c).ScheduleDelayedSessionCleanup(req.CallbackURL, req.SessionID, tenantFromRequest(c, req.TenantID), req.DelaySeconds, req.DeliveryOptions)
 	if err != nil {
+		if errors.Is(err, services.ErrCallbackNotAllowed) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Callback URL not allowed",
+				"details": err.Error(),
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to schedule cleanup",
+			"error":   "Failed to schedule session cleanup",
 			"details": err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":      "Cleanup scheduled successfully",
-		"schedule_id":  scheduleID,
-		"callback_url": req.CallbackURL,
+		"message":       "Session cleanup scheduled successfully",
+		"message_id":    messageID,
+		"session_id":    req.SessionID,
+		"delay_seconds": req.DelaySeconds,
 	})
 }
 
@@ -125,6 +475,7 @@ func (h *WebhookHandler) ScheduleUserCleanup(c *gin.Context) {
 		CallbackURL  string `json:"callback_url" binding:"required"`
 		UserID       string `json:"user_id" binding:"required"`
 		DelaySeconds int    `json:"delay_seconds"`
+		models.DeliveryOptions
 	}
 
 	var req ScheduleUserCleanupRequest
@@ -141,8 +492,16 @@ func (h *WebhookHandler) ScheduleUserCleanup(c *gin.Context) {
 		req.DelaySeconds = 3600
 	}
 
-	messageID, err := h.memoryService.ScheduleDelayedUserCleanup(req.CallbackURL, req.UserID, req.DelaySeconds)
+	messageID, err := h.service(c).ScheduleDelayedUserCleanup(req.CallbackURL, req.UserID, req.DelaySeconds, req.DeliveryOptions)
 	if err != nil {
+		if errors.Is(err, services.ErrCallbackNotAllowed) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Callback URL not allowed",
+				"details": err.Error(),
+			})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to schedule user cleanup",
 			"details": err.Error(),
@@ -162,14 +521,27 @@ func (h *WebhookHandler) ScheduleUserCleanup(c *gin.Context) {
 func (h *WebhookHandler) GetWebhookInfo(c *gin.Context) {
 	info := gin.H{
 		"endpoints": map[string]string{
-			"cleanup":               "POST /webhook/cleanup - Handle cleanup tasks from QStash",
-			"schedule_cleanup":      "POST /webhook/schedule-cleanup - Schedule periodic cleanup",
-			"schedule_user_cleanup": "POST /webhook/schedule-user-cleanup - Schedule user-specific cleanup",
+			"cleanup":                  "POST /webhook/cleanup - Handle cleanup tasks from QStash",
+			"schedule_cleanup":         "POST /webhook/schedule-cleanup - Schedule periodic cleanup (cron, timezone, tenant_id, owner, purpose)",
+			"list_schedules":           "GET /webhook/schedules - List cleanup schedules",
+			"delete_schedule":          "DELETE /webhook/schedules/:id - Cancel a cleanup schedule",
+			"schedule_user_cleanup":    "POST /webhook/schedule-user-cleanup - Schedule user-specific cleanup",
+			"schedule_session_cleanup": "POST /webhook/schedule-session-cleanup - Schedule deletion of a session",
 		},
+		"delivery_options": []string{"retries", "failure_callback", "deduplication_id"},
 		"supported_tasks": []string{
 			"cleanup_expired_memories",
+			"notify_expiring_memories",
 			"cleanup_user_memories",
 			"cleanup_session",
+			"consolidate_user_memories",
+			"reembed_namespace",
+			"archive_expired_sessions",
+			"apply_session_policies",
+			"recompute_user_profile",
+			"rollup_memories",
+			"tier_cold_memories",
+			"send_memory_digest",
 		},
 		"example_payload": models.CleanupTask{
 			TaskType: "cleanup_expired_memories",
@@ -181,25 +553,98 @@ func (h *WebhookHandler) GetWebhookInfo(c *gin.Context) {
 	c.JSON(http.StatusOK, info)
 }
 
-// ValidateWebhook handles webhook signature validation (if needed)
-func (h *WebhookHandler) ValidateWebhook(c *gin.Context) {
-	// Get QStash signature from headers
-	signature := c.GetHeader("Upstash-Signature")
+// RequireScheduler rejects scheduling requests with 501 when QStash is not configured
+func (h *WebhookHandler) RequireScheduler(c *gin.Context) {
+	if h.service(c).SchedulerAvailable() {
+		c.Next()
+		return
+	}
 
-	// In a production environment, you would validate the signature here
-	// For now, we'll just log it
-	if signature != "" {
-		// Log the signature for debugging
-		// In production, implement proper signature validation
+	c.AbortWithStatusJSON(http.StatusNotImplemented, gin.H{
+		"error":              "Scheduling is unavailable",
+		"details":            "QSTASH_TOKEN is not configured",
+		"internal_scheduler": config.AppConfig.InternalSchedulerActive(),
+	})
+}
+
+// RequireSignature rejects webhook deliveries without a valid Upstash-Signature. While no
+// signing keys are configured the request is authenticated by fallback instead.
+func (h *WebhookHandler) RequireSignature(fallback gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys := clients.GetSigningKeys()
+		if !keys.Configured() {
+			fallback(c)
+			return
+		}
+		h.verifySignature(c, keys)
 	}
+}
 
-	// For this example, we'll just return success
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Webhook validation endpoint",
+// verifySignature checks the request body against the Upstash-Signature header
+func (h *WebhookHandler) verifySignature(c *gin.Context, keys *clients.SigningKeys) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error":   "Failed to read request body",
+			"details": err.Error(),
+		})
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := keys.Verify(c.GetHeader("Upstash-Signature"), body, deliveryURL(c)); err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error":   "Invalid webhook signature",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.Next()
+}
+
+// deliveryURL returns the URL a webhook delivery was sent to, which QStash signs as the
+// token's subject. Behind a proxy, X-Forwarded-Proto and X-Forwarded-Host give the
+// scheme and host the caller used.
+func deliveryURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme, _, _ = strings.Cut(proto, ",")
+	}
+	host := c.Request.Host
+	if forwarded := c.GetHeader("X-Forwarded-Host"); forwarded != "" {
+		host, _, _ = strings.Cut(forwarded, ",")
+	}
+	return strings.TrimSpace(scheme) + "://" + strings.TrimSpace(host) + c.Request.URL.RequestURI()
+}
+
+// ValidateWebhook handles GET /webhook/validate, reporting whether the request's signature verifies
+func (h *WebhookHandler) ValidateWebhook(c *gin.Context) {
+	signature := c.GetHeader("Upstash-Signature")
+	keys := clients.GetSigningKeys()
+
+	response := gin.H{
+		"message":      "Webhook validation endpoint",
+		"signing_keys": keys.Status(),
 		"headers": map[string]string{
 			"Upstash-Signature": signature,
 		},
-	})
+	}
+
+	if keys.Configured() && signature != "" {
+		body, _ := io.ReadAll(c.Request.Body)
+		if err := keys.Verify(signature, body, deliveryURL(c)); err != nil {
+			response["valid"] = false
+			response["details"] = err.Error()
+		} else {
+			response["valid"] = true
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // TestWebhook handles POST /webhook/test - for testing webhook functionality
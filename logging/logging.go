@@ -0,0 +1,91 @@
+// Package logging writes structured log events, as JSON lines or as readable text
+// depending on LOG_FORMAT
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+)
+
+// Fields are the key-value pairs attached to an event
+type Fields map[string]interface{}
+
+var (
+	mu     sync.Mutex
+	output io.Writer = os.Stderr
+)
+
+// Info logs a routine event
+func Info(msg string, fields Fields) {
+	write("info", msg, fields)
+}
+
+// Warn logs an event worth attention that did not fail a request
+func Warn(msg string, fields Fields) {
+	write("warn", msg, fields)
+}
+
+// Error logs a failure
+func Error(msg string, fields Fields) {
+	write("error", msg, fields)
+}
+
+func write(level string, msg string, fields Fields) {
+	if config.AppConfig != nil && config.AppConfig.LogFormat == "json" {
+		event := make(map[string]interface{}, len(fields)+3)
+		for k, v := range fields {
+			event[k] = v
+		}
+		event["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+		event["level"] = level
+		event["msg"] = msg
+
+		line, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("Warning: failed to encode log event %q: %v", msg, err)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		output.Write(append(line, '\n'))
+		return
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(strings.ToUpper(level))
+	b.WriteString(" ")
+	b.WriteString(msg)
+	var multiline []string
+	for _, k := range keys {
+		value := fmt.Sprint(fields[k])
+		if strings.Contains(value, "\n") {
+			// Stack traces follow the event instead of breaking up its line
+			multiline = append(multiline, value)
+			continue
+		}
+		if strings.ContainsAny(value, " \t\"=") {
+			value = fmt.Sprintf("%q", value)
+		}
+		fmt.Fprintf(&b, " %s=%s", k, value)
+	}
+	for _, value := range multiline {
+		b.WriteString("\n")
+		b.WriteString(value)
+	}
+	log.Print(b.String())
+}
@@ -1,124 +1,81 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/Fairy-nn/MemoryCacheAI/app"
 	"github.com/Fairy-nn/MemoryCacheAI/config"
 	"github.com/Fairy-nn/MemoryCacheAI/handlers"
+	"github.com/Fairy-nn/MemoryCacheAI/mcp"
+	"github.com/Fairy-nn/MemoryCacheAI/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	check := flag.Bool("check", false, "validate every configured dependency and exit")
+	migrate := flag.Bool("migrate-key-prefix", false, "rename Redis keys from -from-prefix to REDIS_KEY_PREFIX and exit")
+	fromPrefix := flag.String("from-prefix", "", "key prefix the keys currently use, empty for unprefixed keys")
+	excludePrefixes := flag.String("exclude-prefixes", "", "comma-separated key prefixes of other environments to leave alone")
+	dryRun := flag.Bool("dry-run", false, "with -migrate-key-prefix, count the keys that would move without renaming them")
+	mcpStdio := flag.Bool("mcp", false, "serve the MCP tools over stdin and stdout instead of HTTP")
+	flag.Parse()
+
 	// Load configuration
 	config.LoadConfig()
 
+	if *check {
+		os.Exit(runSelfTest())
+	}
+	if *migrate {
+		os.Exit(runKeyMigration(*fromPrefix, splitList(*excludePrefixes), *dryRun))
+	}
+	if *mcpStdio {
+		os.Exit(runMCP())
+	}
+
 	// Set Gin mode
 	gin.SetMode(config.AppConfig.GinMode)
 
-	// Create Gin router
-	router := gin.Default()
+	// Build the shared services once and hand them to every handler
+	application := app.New()
+
+	// Register every route on the shared router
+	router := handlers.NewRouter(application)
 
-	// Add CORS middleware
-	router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	// Start probing embedding providers and, without QStash, the internal scheduler
+	application.Start()
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(http.StatusNoContent)
-			return
+	// Without QStash, scheduled cleanup falls back to the internal scheduler if enabled
+	if config.AppConfig.SchedulerMode == "local" {
+		log.Printf("⏱ Local scheduler queues maintenance jobs on cron in %s: %v", config.AppConfig.SchedulerTimezone, config.AppConfig.SchedulerCrons)
+	} else if !config.AppConfig.QStashConfigured() {
+		if config.AppConfig.InternalSchedulerEnabled {
+			log.Printf("⏱ QStash is not configured; internal scheduler runs cleanup every %s", config.AppConfig.InternalCleanupInterval)
+		} else {
+			log.Println("⚠️ QStash is not configured; scheduling endpoints are disabled")
 		}
+	}
 
-		c.Next()
-	})
-
-	// Initialize handlers
-	memoryHandler := handlers.NewMemoryHandler()
-	webhookHandler := handlers.NewWebhookHandler()
-
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":  "healthy",
-			"service": "MemoryCacheAI",
-			"version": "1.0.0",
-		})
-	})
-
-	// API info endpoint
-	router.GET("/", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"service":     "MemoryCacheAI",
-			"description": "AI Assistant Memory Cache Service",
-			"version":     "1.0.0",
-			"endpoints": map[string]interface{}{
-				"memory": map[string]string{
-					"save":           "POST /memory/save",
-					"query":          "POST /memory/query",
-					"stats":          "GET /memory/stats",
-					"embedding_info": "GET /memory/embedding-info",
-					"delete":         "DELETE /memory/:id?user_id=user-id",
-				},
-				"sessions": map[string]string{
-					"get":     "GET /session/:id",
-					"delete":  "DELETE /session/:id",
-					"context": "PUT /session/:id/context",
-				},
-				"users": map[string]string{
-					"sessions":        "GET /user/:id/sessions",
-					"recent_memories": "GET /user/:id/memories/recent",
-					"search_memories": "GET /user/:id/memories/search?q=keyword",
-					"cleanup":         "DELETE /user/:id/memories",
-				},
-				"webhooks": map[string]string{
-					"cleanup":               "POST /webhook/cleanup",
-					"schedule_cleanup":      "POST /webhook/schedule-cleanup",
-					"schedule_user_cleanup": "POST /webhook/schedule-user-cleanup",
-					"test":                  "POST /webhook/test",
-					"info":                  "GET /webhook/info",
-				},
-			},
-		})
-	})
-
-	// Memory routes
-	memoryRoutes := router.Group("/memory")
-	{
-		memoryRoutes.POST("/save", memoryHandler.SaveMemory)
-		memoryRoutes.POST("/query", memoryHandler.QueryMemory)
-		memoryRoutes.GET("/stats", memoryHandler.GetMemoryStats)
-		memoryRoutes.GET("/embedding-info", memoryHandler.GetEmbeddingInfo)
-		memoryRoutes.DELETE("/:id", memoryHandler.DeleteMemory)
-	}
-
-	// Session routes
-	sessionRoutes := router.Group("/session")
-	{
-		sessionRoutes.GET("/:id", memoryHandler.GetSession)
-		sessionRoutes.DELETE("/:id", memoryHandler.DeleteSession)
-		sessionRoutes.PUT("/:id/context", memoryHandler.SetSessionContext)
-	}
-
-	// User routes
-	userRoutes := router.Group("/user")
-	{
-		userRoutes.GET("/:id/sessions", memoryHandler.GetUserSessions)
-		userRoutes.GET("/:id/memories/recent", memoryHandler.GetRecentMemories)
-		userRoutes.GET("/:id/memories/search", memoryHandler.SearchMemories)
-		userRoutes.DELETE("/:id/memories", memoryHandler.CleanupUserMemories)
-	}
-
-	// Webhook routes
-	webhookRoutes := router.Group("/webhook")
-	{
-		webhookRoutes.POST("/cleanup", webhookHandler.HandleCleanupWebhook)
-		webhookRoutes.POST("/schedule-cleanup", webhookHandler.ScheduleCleanup)
-		webhookRoutes.POST("/schedule-user-cleanup", webhookHandler.ScheduleUserCleanup)
-		webhookRoutes.POST("/test", webhookHandler.TestWebhook)
-		webhookRoutes.GET("/info", webhookHandler.GetWebhookInfo)
-		webhookRoutes.GET("/validate", webhookHandler.ValidateWebhook)
+	// Validate credentials and warm local caches; readiness waits for this
+	if config.AppConfig.PrewarmEnabled {
+		go func() {
+			if err := application.MemoryService.Prewarm(); err != nil {
+				log.Printf("❌ Prewarm failed: %v", err)
+				return
+			}
+			log.Println("🔥 Prewarm completed")
+		}()
 	}
 
 	// Start server
@@ -128,9 +85,156 @@ func main() {
 	log.Printf("🔗 Session endpoints: /session/:id")
 	log.Printf("👤 User endpoints: /user/:id/sessions, /user/:id/memories/*")
 	log.Printf("🪝 Webhook endpoints: /webhook/*")
-	log.Printf("🏥 Health check: /health")
+	log.Printf("🛠 Admin endpoints: /admin/*")
+	log.Printf("🏥 Health check: /health, /health/ready, /metrics")
+
+	server := &http.Server{
+		Addr:    port,
+		Handler: router,
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	// Drain in-flight requests on SIGINT/SIGTERM before releasing shared resources
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	<-ctx.Done()
+	// A second signal stops the process without draining
+	stop()
+
+	shutdown(server, application)
+}
+
+// shutdown fails readiness and keeps serving for SHUTDOWN_DELAY so load balancers stop
+// sending requests, then gives in-flight requests and the writes they left running
+// SHUTDOWN_TIMEOUT to finish before closing client connection pools
+func shutdown(server *http.Server, application *app.App) {
+	log.Println("🛑 Shutting down...")
+	services.BeginShutdown()
+	server.SetKeepAlivesEnabled(false)
+	if delay := config.AppConfig.ShutdownDelay; delay > 0 {
+		log.Printf("⏳ Failing readiness for %s before draining", delay)
+		time.Sleep(delay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.AppConfig.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("❌ In-flight requests did not finish within %s: %v", config.AppConfig.ShutdownTimeout, err)
+	}
+	if err := application.Drain(ctx); err != nil {
+		log.Printf("❌ Background writes did not finish within %s: %v", config.AppConfig.ShutdownTimeout, err)
+	}
+	application.Close()
+	log.Println("👋 Server stopped")
+}
+
+// runSelfTest checks every configured dependency, prints the report and returns the
+// process exit code: 0 when all checks pass, 1 otherwise
+func runSelfTest() int {
+	application := app.New()
+	defer application.Close()
+
+	report := application.MemoryService.SelfTest()
+	for _, check := range report.Checks {
+		switch {
+		case check.Skipped:
+			log.Printf("⏭ %s (%s): skipped, %s", check.Name, check.Target, check.Error)
+		case check.OK:
+			log.Printf("✅ %s (%s): ok in %dms", check.Name, check.Target, check.LatencyMs)
+		default:
+			log.Printf("❌ %s (%s): %s", check.Name, check.Target, check.Error)
+		}
+	}
 
-	if err := router.Run(port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	output, _ := json.MarshalIndent(report, "", "  ")
+	os.Stdout.Write(append(output, '\n'))
+
+	if !report.OK {
+		log.Println("❌ Self-test failed")
+		return 1
+	}
+	log.Println("✅ Self-test passed")
+	return 0
+}
+
+// runMCP serves the MCP tools over stdin and stdout until stdin closes or the process is
+// signalled, then drains background writes, and returns the process exit code
+func runMCP() int {
+	// stdout carries MCP messages only, so warnings printed there go to stderr instead
+	protocol := os.Stdout
+	os.Stdout = os.Stderr
+
+	application := app.New()
+	application.Start()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Println("🔌 Serving MCP tools over stdio")
+	err := mcp.NewServer(application.MemoryService).ServeStdio(ctx, os.Stdin, protocol)
+	if err != nil {
+		log.Printf("❌ MCP stdio transport failed: %v", err)
+	}
+
+	services.BeginShutdown()
+	drainCtx, cancel := context.WithTimeout(context.Background(), config.AppConfig.ShutdownTimeout)
+	defer cancel()
+	if err := application.Drain(drainCtx); err != nil {
+		log.Printf("❌ Background writes did not finish within %s: %v", config.AppConfig.ShutdownTimeout, err)
+	}
+	application.Close()
+
+	if err != nil {
+		return 1
+	}
+	return 0
+}
+
+// runKeyMigration moves every Redis key from the old prefix to REDIS_KEY_PREFIX, prints the
+// report and returns the process exit code: 0 when every instance migrated, 1 otherwise
+func runKeyMigration(from string, exclude []string, dryRun bool) int {
+	application := app.New()
+	defer application.Close()
+
+	to := config.AppConfig.RedisKeyPrefix
+	log.Printf("🔀 Moving Redis keys from prefix %q to %q (dry run: %v)", from, to, dryRun)
+
+	reports := application.MemoryService.MigrateKeyPrefix(from, exclude, dryRun)
+	failed := false
+	for _, report := range reports {
+		target := report.Region
+		if target == "" {
+			target = "default"
+		}
+		if report.Error != "" {
+			failed = true
+			log.Printf("❌ %s: %s", target, report.Error)
+			continue
+		}
+		log.Printf("✅ %s: %d scanned, %d renamed, %d skipped, %d conflicts",
+			target, report.Scanned, report.Renamed, report.Skipped, len(report.Conflicts))
+	}
+
+	output, _ := json.MarshalIndent(reports, "", "  ")
+	os.Stdout.Write(append(output, '\n'))
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
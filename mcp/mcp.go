@@ -0,0 +1,149 @@
+// Package mcp serves the memory service as a Model Context Protocol server, so MCP
+// clients such as desktop assistants can save and recall memories as tools. Server
+// answers single JSON-RPC messages; ServeStdio carries them over stdin and stdout, and
+// the HTTP server carries them over server-sent events (see handlers.MCPHandler).
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Fairy-nn/MemoryCacheAI/services"
+)
+
+// protocolVersions are the MCP revisions the server speaks, newest first
+var protocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// serverInstructions tell the client's model how to use the tools
+const serverInstructions = `MemoryCacheAI stores long-term memories of conversations. Call query_memory before answering questions that may depend on what the user said earlier, and save_memory when the user shares something worth remembering, such as a preference, a fact about themselves or a decision.`
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// Server answers MCP requests with the memory service. It holds no per-client state, so
+// one Server serves every connection.
+type Server struct {
+	memory *services.MemoryService
+}
+
+// NewServer returns a server exposing the memory service's tools
+func NewServer(memory *services.MemoryService) *Server {
+	return &Server{memory: memory}
+}
+
+// Handle answers one JSON-RPC message for the tenant, returning nil for notifications.
+// Tool calls run on the memory service bound to ctx.
+func (s *Server) Handle(ctx context.Context, tenantID string, message []byte) []byte {
+	var req request
+	if err := json.Unmarshal(message, &req); err != nil {
+		return encode(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: "invalid JSON: " + err.Error()}})
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return encode(response{JSONRPC: "2.0", ID: idOrNull(req.ID), Error: &rpcError{Code: codeInvalidRequest, Message: "not a JSON-RPC 2.0 request"}})
+	}
+
+	result, err := s.dispatch(ctx, tenantID, req)
+	// Notifications carry no ID and get no response, not even an error
+	if len(req.ID) == 0 {
+		return nil
+	}
+	if err != nil {
+		rpcErr, ok := err.(*rpcError)
+		if !ok {
+			rpcErr = &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		return encode(response{JSONRPC: "2.0", ID: req.ID, Error: rpcErr})
+	}
+	return encode(response{JSONRPC: "2.0", ID: req.ID, Result: result})
+}
+
+func (s *Server) dispatch(ctx context.Context, tenantID string, req request) (interface{}, error) {
+	switch req.Method {
+	case "initialize":
+		return initialize(req.Params)
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": listTools()}, nil
+	case "tools/call":
+		return s.callTool(ctx, tenantID, req.Params)
+	case "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+// initialize agrees on the client's protocol revision when the server speaks it, and
+// offers the newest one otherwise
+func initialize(params json.RawMessage) (interface{}, error) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "invalid initialize params: " + err.Error()}
+		}
+	}
+
+	version := protocolVersions[0]
+	for _, supported := range protocolVersions {
+		if p.ProtocolVersion == supported {
+			version = supported
+		}
+	}
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities": map[string]interface{}{
+			"tools": map[string]interface{}{"listChanged": false},
+		},
+		"serverInfo": map[string]interface{}{
+			"name":    "memorycache-ai",
+			"version": "1.0.0",
+		},
+		"instructions": serverInstructions,
+	}, nil
+}
+
+func idOrNull(id json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+func encode(resp response) []byte {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(response{JSONRPC: "2.0", ID: resp.ID, Error: &rpcError{Code: codeInternalError, Message: err.Error()}})
+	}
+	return data
+}
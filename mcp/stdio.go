@@ -0,0 +1,51 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+)
+
+// ServeStdio answers newline-delimited JSON-RPC messages read from in, writing each
+// response to out on its own line, until in ends or ctx is done. Messages are handled
+// one at a time for the default tenant. Nothing else may write to out.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				select {
+				case lines <- line:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case line := <-lines:
+			if response := s.Handle(ctx, "", line); response != nil {
+				if _, err := out.Write(append(response, '\n')); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/tools"
+)
+
+// tool describes one tool to MCP clients
+type tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// listTools describes the memory tools. Calls name their user, or act for MCP_USER_ID.
+func listTools() []tool {
+	var listed []tool
+	for _, t := range tools.List(true) {
+		listed = append(listed, tool{Name: t.Name, Description: t.Description, InputSchema: t.Parameters})
+	}
+	return listed
+}
+
+// toolResult is the result of tools/call. Failed calls, including those with invalid
+// arguments, are reported in the result with IsError set, so the model sees the error
+// and can correct its call.
+type toolResult struct {
+	Content []textContent `json:"content"`
+	IsError bool          `json:"isError,omitempty"`
+}
+
+type textContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// callTool runs a tools/call request
+func (s *Server) callTool(ctx context.Context, tenantID string, params json.RawMessage) (interface{}, error) {
+	var call struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &call); err != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "invalid tools/call params: " + err.Error()}
+	}
+
+	output, err := tools.Invoke(s.memory.WithContext(ctx), tools.Call{
+		Name:          call.Name,
+		Arguments:     call.Arguments,
+		TenantID:      tenantID,
+		DefaultUserID: config.AppConfig.MCPUserID,
+	})
+	if errors.Is(err, tools.ErrUnknownTool) {
+		return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	if err != nil {
+		return toolResult{Content: []textContent{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+
+	text, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return nil, &rpcError{Code: codeInternalError, Message: err.Error()}
+	}
+	return toolResult{Content: []textContent{{Type: "text", Text: string(text)}}}, nil
+}
@@ -0,0 +1,258 @@
+// Package memorycache embeds the memory engine in a Go program, without the HTTP server.
+// It saves, queries and reads sessions through the same services the server uses, on
+// the same Redis and vector store backends:
+//
+//	svc, err := memorycache.New(memorycache.Options{
+//		RedisAddr:       "localhost:6379",
+//		VectorProvider:  "qdrant",
+//		VectorURL:       "http://localhost:6333",
+//		EmbeddingAPIKey: os.Getenv("JINA_API_KEY"),
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer svc.Close()
+//
+//	_, err = svc.Save(ctx, memorycache.SaveRequest{UserID: "user123", SessionID: "s1", Content: "My cat is called Miso"})
+//	response, err := svc.Query(ctx, memorycache.QueryRequest{UserID: "user123", Query: "what is my cat called"})
+//
+// With InMemory set, Redis and the vector store live in the process and embeddings are
+// computed locally, so no external service or API key is needed, e.g. for tests:
+//
+//	svc, err := memorycache.New(memorycache.Options{InMemory: true})
+//
+// The configuration is process-wide, so a program embeds one Service at a time.
+// Built with the lite tag, the package keeps all state in the Upstash REST APIs and runs
+// nothing in the background, for edge runtimes; see config.Lite.
+package memorycache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Fairy-nn/MemoryCacheAI/app"
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/Fairy-nn/MemoryCacheAI/services"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Request and response types, shared with the HTTP API
+type (
+	SaveRequest   = models.SaveMemoryRequest
+	SaveResult    = models.SaveMemoryResult
+	QueryRequest  = models.QueryMemoryRequest
+	QueryResponse = models.QueryMemoryResponse
+	Session       = models.SessionData
+)
+
+// Errors callers may want to tell apart with errors.Is
+var (
+	ErrAlreadyOpen             = errors.New("a memorycache service is already open in this process")
+	ErrInvalidRequest          = errors.New("invalid request")
+	ErrEmbeddingBudgetExceeded = services.ErrEmbeddingBudgetExceeded
+	ErrWriteBlocked            = services.ErrWriteBlocked
+	ErrSessionClosed           = services.ErrSessionClosed
+	ErrPoisonedContent         = services.ErrPoisonedContent
+)
+
+// Options select the backends of an embedded service. Settings not covered by a field
+// go in Settings, keyed by the environment variable names documented in env.example;
+// anything left unset is read from the environment as the server would.
+type Options struct {
+	// Redis: the Upstash REST API, or native Redis when RedisAddr is set
+	RedisURL      string
+	RedisToken    string
+	RedisAddr     string
+	RedisPassword string
+
+	// Vector store: "upstash" (the default) or "qdrant"
+	VectorProvider string
+	VectorURL      string
+	VectorToken    string // Upstash token or Qdrant API key
+
+	// Embeddings: "jina" (the default), "openai" or "local"
+	EmbeddingProvider string
+	EmbeddingAPIKey   string
+
+	// InMemory keeps Redis and vector data in the process and embeds with the "local"
+	// provider unless EmbeddingProvider names another. The data starts empty with each
+	// New and is lost when the process exits. The Redis and vector fields are ignored.
+	InMemory bool
+
+	// StartWorkers runs the background workers as the server does: embedding health
+	// probes, storage usage sampling, write-ahead queue replay and the internal scheduler
+	StartWorkers bool
+
+	// Settings override the fields above, such as "REDIS_KEY_PREFIX" or "SESSION_TTL"
+	Settings map[string]string
+}
+
+// settings maps the options to configuration variables
+func (o Options) settings() map[string]string {
+	settings := make(map[string]string)
+	set := func(key, value string) {
+		if value != "" {
+			settings[key] = value
+		}
+	}
+
+	if o.InMemory {
+		set("REDIS_PROVIDER", "memory")
+		set("VECTOR_PROVIDER", "memory")
+		if o.EmbeddingProvider == "" {
+			set("EMBEDDING_PROVIDER", "local")
+		}
+	} else {
+		set("UPSTASH_REDIS_URL", o.RedisURL)
+		set("UPSTASH_REDIS_TOKEN", o.RedisToken)
+		set("REDIS_ADDR", o.RedisAddr)
+		set("REDIS_PASSWORD", o.RedisPassword)
+
+		set("VECTOR_PROVIDER", o.VectorProvider)
+		if strings.EqualFold(o.VectorProvider, "qdrant") {
+			set("QDRANT_URL", o.VectorURL)
+			set("QDRANT_API_KEY", o.VectorToken)
+		} else {
+			set("UPSTASH_VECTOR_URL", o.VectorURL)
+			set("UPSTASH_VECTOR_TOKEN", o.VectorToken)
+		}
+	}
+
+	set("EMBEDDING_PROVIDER", o.EmbeddingProvider)
+	if strings.EqualFold(o.EmbeddingProvider, "openai") {
+		set("OPENAI_API_KEY", o.EmbeddingAPIKey)
+	} else {
+		set("JINA_API_KEY", o.EmbeddingAPIKey)
+	}
+
+	for key, value := range o.Settings {
+		settings[key] = value
+	}
+	return settings
+}
+
+// Service is an embedded memory engine. Its methods are safe for concurrent use.
+type Service struct {
+	app       *app.App
+	closeOnce sync.Once
+}
+
+var (
+	openMu sync.Mutex
+	isOpen bool
+)
+
+// New configures and starts an embedded service. It fails with ErrAlreadyOpen while
+// another Service is open, and with the invalid setting when the options are incomplete.
+func New(opts Options) (*Service, error) {
+	openMu.Lock()
+	defer openMu.Unlock()
+	if isOpen {
+		return nil, ErrAlreadyOpen
+	}
+
+	if err := config.LoadConfigFrom(opts.settings()); err != nil {
+		return nil, fmt.Errorf("invalid memorycache options: %w", err)
+	}
+	if opts.InMemory {
+		clients.ResetMemoryBackends()
+	}
+
+	s := &Service{app: app.New()}
+	if opts.StartWorkers {
+		s.app.Start()
+	}
+	isOpen = true
+	return s, nil
+}
+
+// Shutdown waits for the writes Save left running in the background, such as session
+// condensing and standing query alerts, until ctx ends, then closes the service
+func (s *Service) Shutdown(ctx context.Context) error {
+	err := s.app.Drain(ctx)
+	s.Close()
+	return err
+}
+
+// Close stops the background workers and releases client connections without waiting
+// for background writes; see Shutdown. The service must not be used afterwards; a new
+// one may then be opened.
+func (s *Service) Close() {
+	s.closeOnce.Do(func() {
+		s.app.Close()
+
+		openMu.Lock()
+		defer openMu.Unlock()
+		isOpen = false
+	})
+}
+
+// Save adds a message to its session and stores it as a long-term memory. When the
+// vector store is down and the write-ahead queue is enabled, the memory is queued and
+// the result's Storage is models.StorageQueued. An async save (req.Async or SAVE_ASYNC)
+// returns once the message is in its session, with Storage models.StoragePending and the
+// JobID of the job storing the memory; Shutdown waits for it.
+func (s *Service) Save(ctx context.Context, req SaveRequest) (*SaveResult, error) {
+	if err := validate(&req); err != nil {
+		return nil, err
+	}
+	return s.memory(ctx).SaveMemory(req)
+}
+
+// Query returns the user's memories most relevant to req.Query
+func (s *Service) Query(ctx context.Context, req QueryRequest) (*QueryResponse, error) {
+	if err := validate(&req); err != nil {
+		return nil, err
+	}
+	return s.memory(ctx).QueryMemory(req)
+}
+
+// Session returns a session with all of its messages. tenantID routes the read to the
+// tenant's data region and may be empty.
+func (s *Service) Session(ctx context.Context, tenantID string, sessionID string) (*Session, error) {
+	return s.memory(ctx).ForTenant(tenantID).GetSession(sessionID, nil)
+}
+
+// UserSessions returns the IDs of a user's sessions
+func (s *Service) UserSessions(ctx context.Context, tenantID string, userID string) ([]string, error) {
+	return s.memory(ctx).ForTenant(tenantID).GetUserSessions(userID)
+}
+
+// DeleteSession removes a session and, with deleteMemories, the memories saved from it
+func (s *Service) DeleteSession(ctx context.Context, tenantID string, sessionID string, deleteMemories bool) error {
+	return s.memory(ctx).DeleteSession(sessionID, deleteMemories, tenantID)
+}
+
+// Services returns the underlying memory service, for features the facade does not cover
+func (s *Service) Services() *services.MemoryService {
+	return s.app.MemoryService
+}
+
+// memory returns the service bound to ctx, so cancelling ctx abandons its upstream calls
+func (s *Service) memory(ctx context.Context) *services.MemoryService {
+	return s.app.MemoryService.WithContext(ctx)
+}
+
+// requestValidator reads the binding tags the HTTP API validates request bodies with,
+// without pulling the HTTP framework into lite builds
+var requestValidator = newRequestValidator()
+
+func newRequestValidator() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	return v
+}
+
+// validate applies the checks the HTTP API makes when binding a request body
+func validate(req interface{}) error {
+	if err := requestValidator.Struct(req); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type metricType string
+
+const (
+	typeGauge   metricType = "gauge"
+	typeCounter metricType = "counter"
+)
+
+// family groups all samples that share a metric name
+type family struct {
+	help    string
+	typ     metricType
+	samples map[string]float64 // keyed by rendered label set
+}
+
+var (
+	mu       sync.RWMutex
+	families = make(map[string]*family)
+)
+
+// SetGauge records the current value of a gauge
+func SetGauge(name, help string, labels map[string]string, value float64) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	getFamily(name, help, typeGauge).samples[renderLabels(labels)] = value
+}
+
+// AddCounter increments a counter by delta
+func AddCounter(name, help string, labels map[string]string, delta float64) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	getFamily(name, help, typeCounter).samples[renderLabels(labels)] += delta
+}
+
+// WriteText writes every registered metric in the Prometheus text exposition format
+func WriteText(w io.Writer) error {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := families[name]
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, f.help, name, f.typ); err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(f.samples))
+		for key := range f.samples {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if _, err := fmt.Fprintf(w, "%s%s %g\n", name, key, f.samples[key]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func getFamily(name, help string, typ metricType) *family {
+	f, ok := families[name]
+	if !ok {
+		f = &family{help: help, typ: typ, samples: make(map[string]float64)}
+		families[name] = f
+	}
+	return f
+}
+
+func renderLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = fmt.Sprintf("%s=%q", key, labels[key])
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
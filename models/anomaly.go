@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Write anomaly kinds detected by the write guard
+const (
+	AnomalySaveBurst        = "save_burst"        // a user saving faster than WRITE_GUARD_BURST_LIMIT
+	AnomalyContentFanout    = "content_fanout"    // identical content saved by many users
+	AnomalyOversizedContent = "oversized_content" // a memory over WRITE_GUARD_MAX_CONTENT_TOKENS
+	AnomalyOversizedContext = "oversized_context" // a session context over WRITE_GUARD_MAX_CONTEXT_BYTES
+)
+
+// Actions the write guard takes on an anomalous write
+const (
+	WriteGuardThrottle   = "throttle"   // the write is rejected
+	WriteGuardQuarantine = "quarantine" // the memory is stored but hidden from queries
+)
+
+// WriteAnomaly is an anomalous write reported to the admin API
+type WriteAnomaly struct {
+	Kind      string    `json:"kind"`
+	Action    string    `json:"action"`
+	TenantID  string    `json:"tenant_id,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
+	MemoryID  string    `json:"memory_id,omitempty"` // the quarantined memory
+	Value     int64     `json:"value"`               // what was measured
+	Limit     int64     `json:"limit"`
+	At        time.Time `json:"at"`
+}
+
+// QuarantinedMemory is a memory the write guard hid from queries
+type QuarantinedMemory struct {
+	ID            string    `json:"id"`
+	UserID        string    `json:"user_id"`
+	TenantID      string    `json:"tenant_id"`
+	Content       string    `json:"content"`
+	Reason        string    `json:"reason"` // the anomaly kind
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
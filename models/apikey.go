@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// APIKey is a stored API key. Only the SHA-256 hash of the key is kept.
+type APIKey struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// TenantID is the only tenant the key may act on; empty keys may act on any tenant
+	TenantID  string    `json:"tenant_id,omitempty"`
+	Hash      string    `json:"hash,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateAPIKeyRequest names a new API key
+type CreateAPIKeyRequest struct {
+	Name     string `json:"name" binding:"required"`
+	TenantID string `json:"tenant_id,omitempty"`
+}
+
+// CreatedAPIKey is returned once when a key is issued; the plaintext key cannot be read back
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
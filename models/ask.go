@@ -0,0 +1,27 @@
+package models
+
+// AskRequest answers a question from the user's memories with the configured LLM
+type AskRequest struct {
+	TenantID    string  `json:"tenant_id,omitempty"`
+	UserID      string  `json:"user_id" binding:"required"`
+	Question    string  `json:"question" binding:"required"`
+	Limit       int     `json:"limit,omitempty"` // memories given to the model, defaults to 5
+	MinScore    float64 `json:"min_score,omitempty"`
+	AssistantID string  `json:"assistant_id,omitempty"`
+	// MinConfidence, Granularity and Mode behave as in QueryMemoryRequest
+	MinConfidence float64 `json:"min_confidence,omitempty"`
+	Granularity   string  `json:"granularity,omitempty"`
+	Mode          string  `json:"mode,omitempty"`
+	// Stream sends the answer as server-sent events while it is generated
+	Stream bool `json:"stream,omitempty"`
+}
+
+// AskResponse is the generated answer and the memories it was grounded on
+type AskResponse struct {
+	Answer           string         `json:"answer"`
+	Model            string         `json:"model"`
+	Memories         []MemoryResult `json:"memories"`
+	Instructions     []Instruction  `json:"instructions"`
+	PromptTokens     int            `json:"prompt_tokens"`
+	CompletionTokens int            `json:"completion_tokens"`
+}
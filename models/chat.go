@@ -0,0 +1,107 @@
+package models
+
+import "encoding/json"
+
+// ChatMessage is one turn of an OpenAI-style conversation
+type ChatMessage struct {
+	Role    string `json:"role,omitempty"` // only on the first chunk of a streamed reply
+	Content string `json:"content"`
+}
+
+// ChatCompletionRequest is an OpenAI chat completions request. User carries the
+// MemoryCache user ID whose memories are added to the conversation.
+type ChatCompletionRequest struct {
+	Model     string        `json:"model,omitempty"` // empty uses the chat feature's model
+	Messages  []ChatMessage `json:"messages" binding:"required,min=1"`
+	Stream    bool          `json:"stream,omitempty"`
+	MaxTokens int           `json:"max_tokens,omitempty"`
+	User      string        `json:"user" binding:"required"`
+	// MemoryCache extensions
+	TenantID    string `json:"tenant_id,omitempty"`
+	AssistantID string `json:"assistant_id,omitempty"`
+	MemoryLimit int    `json:"memory_limit,omitempty"` // memories added to the conversation, defaults to 5
+}
+
+// ChatCompletionResponse is an OpenAI chat completion, plus the memories it used
+type ChatCompletionResponse struct {
+	ID       string                 `json:"id"`
+	Object   string                 `json:"object"` // "chat.completion"
+	Created  int64                  `json:"created"`
+	Model    string                 `json:"model"`
+	Choices  []ChatCompletionChoice `json:"choices"`
+	Usage    ChatCompletionUsage    `json:"usage"`
+	Memories []MemoryResult         `json:"memories"`
+}
+
+// ChatCompletionChoice is a completion choice; streamed chunks carry Delta instead of Message
+type ChatCompletionChoice struct {
+	Index        int          `json:"index"`
+	Message      *ChatMessage `json:"message,omitempty"`
+	Delta        *ChatMessage `json:"delta,omitempty"`
+	FinishReason *string      `json:"finish_reason"`
+}
+
+// ChatCompletionUsage reports the tokens a completion cost
+type ChatCompletionUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ChatCompletionChunk is one server-sent event of a streamed chat completion
+type ChatCompletionChunk struct {
+	ID      string                 `json:"id"`
+	Object  string                 `json:"object"` // "chat.completion.chunk"
+	Created int64                  `json:"created"`
+	Model   string                 `json:"model"`
+	Choices []ChatCompletionChoice `json:"choices"`
+	Usage   *ChatCompletionUsage   `json:"usage,omitempty"`
+}
+
+// OpenAITool is a tool in the OpenAI function-calling format
+type OpenAITool struct {
+	Type     string         `json:"type"` // "function"
+	Function OpenAIFunction `json:"function"`
+}
+
+// OpenAIFunction describes a function; Parameters is the JSON Schema of its arguments
+type OpenAIFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// ToolInvokeRequest runs the tool calls of an OpenAI assistant message for one user.
+// The user is fixed by the caller, never by the model.
+type ToolInvokeRequest struct {
+	TenantID  string     `json:"tenant_id,omitempty"`
+	UserID    string     `json:"user_id" binding:"required"`
+	ToolCalls []ToolCall `json:"tool_calls" binding:"required,min=1,max=20,dive"`
+}
+
+// ToolCall is one tool call of an assistant message
+type ToolCall struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type,omitempty"`
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction names the function called. Arguments are a JSON object, or a string
+// holding one as OpenAI sends them.
+type ToolCallFunction struct {
+	Name      string          `json:"name" binding:"required"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// ToolInvokeResponse holds one tool message per call, in order, ready to be appended to
+// the conversation
+type ToolInvokeResponse struct {
+	Messages []ToolMessage `json:"messages"`
+}
+
+// ToolMessage is the result of a tool call as an OpenAI tool message
+type ToolMessage struct {
+	Role       string `json:"role"` // "tool"
+	ToolCallID string `json:"tool_call_id"`
+	Content    string `json:"content"`
+}
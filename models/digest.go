@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// DigestSubscription schedules a recurring digest of a user's new memories
+type DigestSubscription struct {
+	UserID     string     `json:"user_id"`
+	TenantID   string     `json:"tenant_id"`
+	Cron       string     `json:"cron"`
+	Timezone   string     `json:"timezone"`
+	WebhookURL string     `json:"webhook_url,omitempty"`
+	Email      string     `json:"email,omitempty"`
+	ScheduleID string     `json:"schedule_id"`
+	LastSentAt *time.Time `json:"last_sent_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// DigestSubscriptionRequest creates or replaces a user's digest subscription. At least
+// one of WebhookURL and Email is required.
+type DigestSubscriptionRequest struct {
+	CallbackURL string `json:"callback_url" binding:"required"` // receives the scheduled send_memory_digest task
+	TenantID    string `json:"tenant_id,omitempty"`
+	Cron        string `json:"cron,omitempty"`     // defaults to Mondays at 9 AM
+	Timezone    string `json:"timezone,omitempty"` // IANA name, defaults to UTC
+	WebhookURL  string `json:"webhook_url,omitempty"`
+	Email       string `json:"email,omitempty"`
+}
+
+// MemoryDigest summarises the memories a user stored over a period
+type MemoryDigest struct {
+	UserID      string    `json:"user_id"`
+	TenantID    string    `json:"tenant_id"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	MemoryCount int       `json:"memory_count"`
+	Summary     string    `json:"summary"`
+	Delivered   []string  `json:"delivered"` // "webhook" and/or "email"; empty when there was nothing new
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// ErasureStoreResult records what an erasure removed from one store
+type ErasureStoreResult struct {
+	Store     string `json:"store"`
+	Deleted   int    `json:"deleted"`
+	Remaining int    `json:"remaining"`
+	Retained  int    `json:"retained,omitempty"` // kept because of a minimum retention policy
+	Verified  bool   `json:"verified"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ErasureReport is the signed record of a right-to-be-forgotten request
+type ErasureReport struct {
+	JobID              string               `json:"job_id"`
+	UserID             string               `json:"user_id"`
+	TenantID           string               `json:"tenant_id"`
+	Stores             []ErasureStoreResult `json:"stores"`
+	VerificationPasses int                  `json:"verification_passes"`
+	Complete           bool                 `json:"complete"`
+	StartedAt          time.Time            `json:"started_at"`
+	CompletedAt        time.Time            `json:"completed_at"`
+	SignatureAlgorithm string               `json:"signature_algorithm"`
+	Signature          string               `json:"signature,omitempty"`
+}
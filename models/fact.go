@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Fact is an exactly known detail about a user, such as a birthday or an address, stored
+// by key instead of as a free-text memory so queries naming it get the same answer
+// every time
+type Fact struct {
+	UserID string `json:"user_id"`
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	// Aliases are other phrases a query may name the fact by, such as "date of birth"
+	Aliases   []string  `json:"aliases,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SetFactRequest sets the value of a user's fact
+type SetFactRequest struct {
+	Value   string   `json:"value" binding:"required"`
+	Aliases []string `json:"aliases,omitempty"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Alias kinds: external identifiers that resolve to a canonical user
+const (
+	AliasEmail    = "email"    // an email address, matched case-insensitively
+	AliasDevice   = "device"   // a device or install ID
+	AliasOAuth    = "oauth"    // an OAuth subject, conventionally "issuer|subject"
+	AliasExternal = "external" // any other ID issued by the client's systems
+	AliasMerged   = "user_id"  // a user ID merged into another user
+)
+
+// UserAlias maps one external identifier to a canonical user
+type UserAlias struct {
+	Kind      string    `json:"kind"`
+	Value     string    `json:"value"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AliasRequest links or unlinks an external identifier
+type AliasRequest struct {
+	Kind  string `json:"kind" form:"kind" binding:"required"`
+	Value string `json:"value" form:"value" binding:"required"`
+}
+
+// MergeUsersRequest folds one user into another
+type MergeUsersRequest struct {
+	TenantID     string `json:"tenant_id,omitempty"`
+	SourceUserID string `json:"source_user_id" binding:"required"` // the user merged away
+	TargetUserID string `json:"target_user_id" binding:"required"` // the canonical user that remains
+}
+
+// MergeReport counts what a user merge moved to the target user
+type MergeReport struct {
+	SourceUserID     string    `json:"source_user_id"`
+	TargetUserID     string    `json:"target_user_id"`
+	Memories         int       `json:"memories"`
+	ColdMemories     int       `json:"cold_memories"`
+	KeywordMemories  int       `json:"keyword_memories"`
+	Sessions         int       `json:"sessions"`
+	ArchivedSessions int       `json:"archived_sessions"`
+	Aliases          int       `json:"aliases"` // including the alias added for the source user ID
+	MergedAt         time.Time `json:"merged_at"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Impersonation audit actions
+const (
+	ImpersonationStarted = "started" // an admin opened the impersonation
+	ImpersonationRequest = "request" // a request was made on the user's behalf
+	ImpersonationDenied  = "denied"  // a request outside the impersonated user was refused
+	ImpersonationRevoked = "revoked" // the impersonation was ended before it expired
+)
+
+// StartImpersonationRequest opens a time-limited support session acting as one user
+type StartImpersonationRequest struct {
+	UserID     string `json:"user_id" binding:"required"`
+	TenantID   string `json:"tenant_id"`
+	Operator   string `json:"operator" binding:"required"` // the support engineer, recorded in the audit trail
+	Reason     string `json:"reason" binding:"required"`   // e.g. the support ticket
+	TTLSeconds int64  `json:"ttl_seconds"`                 // 0 uses IMPERSONATION_DEFAULT_TTL
+}
+
+// Impersonation lets requests made with the admin token act as UserID until ExpiresAt
+type Impersonation struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	TenantID  string    `json:"tenant_id,omitempty"`
+	Operator  string    `json:"operator"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ImpersonationAuditEntry records one event of an impersonation
+type ImpersonationAuditEntry struct {
+	ImpersonationID string    `json:"impersonation_id"`
+	Action          string    `json:"action"`
+	Operator        string    `json:"operator"`
+	UserID          string    `json:"user_id"`
+	TenantID        string    `json:"tenant_id,omitempty"`
+	Method          string    `json:"method,omitempty"`
+	Path            string    `json:"path,omitempty"`
+	Status          int       `json:"status,omitempty"`
+	Details         string    `json:"details,omitempty"`
+	At              time.Time `json:"at"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Job statuses
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+	// JobDead is a queued job that failed every attempt and was moved to the dead-letter list
+	JobDead = "dead"
+)
+
+// Job tracks a long-running background operation
+type Job struct {
+	ID       string         `json:"id"`
+	Type     string         `json:"type"`
+	Status   string         `json:"status"`
+	TenantID string         `json:"tenant_id,omitempty"`
+	UserID   string         `json:"user_id,omitempty"`
+	Progress map[string]int `json:"progress"`
+	Error    string         `json:"error,omitempty"`
+	// Result is the outcome of jobs that produce one, such as the storage of an async save
+	Result interface{} `json:"result,omitempty"`
+	// Set for jobs run by a QStash delivery
+	MessageID  string `json:"message_id,omitempty"`
+	ScheduleID string `json:"schedule_id,omitempty"`
+	// Set for jobs run from the job queue; NextAttemptAt is when a failed job is retried
+	Queued        bool       `json:"queued,omitempty"`
+	Attempts      int        `json:"attempts,omitempty"`
+	MaxAttempts   int        `json:"max_attempts,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// EnqueueJobRequest asks for a maintenance job to run on the job queue
+type EnqueueJobRequest struct {
+	Type     string `json:"type" binding:"required"`
+	TenantID string `json:"tenant_id,omitempty"`
+	UserID   string `json:"user_id,omitempty"` // required by cleanup_user_memories
+}
+
+// JobFilter selects jobs to list; empty fields match every job
+type JobFilter struct {
+	TenantID string `form:"tenant_id"`
+	Status   string `form:"status"`
+	Type     string `form:"type"`
+	UserID   string `form:"user_id"`
+	Limit    int    `form:"limit"`
+}
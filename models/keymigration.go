@@ -0,0 +1,14 @@
+package models
+
+// KeyMigrationReport describes moving one Redis instance's keys to a new key prefix
+type KeyMigrationReport struct {
+	Region    string   `json:"region,omitempty"` // empty for the default instance
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	DryRun    bool     `json:"dry_run"`
+	Scanned   int      `json:"scanned"`
+	Renamed   int      `json:"renamed"`             // keys renamed, or that would be on a dry run
+	Skipped   int      `json:"skipped"`             // keys already under the target prefix or excluded
+	Conflicts []string `json:"conflicts,omitempty"` // keys left in place because the target name exists
+	Error     string   `json:"error,omitempty"`
+}
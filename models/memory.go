@@ -2,14 +2,66 @@ package models
 
 import "time"
 
+// DefaultTenant is used when a request does not identify its tenant
+const DefaultTenant = "default"
+
+// Storage modes reported when saving a memory
+const (
+	StorageVector      = "vector"
+	StorageKeywordOnly = "keyword_only"
+	StorageReinforced  = "reinforced" // not stored; an existing memory was reinforced instead
+	StorageQueued      = "queued"     // held in the write-ahead queue until the vector store is back
+	StoragePending     = "pending"    // in its session; an async save job is embedding and storing it
+)
+
+// Memory scopes. Task memories are scratchpad memories of one task, kept out of
+// long-term queries and deleted when the task completes.
+const (
+	ScopeLongTerm = "long_term"
+	ScopeTask     = "task"
+)
+
+// MemoryTypeInstruction marks a standing instruction such as "always answer in French",
+// included whenever context is assembled for the user regardless of query similarity
+const MemoryTypeInstruction = "instruction"
+
 // SessionData represents short-term memory stored in Redis
 type SessionData struct {
+	TenantID     string                 `json:"tenant_id,omitempty"`
 	UserID       string                 `json:"user_id"`
 	SessionID    string                 `json:"session_id"`
 	Messages     []Message              `json:"messages"`
 	Context      map[string]interface{} `json:"context"`
 	LastActivity time.Time              `json:"last_activity"`
 	CreatedAt    time.Time              `json:"created_at"`
+	// TTLSeconds is how long the session is kept after its last activity; 0 means SESSION_TTL
+	TTLSeconds int64 `json:"ttl_seconds,omitempty"`
+	// ClosedAt is set once the session is closed and takes no further writes
+	ClosedAt *time.Time `json:"closed_at,omitempty"`
+	// ResumedFrom is the closed session this one continues
+	ResumedFrom string `json:"resumed_from,omitempty"`
+	// TrimmedMessages counts the oldest messages dropped to keep the session within
+	// SESSION_MAX_MESSAGES
+	TrimmedMessages int `json:"trimmed_messages,omitempty"`
+	// Page describes the window of Messages returned when a read asked for one
+	Page *MessagePage `json:"page,omitempty"`
+}
+
+// MessageWindow selects part of a session's messages: those after Since, skipping Offset
+// of them and returning at most Limit (all when 0)
+type MessageWindow struct {
+	Offset int
+	Limit  int
+	Since  time.Time
+}
+
+// MessagePage reports the window of a session's messages a read returned
+type MessagePage struct {
+	Offset int        `json:"offset"`
+	Limit  int        `json:"limit,omitempty"`
+	Since  *time.Time `json:"since,omitempty"`
+	Total  int        `json:"total"`                 // stored messages after since
+	Next   int        `json:"next_offset,omitempty"` // offset of the next page, if any
 }
 
 // Message represents a single conversation message
@@ -18,6 +70,7 @@ type Message struct {
 	Role      string    `json:"role"` // "user" or "assistant"
 	Content   string    `json:"content"`
 	Timestamp time.Time `json:"timestamp"`
+	Tags      []string  `json:"tags,omitempty"`
 }
 
 // MemoryEntry represents long-term memory stored in Vector DB
@@ -29,6 +82,9 @@ type MemoryEntry struct {
 	Metadata  map[string]interface{} `json:"metadata"`
 	Timestamp time.Time              `json:"timestamp"`
 	TTL       int64                  `json:"ttl"` // Time to live in seconds
+	// Tags categorize the memory, e.g. "preferences" or "facts"; stored as the "tags"
+	// metadata field
+	Tags []string `json:"tags,omitempty"`
 }
 
 // VectorMetadata represents metadata stored with vector embeddings
@@ -42,39 +98,251 @@ type VectorMetadata struct {
 
 // SaveMemoryRequest represents the request to save memory
 type SaveMemoryRequest struct {
+	TenantID  string `json:"tenant_id,omitempty"`
 	UserID    string `json:"user_id" binding:"required"`
 	SessionID string `json:"session_id" binding:"required"`
 	Content   string `json:"content" binding:"required"`
 	Role      string `json:"role" binding:"required"`
+	// AssistantID partitions the memory to one assistant persona; empty shares it with all
+	AssistantID string `json:"assistant_id,omitempty"`
+	// Scope is "long_term" (default) or "task", which requires TaskID
+	Scope  string `json:"scope,omitempty"`
+	TaskID string `json:"task_id,omitempty"`
+	// Type "instruction" saves a standing instruction; Priority orders a user's
+	// instructions, lowest first
+	Type     string `json:"type,omitempty"`
+	Priority int    `json:"priority,omitempty"`
+	// Origin is "message" (default), "extracted" (requires ParentIDs) or "imported"
+	Origin    string   `json:"origin,omitempty"`
+	ParentIDs []string `json:"parent_ids,omitempty"`
+	Source    string   `json:"source,omitempty"` // where an imported memory came from
+	// SourceTrust is "user", "assistant" or "third_party"; defaults from Origin and Role
+	SourceTrust string `json:"source_trust,omitempty"`
+	// Confidence (0-1) of a derived memory; defaults to CONFIDENCE_DEFAULT
+	Confidence *float64 `json:"confidence,omitempty"`
+	// SkipDuplicate overrides REINFORCEMENT_SKIP_DUPLICATES for this request
+	SkipDuplicate *bool `json:"skip_duplicate,omitempty"`
+	// Embedding is a vector of the content precomputed with the configured embedding model;
+	// it must match the index dimension and is stored instead of embedding the content
+	Embedding []float64 `json:"embedding,omitempty"`
+	// Tags categorize the memory; they are lowercased and kept on the session message too
+	Tags []string `json:"tags,omitempty"`
+	// ReplyTo is the ID of the user message an assistant message answers; empty pairs it
+	// with the session's previous message when the user sent it (TURN_PAIRING)
+	ReplyTo string `json:"reply_to,omitempty"`
+	// Async answers once the message is in its session and leaves embedding and storing
+	// the memory to a job; nil uses SAVE_ASYNC. Set from the ?async= parameter.
+	Async *bool `json:"-"`
 }
 
+// SaveMemoryResult describes where a saved memory ended up
+type SaveMemoryResult struct {
+	MemoryID     string `json:"memory_id"`
+	Storage      string `json:"storage"`                 // "vector", "keyword_only", "reinforced", "queued" or "pending"
+	ReinforcedID string `json:"reinforced_id,omitempty"` // existing memory the content reinforced
+	Quarantined  bool   `json:"quarantined,omitempty"`   // hidden from queries by the write guard
+	// DegradedReason is the vector store failure that sent a memory to the write-ahead queue
+	DegradedReason string `json:"degraded_reason,omitempty"`
+	// JobID is the job storing a pending memory, readable at GET /jobs/:id
+	JobID string `json:"job_id,omitempty"`
+}
+
+// Query modes. Semantic queries rank by embedding similarity; hybrid queries also rank the
+// memories by keyword and fuse the two rankings.
+const (
+	QueryModeSemantic = "semantic"
+	QueryModeHybrid   = "hybrid"
+)
+
+// Dedupe options of a query. Results are collapsed by parent document (the import source,
+// or the parent memories of extracted facts), by session, by near-identical content or by
+// conversation turn, keeping one of a question and its answer.
+const (
+	DedupeDocument = "document"
+	DedupeSession  = "session"
+	DedupeContent  = "content"
+	DedupeTurn     = "turn"
+)
+
 // QueryMemoryRequest represents the request to query memory
 type QueryMemoryRequest struct {
+	TenantID string  `json:"tenant_id,omitempty"`
 	UserID   string  `json:"user_id" binding:"required"`
 	Query    string  `json:"query" binding:"required"`
 	Limit    int     `json:"limit,omitempty"`
 	MinScore float64 `json:"min_score,omitempty"`
+	// AssistantID restricts results to memories that assistant may read; empty reads all
+	AssistantID string `json:"assistant_id,omitempty"`
+	// TaskID adds that task's memories to the results; task memories are excluded otherwise
+	TaskID string `json:"task_id,omitempty"`
+	// MinConfidence drops derived memories whose decayed confidence is lower
+	MinConfidence float64 `json:"min_confidence,omitempty"`
+	// SourceTrust restricts results to memories with one of these source trust levels
+	SourceTrust []string `json:"source_trust,omitempty"`
+	// TrustWeights overrides the configured score multiplier of a source trust level
+	TrustWeights map[string]float64 `json:"trust_weights,omitempty"`
+	// Granularity selects raw memories (default), one summary level (day, week, month,
+	// session) or all
+	Granularity string `json:"granularity,omitempty"`
+	// Mode is semantic (default) or hybrid
+	Mode string `json:"mode,omitempty"`
+	// Embedding is a vector of the query precomputed with the configured embedding model;
+	// it must match the index dimension and is searched with instead of embedding the query
+	Embedding []float64 `json:"embedding,omitempty"`
+	// DeepRecall also searches the user's memories moved to the cold tier, at higher latency
+	DeepRecall bool `json:"deep_recall,omitempty"`
+	// Tags restricts results to memories carrying any of these tags
+	Tags []string `json:"tags,omitempty"`
+	// Dedupe collapses results sharing a document, a session or near-identical content,
+	// keeping the best-scoring ones, so one long source cannot fill every slot
+	Dedupe []string `json:"dedupe,omitempty"`
+	// MaxPerGroup is how many results one document or session may keep; defaults to 1
+	MaxPerGroup int `json:"max_per_group,omitempty"`
+	// SkipFacts runs the semantic search even when the query names one of the user's facts
+	SkipFacts bool `json:"skip_facts,omitempty"`
+	// Trace returns a stage-by-stage trace of the query; set from the ?trace=true parameter
+	Trace bool `json:"-"`
+	ContentFilter
+}
+
+// ContentFilter restricts results to memories whose content matches, applied after retrieval
+type ContentFilter struct {
+	ContentContains string `json:"content_contains,omitempty" form:"content_contains"`
+	ContentRegex    string `json:"content_regex,omitempty" form:"content_regex"`
+}
+
+// Active reports whether any content filter is set
+func (f ContentFilter) Active() bool {
+	return f.ContentContains != "" || f.ContentRegex != ""
+}
+
+// MemoryPatchRequest describes a bulk metadata patch applied to a user's memories
+type MemoryPatchRequest struct {
+	Filter     MemoryPatchFilter      `json:"filter"`
+	AddTags    []string               `json:"add_tags,omitempty"`
+	RemoveTags []string               `json:"remove_tags,omitempty"`
+	Set        map[string]interface{} `json:"set,omitempty"`
+	Unset      []string               `json:"unset,omitempty"`
+}
+
+// MemoryPatchFilter selects the memories a patch applies to; empty fields match everything
+type MemoryPatchFilter struct {
+	SessionID string   `json:"session_id,omitempty"`
+	Role      string   `json:"role,omitempty"`
+	Tags      []string `json:"tags,omitempty"` // matches memories carrying any of these tags
+	ContentFilter
 }
 
 // QueryMemoryResponse represents the response from memory query
 type QueryMemoryResponse struct {
 	Results []MemoryResult `json:"results"`
 	Total   int            `json:"total"`
+	// Facts are the user's facts the query named; when set, they answer the query and no
+	// semantic search was run
+	Facts []Fact      `json:"facts,omitempty"`
+	Trace *QueryTrace `json:"trace,omitempty"`
+	// Degraded is set when the vector store failed and only session messages were searched
+	Degraded       bool   `json:"degraded,omitempty"`
+	DegradedReason string `json:"degraded_reason,omitempty"`
 }
 
 // MemoryResult represents a single memory search result
 type MemoryResult struct {
-	ID        string                 `json:"id"`
-	Content   string                 `json:"content"`
-	Score     float64                `json:"score"`
-	Metadata  map[string]interface{} `json:"metadata"`
-	Timestamp time.Time              `json:"timestamp"`
+	ID          string                 `json:"id"`
+	Content     string                 `json:"content"`
+	Score       float64                `json:"score"`
+	Metadata    map[string]interface{} `json:"metadata"`
+	Timestamp   time.Time              `json:"timestamp"`
+	Provenance  *EmbeddingProvenance   `json:"provenance,omitempty"`
+	Confidence  *float64               `json:"confidence,omitempty"` // decayed confidence of derived memories
+	SourceTrust string                 `json:"source_trust,omitempty"`
+	// Turn is the question and answer a paired memory belongs to
+	Turn *ConversationTurn `json:"turn,omitempty"`
+}
+
+// ConversationTurn is a user question paired with the assistant answer to it. Either
+// memory of the pair returns the whole turn, so an answer is never read without its
+// question.
+type ConversationTurn struct {
+	QuestionID string `json:"question_id"`
+	Question   string `json:"question"`
+	AnswerID   string `json:"answer_id"`
+	Answer     string `json:"answer"`
+}
+
+// EmbeddingProvenance records which embedding model produced a memory's vector
+type EmbeddingProvenance struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	Version  string `json:"version"`
+	Stale    bool   `json:"stale"` // true when it differs from the currently configured model
+}
+
+// ExpiringMemory is a memory that will reach its TTL soon
+type ExpiringMemory struct {
+	ID               string    `json:"id"`
+	UserID           string    `json:"user_id"`
+	TenantID         string    `json:"tenant_id,omitempty"`
+	Content          string    `json:"content"`
+	CreatedAt        time.Time `json:"created_at"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	ExpiresInSeconds int64     `json:"expires_in_seconds"`
 }
 
 // CleanupTask represents a cleanup task for QStash
 type CleanupTask struct {
+	TaskID    string    `json:"task_id,omitempty"` // deduplicates retried deliveries
 	TaskType  string    `json:"task_type"`
+	TenantID  string    `json:"tenant_id,omitempty"`
 	UserID    string    `json:"user_id,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
 	TTL       int64     `json:"ttl"`
 }
+
+// ExportedMemory is one line of a streamed memory export
+type ExportedMemory struct {
+	ID        string                 `json:"id"`
+	UserID    string                 `json:"user_id"`
+	Content   string                 `json:"content"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+	TTL       int64                  `json:"ttl"`
+	Source    string                 `json:"source"` // "vector" or "keyword_only"
+}
+
+// Instruction is an instruction memory included in assembled context
+type Instruction struct {
+	ID       string `json:"id"`
+	Content  string `json:"content"`
+	Priority int    `json:"priority"`
+}
+
+// DiffMemory is a memory that was added or expired within a memory diff window
+type DiffMemory struct {
+	ID        string     `json:"id"`
+	Content   string     `json:"content"`
+	Origin    string     `json:"origin"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// SupersededMemory is a memory that a summary or extracted memory derived from it
+// replaced within a memory diff window
+type SupersededMemory struct {
+	ID           string    `json:"id"`
+	Content      string    `json:"content,omitempty"`
+	SupersededBy string    `json:"superseded_by"`
+	SupersededAt time.Time `json:"superseded_at"`
+	Missing      bool      `json:"missing,omitempty"` // the memory itself has since been deleted
+}
+
+// MemoryDiff reports what changed in a user's memories between two points in time
+type MemoryDiff struct {
+	UserID     string             `json:"user_id"`
+	From       time.Time          `json:"from"`
+	To         time.Time          `json:"to"`
+	Added      []DiffMemory       `json:"added"`
+	Superseded []SupersededMemory `json:"superseded"`
+	Expired    []DiffMemory       `json:"expired"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// TagCount is how many of a user's memories carry a tag
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// UserProfile summarises a user's stored memories
+type UserProfile struct {
+	UserID           string         `json:"user_id"`
+	TenantID         string         `json:"tenant_id"`
+	MemoryCount      int            `json:"memory_count"`
+	KeywordOnlyCount int            `json:"keyword_only_count"`
+	SessionCount     int            `json:"session_count"`
+	RoleCounts       map[string]int `json:"role_counts"`
+	TopTags          []TagCount     `json:"top_tags"`
+	FirstMemoryAt    *time.Time     `json:"first_memory_at,omitempty"`
+	LastMemoryAt     *time.Time     `json:"last_memory_at,omitempty"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// Formality levels of UserPreferences
+const (
+	FormalityFormal  = "formal"
+	FormalityNeutral = "neutral"
+	FormalityCasual  = "casual"
+)
+
+// UserPreferences holds settings an assistant should respect in every reply, kept out of
+// free-text memories. Empty fields are unset.
+type UserPreferences struct {
+	UserID    string    `json:"user_id"`
+	Timezone  string    `json:"timezone,omitempty"`  // IANA name such as Europe/Paris
+	Locale    string    `json:"locale,omitempty"`    // BCP 47 tag such as fr-FR
+	Formality string    `json:"formality,omitempty"` // formal, neutral or casual
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Memory origins recorded in the "origin" metadata field. Memories saved before origins
+// were recorded are treated as messages.
+const (
+	OriginMessage   = "message"   // a conversation turn saved as is
+	OriginSummary   = "summary"   // a rollup summary of its parents
+	OriginExtracted = "extracted" // a fact extracted from its parents
+	OriginImported  = "imported"  // brought in from an external source
+)
+
+// Source trust levels recorded in the "source_trust" metadata field. Memories saved without
+// one are classified by their origin and role.
+const (
+	SourceTrustUser       = "user"        // stated by the user
+	SourceTrustAssistant  = "assistant"   // written by the assistant or derived from other memories
+	SourceTrustThirdParty = "third_party" // ingested from an external source such as the web
+)
+
+// ProvenanceNode is one memory in a provenance chain
+type ProvenanceNode struct {
+	ID        string     `json:"id"`
+	Depth     int        `json:"depth"` // 0 for the memory asked about, 1 for its parents...
+	Origin    string     `json:"origin,omitempty"`
+	Content   string     `json:"content,omitempty"`
+	SessionID string     `json:"session_id,omitempty"`
+	Source    string     `json:"source,omitempty"` // where an imported memory came from
+	ParentIDs []string   `json:"parent_ids,omitempty"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	Missing   bool       `json:"missing,omitempty"` // parent was deleted or is not a vector memory
+}
+
+// MemoryProvenance is the chain of memories a memory was derived from, breadth first
+type MemoryProvenance struct {
+	MemoryID  string           `json:"memory_id"`
+	Nodes     []ProvenanceNode `json:"nodes"`
+	Truncated bool             `json:"truncated,omitempty"`
+}
+
+// MemoryVerificationRequest confirms or refutes a memory
+type MemoryVerificationRequest struct {
+	UserID    string `json:"user_id" binding:"required"`
+	Confirmed *bool  `json:"confirmed,omitempty"` // defaults to true
+}
+
+// MemoryVerification reports a memory's confidence before and after verification
+type MemoryVerification struct {
+	MemoryID           string    `json:"memory_id"`
+	Confirmed          bool      `json:"confirmed"`
+	PreviousConfidence float64   `json:"previous_confidence"`
+	Confidence         float64   `json:"confidence"`
+	VerifiedAt         time.Time `json:"verified_at"`
+}
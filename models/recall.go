@@ -0,0 +1,34 @@
+package models
+
+// Outcomes of a memory in a recall check
+const (
+	RecallReturned      = "returned"        // the index returned it
+	RecallBelowMinScore = "below_min_score" // the index returned it, but the query's min_score drops it
+	RecallMissed        = "missed_by_index" // the approximate search did not return it
+)
+
+// RecallCheckResult is one of the memories an exact search ranks highest for a query
+type RecallCheckResult struct {
+	ID         string  `json:"id"`
+	Content    string  `json:"content"`
+	ExactScore float64 `json:"exact_score"`
+	ExactRank  int     `json:"exact_rank"`
+	IndexScore float64 `json:"index_score,omitempty"`
+	IndexRank  int     `json:"index_rank,omitempty"` // 1-based, 0 when the index did not return it
+	Outcome    string  `json:"outcome"`
+}
+
+// RecallReport compares the index's approximate results for a query with an exact cosine
+// search over every memory of the user
+type RecallReport struct {
+	UserID   string              `json:"user_id"`
+	Query    string              `json:"query"`
+	Limit    int                 `json:"limit"`
+	MinScore float64             `json:"min_score"`
+	Scanned  int                 `json:"scanned"`  // vectors in the index
+	Compared int                 `json:"compared"` // of them, the user's memories visible to the query
+	Recall   float64             `json:"recall"`   // share of the exact top results the index returned
+	Exact    []RecallCheckResult `json:"exact"`
+	// IndexOnly lists memories the index returned that are not in the exact top results
+	IndexOnly []string `json:"index_only,omitempty"`
+}
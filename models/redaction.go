@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// RedactionRequest asks to forget the memories matching a natural-language description.
+// Without a redaction ID it previews the matching memories; with one, the approved
+// memories of that preview are deleted.
+type RedactionRequest struct {
+	TenantID    string  `json:"tenant_id,omitempty"`
+	Description string  `json:"description,omitempty"` // e.g. "anything about my salary"
+	Limit       int     `json:"limit,omitempty"`       // candidates previewed, defaults to 20
+	MinScore    float64 `json:"min_score,omitempty"`   // defaults to 0.6
+	// RedactionID and Approve confirm a preview, deleting the approved candidates
+	RedactionID string   `json:"redaction_id,omitempty"`
+	Approve     []string `json:"approve,omitempty"`
+}
+
+// RedactionPreview lists the memories a redaction would forget, awaiting confirmation
+type RedactionPreview struct {
+	RedactionID string         `json:"redaction_id"`
+	Description string         `json:"description"`
+	Candidates  []MemoryResult `json:"candidates"`
+	ExpiresAt   time.Time      `json:"expires_at"`
+}
+
+// PendingRedaction is a previewed redaction kept until it is confirmed or expires
+type PendingRedaction struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"user_id"`
+	TenantID     string    `json:"tenant_id"`
+	Description  string    `json:"description"`
+	CandidateIDs []string  `json:"candidate_ids"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// RedactionResult reports what a confirmed redaction deleted
+type RedactionResult struct {
+	RedactionID  string   `json:"redaction_id"`
+	Forgotten    int      `json:"forgotten"`
+	NotFound     []string `json:"not_found,omitempty"`
+	Retained     []string `json:"retained,omitempty"`      // within the tenant's minimum retention period
+	NotPreviewed []string `json:"not_previewed,omitempty"` // approved IDs the preview did not offer
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// RetentionPolicy constrains how a tenant's memories may be deleted
+type RetentionPolicy struct {
+	TenantID            string    `json:"tenant_id"`
+	MinRetentionSeconds int64     `json:"min_retention_seconds"` // memories younger than this cannot be deleted
+	MaxRetentionSeconds int64     `json:"max_retention_seconds"` // memories older than this expire regardless of TTL, 0 disables
+	LegalHold           bool      `json:"legal_hold"`            // blocks every deletion, including expiry and user cleanup
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// SessionPolicy controls the lifecycle of a tenant's sessions. Both periods count from a
+// session's last activity.
+type SessionPolicy struct {
+	TenantID            string    `json:"tenant_id"`
+	ArchiveAfterSeconds int64     `json:"archive_after_seconds"` // idle sessions are moved to the archive, 0 never archives
+	DeleteAfterSeconds  int64     `json:"delete_after_seconds"`  // idle and archived sessions are deleted, 0 keeps archives for 30 days
+	UpdatedAt           time.Time `json:"updated_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+// Memory tiers reported by combined retrieval
+const (
+	TierShortTerm = "short_term" // a message in the active session's Redis window
+	TierLongTerm  = "long_term"  // a memory in the vector store
+	TierBoth      = "both"       // found in the session window and the vector store
+)
+
+// RetrieveRequest searches the latest messages of an active session together with the
+// user's long-term memories. Query options apply to the long-term search; the limit,
+// minimum score and content filter apply to both.
+type RetrieveRequest struct {
+	QueryMemoryRequest
+	SessionID string `json:"session_id" binding:"required"`
+	// Window is how many of the session's latest messages are searched; defaults to 20
+	Window int `json:"window,omitempty"`
+}
+
+// RetrieveResult is a retrieval result labelled with the tier it was found in
+type RetrieveResult struct {
+	MemoryResult
+	Tier string `json:"tier"`
+}
+
+// RetrieveResponse holds merged results from both tiers, best first
+type RetrieveResponse struct {
+	Results         []RetrieveResult `json:"results"`
+	Total           int              `json:"total"`
+	SessionMessages int              `json:"session_messages"` // messages searched in the session window
+	// Instructions are the user's instruction memories, always included, by priority
+	Instructions []Instruction `json:"instructions"`
+	// InstructionsOmitted counts instructions left out by INSTRUCTION_MAX_TOKENS
+	InstructionsOmitted int `json:"instructions_omitted,omitempty"`
+	// Preferences are the user's timezone, locale and formality, when set
+	Preferences *UserPreferences `json:"preferences,omitempty"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// StaleMemory is a memory queued for review: old, never retrieved and of low importance
+type StaleMemory struct {
+	ID         string    `json:"id"`
+	Content    string    `json:"content"`
+	SessionID  string    `json:"session_id,omitempty"`
+	Importance float64   `json:"importance"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// MemoryReviewRequest approves (keeps) or forgets (deletes) reviewed memories in one batch
+type MemoryReviewRequest struct {
+	Approve []string `json:"approve,omitempty"`
+	Forget  []string `json:"forget,omitempty"`
+}
+
+// MemoryReviewResult reports what a review batch did
+type MemoryReviewResult struct {
+	Approved  int      `json:"approved"`
+	Forgotten int      `json:"forgotten"`
+	NotFound  []string `json:"not_found,omitempty"`
+	Retained  []string `json:"retained,omitempty"` // within the tenant's minimum retention period
+}
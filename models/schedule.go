@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// CleanupSchedule records a recurring QStash cleanup schedule and who created it
+type CleanupSchedule struct {
+	ScheduleID  string    `json:"schedule_id"`
+	TenantID    string    `json:"tenant_id"`
+	CallbackURL string    `json:"callback_url"`
+	Cron        string    `json:"cron"`
+	Timezone    string    `json:"timezone"`
+	Owner       string    `json:"owner,omitempty"`
+	Purpose     string    `json:"purpose,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ScheduleCleanupRequest is the request to create a recurring cleanup schedule
+type ScheduleCleanupRequest struct {
+	CallbackURL string `json:"callback_url" binding:"required"`
+	TenantID    string `json:"tenant_id,omitempty"`
+	Cron        string `json:"cron,omitempty"`     // defaults to daily at 2 AM
+	Timezone    string `json:"timezone,omitempty"` // IANA name, defaults to UTC
+	Owner       string `json:"owner,omitempty"`
+	Purpose     string `json:"purpose,omitempty"`
+	DeliveryOptions
+}
+
+// DeliveryOptions tune how QStash delivers a task; unset fields use the configured defaults
+type DeliveryOptions struct {
+	Retries         *int   `json:"retries,omitempty"`
+	FailureCallback string `json:"failure_callback,omitempty"`
+	DeduplicationID string `json:"deduplication_id,omitempty"` // QStash drops repeated publishes with the same ID
+}
+
+// PublishFailure is a task that could not be handed to QStash
+type PublishFailure struct {
+	Operation   string    `json:"operation"` // "publish" or "schedule"
+	TaskType    string    `json:"task_type"`
+	Destination string    `json:"destination"`
+	TenantID    string    `json:"tenant_id,omitempty"`
+	Subject     string    `json:"subject,omitempty"` // user or session the task targets
+	Error       string    `json:"error"`
+	FailedAt    time.Time `json:"failed_at"`
+}
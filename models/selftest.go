@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// SelfTestCheck is the outcome of exercising one configured dependency
+type SelfTestCheck struct {
+	Name      string `json:"name"`             // redis, vector, embedding or qstash
+	Target    string `json:"target,omitempty"` // region or provider the check ran against
+	OK        bool   `json:"ok"`
+	Skipped   bool   `json:"skipped,omitempty"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// SelfTestReport collects every dependency check of a self-test run
+type SelfTestReport struct {
+	OK     bool            `json:"ok"`
+	Checks []SelfTestCheck `json:"checks"`
+	RanAt  time.Time       `json:"ran_at"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// SessionSummary is a compact view of a session sized for prompt injection: a title,
+// a rolling summary of the earlier messages, the latest messages verbatim and the
+// session context
+type SessionSummary struct {
+	SessionID          string                 `json:"session_id"`
+	UserID             string                 `json:"user_id"`
+	Title              string                 `json:"title"`
+	Summary            string                 `json:"summary"`
+	SummarizedMessages int                    `json:"summarized_messages"` // earliest messages covered by the summary
+	RecentMessages     []Message              `json:"recent_messages"`
+	Context            map[string]interface{} `json:"context,omitempty"`
+	MessageCount       int                    `json:"message_count"`
+	TokenCount         int                    `json:"token_count"` // tokens of the title, summary, messages and context
+	UpdatedAt          time.Time              `json:"updated_at"`
+}
+
+// RollingSummary is the server-side summary of a session's earlier messages. It is
+// extended with the messages that leave the recent window instead of being rebuilt.
+type RollingSummary struct {
+	Title     string    `json:"title"`
+	Summary   string    `json:"summary"`
+	Messages  int       `json:"messages"` // earliest messages covered by the summary
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SessionExport is a session transcript prepared for sharing or archiving
+type SessionExport struct {
+	SessionID    string    `json:"session_id"`
+	UserID       string    `json:"user_id"`
+	Title        string    `json:"title"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastActivity time.Time `json:"last_activity"`
+	ExportedAt   time.Time `json:"exported_at"`
+	MessageCount int       `json:"message_count"`
+	Messages     []Message `json:"messages"`
+}
+
+// ClosedSession is the final summary of a closed session
+type ClosedSession struct {
+	SessionID    string    `json:"session_id"`
+	UserID       string    `json:"user_id"`
+	Title        string    `json:"title"`
+	Summary      string    `json:"summary"`
+	MessageCount int       `json:"message_count"`
+	ClosedAt     time.Time `json:"closed_at"`
+}
+
+// ResumeSessionRequest starts a new session continuing a closed one
+type ResumeSessionRequest struct {
+	TenantID  string `json:"tenant_id,omitempty"`
+	SessionID string `json:"session_id" binding:"required"` // the closed session
+	// NewSessionID names the new session; one is generated when empty
+	NewSessionID string `json:"new_session_id,omitempty"`
+	// ContextKeys selects the context fields carried over; all of them when empty
+	ContextKeys []string `json:"context_keys,omitempty"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// StandingQuery alerts on newly saved memories that match a query
+type StandingQuery struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	TenantID   string    `json:"tenant_id"`
+	Query      string    `json:"query"`
+	Threshold  float64   `json:"threshold"` // minimum similarity score of a match, 0-1
+	WebhookURL string    `json:"webhook_url,omitempty"`
+	Embedding  []float64 `json:"embedding,omitempty"` // stored only, never returned by the API
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// StandingQueryRequest registers a standing query
+type StandingQueryRequest struct {
+	TenantID   string  `json:"tenant_id,omitempty"`
+	Query      string  `json:"query" binding:"required"`
+	Threshold  float64 `json:"threshold,omitempty"` // defaults to 0.8
+	WebhookURL string  `json:"webhook_url,omitempty"`
+}
+
+// StandingQueryAlert reports a saved memory that matched a standing query. It is posted
+// to the query's webhook and streamed to the user's open alert streams.
+type StandingQueryAlert struct {
+	Event    string    `json:"event"` // always "standing_query.matched"
+	QueryID  string    `json:"query_id"`
+	Query    string    `json:"query"`
+	UserID   string    `json:"user_id"`
+	TenantID string    `json:"tenant_id"`
+	MemoryID string    `json:"memory_id"`
+	Content  string    `json:"content"`
+	Score    float64   `json:"score"`
+	At       time.Time `json:"at"`
+}
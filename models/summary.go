@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Memory granularities. Raw memories are stored as saved; day, week and month are rollup
+// summaries built from the level below them (day from raw, week and month from day), and
+// session summaries condense the older messages of long sessions.
+const (
+	GranularityRaw     = "raw"
+	GranularityDay     = "day"
+	GranularityWeek    = "week"
+	GranularityMonth   = "month"
+	GranularitySession = "session"
+	GranularityAll     = "all" // query filter only: raw memories and every summary level
+)
+
+// MemorySummary is a rollup of a user's memories over one closed period
+type MemorySummary struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"user_id"`
+	TenantID    string    `json:"tenant_id"`
+	AssistantID string    `json:"assistant_id,omitempty"`
+	Granularity string    `json:"granularity"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Content     string    `json:"content"`
+	SourceCount int       `json:"source_count"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
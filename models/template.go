@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Prompt template names, one per LLM-backed feature
+const (
+	PromptSummarization = "summarization"
+	PromptExtraction    = "extraction"
+	PromptTitling       = "titling"
+	PromptAsk           = "ask"
+	PromptChat          = "chat" // system prompt injecting memories into /chat/completions
+)
+
+// PromptTemplate is one version of a feature's prompt, written in Go text/template
+// syntax. Version 0 is the built-in default shipped with the service.
+type PromptTemplate struct {
+	Name        string    `json:"name"`
+	Version     int       `json:"version"`
+	Content     string    `json:"content"`
+	Description string    `json:"description,omitempty"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PromptTemplateRequest stores a new version of a prompt template
+type PromptTemplateRequest struct {
+	Content     string `json:"content" binding:"required"`
+	Description string `json:"description,omitempty"`
+	Activate    *bool  `json:"activate,omitempty"` // defaults to true
+}
+
+// PromptTemplateActivation switches a template to one of its versions; 0 restores the default
+type PromptTemplateActivation struct {
+	Version *int `json:"version" binding:"required"`
+}
@@ -0,0 +1,46 @@
+package models
+
+// QueryTrace records how a query was answered, stage by stage
+type QueryTrace struct {
+	Embedding TraceEmbedding `json:"embedding"`
+	Filters   TraceFilters   `json:"filters"`
+	Stages    []TraceStage   `json:"stages"`
+	TotalMs   float64        `json:"total_ms"`
+}
+
+// TraceEmbedding describes the query embedding
+type TraceEmbedding struct {
+	Source     string  `json:"source"` // "generated" or "client"
+	Dimensions int     `json:"dimensions"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// TraceFilters are the filters and limits a query ran with
+type TraceFilters struct {
+	VectorFilter  string        `json:"vector_filter"` // applied by the index, besides the user
+	MinScore      float64       `json:"min_score"`     // applied by the index
+	Candidates    int           `json:"candidates"`    // results requested from the index
+	Limit         int           `json:"limit"`
+	MinConfidence float64       `json:"min_confidence,omitempty"`
+	SourceTrust   []string      `json:"source_trust,omitempty"`
+	Dedupe        []string      `json:"dedupe,omitempty"`
+	MaxPerGroup   int           `json:"max_per_group,omitempty"`
+	ContentFilter ContentFilter `json:"content_filter"`
+}
+
+// TraceStage is the ranking after one stage of the query pipeline
+type TraceStage struct {
+	Name       string           `json:"name"`
+	DurationMs float64          `json:"duration_ms"`
+	Results    []TraceCandidate `json:"results"`
+	Dropped    []string         `json:"dropped,omitempty"` // IDs removed by this stage
+}
+
+// TraceCandidate is one result of a stage
+type TraceCandidate struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+	Rank  int     `json:"rank"`
+	// PreviousRank is the rank in the previous stage when this stage moved the result
+	PreviousRank int `json:"previous_rank,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// TenantStorageUsage is the approximate storage one tenant occupies across every data region.
+// Redis bytes count live and archived sessions; vector bytes count 4 bytes per dimension
+// plus the serialized metadata of each vector.
+type TenantStorageUsage struct {
+	TenantID    string `json:"tenant_id"`
+	Sessions    int    `json:"sessions"`
+	RedisBytes  int64  `json:"redis_bytes"`
+	Vectors     int    `json:"vectors"`
+	VectorBytes int64  `json:"vector_bytes"`
+}
+
+// StorageUsageReport is one sampling run of per-tenant storage usage
+type StorageUsageReport struct {
+	SampledAt  time.Time            `json:"sampled_at"`
+	DurationMs int64                `json:"duration_ms"`
+	Tenants    []TenantStorageUsage `json:"tenants"`
+	Errors     []string             `json:"errors,omitempty"` // regions or stores that could not be sampled
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// QueuedVectorWrite is a memory accepted while the vector store was unreachable, waiting
+// in the write-ahead queue to be replayed
+type QueuedVectorWrite struct {
+	Memory    *MemoryEntry `json:"memory"`
+	TenantID  string       `json:"tenant_id"`
+	QueuedAt  time.Time    `json:"queued_at"`
+	Attempts  int          `json:"attempts,omitempty"` // failed replays so far
+	LastError string       `json:"last_error,omitempty"`
+}
+
+// WriteQueueReplay reports one replay of a region's write-ahead queue
+type WriteQueueReplay struct {
+	Region    string `json:"region,omitempty"` // empty for the default instances
+	Replayed  int    `json:"replayed"`         // memories written to the vector store
+	GivenUp   int    `json:"given_up"`         // memories moved aside after too many failed replays
+	Remaining int    `json:"remaining"`        // memories still queued
+	Error     string `json:"error,omitempty"`  // why the replay stopped early
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// piiPatterns replace common personal identifiers in memory content.
+// Order matters: emails and URLs are scrubbed before the digit patterns.
+var piiPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`), "[EMAIL]"},
+	{regexp.MustCompile(`https?://\S+`), "[URL]"},
+	{regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}\b`), "[IP]"},
+	{regexp.MustCompile(`\b(?:\d[ -]?){13,19}\b`), "[CARD]"},
+	{regexp.MustCompile(`\+?\d[\d\s().\-]{7,}\d`), "[PHONE]"},
+}
+
+// AnonymizeUser starts a background job that unlinks a user's memories from them
+// instead of deleting them: identifiers are replaced by keyed hashes, PII is
+// scrubbed from the content and re-embedded, and raw sessions are removed.
+func (m *MemoryService) AnonymizeUser(userID string, tenantID string) (*models.Job, error) {
+	m = m.ForTenant(tenantID)
+
+	policy, err := m.retention.Get(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLegalHold(policy); err != nil {
+		return nil, err
+	}
+
+	salt, err := anonymizationSalt()
+	if err != nil {
+		return nil, err
+	}
+	pseudonym := pseudonymize(salt, userID)
+
+	return m.startJob("user_anonymization", tenantID, userID, func(m *MemoryService, job *models.Job) error {
+		defer m.publish(EventMemoryUpdated, tenantID, userID)
+
+		// Collect every memory first: anonymizing changes the user ID the scan filters on
+		var matches []clients.QueryMatch
+		err := m.forEachUserMemoryPage(userID, func(page []clients.QueryMatch) error {
+			matches = append(matches, page...)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list user memories: %w", err)
+		}
+
+		for _, match := range matches {
+			job.Progress["scanned"]++
+
+			memory := anonymizeMemory(match.ID, match.Metadata, salt, pseudonym)
+			original, _ := match.Metadata["content"].(string)
+
+			if memory.Content != original {
+				// The old vector encodes the PII, so it has to be replaced too
+				embedding, err := m.embeddingClient.GenerateEmbedding(memory.Content)
+				if err != nil {
+					job.Progress["failed"]++
+					fmt.Printf("Warning: failed to re-embed anonymized memory %s: %v\n", match.ID, err)
+					continue
+				}
+				m.recordEmbeddingUsage(tenantID, EstimateTokens(memory.Content))
+				memory.Embedding = embedding
+
+				if err := m.vectorClient.UpsertMemory(memory); err != nil {
+					job.Progress["failed"]++
+					fmt.Printf("Warning: failed to anonymize memory %s: %v\n", match.ID, err)
+					continue
+				}
+				job.Progress["reembedded"]++
+			} else {
+				metadata := make(map[string]interface{}, len(memory.Metadata)+4)
+				for k, v := range memory.Metadata {
+					metadata[k] = v
+				}
+				metadata["user_id"] = memory.UserID
+				metadata["content"] = memory.Content
+				metadata["timestamp"] = memory.Timestamp.Unix()
+				metadata["ttl"] = memory.TTL
+
+				if err := m.vectorClient.UpdateMetadata(match.ID, metadata); err != nil {
+					job.Progress["failed"]++
+					fmt.Printf("Warning: failed to anonymize memory %s: %v\n", match.ID, err)
+					continue
+				}
+			}
+
+			job.Progress["anonymized"]++
+			if job.Progress["scanned"]%patchPageSize == 0 {
+				m.saveJob(job)
+			}
+		}
+
+		// Search index entries are keyed by memory ID but carry the raw user ID and content
+		if config.AppConfig.RedisSearchEnabled {
+			if err := m.redisClient.DeleteUserIndexedMemories(userID); err != nil {
+				return fmt.Errorf("failed to remove user from search index: %w", err)
+			}
+		}
+
+		// Sessions, keyword-only memories and the profile are tied to the user and are not kept
+		if err := m.redisClient.DeleteKeywordMemories(userID); err != nil {
+			return fmt.Errorf("failed to delete keyword memories: %w", err)
+		}
+		keys, err := m.userSessionKeys(userID)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, fmt.Sprintf("user_profile:%s", userID))
+		if _, err := m.redisClient.DeleteKeys(keys...); err != nil {
+			return fmt.Errorf("failed to delete user sessions: %w", err)
+		}
+
+		if job.Progress["failed"] > 0 {
+			return fmt.Errorf("%d memories could not be anonymized", job.Progress["failed"])
+		}
+
+		// Scan again to prove no memory still carries the user ID, e.g. one saved during the job
+		err = m.forEachUserMemoryPage(userID, func(page []clients.QueryMatch) error {
+			job.Progress["remaining"] += len(page)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to verify anonymization: %w", err)
+		}
+		if job.Progress["remaining"] > 0 {
+			return fmt.Errorf("%d memories of the user remain after anonymization", job.Progress["remaining"])
+		}
+		return nil
+	})
+}
+
+// anonymizeMemory builds the anonymized form of a stored memory
+func anonymizeMemory(id string, metadata map[string]interface{}, salt []byte, pseudonym string) *models.MemoryEntry {
+	memory := memoryFromMetadata(id, metadata)
+	memory.UserID = pseudonym
+	memory.Content = scrubPII(memory.Content)
+
+	if sessionID, ok := memory.Metadata["session_id"].(string); ok && sessionID != "" {
+		memory.Metadata["session_id"] = pseudonymize(salt, sessionID)
+	}
+	memory.Metadata["anonymized"] = true
+	memory.Metadata["anonymized_at"] = time.Now().Unix()
+
+	return memory
+}
+
+// scrubPII replaces personal identifiers in text with placeholders
+func scrubPII(text string) string {
+	for _, p := range piiPatterns {
+		text = p.pattern.ReplaceAllString(text, p.replacement)
+	}
+	return text
+}
+
+// anonymizationSalt returns the configured pseudonymization key, or a random one
+// so that pseudonyms cannot be linked across anonymization runs
+func anonymizationSalt() ([]byte, error) {
+	if config.AppConfig.AnonymizationSalt != "" {
+		return []byte(config.AppConfig.AnonymizationSalt), nil
+	}
+
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate anonymization salt: %w", err)
+	}
+	return salt, nil
+}
+
+// pseudonymize derives a stable, non-reversible identifier from value
+func pseudonymize(salt []byte, value string) string {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(value))
+	return "anon_" + hex.EncodeToString(mac.Sum(nil))[:24]
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+var (
+	// ErrAPIKeyNotFound is returned when revoking a key that is not stored
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	// ErrAPIKeyStoreDisabled is returned when issuing keys while API_KEY_STORE_ENABLED is off
+	ErrAPIKeyStoreDisabled = errors.New("API key store is disabled")
+)
+
+// apiKeyPrefix marks issued keys so they are recognisable in logs and secret scanners
+const apiKeyPrefix = "mck_"
+
+// APIKeys authenticates requests against the keys from API_KEYS and, when enabled,
+// the keys issued through the admin API. Stored keys are kept as SHA-256 hashes; the
+// first 16 hex characters of the hash are the key's ID.
+type APIKeys struct {
+	redisClient *clients.RedisClient
+
+	mu       sync.Mutex
+	verified map[string]verifiedKey // hashes of stored keys seen valid
+}
+
+// verifiedKey is a stored key seen valid, cached until a time
+type verifiedKey struct {
+	tenantID string
+	until    time.Time
+}
+
+func NewAPIKeys(redisClient *clients.RedisClient) *APIKeys {
+	return &APIKeys{
+		redisClient: redisClient,
+		verified:    make(map[string]verifiedKey),
+	}
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticate reports whether key is a configured or stored API key, and the tenant the
+// key is bound to, if any. A revoked key may keep working on other instances for up to
+// API_KEY_CACHE_TTL.
+func (k *APIKeys) Authenticate(key string) (string, bool, error) {
+	if key == "" {
+		return "", false, nil
+	}
+	for _, configured := range config.AppConfig.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(configured)) == 1 {
+			return config.AppConfig.APIKeyTenants[configured], true, nil
+		}
+	}
+	if !config.AppConfig.APIKeyStoreEnabled {
+		return "", false, nil
+	}
+
+	hash := hashAPIKey(key)
+	k.mu.Lock()
+	cached, ok := k.verified[hash]
+	k.mu.Unlock()
+	if ok && time.Now().Before(cached.until) {
+		return cached.tenantID, true, nil
+	}
+
+	stored, err := k.redisClient.GetAPIKey(hash[:16])
+	if err != nil {
+		return "", false, err
+	}
+	valid := stored != nil && subtle.ConstantTimeCompare([]byte(stored.Hash), []byte(hash)) == 1
+
+	k.mu.Lock()
+	if valid && config.AppConfig.APIKeyCacheTTL > 0 {
+		k.verified[hash] = verifiedKey{tenantID: stored.TenantID, until: time.Now().Add(config.AppConfig.APIKeyCacheTTL)}
+	} else {
+		delete(k.verified, hash)
+	}
+	k.mu.Unlock()
+
+	if !valid {
+		return "", false, nil
+	}
+	return stored.TenantID, true, nil
+}
+
+// Create issues and stores a new key, bound to tenantID unless it is empty. The plaintext
+// key is only returned here.
+func (k *APIKeys) Create(name string, tenantID string) (*models.CreatedAPIKey, error) {
+	if !config.AppConfig.APIKeyStoreEnabled {
+		return nil, ErrAPIKeyStoreDisabled
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	plaintext := apiKeyPrefix + hex.EncodeToString(secret)
+	hash := hashAPIKey(plaintext)
+
+	key := models.APIKey{
+		ID:        hash[:16],
+		Name:      name,
+		TenantID:  tenantID,
+		Hash:      hash,
+		CreatedAt: time.Now(),
+	}
+	if err := k.redisClient.SaveAPIKey(&key); err != nil {
+		return nil, err
+	}
+
+	key.Hash = ""
+	return &models.CreatedAPIKey{APIKey: key, Key: plaintext}, nil
+}
+
+// List returns the stored keys without their hashes
+func (k *APIKeys) List() ([]models.APIKey, error) {
+	keys, err := k.redisClient.ListAPIKeys()
+	if err != nil {
+		return nil, err
+	}
+	for i := range keys {
+		keys[i].Hash = ""
+	}
+	return keys, nil
+}
+
+// Revoke deletes a stored key and forgets it on this instance
+func (k *APIKeys) Revoke(id string) error {
+	deleted, err := k.redisClient.DeleteAPIKey(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: %s", ErrAPIKeyNotFound, id)
+	}
+
+	k.mu.Lock()
+	for hash := range k.verified {
+		if hash[:16] == id {
+			delete(k.verified, hash)
+		}
+	}
+	k.mu.Unlock()
+
+	return nil
+}
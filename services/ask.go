@@ -0,0 +1,150 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// defaultAskLimit is how many memories an answer is grounded on by default
+const defaultAskLimit = 5
+
+// Ask answers a question from the user's most relevant memories. When onDelta is set the
+// answer is streamed to it as it is generated.
+func (m *MemoryService) Ask(req models.AskRequest, onDelta func(string) error) (*models.AskResponse, error) {
+	if m.llmClient == nil {
+		return nil, ErrLLMUnavailable
+	}
+
+	instructions, err := m.instructionsFor(req.TenantID, req.UserID, req.AssistantID)
+	if err != nil {
+		return nil, err
+	}
+	retrieved, err := m.groundingMemories(models.QueryMemoryRequest{
+		TenantID:      req.TenantID,
+		UserID:        req.UserID,
+		Query:         req.Question,
+		Limit:         req.Limit,
+		MinScore:      req.MinScore,
+		AssistantID:   req.AssistantID,
+		MinConfidence: req.MinConfidence,
+		Granularity:   req.Granularity,
+		Mode:          req.Mode,
+	})
+	if err != nil {
+		return nil, err
+	}
+	retrieved = withoutInstructions(retrieved, instructions)
+
+	prompt, err := m.templates.Render(models.PromptAsk, map[string]interface{}{
+		"Question":     req.Question,
+		"Instructions": instructionContents(instructions),
+		"Memories":     escapedContents(retrieved),
+		"Context":      memoryContext(retrieved),
+	})
+	if err != nil {
+		return nil, err
+	}
+	response, err := m.complete(models.PromptAsk, clients.LLMRequest{Prompt: prompt}, onDelta)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AskResponse{
+		Answer:           response.Text,
+		Model:            response.Model,
+		Memories:         retrieved,
+		Instructions:     instructions,
+		PromptTokens:     response.PromptTokens,
+		CompletionTokens: response.CompletionTokens,
+	}, nil
+}
+
+// ChatCompletion continues a conversation with the memories most relevant to its last
+// user message added to the system prompt. When onDelta is set the reply is streamed to
+// it as it is generated.
+func (m *MemoryService) ChatCompletion(req models.ChatCompletionRequest, onDelta func(string) error) (*clients.LLMResponse, []models.MemoryResult, error) {
+	if m.llmClient == nil {
+		return nil, nil, ErrLLMUnavailable
+	}
+
+	var query string
+	messages := make([]clients.LLMMessage, 0, len(req.Messages))
+	for _, message := range req.Messages {
+		switch message.Role {
+		case "system", "user", "assistant":
+		default:
+			return nil, nil, fmt.Errorf("%w: unsupported message role %q", ErrInvalidChat, message.Role)
+		}
+		if message.Role == "user" {
+			query = message.Content
+		}
+		messages = append(messages, clients.LLMMessage{Role: message.Role, Content: message.Content})
+	}
+	if strings.TrimSpace(query) == "" {
+		return nil, nil, fmt.Errorf("%w: the conversation has no user message", ErrInvalidChat)
+	}
+
+	instructions, err := m.instructionsFor(req.TenantID, req.User, req.AssistantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	retrieved, err := m.groundingMemories(models.QueryMemoryRequest{
+		TenantID:    req.TenantID,
+		UserID:      req.User,
+		Query:       query,
+		Limit:       req.MemoryLimit,
+		AssistantID: req.AssistantID,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	retrieved = withoutInstructions(retrieved, instructions)
+
+	system, err := m.templates.Render(models.PromptChat, map[string]interface{}{
+		"Instructions": instructionContents(instructions),
+		"Memories":     escapedContents(retrieved),
+		"Context":      memoryContext(retrieved),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	response, err := m.complete(models.PromptChat, clients.LLMRequest{
+		Model:     req.Model,
+		System:    system,
+		Messages:  messages,
+		MaxTokens: req.MaxTokens,
+	}, onDelta)
+	if err != nil {
+		return nil, nil, err
+	}
+	return response, retrieved, nil
+}
+
+// groundingMemories retrieves the memories a generated answer is based on, keeping the
+// most relevant ones whose rendered blocks fit in LLM_CONTEXT_TOKENS
+func (m *MemoryService) groundingMemories(req models.QueryMemoryRequest) ([]models.MemoryResult, error) {
+	if req.Limit <= 0 {
+		req.Limit = defaultAskLimit
+	}
+	req.SkipFacts = true
+
+	retrieved, err := m.QueryMemory(req)
+	if err != nil {
+		return nil, err
+	}
+	// Memories flagged for embedded instructions are never placed in a prompt
+	results := withoutUntrusted(retrieved.Results)
+	return results[:contextFit(memoryBlocks(results))], nil
+}
+
+// instructionsFor returns the instructions included in a generated answer for a user
+func (m *MemoryService) instructionsFor(tenantID string, userID string, assistantID string) ([]models.Instruction, error) {
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	instructions, _, err := m.ForTenant(tenantID).userInstructions(tenantID, userID, assistantID)
+	return instructions, err
+}
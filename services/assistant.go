@@ -0,0 +1,70 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+)
+
+// ErrInvalidAssistant is returned for assistant IDs that cannot be used in vector filters
+var ErrInvalidAssistant = errors.New("invalid assistant ID")
+
+// validateAssistantID accepts letters, digits, '-', '_', '.' and ':'
+func validateAssistantID(assistantID string) error {
+	for _, r := range assistantID {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) && !strings.ContainsRune("-_.:", r) {
+			return fmt.Errorf("%w: %q may only contain letters, digits, '-', '_', '.' and ':'", ErrInvalidAssistant, assistantID)
+		}
+	}
+	return nil
+}
+
+// visibleAssistants returns the assistants whose memories an assistant may read: its own
+// and those granted by ASSISTANT_SHARING
+func visibleAssistants(assistantID string) []string {
+	return append([]string{assistantID}, config.AppConfig.AssistantSharing[assistantID]...)
+}
+
+// assistantFilter returns the vector filter isolating an assistant's memories, or "" when
+// no assistant is given. Memories saved without an assistant are shared by all of them.
+func assistantFilter(assistantID string) string {
+	if assistantID == "" {
+		return ""
+	}
+
+	visible := visibleAssistants(assistantID)
+	quoted := make([]string, len(visible))
+	for i, id := range visible {
+		quoted[i] = fmt.Sprintf("'%s'", id)
+	}
+	return fmt.Sprintf("(assistant_id IN (%s) OR HAS NOT FIELD assistant_id)", strings.Join(quoted, ", "))
+}
+
+// assistantVisible reports whether a memory's metadata is readable by an assistant,
+// mirroring assistantFilter for memories listed without a filter
+func assistantVisible(metadata map[string]interface{}, assistantID string) bool {
+	owner, _ := metadata["assistant_id"].(string)
+	if assistantID == "" || owner == "" {
+		return true
+	}
+	for _, id := range visibleAssistants(assistantID) {
+		if id == owner {
+			return true
+		}
+	}
+	return false
+}
+
+// joinFilters ANDs the non-empty vector filters
+func joinFilters(filters ...string) string {
+	parts := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		if filter != "" {
+			parts = append(parts, filter)
+		}
+	}
+	return strings.Join(parts, " AND ")
+}
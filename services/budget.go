@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/tokenizer"
+)
+
+// ErrEmbeddingBudgetExceeded is returned when a tenant has used its daily embedding budget
+var ErrEmbeddingBudgetExceeded = errors.New("daily embedding token budget exceeded")
+
+// budgetKeyTTL keeps yesterday's counter around long enough to inspect it
+const budgetKeyTTL = 48 * 60 * 60
+
+// EmbeddingBudget tracks per-tenant daily embedding token usage in Redis
+type EmbeddingBudget struct {
+	redisClient *clients.RedisClient
+}
+
+func NewEmbeddingBudget(redisClient *clients.RedisClient) *EmbeddingBudget {
+	return &EmbeddingBudget{redisClient: redisClient}
+}
+
+// EstimateTokens returns the token count of a text, exact when a tokenizer rank file is loaded
+func EstimateTokens(text string) int64 {
+	return int64(tokenizer.Count(text))
+}
+
+// Limit returns the daily token budget for a tenant, 0 meaning unlimited
+func (b *EmbeddingBudget) Limit(tenantID string) int64 {
+	if limit, ok := config.AppConfig.EmbeddingTenantBudgets[tenantID]; ok {
+		return limit
+	}
+	return config.AppConfig.EmbeddingDailyTokenBudget
+}
+
+// Reserve adds tokens the tenant is about to spend to today's usage and reports whether
+// they fit its budget. The tokens are added before the limit is checked, so concurrent
+// requests cannot overshoot it together; tokens that do not fit are taken back.
+func (b *EmbeddingBudget) Reserve(tenantID string, tokens int64) (bool, error) {
+	key := budgetKey(tenantID, time.Now())
+	used, err := b.redisClient.IncrementCounter(key, tokens, budgetKeyTTL)
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve embedding budget: %w", err)
+	}
+
+	limit := b.Limit(tenantID)
+	if limit == 0 || used <= limit {
+		return true, nil
+	}
+	if _, err := b.redisClient.IncrementCounter(key, -tokens, budgetKeyTTL); err != nil {
+		return false, fmt.Errorf("failed to release embedding budget: %w", err)
+	}
+	return false, nil
+}
+
+// Release takes back reserved tokens that were not spent, such as when embedding failed
+func (b *EmbeddingBudget) Release(tenantID string, tokens int64) error {
+	_, err := b.redisClient.IncrementCounter(budgetKey(tenantID, time.Now()), -tokens, budgetKeyTTL)
+	return err
+}
+
+// Record adds tokens a tenant spent without a reservation to today's usage
+func (b *EmbeddingBudget) Record(tenantID string, tokens int64) error {
+	_, err := b.redisClient.IncrementCounter(budgetKey(tenantID, time.Now()), tokens, budgetKeyTTL)
+	return err
+}
+
+// Usage returns the tokens a tenant has spent today alongside its limit
+func (b *EmbeddingBudget) Usage(tenantID string) (map[string]interface{}, error) {
+	used, err := b.redisClient.GetCounter(budgetKey(tenantID, time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding budget: %w", err)
+	}
+
+	return map[string]interface{}{
+		"tenant_id":   tenantID,
+		"used_tokens": used,
+		"limit":       b.Limit(tenantID),
+		"policy":      config.AppConfig.EmbeddingBudgetPolicy,
+	}, nil
+}
+
+// budgetTenantKey holds the tenant a request's embedding usage is charged to
+type budgetTenantKey struct{}
+
+// WithBudgetTenant returns ctx charging the embedding usage of services bound to it (see
+// MemoryService.WithContext) to tenantID, whatever tenant their requests name. Handlers
+// set it to the authenticated tenant, so a client cannot choose its budget.
+func WithBudgetTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, budgetTenantKey{}, tenantID)
+}
+
+// budgetTenant returns the tenant embedding usage is charged to: the one the bound
+// request was authenticated as, or tenantID for work not bound to a request
+func (m *MemoryService) budgetTenant(tenantID string) string {
+	if m.ctx != nil {
+		if charged, ok := m.ctx.Value(budgetTenantKey{}).(string); ok && charged != "" {
+			return charged
+		}
+	}
+	return tenantID
+}
+
+// reserveEmbedding reserves tokens of the tenant's embedding budget, see EmbeddingBudget.Reserve
+func (m *MemoryService) reserveEmbedding(tenantID string, tokens int64) (bool, error) {
+	return m.budget.Reserve(m.budgetTenant(tenantID), tokens)
+}
+
+// releaseEmbedding takes back tokens reserved for an embedding that failed, without
+// failing the request
+func (m *MemoryService) releaseEmbedding(tenantID string, tokens int64) {
+	if err := m.budget.Release(m.budgetTenant(tenantID), tokens); err != nil {
+		fmt.Printf("Warning: failed to release embedding budget of tenant %s: %v\n", tenantID, err)
+	}
+}
+
+func budgetKey(tenantID string, day time.Time) string {
+	return fmt.Sprintf("embedding_budget:%s:%s", tenantID, day.UTC().Format("2006-01-02"))
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrCallbackNotAllowed is returned when a QStash callback URL is malformed or its host
+// is outside CALLBACK_ALLOWED_HOSTS
+var ErrCallbackNotAllowed = errors.New("callback URL not allowed")
+
+// validateCallback checks a task's destination and any failure callback requested with it
+// before anything is handed to QStash
+func validateCallback(callbackURL string, opts models.DeliveryOptions) error {
+	if err := validateCallbackURL(callbackURL); err != nil {
+		return err
+	}
+	if opts.FailureCallback != "" {
+		if err := validateCallbackURL(opts.FailureCallback); err != nil {
+			return fmt.Errorf("failure_callback: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateCallbackURL accepts absolute http and https URLs whose host is allowed and
+// resolves to public addresses
+func validateCallbackURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return fmt.Errorf("%w: %q must be an absolute http or https URL", ErrCallbackNotAllowed, rawURL)
+	}
+	if !callbackHostAllowed(parsed.Hostname()) {
+		return fmt.Errorf("%w: host %q is not in CALLBACK_ALLOWED_HOSTS", ErrCallbackNotAllowed, parsed.Hostname())
+	}
+	if err := clients.CheckOutboundURL(rawURL); err != nil {
+		return fmt.Errorf("%w: %v", ErrCallbackNotAllowed, err)
+	}
+	return nil
+}
+
+// callbackHostAllowed matches host against CALLBACK_ALLOWED_HOSTS; "*.example.com"
+// matches example.com's subdomains but not example.com itself
+func callbackHostAllowed(host string) bool {
+	allowed := config.AppConfig.CallbackAllowedHosts
+	if len(allowed) == 0 {
+		return true
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for _, pattern := range allowed {
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/metrics"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// embeddingCanary compares a second embedding provider with the primary on a sample of
+// saves and queries. The vector index only holds primary vectors, so both providers rank
+// the same memories: the primary's top results for the text are re-scored by each
+// provider's cosine similarity and the two rankings are compared.
+type embeddingCanary struct {
+	client     clients.EmbeddingClient
+	sampleRate float64
+	topK       int
+
+	mu              sync.Mutex
+	comparisons     int64
+	failures        int64
+	scoreDivergence float64 // sums over comparisons, averaged in snapshot
+	rankCorrelation float64
+	topAgreements   int64
+}
+
+// newEmbeddingCanary returns the configured canary, or nil when none is configured
+func newEmbeddingCanary() *embeddingCanary {
+	provider := config.AppConfig.EmbeddingCanaryProvider
+	if provider == "" {
+		return nil
+	}
+	return &embeddingCanary{
+		client:     clients.NewProviderClient(clients.EmbeddingProvider(provider)),
+		sampleRate: config.AppConfig.EmbeddingCanarySampleRate,
+		topK:       config.AppConfig.EmbeddingCanaryTopK,
+	}
+}
+
+// sampled reports whether the current save or query should be compared
+func (c *embeddingCanary) sampled() bool {
+	return c != nil && rand.Float64() < c.sampleRate
+}
+
+// canaryQuery compares the providers on a query in the background
+func (m *MemoryService) canaryQuery(query string, queryEmbedding []float64, results []models.MemoryResult) {
+	if !m.canary.sampled() {
+		return
+	}
+	go m.detached().compareEmbeddings("query", query, queryEmbedding, results)
+}
+
+// canarySave compares the providers on a saved memory in the background, ranking the
+// user's memories closest to it
+func (m *MemoryService) canarySave(memory *models.MemoryEntry) {
+	if !m.canary.sampled() {
+		return
+	}
+	m = m.detached()
+	go func() {
+		neighbours, err := m.vectorClient.QueryMemories(memory.UserID, "", "", memory.Embedding, m.canary.topK+1, 0)
+		if err != nil {
+			m.canary.fail(fmt.Errorf("failed to find neighbours of memory %s: %w", memory.ID, err))
+			return
+		}
+		results := make([]models.MemoryResult, 0, len(neighbours))
+		for _, neighbour := range neighbours {
+			if neighbour.ID != memory.ID {
+				results = append(results, neighbour)
+			}
+		}
+		m.compareEmbeddings("save", memory.Content, memory.Embedding, results)
+	}()
+}
+
+// compareEmbeddings scores the top results for text with both providers and records how
+// far the scores and rankings diverge
+func (m *MemoryService) compareEmbeddings(kind string, text string, primaryEmbedding []float64, results []models.MemoryResult) {
+	if len(results) > m.canary.topK {
+		results = results[:m.canary.topK]
+	}
+	if len(results) < 2 {
+		return
+	}
+
+	ids := make([]string, len(results))
+	texts := make([]string, 0, len(results)+1)
+	texts = append(texts, text)
+	for i, result := range results {
+		ids[i] = result.ID
+		texts = append(texts, result.Content)
+	}
+
+	// Score with stored primary vectors rather than reported scores, which may be fused or boosted
+	vectors, err := m.vectorClient.FetchVectors(ids)
+	if err != nil {
+		m.canary.fail(fmt.Errorf("failed to fetch primary vectors: %w", err))
+		return
+	}
+	primaryVectors := make(map[string][]float64, len(vectors))
+	for _, vector := range vectors {
+		primaryVectors[vector.ID] = vector.Vector
+	}
+	canaryEmbeddings, err := m.canary.client.GenerateBatchEmbeddings(texts)
+	if err != nil || len(canaryEmbeddings) != len(texts) {
+		m.canary.fail(fmt.Errorf("canary embedding failed: %v", err))
+		return
+	}
+
+	var primaryScores, canaryScores []float64
+	for i, result := range results {
+		vector, ok := primaryVectors[result.ID]
+		if !ok {
+			continue
+		}
+		primaryScores = append(primaryScores, (1+cosineSimilarity(primaryEmbedding, vector))/2)
+		canaryScores = append(canaryScores, (1+cosineSimilarity(canaryEmbeddings[0], canaryEmbeddings[i+1]))/2)
+	}
+	if len(primaryScores) < 2 {
+		return
+	}
+
+	divergence := 0.0
+	for i := range primaryScores {
+		diff := primaryScores[i] - canaryScores[i]
+		if diff < 0 {
+			diff = -diff
+		}
+		divergence += diff
+	}
+	divergence /= float64(len(primaryScores))
+
+	primaryRanks := scoreRanks(primaryScores)
+	canaryRanks := scoreRanks(canaryScores)
+	correlation := spearman(primaryRanks, canaryRanks)
+	topAgrees := false
+	for i := range primaryRanks {
+		if primaryRanks[i] == 0 {
+			topAgrees = canaryRanks[i] == 0
+		}
+	}
+
+	m.canary.record(divergence, correlation, topAgrees)
+	fmt.Printf("🐤 Embedding canary (%s): %s vs %s over %d memories: score divergence %.3f, rank correlation %.3f, same top memory: %t\n",
+		kind, m.embeddingClient.GetProvider(), m.canary.client.GetProvider(), len(primaryScores), divergence, correlation, topAgrees)
+}
+
+// scoreRanks returns the rank of each score, 0 being the best
+func scoreRanks(scores []float64) []int {
+	order := make([]int, len(scores))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return scores[order[a]] > scores[order[b]]
+	})
+
+	ranks := make([]int, len(scores))
+	for rank, i := range order {
+		ranks[i] = rank
+	}
+	return ranks
+}
+
+// spearman returns the Spearman rank correlation of two rankings of the same items
+func spearman(a []int, b []int) float64 {
+	n := float64(len(a))
+	sum := 0.0
+	for i := range a {
+		d := float64(a[i] - b[i])
+		sum += d * d
+	}
+	return 1 - 6*sum/(n*(n*n-1))
+}
+
+func (c *embeddingCanary) record(divergence float64, correlation float64, topAgrees bool) {
+	c.mu.Lock()
+	c.comparisons++
+	c.scoreDivergence += divergence
+	c.rankCorrelation += correlation
+	if topAgrees {
+		c.topAgreements++
+	}
+	c.mu.Unlock()
+
+	labels := map[string]string{"provider": string(c.client.GetProvider())}
+	metrics.AddCounter("memorycache_embedding_canary_comparisons_total", "Sampled saves and queries compared with the canary provider", labels, 1)
+	metrics.SetGauge("memorycache_embedding_canary_score_divergence", "Mean absolute score difference of the last canary comparison", labels, divergence)
+	metrics.SetGauge("memorycache_embedding_canary_rank_correlation", "Spearman rank correlation of the last canary comparison", labels, correlation)
+}
+
+func (c *embeddingCanary) fail(err error) {
+	c.mu.Lock()
+	c.failures++
+	c.mu.Unlock()
+	fmt.Printf("Warning: embedding canary comparison skipped: %v\n", err)
+}
+
+// snapshot summarises the comparisons made so far
+func (c *embeddingCanary) snapshot() map[string]interface{} {
+	if c == nil {
+		return map[string]interface{}{"enabled": false}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snapshot := map[string]interface{}{
+		"enabled":     true,
+		"provider":    c.client.GetProvider(),
+		"model":       c.client.GetModel(),
+		"sample_rate": c.sampleRate,
+		"comparisons": c.comparisons,
+		"failures":    c.failures,
+	}
+	if c.comparisons > 0 {
+		n := float64(c.comparisons)
+		snapshot["avg_score_divergence"] = c.scoreDivergence / n
+		snapshot["avg_rank_correlation"] = c.rankCorrelation / n
+		snapshot["top_agreement_rate"] = float64(c.topAgreements) / n
+	}
+	return snapshot
+}
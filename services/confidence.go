@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrInvalidConfidence is returned for confidences outside 0-1
+var ErrInvalidConfidence = errors.New("invalid confidence")
+
+// isDerived reports whether memories of an origin carry a confidence
+func isDerived(origin string) bool {
+	switch origin {
+	case models.OriginExtracted, models.OriginImported, models.OriginSummary:
+		return true
+	}
+	return false
+}
+
+// initialConfidence returns the confidence a new memory is saved with. Messages are
+// taken at face value and get none unless one is requested.
+func initialConfidence(origin string, requested *float64) (*float64, error) {
+	if requested != nil {
+		if *requested < 0 || *requested > 1 {
+			return nil, fmt.Errorf("%w: %v is not between 0 and 1", ErrInvalidConfidence, *requested)
+		}
+		return requested, nil
+	}
+	if !isDerived(origin) {
+		return nil, nil
+	}
+	confidence := config.AppConfig.ConfidenceDefault
+	return &confidence, nil
+}
+
+// effectiveConfidence returns a memory's confidence decayed since it was last confirmed.
+// Memories without a confidence report 1 and false.
+func effectiveConfidence(metadata map[string]interface{}, now time.Time) (float64, bool) {
+	confidence, ok := metadata["confidence"].(float64)
+	if !ok {
+		return 1, false
+	}
+
+	halfLife := config.AppConfig.ConfidenceHalfLife
+	if halfLife <= 0 {
+		return confidence, true
+	}
+	confirmedAt, ok := metadata["confirmed_at"].(float64)
+	if !ok {
+		confirmedAt, _ = metadata["timestamp"].(float64)
+	}
+	age := now.Sub(time.Unix(int64(confirmedAt), 0))
+	if age < 0 {
+		age = 0
+	}
+	return confidence * math.Pow(0.5, float64(age)/float64(halfLife)), true
+}
+
+// verifiedConfidence is the confidence after a confirmation, which removes a share of
+// the remaining doubt, or a refutation, which halves it
+func verifiedConfidence(current float64, confirmed bool) float64 {
+	if confirmed {
+		return current + (1-current)*config.AppConfig.ConfidenceVerifyBoost
+	}
+	return current / 2
+}
+
+// applyConfidence reports the decayed confidence of derived results and drops those
+// below minConfidence
+func applyConfidence(results []models.MemoryResult, minConfidence float64) []models.MemoryResult {
+	now := time.Now()
+	kept := results[:0]
+	for _, result := range results {
+		confidence, ok := effectiveConfidence(result.Metadata, now)
+		if ok {
+			rounded := math.Round(confidence*1000) / 1000
+			result.Confidence = &rounded
+		}
+		if confidence >= minConfidence {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
+
+// VerifyMemory confirms or refutes a memory, restarting its confidence decay. It returns
+// nil when the memory does not exist or belongs to another user.
+func (m *MemoryService) VerifyMemory(memoryID string, userID string, tenantID string, confirmed bool) (*models.MemoryVerification, error) {
+	m = m.ForTenant(tenantID)
+
+	match, err := m.vectorClient.FetchMemory(memoryID)
+	if err != nil {
+		return nil, err
+	}
+	if match == nil {
+		return nil, nil
+	}
+	if owner, _ := match.Metadata["user_id"].(string); owner != userID {
+		return nil, nil
+	}
+
+	now := time.Now()
+	previous, _ := effectiveConfidence(match.Metadata, now)
+	verification := &models.MemoryVerification{
+		MemoryID:           memoryID,
+		Confirmed:          confirmed,
+		PreviousConfidence: previous,
+		Confidence:         verifiedConfidence(previous, confirmed),
+		VerifiedAt:         now,
+	}
+
+	metadata := make(map[string]interface{}, len(match.Metadata)+3)
+	for k, v := range match.Metadata {
+		metadata[k] = v
+	}
+	metadata["confidence"] = verification.Confidence
+	metadata["confirmed_at"] = now.Unix()
+	verifications, _ := match.Metadata["verification_count"].(float64)
+	metadata["verification_count"] = int(verifications) + 1
+
+	if err := m.vectorClient.UpdateMetadata(memoryID, metadata); err != nil {
+		return nil, err
+	}
+	m.publish(EventMemoryUpdated, tenantID, userID, memoryID)
+	return verification, nil
+}
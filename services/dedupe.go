@@ -0,0 +1,162 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrInvalidDedupe is returned for an unknown dedupe option
+var ErrInvalidDedupe = errors.New("invalid dedupe option")
+
+// nearDuplicateSimilarity is the word overlap (Jaccard index) above which two memories
+// count as the same content
+const nearDuplicateSimilarity = 0.9
+
+func validateDedupe(req models.QueryMemoryRequest) error {
+	grouped := false
+	for _, option := range req.Dedupe {
+		switch option {
+		case models.DedupeDocument, models.DedupeSession:
+			grouped = true
+		case models.DedupeContent, models.DedupeTurn:
+		default:
+			return fmt.Errorf("%w: unknown dedupe %q (use document, session, content or turn)", ErrInvalidDedupe, option)
+		}
+	}
+	if req.MaxPerGroup < 0 {
+		return fmt.Errorf("%w: max_per_group %d is negative", ErrInvalidDedupe, req.MaxPerGroup)
+	}
+	if req.MaxPerGroup > 0 && !grouped {
+		return fmt.Errorf("%w: max_per_group requires dedupe by document or session", ErrInvalidDedupe)
+	}
+	return nil
+}
+
+// dedupeResults walks results best first, dropping those whose document or session
+// already has maxPerGroup results, those nearly identical to a result already kept and
+// those whose question or answer was already kept
+func dedupeResults(results []models.MemoryResult, dedupe []string, maxPerGroup int) []models.MemoryResult {
+	if len(dedupe) == 0 {
+		return results
+	}
+	if maxPerGroup <= 0 {
+		maxPerGroup = 1
+	}
+
+	var byDocument, bySession, byContent, byTurn bool
+	for _, option := range dedupe {
+		switch option {
+		case models.DedupeDocument:
+			byDocument = true
+		case models.DedupeSession:
+			bySession = true
+		case models.DedupeContent:
+			byContent = true
+		case models.DedupeTurn:
+			byTurn = true
+		}
+	}
+
+	documents := make(map[string]int)
+	sessions := make(map[string]int)
+	turns := make(map[string]bool)
+	var keptWords []map[string]bool
+	kept := make([]models.MemoryResult, 0, len(results))
+	for _, result := range results {
+		document := ""
+		if byDocument {
+			document = documentKey(result.Metadata)
+			if document != "" && documents[document] >= maxPerGroup {
+				continue
+			}
+		}
+		session := ""
+		if bySession {
+			session, _ = result.Metadata["session_id"].(string)
+			if session != "" && sessions[session] >= maxPerGroup {
+				continue
+			}
+		}
+		turn := ""
+		if byTurn {
+			turn = turnKey(result)
+			if turns[turn] {
+				continue
+			}
+		}
+		if byContent {
+			words := contentWords(result.Content)
+			if nearDuplicateOf(words, keptWords) {
+				continue
+			}
+			keptWords = append(keptWords, words)
+		}
+
+		if document != "" {
+			documents[document]++
+		}
+		if session != "" {
+			sessions[session]++
+		}
+		if turn != "" {
+			turns[turn] = true
+		}
+		kept = append(kept, result)
+	}
+	return kept
+}
+
+// documentKey identifies the document a memory was taken from: its import source, or
+// the parent memories it was extracted from. Memories of neither kind have no document.
+func documentKey(metadata map[string]interface{}) string {
+	if source, _ := metadata["import_source"].(string); source != "" {
+		return "source:" + source
+	}
+	parents := metadataStrings(metadata["parent_ids"])
+	if len(parents) == 0 {
+		return ""
+	}
+	parents = append([]string(nil), parents...)
+	sort.Strings(parents)
+	return "parents:" + strings.Join(parents, ",")
+}
+
+// turnKey identifies the conversation turn of a memory by its question: the question an
+// answer replies to, or the memory itself
+func turnKey(result models.MemoryResult) string {
+	if questionID, _ := result.Metadata["reply_to"].(string); questionID != "" {
+		return questionID
+	}
+	return result.ID
+}
+
+// contentWords is the set of words in normalized content
+func contentWords(content string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.Fields(normalizeContent(content)) {
+		words[word] = true
+	}
+	return words
+}
+
+// nearDuplicateOf reports whether words overlap any of the kept word sets by at least
+// nearDuplicateSimilarity
+func nearDuplicateOf(words map[string]bool, kept []map[string]bool) bool {
+	for _, other := range kept {
+		shared := 0
+		for word := range words {
+			if other[word] {
+				shared++
+			}
+		}
+		union := len(words) + len(other) - shared
+		if union == 0 || float64(shared)/float64(union) >= nearDuplicateSimilarity {
+			return true
+		}
+	}
+	return false
+}
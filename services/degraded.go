@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Fairy-nn/MemoryCacheAI/metrics"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrVectorUnavailable is returned when a query fails in the vector store
+var ErrVectorUnavailable = errors.New("vector store unavailable")
+
+// degradedQuery answers a query whose vector search failed from the latest messages of the
+// user's live sessions, scored lexically, as long as Redis is reachable. Assistant, task,
+// trust and confidence options cannot be applied to session messages; queries for summary
+// granularities find nothing. It returns an error when the failure was not the vector
+// store's or Redis is down too.
+func (m *MemoryService) degradedQuery(req models.QueryMemoryRequest, cause error) (*models.QueryMemoryResponse, error) {
+	if !errors.Is(cause, ErrVectorUnavailable) {
+		return nil, cause
+	}
+	if err := m.redisClient.Ping(); err != nil {
+		return nil, fmt.Errorf("redis is unavailable too: %w", err)
+	}
+	matcher, err := newContentMatcher(req.ContentFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantID := req.TenantID
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	metrics.AddCounter("memorycache_degraded_queries_total", "Queries answered from session messages while the vector store failed",
+		map[string]string{"tenant": tenantID}, 1)
+
+	results := []models.MemoryResult{}
+	if req.Granularity == "" || req.Granularity == models.GranularityRaw || req.Granularity == models.GranularityAll {
+		sessionIDs, err := m.redisClient.GetUserSessions(req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		for _, sessionID := range sessionIDs {
+			session, err := m.redisClient.GetSessionCached(sessionID)
+			if err != nil {
+				// The session may have expired since it was indexed
+				continue
+			}
+			sessionTenant := session.TenantID
+			if sessionTenant == "" {
+				sessionTenant = models.DefaultTenant
+			}
+			if session.UserID != req.UserID || sessionTenant != tenantID {
+				continue
+			}
+
+			messages := session.Messages
+			if len(messages) > defaultSessionWindow {
+				messages = messages[len(messages)-defaultSessionWindow:]
+			}
+			window := models.RetrieveRequest{QueryMemoryRequest: req, SessionID: sessionID}
+			results = append(results, scoreSessionMessages(window, messages, nil, nil, matcher)...)
+		}
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return &models.QueryMemoryResponse{
+		Results:        results,
+		Total:          len(results),
+		Degraded:       true,
+		DegradedReason: cause.Error(),
+	}, nil
+}
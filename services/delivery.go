@@ -0,0 +1,192 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+
+	"github.com/google/uuid"
+)
+
+// StartDelivery records a job for a task delivery and links it to the QStash message
+// and schedule that triggered it, if any. Tracking is best-effort: failures are
+// logged and never stop the delivery from running.
+func (m *MemoryService) StartDelivery(taskType string, tenantID string, userID string, messageID string, scheduleID string) *models.Job {
+	now := time.Now()
+	job := &models.Job{
+		ID:         uuid.New().String(),
+		Type:       taskType,
+		Status:     models.JobRunning,
+		TenantID:   tenantID,
+		UserID:     userID,
+		Progress:   map[string]int{},
+		MessageID:  messageID,
+		ScheduleID: scheduleID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := m.recordJob(job); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	if err := m.controlClient.LinkDeliveryJob(messageID, scheduleID, job.ID); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	return job
+}
+
+// FinishDelivery records the outcome of a delivery's job; an empty failure means it succeeded
+func (m *MemoryService) FinishDelivery(job *models.Job, failure string) {
+	if failure != "" {
+		job.Status = models.JobFailed
+		job.Error = failure
+	} else {
+		job.Status = models.JobCompleted
+	}
+	m.saveJob(job)
+}
+
+// ListDeliveryEvents returns QStash delivery events matching filter, each with the
+// job its message ran when one was recorded
+func (m *MemoryService) ListDeliveryEvents(filter clients.EventFilter) ([]map[string]interface{}, error) {
+	if !m.SchedulerAvailable() {
+		return nil, ErrSchedulerUnavailable
+	}
+
+	events, err := m.qstashClient.GetEvents(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := map[string]*models.Job{}
+	for _, event := range events {
+		messageID, _ := event["messageId"].(string)
+		if messageID == "" {
+			continue
+		}
+
+		job, seen := jobs[messageID]
+		if !seen {
+			job = m.messageJob(messageID)
+			jobs[messageID] = job
+		}
+		if job != nil {
+			event["job"] = job
+		}
+	}
+
+	return events, nil
+}
+
+// GetDeliveryStatus returns the delivery events of one QStash message and the job it ran
+func (m *MemoryService) GetDeliveryStatus(messageID string) (map[string]interface{}, error) {
+	if !m.SchedulerAvailable() {
+		return nil, ErrSchedulerUnavailable
+	}
+
+	events, err := m.qstashClient.GetEvents(clients.EventFilter{MessageID: messageID})
+	if err != nil {
+		return nil, err
+	}
+
+	status := map[string]interface{}{
+		"message_id": messageID,
+		"events":     events,
+	}
+	if len(events) > 0 {
+		// Events are newest first
+		status["state"] = events[0]["state"]
+	}
+	if job := m.messageJob(messageID); job != nil {
+		status["job"] = job
+	}
+
+	return status, nil
+}
+
+// ListQStashSchedules returns the QStash schedules targeting destination (all when
+// empty), each with its local cleanup schedule metadata and the job its last delivery ran
+func (m *MemoryService) ListQStashSchedules(destination string) ([]map[string]interface{}, error) {
+	if !m.SchedulerAvailable() {
+		return nil, ErrSchedulerUnavailable
+	}
+
+	schedules, err := m.qstashClient.GetSchedules()
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]map[string]interface{}, 0, len(schedules))
+	for _, schedule := range schedules {
+		if destination != "" && schedule["destination"] != destination {
+			continue
+		}
+
+		scheduleID, _ := schedule["scheduleId"].(string)
+		if scheduleID != "" {
+			if local, err := m.controlClient.GetCleanupSchedule(scheduleID); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			} else if local != nil {
+				schedule["cleanup_schedule"] = local
+			}
+
+			if jobID, err := m.controlClient.GetScheduleLastJobID(scheduleID); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			} else if jobID != "" {
+				if job, err := m.controlClient.GetJob(jobID); err == nil {
+					schedule["last_job"] = job
+				}
+			}
+		}
+
+		filtered = append(filtered, schedule)
+	}
+
+	return filtered, nil
+}
+
+// ListPublishFailures returns the most recent tasks that could not be handed to QStash
+func (m *MemoryService) ListPublishFailures(limit int) ([]models.PublishFailure, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	return m.controlClient.ListPublishFailures(limit)
+}
+
+// recordPublishFailure adds a task QStash rejected to the dead-letter list
+func (m *MemoryService) recordPublishFailure(operation string, taskType string, destination string, tenantID string, subject string, cause error) {
+	failure := &models.PublishFailure{
+		Operation:   operation,
+		TaskType:    taskType,
+		Destination: destination,
+		TenantID:    tenantID,
+		Subject:     subject,
+		Error:       cause.Error(),
+		FailedAt:    time.Now(),
+	}
+	if err := m.controlClient.RecordPublishFailure(failure); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// messageJob returns the job run by a QStash message, or nil if none is known
+func (m *MemoryService) messageJob(messageID string) *models.Job {
+	jobID, err := m.controlClient.GetMessageJobID(messageID)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return nil
+	}
+	if jobID == "" {
+		return nil
+	}
+
+	job, err := m.controlClient.GetJob(jobID)
+	if err != nil {
+		// The job record expired before the link did
+		return nil
+	}
+	return job
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// DiffMemories reports the memories a user gained, had superseded and lost to their TTL
+// between from and to. A memory counts as superseded when a summary or extracted memory
+// naming it as a parent was created in the window. Memories deleted outright, by cleanup
+// or consolidation, leave nothing to report and are not included.
+func (m *MemoryService) DiffMemories(userID string, from time.Time, to time.Time) (*models.MemoryDiff, error) {
+	matches, err := m.vectorClient.ListUserMemories(userID, expiryScanLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memories: %w", err)
+	}
+
+	now := time.Now()
+	inWindow := func(t time.Time) bool {
+		return !t.Before(from) && t.Before(to)
+	}
+
+	diff := &models.MemoryDiff{
+		UserID:     userID,
+		From:       from,
+		To:         to,
+		Added:      []models.DiffMemory{},
+		Superseded: []models.SupersededMemory{},
+		Expired:    []models.DiffMemory{},
+	}
+
+	memories := make(map[string]*models.MemoryEntry, len(matches))
+	for _, match := range matches {
+		memories[match.ID] = memoryFromMetadata(match.ID, match.Metadata)
+	}
+
+	superseded := make(map[string]bool)
+	for _, match := range matches {
+		memory := memories[match.ID]
+		entry := models.DiffMemory{
+			ID:        memory.ID,
+			Content:   memory.Content,
+			Origin:    memoryOrigin(match.Metadata),
+			CreatedAt: memory.Timestamp,
+		}
+		if memory.TTL > 0 {
+			expiresAt := memory.Timestamp.Add(time.Duration(memory.TTL) * time.Second)
+			entry.ExpiresAt = &expiresAt
+			// Expired memories linger in the index until the next cleanup removes them
+			if inWindow(expiresAt) && !expiresAt.After(now) {
+				diff.Expired = append(diff.Expired, entry)
+			}
+		}
+		if !inWindow(memory.Timestamp) {
+			continue
+		}
+		diff.Added = append(diff.Added, entry)
+
+		for _, parentID := range metadataStrings(match.Metadata["parent_ids"]) {
+			if superseded[parentID] {
+				continue
+			}
+			superseded[parentID] = true
+
+			replaced := models.SupersededMemory{
+				ID:           parentID,
+				SupersededBy: memory.ID,
+				SupersededAt: memory.Timestamp,
+			}
+			if parent, ok := memories[parentID]; ok {
+				replaced.Content = parent.Content
+			} else {
+				replaced.Missing = true
+			}
+			diff.Superseded = append(diff.Superseded, replaced)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool {
+		return diff.Added[i].CreatedAt.Before(diff.Added[j].CreatedAt)
+	})
+	sort.Slice(diff.Superseded, func(i, j int) bool {
+		return diff.Superseded[i].SupersededAt.Before(diff.Superseded[j].SupersededAt)
+	})
+	sort.Slice(diff.Expired, func(i, j int) bool {
+		return diff.Expired[i].ExpiresAt.Before(*diff.Expired[j].ExpiresAt)
+	})
+
+	return diff, nil
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrInvalidDigest is returned when a digest subscription cannot be delivered as requested
+var ErrInvalidDigest = errors.New("invalid digest subscription")
+
+const (
+	// defaultDigestCron sends digests on Mondays at 9 AM
+	defaultDigestCron = "0 9 * * 1"
+	// defaultDigestPeriod is covered by a user's first digest
+	defaultDigestPeriod = 7 * 24 * time.Hour
+	// digestSentences is how many sentences a digest summary keeps
+	digestSentences = 8
+)
+
+// SubscribeDigest schedules a recurring digest of the user's new memories, replacing any
+// existing subscription
+func (m *MemoryService) SubscribeDigest(userID string, req models.DigestSubscriptionRequest) (*models.DigestSubscription, error) {
+	if !m.SchedulerAvailable() {
+		return nil, ErrSchedulerUnavailable
+	}
+	if err := validateCallbackURL(req.CallbackURL); err != nil {
+		return nil, err
+	}
+	if req.WebhookURL == "" && req.Email == "" {
+		return nil, fmt.Errorf("%w: webhook_url or email is required", ErrInvalidDigest)
+	}
+	if req.WebhookURL != "" {
+		if err := clients.CheckOutboundURL(req.WebhookURL); err != nil {
+			return nil, fmt.Errorf("%w: webhook_url: %v", ErrInvalidDigest, err)
+		}
+	}
+	if req.Email != "" {
+		if config.AppConfig.SMTPHost == "" {
+			return nil, fmt.Errorf("%w: email delivery needs SMTP_HOST", ErrInvalidDigest)
+		}
+		if _, err := mail.ParseAddress(req.Email); err != nil {
+			return nil, fmt.Errorf("%w: invalid email %q", ErrInvalidDigest, req.Email)
+		}
+	}
+
+	cron := strings.TrimSpace(req.Cron)
+	if cron == "" {
+		cron = defaultDigestCron
+	}
+	if err := validateCron(cron); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDigest, err)
+	}
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidDigest, timezone)
+	}
+
+	tenantID := req.TenantID
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	m = m.ForTenant(tenantID)
+
+	previous, err := m.redisClient.GetDigestSubscription(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	task := models.CleanupTask{
+		TaskType:  "send_memory_digest",
+		TenantID:  tenantID,
+		UserID:    userID,
+		Timestamp: time.Now(),
+	}
+	scheduleID, err := m.qstashClient.ScheduleTask(req.CallbackURL, fmt.Sprintf("CRON_TZ=%s %s", timezone, cron), task, models.DeliveryOptions{})
+	if err != nil {
+		m.recordPublishFailure("schedule", task.TaskType, req.CallbackURL, tenantID, userID, err)
+		return nil, fmt.Errorf("failed to schedule digest: %w", err)
+	}
+
+	subscription := &models.DigestSubscription{
+		UserID:     userID,
+		TenantID:   tenantID,
+		Cron:       cron,
+		Timezone:   timezone,
+		WebhookURL: req.WebhookURL,
+		Email:      req.Email,
+		ScheduleID: scheduleID,
+		CreatedAt:  time.Now(),
+	}
+	if previous != nil {
+		subscription.LastSentAt = previous.LastSentAt
+		if err := m.qstashClient.CancelSchedule(previous.ScheduleID); err != nil {
+			fmt.Printf("Warning: failed to cancel previous digest schedule %s: %v\n", previous.ScheduleID, err)
+		}
+	}
+	if err := m.redisClient.SaveDigestSubscription(subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// GetDigestSubscription returns a user's digest subscription, or nil if there is none
+func (m *MemoryService) GetDigestSubscription(userID string) (*models.DigestSubscription, error) {
+	return m.redisClient.GetDigestSubscription(userID)
+}
+
+// UnsubscribeDigest cancels a user's digest schedule and forgets the subscription,
+// reporting whether there was one
+func (m *MemoryService) UnsubscribeDigest(userID string, tenantID string) (bool, error) {
+	m = m.ForTenant(tenantID)
+
+	subscription, err := m.redisClient.GetDigestSubscription(userID)
+	if err != nil || subscription == nil {
+		return false, err
+	}
+	if m.SchedulerAvailable() {
+		if err := m.qstashClient.CancelSchedule(subscription.ScheduleID); err != nil {
+			return false, fmt.Errorf("failed to cancel digest schedule: %w", err)
+		}
+	}
+	return true, m.redisClient.DeleteDigestSubscription(userID)
+}
+
+// SendMemoryDigest summarises the memories a user stored since the last digest and
+// delivers the summary to the subscription's webhook and email. Nothing is delivered
+// when there are no new memories.
+func (m *MemoryService) SendMemoryDigest(userID string, tenantID string) (*models.MemoryDigest, error) {
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	m = m.ForTenant(tenantID)
+
+	subscription, err := m.redisClient.GetDigestSubscription(userID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil {
+		return nil, fmt.Errorf("%w: user %s has no digest subscription", ErrInvalidDigest, userID)
+	}
+
+	now := time.Now()
+	digest := &models.MemoryDigest{
+		UserID:      userID,
+		TenantID:    tenantID,
+		PeriodStart: now.Add(-defaultDigestPeriod),
+		PeriodEnd:   now,
+		Delivered:   []string{},
+	}
+	if subscription.LastSentAt != nil {
+		digest.PeriodStart = *subscription.LastSentAt
+	}
+
+	matches, err := m.vectorClient.ListUserMemories(userID, 10000)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user memories: %w", err)
+	}
+	var memories []*models.MemoryEntry
+	for _, match := range matches {
+		if metadataTenant(match.Metadata) != tenantID || isSummary(match.Metadata) || isTaskMemory(match.Metadata) || isInstruction(match.Metadata) || isQuarantined(match.Metadata) {
+			continue
+		}
+		memory := memoryFromMetadata(match.ID, match.Metadata)
+		if memory.Timestamp.After(digest.PeriodStart) && !memory.Timestamp.After(now) {
+			memories = append(memories, memory)
+		}
+	}
+	sort.Slice(memories, func(i, j int) bool {
+		return memories[i].Timestamp.Before(memories[j].Timestamp)
+	})
+
+	digest.MemoryCount = len(memories)
+	if len(memories) == 0 {
+		return digest, nil
+	}
+	texts := make([]string, len(memories))
+	for i, memory := range memories {
+		texts[i] = memory.Content
+	}
+	digest.Summary = m.summarize(texts, digestSentences, "period")
+
+	var failures []string
+	if subscription.WebhookURL != "" {
+		payload := map[string]interface{}{
+			"event":  "memories.digest",
+			"digest": digest,
+		}
+		if err := m.notifier.Send(subscription.WebhookURL, tenantID, payload); err != nil {
+			failures = append(failures, err.Error())
+		} else {
+			digest.Delivered = append(digest.Delivered, "webhook")
+		}
+	}
+	if subscription.Email != "" {
+		if err := m.mailer.Send(subscription.Email, "What your assistant learned", digestEmailBody(digest)); err != nil {
+			failures = append(failures, err.Error())
+		} else {
+			digest.Delivered = append(digest.Delivered, "email")
+		}
+	}
+	if len(digest.Delivered) == 0 {
+		return digest, fmt.Errorf("failed to deliver digest: %s", strings.Join(failures, "; "))
+	}
+	for _, failure := range failures {
+		fmt.Printf("Warning: partial digest delivery for user %s: %s\n", userID, failure)
+	}
+
+	subscription.LastSentAt = &now
+	if err := m.redisClient.SaveDigestSubscription(subscription); err != nil {
+		return digest, err
+	}
+	return digest, nil
+}
+
+func digestEmailBody(digest *models.MemoryDigest) string {
+	return fmt.Sprintf("Between %s and %s your assistant stored %d new memories.\n\n%s\n",
+		digest.PeriodStart.Format("Jan 2, 2006"), digest.PeriodEnd.Format("Jan 2, 2006"), digest.MemoryCount, digest.Summary)
+}
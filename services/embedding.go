@@ -0,0 +1,42 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrInvalidEmbedding is returned for client-supplied embeddings that do not fit the index
+var ErrInvalidEmbedding = errors.New("invalid embedding")
+
+// checkEmbedding verifies that a client-supplied embedding has the index dimension
+func (m *MemoryService) checkEmbedding(embedding []float64) error {
+	dimensions, err := m.vectorClient.GetDimensions()
+	if err != nil {
+		dimensions = config.GetEmbeddingDimensions()
+	}
+	if len(embedding) != dimensions {
+		return fmt.Errorf("%w: got %d dimensions, the index has %d", ErrInvalidEmbedding, len(embedding), dimensions)
+	}
+	return nil
+}
+
+// queryEmbedding returns the embedding a query is searched with: the one supplied with the
+// request, or a new one charged to the tenant's embedding budget
+func (m *MemoryService) queryEmbedding(tenantID string, req models.QueryMemoryRequest) ([]float64, error) {
+	if len(req.Embedding) > 0 {
+		if err := m.checkEmbedding(req.Embedding); err != nil {
+			return nil, err
+		}
+		return req.Embedding, nil
+	}
+
+	embedding, err := m.embeddingClient.GenerateEmbedding(req.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	m.recordEmbeddingUsage(tenantID, EstimateTokens(req.Query))
+	return embedding, nil
+}
@@ -0,0 +1,517 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+const (
+	// erasureMaxPasses bounds how often a store is re-deleted before giving up
+	erasureMaxPasses = 3
+	// erasurePassDelay gives eventually consistent stores time to settle between passes
+	erasurePassDelay = 2 * time.Second
+)
+
+// erasureStore is one place where user data lives
+type erasureStore struct {
+	name      string
+	remove    func() (int, error)
+	remaining func() (int, error)
+}
+
+// erasureScope is the set of a user's memories an erasure may touch
+type erasureScope struct {
+	policy       *models.RetentionPolicy
+	erasable     map[string]bool
+	retained     map[string]bool
+	coldRetained map[string]bool // cold tier memories kept for minimum retention
+	sessionKeys  []string        // captured up front since deleting the index set hides them
+	aliasKeys    []string        // likewise for the user's aliases
+}
+
+// EraseUser starts a tracked right-to-be-forgotten job for a user. Every store
+// holding user data is deleted and re-checked until it is verified empty, and a
+// signed completion report is stored under the job ID.
+func (m *MemoryService) EraseUser(userID string, tenantID string) (*models.Job, error) {
+	m = m.ForTenant(tenantID)
+
+	policy, err := m.retention.Get(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLegalHold(policy); err != nil {
+		return nil, err
+	}
+
+	return m.startJob("user_erasure", tenantID, userID, func(m *MemoryService, job *models.Job) error {
+		report := &models.ErasureReport{
+			JobID:     job.ID,
+			UserID:    userID,
+			TenantID:  tenantID,
+			StartedAt: time.Now(),
+		}
+
+		err := m.runErasure(job, report, policy)
+		m.publish(EventMemoryDeleted, tenantID, userID)
+
+		report.CompletedAt = time.Now()
+		m.signErasureReport(report)
+		if saveErr := m.controlClient.SaveErasureReport(report); saveErr != nil && err == nil {
+			err = saveErr
+		}
+		return err
+	})
+}
+
+// GetErasureReport returns the signed report of one of a tenant's erasure jobs, or nil if
+// there is none yet
+func (m *MemoryService) GetErasureReport(tenantID string, jobID string) (*models.ErasureReport, error) {
+	report, err := m.controlClient.GetErasureReport(jobID)
+	if err != nil || report == nil {
+		return report, err
+	}
+	if !jobOfTenant(&models.Job{TenantID: report.TenantID}, tenantID) {
+		return nil, nil
+	}
+	return report, nil
+}
+
+func (m *MemoryService) runErasure(job *models.Job, report *models.ErasureReport, policy *models.RetentionPolicy) error {
+	scope, err := m.erasureScope(report.UserID, policy)
+	if err != nil {
+		return err
+	}
+
+	stores := m.erasureStores(report.UserID, scope)
+	results := make([]models.ErasureStoreResult, len(stores))
+	for i, store := range stores {
+		results[i].Store = store.name
+	}
+	// Memories under minimum retention are kept and reported, not erased
+	for i := range results {
+		if results[i].Store == "cold_memories" {
+			results[i].Retained = len(scope.coldRetained)
+		}
+	}
+
+	for pass := 1; pass <= erasureMaxPasses; pass++ {
+		if pass > 1 {
+			time.Sleep(erasurePassDelay)
+		}
+		report.VerificationPasses = pass
+
+		pending := 0
+		for i, store := range stores {
+			if results[i].Verified {
+				continue
+			}
+
+			deleted, err := store.remove()
+			results[i].Deleted += deleted
+			if err != nil {
+				results[i].Error = err.Error()
+				pending++
+				continue
+			}
+
+			remaining, err := store.remaining()
+			if err != nil {
+				results[i].Error = err.Error()
+				pending++
+				continue
+			}
+
+			results[i].Remaining = remaining
+			results[i].Verified = remaining == 0
+			results[i].Error = ""
+			if !results[i].Verified {
+				pending++
+			}
+		}
+
+		job.Progress["passes"] = pass
+		job.Progress["stores_verified"] = len(stores) - pending
+		job.Progress["stores_total"] = len(stores)
+		m.saveJob(job)
+
+		if pending == 0 {
+			break
+		}
+	}
+
+	// Every pass re-classifies the vector memories, so the count is final only now
+	results[0].Retained = len(scope.retained)
+	report.Stores = results
+	report.Complete = job.Progress["stores_verified"] == len(stores)
+	if !report.Complete {
+		return fmt.Errorf("erasure not verified after %d passes", report.VerificationPasses)
+	}
+	return nil
+}
+
+// erasureScope splits a user's memories into those that may be erased and
+// those a minimum retention policy requires to be kept
+func (m *MemoryService) erasureScope(userID string, policy *models.RetentionPolicy) (*erasureScope, error) {
+	sessionKeys, err := m.userSessionKeys(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate user sessions: %w", err)
+	}
+
+	aliasKeys, err := m.redisClient.UserAliasKeys(policy.TenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate user aliases: %w", err)
+	}
+
+	scope := &erasureScope{policy: policy, erasable: map[string]bool{}, retained: map[string]bool{}, coldRetained: map[string]bool{}, sessionKeys: sessionKeys, aliasKeys: aliasKeys}
+	now := time.Now()
+	if _, err := m.erasableUserMemories(userID, scope, now); err != nil {
+		return nil, fmt.Errorf("failed to enumerate user memories: %w", err)
+	}
+
+	if m.coldStore != nil {
+		coldMemories, err := m.coldStore.ListColdMemories(userID, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to enumerate cold memories: %w", err)
+		}
+		for _, memory := range coldMemories {
+			if checkMinRetention(policy, memory.Timestamp, now) != nil {
+				scope.coldRetained[memory.ID] = true
+			}
+		}
+	}
+
+	// Keyword-only memories are stored together, so they can only be erased as a whole
+	if policy.MinRetentionSeconds > 0 {
+		keywordMemories, err := m.redisClient.GetKeywordMemories(userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to enumerate keyword memories: %w", err)
+		}
+		for _, memory := range keywordMemories {
+			if checkMinRetention(policy, memory.Timestamp, now) != nil {
+				return nil, fmt.Errorf("%w: keyword-only memory %s is within the minimum retention period", ErrRetentionBlocked, memory.ID)
+			}
+		}
+	}
+
+	return scope, nil
+}
+
+// erasableUserMemories pages through every vector memory of a user, classifying each as
+// erasable or retained in scope, and returns the IDs of the erasable ones
+func (m *MemoryService) erasableUserMemories(userID string, scope *erasureScope, now time.Time) ([]string, error) {
+	var ids []string
+	err := m.forEachUserMemoryPage(userID, func(matches []clients.QueryMatch) error {
+		for _, match := range matches {
+			if timestampFloat, ok := match.Metadata["timestamp"].(float64); ok {
+				if checkMinRetention(scope.policy, time.Unix(int64(timestampFloat), 0), now) != nil {
+					scope.retained[match.ID] = true
+					continue
+				}
+			}
+			delete(scope.retained, match.ID)
+			scope.erasable[match.ID] = true
+			ids = append(ids, match.ID)
+		}
+		return nil
+	})
+	return ids, err
+}
+
+// erasureStores lists every store holding data for a user. The vector store must come first.
+func (m *MemoryService) erasureStores(userID string, scope *erasureScope) []erasureStore {
+	stores := []erasureStore{
+		{
+			name: "vector_memories",
+			// Every memory of the user is classified again on each pass, so memories
+			// saved since the scope was taken are erased too; deletion waits for the
+			// scan to finish so it cannot shift the pages still to be read
+			remove: func() (int, error) {
+				ids, err := m.erasableUserMemories(userID, scope, time.Now())
+				if err != nil {
+					return 0, fmt.Errorf("failed to list user memories: %w", err)
+				}
+				if err := m.vectorClient.DeleteMemories(ids); err != nil {
+					return 0, err
+				}
+				return len(ids), nil
+			},
+			remaining: func() (int, error) {
+				ids, err := m.erasableUserMemories(userID, scope, time.Now())
+				if err != nil {
+					return 0, fmt.Errorf("failed to list user memories: %w", err)
+				}
+				return len(ids), nil
+			},
+		},
+		{
+			// Deleting the vectors drops their full content too; this verifies it and
+			// catches content left behind by an earlier failed deletion
+			name: "memory_contents",
+			remove: func() (int, error) {
+				count, err := m.countExistingKeys(memoryContentKeys(scope.erasable))
+				if err != nil {
+					return 0, err
+				}
+				ids := make([]string, 0, len(scope.erasable))
+				for id := range scope.erasable {
+					ids = append(ids, id)
+				}
+				return count, m.contentStore.DeleteMemoryContents(ids...)
+			},
+			remaining: func() (int, error) {
+				return m.countExistingKeys(memoryContentKeys(scope.erasable))
+			},
+		},
+		{
+			name: "keyword_memories",
+			remove: func() (int, error) {
+				return m.redisClient.DeleteKeys(fmt.Sprintf("keyword_memories:%s", userID))
+			},
+			remaining: func() (int, error) {
+				return m.countExistingKeys([]string{fmt.Sprintf("keyword_memories:%s", userID)})
+			},
+		},
+		{
+			name: "profile",
+			remove: func() (int, error) {
+				return m.redisClient.DeleteKeys(fmt.Sprintf("user_profile:%s", userID))
+			},
+			remaining: func() (int, error) {
+				return m.countExistingKeys([]string{fmt.Sprintf("user_profile:%s", userID)})
+			},
+		},
+		{
+			name: "digest_subscription",
+			remove: func() (int, error) {
+				// Stop the schedule too, or it would keep delivering tasks for the erased user
+				subscription, err := m.redisClient.GetDigestSubscription(userID)
+				if err != nil {
+					return 0, err
+				}
+				if subscription != nil && m.SchedulerAvailable() {
+					if err := m.qstashClient.CancelSchedule(subscription.ScheduleID); err != nil {
+						fmt.Printf("Warning: failed to cancel digest schedule %s: %v\n", subscription.ScheduleID, err)
+					}
+				}
+				return m.redisClient.DeleteKeys(fmt.Sprintf("digest_subscription:%s", userID))
+			},
+			remaining: func() (int, error) {
+				return m.countExistingKeys([]string{fmt.Sprintf("digest_subscription:%s", userID)})
+			},
+		},
+		{
+			name: "preferences",
+			remove: func() (int, error) {
+				return m.redisClient.DeleteKeys(fmt.Sprintf("user_preferences:%s", userID))
+			},
+			remaining: func() (int, error) {
+				return m.countExistingKeys([]string{fmt.Sprintf("user_preferences:%s", userID)})
+			},
+		},
+		{
+			name: "facts",
+			remove: func() (int, error) {
+				return m.redisClient.DeleteKeys(fmt.Sprintf("user_facts:%s", userID))
+			},
+			remaining: func() (int, error) {
+				return m.countExistingKeys([]string{fmt.Sprintf("user_facts:%s", userID)})
+			},
+		},
+		{
+			name: "standing_queries",
+			remove: func() (int, error) {
+				return m.redisClient.DeleteKeys(fmt.Sprintf("standing_queries:%s", userID))
+			},
+			remaining: func() (int, error) {
+				return m.countExistingKeys([]string{fmt.Sprintf("standing_queries:%s", userID)})
+			},
+		},
+		{
+			name: "aliases",
+			remove: func() (int, error) {
+				return m.redisClient.DeleteKeys(scope.aliasKeys...)
+			},
+			remaining: func() (int, error) {
+				return m.countExistingKeys(scope.aliasKeys)
+			},
+		},
+		{
+			name: "retrievals",
+			remove: func() (int, error) {
+				return m.redisClient.DeleteKeys(fmt.Sprintf("memory_retrievals:%s", userID))
+			},
+			remaining: func() (int, error) {
+				return m.countExistingKeys([]string{fmt.Sprintf("memory_retrievals:%s", userID)})
+			},
+		},
+		{
+			name: "sessions",
+			remove: func() (int, error) {
+				return m.redisClient.DeleteKeys(scope.sessionKeys...)
+			},
+			remaining: func() (int, error) {
+				return m.countExistingKeys(scope.sessionKeys)
+			},
+		},
+		{
+			name: "expiry_notices",
+			remove: func() (int, error) {
+				return m.redisClient.DeleteKeys(expiryNoticeKeys(scope.erasable)...)
+			},
+			remaining: func() (int, error) {
+				return m.countExistingKeys(expiryNoticeKeys(scope.erasable))
+			},
+		},
+	}
+
+	if m.coldStore != nil {
+		stores = append(stores, erasureStore{
+			name: "cold_memories",
+			remove: func() (int, error) {
+				ids, err := m.erasableColdMemories(userID, scope)
+				if err != nil {
+					return 0, err
+				}
+				return len(ids), m.coldStore.DeleteColdMemories(userID, ids...)
+			},
+			remaining: func() (int, error) {
+				ids, err := m.erasableColdMemories(userID, scope)
+				return len(ids), err
+			},
+		})
+	}
+
+	if config.AppConfig.RedisSearchEnabled {
+		stores = append(stores, erasureStore{
+			name: "search_index",
+			remove: func() (int, error) {
+				keys, err := m.erasableIndexKeys(userID, scope)
+				if err != nil {
+					return 0, err
+				}
+				return m.redisClient.DeleteKeys(keys...)
+			},
+			remaining: func() (int, error) {
+				keys, err := m.erasableIndexKeys(userID, scope)
+				return len(keys), err
+			},
+		})
+	}
+
+	return stores
+}
+
+// userSessionKeys returns the live and archived session keys of a user, including
+// the sets indexing them and the message lists and summaries of live sessions
+func (m *MemoryService) userSessionKeys(userID string) ([]string, error) {
+	sessions, err := m.redisClient.GetUserSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+	archived, err := m.redisClient.GetArchivedSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, 3*len(sessions)+len(archived)+2)
+	for _, sessionID := range sessions {
+		keys = append(keys, fmt.Sprintf("session:%s", sessionID), fmt.Sprintf("session_messages:%s", sessionID), fmt.Sprintf("session_summary:%s", sessionID))
+	}
+	for _, sessionID := range archived {
+		keys = append(keys, fmt.Sprintf("session_archive:%s", sessionID))
+	}
+	return append(keys, fmt.Sprintf("user_sessions:%s", userID), fmt.Sprintf("user_session_archive:%s", userID)), nil
+}
+
+// erasableIndexKeys returns the user's search index entries that are not retained by policy
+func (m *MemoryService) erasableIndexKeys(userID string, scope *erasureScope) ([]string, error) {
+	keys, err := m.redisClient.ListUserIndexedKeys(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	erasable := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !scope.retained[key[len("memory:"):]] {
+			erasable = append(erasable, key)
+		}
+	}
+	return erasable, nil
+}
+
+// erasableColdMemories returns the IDs of the user's cold tier memories not retained by policy
+func (m *MemoryService) erasableColdMemories(userID string, scope *erasureScope) ([]string, error) {
+	memories, err := m.coldStore.ListColdMemories(userID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, memory := range memories {
+		if !scope.coldRetained[memory.ID] {
+			ids = append(ids, memory.ID)
+		}
+	}
+	return ids, nil
+}
+
+// countExistingKeys counts how many of keys are still present
+func (m *MemoryService) countExistingKeys(keys []string) (int, error) {
+	count := 0
+	for _, key := range keys {
+		exists, err := m.redisClient.KeyExists(key)
+		if err != nil {
+			return 0, err
+		}
+		if exists {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func expiryNoticeKeys(memoryIDs map[string]bool) []string {
+	keys := make([]string, 0, len(memoryIDs))
+	for id := range memoryIDs {
+		keys = append(keys, fmt.Sprintf("expiry_notified:%s", id))
+	}
+	return keys
+}
+
+// memoryContentKeys returns the keys that may hold the full content of memories or their
+// blob references; blobs are deleted with their last reference
+func memoryContentKeys(memoryIDs map[string]bool) []string {
+	keys := make([]string, 0, 2*len(memoryIDs))
+	for id := range memoryIDs {
+		keys = append(keys, fmt.Sprintf("memory_content:%s", id), fmt.Sprintf("blob_ref:%s", id))
+	}
+	return keys
+}
+
+// signErasureReport signs the report with HMAC-SHA256 when a signing key is configured
+func (m *MemoryService) signErasureReport(report *models.ErasureReport) {
+	report.Signature = ""
+	if config.AppConfig.ErasureSigningKey == "" {
+		report.SignatureAlgorithm = "none"
+		return
+	}
+	report.SignatureAlgorithm = "HMAC-SHA256"
+
+	payload, err := json.Marshal(report)
+	if err != nil {
+		fmt.Printf("Warning: failed to sign erasure report %s: %v\n", report.JobID, err)
+		return
+	}
+
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.ErasureSigningKey))
+	mac.Write(payload)
+	report.Signature = hex.EncodeToString(mac.Sum(nil))
+}
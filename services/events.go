@@ -0,0 +1,92 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// Memory events published on the service's event bus once a write has succeeded
+const (
+	EventMemorySaved   = "memory.saved"
+	EventMemoryUpdated = "memory.updated"
+	EventMemoryDeleted = "memory.deleted"
+	EventMemoryTiered  = "memory.tiered" // moved to the cold tier, found only by deep recall
+
+	// EventAny subscribes a handler to every event type
+	EventAny = "*"
+)
+
+// MemoryEvent describes a change to a user's memories
+type MemoryEvent struct {
+	Type      string
+	TenantID  string
+	UserID    string
+	MemoryIDs []string // empty when the change is not limited to known memories
+	At        time.Time
+}
+
+// EventBus delivers memory events to in-process subscribers. Handlers run synchronously
+// in subscription order, so a write's caches are invalidated before it returns.
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers map[string][]func(MemoryEvent)
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{handlers: make(map[string][]func(MemoryEvent))}
+}
+
+// Subscribe registers a handler for one event type, or for all of them with EventAny
+func (b *EventBus) Subscribe(eventType string, handler func(MemoryEvent)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish delivers an event to its subscribers. A panicking handler is logged and
+// skipped so it cannot fail the write that published the event.
+func (b *EventBus) Publish(event MemoryEvent) {
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := append(append([]func(MemoryEvent){}, b.handlers[event.Type]...), b.handlers[EventAny]...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					fmt.Printf("Warning: %s event handler panicked: %v\n", event.Type, r)
+				}
+			}()
+			handler(event)
+		}()
+	}
+}
+
+// publish announces a change to a user's memories
+func (m *MemoryService) publish(eventType string, tenantID string, userID string, memoryIDs ...string) {
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	m.events.Publish(MemoryEvent{
+		Type:      eventType,
+		TenantID:  tenantID,
+		UserID:    userID,
+		MemoryIDs: memoryIDs,
+	})
+}
+
+// invalidateUserCaches drops what was derived from a user's memories before they changed:
+// cached query results and the stored profile, which is recomputed on its next read
+func (m *MemoryService) invalidateUserCaches(event MemoryEvent) {
+	m.queryCache.invalidate(event.TenantID, event.UserID)
+	if err := m.ForTenant(event.TenantID).redisClient.DeleteUserProfile(event.UserID); err != nil {
+		fmt.Printf("Warning: failed to invalidate profile of user %s: %v\n", event.UserID, err)
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// expiryScanLimit bounds how many memories a single expiry scan inspects
+const expiryScanLimit = 10000
+
+// GetExpiringMemories lists a user's memories that expire within the given window, soonest first
+func (m *MemoryService) GetExpiringMemories(userID string, within time.Duration) ([]models.ExpiringMemory, error) {
+	matches, err := m.vectorClient.ListUserMemories(userID, expiryScanLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memories: %w", err)
+	}
+
+	return expiringWithin(matches, time.Now(), within), nil
+}
+
+// NotifyExpiringMemories posts a memories.expiring event per user to the configured
+// webhook. Each memory is announced once: a memory whose notification fails is announced
+// again by the next run.
+func (m *MemoryService) NotifyExpiringMemories() error {
+	if config.AppConfig.ExpiryWebhookURL == "" {
+		return fmt.Errorf("expiry notifications are not configured")
+	}
+	return m.forEachRegion((*MemoryService).notifyExpiringMemories)
+}
+
+func (m *MemoryService) notifyExpiringMemories() error {
+	matches, err := m.vectorClient.ListAllMemories(expiryScanLimit)
+	if err != nil {
+		return fmt.Errorf("failed to list memories: %w", err)
+	}
+
+	now := time.Now()
+	// Notifications are signed per tenant, so users are grouped within their tenant
+	type recipient struct{ tenantID, userID string }
+	byUser := make(map[recipient][]models.ExpiringMemory)
+	for _, memory := range expiringWithin(matches, now, config.AppConfig.ExpiryNoticeWindow) {
+		// Claim the notification until the memory is gone, so concurrent runs skip it;
+		// the claim is released below if the webhook fails
+		key := fmt.Sprintf("expiry_notified:%s", memory.ID)
+		first, err := m.redisClient.SetIfAbsent(key, "1", memory.ExpiresInSeconds+1)
+		if err != nil {
+			return fmt.Errorf("failed to record expiry notification: %w", err)
+		}
+		if first {
+			to := recipient{memory.TenantID, memory.UserID}
+			byUser[to] = append(byUser[to], memory)
+		}
+	}
+
+	for to, memories := range byUser {
+		payload := map[string]interface{}{
+			"event":     "memories.expiring",
+			"user_id":   to.userID,
+			"memories":  memories,
+			"timestamp": now,
+		}
+		if to.tenantID != "" {
+			payload["tenant_id"] = to.tenantID
+		}
+		if err := m.notifier.Send(config.AppConfig.ExpiryWebhookURL, to.tenantID, payload); err != nil {
+			fmt.Printf("Warning: failed to notify user %s about expiring memories: %v\n", to.userID, err)
+			keys := make([]string, len(memories))
+			for i, memory := range memories {
+				keys[i] = fmt.Sprintf("expiry_notified:%s", memory.ID)
+			}
+			if _, err := m.redisClient.DeleteKeys(keys...); err != nil {
+				fmt.Printf("Warning: failed to release expiry notifications of user %s: %v\n", to.userID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// expiringWithin converts vector matches into expiring memories within the window
+func expiringWithin(matches []clients.QueryMatch, now time.Time, within time.Duration) []models.ExpiringMemory {
+	deadline := now.Add(within)
+
+	memories := make([]models.ExpiringMemory, 0)
+	for _, match := range matches {
+		timestampFloat, ok := match.Metadata["timestamp"].(float64)
+		if !ok {
+			continue
+		}
+		ttlFloat, ok := match.Metadata["ttl"].(float64)
+		if !ok || ttlFloat <= 0 {
+			continue
+		}
+
+		createdAt := time.Unix(int64(timestampFloat), 0)
+		expiresAt := createdAt.Add(time.Duration(ttlFloat) * time.Second)
+		if expiresAt.Before(now) || expiresAt.After(deadline) {
+			continue
+		}
+
+		userID, _ := match.Metadata["user_id"].(string)
+		tenantID, _ := match.Metadata["tenant_id"].(string)
+		content, _ := match.Metadata["content"].(string)
+		memories = append(memories, models.ExpiringMemory{
+			ID:               match.ID,
+			UserID:           userID,
+			TenantID:         tenantID,
+			Content:          content,
+			CreatedAt:        createdAt,
+			ExpiresAt:        expiresAt,
+			ExpiresInSeconds: int64(expiresAt.Sub(now).Seconds()),
+		})
+	}
+
+	sort.Slice(memories, func(i, j int) bool {
+		return memories[i].ExpiresAt.Before(memories[j].ExpiresAt)
+	})
+
+	return memories
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ExportUserMemories streams every memory of a user to emit one page at a time, so
+// exports of users with tens of thousands of memories never sit in memory at once.
+// emit's error aborts the export, e.g. when the client disconnects.
+func (m *MemoryService) ExportUserMemories(userID string, tenantID string, emit func([]models.ExportedMemory) error) error {
+	m = m.ForTenant(tenantID)
+
+	err := m.forEachUserMemoryPage(userID, func(matches []clients.QueryMatch) error {
+		page := make([]models.ExportedMemory, 0, len(matches))
+		for _, match := range matches {
+			page = append(page, exportedMemory(memoryFromMetadata(match.ID, match.Metadata), "vector"))
+		}
+		return emit(page)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Keyword-only memories have no vector and live in Redis
+	keywordMemories, err := m.redisClient.GetKeywordMemories(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get keyword memories: %w", err)
+	}
+	if len(keywordMemories) == 0 {
+		return nil
+	}
+
+	page := make([]models.ExportedMemory, 0, len(keywordMemories))
+	for i := range keywordMemories {
+		page = append(page, exportedMemory(&keywordMemories[i], "keyword_only"))
+	}
+	return emit(page)
+}
+
+// forEachUserMemoryPage calls fn with every page of a user's memories, ranging over the
+// whole vector store so no listing cap applies. Pages without memories of the user are
+// skipped, and fn's error stops the scan. Memories saved during the scan may be missed.
+func (m *MemoryService) forEachUserMemoryPage(userID string, fn func([]clients.QueryMatch) error) error {
+	cursor := "0"
+	for {
+		matches, next, err := m.vectorClient.RangeMemories(cursor, patchPageSize)
+		if err != nil {
+			return fmt.Errorf("failed to range memories: %w", err)
+		}
+
+		page := make([]clients.QueryMatch, 0, len(matches))
+		for _, match := range matches {
+			if owner, _ := match.Metadata["user_id"].(string); owner == userID {
+				page = append(page, match)
+			}
+		}
+		if len(page) > 0 {
+			if err := fn(page); err != nil {
+				return err
+			}
+		}
+
+		if next == "" || next == cursor {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+func exportedMemory(memory *models.MemoryEntry, source string) models.ExportedMemory {
+	return models.ExportedMemory{
+		ID:        memory.ID,
+		UserID:    memory.UserID,
+		Content:   memory.Content,
+		Metadata:  memory.Metadata,
+		Timestamp: memory.Timestamp,
+		TTL:       memory.TTL,
+		Source:    source,
+	}
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Fairy-nn/MemoryCacheAI/metrics"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrInvalidFact is returned for malformed fact keys, values and aliases, and for users
+// already holding maxUserFacts facts
+var ErrInvalidFact = errors.New("invalid fact")
+
+const (
+	// maxUserFacts bounds the facts of one user, all of which every query reads
+	maxUserFacts = 200
+	// maxFactKeyLength bounds the length of a fact key and of each alias
+	maxFactKeyLength = 64
+	// maxFactValueLength bounds the length of a fact value in characters
+	maxFactValueLength = 2000
+	// maxFactAliases bounds the aliases of one fact
+	maxFactAliases = 10
+)
+
+// normalizeFactKey lowercases and trims a fact key. Keys accept letters, digits, '_', '-'
+// and '.', which also separate the words a query names the fact by.
+func normalizeFactKey(key string) (string, error) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	if key == "" {
+		return "", fmt.Errorf("%w: key is required", ErrInvalidFact)
+	}
+	if len(key) > maxFactKeyLength {
+		return "", fmt.Errorf("%w: key %q is longer than %d characters", ErrInvalidFact, key, maxFactKeyLength)
+	}
+	for _, r := range key {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) && !strings.ContainsRune("_-.", r) {
+			return "", fmt.Errorf("%w: key %q may only contain letters, digits, '_', '-' and '.'", ErrInvalidFact, key)
+		}
+	}
+	if len(factWords(key)) == 0 {
+		return "", fmt.Errorf("%w: key %q has no letters or digits", ErrInvalidFact, key)
+	}
+	return key, nil
+}
+
+// normalizeFactAliases lowercases aliases and collapses their spacing, dropping empty and
+// repeated ones
+func normalizeFactAliases(aliases []string) ([]string, error) {
+	seen := make(map[string]bool, len(aliases))
+	normalized := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		alias = normalizeContent(alias)
+		if alias == "" || seen[alias] {
+			continue
+		}
+		if len(alias) > maxFactKeyLength {
+			return nil, fmt.Errorf("%w: alias %q is longer than %d characters", ErrInvalidFact, alias, maxFactKeyLength)
+		}
+		if len(factWords(alias)) == 0 {
+			return nil, fmt.Errorf("%w: alias %q has no letters or digits", ErrInvalidFact, alias)
+		}
+		seen[alias] = true
+		normalized = append(normalized, alias)
+	}
+	if len(normalized) > maxFactAliases {
+		return nil, fmt.Errorf("%w: at most %d aliases are allowed, got %d", ErrInvalidFact, maxFactAliases, len(normalized))
+	}
+	if len(normalized) == 0 {
+		return nil, nil
+	}
+	return normalized, nil
+}
+
+// GetFact returns a user's fact by key, or nil if it is not set
+func (m *MemoryService) GetFact(userID string, key string) (*models.Fact, error) {
+	key, err := normalizeFactKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return m.redisClient.GetFact(userID, key)
+}
+
+// ListFacts returns all of a user's facts, ordered by key
+func (m *MemoryService) ListFacts(userID string) ([]models.Fact, error) {
+	return m.redisClient.GetFacts(userID)
+}
+
+// SetFact validates and stores a user's fact, replacing any previous value of the key
+func (m *MemoryService) SetFact(userID string, key string, req models.SetFactRequest) (*models.Fact, error) {
+	key, err := normalizeFactKey(key)
+	if err != nil {
+		return nil, err
+	}
+	value := strings.TrimSpace(req.Value)
+	if value == "" {
+		return nil, fmt.Errorf("%w: value is required", ErrInvalidFact)
+	}
+	if utf8.RuneCountInString(value) > maxFactValueLength {
+		return nil, fmt.Errorf("%w: value is longer than %d characters", ErrInvalidFact, maxFactValueLength)
+	}
+	aliases, err := normalizeFactAliases(req.Aliases)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := m.redisClient.GetFact(userID, key)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		count, err := m.redisClient.CountFacts(userID)
+		if err != nil {
+			return nil, err
+		}
+		if count >= maxUserFacts {
+			return nil, fmt.Errorf("%w: user %s already has %d facts", ErrInvalidFact, userID, count)
+		}
+	}
+
+	fact := &models.Fact{
+		UserID:    userID,
+		Key:       key,
+		Value:     value,
+		Aliases:   aliases,
+		UpdatedAt: time.Now(),
+	}
+	if err := m.redisClient.SaveFact(fact); err != nil {
+		return nil, err
+	}
+	return fact, nil
+}
+
+// DeleteFact removes a user's fact, reporting whether it was set
+func (m *MemoryService) DeleteFact(userID string, key string) (bool, error) {
+	key, err := normalizeFactKey(key)
+	if err != nil {
+		return false, err
+	}
+	return m.redisClient.DeleteFact(userID, key)
+}
+
+// factAnswer returns the user's facts a query names by key or alias, so the query can be
+// answered from them without a semantic search. A failed lookup falls back to the search.
+func (m *MemoryService) factAnswer(req models.QueryMemoryRequest) []models.Fact {
+	if req.SkipFacts {
+		return nil
+	}
+	facts, err := m.redisClient.GetFacts(req.UserID)
+	if err != nil {
+		fmt.Printf("Warning: answering query without facts: %v\n", err)
+		return nil
+	}
+	if len(facts) == 0 {
+		return nil
+	}
+
+	matched := matchFacts(req.Query, facts)
+	outcome := "miss"
+	if len(matched) > 0 {
+		outcome = "hit"
+	}
+	metrics.AddCounter("memorycache_fact_lookups_total", "Queries checked against the user's facts, by whether a fact answered them",
+		map[string]string{"outcome": outcome}, 1)
+	return matched
+}
+
+// matchFacts returns the facts whose key or one of whose aliases appears in the query as
+// consecutive words, so "birthday" answers "When is my birthday?" and "home_address"
+// answers "what's my home address" but not "address book at home"
+func matchFacts(query string, facts []models.Fact) []models.Fact {
+	queryWords := factWords(query)
+	var matched []models.Fact
+	for _, fact := range facts {
+		phrases := append([]string{fact.Key}, fact.Aliases...)
+		for _, phrase := range phrases {
+			if containsWords(queryWords, factWords(phrase)) {
+				matched = append(matched, fact)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// factWords splits text into lowercase runs of letters and digits
+func factWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// containsWords reports whether phrase appears in words as a consecutive run
+func containsWords(words []string, phrase []string) bool {
+	if len(phrase) == 0 {
+		return false
+	}
+	for start := 0; start+len(phrase) <= len(words); start++ {
+		found := true
+		for i, word := range phrase {
+			if words[start+i] != word {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
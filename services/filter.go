@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrInvalidContentFilter is returned when a content filter cannot be applied
+var ErrInvalidContentFilter = errors.New("invalid content filter")
+
+const (
+	// maxContentRegexLength bounds user-supplied patterns
+	maxContentRegexLength = 512
+	// contentFilterCandidates is the minimum number of candidates fetched when post-filtering
+	contentFilterCandidates = 100
+)
+
+// contentMatcher applies a ContentFilter to memory content
+type contentMatcher struct {
+	contains string
+	regex    *regexp.Regexp
+}
+
+func newContentMatcher(filter models.ContentFilter) (*contentMatcher, error) {
+	matcher := &contentMatcher{contains: strings.ToLower(filter.ContentContains)}
+
+	if filter.ContentRegex != "" {
+		if len(filter.ContentRegex) > maxContentRegexLength {
+			return nil, fmt.Errorf("%w: content_regex exceeds %d characters", ErrInvalidContentFilter, maxContentRegexLength)
+		}
+		regex, err := regexp.Compile(filter.ContentRegex)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidContentFilter, err)
+		}
+		matcher.regex = regex
+	}
+
+	return matcher, nil
+}
+
+// Matches reports whether content satisfies every configured condition.
+// content_contains is case-insensitive; content_regex is applied as written.
+func (cm *contentMatcher) Matches(content string) bool {
+	if cm.contains != "" && !strings.Contains(strings.ToLower(content), cm.contains) {
+		return false
+	}
+	if cm.regex != nil && !cm.regex.MatchString(content) {
+		return false
+	}
+	return true
+}
+
+// filterByContent keeps results matching the filter, up to limit
+func filterByContent(results []models.MemoryResult, filter models.ContentFilter, limit int) ([]models.MemoryResult, error) {
+	if !filter.Active() {
+		return results, nil
+	}
+
+	matcher, err := newContentMatcher(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]models.MemoryResult, 0, len(results))
+	for _, result := range results {
+		if limit > 0 && len(filtered) >= limit {
+			break
+		}
+		if matcher.Matches(result.Content) {
+			filtered = append(filtered, result)
+		}
+	}
+
+	return filtered, nil
+}
+
+// candidateLimit widens the retrieval window when results will be post-filtered
+func candidateLimit(limit int, filter models.ContentFilter) int {
+	if !filter.Active() {
+		return limit
+	}
+	return widenedLimit(limit)
+}
+
+// widenedLimit is the retrieval window used when results will be post-filtered
+func widenedLimit(limit int) int {
+	if candidates := limit * 5; candidates > contentFilterCandidates {
+		return candidates
+	}
+	return contentFilterCandidates
+}
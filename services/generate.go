@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/Fairy-nn/MemoryCacheAI/tokenizer"
+)
+
+var (
+	// ErrLLMUnavailable is returned by generation features when no LLM provider is configured
+	ErrLLMUnavailable = errors.New("no LLM provider configured")
+	// ErrInvalidChat is returned for chat completion requests that cannot be answered
+	ErrInvalidChat = errors.New("invalid chat completion request")
+)
+
+// LLMAvailable reports whether generation features can call an LLM
+func (m *MemoryService) LLMAvailable() bool {
+	return m.llmClient != nil
+}
+
+// FeatureModel returns the model a feature's requests go to, or "" without an LLM
+func (m *MemoryService) FeatureModel(feature string) string {
+	if m.llmClient == nil {
+		return ""
+	}
+	if model := config.AppConfig.LLMFeatureModels[feature]; model != "" {
+		return model
+	}
+	return m.llmClient.GetModel()
+}
+
+// generate renders a feature's prompt template and sends it to the feature's model
+func (m *MemoryService) generate(feature string, data map[string]interface{}) (*clients.LLMResponse, error) {
+	if m.llmClient == nil {
+		return nil, ErrLLMUnavailable
+	}
+
+	prompt, err := m.templates.Render(feature, data)
+	if err != nil {
+		return nil, err
+	}
+	return m.complete(feature, clients.LLMRequest{Prompt: prompt}, nil)
+}
+
+// complete sends a feature's request to the LLM, streaming text to onDelta when it is
+// set. Requests without a model use the feature's (LLM_FEATURE_MODELS, or the provider
+// default).
+func (m *MemoryService) complete(feature string, req clients.LLMRequest, onDelta func(string) error) (*clients.LLMResponse, error) {
+	if m.llmClient == nil {
+		return nil, ErrLLMUnavailable
+	}
+	if req.Model == "" {
+		req.Model = config.AppConfig.LLMFeatureModels[feature]
+	}
+
+	var response *clients.LLMResponse
+	var err error
+	if onDelta != nil {
+		response, err = m.llmClient.GenerateStream(req, onDelta)
+	} else {
+		response, err = m.llmClient.Generate(req)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s generation failed: %w", feature, err)
+	}
+	return response, nil
+}
+
+// contextFit returns how many of texts, taken in order, fit in LLM_CONTEXT_TOKENS
+func contextFit(texts []string) int {
+	used := 0
+	for i, text := range texts {
+		used += tokenizer.Count(text)
+		if used > config.AppConfig.LLMContextTokens {
+			return i
+		}
+	}
+	return len(texts)
+}
+
+// summarize condenses texts into at most maxSentences sentences, abstractively when an
+// LLM is configured and extractively otherwise or when generation fails. scope names
+// the period being summarised ("day", "week", ...).
+func (m *MemoryService) summarize(texts []string, maxSentences int, scope string) string {
+	if m.llmClient != nil {
+		response, err := m.generate(models.PromptSummarization, map[string]interface{}{
+			"Texts":        texts[:contextFit(texts)],
+			"Granularity":  scope,
+			"MaxSentences": maxSentences,
+		})
+		if err == nil && response.Text != "" {
+			return response.Text
+		}
+		if err != nil {
+			fmt.Printf("Warning: falling back to extractive summary: %v\n", err)
+		}
+	}
+	return summarizeTexts(texts, maxSentences)
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrInvalidQueryMode is returned for query modes other than semantic and hybrid
+var ErrInvalidQueryMode = errors.New("invalid query mode")
+
+// rrfK damps the weight of top ranks in reciprocal rank fusion; 60 is the customary value
+const rrfK = 60
+
+// validateQueryMode rejects unknown query modes
+func validateQueryMode(mode string) error {
+	switch mode {
+	case "", models.QueryModeSemantic, models.QueryModeHybrid:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q (use semantic or hybrid)", ErrInvalidQueryMode, mode)
+	}
+}
+
+// fuseRanks merges a semantic and a keyword ranking with reciprocal rank fusion: each
+// memory scores the sum of 1 / (rrfK + rank) over the rankings it appears in. The
+// semantic result is kept for memories found by both. Returns up to limit results.
+func fuseRanks(semantic []models.MemoryResult, keyword []models.MemoryResult, limit int) []models.MemoryResult {
+	scores := make(map[string]float64, len(semantic)+len(keyword))
+	fused := make([]models.MemoryResult, 0, len(semantic)+len(keyword))
+	for _, ranking := range [][]models.MemoryResult{semantic, keyword} {
+		for rank, result := range ranking {
+			if _, seen := scores[result.ID]; !seen {
+				fused = append(fused, result)
+			}
+			scores[result.ID] += 1 / float64(rrfK+rank+1)
+		}
+	}
+
+	for i := range fused {
+		fused[i].Score = scores[fused[i].ID]
+	}
+	sort.SliceStable(fused, func(i, j int) bool {
+		return fused[i].Score > fused[j].Score
+	})
+	if len(fused) > limit {
+		fused = fused[:limit]
+	}
+	return fused
+}
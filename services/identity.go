@@ -0,0 +1,297 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// maxAliasLength bounds alias values, in bytes
+const maxAliasLength = 256
+
+var (
+	// ErrInvalidAlias is returned for unknown alias kinds and malformed values
+	ErrInvalidAlias = errors.New("invalid alias")
+	// ErrAliasTaken is returned when an alias is already linked to another user
+	ErrAliasTaken = errors.New("alias is linked to another user")
+	// ErrAliasNotFound is returned when unlinking an alias the user does not have
+	ErrAliasNotFound = errors.New("alias not found")
+	// ErrInvalidMerge is returned when a merge names the same or an empty user
+	ErrInvalidMerge = errors.New("invalid user merge")
+)
+
+// normalizeAlias validates an alias and returns its canonical value; emails are
+// compared case-insensitively
+func normalizeAlias(kind string, value string, merged bool) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || len(value) > maxAliasLength {
+		return "", fmt.Errorf("%w: value must be 1-%d bytes", ErrInvalidAlias, maxAliasLength)
+	}
+
+	switch kind {
+	case models.AliasEmail:
+		if !strings.Contains(value, "@") {
+			return "", fmt.Errorf("%w: %q is not an email address", ErrInvalidAlias, value)
+		}
+		return strings.ToLower(value), nil
+	case models.AliasDevice, models.AliasOAuth, models.AliasExternal:
+		return value, nil
+	case models.AliasMerged:
+		if merged {
+			return value, nil
+		}
+		return "", fmt.Errorf("%w: %s aliases are created by merging users", ErrInvalidAlias, kind)
+	}
+	return "", fmt.Errorf("%w: unknown kind %q (use email, device, oauth or external)", ErrInvalidAlias, kind)
+}
+
+// LinkAlias links an external identifier to a user. Linking an alias the user already
+// has returns it unchanged.
+func (m *MemoryService) LinkAlias(tenantID string, userID string, req models.AliasRequest) (*models.UserAlias, error) {
+	value, err := normalizeAlias(req.Kind, req.Value, false)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := m.redisClient.GetUserAlias(tenantID, req.Kind, value)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if existing.UserID != userID {
+			return nil, fmt.Errorf("%w: %s %q", ErrAliasTaken, req.Kind, value)
+		}
+		return existing, nil
+	}
+
+	alias := &models.UserAlias{Kind: req.Kind, Value: value, UserID: userID, CreatedAt: time.Now()}
+	if err := m.redisClient.SaveUserAlias(tenantID, alias); err != nil {
+		return nil, err
+	}
+	return alias, nil
+}
+
+// ResolveAlias returns the alias of an external identifier, or nil if it is not linked
+func (m *MemoryService) ResolveAlias(tenantID string, req models.AliasRequest) (*models.UserAlias, error) {
+	value, err := normalizeAlias(req.Kind, req.Value, true)
+	if err != nil {
+		return nil, err
+	}
+	return m.redisClient.GetUserAlias(tenantID, req.Kind, value)
+}
+
+// ListAliases returns every alias linked to a user
+func (m *MemoryService) ListAliases(tenantID string, userID string) ([]models.UserAlias, error) {
+	return m.redisClient.ListUserAliases(tenantID, userID)
+}
+
+// UnlinkAlias removes one of a user's aliases
+func (m *MemoryService) UnlinkAlias(tenantID string, userID string, req models.AliasRequest) error {
+	value, err := normalizeAlias(req.Kind, req.Value, true)
+	if err != nil {
+		return err
+	}
+
+	alias, err := m.redisClient.GetUserAlias(tenantID, req.Kind, value)
+	if err != nil {
+		return err
+	}
+	if alias == nil || alias.UserID != userID {
+		return fmt.Errorf("%w: %s %q", ErrAliasNotFound, req.Kind, value)
+	}
+	return m.redisClient.DeleteUserAlias(tenantID, alias)
+}
+
+// MergeUsers folds the source user into the target: the source's memories, cold tier
+// memories, keyword-only memories, live and archived sessions, search index entries and aliases are reassigned,
+// and the source user ID becomes an alias of the target. Preferences move when the target
+// has none, and facts for keys the target has not set; digest subscriptions and standing
+// queries are not carried over.
+func (m *MemoryService) MergeUsers(req models.MergeUsersRequest) (*models.MergeReport, error) {
+	tenantID := req.TenantID
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	m = m.ForTenant(tenantID)
+
+	source, target := req.SourceUserID, req.TargetUserID
+	if source == "" || target == "" || source == target {
+		return nil, fmt.Errorf("%w: source and target must be two different users", ErrInvalidMerge)
+	}
+	policy, err := m.retention.Get(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLegalHold(policy); err != nil {
+		return nil, err
+	}
+
+	report := &models.MergeReport{SourceUserID: source, TargetUserID: target}
+
+	// Collect every memory first: reassigning changes the user ID the scan filters on.
+	// Memories of the same user ID in other tenants stay behind.
+	var matches []clients.QueryMatch
+	seen := make(map[string]bool)
+	err = m.forEachUserMemoryPage(source, func(page []clients.QueryMatch) error {
+		for _, match := range page {
+			if metadataTenant(match.Metadata) != tenantID || seen[match.ID] {
+				continue
+			}
+			seen[match.ID] = true
+			matches = append(matches, match)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user memories: %w", err)
+	}
+	moved := make([]string, 0, len(matches))
+	for _, match := range matches {
+		match.Metadata["user_id"] = target
+		if err := m.vectorClient.UpdateMetadata(match.ID, match.Metadata); err != nil {
+			return nil, err
+		}
+		moved = append(moved, match.ID)
+	}
+	report.Memories = len(moved)
+
+	if report.ColdMemories, err = m.mergeColdMemories(tenantID, source, target); err != nil {
+		return nil, err
+	}
+	if report.KeywordMemories, err = m.redisClient.ReassignKeywordMemories(source, target); err != nil {
+		return nil, err
+	}
+
+	sessions, err := m.redisClient.GetUserSessions(source)
+	if err != nil {
+		return nil, err
+	}
+	for _, sessionID := range sessions {
+		session, err := m.redisClient.GetSession(sessionID)
+		if err != nil {
+			// The session may have expired since it was indexed
+			continue
+		}
+		session.UserID = target
+		if err := m.redisClient.SaveSession(session); err != nil {
+			return nil, err
+		}
+		report.Sessions++
+	}
+	if _, err := m.redisClient.DeleteKeys(fmt.Sprintf("user_sessions:%s", source)); err != nil {
+		return nil, err
+	}
+
+	archived, err := m.redisClient.GetArchivedSessions(source)
+	if err != nil {
+		return nil, err
+	}
+	for _, sessionID := range archived {
+		reassigned, err := m.redisClient.ReassignArchivedSession(sessionID, source, target)
+		if err != nil {
+			return nil, err
+		}
+		if reassigned {
+			report.ArchivedSessions++
+		}
+	}
+
+	if config.AppConfig.RedisSearchEnabled {
+		keys, err := m.redisClient.ListUserIndexedKeys(source)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.redisClient.ReassignIndexedMemories(keys, target); err != nil {
+			return nil, err
+		}
+	}
+
+	aliases, err := m.redisClient.ListUserAliases(tenantID, source)
+	if err != nil {
+		return nil, err
+	}
+	aliases = append(aliases, models.UserAlias{Kind: models.AliasMerged, Value: source, UserID: source, CreatedAt: time.Now()})
+	for i := range aliases {
+		alias := &aliases[i]
+		if err := m.redisClient.DeleteUserAlias(tenantID, alias); err != nil {
+			return nil, err
+		}
+		alias.UserID = target
+		if err := m.redisClient.SaveUserAlias(tenantID, alias); err != nil {
+			return nil, err
+		}
+	}
+	report.Aliases = len(aliases)
+
+	// Preferences move only when the target has none of its own
+	if err := m.mergePreferences(source, target); err != nil {
+		return nil, err
+	}
+
+	if err := m.mergeFacts(source, target); err != nil {
+		return nil, err
+	}
+
+	// The source's profile described memories that now belong to the target
+	if err := m.redisClient.DeleteUserProfile(source); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	m.publish(EventMemoryDeleted, tenantID, source, moved...)
+	m.publish(EventMemoryUpdated, tenantID, target, moved...)
+
+	report.MergedAt = time.Now()
+	return report, nil
+}
+
+// mergePreferences gives the target the source's preferences unless it has its own
+func (m *MemoryService) mergePreferences(source string, target string) error {
+	preferences, err := m.redisClient.GetUserPreferences(source)
+	if err != nil || preferences == nil {
+		return err
+	}
+	existing, err := m.redisClient.GetUserPreferences(target)
+	if err != nil || existing != nil {
+		return err
+	}
+	preferences.UserID = target
+	if err := m.redisClient.SaveUserPreferences(preferences); err != nil {
+		return err
+	}
+	return m.redisClient.DeleteUserPreferences(source)
+}
+
+// mergeFacts gives the target the source's facts for keys it has not set, up to
+// maxUserFacts, and drops the rest with the source
+func (m *MemoryService) mergeFacts(source string, target string) error {
+	facts, err := m.redisClient.GetFacts(source)
+	if err != nil || len(facts) == 0 {
+		return err
+	}
+	existing, err := m.redisClient.GetFacts(target)
+	if err != nil {
+		return err
+	}
+	set := make(map[string]bool, len(existing))
+	for _, fact := range existing {
+		set[fact.Key] = true
+	}
+
+	count := len(existing)
+	for _, fact := range facts {
+		if set[fact.Key] || count >= maxUserFacts {
+			continue
+		}
+		fact.UserID = target
+		if err := m.redisClient.SaveFact(&fact); err != nil {
+			return err
+		}
+		count++
+	}
+	_, err = m.redisClient.DeleteKeys(fmt.Sprintf("user_facts:%s", source))
+	return err
+}
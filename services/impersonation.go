@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrImpersonationDisabled is returned when impersonating without ADMIN_API_TOKEN; an
+	// open admin API would let anyone act as any user
+	ErrImpersonationDisabled = errors.New("impersonation requires ADMIN_API_TOKEN")
+	// ErrInvalidImpersonation is returned for an impersonation with an invalid lifetime
+	ErrInvalidImpersonation = errors.New("invalid impersonation")
+	// ErrImpersonationNotFound is returned for an impersonation that expired, was revoked or never existed
+	ErrImpersonationNotFound = errors.New("impersonation not found or expired")
+)
+
+// StartImpersonation opens a time-limited impersonation of a user and audits it
+func (m *MemoryService) StartImpersonation(req models.StartImpersonationRequest) (*models.Impersonation, error) {
+	if config.AppConfig.AdminAPIToken == "" {
+		return nil, ErrImpersonationDisabled
+	}
+
+	ttl := config.AppConfig.ImpersonationDefaultTTL
+	if req.TTLSeconds != 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	if ttl <= 0 || ttl > config.AppConfig.ImpersonationMaxTTL {
+		return nil, fmt.Errorf("%w: ttl_seconds must be between 1 and %d", ErrInvalidImpersonation, int64(config.AppConfig.ImpersonationMaxTTL/time.Second))
+	}
+
+	now := time.Now()
+	impersonation := &models.Impersonation{
+		ID:        uuid.New().String(),
+		UserID:    req.UserID,
+		TenantID:  req.TenantID,
+		Operator:  req.Operator,
+		Reason:    req.Reason,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := m.controlClient.SaveImpersonation(impersonation, int64(ttl/time.Second)); err != nil {
+		return nil, err
+	}
+
+	m.AuditImpersonation(impersonation, models.ImpersonationStarted, "", "", 0, req.Reason)
+	return impersonation, nil
+}
+
+// GetImpersonation returns an active impersonation
+func (m *MemoryService) GetImpersonation(id string) (*models.Impersonation, error) {
+	impersonation, err := m.controlClient.GetImpersonation(id)
+	if err != nil {
+		return nil, err
+	}
+	if impersonation == nil || time.Now().After(impersonation.ExpiresAt) {
+		return nil, fmt.Errorf("%w: %s", ErrImpersonationNotFound, id)
+	}
+	return impersonation, nil
+}
+
+// RevokeImpersonation ends an impersonation before it expires
+func (m *MemoryService) RevokeImpersonation(id string) error {
+	impersonation, err := m.GetImpersonation(id)
+	if err != nil {
+		return err
+	}
+	if _, err := m.controlClient.DeleteImpersonation(id); err != nil {
+		return err
+	}
+
+	m.AuditImpersonation(impersonation, models.ImpersonationRevoked, "", "", 0, "")
+	return nil
+}
+
+// AuditImpersonation records an impersonation event. Failures are logged rather than
+// returned so the audit trail never fails the request it describes.
+func (m *MemoryService) AuditImpersonation(impersonation *models.Impersonation, action string, method string, path string, status int, details string) {
+	entry := &models.ImpersonationAuditEntry{
+		ImpersonationID: impersonation.ID,
+		Action:          action,
+		Operator:        impersonation.Operator,
+		UserID:          impersonation.UserID,
+		TenantID:        impersonation.TenantID,
+		Method:          method,
+		Path:            path,
+		Status:          status,
+		Details:         details,
+		At:              time.Now(),
+	}
+	if err := m.controlClient.RecordImpersonationAudit(entry); err != nil {
+		fmt.Printf("Warning: failed to audit impersonation %s: %v\n", impersonation.ID, err)
+	}
+}
+
+// ListImpersonationAudit returns up to limit audit entries, newest first, optionally of
+// one impersonation or one target user
+func (m *MemoryService) ListImpersonationAudit(impersonationID string, userID string, limit int) ([]models.ImpersonationAuditEntry, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	entries, err := m.controlClient.ListImpersonationAudit()
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]models.ImpersonationAuditEntry, 0, limit)
+	for _, entry := range entries {
+		if len(filtered) >= limit {
+			break
+		}
+		if (impersonationID == "" || entry.ImpersonationID == impersonationID) && (userID == "" || entry.UserID == userID) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered, nil
+}
+
+// SessionOwner returns the user a session belongs to, or "" if it does not exist
+func (m *MemoryService) SessionOwner(sessionID string) (string, error) {
+	session, err := m.redisClient.GetSessionCached(sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get session: %w", err)
+	}
+	if session == nil {
+		return "", nil
+	}
+	return session.UserID, nil
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/Fairy-nn/MemoryCacheAI/tokenizer"
+)
+
+// ErrInvalidInstruction is returned for instruction memories that cannot be saved
+var ErrInvalidInstruction = errors.New("invalid instruction")
+
+// instructionScanLimit bounds how many instructions are listed for one user
+const instructionScanLimit = 1000
+
+// validateMemoryType checks a save's memory type. Instructions live outside tasks and
+// must fit in the instruction token cap on their own.
+func validateMemoryType(req models.SaveMemoryRequest) error {
+	switch req.Type {
+	case "":
+		return nil
+	case models.MemoryTypeInstruction:
+		if req.TaskID != "" {
+			return fmt.Errorf("%w: instructions cannot be task memories", ErrInvalidInstruction)
+		}
+		if tokens := tokenizer.Count(req.Content); tokens > config.AppConfig.InstructionMaxTokens {
+			return fmt.Errorf("%w: %d tokens exceeds INSTRUCTION_MAX_TOKENS (%d)", ErrInvalidInstruction, tokens, config.AppConfig.InstructionMaxTokens)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown memory type %q, expected %q", ErrInvalidInstruction, req.Type, models.MemoryTypeInstruction)
+	}
+}
+
+// isInstruction reports whether vector metadata belongs to an instruction memory
+func isInstruction(metadata map[string]interface{}) bool {
+	memoryType, _ := metadata["memory_type"].(string)
+	return memoryType == models.MemoryTypeInstruction
+}
+
+// checkInstructionRoom fails when a user already stores INSTRUCTION_MAX_PER_USER instructions
+func (m *MemoryService) checkInstructionRoom(tenantID string, userID string) error {
+	matches, err := m.vectorClient.ListUserInstructions(userID, instructionScanLimit)
+	if err != nil {
+		return fmt.Errorf("failed to list instructions: %w", err)
+	}
+
+	count := 0
+	for _, match := range matches {
+		if metadataTenant(match.Metadata) == tenantID {
+			count++
+		}
+	}
+	if count >= config.AppConfig.InstructionMaxPerUser {
+		return fmt.Errorf("%w: user already has %d instructions, the INSTRUCTION_MAX_PER_USER limit", ErrInvalidInstruction, count)
+	}
+	return nil
+}
+
+// userInstructions returns the instructions an assistant follows for a user, lowest
+// priority first and oldest first within a priority, as far as INSTRUCTION_MAX_TOKENS
+// allows. It also returns how many were left out for size.
+func (m *MemoryService) userInstructions(tenantID string, userID string, assistantID string) ([]models.Instruction, int, error) {
+	matches, err := m.vectorClient.ListUserInstructions(userID, instructionScanLimit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list instructions: %w", err)
+	}
+
+	var memories []*models.MemoryEntry
+	for _, match := range matches {
+		if metadataTenant(match.Metadata) == tenantID && assistantVisible(match.Metadata, assistantID) {
+			memories = append(memories, memoryFromMetadata(match.ID, match.Metadata))
+		}
+	}
+	priority := func(memory *models.MemoryEntry) int {
+		value, _ := memory.Metadata["priority"].(float64)
+		return int(value)
+	}
+	sort.SliceStable(memories, func(i, j int) bool {
+		if priority(memories[i]) != priority(memories[j]) {
+			return priority(memories[i]) < priority(memories[j])
+		}
+		return memories[i].Timestamp.Before(memories[j].Timestamp)
+	})
+
+	instructions := make([]models.Instruction, 0, len(memories))
+	used := 0
+	for _, memory := range memories {
+		used += tokenizer.Count(memory.Content)
+		if used > config.AppConfig.InstructionMaxTokens {
+			return instructions, len(memories) - len(instructions), nil
+		}
+		instructions = append(instructions, models.Instruction{
+			ID:       memory.ID,
+			Content:  memory.Content,
+			Priority: priority(memory),
+		})
+	}
+	return instructions, 0, nil
+}
+
+// withoutInstructions drops retrieved memories that are already included as instructions.
+// results may be shared with the query cache, so a new slice is returned.
+func withoutInstructions(results []models.MemoryResult, instructions []models.Instruction) []models.MemoryResult {
+	if len(instructions) == 0 {
+		return results
+	}
+	included := make(map[string]bool, len(instructions))
+	for _, instruction := range instructions {
+		included[instruction.ID] = true
+	}
+
+	kept := make([]models.MemoryResult, 0, len(results))
+	for _, result := range results {
+		if !included[result.ID] {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
+
+// instructionContents returns the content of each instruction
+func instructionContents(instructions []models.Instruction) []string {
+	contents := make([]string, len(instructions))
+	for i, instruction := range instructions {
+		contents[i] = instruction.Content
+	}
+	return contents
+}
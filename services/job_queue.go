@@ -0,0 +1,466 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/metrics"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// jobLease is how long a claimed job is held before another worker may run it. Workers
+	// renew the lease while a job runs, so only the jobs of a crashed worker run again.
+	jobLease = 5 * time.Minute
+	// jobRetryBackoffMax caps the delay between two runs of a failing job
+	jobRetryBackoffMax = time.Hour
+	// jobPromoteBatch bounds the due jobs queued again per poll
+	jobPromoteBatch = 100
+	// jobListScan bounds the newest jobs read to answer a filtered listing
+	jobListScan = 1000
+	// jobListMax bounds the jobs one listing returns
+	jobListMax = 500
+)
+
+// jobSaveMemory stores the memory of an async save
+const jobSaveMemory = "save_memory"
+
+// maintenanceJobs are the job types POST /jobs and the internal scheduler may queue
+var maintenanceJobs = map[string]bool{
+	"cleanup_expired_memories": true,
+	"cleanup_user_memories":    true,
+	"notify_expiring_memories": true,
+	"apply_session_policies":   true,
+	"rollup_memories":          true,
+	"tier_cold_memories":       true,
+	"reembed_namespace":        true,
+}
+
+// crossTenantTasks act on every tenant whatever tenant they are queued for
+var crossTenantTasks = map[string]bool{
+	"notify_expiring_memories": true,
+	"apply_session_policies":   true,
+}
+
+// SweepsAllTenants reports whether a job or cleanup task type acts on every tenant, so
+// only callers not bound to a tenant may start it
+func SweepsAllTenants(taskType string) bool {
+	return crossTenantTasks[taskType]
+}
+
+var (
+	// ErrInvalidJob is returned for a job of an unknown type or missing what it needs
+	ErrInvalidJob = errors.New("invalid job")
+	// ErrInvalidJobFilter is returned when listing jobs with an unknown status
+	ErrInvalidJobFilter = errors.New("invalid job filter")
+	// ErrJobNotDead is returned when retrying a job that is not on the dead-letter list
+	ErrJobNotDead = errors.New("job is not dead-lettered")
+)
+
+var (
+	jobWorkersOnce     sync.Once
+	jobWorkersStop     = make(chan struct{})
+	stopJobWorkersOnce sync.Once
+	// jobWake wakes an idle worker of this replica when it queues a job
+	jobWake = make(chan struct{}, 1)
+)
+
+// EnqueueJob queues a maintenance job to run on the job queue
+func (m *MemoryService) EnqueueJob(req models.EnqueueJobRequest) (*models.Job, error) {
+	if !maintenanceJobs[req.Type] {
+		return nil, fmt.Errorf("%w: unknown job type %q", ErrInvalidJob, req.Type)
+	}
+	if req.Type == "cleanup_user_memories" && req.UserID == "" {
+		return nil, fmt.Errorf("%w: %s requires user_id", ErrInvalidJob, req.Type)
+	}
+	return m.enqueueJob(req.Type, req.TenantID, req.UserID, nil)
+}
+
+// enqueueJob records a job and adds it to the job queue with payload, which may be nil.
+// A job that cannot be queued is recorded as failed.
+func (m *MemoryService) enqueueJob(jobType string, tenantID string, userID string, payload interface{}) (*models.Job, error) {
+	if config.Lite {
+		return nil, ErrJobsUnavailable
+	}
+
+	var data []byte
+	if payload != nil {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return nil, fmt.Errorf("failed to encode job payload: %w", err)
+		}
+	}
+
+	now := time.Now()
+	job := &models.Job{
+		ID:          uuid.New().String(),
+		Type:        jobType,
+		Status:      models.JobPending,
+		TenantID:    tenantID,
+		UserID:      userID,
+		Progress:    map[string]int{},
+		Queued:      true,
+		MaxAttempts: config.AppConfig.JobMaxAttempts,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := m.recordJob(job); err != nil {
+		return nil, err
+	}
+
+	if err := m.controlClient.EnqueueJob(job.ID, data, config.AppConfig.JobQueueMax); err != nil {
+		job.Status = models.JobFailed
+		job.Error = err.Error()
+		m.saveJob(job)
+		return nil, err
+	}
+	wakeJobWorker()
+	return job, nil
+}
+
+// recordJob persists a new job and adds it to the job listing
+func (m *MemoryService) recordJob(job *models.Job) error {
+	if err := m.controlClient.SaveJob(job); err != nil {
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+	if err := m.controlClient.IndexJob(job); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	return nil
+}
+
+func wakeJobWorker() {
+	select {
+	case jobWake <- struct{}{}:
+	default:
+	}
+}
+
+// StartJobWorkers starts JOB_WORKERS workers running queued jobs, and queues again the
+// retries that came due and the jobs of crashed workers every JOB_POLL_INTERVAL. Workers
+// stop claiming jobs once shutdown begins; the jobs they are running are drained.
+func (m *MemoryService) StartJobWorkers() {
+	if config.Lite || config.AppConfig.JobWorkers <= 0 {
+		return
+	}
+
+	jobWorkersOnce.Do(func() {
+		go m.promoteJobs()
+		for i := 0; i < config.AppConfig.JobWorkers; i++ {
+			go m.runJobWorker()
+		}
+	})
+}
+
+// StopJobWorkers stops the job workers; jobs in progress finish first
+func StopJobWorkers() {
+	stopJobWorkersOnce.Do(func() {
+		close(jobWorkersStop)
+	})
+}
+
+func (m *MemoryService) promoteJobs() {
+	ticker := time.NewTicker(config.AppConfig.JobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			promoted, err := m.controlClient.PromoteJobs(time.Now(), jobPromoteBatch)
+			if err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+			if promoted > 0 {
+				wakeJobWorker()
+			}
+		case <-jobWorkersStop:
+			return
+		}
+	}
+}
+
+func (m *MemoryService) runJobWorker() {
+	idle := time.NewTimer(0)
+	defer idle.Stop()
+
+	for {
+		select {
+		case <-idle.C:
+		case <-jobWake:
+		case <-jobWorkersStop:
+			return
+		case <-shutdownStarted:
+			return
+		}
+
+		// Keep running jobs while the queue has any, then wait for a wake-up or the next poll
+		for !ShuttingDown() && m.runNextJob() {
+		}
+		if !idle.Stop() {
+			select {
+			case <-idle.C:
+			default:
+			}
+		}
+		idle.Reset(config.AppConfig.JobPollInterval)
+	}
+}
+
+// runNextJob claims and runs the oldest ready job, reporting whether there was one
+func (m *MemoryService) runNextJob() bool {
+	backgroundWrites.Add(1)
+	defer backgroundWrites.Done()
+
+	jobID, err := m.controlClient.ClaimJob(jobLease)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return false
+	}
+	if jobID == "" {
+		return false
+	}
+	m.runQueuedJob(jobID)
+	return true
+}
+
+// runQueuedJob runs a claimed job once and settles it: completed, retried after a
+// backoff, failed for good or dead-lettered once it has used every attempt
+func (m *MemoryService) runQueuedJob(jobID string) {
+	// On errors reading the job, its lease runs out and it is queued again
+	job, err := m.controlClient.GetJob(jobID)
+	if errors.Is(err, clients.ErrJobNotFound) {
+		// Nothing can run a job whose record expired
+		fmt.Printf("Warning: dropping queued job %s: %v\n", jobID, err)
+		if err := m.controlClient.FinishJob(jobID); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		return
+	}
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return
+	}
+	payload, err := m.controlClient.GetJobPayload(jobID)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return
+	}
+
+	job.Attempts++
+	job.Status = models.JobRunning
+	job.Error = ""
+	job.NextAttemptAt = nil
+	m.saveJob(job)
+
+	renewed := make(chan struct{})
+	go m.renewJobLease(jobID, renewed)
+	service := m
+	if job.TenantID != "" {
+		service = m.ForTenant(job.TenantID)
+	}
+	err = service.runQueued(job, payload)
+	close(renewed)
+
+	m.settleJob(job, err)
+}
+
+// renewJobLease extends a running job's lease until done is closed
+func (m *MemoryService) renewJobLease(jobID string, done <-chan struct{}) {
+	ticker := time.NewTicker(jobLease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.controlClient.RenewJobLease(jobID, jobLease); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// runQueued runs a queued job by type
+func (m *MemoryService) runQueued(job *models.Job, payload []byte) error {
+	var err error
+	switch job.Type {
+	case jobSaveMemory:
+		var queued queuedSave
+		if err := json.Unmarshal(payload, &queued); err != nil || queued.Memory == nil {
+			return fmt.Errorf("%w: unreadable save payload", ErrInvalidJob)
+		}
+		save := queued.longTermSave()
+		result, err := m.storeLongTerm(save)
+		if err != nil {
+			return err
+		}
+		job.Result = result
+		m.linkAnswerLater(save.tenantID, save.memory, result)
+	case "cleanup_expired_memories":
+		err = m.CleanupExpiredMemories(job.TenantID)
+	case "cleanup_user_memories":
+		err = m.CleanupUserMemories(job.UserID, job.TenantID)
+	case "notify_expiring_memories":
+		err = m.NotifyExpiringMemories()
+	case "apply_session_policies":
+		job.Result, err = m.ApplySessionPolicies()
+	case "rollup_memories":
+		job.Result, err = m.RollupMemories(job.UserID, job.TenantID)
+	case "tier_cold_memories":
+		job.Result, err = m.RunColdTiering(job.TenantID)
+	case "reembed_namespace":
+		job.Result, err = m.ReembedNamespace(job.TenantID)
+	default:
+		err = fmt.Errorf("%w: unknown job type %q", ErrInvalidJob, job.Type)
+	}
+	return err
+}
+
+// settleJob records the outcome of a job's run and moves it on. Errors no retry can fix,
+// such as a deletion a retention policy blocks, fail the job at once.
+func (m *MemoryService) settleJob(job *models.Job, err error) {
+	permanent := errors.Is(err, ErrInvalidJob) || errors.Is(err, ErrRetentionBlocked) || errors.Is(err, ErrColdTierDisabled)
+	var move func() error
+	switch {
+	case err == nil:
+		job.Status = models.JobCompleted
+		move = func() error { return m.controlClient.FinishJob(job.ID) }
+	case permanent:
+		job.Status = models.JobFailed
+		job.Error = err.Error()
+		move = func() error { return m.controlClient.FinishJob(job.ID) }
+	case job.Attempts >= job.MaxAttempts:
+		job.Status = models.JobDead
+		job.Error = err.Error()
+		move = func() error { return m.controlClient.DeadLetterJob(job.ID) }
+	default:
+		next := time.Now().Add(jobRetryDelay(job.Attempts))
+		job.Status = models.JobPending
+		job.Error = err.Error()
+		job.NextAttemptAt = &next
+		move = func() error { return m.controlClient.RetryJobAt(job.ID, next) }
+	}
+
+	m.saveJob(job)
+	if err := move(); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	metrics.AddCounter("memorycache_job_runs_total", "Runs of queued jobs, by job type and the status they left the job in",
+		map[string]string{"type": job.Type, "status": job.Status}, 1)
+}
+
+// jobRetryDelay is JOB_RETRY_BACKOFF doubled for each attempt after the first, capped at
+// jobRetryBackoffMax
+func jobRetryDelay(attempts int) time.Duration {
+	delay := config.AppConfig.JobRetryBackoff
+	for i := 1; i < attempts && delay < jobRetryBackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > jobRetryBackoffMax {
+		delay = jobRetryBackoffMax
+	}
+	return delay
+}
+
+// RetryJob queues one of a tenant's dead-lettered jobs again with a fresh set of attempts
+func (m *MemoryService) RetryJob(tenantID string, jobID string) (*models.Job, error) {
+	job, err := m.GetJob(tenantID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != models.JobDead {
+		return nil, fmt.Errorf("%w: job %s is %s", ErrJobNotDead, jobID, job.Status)
+	}
+	removed, err := m.controlClient.RemoveDeadJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if !removed {
+		return nil, fmt.Errorf("%w: job %s is no longer on the dead-letter list", ErrJobNotDead, jobID)
+	}
+
+	job.Status = models.JobPending
+	job.Attempts = 0
+	job.Error = ""
+	job.NextAttemptAt = nil
+	m.saveJob(job)
+	if err := m.controlClient.RequeueJob(jobID); err != nil {
+		return nil, err
+	}
+	wakeJobWorker()
+	return job, nil
+}
+
+// ListJobs returns the filter tenant's newest jobs matching filter. Dead jobs are read
+// from the dead-letter list; other listings look through the tenant's jobListScan newest jobs.
+func (m *MemoryService) ListJobs(filter models.JobFilter) ([]*models.Job, error) {
+	switch filter.Status {
+	case "", models.JobPending, models.JobRunning, models.JobCompleted, models.JobFailed, models.JobDead:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidJobFilter, filter.Status)
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > jobListMax {
+		limit = jobListMax
+	}
+
+	matches := func(job *models.Job) bool {
+		return jobOfTenant(job, filter.TenantID) &&
+			(filter.Status == "" || job.Status == filter.Status) &&
+			(filter.Type == "" || job.Type == filter.Type) &&
+			(filter.UserID == "" || job.UserID == filter.UserID)
+	}
+
+	jobs := []*models.Job{}
+	if filter.Status == models.JobDead {
+		ids, err := m.controlClient.ListDeadJobIDs(jobListScan)
+		if err != nil {
+			return nil, err
+		}
+		return m.appendMatchingJobs(jobs, ids, matches, limit)
+	}
+
+	const page = 100
+	for offset := 0; offset < jobListScan && len(jobs) < limit; offset += page {
+		ids, err := m.controlClient.ListJobIDs(filter.TenantID, offset, page)
+		if err != nil {
+			return nil, err
+		}
+		if jobs, err = m.appendMatchingJobs(jobs, ids, matches, limit); err != nil {
+			return nil, err
+		}
+		if len(ids) < page {
+			break
+		}
+	}
+	return jobs, nil
+}
+
+// appendMatchingJobs loads the jobs with the given IDs and appends those matching until
+// jobs holds limit
+func (m *MemoryService) appendMatchingJobs(jobs []*models.Job, ids []string, matches func(*models.Job) bool, limit int) ([]*models.Job, error) {
+	loaded, err := m.controlClient.GetJobs(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range loaded {
+		if len(jobs) >= limit {
+			break
+		}
+		if matches(job) {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
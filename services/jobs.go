@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrJobsUnavailable is returned for jobs started in lite builds, which cannot outlive a request
+var ErrJobsUnavailable = errors.New("background jobs are not available in lite builds")
+
+// startJob records a new job and runs fn in the background, persisting its final state.
+// fn receives the service detached from the request, as the job outlives it, and the job
+// so it can update progress through saveJob.
+func (m *MemoryService) startJob(jobType string, tenantID string, userID string, fn func(m *MemoryService, job *models.Job) error) (*models.Job, error) {
+	if config.Lite {
+		return nil, ErrJobsUnavailable
+	}
+
+	job, err := m.newJob(jobType, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	created := *job
+
+	background := m.detached()
+	goBackground(func() {
+		background.runJob(job, fn)
+	})
+
+	return &created, nil
+}
+
+// newJob records a pending job
+func (m *MemoryService) newJob(jobType string, tenantID string, userID string) (*models.Job, error) {
+	now := time.Now()
+	job := &models.Job{
+		ID:        uuid.New().String(),
+		Type:      jobType,
+		Status:    models.JobPending,
+		TenantID:  tenantID,
+		UserID:    userID,
+		Progress:  map[string]int{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := m.recordJob(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// runJob runs fn as the job, persisting the job as it starts and once fn returns
+func (m *MemoryService) runJob(job *models.Job, fn func(m *MemoryService, job *models.Job) error) {
+	job.Status = models.JobRunning
+	m.saveJob(job)
+
+	if err := fn(m, job); err != nil {
+		job.Status = models.JobFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = models.JobCompleted
+	}
+	m.saveJob(job)
+}
+
+// saveJob persists job progress, logging rather than failing the job on errors
+func (m *MemoryService) saveJob(job *models.Job) {
+	job.UpdatedAt = time.Now()
+	if err := m.controlClient.SaveJob(job); err != nil {
+		fmt.Printf("Warning: failed to save job %s: %v\n", job.ID, err)
+	}
+}
+
+// GetJob returns the state of one of a tenant's background jobs. Other tenants' jobs
+// are reported as not found.
+func (m *MemoryService) GetJob(tenantID string, jobID string) (*models.Job, error) {
+	job, err := m.controlClient.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if !jobOfTenant(job, tenantID) {
+		return nil, fmt.Errorf("%w: %s", clients.ErrJobNotFound, jobID)
+	}
+	return job, nil
+}
+
+// jobOfTenant reports whether a job belongs to a tenant; jobs recorded without one
+// belong to the default tenant
+func jobOfTenant(job *models.Job, tenantID string) bool {
+	jobTenant := job.TenantID
+	if jobTenant == "" {
+		jobTenant = models.DefaultTenant
+	}
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	return jobTenant == tenantID
+}
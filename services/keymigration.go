@@ -0,0 +1,22 @@
+package services
+
+import (
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// MigrateKeyPrefix moves the keys of the default instance and every data region from the
+// from prefix to REDIS_KEY_PREFIX. Other environments sharing the database should be
+// listed in exclude when moving unprefixed keys, or their keys would be claimed too.
+// A region that fails is reported and the remaining regions are still migrated.
+func (m *MemoryService) MigrateKeyPrefix(from string, exclude []string, dryRun bool) []*models.KeyMigrationReport {
+	var reports []*models.KeyMigrationReport
+	for _, region := range regionNames() {
+		report, err := m.forRegion(region).redisClient.MigrateKeyPrefix(from, exclude, dryRun)
+		report.Region = region
+		if err != nil {
+			report.Error = err.Error()
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
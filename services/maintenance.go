@@ -0,0 +1,373 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+const (
+	// defaultSessionArchiveAfter is how long a session must be idle before it is archived
+	defaultSessionArchiveAfter = time.Hour
+	// sessionArchiveSeconds is how long archived sessions are kept
+	sessionArchiveSeconds = 30 * 24 * 60 * 60
+	// profileTopTags is how many tags a user profile lists
+	profileTopTags = 10
+)
+
+// ConsolidateUserMemories removes memories whose content duplicates an older memory
+// of the same user, merging their tags into the memory that is kept
+func (m *MemoryService) ConsolidateUserMemories(userID string, tenantID string) (map[string]int, error) {
+	m = m.ForTenant(tenantID)
+
+	policy, err := m.retention.Get(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLegalHold(policy); err != nil {
+		return nil, err
+	}
+
+	matches, err := m.vectorClient.ListUserMemories(userID, 10000)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user memories: %w", err)
+	}
+
+	memories := make([]*models.MemoryEntry, 0, len(matches))
+	for _, match := range matches {
+		if !isSummary(match.Metadata) && !isTaskMemory(match.Metadata) && !isInstruction(match.Metadata) && !isQuarantined(match.Metadata) {
+			memories = append(memories, memoryFromMetadata(match.ID, match.Metadata))
+		}
+	}
+	sort.Slice(memories, func(i, j int) bool {
+		return memories[i].Timestamp.Before(memories[j].Timestamp)
+	})
+
+	result := map[string]int{"scanned": len(memories)}
+	keepers := make(map[string]*models.MemoryEntry)
+	merged := make(map[string]bool)
+	var duplicates []string
+	now := time.Now()
+
+	for _, memory := range memories {
+		key := strings.Join(strings.Fields(strings.ToLower(memory.Content)), " ")
+		keeper, ok := keepers[key]
+		if !ok {
+			keepers[key] = memory
+			continue
+		}
+
+		result["duplicates"]++
+		if checkMinRetention(policy, memory.Timestamp, now) != nil {
+			result["retained"]++
+			continue
+		}
+
+		if tags := mergeTags(metadataTags(keeper.Metadata), metadataTags(memory.Metadata)); len(tags) > len(metadataTags(keeper.Metadata)) {
+			keeper.Metadata["tags"] = tags
+			merged[keeper.ID] = true
+		}
+		duplicates = append(duplicates, memory.ID)
+	}
+
+	for _, keeper := range keepers {
+		if !merged[keeper.ID] {
+			continue
+		}
+		metadata := make(map[string]interface{}, len(keeper.Metadata)+4)
+		for k, v := range keeper.Metadata {
+			metadata[k] = v
+		}
+		metadata["user_id"] = keeper.UserID
+		metadata["content"] = keeper.Content
+		metadata["timestamp"] = keeper.Timestamp.Unix()
+		metadata["ttl"] = keeper.TTL
+
+		if err := m.vectorClient.UpdateMetadata(keeper.ID, metadata); err != nil {
+			return result, fmt.Errorf("failed to merge tags into memory %s: %w", keeper.ID, err)
+		}
+		result["merged"]++
+	}
+
+	if err := m.vectorClient.DeleteMemories(duplicates); err != nil {
+		return result, fmt.Errorf("failed to delete duplicate memories: %w", err)
+	}
+	if config.AppConfig.RedisSearchEnabled {
+		for _, id := range duplicates {
+			if err := m.redisClient.DeleteIndexedMemory(id); err != nil {
+				fmt.Printf("Warning: failed to remove memory %s from search index: %v\n", id, err)
+			}
+		}
+	}
+	result["deleted"] = len(duplicates)
+
+	return result, nil
+}
+
+// ReembedNamespace re-embeds a tenant's memories whose vectors were produced by a
+// different embedding model or version than the current one. An empty tenantID
+// covers every tenant. Work stops for a tenant once its embedding budget runs out.
+func (m *MemoryService) ReembedNamespace(tenantID string) (map[string]int, error) {
+	result := map[string]int{}
+	reembed := func(region *MemoryService) error {
+		return region.reembedStale(tenantID, result)
+	}
+
+	if tenantID != "" {
+		return result, reembed(m.ForTenant(tenantID))
+	}
+	return result, m.forEachRegion(reembed)
+}
+
+func (m *MemoryService) reembedStale(tenantID string, result map[string]int) error {
+	current := m.currentProvenance()
+	exhausted := make(map[string]bool)
+
+	cursor := "0"
+	for {
+		matches, next, err := m.vectorClient.RangeMemories(cursor, patchPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, match := range matches {
+			memoryTenant := metadataTenant(match.Metadata)
+			if tenantID != "" && memoryTenant != tenantID {
+				continue
+			}
+			result["scanned"]++
+
+			provider, _ := match.Metadata["embedding_provider"].(string)
+			model, _ := match.Metadata["embedding_model"].(string)
+			version, _ := match.Metadata["embedding_version"].(string)
+			if provider == current.Provider && model == current.Model && version == current.Version {
+				continue
+			}
+			result["stale"]++
+
+			memory := memoryFromMetadata(match.ID, match.Metadata)
+			tokens := EstimateTokens(memory.Content)
+			if !exhausted[memoryTenant] {
+				allowed, err := m.reserveEmbedding(memoryTenant, tokens)
+				if err != nil {
+					return err
+				}
+				exhausted[memoryTenant] = !allowed
+			}
+			if exhausted[memoryTenant] {
+				result["skipped_budget"]++
+				continue
+			}
+
+			embedding, used, err := m.embedWithProvenance(memory.Content)
+			if err != nil {
+				m.releaseEmbedding(memoryTenant, tokens)
+				result["failed"]++
+				fmt.Printf("Warning: failed to re-embed memory %s: %v\n", match.ID, err)
+				continue
+			}
+
+			memory.Embedding = embedding
+			memory.Metadata["embedding_provider"] = used.Provider
+			memory.Metadata["embedding_model"] = used.Model
+			memory.Metadata["embedding_version"] = used.Version
+			if err := m.vectorClient.UpsertMemory(memory); err != nil {
+				result["failed"]++
+				fmt.Printf("Warning: failed to save re-embedded memory %s: %v\n", match.ID, err)
+				continue
+			}
+			result["reembedded"]++
+		}
+
+		if next == "" || next == cursor {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// ArchiveExpiredSessions moves sessions idle for longer than idleFor out of the live
+// keyspace into a 30-day archive. A non-positive idleFor uses one hour. An empty
+// tenantID covers the default instance and every data region.
+func (m *MemoryService) ArchiveExpiredSessions(tenantID string, idleFor time.Duration) (map[string]int, error) {
+	if idleFor <= 0 {
+		idleFor = defaultSessionArchiveAfter
+	}
+
+	result := map[string]int{}
+	archive := func(region *MemoryService) error {
+		return region.archiveIdleSessions(time.Now().Add(-idleFor), result)
+	}
+
+	if tenantID != "" {
+		return result, archive(m.ForTenant(tenantID))
+	}
+	return result, m.forEachRegion(archive)
+}
+
+func (m *MemoryService) archiveIdleSessions(cutoff time.Time, result map[string]int) error {
+	keys, err := m.redisClient.ScanKeys("session:*")
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		result["scanned"]++
+
+		session, err := m.redisClient.GetSession(strings.TrimPrefix(key, "session:"))
+		if err != nil {
+			// The session may have expired since the scan
+			continue
+		}
+		if session.LastActivity.After(cutoff) {
+			continue
+		}
+
+		if err := m.redisClient.ArchiveSession(session, sessionArchiveSeconds); err != nil {
+			result["failed"]++
+			fmt.Printf("Warning: failed to archive session %s: %v\n", session.SessionID, err)
+			continue
+		}
+		result["archived"]++
+	}
+
+	return nil
+}
+
+// RecomputeUserProfile rebuilds the stored summary of a user's memories
+func (m *MemoryService) RecomputeUserProfile(userID string, tenantID string) (*models.UserProfile, error) {
+	m = m.ForTenant(tenantID)
+
+	matches, err := m.vectorClient.ListUserMemories(userID, 10000)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user memories: %w", err)
+	}
+	keywordMemories, err := m.redisClient.GetKeywordMemories(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rollup summaries restate raw memories and would double count them
+	raw := matches[:0]
+	for _, match := range matches {
+		if !isSummary(match.Metadata) {
+			raw = append(raw, match)
+		}
+	}
+	matches = raw
+
+	profile := &models.UserProfile{
+		UserID:           userID,
+		TenantID:         tenantID,
+		MemoryCount:      len(matches) + len(keywordMemories),
+		KeywordOnlyCount: len(keywordMemories),
+		RoleCounts:       map[string]int{},
+		TopTags:          []models.TagCount{},
+		UpdatedAt:        time.Now(),
+	}
+
+	memories := make([]*models.MemoryEntry, 0, profile.MemoryCount)
+	for _, match := range matches {
+		memories = append(memories, memoryFromMetadata(match.ID, match.Metadata))
+	}
+	for i := range keywordMemories {
+		memories = append(memories, &keywordMemories[i])
+	}
+
+	sessions := make(map[string]bool)
+	tagCounts := make(map[string]int)
+	for _, memory := range memories {
+		if role, ok := memory.Metadata["role"].(string); ok && role != "" {
+			profile.RoleCounts[role]++
+		}
+		if sessionID, ok := memory.Metadata["session_id"].(string); ok && sessionID != "" {
+			sessions[sessionID] = true
+		}
+		for _, tag := range entryTags(memory) {
+			tagCounts[tag]++
+		}
+
+		timestamp := memory.Timestamp
+		if profile.FirstMemoryAt == nil || timestamp.Before(*profile.FirstMemoryAt) {
+			profile.FirstMemoryAt = &timestamp
+		}
+		if profile.LastMemoryAt == nil || timestamp.After(*profile.LastMemoryAt) {
+			profile.LastMemoryAt = &timestamp
+		}
+	}
+	profile.SessionCount = len(sessions)
+
+	for tag, count := range tagCounts {
+		profile.TopTags = append(profile.TopTags, models.TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(profile.TopTags, func(i, j int) bool {
+		if profile.TopTags[i].Count != profile.TopTags[j].Count {
+			return profile.TopTags[i].Count > profile.TopTags[j].Count
+		}
+		return profile.TopTags[i].Tag < profile.TopTags[j].Tag
+	})
+	if len(profile.TopTags) > profileTopTags {
+		profile.TopTags = profile.TopTags[:profileTopTags]
+	}
+
+	if err := m.redisClient.SaveUserProfile(profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// GetUserProfile returns a user's stored profile, recomputing it when a memory write has
+// invalidated it or it was never computed
+func (m *MemoryService) GetUserProfile(userID string, tenantID string) (*models.UserProfile, error) {
+	profile, err := m.ForTenant(tenantID).redisClient.GetUserProfile(userID)
+	if err != nil || profile != nil {
+		return profile, err
+	}
+	return m.RecomputeUserProfile(userID, tenantID)
+}
+
+// memoryFromMetadata rebuilds a memory entry from the metadata stored with its vector.
+// Custom metadata is copied so callers may modify it.
+func memoryFromMetadata(id string, metadata map[string]interface{}) *models.MemoryEntry {
+	userID, _ := metadata["user_id"].(string)
+	content, _ := metadata["content"].(string)
+	timestampFloat, _ := metadata["timestamp"].(float64)
+	ttlFloat, _ := metadata["ttl"].(float64)
+
+	rest := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		switch k {
+		case "user_id", "content", "timestamp", "ttl":
+			continue
+		}
+		rest[k] = v
+	}
+
+	return &models.MemoryEntry{
+		ID:        id,
+		UserID:    userID,
+		Content:   content,
+		Metadata:  rest,
+		Timestamp: time.Unix(int64(timestampFloat), 0),
+		TTL:       int64(ttlFloat),
+	}
+}
+
+// mergeTags returns the tags of a followed by those of b that a lacks
+func mergeTags(a []string, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	merged := make([]string, 0, len(a)+len(b))
+	for _, tags := range [][]string{a, b} {
+		for _, tag := range tags {
+			if !seen[tag] {
+				seen[tag] = true
+				merged = append(merged, tag)
+			}
+		}
+	}
+	return merged
+}
@@ -1,7 +1,9 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Fairy-nn/MemoryCacheAI/clients"
@@ -12,39 +14,205 @@ import (
 )
 
 type MemoryService struct {
-	redisClient     *clients.RedisClient
-	vectorClient    *clients.VectorClient
+	redisClient     *clients.RedisClient // conversation data, routed by tenant region
+	vectorClient    clients.VectorStore
+	contentStore    clients.ContentStore // full text of memories too long for vector metadata
+	coldStore       *clients.ColdStore   // memories moved out of the vector index, nil when tiering is off
+	controlClient   *clients.RedisClient // jobs and reports, always the default instance
 	embeddingClient clients.EmbeddingClient
-	qstashClient    *clients.QStashClient
+	llmClient       clients.LLMClient     // nil when no LLM provider is configured
+	qstashClient    *clients.QStashClient // nil when QStash is not configured
+	budget          *EmbeddingBudget
+	notifier        *clients.WebhookNotifier
+	mailer          *clients.Mailer
+	retention       *RetentionPolicies
+	sessionPolicies *SessionPolicies
+	apiKeys         *APIKeys
+	templates       *PromptTemplates
+	events          *EventBus
+	queryCache      *queryCache
+	alerts          *alertBroker
+	canary          *embeddingCanary          // nil unless EMBEDDING_CANARY_PROVIDER is set
+	regions         map[string]*MemoryService // per data region, sharing everything but the data clients
+	ctx             context.Context           // request the service is bound to, see WithContext; nil otherwise
+	base            *MemoryService            // the unbound service a bound one was made from
 }
 
 func NewMemoryService() *MemoryService {
-	return &MemoryService{
-		redisClient:     clients.NewRedisClient(),
-		vectorClient:    clients.NewVectorClient(),
-		embeddingClient: clients.NewEmbeddingClient(),
-		qstashClient:    clients.NewQStashClient(),
+	redisClient := clients.NewRedisClient()
+	embeddingClient := clients.NewEmbeddingClient()
+
+	var qstashClient *clients.QStashClient
+	if config.AppConfig.QStashConfigured() {
+		qstashClient = clients.NewQStashClient()
+	}
+
+	m := &MemoryService{
+		redisClient:     redisClient,
+		vectorClient:    clients.NewVectorStore(),
+		contentStore:    clients.NewRegionContentStore("", redisClient),
+		coldStore:       clients.NewRegionColdStore("", redisClient),
+		controlClient:   redisClient,
+		embeddingClient: clients.WithEmbeddingCache(embeddingClient, clients.NewEmbeddingCache(redisClient)),
+		llmClient:       clients.NewLLMClient(),
+		qstashClient:    qstashClient,
+		budget:          NewEmbeddingBudget(redisClient),
+		notifier:        clients.NewWebhookNotifier(),
+		mailer:          clients.NewMailer(),
+		retention:       NewRetentionPolicies(redisClient),
+		sessionPolicies: NewSessionPolicies(redisClient),
+		apiKeys:         NewAPIKeys(redisClient),
+		templates:       NewPromptTemplates(redisClient),
+		events:          NewEventBus(),
+		queryCache:      newQueryCache(),
+		canary:          newEmbeddingCanary(),
+		alerts:          newAlertBroker(),
+		regions:         make(map[string]*MemoryService, len(config.AppConfig.DataRegions)),
+	}
+	m.vectorClient.SetContentStore(m.contentStore)
+	for region := range config.AppConfig.DataRegions {
+		routed := *m
+		routed.redisClient = clients.NewRegionRedisClient(region)
+		routed.vectorClient = clients.NewRegionVectorStore(region)
+		routed.contentStore = clients.NewRegionContentStore(region, routed.redisClient)
+		routed.coldStore = clients.NewRegionColdStore(region, routed.redisClient)
+		// Embeddings derive from tenant content, so they are cached in the region's Redis
+		routed.embeddingClient = clients.WithEmbeddingCache(embeddingClient, clients.NewEmbeddingCache(routed.redisClient))
+		routed.vectorClient.SetContentStore(routed.contentStore)
+		m.regions[region] = &routed
+	}
+	m.events.Subscribe(EventAny, m.invalidateUserCaches)
+
+	return m
+}
+
+// Events returns the bus memory writes are announced on
+func (m *MemoryService) Events() *EventBus {
+	return m.events
+}
+
+// RetentionPolicies returns the tenant retention policies the service enforces
+func (m *MemoryService) RetentionPolicies() *RetentionPolicies {
+	return m.retention
+}
+
+// SessionPolicies returns the tenant session lifecycle policies the service applies
+func (m *MemoryService) SessionPolicies() *SessionPolicies {
+	return m.sessionPolicies
+}
+
+// APIKeys returns the API keys the data endpoints accept
+func (m *MemoryService) APIKeys() *APIKeys {
+	return m.apiKeys
+}
+
+// PromptTemplates returns the prompt templates of the service's LLM-backed features
+func (m *MemoryService) PromptTemplates() *PromptTemplates {
+	return m.templates
+}
+
+// Close releases the connections held by the service's clients, including those of
+// every data region. The service must not be used afterwards.
+func (m *MemoryService) Close() {
+	m.redisClient.Close()
+	m.vectorClient.Close()
+	closeContentStore(m.contentStore)
+	closeColdStore(m.coldStore)
+	for _, region := range m.regions {
+		region.redisClient.Close()
+		region.vectorClient.Close()
+		closeContentStore(region.contentStore)
+		closeColdStore(region.coldStore)
+	}
+	if m.qstashClient != nil {
+		m.qstashClient.Close()
+	}
+	m.notifier.Close()
+	if m.llmClient != nil {
+		m.llmClient.Close()
+	}
+	if closer, ok := m.embeddingClient.(interface{ Close() }); ok {
+		closer.Close()
 	}
 }
 
-// SaveMemory saves both short-term (Redis) and long-term (Vector) memory
-func (m *MemoryService) SaveMemory(req models.SaveMemoryRequest) error {
+// closeContentStore releases the connections of blob stores; plain Redis content stores
+// share the region's Redis client, which is closed separately
+func closeContentStore(store clients.ContentStore) {
+	if blobs, ok := store.(*clients.BlobContentStore); ok {
+		blobs.Close()
+	}
+}
+
+// SchedulerAvailable reports whether tasks can be published to QStash
+func (m *MemoryService) SchedulerAvailable() bool {
+	return m.qstashClient != nil
+}
+
+// SaveMemory saves both short-term (Redis) and long-term (Vector) memory.
+// When the tenant's embedding budget is exhausted the memory is either rejected
+// or stored without a vector, depending on the configured policy.
+func (m *MemoryService) SaveMemory(req models.SaveMemoryRequest) (*models.SaveMemoryResult, error) {
 	now := time.Now()
 	messageID := uuid.New().String()
 
-	// Create message for session
-	message := models.Message{
-		ID:        messageID,
-		Role:      req.Role,
-		Content:   req.Content,
-		Timestamp: now,
+	tenantID := req.TenantID
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	m = m.ForTenant(tenantID)
+	if err := validateAssistantID(req.AssistantID); err != nil {
+		return nil, err
+	}
+	if err := validateScope(req.Scope, req.TaskID); err != nil {
+		return nil, err
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateMemoryType(req); err != nil {
+		return nil, err
+	}
+	if req.Type == models.MemoryTypeInstruction {
+		if err := m.checkInstructionRoom(tenantID, req.UserID); err != nil {
+			return nil, err
+		}
+	}
+	var injections []string
+	if req.Type != models.MemoryTypeInstruction {
+		// Instruction memories are meant to steer the model, so only ordinary content is screened
+		var err error
+		if injections, err = screenContent(&req); err != nil {
+			return nil, err
+		}
+	}
+	quarantine, err := m.guardSave(tenantID, messageID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.validateOrigin(req); err != nil {
+		return nil, err
+	}
+	origin := models.OriginMessage
+	if req.Origin != "" {
+		origin = req.Origin
+	}
+	confidence, err := initialConfidence(origin, req.Confidence)
+	if err != nil {
+		return nil, err
+	}
+	trust, err := saveSourceTrust(req, origin)
+	if err != nil {
+		return nil, err
 	}
 
-	// Save to Redis (short-term memory)
+	// Closed sessions take no further messages
 	session, err := m.redisClient.GetSession(req.SessionID)
 	if err != nil {
 		// Create new session if not exists
 		session = &models.SessionData{
+			TenantID:     tenantID,
 			UserID:       req.UserID,
 			SessionID:    req.SessionID,
 			Messages:     []models.Message{},
@@ -53,54 +221,384 @@ func (m *MemoryService) SaveMemory(req models.SaveMemoryRequest) error {
 			CreatedAt:    now,
 		}
 	}
+	if err := checkSessionOpen(session); err != nil {
+		return nil, err
+	}
+	question, err := questionFor(req, session)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reserve the embedding budget before writing anything; a client-supplied embedding
+	// costs nothing. The reservation is kept if embedding fails, as a save job may retry.
+	tokens := EstimateTokens(req.Content)
+	withinBudget := true
+	if len(req.Embedding) > 0 {
+		if err := m.checkEmbedding(req.Embedding); err != nil {
+			return nil, err
+		}
+	} else {
+		withinBudget, err = m.reserveEmbedding(tenantID, tokens)
+		if err != nil {
+			return nil, err
+		}
+		// Keyword-only memories are neither deleted per task, listed as instructions nor
+		// quarantined, so such memories are rejected instead
+		if !withinBudget && (config.AppConfig.EmbeddingBudgetPolicy != models.StorageKeywordOnly || req.TaskID != "" || req.Type != "" || quarantine != "") {
+			return nil, ErrEmbeddingBudgetExceeded
+		}
+	}
+
+	// Create message for session
+	message := models.Message{
+		ID:        messageID,
+		Role:      req.Role,
+		Content:   req.Content,
+		Timestamp: now,
+		Tags:      tags,
+	}
+
+	// Save to Redis (short-term memory)
 
 	// Add message to session
-	session.Messages = append(session.Messages, message)
 	session.LastActivity = now
+	session.TTLSeconds = m.sessionTTLSeconds(tenantID)
 
-	if err := m.redisClient.SaveSession(session); err != nil {
-		return fmt.Errorf("failed to save session: %w", err)
+	if err := m.redisClient.AppendSessionMessage(session, message); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
 	}
-
-	// Generate embedding for long-term memory
-	embedding, err := m.embeddingClient.GenerateEmbedding(req.Content)
-	if err != nil {
-		return fmt.Errorf("failed to generate embedding: %w", err)
+	m.condenseSessionLater(session)
+	if err := m.redisClient.RecordUserActivity(req.UserID); err != nil {
+		fmt.Printf("Warning: %v\n", err)
 	}
 
-	// Create memory entry for vector storage
+	// Create memory entry for long-term storage
 	memoryEntry := &models.MemoryEntry{
-		ID:        messageID,
-		UserID:    req.UserID,
-		Content:   req.Content,
-		Embedding: embedding,
+		ID:      messageID,
+		UserID:  req.UserID,
+		Content: req.Content,
 		Metadata: map[string]interface{}{
+			"tenant_id":  tenantID,
 			"session_id": req.SessionID,
 			"role":       req.Role,
 		},
 		Timestamp: now,
 		TTL:       30 * 24 * 60 * 60, // 30 days TTL
+		Tags:      tags,
+	}
+	if req.AssistantID != "" {
+		memoryEntry.Metadata["assistant_id"] = req.AssistantID
+	}
+	if req.TaskID != "" {
+		scopeTask(memoryEntry, req.TaskID)
+	}
+	if req.Type == models.MemoryTypeInstruction {
+		// Instructions hold until they are deleted
+		memoryEntry.Metadata["memory_type"] = req.Type
+		memoryEntry.Metadata["priority"] = req.Priority
+		memoryEntry.TTL = 0
+	}
+	if len(injections) > 0 {
+		if config.AppConfig.PoisoningGuardMode == "strip" {
+			memoryEntry.Metadata["stripped_injections"] = injections
+		} else {
+			memoryEntry.Metadata["untrusted"] = true
+			memoryEntry.Metadata["injection_patterns"] = injections
+		}
+	}
+	if quarantine != "" {
+		memoryEntry.Metadata["quarantine_reason"] = quarantine
+		memoryEntry.Metadata["quarantined_at"] = now.Unix()
+	}
+
+	// Record how the memory came to exist so its provenance can be audited
+	memoryEntry.Metadata["origin"] = origin
+	memoryEntry.Metadata["source_trust"] = trust
+	if len(req.ParentIDs) > 0 {
+		memoryEntry.Metadata["parent_ids"] = req.ParentIDs
+	}
+	if req.Source != "" {
+		memoryEntry.Metadata["import_source"] = req.Source
+	}
+	if confidence != nil {
+		memoryEntry.Metadata["confidence"] = *confidence
+		memoryEntry.Metadata["confirmed_at"] = now.Unix()
+	}
+	if question != nil {
+		pairWithQuestion(memoryEntry, question)
+	}
+
+	save := &longTermSave{
+		req:          req,
+		memory:       memoryEntry,
+		tenantID:     tenantID,
+		withinBudget: withinBudget,
+		quarantine:   quarantine,
+	}
+	// Async saves answer now; the memory is embedded and stored by a save worker
+	if m.saveAsync(req) {
+		if result := m.saveLater(save); result != nil {
+			return result, nil
+		}
+	}
+	result, err := m.storeLongTerm(save)
+	if err != nil {
+		return nil, err
+	}
+	m.linkAnswerLater(tenantID, memoryEntry, result)
+	return result, nil
+}
+
+// longTermSave is a memory already added to its session, waiting to be stored long-term
+type longTermSave struct {
+	req          models.SaveMemoryRequest
+	memory       *models.MemoryEntry
+	tenantID     string
+	withinBudget bool
+	quarantine   string
+}
+
+// storeLongTerm embeds a saved message and writes it to the vector store, or keeps it
+// searchable by keyword only when the save is over the embedding budget
+func (m *MemoryService) storeLongTerm(save *longTermSave) (*models.SaveMemoryResult, error) {
+	req, memoryEntry, tenantID, quarantine := save.req, save.memory, save.tenantID, save.quarantine
+	messageID := memoryEntry.ID
+	var err error
+
+	// Over budget: keep the memory searchable by keyword without paying for an embedding
+	if !save.withinBudget {
+		if err := m.redisClient.SaveKeywordMemory(memoryEntry); err != nil {
+			return nil, fmt.Errorf("failed to save keyword memory: %w", err)
+		}
+		m.indexForSearch(memoryEntry)
+		m.publish(EventMemorySaved, tenantID, req.UserID, messageID)
+		return &models.SaveMemoryResult{MemoryID: messageID, Storage: models.StorageKeywordOnly}, nil
+	}
+
+	// Generate embedding for long-term memory unless the client supplied one
+	embedding := req.Embedding
+	provenance := m.currentProvenance()
+	if len(embedding) == 0 {
+		// The tokens were reserved by SaveMemory
+		embedding, provenance, err = m.embedWithProvenance(req.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate embedding: %w", err)
+		}
+	}
+	memoryEntry.Embedding = embedding
+
+	// Record which model produced the vector so stale memories can be found later
+	memoryEntry.Metadata["embedding_provider"] = provenance.Provider
+	memoryEntry.Metadata["embedding_model"] = provenance.Model
+	memoryEntry.Metadata["embedding_version"] = provenance.Version
+
+	// Restated facts reinforce the memory that already holds them; task scratchpad,
+	// instructions and quarantined memories never do
+	var reinforcedID string
+	if req.TaskID == "" && req.Type == "" && quarantine == "" {
+		reinforcedID, err = m.reinforceSimilar(memoryEntry, tenantID)
+		if err != nil {
+			fmt.Printf("Warning: failed to reinforce similar memory: %v\n", err)
+		}
+	}
+	skipDuplicate := config.AppConfig.ReinforcementSkipDuplicates
+	if req.SkipDuplicate != nil {
+		skipDuplicate = *req.SkipDuplicate
+	}
+	if reinforcedID != "" && skipDuplicate {
+		m.publish(EventMemoryUpdated, tenantID, req.UserID, reinforcedID)
+		return &models.SaveMemoryResult{MemoryID: reinforcedID, Storage: models.StorageReinforced, ReinforcedID: reinforcedID}, nil
 	}
 
 	// Save to Vector DB (long-term memory)
 	if err := m.vectorClient.UpsertMemory(memoryEntry); err != nil {
-		return fmt.Errorf("failed to save vector memory: %w", err)
+		if !config.AppConfig.WriteAheadQueueEnabled {
+			return nil, fmt.Errorf("failed to save vector memory: %w", err)
+		}
+		// Accept the save and write the memory once the vector store is back
+		if queueErr := m.queueVectorWrite(memoryEntry, tenantID, err); queueErr != nil {
+			return nil, fmt.Errorf("failed to save vector memory: %w (not queued: %v)", err, queueErr)
+		}
+		return &models.SaveMemoryResult{MemoryID: messageID, Storage: models.StorageQueued, Quarantined: quarantine != "", DegradedReason: err.Error()}, nil
 	}
+	m.publish(EventMemorySaved, tenantID, req.UserID, messageID)
+	m.canarySave(memoryEntry)
+	// A quarantined memory stays out of search and alerts until it is released
+	if quarantine != "" {
+		return &models.SaveMemoryResult{MemoryID: messageID, Storage: models.StorageVector, Quarantined: true}, nil
+	}
+	m.indexForSearch(memoryEntry)
+	m.matchStandingQueries(tenantID, memoryEntry)
 
-	return nil
+	return &models.SaveMemoryResult{MemoryID: messageID, Storage: models.StorageVector, ReinforcedID: reinforcedID}, nil
+}
+
+// indexForSearch adds a memory to the RediSearch index when full-text search is enabled
+func (m *MemoryService) indexForSearch(memory *models.MemoryEntry) {
+	if !config.AppConfig.RedisSearchEnabled {
+		return
+	}
+	if err := m.redisClient.IndexMemory(memory); err != nil {
+		fmt.Printf("Warning: failed to index memory %s for full-text search: %v\n", memory.ID, err)
+	}
+}
+
+// recordEmbeddingUsage adds spent tokens to the tenant's budget without failing the request
+func (m *MemoryService) recordEmbeddingUsage(tenantID string, tokens int64) {
+	if err := m.budget.Record(m.budgetTenant(tenantID), tokens); err != nil {
+		fmt.Printf("Warning: failed to record embedding usage for tenant %s: %v\n", tenantID, err)
+	}
+}
+
+// currentProvenance describes the embedding model new vectors are created with
+func (m *MemoryService) currentProvenance() models.EmbeddingProvenance {
+	return models.EmbeddingProvenance{
+		Provider: string(m.embeddingClient.GetProvider()),
+		Model:    m.embeddingClient.GetModel(),
+		Version:  config.AppConfig.EmbeddingVersion,
+	}
+}
+
+// embedWithProvenance embeds text and describes the model that produced the vector, which
+// under failover may differ from currentProvenance
+func (m *MemoryService) embedWithProvenance(text string) ([]float64, models.EmbeddingProvenance, error) {
+	embedding, source, err := m.embeddingClient.GenerateEmbeddingWithSource(text)
+	if err != nil {
+		return nil, models.EmbeddingProvenance{}, err
+	}
+	return embedding, models.EmbeddingProvenance{
+		Provider: string(source.Provider),
+		Model:    source.Model,
+		Version:  config.AppConfig.EmbeddingVersion,
+	}, nil
+}
+
+// GetEmbeddingBudget returns today's embedding usage for a tenant
+func (m *MemoryService) GetEmbeddingBudget(tenantID string) (map[string]interface{}, error) {
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	return m.budget.Usage(tenantID)
 }
 
 // QueryMemory searches for relevant memories using semantic similarity
 func (m *MemoryService) QueryMemory(req models.QueryMemoryRequest) (*models.QueryMemoryResponse, error) {
-	fmt.Printf("🔍 QueryMemory: UserID=%s, Query=%s, Limit=%d, MinScore=%f\n", req.UserID, req.Query, req.Limit, req.MinScore)
+	// Validate the request before paying for an embedding
+	filter, err := queryFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Facts the query names answer it exactly; they are read before the cache, which
+	// fact writes do not invalidate
+	if facts := m.ForTenant(req.TenantID).factAnswer(req); len(facts) > 0 {
+		response := &models.QueryMemoryResponse{Results: []models.MemoryResult{}, Facts: facts}
+		if req.Trace {
+			response.Trace = newQueryTracer(true).finish()
+		}
+		return response, nil
+	}
+
+	// Repeated queries are served from the cache until the user's memories change; traced
+	// queries always run the full pipeline
+	if cached, ok := m.queryCache.get(req); ok && !req.Trace {
+		m.ForTenant(req.TenantID).recordRetrievals(req.UserID, cached.Results)
+		return cached, nil
+	}
+
+	tenantID := req.TenantID
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	m = m.ForTenant(tenantID)
 
 	// Generate embedding for query
-	queryEmbedding, err := m.embeddingClient.GenerateEmbedding(req.Query)
+	trace := newQueryTracer(req.Trace)
+	queryEmbedding, err := m.queryEmbedding(tenantID, req)
+	if err != nil {
+		return nil, err
+	}
+	source := "generated"
+	if len(req.Embedding) > 0 {
+		source = "client"
+	}
+	trace.embedding(source, len(queryEmbedding))
+
+	results, err := m.rankTraced(req, filter, queryEmbedding, trace)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+		// Keep short-term memory available while the vector store is down
+		if degraded, degradeErr := m.degradedQuery(req, err); degradeErr == nil {
+			if req.Trace {
+				degraded.Trace = trace.finish()
+			}
+			return degraded, nil
+		}
+		return nil, err
+	}
+	m.recordRetrievals(req.UserID, results)
+	m.canaryQuery(req.Query, queryEmbedding, results)
+
+	response := &models.QueryMemoryResponse{
+		Results: results,
+		Total:   len(results),
+	}
+	if req.Trace {
+		response.Trace = trace.finish()
+		return response, nil
 	}
-	fmt.Printf("📊 Generated embedding with %d dimensions\n", len(queryEmbedding))
+	m.queryCache.put(req, response)
 
+	return response, nil
+}
+
+// queryFilter validates a query and returns the vector filter for its granularity, assistant,
+// task and tags, leaving out quarantined memories
+func queryFilter(req models.QueryMemoryRequest) (string, error) {
+	if _, err := newContentMatcher(req.ContentFilter); err != nil {
+		return "", err
+	}
+	filter, err := granularityFilter(req.Granularity)
+	if err != nil {
+		return "", err
+	}
+	if err := validateQueryMode(req.Mode); err != nil {
+		return "", err
+	}
+	if err := validateAssistantID(req.AssistantID); err != nil {
+		return "", err
+	}
+	if req.TaskID != "" {
+		if err := validateTaskID(req.TaskID); err != nil {
+			return "", err
+		}
+	}
+	if req.MinConfidence < 0 || req.MinConfidence > 1 {
+		return "", fmt.Errorf("%w: min_confidence %v is not between 0 and 1", ErrInvalidConfidence, req.MinConfidence)
+	}
+	if err := validateTrustQuery(req); err != nil {
+		return "", err
+	}
+	if err := validateDedupe(req); err != nil {
+		return "", err
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return "", err
+	}
+	if req.DeepRecall && !config.AppConfig.ColdTierEnabled() {
+		return "", fmt.Errorf("%w: deep_recall requires COLD_TIER_AFTER_DAYS", ErrColdTierDisabled)
+	}
+	return joinFilters(filter, assistantFilter(req.AssistantID), taskFilter(req.TaskID), tagFilter(tags), "HAS NOT FIELD quarantine_reason"), nil
+}
+
+// rankMemories runs a query whose embedding is already known against the vector store, and
+// the cold tier for deep recall, and applies reinforcement, source trust, confidence, dedupe and content post-filters
+func (m *MemoryService) rankMemories(req models.QueryMemoryRequest, filter string, queryEmbedding []float64) ([]models.MemoryResult, error) {
+	return m.rankTraced(req, filter, queryEmbedding, nil)
+}
+
+// rankTraced is rankMemories recording each stage's ranking in trace, which may be nil
+func (m *MemoryService) rankTraced(req models.QueryMemoryRequest, filter string, queryEmbedding []float64, trace *queryTracer) ([]models.MemoryResult, error) {
 	// Set default values
 	limit := req.Limit
 	if limit <= 0 {
@@ -111,29 +609,101 @@ func (m *MemoryService) QueryMemory(req models.QueryMemoryRequest) (*models.Quer
 	if minScore <= 0 {
 		minScore = 0.5 // Lower default similarity threshold for better recall
 	}
-	fmt.Printf("⚙️ Using limit=%d, minScore=%f\n", limit, minScore)
 
-	// Query vector database
-	results, err := m.vectorClient.QueryMemories(req.UserID, queryEmbedding, limit, minScore)
+	// Query vector database, widening the window when low-confidence or duplicate results will be dropped
+	candidates := candidateLimit(limit, req.ContentFilter)
+	if req.MinConfidence > 0 || len(req.SourceTrust) > 0 || len(req.Dedupe) > 0 {
+		candidates = widenedLimit(limit)
+	}
+	trace.filters(models.TraceFilters{
+		VectorFilter:  filter,
+		MinScore:      minScore,
+		Candidates:    candidates,
+		Limit:         limit,
+		MinConfidence: req.MinConfidence,
+		SourceTrust:   req.SourceTrust,
+		Dedupe:        req.Dedupe,
+		MaxPerGroup:   req.MaxPerGroup,
+		ContentFilter: req.ContentFilter,
+	})
+	results, err := m.vectorClient.QueryMemories(req.UserID, filter, req.Query, queryEmbedding, candidates, minScore)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query memories: %w", err)
+		return nil, fmt.Errorf("%w: failed to query memories: %v", ErrVectorUnavailable, err)
+	}
+	trace.stage("index", results)
+	if req.DeepRecall {
+		cold, err := m.deepRecall(req, queryEmbedding, minScore)
+		if err != nil {
+			return nil, err
+		}
+		results = mergeByScore(results, cold, candidates)
+		trace.stage("cold_tier", results)
+	}
+	if req.Mode == models.QueryModeHybrid {
+		keyword, err := m.vectorClient.SearchKeywords(req.UserID, filter, req.Query, candidates)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrVectorUnavailable, err)
+		}
+		results = fuseRanks(results, keyword, candidates)
+		trace.stage("hybrid_fusion", results)
 	}
-	fmt.Printf("📋 Vector query returned %d results\n", len(results))
 
-	response := &models.QueryMemoryResponse{
-		Results: results,
-		Total:   len(results),
+	// Rank restated memories higher and weigh them by source trust, then apply confidence,
+	// dedupe and content post-filters over the candidates
+	applyReinforcement(results)
+	trace.stage("reinforcement", results)
+	results = applySourceTrust(results, req)
+	trace.stage("source_trust", results)
+	results = applyConfidence(results, req.MinConfidence)
+	trace.stage("confidence", results)
+	if len(req.Dedupe) > 0 {
+		results = dedupeResults(results, req.Dedupe, req.MaxPerGroup)
+		trace.stage("dedupe", results)
+	}
+	results, err = filterByContent(results, req.ContentFilter, limit)
+	if err != nil {
+		return nil, err
+	}
+	trace.stage("content_filter", results)
+	if len(results) > limit {
+		results = results[:limit]
 	}
+	trace.stage("limit", results)
+	pairTurns(results)
 
-	return response, nil
+	// Flag memories embedded by a different model than the current one
+	current := m.currentProvenance()
+	for _, result := range results {
+		if result.Provenance != nil {
+			result.Provenance.Stale = result.Provenance.Provider != current.Provider ||
+				result.Provenance.Model != current.Model ||
+				result.Provenance.Version != current.Version
+		}
+	}
+
+	return results, nil
+}
+
+// recordRetrievals remembers what a query returned so the stale memory review queue can skip it
+func (m *MemoryService) recordRetrievals(userID string, results []models.MemoryResult) {
+	retrieved := make([]string, len(results))
+	for i, result := range results {
+		retrieved[i] = result.ID
+	}
+	if err := m.redisClient.RecordRetrievals(userID, retrieved, time.Now().Unix()); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
 }
 
 // GetSession retrieves current session data
-func (m *MemoryService) GetSession(sessionID string) (*models.SessionData, error) {
-	session, err := m.redisClient.GetSession(sessionID)
+func (m *MemoryService) GetSession(sessionID string, window *models.MessageWindow) (*models.SessionData, error) {
+	session, err := m.redisClient.GetSessionCached(sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
+	if window != nil {
+		pageMessages(session, *window)
+	}
 
 	// Update last activity
 	if err := m.redisClient.UpdateSessionActivity(sessionID); err != nil {
@@ -144,13 +714,63 @@ func (m *MemoryService) GetSession(sessionID string) (*models.SessionData, error
 	return session, nil
 }
 
+// pageMessages narrows a session's messages to a window, describing it in session.Page
+func pageMessages(session *models.SessionData, window models.MessageWindow) {
+	messages := session.Messages
+	if !window.Since.IsZero() {
+		messages = make([]models.Message, 0, len(session.Messages))
+		for _, message := range session.Messages {
+			if message.Timestamp.After(window.Since) {
+				messages = append(messages, message)
+			}
+		}
+	}
+
+	page := &models.MessagePage{Offset: window.Offset, Limit: window.Limit, Total: len(messages)}
+	if !window.Since.IsZero() {
+		since := window.Since
+		page.Since = &since
+	}
+	if window.Offset >= len(messages) {
+		messages = messages[len(messages):]
+	} else {
+		messages = messages[window.Offset:]
+	}
+	if window.Limit > 0 && window.Limit < len(messages) {
+		messages = messages[:window.Limit]
+		page.Next = window.Offset + window.Limit
+	}
+
+	session.Messages = messages
+	session.Page = page
+}
+
 // GetUserSessions retrieves all sessions for a user
 func (m *MemoryService) GetUserSessions(userID string) ([]string, error) {
 	return m.redisClient.GetUserSessions(userID)
 }
 
-// DeleteSession removes a session and optionally its memories
-func (m *MemoryService) DeleteSession(sessionID string, deleteMemories bool) error {
+// DeleteSession removes a session and optionally its memories. The legal hold checked
+// is that of the tenant stored on the session, falling back to tenantID for legacy sessions.
+func (m *MemoryService) DeleteSession(sessionID string, deleteMemories bool, tenantID string) error {
+	m = m.ForTenant(tenantID)
+
+	session, err := m.redisClient.GetSessionCached(sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	policyTenant := tenantID
+	if session != nil && session.TenantID != "" {
+		policyTenant = session.TenantID
+	}
+	policy, err := m.retention.Get(policyTenant)
+	if err != nil {
+		return err
+	}
+	if err := checkLegalHold(policy); err != nil {
+		return err
+	}
+
 	// Get session first to get user ID (if needed for memory deletion)
 	if deleteMemories {
 		_, err := m.redisClient.GetSession(sessionID)
@@ -170,6 +790,16 @@ func (m *MemoryService) DeleteSession(sessionID string, deleteMemories bool) err
 
 // SetSessionContext updates session context
 func (m *MemoryService) SetSessionContext(sessionID string, context map[string]interface{}) error {
+	if err := m.guardContext(sessionID, context); err != nil {
+		return err
+	}
+	session, err := m.redisClient.GetSession(sessionID)
+	if err != nil {
+		return err
+	}
+	if err := checkSessionOpen(session); err != nil {
+		return err
+	}
 	return m.redisClient.SetSessionContext(sessionID, context)
 }
 
@@ -188,18 +818,188 @@ func (m *MemoryService) GetMemoryStats() (map[string]interface{}, error) {
 	return stats, nil
 }
 
-// CleanupExpiredMemories removes expired memories from vector database
-func (m *MemoryService) CleanupExpiredMemories() error {
-	return m.vectorClient.DeleteExpiredMemories()
+// CleanupExpiredMemories removes expired memories from vector database.
+// A memory expires when its TTL or its tenant's maximum retention has passed,
+// unless the tenant is under legal hold or the minimum retention has not elapsed.
+// An empty tenantID cleans up every tenant.
+func (m *MemoryService) CleanupExpiredMemories(tenantID string) error {
+	if tenantID != "" {
+		return m.ForTenant(tenantID).cleanupExpiredMemories(tenantID)
+	}
+	return m.forEachRegion(func(region *MemoryService) error {
+		return region.cleanupExpiredMemories("")
+	})
+}
+
+func (m *MemoryService) cleanupExpiredMemories(onlyTenant string) error {
+	// Query all memories (this is a simplified approach)
+	matches, err := m.vectorClient.ListAllMemories(10000)
+	if err != nil {
+		return fmt.Errorf("failed to query memories for cleanup: %w", err)
+	}
+
+	now := time.Now()
+	policies := make(map[string]*models.RetentionPolicy)
+
+	// Check each memory for expiration
+	var expired []string
+	expiredByUser := make(map[string][]string)
+	for _, match := range matches {
+		timestampFloat, ok := match.Metadata["timestamp"].(float64)
+		if !ok {
+			continue
+		}
+		ttlFloat, _ := match.Metadata["ttl"].(float64)
+
+		tenantID := metadataTenant(match.Metadata)
+		if onlyTenant != "" && tenantID != onlyTenant {
+			continue
+		}
+		policy, ok := policies[tenantID]
+		if !ok {
+			policy, err = m.retention.Get(tenantID)
+			if err != nil {
+				return err
+			}
+			policies[tenantID] = policy
+		}
+
+		createdAt := time.Unix(int64(timestampFloat), 0)
+		if isExpired(policy, createdAt, time.Duration(ttlFloat)*time.Second, now) {
+			expired = append(expired, match.ID)
+			userID, _ := match.Metadata["user_id"].(string)
+			user := queryCacheUser(tenantID, userID)
+			expiredByUser[user] = append(expiredByUser[user], match.ID)
+		}
+	}
+
+	if err := m.vectorClient.DeleteMemories(expired); err != nil {
+		return fmt.Errorf("failed to delete expired memories: %w", err)
+	}
+	for user, ids := range expiredByUser {
+		tenantID, userID, _ := strings.Cut(user, "|")
+		m.publish(EventMemoryDeleted, tenantID, userID, ids...)
+	}
+
+	return nil
 }
 
-// CleanupUserMemories removes all memories for a specific user
-func (m *MemoryService) CleanupUserMemories(userID string) error {
-	// Delete from vector database
-	if err := m.vectorClient.DeleteUserMemories(userID); err != nil {
+// CleanupUserMemories removes all memories for a specific user. Each memory is checked
+// against the retention policy of the tenant stored on it: a legal hold refuses the
+// cleanup, and memories within the minimum retention period are kept.
+func (m *MemoryService) CleanupUserMemories(userID string, tenantID string) error {
+	m = m.ForTenant(tenantID)
+
+	policies := make(map[string]*models.RetentionPolicy)
+	policyFor := func(tenantID string) (*models.RetentionPolicy, error) {
+		if policy, ok := policies[tenantID]; ok {
+			return policy, nil
+		}
+		policy, err := m.retention.Get(tenantID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkLegalHold(policy); err != nil {
+			return nil, err
+		}
+		policies[tenantID] = policy
+		return policy, nil
+	}
+	// The caller's tenant owns the sessions removed below
+	if _, err := policyFor(tenantID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var expired []string
+	retained := make(map[string]bool)
+	err := m.forEachUserMemoryPage(userID, func(matches []clients.QueryMatch) error {
+		for _, match := range matches {
+			policy, err := policyFor(metadataTenant(match.Metadata))
+			if err != nil {
+				return err
+			}
+			if timestampFloat, ok := match.Metadata["timestamp"].(float64); ok {
+				if checkMinRetention(policy, time.Unix(int64(timestampFloat), 0), now) != nil {
+					retained[match.ID] = true
+					continue
+				}
+			}
+			expired = append(expired, match.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var keywordRetained []models.MemoryEntry
+	keywordMemories, err := m.redisClient.GetKeywordMemories(userID)
+	if err != nil {
+		return err
+	}
+	for _, memory := range keywordMemories {
+		policy, err := policyFor(metadataTenant(memory.Metadata))
+		if err != nil {
+			return err
+		}
+		if checkMinRetention(policy, memory.Timestamp, now) != nil {
+			keywordRetained = append(keywordRetained, memory)
+		}
+	}
+
+	// The user's memories can all be deleted at once only when no policy involved has a
+	// minimum retention; otherwise memories saved since the scan might have to be kept
+	bulkDelete := len(retained) == 0
+	for _, policy := range policies {
+		if policy.MinRetentionSeconds > 0 {
+			bulkDelete = false
+		}
+	}
+	if bulkDelete {
+		// Delete from vector database
+		if err := m.vectorClient.DeleteUserMemories(userID); err != nil {
+			return fmt.Errorf("failed to delete user memories from vector DB: %w", err)
+		}
+	} else if err := m.vectorClient.DeleteMemories(expired); err != nil {
 		return fmt.Errorf("failed to delete user memories from vector DB: %w", err)
 	}
 
+	// Delete full-text index entries, keeping those of retained memories
+	if config.AppConfig.RedisSearchEnabled {
+		keys, err := m.redisClient.ListUserIndexedKeys(userID)
+		if err != nil {
+			return fmt.Errorf("failed to find user memories in search index: %w", err)
+		}
+		var remove []string
+		for _, key := range keys {
+			if !retained[strings.TrimPrefix(key, "memory:")] {
+				remove = append(remove, key)
+			}
+		}
+		if _, err := m.redisClient.DeleteKeys(remove...); err != nil {
+			return fmt.Errorf("failed to delete user memories from search index: %w", err)
+		}
+	}
+
+	// Delete keyword-only memories stored while over budget; they are stored together,
+	// so the retained ones are written back
+	if err := m.redisClient.DeleteKeywordMemories(userID); err != nil {
+		return fmt.Errorf("failed to delete keyword memories: %w", err)
+	}
+	for i := range keywordRetained {
+		if err := m.redisClient.SaveKeywordMemory(&keywordRetained[i]); err != nil {
+			return fmt.Errorf("failed to keep retained keyword memory: %w", err)
+		}
+	}
+
+	// Delete the retrieval records used by the stale memory review queue
+	if len(retained) == 0 && len(keywordRetained) == 0 {
+		if _, err := m.redisClient.DeleteKeys(fmt.Sprintf("memory_retrievals:%s", userID)); err != nil {
+			return fmt.Errorf("failed to delete memory retrievals: %w", err)
+		}
+	}
+
 	// Delete user sessions from Redis
 	sessions, err := m.redisClient.GetUserSessions(userID)
 	if err != nil {
@@ -212,26 +1012,40 @@ func (m *MemoryService) CleanupUserMemories(userID string) error {
 		}
 	}
 
+	m.publish(EventMemoryDeleted, tenantID, userID)
 	return nil
 }
 
-// ScheduleCleanup schedules periodic cleanup tasks
-func (m *MemoryService) ScheduleCleanup(callbackURL string) (string, error) {
-	// Schedule daily cleanup at 2 AM
-	cronExpression := "0 2 * * *"
+// ScheduleDelayedSessionCleanup schedules deletion of a session after delay
+func (m *MemoryService) ScheduleDelayedSessionCleanup(callbackURL string, sessionID string, tenantID string, delaySeconds int, opts models.DeliveryOptions) (string, error) {
+	if !m.SchedulerAvailable() {
+		return "", ErrSchedulerUnavailable
+	}
+	if err := validateCallback(callbackURL, opts); err != nil {
+		return "", err
+	}
 
-	scheduleID, err := m.qstashClient.ScheduleCleanupTask(callbackURL, cronExpression)
+	messageID, err := m.qstashClient.PublishSessionCleanup(callbackURL, sessionID, tenantID, delaySeconds, opts)
 	if err != nil {
-		return "", fmt.Errorf("failed to schedule cleanup: %w", err)
+		m.recordPublishFailure("publish", "cleanup_session", callbackURL, tenantID, sessionID, err)
+		return "", fmt.Errorf("failed to schedule session cleanup: %w", err)
 	}
 
-	return scheduleID, nil
+	return messageID, nil
 }
 
 // ScheduleDelayedUserCleanup schedules cleanup for a specific user after delay
-func (m *MemoryService) ScheduleDelayedUserCleanup(callbackURL string, userID string, delaySeconds int) (string, error) {
-	messageID, err := m.qstashClient.PublishDelayedMemoryCleanup(callbackURL, userID, delaySeconds)
+func (m *MemoryService) ScheduleDelayedUserCleanup(callbackURL string, userID string, delaySeconds int, opts models.DeliveryOptions) (string, error) {
+	if !m.SchedulerAvailable() {
+		return "", ErrSchedulerUnavailable
+	}
+	if err := validateCallback(callbackURL, opts); err != nil {
+		return "", err
+	}
+
+	messageID, err := m.qstashClient.PublishDelayedMemoryCleanup(callbackURL, userID, delaySeconds, opts)
 	if err != nil {
+		m.recordPublishFailure("publish", "cleanup_user_memories", callbackURL, "", userID, err)
 		return "", fmt.Errorf("failed to schedule user cleanup: %w", err)
 	}
 
@@ -239,17 +1053,19 @@ func (m *MemoryService) ScheduleDelayedUserCleanup(callbackURL string, userID st
 }
 
 // GetRecentMemories retrieves recent memories for a user
-func (m *MemoryService) GetRecentMemories(userID string, limit int) ([]models.MemoryResult, error) {
+func (m *MemoryService) GetRecentMemories(userID string, limit int, filter models.ContentFilter) ([]models.MemoryResult, error) {
 	if limit <= 0 {
 		limit = 20
 	}
 
 	// Use a generic query to get recent memories
 	queryReq := models.QueryMemoryRequest{
-		UserID:   userID,
-		Query:    "recent conversation", // Generic query
-		Limit:    limit,
-		MinScore: 0.1, // Lower threshold for recent memories
+		UserID:        userID,
+		Query:         "recent conversation", // Generic query
+		Limit:         limit,
+		MinScore:      0.1, // Lower threshold for recent memories
+		SkipFacts:     true,
+		ContentFilter: filter,
 	}
 
 	response, err := m.QueryMemory(queryReq)
@@ -260,13 +1076,29 @@ func (m *MemoryService) GetRecentMemories(userID string, limit int) ([]models.Me
 	return response.Results, nil
 }
 
-// SearchMemoriesByKeyword searches memories using keyword matching
-func (m *MemoryService) SearchMemoriesByKeyword(userID string, keyword string, limit int) ([]models.MemoryResult, error) {
+// SearchMemoriesByKeyword searches memories using keyword matching. With RediSearch
+// enabled the mode selects keyword, prefix or fuzzy matching; otherwise the search
+// falls back to semantic similarity plus substring matches on keyword-only memories.
+func (m *MemoryService) SearchMemoriesByKeyword(userID string, keyword string, mode string, limit int, filter models.ContentFilter) ([]models.MemoryResult, error) {
+	if config.AppConfig.RedisSearchEnabled {
+		if _, err := newContentMatcher(filter); err != nil {
+			return nil, err
+		}
+		results, err := m.redisClient.SearchMemories(userID, keyword, mode, candidateLimit(limit, filter))
+		if err != nil {
+			return nil, err
+		}
+		return filterByContent(results, filter, limit)
+	}
+
 	queryReq := models.QueryMemoryRequest{
-		UserID:   userID,
-		Query:    keyword,
-		Limit:    limit,
-		MinScore: 0.6, // Higher threshold for keyword search
+		UserID:        userID,
+		Query:         keyword,
+		Limit:         limit,
+		MinScore:      0.6, // Higher threshold for keyword search
+		Mode:          models.QueryModeHybrid,
+		SkipFacts:     true,
+		ContentFilter: filter,
 	}
 
 	response, err := m.QueryMemory(queryReq)
@@ -274,13 +1106,53 @@ func (m *MemoryService) SearchMemoriesByKeyword(userID string, keyword string, l
 		return nil, err
 	}
 
-	return response.Results, nil
+	// Include memories that were stored without a vector
+	keywordMemories, err := m.redisClient.GetKeywordMemories(userID)
+	if err != nil {
+		fmt.Printf("Warning: failed to load keyword-only memories: %v\n", err)
+		return response.Results, nil
+	}
+
+	matcher, err := newContentMatcher(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	results := response.Results
+	needle := strings.ToLower(keyword)
+	for _, memory := range keywordMemories {
+		if len(results) >= limit {
+			break
+		}
+		if !strings.Contains(strings.ToLower(memory.Content), needle) || !matcher.Matches(memory.Content) {
+			continue
+		}
+
+		metadata := make(map[string]interface{}, len(memory.Metadata)+2)
+		for k, v := range memory.Metadata {
+			metadata[k] = v
+		}
+		metadata["id"] = memory.ID
+		metadata["storage"] = models.StorageKeywordOnly
+
+		results = append(results, models.MemoryResult{
+			ID:        memory.ID,
+			Content:   memory.Content,
+			Score:     1,
+			Metadata:  metadata,
+			Timestamp: memory.Timestamp,
+		})
+	}
+
+	return results, nil
 }
 
 // GetEmbeddingInfo returns information about the current embedding provider
 func (m *MemoryService) GetEmbeddingInfo() (map[string]interface{}, error) {
 	info := map[string]interface{}{
 		"provider":   string(m.embeddingClient.GetProvider()),
+		"model":      m.embeddingClient.GetModel(),
+		"version":    config.AppConfig.EmbeddingVersion,
 		"dimensions": m.embeddingClient.GetDimensions(),
 		"timestamp":  time.Now(),
 	}
@@ -289,20 +1161,47 @@ func (m *MemoryService) GetEmbeddingInfo() (map[string]interface{}, error) {
 	switch m.embeddingClient.GetProvider() {
 	case "jina":
 		info["api_url"] = "https://api.jina.ai/v1"
-		info["model"] = "jina-embeddings-v3"
 		info["features"] = []string{"multilingual", "high-performance", "normalized"}
 	case "openai":
 		info["api_url"] = "https://api.openai.com/v1"
-		info["model"] = config.AppConfig.OpenAIEmbeddingModel
 		info["features"] = []string{"high-quality", "widely-supported", "english-optimized"}
 	}
 
+	// Per-provider call statistics, for comparing providers' cost and reliability
+	info["usage"] = clients.EmbeddingUsage()
+	info["canary"] = m.canary.snapshot()
+
 	return info, nil
 }
 
 // DeleteMemory removes a specific memory by ID for a user
-func (m *MemoryService) DeleteMemory(memoryID string, userID string) error {
+func (m *MemoryService) DeleteMemory(memoryID string, userID string, tenantID string) error {
 	fmt.Printf("🗑️ DeleteMemory: ID=%s, UserID=%s\n", memoryID, userID)
+	m = m.ForTenant(tenantID)
+
+	// The retention policy is that of the tenant stored on the memory, not the caller's
+	memory, err := m.vectorClient.FetchMemory(memoryID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch memory: %w", err)
+	}
+	policyTenant := tenantID
+	if memory != nil {
+		policyTenant = metadataTenant(memory.Metadata)
+	}
+	policy, err := m.retention.Get(policyTenant)
+	if err != nil {
+		return err
+	}
+	if err := checkLegalHold(policy); err != nil {
+		return err
+	}
+	if memory != nil {
+		if timestampFloat, ok := memory.Metadata["timestamp"].(float64); ok {
+			if err := checkMinRetention(policy, time.Unix(int64(timestampFloat), 0), time.Now()); err != nil {
+				return err
+			}
+		}
+	}
 
 	// For security, we could verify ownership by querying the vector DB directly
 	// but for simplicity, we'll trust that the frontend sends the correct user_id
@@ -314,6 +1213,14 @@ func (m *MemoryService) DeleteMemory(memoryID string, userID string) error {
 		return fmt.Errorf("failed to delete memory: %w", err)
 	}
 
+	if config.AppConfig.RedisSearchEnabled {
+		if err := m.redisClient.DeleteIndexedMemory(memoryID); err != nil {
+			fmt.Printf("Warning: failed to remove memory %s from search index: %v\n", memoryID, err)
+		}
+	}
+
+	m.publish(EventMemoryDeleted, tenantID, userID, memoryID)
+
 	fmt.Printf("✅ Memory deleted successfully: %s\n", memoryID)
 	return nil
 }
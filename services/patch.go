@@ -0,0 +1,192 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrInvalidPatch is returned when a metadata patch cannot be applied
+var ErrInvalidPatch = errors.New("invalid metadata patch")
+
+// patchPageSize is the number of vectors scanned per page by a patch job
+const patchPageSize = 100
+
+// protectedMetadata lists fields that a metadata patch may not modify
+var protectedMetadata = map[string]bool{
+	"user_id":            true,
+	"tenant_id":          true,
+	"content":            true,
+	"timestamp":          true,
+	"ttl":                true,
+	"tags":               true, // use add_tags/remove_tags instead
+	"embedding_provider": true,
+	"embedding_model":    true,
+	"embedding_version":  true,
+	"origin":             true,
+	"parent_ids":         true,
+	"import_source":      true,
+	"source_trust":       true,
+	"confidence":         true, // use POST /memory/:id/verify instead
+	"confirmed_at":       true,
+	"content_truncated":  true,
+	"content_ref":        true,
+}
+
+// PatchUserMemories starts a background job applying a metadata patch to every
+// memory of a user that matches the filter. The job is recorded under tenantID.
+func (m *MemoryService) PatchUserMemories(userID string, tenantID string, req models.MemoryPatchRequest) (*models.Job, error) {
+	if len(req.AddTags) == 0 && len(req.RemoveTags) == 0 && len(req.Set) == 0 && len(req.Unset) == 0 {
+		return nil, fmt.Errorf("%w: patch has no changes", ErrInvalidPatch)
+	}
+	for field := range req.Set {
+		if protectedMetadata[field] {
+			return nil, fmt.Errorf("%w: field %q cannot be set", ErrInvalidPatch, field)
+		}
+	}
+	for _, field := range req.Unset {
+		if protectedMetadata[field] {
+			return nil, fmt.Errorf("%w: field %q cannot be unset", ErrInvalidPatch, field)
+		}
+	}
+
+	// Tags are stored lowercased, so patches and filters use the same form
+	for _, tags := range []*[]string{&req.AddTags, &req.RemoveTags, &req.Filter.Tags} {
+		normalized, err := normalizeTags(*tags)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		*tags = normalized
+	}
+
+	matcher, err := newContentMatcher(req.Filter.ContentFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.startJob("patch_user_memories", tenantID, userID, func(m *MemoryService, job *models.Job) error {
+		// The patch spans every tenant the user has memories in
+		patched := make(map[string][]string)
+		defer func() {
+			for tenantID, ids := range patched {
+				m.publish(EventMemoryUpdated, tenantID, userID, ids...)
+			}
+		}()
+
+		cursor := "0"
+		for {
+			matches, next, err := m.vectorClient.RangeMemories(cursor, patchPageSize)
+			if err != nil {
+				return err
+			}
+
+			for _, match := range matches {
+				job.Progress["scanned"]++
+				if !patchFilterMatches(userID, req.Filter, matcher, match.Metadata) {
+					continue
+				}
+
+				job.Progress["matched"]++
+				if err := m.vectorClient.UpdateMetadata(match.ID, applyMetadataPatch(match.Metadata, req)); err != nil {
+					job.Progress["failed"]++
+					fmt.Printf("Warning: failed to patch memory %s: %v\n", match.ID, err)
+					continue
+				}
+				job.Progress["updated"]++
+				tenantID := metadataTenant(match.Metadata)
+				patched[tenantID] = append(patched[tenantID], match.ID)
+			}
+			m.saveJob(job)
+
+			if next == "" || next == cursor {
+				return nil
+			}
+			cursor = next
+		}
+	})
+}
+
+// patchFilterMatches reports whether a vector belongs to the user and satisfies the filter
+func patchFilterMatches(userID string, filter models.MemoryPatchFilter, matcher *contentMatcher, metadata map[string]interface{}) bool {
+	if owner, _ := metadata["user_id"].(string); owner != userID {
+		return false
+	}
+	if filter.SessionID != "" {
+		if sessionID, _ := metadata["session_id"].(string); sessionID != filter.SessionID {
+			return false
+		}
+	}
+	if filter.Role != "" {
+		if role, _ := metadata["role"].(string); role != filter.Role {
+			return false
+		}
+	}
+	if len(filter.Tags) > 0 && !hasAnyTag(metadataTags(metadata), filter.Tags) {
+		return false
+	}
+
+	content, _ := metadata["content"].(string)
+	return matcher.Matches(content)
+}
+
+// applyMetadataPatch returns a copy of metadata with the patch applied
+func applyMetadataPatch(metadata map[string]interface{}, req models.MemoryPatchRequest) map[string]interface{} {
+	patched := make(map[string]interface{}, len(metadata)+len(req.Set)+1)
+	for k, v := range metadata {
+		patched[k] = v
+	}
+	for k, v := range req.Set {
+		patched[k] = v
+	}
+	for _, k := range req.Unset {
+		delete(patched, k)
+	}
+
+	if len(req.AddTags) > 0 || len(req.RemoveTags) > 0 {
+		remove := make(map[string]bool, len(req.RemoveTags))
+		for _, tag := range req.RemoveTags {
+			remove[tag] = true
+		}
+
+		seen := make(map[string]bool)
+		tags := []string{}
+		for _, tag := range append(metadataTags(metadata), req.AddTags...) {
+			if tag == "" || remove[tag] || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+		patched["tags"] = tags
+	}
+
+	return patched
+}
+
+// metadataTags extracts the tags list from vector metadata
+func metadataTags(metadata map[string]interface{}) []string {
+	var tags []string
+	switch values := metadata["tags"].(type) {
+	case []interface{}:
+		for _, value := range values {
+			if tag, ok := value.(string); ok {
+				tags = append(tags, tag)
+			}
+		}
+	case []string:
+		tags = append(tags, values...)
+	}
+	return tags
+}
+
+func hasAnyTag(tags []string, wanted []string) bool {
+	for _, tag := range tags {
+		for _, w := range wanted {
+			if tag == w {
+				return true
+			}
+		}
+	}
+	return false
+}
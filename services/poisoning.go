@@ -0,0 +1,109 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrPoisonedContent is returned when stripping embedded instructions leaves nothing to save
+var ErrPoisonedContent = errors.New("content consists of embedded instructions")
+
+// injectionPattern recognises one kind of instruction aimed at the model rather than a
+// fact about the user
+type injectionPattern struct {
+	name  string
+	regex *regexp.Regexp
+}
+
+var builtinInjectionPatterns = []injectionPattern{
+	{"ignore_instructions", regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b[^.!?\n]{0,40}\b(previous|prior|above|earlier|all|any|your)\b[^.!?\n]{0,20}\b(instructions?|prompts?|rules|directions)\b`)},
+	{"role_override", regexp.MustCompile(`(?i)\b(you are now|from now on,? you (are|will|must|should))\b`)},
+	{"new_instructions", regexp.MustCompile(`(?i)\b(new|updated|real) (instructions|rules|system prompt)\s*:`)},
+	{"system_prompt", regexp.MustCompile(`(?i)\b(reveal|print|repeat|show)\b[^.!?\n]{0,30}\b(system prompt|hidden instructions|developer message)\b`)},
+	{"concealment", regexp.MustCompile(`(?i)\bdo not (tell|inform|let) the user\b`)},
+	{"prompt_markup", regexp.MustCompile(`(?im)(<\|im_(start|end)\|>|\[/?INST\]|<</?SYS>>|^\s*#{2,}\s*(system|instructions?)\b)`)},
+}
+
+var (
+	injectionPatternsOnce sync.Once
+	injectionPatterns     []injectionPattern
+)
+
+// activeInjectionPatterns returns the built-in patterns and POISONING_EXTRA_PATTERN, which
+// config validation has already compiled once
+func activeInjectionPatterns() []injectionPattern {
+	injectionPatternsOnce.Do(func() {
+		injectionPatterns = builtinInjectionPatterns
+		if extra := config.AppConfig.PoisoningExtraPattern; extra != "" {
+			injectionPatterns = append(injectionPatterns[:len(injectionPatterns):len(injectionPatterns)],
+				injectionPattern{"custom", regexp.MustCompile(extra)})
+		}
+	})
+	return injectionPatterns
+}
+
+// detectInjections returns the names of the patterns text matches
+func detectInjections(text string) []string {
+	var matched []string
+	for _, pattern := range activeInjectionPatterns() {
+		if pattern.regex.MatchString(text) {
+			matched = append(matched, pattern.name)
+		}
+	}
+	return matched
+}
+
+// screenContent applies POISONING_GUARD_MODE to a save. In tag mode the content is kept
+// and the matched patterns are returned so the memory can be flagged untrusted; in strip
+// mode the sentences carrying instructions are removed from the content.
+func screenContent(req *models.SaveMemoryRequest) ([]string, error) {
+	mode := config.AppConfig.PoisoningGuardMode
+	if mode == "off" || mode == "" {
+		return nil, nil
+	}
+
+	matched := detectInjections(req.Content)
+	if len(matched) == 0 || mode == "tag" {
+		return matched, nil
+	}
+
+	var kept []string
+	for _, sentence := range splitSentences(req.Content) {
+		if len(detectInjections(sentence)) == 0 {
+			kept = append(kept, sentence)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("%w: matched %s", ErrPoisonedContent, strings.Join(matched, ", "))
+	}
+	req.Content = strings.Join(kept, " ")
+	// A precomputed embedding describes the content as it was sent
+	req.Embedding = nil
+	return matched, nil
+}
+
+// isUntrusted reports whether vector metadata belongs to a memory flagged for embedded
+// instructions
+func isUntrusted(metadata map[string]interface{}) bool {
+	untrusted, _ := metadata["untrusted"].(bool)
+	return untrusted
+}
+
+// withoutUntrusted drops memories flagged for embedded instructions so they are never
+// placed in a generated prompt. results may be shared with the query cache, so a new
+// slice is returned.
+func withoutUntrusted(results []models.MemoryResult) []models.MemoryResult {
+	kept := make([]models.MemoryResult, 0, len(results))
+	for _, result := range results {
+		if !isUntrusted(result.Metadata) {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+	// Embedded so timezones validate on hosts without a zoneinfo database
+	_ "time/tzdata"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrInvalidPreferences is returned for unknown timezones, malformed locales and formality levels
+var ErrInvalidPreferences = errors.New("invalid preferences")
+
+// localePattern accepts BCP 47 language tags such as en, fr-FR and zh-Hant-TW
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// validatePreferences checks each set preference
+func validatePreferences(preferences *models.UserPreferences) error {
+	if preferences.Timezone != "" {
+		if _, err := time.LoadLocation(preferences.Timezone); err != nil || preferences.Timezone == "Local" {
+			return fmt.Errorf("%w: unknown timezone %q (use an IANA name such as Europe/Paris)", ErrInvalidPreferences, preferences.Timezone)
+		}
+	}
+	if preferences.Locale != "" && !localePattern.MatchString(preferences.Locale) {
+		return fmt.Errorf("%w: locale %q is not a BCP 47 tag such as fr-FR", ErrInvalidPreferences, preferences.Locale)
+	}
+	switch preferences.Formality {
+	case "", models.FormalityFormal, models.FormalityNeutral, models.FormalityCasual:
+	default:
+		return fmt.Errorf("%w: unknown formality %q (use formal, neutral or casual)", ErrInvalidPreferences, preferences.Formality)
+	}
+	return nil
+}
+
+// GetUserPreferences returns a user's preferences, or nil if none are set
+func (m *MemoryService) GetUserPreferences(userID string) (*models.UserPreferences, error) {
+	return m.redisClient.GetUserPreferences(userID)
+}
+
+// SetUserPreferences validates and replaces a user's preferences
+func (m *MemoryService) SetUserPreferences(userID string, preferences *models.UserPreferences) error {
+	if err := validatePreferences(preferences); err != nil {
+		return err
+	}
+	preferences.UserID = userID
+	preferences.UpdatedAt = time.Now()
+	return m.redisClient.SaveUserPreferences(preferences)
+}
+
+// DeleteUserPreferences removes a user's preferences
+func (m *MemoryService) DeleteUserPreferences(userID string) error {
+	return m.redisClient.DeleteUserPreferences(userID)
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+)
+
+// PrewarmStatus describes the progress of the startup prewarm
+type PrewarmStatus struct {
+	Enabled        bool      `json:"enabled"`
+	Done           bool      `json:"done"`
+	Error          string    `json:"error,omitempty"`
+	UsersWarmed    int       `json:"users_warmed"`
+	SessionsWarmed int       `json:"sessions_warmed"`
+	StartedAt      time.Time `json:"started_at,omitempty"`
+	CompletedAt    time.Time `json:"completed_at,omitempty"`
+}
+
+var (
+	prewarmMu    sync.RWMutex
+	prewarmState PrewarmStatus
+)
+
+// GetPrewarmStatus returns a copy of the current prewarm state
+func GetPrewarmStatus() PrewarmStatus {
+	prewarmMu.RLock()
+	defer prewarmMu.RUnlock()
+
+	status := prewarmState
+	status.Enabled = config.AppConfig.PrewarmEnabled
+	return status
+}
+
+// PrewarmReady reports whether readiness may pass as far as prewarming is concerned
+func PrewarmReady() bool {
+	status := GetPrewarmStatus()
+	return !status.Enabled || (status.Done && status.Error == "")
+}
+
+// Prewarm validates every credential and loads the most active users' sessions and
+// each index's dimensions into local caches. Readiness fails until it succeeds.
+func (m *MemoryService) Prewarm() error {
+	prewarmMu.Lock()
+	prewarmState = PrewarmStatus{StartedAt: time.Now()}
+	prewarmMu.Unlock()
+
+	err := m.prewarm()
+
+	prewarmMu.Lock()
+	prewarmState.Done = true
+	prewarmState.CompletedAt = time.Now()
+	if err != nil {
+		prewarmState.Error = err.Error()
+	}
+	prewarmMu.Unlock()
+
+	return err
+}
+
+func (m *MemoryService) prewarm() error {
+	if _, err := clients.WithoutEmbeddingCache(m.embeddingClient).GenerateEmbedding("MemoryCacheAI prewarm"); err != nil {
+		return fmt.Errorf("embedding credentials check failed: %w", err)
+	}
+	if m.SchedulerAvailable() {
+		if _, err := m.qstashClient.GetSchedules(); err != nil {
+			return fmt.Errorf("qstash credentials check failed: %w", err)
+		}
+	}
+
+	return m.forEachRegion(func(region *MemoryService) error {
+		if err := region.redisClient.Ping(); err != nil {
+			return err
+		}
+		if _, err := region.vectorClient.GetDimensions(); err != nil {
+			return err
+		}
+
+		if config.AppConfig.PrewarmTopUsers == 0 {
+			return nil
+		}
+		users, err := region.redisClient.TopActiveUsers(config.AppConfig.PrewarmTopUsers)
+		if err != nil {
+			return err
+		}
+		for _, userID := range users {
+			sessions, err := region.redisClient.GetUserSessions(userID)
+			if err != nil {
+				return err
+			}
+
+			warmed := 0
+			for _, sessionID := range sessions {
+				// Sessions listed in the set may already have expired
+				if _, err := region.redisClient.WarmSession(sessionID); err == nil {
+					warmed++
+				}
+			}
+
+			prewarmMu.Lock()
+			prewarmState.UsersWarmed++
+			prewarmState.SessionsWarmed += warmed
+			prewarmMu.Unlock()
+		}
+
+		return nil
+	})
+}
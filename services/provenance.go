@@ -0,0 +1,144 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrInvalidProvenance is returned when a saved memory declares an unusable origin or parents
+var ErrInvalidProvenance = errors.New("invalid memory provenance")
+
+const (
+	// maxParentIDs bounds the parents a saved memory may declare
+	maxParentIDs = 20
+	// provenanceMaxDepth and provenanceMaxNodes bound a provenance walk
+	provenanceMaxDepth = 5
+	provenanceMaxNodes = 200
+)
+
+// validateOrigin checks the origin and parents declared by a save request. Parents must
+// be stored memories of the same user.
+func (m *MemoryService) validateOrigin(req models.SaveMemoryRequest) error {
+	switch req.Origin {
+	case "", models.OriginMessage, models.OriginImported:
+	case models.OriginExtracted:
+		if len(req.ParentIDs) == 0 {
+			return fmt.Errorf("%w: extracted memories must name the memories they were extracted from", ErrInvalidProvenance)
+		}
+	case models.OriginSummary:
+		return fmt.Errorf("%w: summaries are created by rollups", ErrInvalidProvenance)
+	default:
+		return fmt.Errorf("%w: unknown origin %q (use message, extracted or imported)", ErrInvalidProvenance, req.Origin)
+	}
+	if len(req.ParentIDs) > maxParentIDs {
+		return fmt.Errorf("%w: at most %d parent IDs are allowed", ErrInvalidProvenance, maxParentIDs)
+	}
+
+	for _, parentID := range req.ParentIDs {
+		parent, err := m.vectorClient.FetchMemory(parentID)
+		if err != nil {
+			return err
+		}
+		if parent == nil {
+			return fmt.Errorf("%w: parent memory %s does not exist", ErrInvalidProvenance, parentID)
+		}
+		if owner, _ := parent.Metadata["user_id"].(string); owner != req.UserID {
+			return fmt.Errorf("%w: parent memory %s belongs to another user", ErrInvalidProvenance, parentID)
+		}
+	}
+	return nil
+}
+
+// GetMemoryProvenance walks a memory's parent links breadth first, returning nil when the
+// memory does not exist or belongs to another user. Parents of other users are reported
+// as missing rather than revealed.
+func (m *MemoryService) GetMemoryProvenance(memoryID string, userID string, tenantID string) (*models.MemoryProvenance, error) {
+	m = m.ForTenant(tenantID)
+
+	provenance := &models.MemoryProvenance{MemoryID: memoryID}
+	visited := map[string]bool{memoryID: true}
+	frontier := []string{memoryID}
+
+	for depth := 0; len(frontier) > 0; depth++ {
+		if depth > provenanceMaxDepth {
+			provenance.Truncated = true
+			break
+		}
+
+		var next []string
+		for _, id := range frontier {
+			if len(provenance.Nodes) >= provenanceMaxNodes {
+				provenance.Truncated = true
+				return provenance, nil
+			}
+
+			match, err := m.vectorClient.FetchMemory(id)
+			if err != nil {
+				return nil, err
+			}
+			owner := ""
+			if match != nil {
+				owner, _ = match.Metadata["user_id"].(string)
+			}
+			if match == nil || owner != userID {
+				if depth == 0 {
+					return nil, nil
+				}
+				provenance.Nodes = append(provenance.Nodes, models.ProvenanceNode{ID: id, Depth: depth, Missing: true})
+				continue
+			}
+
+			memory := memoryFromMetadata(id, match.Metadata)
+			node := models.ProvenanceNode{
+				ID:        id,
+				Depth:     depth,
+				Origin:    memoryOrigin(memory.Metadata),
+				Content:   memory.Content,
+				ParentIDs: metadataStrings(memory.Metadata["parent_ids"]),
+				Timestamp: &memory.Timestamp,
+			}
+			node.SessionID, _ = memory.Metadata["session_id"].(string)
+			node.Source, _ = memory.Metadata["import_source"].(string)
+			provenance.Nodes = append(provenance.Nodes, node)
+
+			for _, parentID := range node.ParentIDs {
+				if !visited[parentID] {
+					visited[parentID] = true
+					next = append(next, parentID)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	return provenance, nil
+}
+
+// memoryOrigin returns a memory's recorded origin, inferring it for older memories
+func memoryOrigin(metadata map[string]interface{}) string {
+	if origin, ok := metadata["origin"].(string); ok && origin != "" {
+		return origin
+	}
+	if isSummary(metadata) {
+		return models.OriginSummary
+	}
+	return models.OriginMessage
+}
+
+// metadataStrings reads a string list from metadata, as decoded from JSON or set in memory
+func metadataStrings(value interface{}) []string {
+	var values []string
+	switch items := value.(type) {
+	case []interface{}:
+		for _, item := range items {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	case []string:
+		values = append(values, items...)
+	}
+	return values
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// queryCacheEntry is a query response and the time it stops being served
+type queryCacheEntry struct {
+	response  models.QueryMemoryResponse
+	expiresAt time.Time
+}
+
+// queryCache is a process-local cache of query responses, grouped by tenant and user so
+// a write can drop everything cached for its user at once. Other instances do not see
+// the invalidation, so QUERY_CACHE_TTL bounds how stale they can be.
+type queryCache struct {
+	mu      sync.Mutex
+	users   map[string]map[string]queryCacheEntry
+	entries int
+}
+
+func newQueryCache() *queryCache {
+	return &queryCache{users: make(map[string]map[string]queryCacheEntry)}
+}
+
+func queryCacheUser(tenantID string, userID string) string {
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	return tenantID + "|" + userID
+}
+
+// queryCacheKey identifies a request by all of its fields
+func queryCacheKey(req models.QueryMemoryRequest) string {
+	key, _ := json.Marshal(req)
+	return string(key)
+}
+
+// get returns a fresh cached response to req, if any
+func (q *queryCache) get(req models.QueryMemoryRequest) (*models.QueryMemoryResponse, bool) {
+	if config.AppConfig.QueryCacheTTL <= 0 {
+		return nil, false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.users[queryCacheUser(req.TenantID, req.UserID)][queryCacheKey(req)]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	response := entry.response
+	response.Results = append([]models.MemoryResult(nil), entry.response.Results...)
+	return &response, true
+}
+
+// put caches a response to req when the cache is enabled and has room
+func (q *queryCache) put(req models.QueryMemoryRequest, response *models.QueryMemoryResponse) {
+	ttl := config.AppConfig.QueryCacheTTL
+	if ttl <= 0 {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// Drop expired entries opportunistically so the cache does not grow unbounded
+	now := time.Now()
+	if q.entries >= config.AppConfig.QueryCacheSize {
+		for user, entries := range q.users {
+			for key, entry := range entries {
+				if now.After(entry.expiresAt) {
+					delete(entries, key)
+					q.entries--
+				}
+			}
+			if len(entries) == 0 {
+				delete(q.users, user)
+			}
+		}
+		if q.entries >= config.AppConfig.QueryCacheSize {
+			return
+		}
+	}
+
+	user := queryCacheUser(req.TenantID, req.UserID)
+	if q.users[user] == nil {
+		q.users[user] = make(map[string]queryCacheEntry)
+	}
+	key := queryCacheKey(req)
+	if _, exists := q.users[user][key]; !exists {
+		q.entries++
+	}
+	q.users[user][key] = queryCacheEntry{
+		response:  models.QueryMemoryResponse{Results: append([]models.MemoryResult(nil), response.Results...), Total: response.Total},
+		expiresAt: now.Add(ttl),
+	}
+}
+
+// invalidate drops every cached response of a user
+func (q *queryCache) invalidate(tenantID string, userID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	user := queryCacheUser(tenantID, userID)
+	q.entries -= len(q.users[user])
+	delete(q.users, user)
+}
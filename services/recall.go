@@ -0,0 +1,147 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// CheckRecall diagnoses "why didn't it remember" reports: it pages through every vector in
+// the index, computes exact cosine similarities between the query and the user's memories
+// visible to it (same granularity, assistant and tag rules as QueryMemory) and compares the
+// exact top results with what the index's approximate search returns. Content filters are
+// not applied. The scan reads the whole index, so it is meant for debugging only.
+func (m *MemoryService) CheckRecall(req models.QueryMemoryRequest) (*models.RecallReport, error) {
+	filter, err := queryFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantID := req.TenantID
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	m = m.ForTenant(tenantID)
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	minScore := req.MinScore
+	if minScore <= 0 {
+		minScore = 0.5
+	}
+
+	queryEmbedding, err := m.queryEmbedding(tenantID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ask the index without a score threshold so threshold and index misses can be told apart
+	indexed, err := m.vectorClient.QueryMemories(req.UserID, filter, req.Query, queryEmbedding, limit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query memories: %w", err)
+	}
+
+	report := &models.RecallReport{
+		UserID:   req.UserID,
+		Query:    req.Query,
+		Limit:    limit,
+		MinScore: minScore,
+	}
+
+	tags, _ := normalizeTags(req.Tags)
+	var exact []models.RecallCheckResult
+	cursor := "0"
+	for {
+		matches, next, err := m.vectorClient.RangeVectors(cursor, patchPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			report.Scanned++
+			if userID, _ := match.Metadata["user_id"].(string); userID != req.UserID {
+				continue
+			}
+			if !granularityMatches(match.Metadata, req.Granularity) || !assistantVisible(match.Metadata, req.AssistantID) {
+				continue
+			}
+			if len(tags) > 0 && !hasAnyTag(metadataTags(match.Metadata), tags) {
+				continue
+			}
+			report.Compared++
+
+			content, _ := match.Metadata["content"].(string)
+			exact = append(exact, models.RecallCheckResult{
+				ID:         match.ID,
+				Content:    content,
+				ExactScore: (1 + cosineSimilarity(queryEmbedding, match.Vector)) / 2,
+			})
+		}
+		if next == "" || next == cursor {
+			break
+		}
+		cursor = next
+	}
+
+	sort.SliceStable(exact, func(i, j int) bool {
+		return exact[i].ExactScore > exact[j].ExactScore
+	})
+	if len(exact) > limit {
+		exact = exact[:limit]
+	}
+
+	indexRanks := make(map[string]int, len(indexed))
+	for i, result := range indexed {
+		indexRanks[result.ID] = i
+	}
+	inExact := make(map[string]bool, len(exact))
+	found := 0
+	for i := range exact {
+		result := &exact[i]
+		result.ExactRank = i + 1
+		inExact[result.ID] = true
+
+		rank, ok := indexRanks[result.ID]
+		switch {
+		case !ok:
+			result.Outcome = models.RecallMissed
+		case indexed[rank].Score < minScore:
+			result.Outcome = models.RecallBelowMinScore
+		default:
+			result.Outcome = models.RecallReturned
+		}
+		if ok {
+			found++
+			result.IndexRank = rank + 1
+			result.IndexScore = indexed[rank].Score
+		}
+	}
+	for _, result := range indexed {
+		if !inExact[result.ID] {
+			report.IndexOnly = append(report.IndexOnly, result.ID)
+		}
+	}
+
+	report.Exact = exact
+	report.Recall = 1
+	if len(exact) > 0 {
+		report.Recall = float64(found) / float64(len(exact))
+	}
+	return report, nil
+}
+
+// granularityMatches reports whether vector metadata is selected by a query granularity,
+// mirroring granularityFilter for memories read without a filter
+func granularityMatches(metadata map[string]interface{}, granularity string) bool {
+	level, _ := metadata["granularity"].(string)
+	switch granularity {
+	case "", models.GranularityRaw:
+		return !isSummary(metadata)
+	case models.GranularityAll:
+		return true
+	default:
+		return level == granularity
+	}
+}
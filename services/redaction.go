@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidRedaction is returned for redactions that describe nothing or approve nothing
+	ErrInvalidRedaction = errors.New("invalid redaction")
+	// ErrRedactionNotFound is returned when confirming a preview that expired or was never made
+	ErrRedactionNotFound = errors.New("redaction not found")
+)
+
+const (
+	// defaultRedactionLimit is how many candidate memories a redaction previews by default
+	defaultRedactionLimit = 20
+	// defaultRedactionMinScore is lower than the query default so that a loose description
+	// still surfaces everything worth confirming
+	defaultRedactionMinScore = 0.6
+	// redactionTTL is how long a preview can be confirmed
+	redactionTTL = time.Hour
+)
+
+// PreviewRedaction finds the memories matching a user's description of what to forget and
+// keeps them as a pending redaction. Nothing is deleted until ConfirmRedaction approves it.
+func (m *MemoryService) PreviewRedaction(userID string, req models.RedactionRequest) (*models.RedactionPreview, error) {
+	if strings.TrimSpace(req.Description) == "" {
+		return nil, fmt.Errorf("%w: a description of what to forget is required", ErrInvalidRedaction)
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultRedactionLimit
+	}
+	minScore := req.MinScore
+	if minScore <= 0 {
+		minScore = defaultRedactionMinScore
+	}
+	tenantID := req.TenantID
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+
+	// Rollup summaries restate the memories they cover, so they are candidates too
+	retrieved, err := m.QueryMemory(models.QueryMemoryRequest{
+		TenantID:    tenantID,
+		UserID:      userID,
+		Query:       req.Description,
+		Limit:       limit,
+		MinScore:    minScore,
+		Granularity: models.GranularityAll,
+		SkipFacts:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Session messages alone would give an incomplete list of what to redact
+	if retrieved.Degraded {
+		return nil, fmt.Errorf("%w: %s", ErrVectorUnavailable, retrieved.DegradedReason)
+	}
+
+	redaction := &models.PendingRedaction{
+		ID:           uuid.New().String(),
+		UserID:       userID,
+		TenantID:     tenantID,
+		Description:  req.Description,
+		CandidateIDs: make([]string, len(retrieved.Results)),
+		ExpiresAt:    time.Now().Add(redactionTTL),
+	}
+	for i, result := range retrieved.Results {
+		redaction.CandidateIDs[i] = result.ID
+	}
+	if err := m.ForTenant(tenantID).redisClient.SaveRedaction(redaction, int64(redactionTTL/time.Second)); err != nil {
+		return nil, err
+	}
+
+	return &models.RedactionPreview{
+		RedactionID: redaction.ID,
+		Description: redaction.Description,
+		Candidates:  retrieved.Results,
+		ExpiresAt:   redaction.ExpiresAt,
+	}, nil
+}
+
+// ConfirmRedaction deletes the approved candidates of a previewed redaction. Approved IDs
+// the preview did not offer are refused, so a redaction only forgets what the user saw.
+// A preview can be confirmed once.
+func (m *MemoryService) ConfirmRedaction(userID string, req models.RedactionRequest) (*models.RedactionResult, error) {
+	if len(req.Approve) == 0 {
+		return nil, fmt.Errorf("%w: approve lists no memories to forget", ErrInvalidRedaction)
+	}
+	tenantID := req.TenantID
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	m = m.ForTenant(tenantID)
+
+	redaction, err := m.redisClient.GetRedaction(req.RedactionID)
+	if err != nil {
+		return nil, err
+	}
+	if redaction == nil || redaction.UserID != userID || redaction.TenantID != tenantID {
+		return nil, fmt.Errorf("%w: %s may have expired; preview the redaction again", ErrRedactionNotFound, req.RedactionID)
+	}
+
+	offered := make(map[string]bool, len(redaction.CandidateIDs))
+	for _, id := range redaction.CandidateIDs {
+		offered[id] = true
+	}
+	result := &models.RedactionResult{RedactionID: redaction.ID}
+	var forget []string
+	for _, id := range req.Approve {
+		if offered[id] {
+			forget = append(forget, id)
+		} else {
+			result.NotPreviewed = append(result.NotPreviewed, id)
+		}
+	}
+
+	if len(forget) > 0 {
+		reviewed, err := m.ReviewMemories(userID, tenantID, models.MemoryReviewRequest{Forget: forget})
+		if err != nil {
+			return nil, err
+		}
+		result.Forgotten = reviewed.Forgotten
+		result.NotFound = reviewed.NotFound
+		result.Retained = reviewed.Retained
+	}
+
+	if err := m.redisClient.DeleteRedaction(redaction.ID); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	return result, nil
+}
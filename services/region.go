@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+)
+
+// ForTenant returns the service bound to the data region a tenant is pinned to.
+// Only conversation data moves; jobs, budgets and retention policies stay on the
+// default instance.
+func (m *MemoryService) ForTenant(tenantID string) *MemoryService {
+	return m.forRegion(clients.TenantRegion(tenantID))
+}
+
+// WithContext returns the service bound to the request in ctx. Its Redis, vector,
+// embedding and QStash calls are abandoned once ctx is done and, when ctx carries a
+// trace, are recorded as spans of it. Work that outlives the request runs on detached().
+func (m *MemoryService) WithContext(ctx context.Context) *MemoryService {
+	if ctx == nil || ctx == m.ctx {
+		return m
+	}
+
+	base := m.detached()
+	bound := *base
+	bound.ctx = ctx
+	bound.base = base
+	bound.redisClient = base.redisClient.WithContext(ctx)
+	bound.controlClient = base.controlClient.WithContext(ctx)
+	bound.vectorClient = clients.TraceVectorStore(base.vectorClient.WithContext(ctx), ctx)
+	bound.embeddingClient = clients.TraceEmbeddings(base.embeddingClient.WithContext(ctx), ctx)
+	if base.qstashClient != nil {
+		bound.qstashClient = base.qstashClient.WithContext(ctx)
+	}
+	return &bound
+}
+
+// detached returns the service unbound from any request, for background work started
+// by a request that must not be cancelled with it
+func (m *MemoryService) detached() *MemoryService {
+	if m.base != nil {
+		return m.base
+	}
+	return m
+}
+
+func (m *MemoryService) forRegion(region string) *MemoryService {
+	if routed, ok := m.regions[region]; ok {
+		return routed.WithContext(m.ctx)
+	}
+	return m
+}
+
+// forEachRegion runs fn against the default instances and then every data region,
+// continuing past failures so one unreachable region does not stall the others
+func (m *MemoryService) forEachRegion(fn func(*MemoryService) error) error {
+	regions := regionNames()
+
+	var firstErr error
+	for _, region := range regions {
+		if err := fn(m.forRegion(region)); err != nil {
+			if region != "" {
+				err = fmt.Errorf("region %s: %w", region, err)
+			}
+			if len(regions) > 1 {
+				fmt.Printf("Warning: %v\n", err)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// regionNames returns "" for the default instances followed by every data region, sorted
+func regionNames() []string {
+	regions := []string{""}
+	for region := range config.AppConfig.DataRegions {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions[1:])
+	return regions
+}
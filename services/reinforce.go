@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// reinforceSimilar finds the user's raw memory most similar to a new one and, when it
+// reaches the reinforcement threshold, increments its reinforcement count. It returns
+// the reinforced memory's ID, or "" when nothing was similar enough.
+func (m *MemoryService) reinforceSimilar(memory *models.MemoryEntry, tenantID string) (string, error) {
+	threshold := config.AppConfig.ReinforcementThreshold
+	if threshold <= 0 {
+		return "", nil
+	}
+
+	// Only memories of the same assistant (or equally unassigned ones) are reinforced
+	owner := "HAS NOT FIELD assistant_id"
+	if assistantID, _ := memory.Metadata["assistant_id"].(string); assistantID != "" {
+		owner = fmt.Sprintf("assistant_id = '%s'", assistantID)
+	}
+
+	// No query text: hybrid fusion scores are not similarities and cannot be thresholded
+	results, err := m.vectorClient.QueryMemories(memory.UserID, joinFilters("HAS NOT FIELD granularity", "HAS NOT FIELD memory_type", "HAS NOT FIELD quarantine_reason", owner, taskFilter("")), "", memory.Embedding, 1, threshold)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 || metadataTenant(results[0].Metadata) != tenantID {
+		return "", nil
+	}
+
+	existing, err := m.vectorClient.FetchMemory(results[0].ID)
+	if err != nil {
+		return "", err
+	}
+	if existing == nil {
+		return "", nil
+	}
+
+	metadata := make(map[string]interface{}, len(existing.Metadata)+2)
+	for k, v := range existing.Metadata {
+		metadata[k] = v
+	}
+	now := time.Now()
+	metadata["reinforcement_count"] = reinforcementCount(existing.Metadata) + 1
+	metadata["last_reinforced_at"] = now.Unix()
+
+	// Restating a derived memory confirms it
+	if confidence, ok := effectiveConfidence(existing.Metadata, now); ok {
+		metadata["confidence"] = verifiedConfidence(confidence, true)
+		metadata["confirmed_at"] = now.Unix()
+	}
+
+	if err := m.vectorClient.UpdateMetadata(existing.ID, metadata); err != nil {
+		return "", err
+	}
+	return existing.ID, nil
+}
+
+// reinforcementCount is how many times a memory has been restated
+func reinforcementCount(metadata map[string]interface{}) int {
+	count, _ := metadata["reinforcement_count"].(float64)
+	return int(count)
+}
+
+// applyReinforcement boosts the scores of reinforced memories and re-sorts the results
+func applyReinforcement(results []models.MemoryResult) {
+	weight := config.AppConfig.ReinforcementWeight
+	if weight <= 0 {
+		return
+	}
+
+	for i := range results {
+		if count := reinforcementCount(results[i].Metadata); count > 0 {
+			results[i].Score *= 1 + weight*math.Log1p(float64(count))
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+var (
+	// ErrRetentionBlocked is returned when a retention policy forbids a deletion
+	ErrRetentionBlocked = errors.New("deletion blocked by retention policy")
+	// ErrInvalidRetentionPolicy is returned when a policy fails validation
+	ErrInvalidRetentionPolicy = errors.New("invalid retention policy")
+)
+
+// RetentionPolicies manages per-tenant retention policies stored in Redis
+type RetentionPolicies struct {
+	redisClient *clients.RedisClient
+}
+
+func NewRetentionPolicies(redisClient *clients.RedisClient) *RetentionPolicies {
+	return &RetentionPolicies{redisClient: redisClient}
+}
+
+// Get returns a tenant's policy; tenants without one get an unrestricted policy
+func (p *RetentionPolicies) Get(tenantID string) (*models.RetentionPolicy, error) {
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+
+	policy, err := p.redisClient.GetRetentionPolicy(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		policy = &models.RetentionPolicy{TenantID: tenantID}
+	}
+
+	return policy, nil
+}
+
+// Put validates and stores a tenant's policy
+func (p *RetentionPolicies) Put(policy *models.RetentionPolicy) error {
+	if policy.MinRetentionSeconds < 0 || policy.MaxRetentionSeconds < 0 {
+		return fmt.Errorf("%w: retention periods must not be negative", ErrInvalidRetentionPolicy)
+	}
+	if policy.MaxRetentionSeconds > 0 && policy.MaxRetentionSeconds < policy.MinRetentionSeconds {
+		return fmt.Errorf("%w: max_retention_seconds must be at least min_retention_seconds", ErrInvalidRetentionPolicy)
+	}
+
+	policy.UpdatedAt = time.Now()
+	return p.redisClient.SaveRetentionPolicy(policy)
+}
+
+// List returns every stored policy
+func (p *RetentionPolicies) List() ([]models.RetentionPolicy, error) {
+	return p.redisClient.ListRetentionPolicies()
+}
+
+// Delete removes a tenant's policy
+func (p *RetentionPolicies) Delete(tenantID string) error {
+	return p.redisClient.DeleteRetentionPolicy(tenantID)
+}
+
+// checkLegalHold fails when the tenant is under legal hold
+func checkLegalHold(policy *models.RetentionPolicy) error {
+	if policy.LegalHold {
+		return fmt.Errorf("%w: tenant %s is under legal hold", ErrRetentionBlocked, policy.TenantID)
+	}
+	return nil
+}
+
+// checkMinRetention fails when a memory created at createdAt is still within the minimum retention period
+func checkMinRetention(policy *models.RetentionPolicy, createdAt time.Time, now time.Time) error {
+	minAge := time.Duration(policy.MinRetentionSeconds) * time.Second
+	if minAge > 0 && now.Sub(createdAt) < minAge {
+		return fmt.Errorf("%w: memory must be retained until %s", ErrRetentionBlocked, createdAt.Add(minAge).Format(time.RFC3339))
+	}
+	return nil
+}
+
+// isExpired decides whether a memory should be removed by expiry cleanup under a policy
+func isExpired(policy *models.RetentionPolicy, createdAt time.Time, ttl time.Duration, now time.Time) bool {
+	if policy.LegalHold || checkMinRetention(policy, createdAt, now) != nil {
+		return false
+	}
+
+	age := now.Sub(createdAt)
+	if policy.MaxRetentionSeconds > 0 && age > time.Duration(policy.MaxRetentionSeconds)*time.Second {
+		return true
+	}
+	return ttl > 0 && age > ttl
+}
+
+// metadataTenant returns the tenant recorded on a vector, defaulting for legacy memories
+func metadataTenant(metadata map[string]interface{}) string {
+	if tenantID, ok := metadata["tenant_id"].(string); ok && tenantID != "" {
+		return tenantID
+	}
+	return models.DefaultTenant
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// defaultSessionWindow is how many of a session's latest messages combined retrieval searches
+const defaultSessionWindow = 20
+
+// RetrieveMemories searches an active session's latest messages and the user's long-term
+// memories with one query embedding, then merges the two result lists. Session messages
+// are scored by the better of their lexical overlap with the query and the similarity of
+// the vector saved with them. A message that is also a returned long-term memory, by ID or
+// by identical content, is reported once with the "both" tier. The user's instructions are
+// always returned alongside, however similar they are to the query.
+func (m *MemoryService) RetrieveMemories(req models.RetrieveRequest) (*models.RetrieveResponse, error) {
+	filter, err := queryFilter(req.QueryMemoryRequest)
+	if err != nil {
+		return nil, err
+	}
+	matcher, err := newContentMatcher(req.ContentFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantID := req.TenantID
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	m = m.ForTenant(tenantID)
+
+	window := req.Window
+	if window <= 0 {
+		window = defaultSessionWindow
+	}
+	messages := m.sessionWindow(req.UserID, req.SessionID, window)
+
+	queryEmbedding, err := m.queryEmbedding(tenantID, req.QueryMemoryRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	longTerm, err := m.rankMemories(req.QueryMemoryRequest, filter, queryEmbedding)
+	if err != nil {
+		return nil, err
+	}
+	instructions, omitted, err := m.userInstructions(tenantID, req.UserID, req.AssistantID)
+	if err != nil {
+		return nil, err
+	}
+	longTerm = withoutInstructions(longTerm, instructions)
+	preferences, err := m.redisClient.GetUserPreferences(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	shortTerm := m.rankSessionMessages(req, messages, queryEmbedding, matcher)
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	results := mergeTiers(shortTerm, longTerm, limit)
+
+	retrieved := make([]models.MemoryResult, len(results))
+	for i, result := range results {
+		retrieved[i] = result.MemoryResult
+	}
+	m.recordRetrievals(req.UserID, retrieved)
+
+	return &models.RetrieveResponse{
+		Results:             results,
+		Total:               len(results),
+		SessionMessages:     len(messages),
+		Instructions:        instructions,
+		InstructionsOmitted: omitted,
+		Preferences:         preferences,
+	}, nil
+}
+
+// sessionWindow returns the latest messages of a user's session. A session that has
+// expired or belongs to another user contributes no messages.
+func (m *MemoryService) sessionWindow(userID string, sessionID string, window int) []models.Message {
+	session, err := m.redisClient.GetSession(sessionID)
+	if err != nil {
+		fmt.Printf("Warning: searching without session %s: %v\n", sessionID, err)
+		return nil
+	}
+	if session.UserID != userID {
+		return nil
+	}
+
+	messages := session.Messages
+	if len(messages) > window {
+		messages = messages[len(messages)-window:]
+	}
+	return messages
+}
+
+// rankSessionMessages scores session messages against the query, keeping those that reach
+// the minimum score and pass the content filter, best first
+func (m *MemoryService) rankSessionMessages(req models.RetrieveRequest, messages []models.Message, queryEmbedding []float64, matcher *contentMatcher) []models.MemoryResult {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(messages))
+	for i, message := range messages {
+		ids[i] = message.ID
+	}
+	// Without the saved vectors the window is still searched lexically
+	vectors, err := m.vectorClient.FetchVectors(ids)
+	if err != nil {
+		fmt.Printf("Warning: searching session %s lexically only: %v\n", req.SessionID, err)
+	}
+	byID := make(map[string][]float64, len(vectors))
+	for _, vector := range vectors {
+		byID[vector.ID] = vector.Vector
+	}
+	return scoreSessionMessages(req, messages, byID, queryEmbedding, matcher)
+}
+
+// scoreSessionMessages scores session messages by the better of their lexical overlap with
+// the query and the similarity of their vector in byID, best first. Messages without any
+// of the query's tags are skipped.
+func scoreSessionMessages(req models.RetrieveRequest, messages []models.Message, byID map[string][]float64, queryEmbedding []float64, matcher *contentMatcher) []models.MemoryResult {
+	minScore := req.MinScore
+	if minScore <= 0 {
+		minScore = 0.5
+	}
+
+	// The request's tags were validated with the rest of the query
+	tags, _ := normalizeTags(req.Tags)
+	terms := queryTerms(req.Query)
+	results := make([]models.MemoryResult, 0, len(messages))
+	for _, message := range messages {
+		if !matcher.Matches(message.Content) {
+			continue
+		}
+		if len(tags) > 0 && !hasAnyTag(message.Tags, tags) {
+			continue
+		}
+
+		score := lexicalScore(terms, message.Content)
+		if vector, ok := byID[message.ID]; ok {
+			// Upstash reports cosine similarity as (1 + cos) / 2; use the same scale
+			if semantic := (1 + cosineSimilarity(queryEmbedding, vector)) / 2; semantic > score {
+				score = semantic
+			}
+		}
+		if score < minScore {
+			continue
+		}
+
+		metadata := map[string]interface{}{
+			"id":         message.ID,
+			"session_id": req.SessionID,
+			"role":       message.Role,
+		}
+		if len(message.Tags) > 0 {
+			metadata["tags"] = message.Tags
+		}
+		results = append(results, models.MemoryResult{
+			ID:        message.ID,
+			Content:   message.Content,
+			Score:     score,
+			Metadata:  metadata,
+			Timestamp: message.Timestamp,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
+
+// mergeTiers merges both tiers' results best first, reporting a memory found in both once.
+// The long-term result is kept for its richer metadata, with the better of the two scores.
+func mergeTiers(shortTerm []models.MemoryResult, longTerm []models.MemoryResult, limit int) []models.RetrieveResult {
+	merged := make([]models.RetrieveResult, 0, len(shortTerm)+len(longTerm))
+	byID := make(map[string]int)
+	byContent := make(map[string]int)
+
+	add := func(result models.MemoryResult, tier string) {
+		content := normalizeContent(result.Content)
+		i, found := byID[result.ID]
+		if !found {
+			i, found = byContent[content]
+		}
+		if found {
+			if merged[i].Tier != tier {
+				merged[i].Tier = models.TierBoth
+			}
+			if result.Score > merged[i].Score {
+				merged[i].Score = result.Score
+			}
+			return
+		}
+
+		byID[result.ID] = len(merged)
+		byContent[content] = len(merged)
+		merged = append(merged, models.RetrieveResult{MemoryResult: result, Tier: tier})
+	}
+	for _, result := range longTerm {
+		add(result, models.TierLongTerm)
+	}
+	for _, result := range shortTerm {
+		add(result, models.TierShortTerm)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Score > merged[j].Score
+	})
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged
+}
+
+// queryTerms returns the distinct lowercased words of a query, ignoring short words unless
+// the query has nothing else
+func queryTerms(query string) []string {
+	words := summaryTerms(query)
+	if len(words) == 0 {
+		words = strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+	}
+
+	seen := make(map[string]bool, len(words))
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// lexicalScore is the share of query terms that appear in content
+func lexicalScore(terms []string, content string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		words[word] = true
+	}
+
+	matched := 0
+	for _, term := range terms {
+		if words[term] {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(terms))
+}
+
+// cosineSimilarity returns the cosine of the angle between two vectors, 0 if either is empty
+// or their dimensions differ
+func cosineSimilarity(a []float64, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// normalizeContent lowercases content and collapses whitespace so restated copies compare equal
+func normalizeContent(content string) string {
+	return strings.Join(strings.Fields(strings.ToLower(content)), " ")
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrInvalidReview is returned for review batches that are empty or contradictory
+var ErrInvalidReview = errors.New("invalid memory review")
+
+// ListStaleMemories returns a user's memories older than olderThan that no query has
+// returned, that were never reinforced and whose importance is at most maxImportance,
+// oldest first. Memories without an importance count as 0. Approved memories stay out
+// of the queue until they are olderThan past their approval.
+func (m *MemoryService) ListStaleMemories(userID string, tenantID string, olderThan time.Duration, maxImportance float64, limit int) ([]models.StaleMemory, error) {
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	m = m.ForTenant(tenantID)
+
+	matches, err := m.vectorClient.ListUserMemories(userID, 10000)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user memories: %w", err)
+	}
+	retrievals, err := m.redisClient.GetRetrievals(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	stale := make([]models.StaleMemory, 0)
+	for _, match := range matches {
+		if metadataTenant(match.Metadata) != tenantID || isSummary(match.Metadata) {
+			continue
+		}
+		if _, retrieved := retrievals[match.ID]; retrieved || reinforcementCount(match.Metadata) > 0 {
+			continue
+		}
+		if approvedAt, ok := match.Metadata["review_approved_at"].(float64); ok && time.Unix(int64(approvedAt), 0).After(cutoff) {
+			continue
+		}
+
+		memory := memoryFromMetadata(match.ID, match.Metadata)
+		importance, _ := memory.Metadata["importance"].(float64)
+		if !memory.Timestamp.Before(cutoff) || importance > maxImportance {
+			continue
+		}
+
+		sessionID, _ := memory.Metadata["session_id"].(string)
+		stale = append(stale, models.StaleMemory{
+			ID:         memory.ID,
+			Content:    memory.Content,
+			SessionID:  sessionID,
+			Importance: importance,
+			CreatedAt:  memory.Timestamp,
+		})
+	}
+
+	sort.Slice(stale, func(i, j int) bool {
+		return stale[i].CreatedAt.Before(stale[j].CreatedAt)
+	})
+	if limit > 0 && len(stale) > limit {
+		stale = stale[:limit]
+	}
+	return stale, nil
+}
+
+// ReviewMemories applies a review batch: approved memories are stamped so they leave the
+// stale queue, forgotten memories are deleted. IDs that do not belong to the user are
+// reported as not found; memories still within minimum retention are kept and reported.
+func (m *MemoryService) ReviewMemories(userID string, tenantID string, req models.MemoryReviewRequest) (*models.MemoryReviewResult, error) {
+	if len(req.Approve) == 0 && len(req.Forget) == 0 {
+		return nil, fmt.Errorf("%w: nothing to approve or forget", ErrInvalidReview)
+	}
+	approve := make(map[string]bool, len(req.Approve))
+	for _, id := range req.Approve {
+		approve[id] = true
+	}
+	for _, id := range req.Forget {
+		if approve[id] {
+			return nil, fmt.Errorf("%w: memory %s is both approved and forgotten", ErrInvalidReview, id)
+		}
+	}
+
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	m = m.ForTenant(tenantID)
+
+	policy, err := m.retention.Get(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Forget) > 0 {
+		if err := checkLegalHold(policy); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &models.MemoryReviewResult{}
+	now := time.Now()
+
+	for _, id := range req.Approve {
+		match, err := m.reviewedMemory(userID, tenantID, id)
+		if err != nil {
+			return result, err
+		}
+		if match == nil {
+			result.NotFound = append(result.NotFound, id)
+			continue
+		}
+
+		metadata := make(map[string]interface{}, len(match.Metadata)+1)
+		for k, v := range match.Metadata {
+			metadata[k] = v
+		}
+		metadata["review_approved_at"] = now.Unix()
+		if err := m.vectorClient.UpdateMetadata(id, metadata); err != nil {
+			return result, err
+		}
+		result.Approved++
+	}
+
+	var forget []string
+	for _, id := range req.Forget {
+		match, err := m.reviewedMemory(userID, tenantID, id)
+		if err != nil {
+			return result, err
+		}
+		if match == nil {
+			result.NotFound = append(result.NotFound, id)
+			continue
+		}
+		if checkMinRetention(policy, memoryFromMetadata(id, match.Metadata).Timestamp, now) != nil {
+			result.Retained = append(result.Retained, id)
+			continue
+		}
+		forget = append(forget, id)
+	}
+
+	if err := m.vectorClient.DeleteMemories(forget); err != nil {
+		return result, fmt.Errorf("failed to delete forgotten memories: %w", err)
+	}
+	result.Forgotten = len(forget)
+	if config.AppConfig.RedisSearchEnabled {
+		for _, id := range forget {
+			if err := m.redisClient.DeleteIndexedMemory(id); err != nil {
+				fmt.Printf("Warning: failed to remove memory %s from search index: %v\n", id, err)
+			}
+		}
+	}
+	if err := m.redisClient.ForgetRetrievals(userID, forget); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	if len(forget) > 0 {
+		m.publish(EventMemoryDeleted, tenantID, userID, forget...)
+	}
+
+	return result, nil
+}
+
+// reviewedMemory fetches a memory named in a review batch, or returns nil when it does
+// not exist or belongs to another user or tenant
+func (m *MemoryService) reviewedMemory(userID string, tenantID string, id string) (*clients.QueryMatch, error) {
+	match, err := m.vectorClient.FetchMemory(id)
+	if err != nil || match == nil {
+		return nil, err
+	}
+	if owner, _ := match.Metadata["user_id"].(string); owner != userID || metadataTenant(match.Metadata) != tenantID {
+		return nil, nil
+	}
+	return match, nil
+}
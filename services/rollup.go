@@ -0,0 +1,497 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrInvalidGranularity is returned for granularities other than raw, day, week, month and all
+var ErrInvalidGranularity = errors.New("invalid granularity")
+
+const (
+	// summarySentences is how many sentences a rollup summary keeps
+	summarySentences = 5
+	// summaryListLimit caps how many summaries are listed per granularity
+	summaryListLimit = 1000
+)
+
+// granularityFilter returns the vector filter selecting memories of one granularity.
+// Raw memories carry no granularity field, which keeps memories saved before rollups
+// existed in the raw level.
+func granularityFilter(granularity string) (string, error) {
+	switch granularity {
+	case "", models.GranularityRaw:
+		return "HAS NOT FIELD granularity", nil
+	case models.GranularityDay, models.GranularityWeek, models.GranularityMonth, models.GranularitySession:
+		return fmt.Sprintf("granularity = '%s'", granularity), nil
+	case models.GranularityAll:
+		return "", nil
+	default:
+		return "", fmt.Errorf("%w: %q (use raw, day, week, month, session or all)", ErrInvalidGranularity, granularity)
+	}
+}
+
+// isSummary reports whether vector metadata belongs to a rollup summary
+func isSummary(metadata map[string]interface{}) bool {
+	_, ok := metadata["granularity"]
+	return ok
+}
+
+// RollupMemories builds day summaries from raw memories, then week and month summaries
+// from day summaries, for every closed period overlapping the lookback window. Periods
+// whose sources are unchanged since the last run are skipped, so reruns are cheap. With
+// no user ID every user with recent memories is rolled up.
+func (m *MemoryService) RollupMemories(userID string, tenantID string) (map[string]int, error) {
+	result := map[string]int{}
+	if userID != "" {
+		return result, m.ForTenant(tenantID).rollupUser(userID, tenantID, result)
+	}
+
+	rollup := func(region *MemoryService) error {
+		return region.rollupRecentUsers(tenantID, result)
+	}
+	if tenantID != "" {
+		return result, rollup(m.ForTenant(tenantID))
+	}
+	return result, m.forEachRegion(rollup)
+}
+
+func (m *MemoryService) rollupRecentUsers(tenantID string, result map[string]int) error {
+	type owner struct{ tenantID, userID string }
+	since := rollupWindowStart(time.Now()).Unix()
+	owners := make(map[owner]bool)
+
+	cursor := "0"
+	for {
+		matches, next, err := m.vectorClient.RangeMemories(cursor, patchPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, match := range matches {
+			memoryTenant := metadataTenant(match.Metadata)
+			if (tenantID != "" && memoryTenant != tenantID) || isSummary(match.Metadata) {
+				continue
+			}
+			memory := memoryFromMetadata(match.ID, match.Metadata)
+			if memory.UserID != "" && memory.Timestamp.Unix() >= since {
+				owners[owner{memoryTenant, memory.UserID}] = true
+			}
+		}
+
+		if next == "" || next == cursor {
+			break
+		}
+		cursor = next
+	}
+
+	var firstErr error
+	for o := range owners {
+		if err := m.rollupUser(o.userID, o.tenantID, result); err != nil {
+			result["failed_users"]++
+			fmt.Printf("Warning: failed to roll up memories of user %s: %v\n", o.userID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// rollupPeriod is one period being summarised and the entries it is built from
+type rollupPeriod struct {
+	start, end time.Time
+	label      string
+	sources    []*models.MemoryEntry
+}
+
+func (m *MemoryService) rollupUser(userID string, tenantID string, result map[string]int) error {
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+
+	matches, err := m.vectorClient.ListUserMemories(userID, 10000)
+	if err != nil {
+		return fmt.Errorf("failed to list user memories: %w", err)
+	}
+
+	// Each assistant persona is summarised separately so summaries keep its isolation
+	byAssistant := make(map[string][]clients.QueryMatch)
+	for _, match := range matches {
+		if metadataTenant(match.Metadata) == tenantID && !isTaskMemory(match.Metadata) && !isInstruction(match.Metadata) && !isQuarantined(match.Metadata) {
+			assistantID, _ := match.Metadata["assistant_id"].(string)
+			byAssistant[assistantID] = append(byAssistant[assistantID], match)
+		}
+	}
+
+	// Summaries are returned by queries, so cached results are stale once they change
+	defer m.publish(EventMemoryUpdated, tenantID, userID)
+
+	exhausted := false
+	for assistantID, matches := range byAssistant {
+		if err := m.rollupAssistant(userID, tenantID, assistantID, matches, &exhausted, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MemoryService) rollupAssistant(userID string, tenantID string, assistantID string, matches []clients.QueryMatch,
+	exhausted *bool, result map[string]int) error {
+	now := time.Now().UTC()
+	today := now.Truncate(24 * time.Hour)
+	windowStart := rollupWindowStart(now)
+
+	existing := make(map[string]map[string]interface{})
+	days := make(map[time.Time]*rollupPeriod)
+	var daySummaries []*models.MemoryEntry
+	for _, match := range matches {
+		memory := memoryFromMetadata(match.ID, match.Metadata)
+		switch granularity, _ := match.Metadata["granularity"].(string); granularity {
+		case "":
+			timestamp := memory.Timestamp.UTC()
+			if timestamp.Before(windowStart) || !timestamp.Before(today) {
+				continue
+			}
+			start := timestamp.Truncate(24 * time.Hour)
+			if days[start] == nil {
+				days[start] = &rollupPeriod{start: start, end: start.AddDate(0, 0, 1), label: start.Format("2006-01-02")}
+			}
+			days[start].sources = append(days[start].sources, memory)
+		case models.GranularityDay:
+			existing[match.ID] = match.Metadata
+			daySummaries = append(daySummaries, memory)
+		default:
+			existing[match.ID] = match.Metadata
+		}
+	}
+
+	for _, period := range sortedPeriods(days) {
+		summary, err := m.rollupPeriod(userID, tenantID, assistantID, models.GranularityDay, period, existing, exhausted, result)
+		if err != nil {
+			return err
+		}
+		if summary != nil {
+			daySummaries = replaceSummary(daySummaries, summary)
+		}
+	}
+
+	weeks := make(map[time.Time]*rollupPeriod)
+	months := make(map[time.Time]*rollupPeriod)
+	for _, day := range daySummaries {
+		start := day.Timestamp.UTC()
+
+		weekStart := start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+		if weekEnd := weekStart.AddDate(0, 0, 7); weekEnd.After(windowStart) && !weekEnd.After(today) {
+			if weeks[weekStart] == nil {
+				year, week := weekStart.ISOWeek()
+				weeks[weekStart] = &rollupPeriod{start: weekStart, end: weekEnd, label: fmt.Sprintf("%d-W%02d", year, week)}
+			}
+			weeks[weekStart].sources = append(weeks[weekStart].sources, day)
+		}
+
+		monthStart := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if monthEnd := monthStart.AddDate(0, 1, 0); monthEnd.After(windowStart) && !monthEnd.After(today) {
+			if months[monthStart] == nil {
+				months[monthStart] = &rollupPeriod{start: monthStart, end: monthEnd, label: monthStart.Format("2006-01")}
+			}
+			months[monthStart].sources = append(months[monthStart].sources, day)
+		}
+	}
+
+	for _, period := range sortedPeriods(weeks) {
+		if _, err := m.rollupPeriod(userID, tenantID, assistantID, models.GranularityWeek, period, existing, exhausted, result); err != nil {
+			return err
+		}
+	}
+	for _, period := range sortedPeriods(months) {
+		if _, err := m.rollupPeriod(userID, tenantID, assistantID, models.GranularityMonth, period, existing, exhausted, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollupPeriod stores the summary of one period and returns it, or returns nil when the
+// stored summary is current or the tenant's embedding budget is exhausted
+func (m *MemoryService) rollupPeriod(userID string, tenantID string, assistantID string, granularity string, period *rollupPeriod,
+	existing map[string]map[string]interface{}, exhausted *bool, result map[string]int) (*models.MemoryEntry, error) {
+	id := fmt.Sprintf("summary_%s_%s_%s", userID, granularity, period.start.Format("20060102"))
+	if assistantID != "" {
+		id = fmt.Sprintf("summary_%s_%s_%s_%s", userID, assistantID, granularity, period.start.Format("20060102"))
+	}
+	hash := sourceHash(period.sources)
+	if stored, ok := existing[id]; ok && stored["source_hash"] == hash {
+		result["unchanged"]++
+		return nil, nil
+	}
+
+	texts := make([]string, len(period.sources))
+	parentIDs := make([]string, len(period.sources))
+	confidence := 0.0
+	now := time.Now()
+	for i, source := range period.sources {
+		parentIDs[i] = source.ID
+		// A summary is as trustworthy as its sources on average
+		sourceConfidence, _ := effectiveConfidence(source.Metadata, now)
+		confidence += sourceConfidence / float64(len(period.sources))
+		texts[i] = source.Content
+		if isSummary(source.Metadata) {
+			// Drop the period label so it is not treated as part of the first sentence
+			if colon := strings.Index(texts[i], ": "); colon >= 0 {
+				texts[i] = texts[i][colon+2:]
+			}
+		}
+	}
+	content := period.label + ": " + m.summarize(texts, summarySentences, granularity)
+
+	tokens := EstimateTokens(content)
+	if !*exhausted {
+		allowed, err := m.reserveEmbedding(tenantID, tokens)
+		if err != nil {
+			return nil, err
+		}
+		*exhausted = !allowed
+	}
+	if *exhausted {
+		result["skipped_budget"]++
+		return nil, nil
+	}
+
+	embedding, provenance, err := m.embedWithProvenance(content)
+	if err != nil {
+		m.releaseEmbedding(tenantID, tokens)
+		return nil, fmt.Errorf("failed to generate summary embedding: %w", err)
+	}
+
+	summary := &models.MemoryEntry{
+		ID:        id,
+		UserID:    userID,
+		Content:   content,
+		Embedding: embedding,
+		Metadata: map[string]interface{}{
+			"tenant_id":          tenantID,
+			"granularity":        granularity,
+			"period_start":       period.start.Unix(),
+			"period_end":         period.end.Unix(),
+			"source_count":       len(period.sources),
+			"source_hash":        hash,
+			"origin":             models.OriginSummary,
+			"parent_ids":         parentIDs,
+			"confidence":         confidence,
+			"confirmed_at":       now.Unix(),
+			"updated_at":         now.Unix(),
+			"embedding_provider": provenance.Provider,
+			"embedding_model":    provenance.Model,
+			"embedding_version":  provenance.Version,
+		},
+		Timestamp: period.start,
+	}
+	if assistantID != "" {
+		summary.Metadata["assistant_id"] = assistantID
+	}
+	if err := m.vectorClient.UpsertMemory(summary); err != nil {
+		return nil, err
+	}
+
+	result[granularity]++
+	return summary, nil
+}
+
+// ListUserSummaries returns a user's rollup summaries at one granularity, newest first,
+// limited to those an assistant may read when one is given
+func (m *MemoryService) ListUserSummaries(userID string, granularity string, tenantID string, assistantID string) ([]models.MemorySummary, error) {
+	switch granularity {
+	case models.GranularityDay, models.GranularityWeek, models.GranularityMonth, models.GranularitySession:
+	default:
+		return nil, fmt.Errorf("%w: %q (use day, week, month or session)", ErrInvalidGranularity, granularity)
+	}
+	if err := validateAssistantID(assistantID); err != nil {
+		return nil, err
+	}
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+
+	matches, err := m.ForTenant(tenantID).vectorClient.ListUserSummaries(userID, granularity, summaryListLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]models.MemorySummary, 0, len(matches))
+	for _, match := range matches {
+		if metadataTenant(match.Metadata) != tenantID || !assistantVisible(match.Metadata, assistantID) {
+			continue
+		}
+		owner, _ := match.Metadata["assistant_id"].(string)
+		content, _ := match.Metadata["content"].(string)
+		periodStart, _ := match.Metadata["period_start"].(float64)
+		periodEnd, _ := match.Metadata["period_end"].(float64)
+		sourceCount, _ := match.Metadata["source_count"].(float64)
+		updatedAt, _ := match.Metadata["updated_at"].(float64)
+		summaries = append(summaries, models.MemorySummary{
+			ID:          match.ID,
+			UserID:      userID,
+			TenantID:    tenantID,
+			AssistantID: owner,
+			Granularity: granularity,
+			PeriodStart: time.Unix(int64(periodStart), 0).UTC(),
+			PeriodEnd:   time.Unix(int64(periodEnd), 0).UTC(),
+			Content:     content,
+			SourceCount: int(sourceCount),
+			UpdatedAt:   time.Unix(int64(updatedAt), 0).UTC(),
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].PeriodStart.After(summaries[j].PeriodStart)
+	})
+	return summaries, nil
+}
+
+// rollupWindowStart is the start of the first UTC day rolled up
+func rollupWindowStart(now time.Time) time.Time {
+	return now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -config.AppConfig.RollupLookbackDays)
+}
+
+func sortedPeriods(periods map[time.Time]*rollupPeriod) []*rollupPeriod {
+	sorted := make([]*rollupPeriod, 0, len(periods))
+	for _, period := range periods {
+		sorted = append(sorted, period)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].start.Before(sorted[j].start)
+	})
+	return sorted
+}
+
+// replaceSummary puts a freshly built summary in place of its stored version
+func replaceSummary(summaries []*models.MemoryEntry, summary *models.MemoryEntry) []*models.MemoryEntry {
+	for i, existing := range summaries {
+		if existing.ID == summary.ID {
+			summaries[i] = summary
+			return summaries
+		}
+	}
+	return append(summaries, summary)
+}
+
+// sourceHash fingerprints the entries a summary is built from
+func sourceHash(sources []*models.MemoryEntry) string {
+	parts := make([]string, len(sources))
+	for i, source := range sources {
+		parts[i] = source.ID + "\x00" + source.Content
+	}
+	sort.Strings(parts)
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x01")))
+	return hex.EncodeToString(sum[:])
+}
+
+// summarizeTexts extracts the sentences that best cover the texts' most frequent terms,
+// returned in their original order
+func summarizeTexts(texts []string, limit int) string {
+	type sentence struct {
+		text  string
+		terms []string
+		score float64
+		index int
+	}
+
+	var sentences []sentence
+	seen := make(map[string]bool)
+	frequency := make(map[string]int)
+	for _, text := range texts {
+		for _, raw := range splitSentences(text) {
+			key := strings.ToLower(raw)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			terms := summaryTerms(raw)
+			for _, term := range terms {
+				frequency[term]++
+			}
+			sentences = append(sentences, sentence{text: raw, terms: terms, index: len(sentences)})
+		}
+	}
+
+	for i := range sentences {
+		if len(sentences[i].terms) == 0 {
+			continue
+		}
+		total := 0
+		for _, term := range sentences[i].terms {
+			total += frequency[term]
+		}
+		sentences[i].score = float64(total) / float64(len(sentences[i].terms))
+	}
+
+	sort.SliceStable(sentences, func(i, j int) bool {
+		return sentences[i].score > sentences[j].score
+	})
+	if len(sentences) > limit {
+		sentences = sentences[:limit]
+	}
+	sort.Slice(sentences, func(i, j int) bool {
+		return sentences[i].index < sentences[j].index
+	})
+
+	kept := make([]string, len(sentences))
+	for i, s := range sentences {
+		kept[i] = s.text
+	}
+	return strings.Join(kept, " ")
+}
+
+// splitSentences splits text after sentence punctuation and at line breaks
+func splitSentences(text string) []string {
+	var sentences []string
+	var current strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			sentences = append(sentences, s)
+		}
+		current.Reset()
+	}
+
+	for _, r := range text {
+		if r == '\n' {
+			flush()
+			continue
+		}
+		current.WriteRune(r)
+		switch r {
+		case '.', '!', '?', '。', '！', '？':
+			flush()
+		}
+	}
+	flush()
+	return sentences
+}
+
+// summaryTerms returns the lowercased words of a sentence, ignoring short words that
+// are mostly stop words
+func summaryTerms(sentence string) []string {
+	words := strings.FieldsFunc(strings.ToLower(sentence), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	terms := words[:0]
+	for _, word := range words {
+		if len([]rune(word)) > 3 {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/metrics"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// saveAsync reports whether a save should be answered before its memory is stored.
+// Lite builds always store it first, as nothing may outlive the request.
+func (m *MemoryService) saveAsync(req models.SaveMemoryRequest) bool {
+	if config.Lite {
+		return false
+	}
+	if req.Async != nil {
+		return *req.Async
+	}
+	return config.AppConfig.SaveAsync
+}
+
+// saveLater queues a save as a save_memory job and returns the pending result naming the
+// job. It returns nil when the save should be finished synchronously instead: when the
+// job queue is full or cannot be reached.
+func (m *MemoryService) saveLater(save *longTermSave) *models.SaveMemoryResult {
+	job, err := m.enqueueJob(jobSaveMemory, save.tenantID, save.req.UserID, newQueuedSave(save))
+	if err != nil {
+		if errors.Is(err, clients.ErrJobQueueFull) {
+			countAsyncSave("sync_queue_full")
+		} else {
+			countAsyncSave("sync_queue_error")
+			fmt.Printf("Warning: saving memory %s synchronously: %v\n", save.memory.ID, err)
+		}
+		return nil
+	}
+	countAsyncSave("async")
+
+	return &models.SaveMemoryResult{MemoryID: save.memory.ID, Storage: models.StoragePending, Quarantined: save.quarantine != "", JobID: job.ID}
+}
+
+// queuedSave is the payload of a save_memory job
+type queuedSave struct {
+	Request      models.SaveMemoryRequest `json:"request"`
+	Memory       *models.MemoryEntry      `json:"memory"`
+	TenantID     string                   `json:"tenant_id"`
+	WithinBudget bool                     `json:"within_budget"`
+	Quarantine   string                   `json:"quarantine,omitempty"`
+}
+
+func newQueuedSave(save *longTermSave) queuedSave {
+	return queuedSave{
+		Request:      save.req,
+		Memory:       save.memory,
+		TenantID:     save.tenantID,
+		WithinBudget: save.withinBudget,
+		Quarantine:   save.quarantine,
+	}
+}
+
+func (q queuedSave) longTermSave() *longTermSave {
+	return &longTermSave{
+		req:          q.Request,
+		memory:       q.Memory,
+		tenantID:     q.TenantID,
+		withinBudget: q.WithinBudget,
+		quarantine:   q.Quarantine,
+	}
+}
+
+// countAsyncSave counts a save asked to run asynchronously by how it ran
+func countAsyncSave(mode string) {
+	metrics.AddCounter("memorycache_async_saves_total", "Saves asked to run asynchronously, by how they ran",
+		map[string]string{"mode": mode}, 1)
+}
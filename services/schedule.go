@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+var (
+	// ErrInvalidSchedule is returned when a cron expression or timezone is rejected
+	ErrInvalidSchedule = errors.New("invalid cleanup schedule")
+	// ErrDuplicateSchedule is returned when the tenant already has a schedule for the callback URL
+	ErrDuplicateSchedule = errors.New("cleanup schedule already exists")
+	// ErrSchedulerUnavailable is returned by operations that need QStash when it is not configured
+	ErrSchedulerUnavailable = errors.New("QStash is not configured")
+)
+
+// defaultCleanupCron runs cleanup daily at 2 AM
+const defaultCleanupCron = "0 2 * * *"
+
+// pendingScheduleID marks a target whose schedule is still being created
+const pendingScheduleID = "pending"
+
+var cronFieldPattern = regexp.MustCompile(`^[0-9A-Za-z*/,\-?]+$`)
+
+// ScheduleCleanup creates a recurring expired-memory cleanup. Only one schedule may
+// exist per tenant and callback URL; on a duplicate the existing schedule is returned
+// together with ErrDuplicateSchedule.
+func (m *MemoryService) ScheduleCleanup(req models.ScheduleCleanupRequest) (*models.CleanupSchedule, error) {
+	if !m.SchedulerAvailable() {
+		return nil, ErrSchedulerUnavailable
+	}
+
+	if err := validateCallback(req.CallbackURL, req.DeliveryOptions); err != nil {
+		return nil, err
+	}
+
+	cron := strings.TrimSpace(req.Cron)
+	if cron == "" {
+		cron = defaultCleanupCron
+	}
+	if err := validateCron(cron); err != nil {
+		return nil, err
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, timezone)
+	}
+
+	existingID, err := m.controlClient.ReserveCleanupScheduleTarget(req.TenantID, req.CallbackURL, pendingScheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if existingID != "" {
+		existing, err := m.controlClient.GetCleanupSchedule(existingID)
+		if err != nil {
+			return nil, err
+		}
+		return existing, fmt.Errorf("%w: %s", ErrDuplicateSchedule, existingID)
+	}
+
+	// QStash evaluates the expression in the timezone given by the CRON_TZ prefix
+	scheduleID, err := m.qstashClient.ScheduleCleanupTask(req.CallbackURL, fmt.Sprintf("CRON_TZ=%s %s", timezone, cron), req.TenantID, req.DeliveryOptions)
+	if err != nil {
+		m.recordPublishFailure("schedule", "cleanup_expired_memories", req.CallbackURL, req.TenantID, "", err)
+		if releaseErr := m.controlClient.ReleaseCleanupScheduleTarget(req.TenantID, req.CallbackURL); releaseErr != nil {
+			fmt.Printf("Warning: %v\n", releaseErr)
+		}
+		return nil, fmt.Errorf("failed to schedule cleanup: %w", err)
+	}
+
+	schedule := &models.CleanupSchedule{
+		ScheduleID:  scheduleID,
+		TenantID:    req.TenantID,
+		CallbackURL: req.CallbackURL,
+		Cron:        cron,
+		Timezone:    timezone,
+		Owner:       req.Owner,
+		Purpose:     req.Purpose,
+		CreatedAt:   time.Now(),
+	}
+	if err := m.controlClient.SaveCleanupSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// ListCleanupSchedules returns every recorded cleanup schedule
+func (m *MemoryService) ListCleanupSchedules() ([]models.CleanupSchedule, error) {
+	return m.controlClient.ListCleanupSchedules()
+}
+
+// DeleteCleanupSchedule cancels a schedule in QStash and forgets its metadata
+func (m *MemoryService) DeleteCleanupSchedule(scheduleID string) error {
+	if !m.SchedulerAvailable() {
+		return ErrSchedulerUnavailable
+	}
+
+	schedule, err := m.controlClient.GetCleanupSchedule(scheduleID)
+	if err != nil {
+		return err
+	}
+
+	if err := m.qstashClient.CancelSchedule(scheduleID); err != nil {
+		return fmt.Errorf("failed to cancel schedule: %w", err)
+	}
+
+	if schedule == nil {
+		return nil
+	}
+	return m.controlClient.DeleteCleanupSchedule(schedule)
+}
+
+// validateCron checks that an expression has five plausible fields
+func validateCron(cron string) error {
+	fields := strings.Fields(cron)
+	if len(fields) != 5 {
+		return fmt.Errorf("%w: cron expression must have 5 fields, got %d", ErrInvalidSchedule, len(fields))
+	}
+	for _, field := range fields {
+		if !cronFieldPattern.MatchString(field) {
+			return fmt.Errorf("%w: invalid cron field %q", ErrInvalidSchedule, field)
+		}
+	}
+	return nil
+}
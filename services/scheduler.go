@@ -0,0 +1,159 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/cron"
+)
+
+var (
+	internalSchedulerOnce sync.Once
+	internalSchedulerStop = make(chan struct{})
+	stopSchedulerOnce     sync.Once
+)
+
+// StartInternalScheduler queues expired-memory cleanup on the job queue at a fixed
+// interval, standing in for QStash schedules when QStash is not configured. Expiry
+// notifications, rollups and cold tiering are queued on the same cadence when configured,
+// as is applying tenant session policies. The job workers run them, retrying failures.
+// With SCHEDULER_MODE=local each task is queued on its own cron expression instead.
+func (m *MemoryService) StartInternalScheduler() {
+	if !config.AppConfig.InternalSchedulerActive() {
+		return
+	}
+
+	internalSchedulerOnce.Do(func() {
+		if config.AppConfig.SchedulerMode == "local" {
+			go m.runLocalScheduler()
+			return
+		}
+
+		go func() {
+			ticker := time.NewTicker(config.AppConfig.InternalCleanupInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					for _, jobType := range scheduledJobTypes() {
+						if _, err := m.enqueueJob(jobType, "", "", nil); err != nil {
+							fmt.Printf("Warning: failed to queue scheduled %s: %v\n", jobType, err)
+						}
+					}
+				case <-internalSchedulerStop:
+					return
+				}
+			}
+		}()
+	})
+}
+
+// scheduledJobTypes lists the maintenance jobs the internal scheduler queues
+func scheduledJobTypes() []string {
+	jobTypes := []string{"cleanup_expired_memories"}
+	if config.AppConfig.ExpiryWebhookURL != "" {
+		jobTypes = append(jobTypes, "notify_expiring_memories")
+	}
+	jobTypes = append(jobTypes, "apply_session_policies")
+	if config.AppConfig.RollupEnabled {
+		jobTypes = append(jobTypes, "rollup_memories")
+	}
+	if config.AppConfig.ColdTierEnabled() {
+		jobTypes = append(jobTypes, "tier_cold_memories")
+	}
+	return jobTypes
+}
+
+// runLocalScheduler queues each scheduled job type whenever its SCHEDULER_CRON expression
+// fires in SCHEDULER_TIMEZONE. Every replica keeps the schedule; the first to claim a
+// firing queues it, so a replica going down does not skip runs.
+func (m *MemoryService) runLocalScheduler() {
+	location, err := time.LoadLocation(config.AppConfig.SchedulerTimezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	schedules := make(map[string]*cron.Schedule)
+	next := make(map[string]time.Time)
+	now := time.Now().In(location)
+	for _, jobType := range scheduledJobTypes() {
+		expr, ok := config.AppConfig.SchedulerCrons[jobType]
+		if !ok {
+			continue
+		}
+		schedule, err := cron.Parse(expr)
+		if err != nil {
+			fmt.Printf("Warning: not scheduling %s: %v\n", jobType, err)
+			continue
+		}
+		schedules[jobType] = schedule
+		next[jobType] = schedule.Next(now)
+	}
+
+	for {
+		var due time.Time
+		for _, at := range next {
+			if !at.IsZero() && (due.IsZero() || at.Before(due)) {
+				due = at
+			}
+		}
+		if due.IsZero() {
+			<-internalSchedulerStop
+			return
+		}
+
+		timer := time.NewTimer(time.Until(due))
+		select {
+		case <-timer.C:
+		case <-internalSchedulerStop:
+			timer.Stop()
+			return
+		}
+
+		now := time.Now().In(location)
+		for jobType, at := range next {
+			if at.IsZero() || at.After(now) {
+				continue
+			}
+			m.queueScheduledRun(jobType, at)
+			next[jobType] = schedules[jobType].Next(now)
+		}
+	}
+}
+
+// queueScheduledRun queues the job of a cron firing unless another replica claimed it
+func (m *MemoryService) queueScheduledRun(jobType string, at time.Time) {
+	claimed, err := m.controlClient.ClaimScheduledRun(jobType, at)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	if _, err := m.enqueueJob(jobType, "", "", nil); err != nil {
+		fmt.Printf("Warning: failed to queue scheduled %s: %v\n", jobType, err)
+	}
+}
+
+// StopInternalScheduler stops the internal scheduler; a run in progress finishes first
+func StopInternalScheduler() {
+	stopSchedulerOnce.Do(func() {
+		close(internalSchedulerStop)
+	})
+}
+
+// runScheduledTask runs a periodic task in-process, recording the run as a job
+func (m *MemoryService) runScheduledTask(taskType string, fn func() error) {
+	job := m.StartDelivery(taskType, "", "", "", "")
+
+	failure := ""
+	if err := fn(); err != nil {
+		failure = err.Error()
+		fmt.Printf("Warning: scheduled %s failed: %v\n", taskType, err)
+	}
+	m.FinishDelivery(job, failure)
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// selfTestUserID owns the canary records so they never mix with real memories
+	selfTestUserID = "__selftest__"
+	// selfTestQueryAttempts bounds how long the vector canary may take to become queryable
+	selfTestQueryAttempts = 5
+)
+
+// SelfTest exercises every configured dependency end-to-end with canary data: a Redis
+// write/read/delete and a vector upsert/query/delete in each data region, an embedding
+// call, and QStash authentication. Canary records are removed whether or not a check passes.
+func (m *MemoryService) SelfTest() *models.SelfTestReport {
+	report := &models.SelfTestReport{RanAt: time.Now()}
+
+	embedding, embeddingCheck := m.selfTestEmbedding()
+	report.Checks = append(report.Checks, embeddingCheck)
+
+	for _, region := range regionNames() {
+		target := region
+		if target == "" {
+			target = "default"
+		}
+		routed := m.forRegion(region)
+
+		report.Checks = append(report.Checks, runSelfTestCheck("redis", target, routed.selfTestRedis))
+
+		if embedding == nil {
+			report.Checks = append(report.Checks, models.SelfTestCheck{
+				Name:    "vector",
+				Target:  target,
+				Skipped: true,
+				Error:   "no canary embedding, embedding check failed",
+			})
+			continue
+		}
+		report.Checks = append(report.Checks, runSelfTestCheck("vector", target, func() error {
+			return routed.selfTestVector(embedding)
+		}))
+	}
+
+	if m.SchedulerAvailable() {
+		report.Checks = append(report.Checks, runSelfTestCheck("qstash", config.AppConfig.QStashURL, func() error {
+			_, err := m.qstashClient.GetSchedules()
+			return err
+		}))
+	} else {
+		report.Checks = append(report.Checks, models.SelfTestCheck{
+			Name:    "qstash",
+			Skipped: true,
+			Error:   "QSTASH_TOKEN is not configured",
+		})
+	}
+
+	report.OK = true
+	for _, check := range report.Checks {
+		if !check.OK && !check.Skipped {
+			report.OK = false
+		}
+	}
+	return report
+}
+
+func (m *MemoryService) selfTestEmbedding() ([]float64, models.SelfTestCheck) {
+	var embedding []float64
+	check := runSelfTestCheck("embedding", string(m.embeddingClient.GetProvider()), func() error {
+		var err error
+		embedding, err = clients.WithoutEmbeddingCache(m.embeddingClient).GenerateEmbedding("MemoryCacheAI self-test canary")
+		if err == nil && len(embedding) == 0 {
+			err = fmt.Errorf("provider returned an empty embedding")
+		}
+		return err
+	})
+	if !check.OK {
+		return nil, check
+	}
+	return embedding, check
+}
+
+func (m *MemoryService) selfTestRedis() error {
+	key := fmt.Sprintf("selftest:%s", uuid.New().String())
+	value := time.Now().Format(time.RFC3339Nano)
+
+	if _, err := m.redisClient.SetIfAbsent(key, value, 60); err != nil {
+		return fmt.Errorf("write failed: %w", err)
+	}
+	defer m.redisClient.DeleteKeys(key)
+
+	stored, err := m.redisClient.GetValue(key)
+	if err != nil {
+		return fmt.Errorf("read failed: %w", err)
+	}
+	if stored != value {
+		return fmt.Errorf("read back %q, wrote %q", stored, value)
+	}
+
+	if _, err := m.redisClient.DeleteKeys(key); err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+	return nil
+}
+
+func (m *MemoryService) selfTestVector(embedding []float64) error {
+	canary := &models.MemoryEntry{
+		ID:        fmt.Sprintf("selftest_%s", uuid.New().String()),
+		UserID:    selfTestUserID,
+		Content:   "MemoryCacheAI self-test canary",
+		Embedding: embedding,
+		Timestamp: time.Now(),
+		TTL:       60,
+	}
+
+	if err := m.vectorClient.UpsertMemory(canary); err != nil {
+		return fmt.Errorf("upsert failed: %w", err)
+	}
+	defer m.vectorClient.DeleteMemory(canary.ID)
+
+	// The index is eventually consistent, so give the canary a moment to appear
+	found := false
+	for attempt := 0; attempt < selfTestQueryAttempts && !found; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Second)
+		}
+
+		results, err := m.vectorClient.QueryMemories(selfTestUserID, "", canary.Content, embedding, 5, 0)
+		if err != nil {
+			return fmt.Errorf("query failed: %w", err)
+		}
+		for _, result := range results {
+			if result.ID == canary.ID {
+				found = true
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("canary was not returned by query after %d attempts", selfTestQueryAttempts)
+	}
+
+	if err := m.vectorClient.DeleteMemory(canary.ID); err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+	return nil
+}
+
+// runSelfTestCheck times fn and records its outcome
+func runSelfTestCheck(name string, target string, fn func() error) models.SelfTestCheck {
+	start := time.Now()
+	err := fn()
+
+	check := models.SelfTestCheck{
+		Name:      name,
+		Target:    target,
+		OK:        err == nil,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		check.Error = err.Error()
+	}
+	return check
+}
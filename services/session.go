@@ -0,0 +1,212 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/Fairy-nn/MemoryCacheAI/tokenizer"
+)
+
+const (
+	// defaultSummaryMessages is how many of a session's latest messages a summary keeps verbatim
+	defaultSummaryMessages = 5
+	// sessionSummarySentences bounds the rolling summary of a session's earlier messages
+	sessionSummarySentences = 5
+	// sessionTitleLength bounds session titles, in characters
+	sessionTitleLength = 80
+)
+
+// ErrInvalidSession is returned for session IDs that cannot be used in vector filters
+var ErrInvalidSession = errors.New("invalid session ID")
+
+// sessionFilter returns the vector filter selecting the memories saved in a session
+func sessionFilter(sessionID string) (string, error) {
+	if sessionID == "" || strings.ContainsAny(sessionID, `'\`) {
+		return "", fmt.Errorf("%w: %q must be non-empty and may not contain quotes or backslashes", ErrInvalidSession, sessionID)
+	}
+	return fmt.Sprintf("session_id = '%s'", sessionID), nil
+}
+
+// SearchSession semantically searches only the memories saved in one session, including
+// messages that have since been trimmed from the session window or expired with it.
+// Query options other than the session behave as in QueryMemory.
+func (m *MemoryService) SearchSession(sessionID string, req models.QueryMemoryRequest) (*models.QueryMemoryResponse, error) {
+	filter, err := queryFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := sessionFilter(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if req.DeepRecall {
+		return nil, fmt.Errorf("%w: deep_recall is not supported when searching a session", ErrInvalidSession)
+	}
+
+	tenantID := req.TenantID
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	m = m.ForTenant(tenantID)
+
+	queryEmbedding, err := m.queryEmbedding(tenantID, req)
+	if err != nil {
+		return nil, err
+	}
+	results, err := m.rankMemories(req, joinFilters(filter, scope), queryEmbedding)
+	if err != nil {
+		return nil, err
+	}
+	m.recordRetrievals(req.UserID, results)
+
+	return &models.QueryMemoryResponse{
+		Results: results,
+		Total:   len(results),
+	}, nil
+}
+
+// SummarizeSession returns a compact view of a session: its title, a rolling summary of
+// all but the latest messages, the latest messages themselves and the requested context
+// fields (all of them when contextKeys is empty). The rolling summary is cached in Redis
+// and only the messages that left the recent window since the last call are folded in.
+func (m *MemoryService) SummarizeSession(sessionID string, recent int, contextKeys []string) (*models.SessionSummary, error) {
+	session, err := m.redisClient.GetSessionCached(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if recent <= 0 {
+		recent = defaultSummaryMessages
+	}
+	total := messageTotal(session)
+	summarized := total - recent
+	if summarized < 0 {
+		summarized = 0
+	}
+	firstRecent := summarized - session.TrimmedMessages
+	if firstRecent < 0 {
+		firstRecent = 0
+	}
+
+	rolling := m.rollingSummary(session, summarized)
+
+	summary := &models.SessionSummary{
+		SessionID:          session.SessionID,
+		UserID:             session.UserID,
+		Title:              rolling.Title,
+		Summary:            rolling.Summary,
+		SummarizedMessages: rolling.Messages,
+		RecentMessages:     session.Messages[firstRecent:],
+		Context:            selectContext(session.Context, contextKeys),
+		MessageCount:       total,
+		UpdatedAt:          session.LastActivity,
+	}
+
+	summary.TokenCount = tokenizer.Count(summary.Title) + tokenizer.Count(summary.Summary)
+	for _, message := range summary.RecentMessages {
+		summary.TokenCount += tokenizer.Count(message.Content)
+	}
+	if len(summary.Context) > 0 {
+		if encoded, err := json.Marshal(summary.Context); err == nil {
+			summary.TokenCount += tokenizer.Count(string(encoded))
+		}
+	}
+	return summary, nil
+}
+
+// messageTotal counts every message a session received, including those trimmed from it
+func messageTotal(session *models.SessionData) int {
+	return session.TrimmedMessages + len(session.Messages)
+}
+
+// rollingSummary returns the summary of a session's first summarized messages, extending
+// the cached summary when more messages left the window and rebuilding it when fewer did.
+// Messages trimmed from the session before they were summarized are left out.
+func (m *MemoryService) rollingSummary(session *models.SessionData, summarized int) *models.RollingSummary {
+	cached, err := m.redisClient.GetSessionSummary(session.SessionID)
+	if err != nil {
+		fmt.Printf("Warning: rebuilding summary of session %s: %v\n", session.SessionID, err)
+	}
+	if cached == nil {
+		// Condensed sessions no longer hold the messages their summary covers
+		cached = contextSummary(session)
+	}
+
+	rolling := &models.RollingSummary{}
+	if cached != nil {
+		rolling.Title = cached.Title
+		if cached.Messages <= summarized {
+			*rolling = *cached
+		}
+	}
+	if rolling.Title == "" {
+		rolling.Title = sessionTitle(session.Messages)
+	}
+
+	if rolling.Messages < summarized {
+		texts := make([]string, 0, summarized-rolling.Messages+1)
+		if rolling.Summary != "" {
+			texts = append(texts, rolling.Summary)
+		}
+		// Positions count trimmed messages, which are no longer in session.Messages
+		start, end := rolling.Messages-session.TrimmedMessages, summarized-session.TrimmedMessages
+		if start < 0 {
+			start = 0
+		}
+		if end < start {
+			end = start
+		}
+		for _, message := range session.Messages[start:end] {
+			texts = append(texts, fmt.Sprintf("%s: %s", message.Role, message.Content))
+		}
+		rolling.Summary = m.summarize(texts, sessionSummarySentences, "conversation")
+		rolling.Messages = summarized
+	}
+
+	if cached == nil || *cached != *rolling {
+		rolling.UpdatedAt = time.Now()
+		if err := m.redisClient.SaveSessionSummary(session.SessionID, rolling); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+	return rolling
+}
+
+// sessionTitle titles a session with the first sentence of its first user message
+func sessionTitle(messages []models.Message) string {
+	for _, message := range messages {
+		if message.Role != "user" {
+			continue
+		}
+		sentences := splitSentences(message.Content)
+		if len(sentences) == 0 {
+			continue
+		}
+		title := sentences[0]
+		if utf8.RuneCountInString(title) > sessionTitleLength {
+			title = string([]rune(title)[:sessionTitleLength-1]) + "…"
+		}
+		return title
+	}
+	return ""
+}
+
+// selectContext returns the requested fields of a session context, or all of it when
+// no keys are given
+func selectContext(context map[string]interface{}, keys []string) map[string]interface{} {
+	if len(keys) == 0 {
+		return context
+	}
+	selected := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if value, ok := context[key]; ok {
+			selected[key] = value
+		}
+	}
+	return selected
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrSessionClosed is returned for writes to a closed session
+	ErrSessionClosed = errors.New("session is closed")
+	// ErrSessionNotClosed is returned when resuming a session that is still open
+	ErrSessionNotClosed = errors.New("session is not closed")
+	// ErrSessionExists is returned when a resumed session would replace an existing one
+	ErrSessionExists = errors.New("session already exists")
+)
+
+// checkSessionOpen fails for closed sessions
+func checkSessionOpen(session *models.SessionData) error {
+	if session.ClosedAt != nil {
+		return fmt.Errorf("%w: %s was closed at %s", ErrSessionClosed, session.SessionID, session.ClosedAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// CloseSession freezes a session against further writes and summarizes all of its
+// messages. Closing a closed session returns its summary again.
+func (m *MemoryService) CloseSession(sessionID string) (*models.ClosedSession, error) {
+	session, err := m.redisClient.GetSession(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if session.ClosedAt == nil {
+		now := time.Now()
+		session.ClosedAt = &now
+		if err := m.redisClient.SaveSession(session); err != nil {
+			return nil, fmt.Errorf("failed to close session: %w", err)
+		}
+	}
+
+	rolling := m.rollingSummary(session, messageTotal(session))
+	return &models.ClosedSession{
+		SessionID:    session.SessionID,
+		UserID:       session.UserID,
+		Title:        rolling.Title,
+		Summary:      rolling.Summary,
+		MessageCount: messageTotal(session),
+		ClosedAt:     *session.ClosedAt,
+	}, nil
+}
+
+// ResumeSession starts a new session continuing a closed one, which may since have been
+// archived. The new session carries the selected context fields and a "previous_session"
+// context field holding the closed session's ID, title and summary.
+func (m *MemoryService) ResumeSession(req models.ResumeSessionRequest) (*models.SessionData, error) {
+	previous, err := m.redisClient.GetSession(req.SessionID)
+	if err != nil {
+		archived, archiveErr := m.redisClient.GetArchivedSession(req.SessionID)
+		if archiveErr != nil || archived == nil {
+			return nil, fmt.Errorf("failed to get session: %w", err)
+		}
+		previous = archived
+	}
+	if previous.ClosedAt == nil {
+		return nil, fmt.Errorf("%w: close %s before resuming it", ErrSessionNotClosed, req.SessionID)
+	}
+
+	sessionID := req.NewSessionID
+	if sessionID == "" {
+		sessionID = uuid.New().String()
+	} else if _, err := m.redisClient.GetSession(sessionID); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrSessionExists, sessionID)
+	}
+
+	rolling := m.rollingSummary(previous, messageTotal(previous))
+	context := make(map[string]interface{})
+	for key, value := range selectContext(previous.Context, req.ContextKeys) {
+		context[key] = value
+	}
+	context["previous_session"] = map[string]interface{}{
+		"session_id": previous.SessionID,
+		"title":      rolling.Title,
+		"summary":    rolling.Summary,
+	}
+
+	tenantID := previous.TenantID
+	if tenantID == "" {
+		tenantID = req.TenantID
+	}
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	now := time.Now()
+	session := &models.SessionData{
+		TenantID:     tenantID,
+		UserID:       previous.UserID,
+		SessionID:    sessionID,
+		Messages:     []models.Message{},
+		Context:      context,
+		LastActivity: now,
+		CreatedAt:    now,
+		TTLSeconds:   m.sessionTTLSeconds(tenantID),
+		ResumedFrom:  previous.SessionID,
+	}
+	if err := m.redisClient.SaveSession(session); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+	return session, nil
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// Session export formats
+const (
+	ExportFormatMarkdown = "markdown"
+	ExportFormatHTML     = "html"
+	ExportFormatJSON     = "json"
+)
+
+// exportFlushMessages is how many messages are written between flushes of a streamed export
+const exportFlushMessages = 50
+
+// ErrInvalidExportFormat is returned for session export formats other than markdown, html and json
+var ErrInvalidExportFormat = errors.New("invalid export format")
+
+// ValidateExportFormat checks a session export format
+func ValidateExportFormat(format string) error {
+	switch format {
+	case ExportFormatMarkdown, ExportFormatHTML, ExportFormatJSON:
+		return nil
+	}
+	return fmt.Errorf("%w: %q (use markdown, html or json)", ErrInvalidExportFormat, format)
+}
+
+// ExportSession returns a session's transcript titled like its summary. The cached title
+// is reused when the session has been summarized; no summary is generated for an export.
+func (m *MemoryService) ExportSession(sessionID string) (*models.SessionExport, error) {
+	session, err := m.redisClient.GetSessionCached(sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	title := ""
+	if cached, err := m.redisClient.GetSessionSummary(sessionID); err == nil && cached != nil {
+		title = cached.Title
+	}
+	if title == "" {
+		title = sessionTitle(session.Messages)
+	}
+
+	return &models.SessionExport{
+		SessionID:    session.SessionID,
+		UserID:       session.UserID,
+		Title:        title,
+		CreatedAt:    session.CreatedAt,
+		LastActivity: session.LastActivity,
+		ExportedAt:   time.Now(),
+		MessageCount: len(session.Messages),
+		Messages:     session.Messages,
+	}, nil
+}
+
+// WriteSessionExport renders a transcript to w in the given format, calling flush every
+// exportFlushMessages messages so long sessions reach the client as they are written
+func WriteSessionExport(w io.Writer, export *models.SessionExport, format string, flush func() error) error {
+	var header, footer string
+	var message func(models.Message) (string, error)
+	switch format {
+	case ExportFormatMarkdown:
+		header, footer, message = markdownExport(export)
+	case ExportFormatHTML:
+		header, footer, message = htmlExport(export)
+	case ExportFormatJSON:
+		var err error
+		if header, footer, message, err = jsonExport(export); err != nil {
+			return err
+		}
+	default:
+		return ValidateExportFormat(format)
+	}
+
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	for i, msg := range export.Messages {
+		text, err := message(msg)
+		if err != nil {
+			return err
+		}
+		if format == ExportFormatJSON && i > 0 {
+			text = "," + text
+		}
+		if _, err := io.WriteString(w, text); err != nil {
+			return err
+		}
+		if (i+1)%exportFlushMessages == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := io.WriteString(w, footer); err != nil {
+		return err
+	}
+	return flush()
+}
+
+// exportTitle is the heading of a transcript, falling back to its session ID
+func exportTitle(export *models.SessionExport) string {
+	if export.Title != "" {
+		return export.Title
+	}
+	return "Session " + export.SessionID
+}
+
+// roleLabel capitalizes a message role for display
+func roleLabel(role string) string {
+	if role == "" {
+		return "Unknown"
+	}
+	return strings.ToUpper(role[:1]) + role[1:]
+}
+
+// markdownExport renders a transcript as Markdown, one section per message
+func markdownExport(export *models.SessionExport) (string, string, func(models.Message) (string, error)) {
+	header := fmt.Sprintf("# %s\n\n- Session: `%s`\n- User: `%s`\n- Started: %s\n- Last activity: %s\n- Messages: %d\n\n---\n",
+		exportTitle(export), export.SessionID, export.UserID,
+		export.CreatedAt.Format(time.RFC3339), export.LastActivity.Format(time.RFC3339), export.MessageCount)
+	footer := fmt.Sprintf("\n---\n\n_Exported %s_\n", export.ExportedAt.Format(time.RFC3339))
+	return header, footer, func(msg models.Message) (string, error) {
+		return fmt.Sprintf("\n### %s · %s\n\n%s\n", roleLabel(msg.Role), msg.Timestamp.Format(time.RFC3339), msg.Content), nil
+	}
+}
+
+// htmlExport renders a transcript as a standalone HTML page with every value escaped
+func htmlExport(export *models.SessionExport) (string, string, func(models.Message) (string, error)) {
+	title := html.EscapeString(exportTitle(export))
+	header := fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+.meta { color: #666; font-size: 0.9rem; }
+.message { border-left: 3px solid #ccc; margin: 1rem 0; padding: 0.25rem 1rem; }
+.message.user { border-color: #3b82f6; }
+.message.assistant { border-color: #10b981; }
+.message header { font-weight: 600; }
+.message time { color: #666; font-weight: normal; font-size: 0.85rem; margin-left: 0.5rem; }
+.content { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>%s</h1>
+<p class="meta">Session <code>%s</code> · User <code>%s</code> · Started <time datetime="%s">%s</time> · %d messages</p>
+`, title, title, html.EscapeString(export.SessionID), html.EscapeString(export.UserID),
+		export.CreatedAt.Format(time.RFC3339), export.CreatedAt.Format("2006-01-02 15:04 MST"), export.MessageCount)
+	footer := fmt.Sprintf("<p class=\"meta\">Exported <time datetime=\"%s\">%s</time></p>\n</body>\n</html>\n",
+		export.ExportedAt.Format(time.RFC3339), export.ExportedAt.Format("2006-01-02 15:04 MST"))
+	return header, footer, func(msg models.Message) (string, error) {
+		return fmt.Sprintf("<section class=\"message %s\">\n<header>%s<time datetime=\"%s\">%s</time></header>\n<div class=\"content\">%s</div>\n</section>\n",
+			html.EscapeString(msg.Role), html.EscapeString(roleLabel(msg.Role)),
+			msg.Timestamp.Format(time.RFC3339), msg.Timestamp.Format("2006-01-02 15:04:05"),
+			html.EscapeString(msg.Content)), nil
+	}
+}
+
+// jsonExport renders a transcript as one JSON document whose messages array is written
+// element by element
+func jsonExport(export *models.SessionExport) (string, string, func(models.Message) (string, error), error) {
+	meta := *export
+	meta.Messages = nil
+	encoded, err := json.Marshal(meta)
+	if err != nil {
+		return "", "", nil, err
+	}
+	// Reopen the object in place of its null messages field
+	header := strings.TrimSuffix(string(encoded), `,"messages":null}`) + `,"messages":[`
+	return header, "]}\n", func(msg models.Message) (string, error) {
+		encoded, err := json.Marshal(msg)
+		return string(encoded), err
+	}, nil
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/metrics"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// sessionPolicyGrace is how long live and archived sessions outlive the point at which a
+// policy acts on them, so a daily sweep reaches them before Redis expires them
+const sessionPolicyGrace = 24 * time.Hour
+
+// ErrInvalidSessionPolicy is returned when a session policy fails validation
+var ErrInvalidSessionPolicy = errors.New("invalid session policy")
+
+// SessionPolicies manages per-tenant session lifecycle policies stored in Redis
+type SessionPolicies struct {
+	redisClient *clients.RedisClient
+}
+
+func NewSessionPolicies(redisClient *clients.RedisClient) *SessionPolicies {
+	return &SessionPolicies{redisClient: redisClient}
+}
+
+// Get returns a tenant's policy; tenants without one get an empty policy, under which
+// sessions expire after SESSION_TTL
+func (p *SessionPolicies) Get(tenantID string) (*models.SessionPolicy, error) {
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+
+	policy, err := p.redisClient.GetSessionPolicy(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		policy = &models.SessionPolicy{TenantID: tenantID}
+	}
+
+	return policy, nil
+}
+
+// Put validates and stores a tenant's policy
+func (p *SessionPolicies) Put(policy *models.SessionPolicy) error {
+	if policy.ArchiveAfterSeconds < 0 || policy.DeleteAfterSeconds < 0 {
+		return fmt.Errorf("%w: periods must not be negative", ErrInvalidSessionPolicy)
+	}
+	if policy.ArchiveAfterSeconds == 0 && policy.DeleteAfterSeconds == 0 {
+		return fmt.Errorf("%w: set archive_after_seconds, delete_after_seconds or both", ErrInvalidSessionPolicy)
+	}
+	if policy.ArchiveAfterSeconds > 0 && policy.DeleteAfterSeconds > 0 && policy.DeleteAfterSeconds <= policy.ArchiveAfterSeconds {
+		return fmt.Errorf("%w: delete_after_seconds must be greater than archive_after_seconds", ErrInvalidSessionPolicy)
+	}
+
+	policy.UpdatedAt = time.Now()
+	return p.redisClient.SaveSessionPolicy(policy)
+}
+
+// List returns every stored policy
+func (p *SessionPolicies) List() ([]models.SessionPolicy, error) {
+	return p.redisClient.ListSessionPolicies()
+}
+
+// Delete removes a tenant's policy; its sessions expire after SESSION_TTL from their next write
+func (p *SessionPolicies) Delete(tenantID string) error {
+	return p.redisClient.DeleteSessionPolicy(tenantID)
+}
+
+// sessionTTLSeconds returns how long a tenant's sessions are kept after their last
+// activity: until the policy archives or deletes them, or SESSION_TTL without a policy
+func (m *MemoryService) sessionTTLSeconds(tenantID string) int64 {
+	ttl := int64(config.AppConfig.SessionTTL / time.Second)
+
+	policy, err := m.sessionPolicies.Get(tenantID)
+	if err != nil {
+		fmt.Printf("Warning: using SESSION_TTL for tenant %s: %v\n", tenantID, err)
+		return ttl
+	}
+	grace := int64(sessionPolicyGrace / time.Second)
+	switch {
+	case policy.ArchiveAfterSeconds > 0:
+		return policy.ArchiveAfterSeconds + grace
+	case policy.DeleteAfterSeconds > 0:
+		return policy.DeleteAfterSeconds + grace
+	}
+	return ttl
+}
+
+// ApplySessionPolicies archives and deletes idle sessions of every tenant with a session
+// policy, returning per-tenant counts. Deletions are skipped for tenants under legal hold.
+func (m *MemoryService) ApplySessionPolicies() (map[string]map[string]int, error) {
+	policies, err := m.sessionPolicies.List()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]map[string]int, len(policies))
+	var firstErr error
+	for i := range policies {
+		policy := &policies[i]
+		result := map[string]int{}
+		results[policy.TenantID] = result
+
+		if err := m.ForTenant(policy.TenantID).applySessionPolicy(policy, time.Now(), result); err != nil {
+			fmt.Printf("Warning: session policy of tenant %s failed: %v\n", policy.TenantID, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("tenant %s: %w", policy.TenantID, err)
+			}
+		}
+		for _, action := range []string{"archived", "deleted", "failed"} {
+			if result[action] > 0 {
+				metrics.AddCounter("memorycache_session_policy_sessions_total", "Sessions archived or deleted by tenant session policies",
+					map[string]string{"tenant": policy.TenantID, "action": action}, float64(result[action]))
+			}
+		}
+	}
+	return results, firstErr
+}
+
+// applySessionPolicy sweeps the live and archived sessions of one tenant
+func (m *MemoryService) applySessionPolicy(policy *models.SessionPolicy, now time.Time, result map[string]int) error {
+	retention, err := m.retention.Get(policy.TenantID)
+	if err != nil {
+		return err
+	}
+	canDelete := policy.DeleteAfterSeconds > 0 && !retention.LegalHold
+	archiveAfter := time.Duration(policy.ArchiveAfterSeconds) * time.Second
+	deleteAfter := time.Duration(policy.DeleteAfterSeconds) * time.Second
+
+	live, err := m.tenantSessions("session:", policy.TenantID, m.redisClient.GetSession)
+	if err != nil {
+		return err
+	}
+	for _, session := range live {
+		result["scanned"]++
+		idle := now.Sub(session.LastActivity)
+
+		switch {
+		case canDelete && idle > deleteAfter:
+			if err := m.redisClient.PurgeSession(session); err != nil {
+				result["failed"]++
+				fmt.Printf("Warning: failed to delete session %s: %v\n", session.SessionID, err)
+				continue
+			}
+			result["deleted"]++
+		case archiveAfter > 0 && idle > archiveAfter:
+			keep := int64(sessionArchiveSeconds)
+			if deleteAfter > 0 {
+				keep = int64((deleteAfter - idle + sessionPolicyGrace) / time.Second)
+			}
+			if err := m.redisClient.ArchiveSession(session, keep); err != nil {
+				result["failed"]++
+				fmt.Printf("Warning: failed to archive session %s: %v\n", session.SessionID, err)
+				continue
+			}
+			result["archived"]++
+		}
+	}
+
+	if !canDelete {
+		return nil
+	}
+	archived, err := m.tenantSessions("session_archive:", policy.TenantID, func(sessionID string) (*models.SessionData, error) {
+		session, err := m.redisClient.GetArchivedSession(sessionID)
+		if err == nil && session == nil {
+			err = fmt.Errorf("archived session not found")
+		}
+		return session, err
+	})
+	if err != nil {
+		return err
+	}
+	for _, session := range archived {
+		result["scanned"]++
+		if now.Sub(session.LastActivity) <= deleteAfter {
+			continue
+		}
+		if err := m.redisClient.PurgeArchivedSession(session); err != nil {
+			result["failed"]++
+			fmt.Printf("Warning: failed to delete archived session %s: %v\n", session.SessionID, err)
+			continue
+		}
+		result["deleted"]++
+	}
+	return nil
+}
+
+// tenantSessions loads the sessions stored under prefix that belong to a tenant. Sessions
+// saved before tenants were recorded belong to the default tenant.
+func (m *MemoryService) tenantSessions(prefix string, tenantID string, load func(string) (*models.SessionData, error)) ([]*models.SessionData, error) {
+	keys, err := m.redisClient.ScanKeys(prefix + "*")
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []*models.SessionData
+	for _, key := range keys {
+		session, err := load(strings.TrimPrefix(key, prefix))
+		if err != nil {
+			// The session may have expired since the scan
+			continue
+		}
+		owner := session.TenantID
+		if owner == "" {
+			owner = models.DefaultTenant
+		}
+		if owner == tenantID {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
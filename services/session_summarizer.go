@@ -0,0 +1,173 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+const (
+	// sessionSummaryContextKey is the session context field holding a condensed session's
+	// rolling summary
+	sessionSummaryContextKey = "conversation_summary"
+	// condenseLeaseSeconds bounds how long a crashed instance can keep a session from
+	// being condensed
+	condenseLeaseSeconds = 120
+)
+
+// sessionSummaryMemoryID is the vector memory holding a session's condensed history
+func sessionSummaryMemoryID(sessionID string) string {
+	return fmt.Sprintf("summary_session_%s", sessionID)
+}
+
+// condenseSessionLater condenses a session in the background once it holds more than
+// SESSION_SUMMARIZE_AFTER messages, keeping the save that grew it fast
+func (m *MemoryService) condenseSessionLater(session *models.SessionData) {
+	after := config.AppConfig.SessionSummarizeAfter
+	if after <= 0 || len(session.Messages) <= after {
+		return
+	}
+
+	sessionID := session.SessionID
+	background := m.detached()
+	goBackground(func() {
+		if err := background.condenseSession(sessionID); err != nil {
+			fmt.Printf("Warning: failed to condense session %s: %v\n", sessionID, err)
+		}
+	})
+}
+
+// condenseSession folds all but the latest SESSION_SUMMARY_KEEP messages of a session into
+// its rolling summary, written by the LLM when one is configured. The summary is stored in
+// the session's "conversation_summary" context field and as a session-granularity vector
+// memory, and the folded messages are dropped from the session; their raw memories stay
+// in the vector store.
+func (m *MemoryService) condenseSession(sessionID string) error {
+	claimed, err := m.redisClient.ClaimSessionCondensing(sessionID, condenseLeaseSeconds)
+	if err != nil || !claimed {
+		return err
+	}
+	defer func() {
+		if err := m.redisClient.ReleaseSessionCondensing(sessionID); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}()
+
+	session, err := m.redisClient.GetSession(sessionID)
+	if err != nil {
+		return err
+	}
+	if len(session.Messages) <= config.AppConfig.SessionSummarizeAfter {
+		return nil
+	}
+
+	summarized := messageTotal(session) - config.AppConfig.SessionSummaryKeep
+	rolling := m.rollingSummary(session, summarized)
+	if err := m.saveSessionSummaryMemory(session, rolling); err != nil {
+		return err
+	}
+
+	// Summarizing can take a while; keep the messages and context saved meanwhile
+	latest, err := m.redisClient.GetSession(sessionID)
+	if err != nil {
+		return err
+	}
+	if latest.Context == nil {
+		latest.Context = make(map[string]interface{})
+	}
+	latest.Context[sessionSummaryContextKey] = rolling
+	if err := m.redisClient.DropOldestSessionMessages(latest, summarized-latest.TrimmedMessages); err != nil {
+		return err
+	}
+
+	tenantID := latest.TenantID
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	m.publish(EventMemoryUpdated, tenantID, latest.UserID, sessionSummaryMemoryID(sessionID))
+	return nil
+}
+
+// saveSessionSummaryMemory stores a session's rolling summary as a vector memory, replacing
+// the previous one, so queries at session granularity can find long-past conversations.
+// It is skipped while the tenant's embedding budget is exhausted.
+func (m *MemoryService) saveSessionSummaryMemory(session *models.SessionData, rolling *models.RollingSummary) error {
+	tenantID := session.TenantID
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+
+	content := rolling.Summary
+	if rolling.Title != "" {
+		content = rolling.Title + ": " + content
+	}
+	tokens := EstimateTokens(content)
+	allowed, err := m.reserveEmbedding(tenantID, tokens)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		fmt.Printf("Warning: embedding budget of tenant %s exhausted, session %s summary kept in its context only\n", tenantID, session.SessionID)
+		return nil
+	}
+
+	embedding, provenance, err := m.embedWithProvenance(content)
+	if err != nil {
+		m.releaseEmbedding(tenantID, tokens)
+		return fmt.Errorf("failed to generate session summary embedding: %w", err)
+	}
+
+	// The summary covers up to the last message folded into it
+	periodEnd := session.LastActivity
+	if last := rolling.Messages - session.TrimmedMessages - 1; last >= 0 && last < len(session.Messages) {
+		periodEnd = session.Messages[last].Timestamp
+	}
+
+	now := time.Now()
+	summary := &models.MemoryEntry{
+		ID:        sessionSummaryMemoryID(session.SessionID),
+		UserID:    session.UserID,
+		Content:   content,
+		Embedding: embedding,
+		Metadata: map[string]interface{}{
+			"tenant_id":          tenantID,
+			"session_id":         session.SessionID,
+			"granularity":        models.GranularitySession,
+			"period_start":       session.CreatedAt.Unix(),
+			"period_end":         periodEnd.Unix(),
+			"source_count":       rolling.Messages,
+			"origin":             models.OriginSummary,
+			"confidence":         config.AppConfig.ConfidenceDefault,
+			"confirmed_at":       now.Unix(),
+			"updated_at":         now.Unix(),
+			"embedding_provider": provenance.Provider,
+			"embedding_model":    provenance.Model,
+			"embedding_version":  provenance.Version,
+		},
+		Timestamp: session.CreatedAt,
+	}
+	return m.vectorClient.UpsertMemory(summary)
+}
+
+// contextSummary returns the rolling summary a condensed session keeps in its context, or
+// nil if it has none
+func contextSummary(session *models.SessionData) *models.RollingSummary {
+	value, ok := session.Context[sessionSummaryContextKey]
+	if !ok {
+		return nil
+	}
+
+	// The context is read back from JSON, so the summary arrives as a generic map
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var rolling models.RollingSummary
+	if err := json.Unmarshal(data, &rolling); err != nil {
+		return nil
+	}
+	return &rolling
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+)
+
+var (
+	// backgroundWrites tracks writes a request leaves running after it is answered
+	backgroundWrites sync.WaitGroup
+	shutdownStarted  = make(chan struct{})
+	shutdownOnce     sync.Once
+)
+
+// goBackground runs fn in its own goroutine as a write that DrainBackground waits for.
+// Lite builds run fn before returning, as edge runtimes stop once the response is sent.
+func goBackground(fn func()) {
+	if config.Lite {
+		fn()
+		return
+	}
+
+	backgroundWrites.Add(1)
+	go func() {
+		defer backgroundWrites.Done()
+		fn()
+	}()
+}
+
+// BeginShutdown marks the process as shutting down so readiness fails, load balancers
+// stop routing new requests to it and open event streams end
+func BeginShutdown() {
+	shutdownOnce.Do(func() { close(shutdownStarted) })
+}
+
+// ShutdownStarted returns a channel closed by BeginShutdown, for long-lived requests
+// that would otherwise hold the server open until the shutdown timeout
+func ShutdownStarted() <-chan struct{} {
+	return shutdownStarted
+}
+
+// ShuttingDown reports whether BeginShutdown has been called
+func ShuttingDown() bool {
+	select {
+	case <-shutdownStarted:
+		return true
+	default:
+		return false
+	}
+}
+
+// DrainBackground waits for the background writes started by requests, such as jobs,
+// session condensing and standing query alerts, to finish. It returns ctx's error when
+// ctx ends first, leaving the rest to be cut off with the process.
+func DrainBackground(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		backgroundWrites.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
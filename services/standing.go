@@ -0,0 +1,209 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidStandingQuery is returned for standing queries that cannot be registered
+var ErrInvalidStandingQuery = errors.New("invalid standing query")
+
+const (
+	// defaultStandingThreshold is the similarity a new memory needs to trigger an alert
+	defaultStandingThreshold = 0.8
+	// maxStandingQueries bounds the standing queries of one user, each compared with every save
+	maxStandingQueries = 20
+	// alertBuffer is how many undelivered alerts an alert stream holds before dropping them
+	alertBuffer = 16
+)
+
+// AddStandingQuery registers a query that is compared with every memory the user saves
+// from now on. The query is embedded once, when it is registered.
+func (m *MemoryService) AddStandingQuery(userID string, req models.StandingQueryRequest) (*models.StandingQuery, error) {
+	threshold := req.Threshold
+	if threshold == 0 {
+		threshold = defaultStandingThreshold
+	}
+	if threshold < 0 || threshold > 1 {
+		return nil, fmt.Errorf("%w: threshold %v is not between 0 and 1", ErrInvalidStandingQuery, threshold)
+	}
+	if req.WebhookURL != "" {
+		if err := clients.CheckOutboundURL(req.WebhookURL); err != nil {
+			return nil, fmt.Errorf("%w: webhook_url: %v", ErrInvalidStandingQuery, err)
+		}
+	}
+
+	tenantID := req.TenantID
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	m = m.ForTenant(tenantID)
+
+	queries, err := m.redisClient.GetStandingQueries(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(queries) >= maxStandingQueries {
+		return nil, fmt.Errorf("%w: a user may have at most %d standing queries", ErrInvalidStandingQuery, maxStandingQueries)
+	}
+
+	embedding, err := m.queryEmbedding(tenantID, models.QueryMemoryRequest{Query: req.Query})
+	if err != nil {
+		return nil, err
+	}
+
+	query := models.StandingQuery{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		TenantID:   tenantID,
+		Query:      req.Query,
+		Threshold:  threshold,
+		WebhookURL: req.WebhookURL,
+		Embedding:  embedding,
+		CreatedAt:  time.Now(),
+	}
+	if err := m.redisClient.SaveStandingQueries(userID, append(queries, query)); err != nil {
+		return nil, err
+	}
+
+	query.Embedding = nil
+	return &query, nil
+}
+
+// ListStandingQueries returns a user's standing queries
+func (m *MemoryService) ListStandingQueries(userID string) ([]models.StandingQuery, error) {
+	queries, err := m.redisClient.GetStandingQueries(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range queries {
+		queries[i].Embedding = nil
+	}
+	if queries == nil {
+		queries = []models.StandingQuery{}
+	}
+	return queries, nil
+}
+
+// DeleteStandingQuery removes one of a user's standing queries, reporting whether it existed
+func (m *MemoryService) DeleteStandingQuery(userID string, queryID string) (bool, error) {
+	queries, err := m.redisClient.GetStandingQueries(userID)
+	if err != nil {
+		return false, err
+	}
+
+	kept := queries[:0]
+	for _, query := range queries {
+		if query.ID != queryID {
+			kept = append(kept, query)
+		}
+	}
+	if len(kept) == len(queries) {
+		return false, nil
+	}
+	if len(kept) == 0 {
+		_, err := m.redisClient.DeleteKeys(fmt.Sprintf("standing_queries:%s", userID))
+		return true, err
+	}
+	return true, m.redisClient.SaveStandingQueries(userID, kept)
+}
+
+// matchStandingQueries compares a newly saved memory with its user's standing queries in
+// the background, alerting on every query it matches
+func (m *MemoryService) matchStandingQueries(tenantID string, memory *models.MemoryEntry) {
+	m = m.detached()
+	goBackground(func() {
+		queries, err := m.redisClient.GetStandingQueries(memory.UserID)
+		if err != nil {
+			fmt.Printf("Warning: failed to match standing queries: %v\n", err)
+			return
+		}
+
+		for _, query := range queries {
+			score := (1 + cosineSimilarity(query.Embedding, memory.Embedding)) / 2
+			if score < query.Threshold {
+				continue
+			}
+
+			alert := models.StandingQueryAlert{
+				Event:    "standing_query.matched",
+				QueryID:  query.ID,
+				Query:    query.Query,
+				UserID:   memory.UserID,
+				TenantID: tenantID,
+				MemoryID: memory.ID,
+				Content:  memory.Content,
+				Score:    score,
+				At:       time.Now(),
+			}
+			m.alerts.publish(alert)
+			if query.WebhookURL != "" {
+				if err := m.notifier.Send(query.WebhookURL, tenantID, alert); err != nil {
+					fmt.Printf("Warning: failed to deliver standing query alert %s: %v\n", query.ID, err)
+				}
+			}
+		}
+	})
+}
+
+// SubscribeAlerts streams a user's standing query alerts until the returned cancel
+// function is called. Alerts are dropped for a subscriber that falls behind.
+func (m *MemoryService) SubscribeAlerts(tenantID string, userID string) (<-chan models.StandingQueryAlert, func()) {
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	return m.alerts.subscribe(tenantID + "|" + userID)
+}
+
+// alertBroker fans standing query alerts out to the open alert streams of each user
+type alertBroker struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan models.StandingQueryAlert]bool
+}
+
+func newAlertBroker() *alertBroker {
+	return &alertBroker{subscribers: make(map[string]map[chan models.StandingQueryAlert]bool)}
+}
+
+func (b *alertBroker) subscribe(user string) (<-chan models.StandingQueryAlert, func()) {
+	ch := make(chan models.StandingQueryAlert, alertBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[user] == nil {
+		b.subscribers[user] = make(map[chan models.StandingQueryAlert]bool)
+	}
+	b.subscribers[user][ch] = true
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers[user], ch)
+			if len(b.subscribers[user]) == 0 {
+				delete(b.subscribers, user)
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (b *alertBroker) publish(alert models.StandingQueryAlert) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[alert.TenantID+"|"+alert.UserID] {
+		select {
+		case ch <- alert:
+		default:
+			fmt.Printf("Warning: dropping standing query alert for a slow stream of user %s\n", alert.UserID)
+		}
+	}
+}
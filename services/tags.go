@@ -0,0 +1,117 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrInvalidTags is returned for tags that cannot be stored or used in vector filters
+var ErrInvalidTags = errors.New("invalid tags")
+
+const (
+	// maxMemoryTags bounds the tags of one memory or query
+	maxMemoryTags = 20
+	// maxTagLength bounds the length of one tag
+	maxTagLength = 64
+	// tagScanLimit bounds the memories scanned when listing a user's tags
+	tagScanLimit = 10000
+)
+
+// normalizeTags lowercases and trims tags, dropping empty and repeated ones, so that
+// "Preferences" and "preferences" are the same tag. Tags accept letters, digits, '-',
+// '_', '.' and ':'.
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidTags, tag, maxTagLength)
+		}
+		for _, r := range tag {
+			if !unicode.IsLetter(r) && !unicode.IsNumber(r) && !strings.ContainsRune("-_.:", r) {
+				return nil, fmt.Errorf("%w: %q may only contain letters, digits, '-', '_', '.' and ':'", ErrInvalidTags, tag)
+			}
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > maxMemoryTags {
+		return nil, fmt.Errorf("%w: at most %d tags are allowed, got %d", ErrInvalidTags, maxMemoryTags, len(normalized))
+	}
+	return normalized, nil
+}
+
+// tagFilter returns the vector filter keeping memories that carry any of the tags
+func tagFilter(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	conditions := make([]string, len(tags))
+	for i, tag := range tags {
+		conditions[i] = fmt.Sprintf("tags CONTAINS '%s'", tag)
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+// ListUserTags counts the user's memories per tag, most used first, including keyword-only
+// memories. Quarantined memories are left out, as they are of queries.
+func (m *MemoryService) ListUserTags(userID string) ([]models.TagCount, error) {
+	matches, err := m.vectorClient.ListUserMemories(userID, tagScanLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list memories: %w", err)
+	}
+	keywordMemories, err := m.redisClient.GetKeywordMemories(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	memories := make([]*models.MemoryEntry, 0, len(matches)+len(keywordMemories))
+	for _, match := range matches {
+		memories = append(memories, memoryFromMetadata(match.ID, match.Metadata))
+	}
+	for i := range keywordMemories {
+		memories = append(memories, &keywordMemories[i])
+	}
+
+	counts := make(map[string]int)
+	for _, memory := range memories {
+		if _, quarantined := memory.Metadata["quarantine_reason"]; quarantined {
+			continue
+		}
+		for _, tag := range entryTags(memory) {
+			counts[tag]++
+		}
+	}
+
+	tags := make([]models.TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, models.TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	return tags, nil
+}
+
+// entryTags returns a memory's tags, whether set on the entry or in its metadata as
+// vectors and patched memories carry them
+func entryTags(memory *models.MemoryEntry) []string {
+	return mergeTags(memory.Tags, metadataTags(memory.Metadata))
+}
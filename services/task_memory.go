@@ -0,0 +1,129 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrInvalidTask is returned for task scopes and IDs that cannot be used
+var ErrInvalidTask = errors.New("invalid task")
+
+// taskDeleteBatch bounds how many task memories are listed and deleted at a time
+const taskDeleteBatch = 1000
+
+// validateScope checks a save's scope and task ID, which must be given together
+func validateScope(scope string, taskID string) error {
+	switch scope {
+	case "", models.ScopeLongTerm:
+		if taskID != "" {
+			return fmt.Errorf("%w: task_id requires scope %q", ErrInvalidTask, models.ScopeTask)
+		}
+		return nil
+	case models.ScopeTask:
+		if taskID == "" {
+			return fmt.Errorf("%w: scope %q requires a task_id", ErrInvalidTask, models.ScopeTask)
+		}
+		return validateTaskID(taskID)
+	default:
+		return fmt.Errorf("%w: unknown scope %q, expected %q or %q", ErrInvalidTask, scope, models.ScopeLongTerm, models.ScopeTask)
+	}
+}
+
+// validateTaskID accepts the same characters as assistant IDs
+func validateTaskID(taskID string) error {
+	if err := validateAssistantID(taskID); err != nil {
+		return fmt.Errorf("%w: task ID %q may only contain letters, digits, '-', '_', '.' and ':'", ErrInvalidTask, taskID)
+	}
+	return nil
+}
+
+// taskFilter returns the vector filter keeping task memories out of a query, except those
+// of the given task
+func taskFilter(taskID string) string {
+	if taskID == "" {
+		return "HAS NOT FIELD task_id"
+	}
+	return fmt.Sprintf("(HAS NOT FIELD task_id OR task_id = '%s')", taskID)
+}
+
+// isTaskMemory reports whether vector metadata belongs to a task memory, which rollups,
+// digests and consolidation leave alone
+func isTaskMemory(metadata map[string]interface{}) bool {
+	_, ok := metadata["task_id"]
+	return ok
+}
+
+// scopeTask marks a memory as belonging to a task, expiring with TASK_MEMORY_TTL in case
+// the task is never completed
+func scopeTask(memory *models.MemoryEntry, taskID string) {
+	memory.Metadata["scope"] = models.ScopeTask
+	memory.Metadata["task_id"] = taskID
+	memory.TTL = int64(config.AppConfig.TaskMemoryTTL / time.Second)
+}
+
+// CompleteTaskMemories deletes every memory saved for a task, returning how many were
+// deleted and how many were kept. A tenant under legal hold cannot complete tasks, and
+// memories within the tenant's minimum retention period are kept.
+func (m *MemoryService) CompleteTaskMemories(tenantID string, taskID string) (int, int, error) {
+	if err := validateTaskID(taskID); err != nil {
+		return 0, 0, err
+	}
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	m = m.ForTenant(tenantID)
+
+	policy, err := m.retention.Get(tenantID)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := checkLegalHold(policy); err != nil {
+		return 0, 0, err
+	}
+
+	deleted := 0
+	retained := make(map[string]bool)
+	now := time.Now()
+	for {
+		// Retained memories are listed again each round, so the batch makes room for them
+		matches, err := m.vectorClient.ListTaskMemories(tenantID, taskID, taskDeleteBatch+len(retained))
+		if err != nil {
+			return deleted, len(retained), fmt.Errorf("failed to list task memories: %w", err)
+		}
+
+		var ids []string
+		byUser := make(map[string][]string)
+		for _, match := range matches {
+			if retained[match.ID] {
+				continue
+			}
+			if timestampFloat, ok := match.Metadata["timestamp"].(float64); ok {
+				if checkMinRetention(policy, time.Unix(int64(timestampFloat), 0), now) != nil {
+					retained[match.ID] = true
+					continue
+				}
+			}
+			ids = append(ids, match.ID)
+			userID, _ := match.Metadata["user_id"].(string)
+			byUser[userID] = append(byUser[userID], match.ID)
+		}
+		if len(ids) == 0 {
+			return deleted, len(retained), nil
+		}
+		if err := m.vectorClient.DeleteMemories(ids); err != nil {
+			return deleted, len(retained), err
+		}
+		deleted += len(ids)
+		for userID, memoryIDs := range byUser {
+			m.publish(EventMemoryDeleted, tenantID, userID, memoryIDs...)
+		}
+
+		if len(matches) < taskDeleteBatch+len(retained) {
+			return deleted, len(retained), nil
+		}
+	}
+}
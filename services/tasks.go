@@ -0,0 +1,55 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+)
+
+// TaskState is the outcome of trying to start a webhook task
+type TaskState int
+
+const (
+	// TaskAcquired means this delivery holds the lease and should run the task
+	TaskAcquired TaskState = iota
+	// TaskInProgress means another delivery is running the task right now
+	TaskInProgress
+	// TaskCompleted means the task already finished
+	TaskCompleted
+)
+
+const (
+	// taskLeaseSeconds bounds how long a crashed delivery blocks retries
+	taskLeaseSeconds = 15 * 60
+	// taskDoneSeconds is how long finished tasks are remembered, beyond QStash's retry window
+	taskDoneSeconds = 7 * 24 * 60 * 60
+)
+
+// BeginTask takes the lease for a webhook task, or reports why it cannot run
+func (m *MemoryService) BeginTask(taskID string) (TaskState, error) {
+	acquired, state, err := m.controlClient.AcquireTaskLease(taskID, taskLeaseSeconds)
+	if err != nil {
+		return TaskInProgress, err
+	}
+	if acquired {
+		return TaskAcquired, nil
+	}
+	if state == clients.TaskLeaseDone {
+		return TaskCompleted, nil
+	}
+	return TaskInProgress, nil
+}
+
+// CompleteTask marks a webhook task as done
+func (m *MemoryService) CompleteTask(taskID string) {
+	if err := m.controlClient.CompleteTaskLease(taskID, taskDoneSeconds); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// AbandonTask releases a failed task's lease so a retry can run it
+func (m *MemoryService) AbandonTask(taskID string) {
+	if err := m.controlClient.ReleaseTaskLease(taskID); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
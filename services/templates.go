@@ -0,0 +1,291 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+var (
+	// ErrUnknownPromptTemplate is returned for template names no feature uses
+	ErrUnknownPromptTemplate = errors.New("unknown prompt template")
+	// ErrInvalidPromptTemplate is returned for templates that fail to parse or render
+	ErrInvalidPromptTemplate = errors.New("invalid prompt template")
+)
+
+// promptDefault is a feature's built-in prompt and the sample data new versions are
+// rendered against before they are stored
+type promptDefault struct {
+	description string
+	content     string
+	sample      map[string]interface{}
+}
+
+// sampleMemory is the retrieved memory new ask and chat templates are rendered with, as
+// listed in .Memories and as a block of .Context
+const (
+	sampleMemory      = "I adopted a cat named Miso."
+	sampleMemoryBlock = `<memory index="1" id="mem_1" source="user" origin="message" role="user" session="session456" date="2024-05-01">
+I adopted a cat named Miso.
+</memory>`
+)
+
+var promptDefaults = map[string]promptDefault{
+	models.PromptSummarization: {
+		description: "Summarises a period of memories for rollups and digests",
+		content: `Summarise what the user shared over this {{.Granularity}} in at most {{.MaxSentences}} sentences.
+Keep names, dates, preferences and decisions; drop small talk. Write in the language of the memories.
+
+Memories:
+{{range .Texts}}- {{.}}
+{{end}}`,
+		sample: map[string]interface{}{
+			"Granularity":  "week",
+			"MaxSentences": 8,
+			"Texts":        []string{"I adopted a cat named Miso.", "Miso likes tuna."},
+		},
+	},
+	models.PromptExtraction: {
+		description: "Extracts durable facts about the user from a message",
+		content: `Extract durable facts about the user from the message below, one per line.
+Only include facts worth remembering in later conversations. Reply with nothing if there are none.
+
+Message:
+{{.Content}}`,
+		sample: map[string]interface{}{
+			"Content": "I moved to Berlin last month and started learning German.",
+		},
+	},
+	models.PromptTitling: {
+		description: "Titles a conversation session",
+		content: `Write a title of at most six words for this conversation. Reply with the title only.
+
+{{range .Messages}}{{.}}
+{{end}}`,
+		sample: map[string]interface{}{
+			"Messages": []string{"user: Can you help me plan a trip to Kyoto?", "assistant: Sure, when are you going?"},
+		},
+	},
+	models.PromptAsk: {
+		description: "Answers a question from the user's memories",
+		content: `Answer the question using only the memories below. If they do not contain the answer, say you don't know.
+{{if .Instructions}}
+Follow these instructions from the user:
+{{range .Instructions}}- {{.}}
+{{end}}{{end}}
+{{.Context}}
+
+Question: {{.Question}}`,
+		sample: map[string]interface{}{
+			"Question":     "What is my cat called?",
+			"Instructions": []string{"Always answer in French."},
+			"Memories":     []string{sampleMemory},
+			"Context":      renderMemoryContext([]string{sampleMemoryBlock}),
+		},
+	},
+	models.PromptChat: {
+		description: "System prompt giving chat completions the user's relevant memories",
+		content: `You have talked with this user before. These memories about them may be relevant; use them when they help, and do not mention that they were retrieved.
+{{if .Instructions}}
+Always follow these instructions from the user:
+{{range .Instructions}}- {{.}}
+{{end}}{{end}}{{if .Context}}
+{{.Context}}{{end}}`,
+		sample: map[string]interface{}{
+			"Instructions": []string{"Always answer in French."},
+			"Memories":     []string{sampleMemory},
+			"Context":      renderMemoryContext([]string{sampleMemoryBlock}),
+		},
+	},
+}
+
+// PromptTemplates manages the versioned prompts of LLM-backed features stored in Redis.
+// Features without a stored version use their built-in default.
+type PromptTemplates struct {
+	redisClient *clients.RedisClient
+}
+
+func NewPromptTemplates(redisClient *clients.RedisClient) *PromptTemplates {
+	return &PromptTemplates{redisClient: redisClient}
+}
+
+// List returns the active template of every feature
+func (p *PromptTemplates) List() ([]models.PromptTemplate, error) {
+	names := make([]string, 0, len(promptDefaults))
+	for name := range promptDefaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	templates := make([]models.PromptTemplate, 0, len(names))
+	for _, name := range names {
+		tmpl, err := p.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *tmpl)
+	}
+	return templates, nil
+}
+
+// Get returns a feature's active template
+func (p *PromptTemplates) Get(name string) (*models.PromptTemplate, error) {
+	if _, ok := promptDefaults[name]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPromptTemplate, name)
+	}
+
+	version, err := p.redisClient.GetActivePromptTemplateVersion(name)
+	if err != nil {
+		return nil, err
+	}
+	if version > 0 {
+		stored, err := p.redisClient.GetPromptTemplate(name, version)
+		if err != nil {
+			return nil, err
+		}
+		if stored != nil {
+			stored.Active = true
+			return stored, nil
+		}
+		fmt.Printf("Warning: active prompt template %s v%d is missing, using the default\n", name, version)
+	}
+
+	tmpl := defaultPromptTemplate(name)
+	tmpl.Active = true
+	return tmpl, nil
+}
+
+// Versions returns the built-in default and every stored version of a feature's template
+func (p *PromptTemplates) Versions(name string) ([]models.PromptTemplate, error) {
+	if _, ok := promptDefaults[name]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPromptTemplate, name)
+	}
+
+	stored, err := p.redisClient.ListPromptTemplateVersions(name)
+	if err != nil {
+		return nil, err
+	}
+	active, err := p.redisClient.GetActivePromptTemplateVersion(name)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := append([]models.PromptTemplate{*defaultPromptTemplate(name)}, stored...)
+	for i := range versions {
+		versions[i].Active = versions[i].Version == active
+	}
+	return versions, nil
+}
+
+// Put validates and stores a new version of a feature's template, activating it unless
+// the request says otherwise
+func (p *PromptTemplates) Put(name string, req models.PromptTemplateRequest) (*models.PromptTemplate, error) {
+	defaults, ok := promptDefaults[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPromptTemplate, name)
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		return nil, fmt.Errorf("%w: content must not be empty", ErrInvalidPromptTemplate)
+	}
+	// Rendering the sample catches syntax errors and references to fields the feature does not provide
+	if _, err := renderPrompt(name, req.Content, defaults.sample); err != nil {
+		return nil, err
+	}
+
+	tmpl := &models.PromptTemplate{
+		Name:        name,
+		Content:     req.Content,
+		Description: req.Description,
+		CreatedAt:   time.Now(),
+	}
+	if _, err := p.redisClient.SavePromptTemplate(tmpl); err != nil {
+		return nil, err
+	}
+
+	if req.Activate == nil || *req.Activate {
+		if err := p.redisClient.SetActivePromptTemplateVersion(name, tmpl.Version); err != nil {
+			return nil, err
+		}
+		tmpl.Active = true
+	}
+	return tmpl, nil
+}
+
+// Activate makes a stored version of a feature's template active; version 0 restores the default
+func (p *PromptTemplates) Activate(name string, version int) (*models.PromptTemplate, error) {
+	if _, ok := promptDefaults[name]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPromptTemplate, name)
+	}
+	if version < 0 {
+		return nil, fmt.Errorf("%w: version must not be negative", ErrInvalidPromptTemplate)
+	}
+	if version > 0 {
+		stored, err := p.redisClient.GetPromptTemplate(name, version)
+		if err != nil {
+			return nil, err
+		}
+		if stored == nil {
+			return nil, fmt.Errorf("%w: %s has no version %d", ErrInvalidPromptTemplate, name, version)
+		}
+	}
+
+	if err := p.redisClient.SetActivePromptTemplateVersion(name, version); err != nil {
+		return nil, err
+	}
+	return p.Get(name)
+}
+
+// Reset deletes every stored version of a feature's template, restoring the default
+func (p *PromptTemplates) Reset(name string) error {
+	if _, ok := promptDefaults[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownPromptTemplate, name)
+	}
+	return p.redisClient.DeletePromptTemplates(name)
+}
+
+// Render fills a feature's active template with data. A stored template that no longer
+// renders falls back to the default so a bad edit cannot take the feature down.
+func (p *PromptTemplates) Render(name string, data map[string]interface{}) (string, error) {
+	tmpl, err := p.Get(name)
+	if err != nil {
+		return "", err
+	}
+
+	prompt, err := renderPrompt(name, tmpl.Content, data)
+	if err != nil && tmpl.Version > 0 {
+		fmt.Printf("Warning: prompt template %s v%d failed to render, using the default: %v\n", name, tmpl.Version, err)
+		return renderPrompt(name, promptDefaults[name].content, data)
+	}
+	return prompt, err
+}
+
+// defaultPromptTemplate returns a feature's built-in template as version 0
+func defaultPromptTemplate(name string) *models.PromptTemplate {
+	defaults := promptDefaults[name]
+	return &models.PromptTemplate{
+		Name:        name,
+		Version:     0,
+		Content:     defaults.content,
+		Description: defaults.description,
+	}
+}
+
+// renderPrompt executes a template, failing on references to keys missing from data
+func renderPrompt(name string, content string, data map[string]interface{}) (string, error) {
+	parsed, err := template.New(name).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPromptTemplate, err)
+	}
+
+	var prompt strings.Builder
+	if err := parsed.Execute(&prompt, data); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPromptTemplate, err)
+	}
+	return prompt.String(), nil
+}
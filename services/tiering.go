@@ -0,0 +1,274 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrColdTierDisabled is returned by deep recall and tiering when COLD_TIER_AFTER_DAYS is not set
+var ErrColdTierDisabled = errors.New("cold tier is not enabled")
+
+// closeColdStore releases the connections of a region's cold tier, if it has one
+func closeColdStore(store *clients.ColdStore) {
+	if store != nil {
+		store.Close()
+	}
+}
+
+// TierColdMemories starts a job moving raw memories older than COLD_TIER_AFTER_DAYS out of
+// the vector index into the cold tier, with their full content and embedding. Summaries,
+// instructions, task memories and quarantined memories stay in the index. The job also
+// removes cold memories whose TTL or tenant retention has passed. An empty tenantID
+// tiers every tenant.
+func (m *MemoryService) TierColdMemories(tenantID string) (*models.Job, error) {
+	if !config.AppConfig.ColdTierEnabled() {
+		return nil, ErrColdTierDisabled
+	}
+
+	return m.startJob("tier_cold_memories", tenantID, "", func(m *MemoryService, job *models.Job) error {
+		return m.tierColdMemories(tenantID, job.Progress, func() { m.saveJob(job) })
+	})
+}
+
+// RunColdTiering runs a tiering pass like TierColdMemories but synchronously, for scheduled
+// runs, returning the scanned, tiered and purged counts
+func (m *MemoryService) RunColdTiering(tenantID string) (map[string]int, error) {
+	if !config.AppConfig.ColdTierEnabled() {
+		return nil, ErrColdTierDisabled
+	}
+
+	progress := map[string]int{}
+	err := m.tierColdMemories(tenantID, progress, func() {})
+	return progress, err
+}
+
+// tierColdMemories runs a tiering pass, counting scanned, tiered and purged memories in
+// progress and calling checkpoint after each page
+func (m *MemoryService) tierColdMemories(tenantID string, progress map[string]int, checkpoint func()) error {
+	tier := func(region *MemoryService) error {
+		if err := region.tierRegion(tenantID, progress, checkpoint); err != nil {
+			return err
+		}
+		return region.purgeExpiredColdMemories(tenantID, progress)
+	}
+
+	if tenantID != "" {
+		return tier(m.ForTenant(tenantID))
+	}
+	return m.forEachRegion(tier)
+}
+
+func (m *MemoryService) tierRegion(tenantID string, progress map[string]int, checkpoint func()) error {
+	cutoff := time.Now().AddDate(0, 0, -config.AppConfig.ColdTierAfterDays).Unix()
+
+	cursor := "0"
+	for {
+		matches, next, err := m.vectorClient.RangeVectors(cursor, patchPageSize)
+		if err != nil {
+			return err
+		}
+
+		var tiered []string
+		tieredByUser := make(map[string][]string)
+		for _, match := range matches {
+			memoryTenant := metadataTenant(match.Metadata)
+			if tenantID != "" && memoryTenant != tenantID {
+				continue
+			}
+			progress["scanned"]++
+
+			timestamp, _ := match.Metadata["timestamp"].(float64)
+			if int64(timestamp) >= cutoff || len(match.Vector) == 0 || !coldTierable(match.Metadata) {
+				continue
+			}
+
+			memory := memoryFromMetadata(match.ID, match.Metadata)
+			memory.Embedding = match.Vector
+			memory.Metadata["tiered_at"] = time.Now().Unix()
+
+			// Deleting the vector drops its full content, so the cold copy must hold it
+			if truncated, _ := match.Metadata["content_truncated"].(bool); truncated {
+				ref, _ := match.Metadata["content_ref"].(string)
+				contents, err := m.contentStore.GetMemoryContents(map[string]string{match.ID: ref})
+				if err != nil {
+					return err
+				}
+				content, ok := contents[match.ID]
+				if !ok {
+					fmt.Printf("Warning: full content of memory %s not found, leaving it in the index\n", match.ID)
+					continue
+				}
+				memory.Content = content
+				delete(memory.Metadata, "content_truncated")
+				delete(memory.Metadata, "content_ref")
+			}
+			if err := m.coldStore.SaveColdMemory(memory); err != nil {
+				return err
+			}
+			tiered = append(tiered, match.ID)
+			user := queryCacheUser(memoryTenant, memory.UserID)
+			tieredByUser[user] = append(tieredByUser[user], match.ID)
+		}
+
+		// Memories leave the index only once their cold copy is stored
+		if err := m.vectorClient.DeleteMemories(tiered); err != nil {
+			return fmt.Errorf("failed to remove tiered memories from the vector index: %w", err)
+		}
+		for user, ids := range tieredByUser {
+			memoryTenant, userID, _ := strings.Cut(user, "|")
+			m.publish(EventMemoryTiered, memoryTenant, userID, ids...)
+		}
+		progress["tiered"] += len(tiered)
+		checkpoint()
+
+		if next == "" {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// coldTierable reports whether a memory may move to the cold tier: raw memories only, so
+// rollups, instructions, task context and quarantine review keep working on the index
+func coldTierable(metadata map[string]interface{}) bool {
+	_, quarantined := metadata["quarantine_reason"]
+	return !quarantined && !isSummary(metadata) && !isInstruction(metadata) && !isTaskMemory(metadata)
+}
+
+// purgeExpiredColdMemories deletes cold memories whose TTL or tenant maximum retention has
+// passed, reading every cold memory of the region
+func (m *MemoryService) purgeExpiredColdMemories(tenantID string, progress map[string]int) error {
+	users, err := m.coldStore.ColdUsers()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	policies := make(map[string]*models.RetentionPolicy)
+	for _, userID := range users {
+		memories, err := m.coldStore.ListColdMemories(userID, 0)
+		if err != nil {
+			return err
+		}
+
+		var expired []string
+		expiredByTenant := make(map[string][]string)
+		for _, memory := range memories {
+			memoryTenant := metadataTenant(memory.Metadata)
+			if tenantID != "" && memoryTenant != tenantID {
+				continue
+			}
+			policy, ok := policies[memoryTenant]
+			if !ok {
+				if policy, err = m.retention.Get(memoryTenant); err != nil {
+					return err
+				}
+				policies[memoryTenant] = policy
+			}
+			if isExpired(policy, memory.Timestamp, time.Duration(memory.TTL)*time.Second, now) {
+				expired = append(expired, memory.ID)
+				expiredByTenant[memoryTenant] = append(expiredByTenant[memoryTenant], memory.ID)
+			}
+		}
+		if len(expired) == 0 {
+			continue
+		}
+		if err := m.coldStore.DeleteColdMemories(userID, expired...); err != nil {
+			return err
+		}
+		progress["purged"] += len(expired)
+		for memoryTenant, ids := range expiredByTenant {
+			m.publish(EventMemoryDeleted, memoryTenant, userID, ids...)
+		}
+	}
+	return nil
+}
+
+// deepRecall scores the user's most recent COLD_RECALL_MAX_MEMORIES cold memories against
+// the query embedding, applying the query's tenant, granularity, assistant, tag and retention rules
+func (m *MemoryService) deepRecall(req models.QueryMemoryRequest, queryEmbedding []float64, minScore float64) ([]models.MemoryResult, error) {
+	// Only raw memories are tiered
+	if req.Granularity != "" && req.Granularity != models.GranularityRaw && req.Granularity != models.GranularityAll {
+		return nil, nil
+	}
+
+	memories, err := m.coldStore.ListColdMemories(req.UserID, config.AppConfig.ColdRecallMaxMemories)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cold memories: %w", err)
+	}
+
+	tenantID := req.TenantID
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	policy, err := m.retention.Get(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, _ := normalizeTags(req.Tags)
+	now := time.Now()
+	visible := memories[:0]
+	for _, memory := range memories {
+		if metadataTenant(memory.Metadata) != tenantID || !assistantVisible(memory.Metadata, req.AssistantID) {
+			continue
+		}
+		if len(tags) > 0 && !hasAnyTag(entryTags(&memory), tags) {
+			continue
+		}
+		if isExpired(policy, memory.Timestamp, time.Duration(memory.TTL)*time.Second, now) {
+			continue
+		}
+		visible = append(visible, memory)
+	}
+
+	results := clients.ScoreColdMemories(visible, queryEmbedding, minScore)
+	for i := range results {
+		results[i].Metadata["storage"] = "cold_tier"
+	}
+	return results, nil
+}
+
+// mergeByScore combines index and cold tier results, best first, keeping at most limit
+func mergeByScore(results []models.MemoryResult, cold []models.MemoryResult, limit int) []models.MemoryResult {
+	merged := append(results, cold...)
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Score > merged[j].Score
+	})
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged
+}
+
+// mergeColdMemories moves the source user's cold memories in the tenant to the target
+func (m *MemoryService) mergeColdMemories(tenantID string, source string, target string) (int, error) {
+	if m.coldStore == nil {
+		return 0, nil
+	}
+
+	memories, err := m.coldStore.ListColdMemories(source, 0)
+	if err != nil {
+		return 0, err
+	}
+	moved := 0
+	for i := range memories {
+		memory := &memories[i]
+		if metadataTenant(memory.Metadata) != tenantID {
+			continue
+		}
+		memory.UserID = target
+		if err := m.coldStore.ReassignColdMemory(memory, source); err != nil {
+			return moved, err
+		}
+		moved++
+	}
+	return moved, nil
+}
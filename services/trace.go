@@ -0,0 +1,93 @@
+package services
+
+import (
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// queryTracer fills a query trace; a nil tracer records nothing, so the query pipeline
+// calls it unconditionally
+type queryTracer struct {
+	trace   *models.QueryTrace
+	started time.Time
+	last    time.Time
+}
+
+func newQueryTracer(enabled bool) *queryTracer {
+	if !enabled {
+		return nil
+	}
+	now := time.Now()
+	return &queryTracer{trace: &models.QueryTrace{Stages: []models.TraceStage{}}, started: now, last: now}
+}
+
+// embedding records the query embedding and how long it took
+func (t *queryTracer) embedding(source string, dimensions int) {
+	if t == nil {
+		return
+	}
+	t.trace.Embedding = models.TraceEmbedding{Source: source, Dimensions: dimensions, DurationMs: t.lap()}
+}
+
+// filters records the filters and limits the query runs with
+func (t *queryTracer) filters(filters models.TraceFilters) {
+	if t == nil {
+		return
+	}
+	t.trace.Filters = filters
+	t.lap()
+}
+
+// stage records the ranking after a pipeline stage, with the results it dropped or moved
+func (t *queryTracer) stage(name string, results []models.MemoryResult) {
+	if t == nil {
+		return
+	}
+
+	previous := make(map[string]int)
+	if n := len(t.trace.Stages); n > 0 {
+		for _, candidate := range t.trace.Stages[n-1].Results {
+			previous[candidate.ID] = candidate.Rank
+		}
+	}
+
+	stage := models.TraceStage{Name: name, DurationMs: t.lap(), Results: make([]models.TraceCandidate, len(results))}
+	kept := make(map[string]bool, len(results))
+	for i, result := range results {
+		candidate := models.TraceCandidate{ID: result.ID, Score: result.Score, Rank: i + 1}
+		if rank, ok := previous[result.ID]; ok && rank != candidate.Rank {
+			candidate.PreviousRank = rank
+		}
+		stage.Results[i] = candidate
+		kept[result.ID] = true
+	}
+	if n := len(t.trace.Stages); n > 0 {
+		for _, candidate := range t.trace.Stages[n-1].Results {
+			if !kept[candidate.ID] {
+				stage.Dropped = append(stage.Dropped, candidate.ID)
+			}
+		}
+	}
+	t.trace.Stages = append(t.trace.Stages, stage)
+}
+
+// finish returns the trace with its total duration
+func (t *queryTracer) finish() *models.QueryTrace {
+	if t == nil {
+		return nil
+	}
+	t.trace.TotalMs = msSince(t.started)
+	return t.trace
+}
+
+// lap returns the milliseconds since the previous record
+func (t *queryTracer) lap() float64 {
+	elapsed := msSince(t.last)
+	t.last = time.Now()
+	return elapsed
+}
+
+func msSince(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000
+}
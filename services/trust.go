@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrInvalidSourceTrust is returned for unknown source trust levels and negative weights
+var ErrInvalidSourceTrust = errors.New("invalid source trust")
+
+// validSourceTrust reports whether level is a known source trust level
+func validSourceTrust(level string) bool {
+	switch level {
+	case models.SourceTrustUser, models.SourceTrustAssistant, models.SourceTrustThirdParty:
+		return true
+	}
+	return false
+}
+
+// saveSourceTrust returns the source trust recorded for a save, classifying it by origin
+// and role when the request does not name one
+func saveSourceTrust(req models.SaveMemoryRequest, origin string) (string, error) {
+	if req.SourceTrust != "" {
+		if !validSourceTrust(req.SourceTrust) {
+			return "", fmt.Errorf("%w: unknown source_trust %q (use user, assistant or third_party)", ErrInvalidSourceTrust, req.SourceTrust)
+		}
+		return req.SourceTrust, nil
+	}
+	return classifySourceTrust(origin, req.Role), nil
+}
+
+// classifySourceTrust derives a source trust level from a memory's origin and role
+func classifySourceTrust(origin string, role string) string {
+	switch {
+	case origin == models.OriginImported:
+		return models.SourceTrustThirdParty
+	case origin == models.OriginSummary || origin == models.OriginExtracted:
+		return models.SourceTrustAssistant
+	case role == "user":
+		return models.SourceTrustUser
+	}
+	return models.SourceTrustAssistant
+}
+
+// sourceTrust returns the source trust of a stored memory; memories saved before source
+// trust was recorded are classified by their origin and role
+func sourceTrust(metadata map[string]interface{}) string {
+	if level, _ := metadata["source_trust"].(string); validSourceTrust(level) {
+		return level
+	}
+	origin, _ := metadata["origin"].(string)
+	role, _ := metadata["role"].(string)
+	return classifySourceTrust(origin, role)
+}
+
+// validateTrustQuery checks a query's source trust filter and weight overrides
+func validateTrustQuery(req models.QueryMemoryRequest) error {
+	for _, level := range req.SourceTrust {
+		if !validSourceTrust(level) {
+			return fmt.Errorf("%w: unknown source_trust %q (use user, assistant or third_party)", ErrInvalidSourceTrust, level)
+		}
+	}
+	for level, weight := range req.TrustWeights {
+		if !validSourceTrust(level) {
+			return fmt.Errorf("%w: unknown trust_weights level %q", ErrInvalidSourceTrust, level)
+		}
+		if weight < 0 {
+			return fmt.Errorf("%w: trust weight %v for %s is negative", ErrInvalidSourceTrust, weight, level)
+		}
+	}
+	return nil
+}
+
+// trustWeight returns the score multiplier of a source trust level for a query
+func trustWeight(level string, overrides map[string]float64) float64 {
+	if weight, ok := overrides[level]; ok {
+		return weight
+	}
+	switch level {
+	case models.SourceTrustAssistant:
+		return config.AppConfig.SourceTrustWeightAssistant
+	case models.SourceTrustThirdParty:
+		return config.AppConfig.SourceTrustWeightThirdParty
+	}
+	return 1
+}
+
+// applySourceTrust labels each result with its source trust, drops levels the query did
+// not ask for and rescales scores by trust weight, keeping the ranking sorted
+func applySourceTrust(results []models.MemoryResult, req models.QueryMemoryRequest) []models.MemoryResult {
+	allowed := make(map[string]bool, len(req.SourceTrust))
+	for _, level := range req.SourceTrust {
+		allowed[level] = true
+	}
+
+	kept := results[:0]
+	reweighted := false
+	for _, result := range results {
+		result.SourceTrust = sourceTrust(result.Metadata)
+		if len(allowed) > 0 && !allowed[result.SourceTrust] {
+			continue
+		}
+		if weight := trustWeight(result.SourceTrust, req.TrustWeights); weight != 1 {
+			result.Score *= weight
+			reweighted = true
+		}
+		kept = append(kept, result)
+	}
+	if reweighted {
+		sort.SliceStable(kept, func(i, j int) bool {
+			return kept[i].Score > kept[j].Score
+		})
+	}
+	return kept
+}
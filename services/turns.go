@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+// ErrInvalidReplyTo is returned for a reply_to that names no user message of the session
+var ErrInvalidReplyTo = errors.New("invalid reply_to")
+
+// pairedContentLength bounds, in runes, the question or answer copied into the metadata
+// of the other memory of a turn
+const pairedContentLength = 1000
+
+// questionFor returns the user message an assistant message answers: the one named by
+// reply_to or, with TURN_PAIRING, the session's latest message when the user sent it.
+// It returns nil for messages that answer nothing.
+func questionFor(req models.SaveMemoryRequest, session *models.SessionData) (*models.Message, error) {
+	if req.ReplyTo != "" {
+		if req.Role != "assistant" {
+			return nil, fmt.Errorf("%w: only assistant messages reply to a question", ErrInvalidReplyTo)
+		}
+		for i := len(session.Messages) - 1; i >= 0; i-- {
+			message := session.Messages[i]
+			if message.ID != req.ReplyTo {
+				continue
+			}
+			if message.Role != "user" {
+				return nil, fmt.Errorf("%w: message %s was not sent by the user", ErrInvalidReplyTo, req.ReplyTo)
+			}
+			return &message, nil
+		}
+		return nil, fmt.Errorf("%w: message %s is not in session %s", ErrInvalidReplyTo, req.ReplyTo, req.SessionID)
+	}
+
+	if req.Role != "assistant" || !config.AppConfig.TurnPairing || len(session.Messages) == 0 {
+		return nil, nil
+	}
+	last := session.Messages[len(session.Messages)-1]
+	if last.Role != "user" {
+		return nil, nil
+	}
+	return &last, nil
+}
+
+// pairWithQuestion records on an answer's memory the question it answers
+func pairWithQuestion(answer *models.MemoryEntry, question *models.Message) {
+	answer.Metadata["reply_to"] = question.ID
+	answer.Metadata["question"] = clipRunes(question.Content, pairedContentLength)
+}
+
+// linkAnswerLater records a saved answer on its question's memory in the background, so
+// the question returns the turn too. Answers hidden from queries, flagged as untrusted or
+// not stored because they reinforced another memory are not linked, nor are questions
+// missing from the vector store (keyword-only, still queued or reinforcing another).
+func (m *MemoryService) linkAnswerLater(tenantID string, answer *models.MemoryEntry, saved *models.SaveMemoryResult) {
+	questionID, _ := answer.Metadata["reply_to"].(string)
+	if questionID == "" || saved.Storage == models.StorageReinforced ||
+		answer.Metadata["quarantine_reason"] != nil || answer.Metadata["untrusted"] == true {
+		return
+	}
+
+	background := m.detached()
+	goBackground(func() {
+		question, err := background.vectorClient.FetchMemory(questionID)
+		if err != nil {
+			fmt.Printf("Warning: failed to link answer %s to question %s: %v\n", answer.ID, questionID, err)
+			return
+		}
+		if question == nil || metadataTenant(question.Metadata) != tenantID {
+			return
+		}
+
+		metadata := make(map[string]interface{}, len(question.Metadata)+2)
+		for k, v := range question.Metadata {
+			metadata[k] = v
+		}
+		metadata["answered_by"] = answer.ID
+		metadata["answer"] = clipRunes(answer.Content, pairedContentLength)
+		if err := background.vectorClient.UpdateMetadata(question.ID, metadata); err != nil {
+			fmt.Printf("Warning: failed to link answer %s to question %s: %v\n", answer.ID, questionID, err)
+		}
+	})
+}
+
+// pairTurns sets the turn of each result that is a linked question or answer
+func pairTurns(results []models.MemoryResult) {
+	for i := range results {
+		results[i].Turn = turnOf(results[i])
+	}
+}
+
+// turnOf returns the turn a result belongs to, or nil when it is not paired
+func turnOf(result models.MemoryResult) *models.ConversationTurn {
+	if questionID, _ := result.Metadata["reply_to"].(string); questionID != "" {
+		question, _ := result.Metadata["question"].(string)
+		return &models.ConversationTurn{QuestionID: questionID, Question: question, AnswerID: result.ID, Answer: result.Content}
+	}
+	if answerID, _ := result.Metadata["answered_by"].(string); answerID != "" {
+		answer, _ := result.Metadata["answer"].(string)
+		return &models.ConversationTurn{QuestionID: result.ID, Question: result.Content, AnswerID: answerID, Answer: answer}
+	}
+	return nil
+}
+
+// clipRunes shortens s to at most n runes, marking the cut with an ellipsis
+func clipRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
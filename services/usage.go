@@ -0,0 +1,183 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+var (
+	usageSamplerOnce sync.Once
+	usageSamplerStop = make(chan struct{})
+	stopUsageOnce    sync.Once
+)
+
+// StartUsageSampler samples per-tenant storage usage every USAGE_SAMPLE_INTERVAL. Replicas
+// share a lease so that only one of them scans storage per interval.
+func (m *MemoryService) StartUsageSampler() {
+	interval := config.AppConfig.UsageSampleInterval
+	if interval <= 0 {
+		return
+	}
+
+	usageSamplerOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					// The lease lapses a little early so this replica's next tick can claim it
+					claimed, err := m.controlClient.ClaimStorageUsageSample(interval - interval/10)
+					if err != nil {
+						fmt.Printf("Warning: %v\n", err)
+						continue
+					}
+					if claimed {
+						m.runScheduledTask("sample_storage_usage", func() error {
+							_, err := m.SampleStorageUsage()
+							return err
+						})
+					}
+				case <-usageSamplerStop:
+					return
+				}
+			}
+		}()
+	})
+}
+
+// StopUsageSampler stops the usage sampler; a sample in progress finishes first
+func StopUsageSampler() {
+	stopUsageOnce.Do(func() {
+		close(usageSamplerStop)
+	})
+}
+
+// SampleStorageUsage measures the approximate Redis and vector storage of every tenant
+// across all data regions and stores the report for GetStorageUsage. A region that cannot
+// be read is listed in the report's errors and the others are still counted.
+func (m *MemoryService) SampleStorageUsage() (*models.StorageUsageReport, error) {
+	start := time.Now()
+	usage := make(map[string]*models.TenantStorageUsage)
+	tenant := func(tenantID string) *models.TenantStorageUsage {
+		if tenantID == "" {
+			tenantID = models.DefaultTenant
+		}
+		if usage[tenantID] == nil {
+			usage[tenantID] = &models.TenantStorageUsage{TenantID: tenantID}
+		}
+		return usage[tenantID]
+	}
+
+	report := &models.StorageUsageReport{SampledAt: start}
+	for _, region := range regionNames() {
+		name := region
+		if name == "" {
+			name = "default"
+		}
+		routed := m.forRegion(region)
+		if err := routed.sampleSessionUsage(tenant); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("redis %s: %v", name, err))
+		}
+		if err := routed.sampleVectorUsage(tenant); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("vector %s: %v", name, err))
+		}
+	}
+
+	report.Tenants = make([]models.TenantStorageUsage, 0, len(usage))
+	for _, entry := range usage {
+		report.Tenants = append(report.Tenants, *entry)
+	}
+	sort.Slice(report.Tenants, func(i, j int) bool {
+		return report.Tenants[i].TenantID < report.Tenants[j].TenantID
+	})
+	report.DurationMs = time.Since(start).Milliseconds()
+
+	if err := m.controlClient.SaveStorageUsage(report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// GetStorageUsage returns the latest sample, narrowed to one tenant when tenantID is set,
+// or nil if storage has not been sampled yet
+func (m *MemoryService) GetStorageUsage(tenantID string) (*models.StorageUsageReport, error) {
+	report, err := m.controlClient.GetStorageUsage()
+	if err != nil || report == nil || tenantID == "" {
+		return report, err
+	}
+
+	tenants := []models.TenantStorageUsage{}
+	for _, entry := range report.Tenants {
+		if entry.TenantID == tenantID {
+			tenants = append(tenants, entry)
+		}
+	}
+	report.Tenants = tenants
+	return report, nil
+}
+
+// sampleSessionUsage counts the live and archived sessions of the region's Redis by tenant,
+// sized as their key plus serialized session
+func (m *MemoryService) sampleSessionUsage(tenant func(string) *models.TenantStorageUsage) error {
+	loaders := map[string]func(string) (*models.SessionData, error){
+		"session:":         m.redisClient.GetSession,
+		"session_archive:": m.redisClient.GetArchivedSession,
+	}
+	for prefix, load := range loaders {
+		keys, err := m.redisClient.ScanKeys(prefix + "*")
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			session, err := load(strings.TrimPrefix(key, prefix))
+			if err != nil || session == nil {
+				// The session may have expired since the scan
+				continue
+			}
+			data, err := json.Marshal(session)
+			if err != nil {
+				continue
+			}
+			entry := tenant(session.TenantID)
+			entry.Sessions++
+			entry.RedisBytes += int64(len(key) + len(data))
+		}
+	}
+	return nil
+}
+
+// sampleVectorUsage counts the region's vectors by tenant, sized as 4 bytes per dimension
+// plus the ID and serialized metadata
+func (m *MemoryService) sampleVectorUsage(tenant func(string) *models.TenantStorageUsage) error {
+	dimensions, err := m.vectorClient.GetDimensions()
+	if err != nil {
+		return err
+	}
+
+	cursor := "0"
+	for {
+		matches, next, err := m.vectorClient.RangeMemories(cursor, patchPageSize)
+		if err != nil {
+			return err
+		}
+		for _, match := range matches {
+			metadata, _ := json.Marshal(match.Metadata)
+			entry := tenant(metadataTenant(match.Metadata))
+			entry.Vectors++
+			entry.VectorBytes += int64(dimensions*4 + len(match.ID) + len(metadata))
+		}
+		if next == "" {
+			return nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/metrics"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+)
+
+const (
+	// writeQueueReplayBatch bounds the memories replayed per region on one tick
+	writeQueueReplayBatch = 100
+	// writeQueueMaxAttempts is how often a queued memory may fail to replay while the
+	// vector store is reachable before it is moved aside
+	writeQueueMaxAttempts = 5
+)
+
+var (
+	writeQueueOnce     sync.Once
+	writeQueueStop     = make(chan struct{})
+	stopWriteQueueOnce sync.Once
+)
+
+// queueVectorWrite holds a memory the vector store rejected in the write-ahead queue of
+// the service's region so the save can still be accepted
+func (m *MemoryService) queueVectorWrite(memory *models.MemoryEntry, tenantID string, cause error) error {
+	write := &models.QueuedVectorWrite{
+		Memory:    memory,
+		TenantID:  tenantID,
+		QueuedAt:  time.Now(),
+		LastError: cause.Error(),
+	}
+	if err := m.redisClient.EnqueueVectorWrite(write, config.AppConfig.WriteAheadQueueMax); err != nil {
+		return err
+	}
+	metrics.AddCounter("memorycache_write_queue_enqueued_total", "Saves held in the write-ahead queue while the vector store failed",
+		map[string]string{"tenant": tenantID}, 1)
+	return nil
+}
+
+// StartWriteQueueReplay replays the write-ahead queue of every region each
+// WRITE_AHEAD_REPLAY_INTERVAL. Replicas share a lease so writes are replayed once.
+func (m *MemoryService) StartWriteQueueReplay() {
+	if !config.AppConfig.WriteAheadQueueEnabled {
+		return
+	}
+
+	writeQueueOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(config.AppConfig.WriteAheadReplayInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					if _, err := m.ReplayWriteQueue(); err != nil {
+						fmt.Printf("Warning: write-ahead replay failed: %v\n", err)
+					}
+				case <-writeQueueStop:
+					return
+				}
+			}
+		}()
+	})
+}
+
+// StopWriteQueueReplay stops the replay loop; a replay in progress finishes first
+func StopWriteQueueReplay() {
+	stopWriteQueueOnce.Do(func() {
+		close(writeQueueStop)
+	})
+}
+
+// ReplayWriteQueue writes queued memories to the vector store, region by region, and
+// reports what each region's replay achieved. A region whose vector store still fails
+// keeps its queue for the next attempt.
+func (m *MemoryService) ReplayWriteQueue() ([]models.WriteQueueReplay, error) {
+	var replays []models.WriteQueueReplay
+	err := m.forEachRegionNamed(func(region string, routed *MemoryService) error {
+		replay, err := routed.replayWriteQueue()
+		replay.Region = region
+		replays = append(replays, replay)
+		return err
+	})
+	return replays, err
+}
+
+// WriteQueueStatus reports how many memories wait in each region's write-ahead queue
+func (m *MemoryService) WriteQueueStatus() ([]models.WriteQueueReplay, error) {
+	var status []models.WriteQueueReplay
+	err := m.forEachRegionNamed(func(region string, routed *MemoryService) error {
+		length, err := routed.redisClient.VectorWriteQueueLength()
+		status = append(status, models.WriteQueueReplay{Region: region, Remaining: length})
+		return err
+	})
+	return status, err
+}
+
+func (m *MemoryService) replayWriteQueue() (models.WriteQueueReplay, error) {
+	var replay models.WriteQueueReplay
+
+	length, err := m.redisClient.VectorWriteQueueLength()
+	if err != nil || length == 0 {
+		return replay, err
+	}
+	claimed, err := m.redisClient.ClaimVectorWriteReplay(config.AppConfig.WriteAheadReplayInterval)
+	if err != nil || !claimed {
+		replay.Remaining = length
+		return replay, err
+	}
+	defer func() {
+		if err := m.redisClient.ReleaseVectorWriteReplay(); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}()
+
+	writes, err := m.redisClient.PeekVectorWrites(writeQueueReplayBatch)
+	if err != nil {
+		replay.Remaining = length
+		return replay, err
+	}
+
+	done := 0
+	var failed *models.QueuedVectorWrite
+	var replayErr error
+	for _, write := range writes {
+		if write == nil {
+			// Undecodable entries can never be replayed
+			done++
+			replay.GivenUp++
+			continue
+		}
+		err := m.vectorClient.UpsertMemory(write.Memory)
+		if err == nil {
+			done++
+			replay.Replayed++
+			m.afterQueuedWrite(write)
+			continue
+		}
+
+		// Only count the failure against the memory when the store itself is reachable
+		if _, probeErr := m.vectorClient.GetDimensions(); probeErr != nil {
+			replayErr = fmt.Errorf("vector store still unavailable: %w", err)
+			break
+		}
+		write.Attempts++
+		write.LastError = err.Error()
+		if write.Attempts < writeQueueMaxAttempts {
+			failed = write
+			replayErr = fmt.Errorf("memory %s failed to replay: %w", write.Memory.ID, err)
+			break
+		}
+		if err := m.redisClient.RecordFailedVectorWrite(write); err != nil {
+			replayErr = err
+			break
+		}
+		fmt.Printf("Warning: gave up replaying memory %s after %d attempts: %v\n", write.Memory.ID, write.Attempts, err)
+		done++
+		replay.GivenUp++
+	}
+
+	if err := m.redisClient.DropVectorWrites(done); err != nil {
+		replay.Remaining = length
+		return replay, err
+	}
+	// The failed memory is now the head of the queue
+	if failed != nil {
+		if err := m.redisClient.UpdateOldestVectorWrite(failed); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+	replay.Remaining = length - done
+	if replayErr != nil {
+		replay.Error = replayErr.Error()
+	}
+	metrics.AddCounter("memorycache_write_queue_replayed_total", "Queued saves written to the vector store", nil, float64(replay.Replayed))
+	return replay, replayErr
+}
+
+// afterQueuedWrite runs the steps a save skips while its memory is queued
+func (m *MemoryService) afterQueuedWrite(write *models.QueuedVectorWrite) {
+	memory := write.Memory
+	m.publish(EventMemorySaved, write.TenantID, memory.UserID, memory.ID)
+	if _, quarantined := memory.Metadata["quarantine_reason"]; quarantined {
+		return
+	}
+	m.indexForSearch(memory)
+	m.matchStandingQueries(write.TenantID, memory)
+}
+
+// forEachRegionNamed is forEachRegion for callers that report per region
+func (m *MemoryService) forEachRegionNamed(fn func(region string, routed *MemoryService) error) error {
+	var firstErr error
+	for _, region := range regionNames() {
+		if err := fn(region, m.forRegion(region)); err != nil {
+			if region != "" {
+				err = fmt.Errorf("region %s: %w", region, err)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
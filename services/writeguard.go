@@ -0,0 +1,252 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/clients"
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/metrics"
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/Fairy-nn/MemoryCacheAI/tokenizer"
+)
+
+// ErrWriteBlocked is returned for writes the write guard rejects
+var ErrWriteBlocked = errors.New("write blocked by the write guard")
+
+// quarantineScanLimit bounds how many quarantined memories are listed
+const quarantineScanLimit = 1000
+
+// guardSave checks a save against the write guard. An anomalous save is rejected with
+// ErrWriteBlocked under the throttle action; under the quarantine action the anomaly kind
+// is returned and the memory is stored hidden from queries. Guard state that cannot be
+// read lets the save through.
+func (m *MemoryService) guardSave(tenantID string, memoryID string, req models.SaveMemoryRequest) (string, error) {
+	if !config.AppConfig.WriteGuardEnabled {
+		return "", nil
+	}
+
+	anomaly := m.detectSaveAnomaly(tenantID, req, time.Now())
+	if anomaly == nil {
+		return "", nil
+	}
+	anomaly.Action = config.AppConfig.WriteGuardAction
+	if anomaly.Action == models.WriteGuardQuarantine {
+		anomaly.MemoryID = memoryID
+	}
+	m.raiseAnomaly(anomaly)
+
+	if anomaly.Action == models.WriteGuardThrottle {
+		return "", fmt.Errorf("%w: %s (%d exceeds the limit of %d)", ErrWriteBlocked, anomaly.Kind, anomaly.Value, anomaly.Limit)
+	}
+	return anomaly.Kind, nil
+}
+
+// detectSaveAnomaly returns the first anomaly a save shows, or nil
+func (m *MemoryService) detectSaveAnomaly(tenantID string, req models.SaveMemoryRequest, now time.Time) *models.WriteAnomaly {
+	anomaly := func(kind string, value int64, limit int) *models.WriteAnomaly {
+		return &models.WriteAnomaly{
+			Kind:      kind,
+			TenantID:  tenantID,
+			UserID:    req.UserID,
+			SessionID: req.SessionID,
+			Value:     value,
+			Limit:     int64(limit),
+			At:        now,
+		}
+	}
+
+	if tokens := tokenizer.Count(req.Content); tokens > config.AppConfig.WriteGuardMaxContentTokens {
+		return anomaly(models.AnomalyOversizedContent, int64(tokens), config.AppConfig.WriteGuardMaxContentTokens)
+	}
+
+	burstKey := fmt.Sprintf("write_burst:%s:%s:%d", tenantID, req.UserID, now.Unix()/60)
+	saves, err := m.redisClient.IncrementCounter(burstKey, 1, 120)
+	if err != nil {
+		fmt.Printf("Warning: write guard skipped the burst check: %v\n", err)
+	} else if saves > int64(config.AppConfig.WriteGuardBurstLimit) {
+		return anomaly(models.AnomalySaveBurst, saves, config.AppConfig.WriteGuardBurstLimit)
+	}
+
+	// Identical content from many users is the signature of an injected payload spreading
+	window := int64(config.AppConfig.WriteGuardFanoutWindow / time.Second)
+	fanoutKey := fmt.Sprintf("write_fanout:%s:%s:%d", tenantID, contentFingerprint(req.Content), now.Unix()/window)
+	users, err := m.redisClient.AddToSet(fanoutKey, req.UserID, 2*window)
+	if err != nil {
+		fmt.Printf("Warning: write guard skipped the fanout check: %v\n", err)
+	} else if users > int64(config.AppConfig.WriteGuardFanoutLimit) {
+		return anomaly(models.AnomalyContentFanout, users, config.AppConfig.WriteGuardFanoutLimit)
+	}
+
+	return nil
+}
+
+// guardContext rejects session contexts over WRITE_GUARD_MAX_CONTEXT_BYTES
+func (m *MemoryService) guardContext(sessionID string, context map[string]interface{}) error {
+	if !config.AppConfig.WriteGuardEnabled {
+		return nil
+	}
+
+	encoded, err := json.Marshal(context)
+	if err != nil {
+		return fmt.Errorf("failed to encode session context: %w", err)
+	}
+	if len(encoded) <= config.AppConfig.WriteGuardMaxContextBytes {
+		return nil
+	}
+
+	m.raiseAnomaly(&models.WriteAnomaly{
+		Kind:      models.AnomalyOversizedContext,
+		Action:    models.WriteGuardThrottle,
+		SessionID: sessionID,
+		Value:     int64(len(encoded)),
+		Limit:     int64(config.AppConfig.WriteGuardMaxContextBytes),
+		At:        time.Now(),
+	})
+	return fmt.Errorf("%w: session context of %d bytes exceeds the limit of %d", ErrWriteBlocked, len(encoded), config.AppConfig.WriteGuardMaxContextBytes)
+}
+
+// raiseAnomaly counts an anomaly and reports it to the admin API. A quarantined memory is
+// always reported; otherwise one report per kind and subject per minute is kept, so a
+// burst does not flood the list.
+func (m *MemoryService) raiseAnomaly(anomaly *models.WriteAnomaly) {
+	metrics.AddCounter("memorycache_write_anomalies_total", "Anomalous writes detected by the write guard",
+		map[string]string{"kind": anomaly.Kind, "action": anomaly.Action}, 1)
+	fmt.Printf("Warning: write guard detected %s (%d, limit %d) for tenant %q user %q session %q, action %s\n",
+		anomaly.Kind, anomaly.Value, anomaly.Limit, anomaly.TenantID, anomaly.UserID, anomaly.SessionID, anomaly.Action)
+
+	if anomaly.MemoryID == "" {
+		subject := anomaly.TenantID + "|" + anomaly.UserID + "|" + anomaly.SessionID
+		first, err := m.controlClient.SetIfAbsent(fmt.Sprintf("write_anomaly:%s:%s", anomaly.Kind, subject), "1", 60)
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		if !first {
+			return
+		}
+	}
+	if err := m.controlClient.RecordWriteAnomaly(anomaly); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// contentFingerprint hashes content with case and whitespace normalised
+func contentFingerprint(content string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(content)), " ")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// isQuarantined reports whether vector metadata belongs to a quarantined memory
+func isQuarantined(metadata map[string]interface{}) bool {
+	_, ok := metadata["quarantine_reason"]
+	return ok
+}
+
+// ListWriteAnomalies returns the most recent anomalous writes
+func (m *MemoryService) ListWriteAnomalies(limit int) ([]models.WriteAnomaly, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	return m.controlClient.ListWriteAnomalies(limit)
+}
+
+// ListQuarantinedMemories returns a tenant's quarantined memories, newest first
+func (m *MemoryService) ListQuarantinedMemories(tenantID string) ([]models.QuarantinedMemory, error) {
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	matches, err := m.ForTenant(tenantID).vectorClient.ListQuarantinedMemories(quarantineScanLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantined memories: %w", err)
+	}
+
+	quarantined := make([]models.QuarantinedMemory, 0, len(matches))
+	for _, match := range matches {
+		if metadataTenant(match.Metadata) != tenantID {
+			continue
+		}
+		memory := memoryFromMetadata(match.ID, match.Metadata)
+		reason, _ := match.Metadata["quarantine_reason"].(string)
+		quarantinedAt, _ := match.Metadata["quarantined_at"].(float64)
+		quarantined = append(quarantined, models.QuarantinedMemory{
+			ID:            memory.ID,
+			UserID:        memory.UserID,
+			TenantID:      tenantID,
+			Content:       memory.Content,
+			Reason:        reason,
+			QuarantinedAt: time.Unix(int64(quarantinedAt), 0),
+		})
+	}
+	sort.Slice(quarantined, func(i, j int) bool {
+		return quarantined[i].QuarantinedAt.After(quarantined[j].QuarantinedAt)
+	})
+	return quarantined, nil
+}
+
+// ReleaseQuarantinedMemory makes a quarantined memory visible to queries again, reporting
+// whether the tenant had such a memory
+func (m *MemoryService) ReleaseQuarantinedMemory(tenantID string, memoryID string) (bool, error) {
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	m = m.ForTenant(tenantID)
+
+	match, err := m.quarantinedMemory(tenantID, memoryID)
+	if err != nil || match == nil {
+		return false, err
+	}
+
+	metadata := make(map[string]interface{}, len(match.Metadata))
+	for k, v := range match.Metadata {
+		if k != "quarantine_reason" && k != "quarantined_at" {
+			metadata[k] = v
+		}
+	}
+	if err := m.vectorClient.UpdateMetadata(memoryID, metadata); err != nil {
+		return false, err
+	}
+	memory := memoryFromMetadata(memoryID, metadata)
+	m.indexForSearch(memory)
+	m.publish(EventMemoryUpdated, tenantID, memory.UserID, memoryID)
+	return true, nil
+}
+
+// DeleteQuarantinedMemory deletes a quarantined memory, reporting whether the tenant had
+// such a memory
+func (m *MemoryService) DeleteQuarantinedMemory(tenantID string, memoryID string) (bool, error) {
+	if tenantID == "" {
+		tenantID = models.DefaultTenant
+	}
+	m = m.ForTenant(tenantID)
+
+	match, err := m.quarantinedMemory(tenantID, memoryID)
+	if err != nil || match == nil {
+		return false, err
+	}
+
+	if err := m.vectorClient.DeleteMemory(memoryID); err != nil {
+		return false, err
+	}
+	userID, _ := match.Metadata["user_id"].(string)
+	m.publish(EventMemoryDeleted, tenantID, userID, memoryID)
+	return true, nil
+}
+
+// quarantinedMemory fetches a memory if it is quarantined in the tenant, or returns nil
+func (m *MemoryService) quarantinedMemory(tenantID string, memoryID string) (*clients.QueryMatch, error) {
+	match, err := m.vectorClient.FetchMemory(memoryID)
+	if err != nil || match == nil {
+		return nil, err
+	}
+	if metadataTenant(match.Metadata) != tenantID || !isQuarantined(match.Metadata) {
+		return nil, nil
+	}
+	return match, nil
+}
@@ -0,0 +1,270 @@
+// Package tokenizer counts tokens the way OpenAI's tiktoken does. Loaded with a
+// .tiktoken rank file (e.g. cl100k_base.tiktoken) counts are exact; without one they are
+// estimated from the same pre-tokenization, which is close for English and CJK text.
+package tokenizer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// EncodingEstimate names the fallback used while no rank file is loaded
+const EncodingEstimate = "estimate"
+
+var (
+	mu       sync.RWMutex
+	ranks    map[string]int // byte sequence -> BPE merge rank, nil while estimating
+	encoding = EncodingEstimate
+)
+
+// Load reads a tiktoken rank file (one "<base64 token> <rank>" per line) and switches
+// counting to exact byte-pair encoding. The encoding is named after the file.
+func Load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open tokenizer ranks: %w", err)
+	}
+	defer file.Close()
+
+	loaded := make(map[string]int, 100000)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return fmt.Errorf("invalid tokenizer ranks line %d", line)
+		}
+		token, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return fmt.Errorf("invalid token on tokenizer ranks line %d: %w", line, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return fmt.Errorf("invalid rank on tokenizer ranks line %d: %w", line, err)
+		}
+		loaded[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read tokenizer ranks: %w", err)
+	}
+	if len(loaded) == 0 {
+		return fmt.Errorf("tokenizer ranks file %s is empty", path)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	ranks = loaded
+	encoding = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return nil
+}
+
+// Encoding returns the loaded encoding's name, or EncodingEstimate
+func Encoding() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return encoding
+}
+
+// Exact reports whether counts come from a loaded rank file
+func Exact() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return ranks != nil
+}
+
+// Count returns the number of tokens in text
+func Count(text string) int {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	count := 0
+	for _, piece := range split(text) {
+		count += countPiece(piece)
+	}
+	return count
+}
+
+// Truncate returns the longest prefix of text, cut between pre-tokenized pieces (words,
+// numbers, punctuation runs), that fits in maxTokens, along with its token count
+func Truncate(text string, maxTokens int) (string, int) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	count, end := 0, 0
+	for _, piece := range split(text) {
+		tokens := countPiece(piece)
+		if count+tokens > maxTokens {
+			break
+		}
+		count += tokens
+		end += len(piece)
+	}
+	return text[:end], count
+}
+
+// countPiece counts the tokens of one pre-tokenized piece; callers hold mu
+func countPiece(piece string) int {
+	if ranks == nil {
+		return estimatePiece(piece)
+	}
+	if _, ok := ranks[piece]; ok {
+		return 1
+	}
+	return bytePairCount(piece)
+}
+
+// bytePairCount runs tiktoken's merge loop: starting from single bytes, repeatedly merge
+// the adjacent pair with the lowest rank until no pair is in the vocabulary
+func bytePairCount(piece string) int {
+	bounds := make([]int, len(piece)+1) // start offsets of the current parts, plus the end
+	for i := range bounds {
+		bounds[i] = i
+	}
+
+	for len(bounds) > 2 {
+		best, bestRank := -1, 0
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := ranks[piece[bounds[i]:bounds[i+2]]]; ok && (best < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+	return len(bounds) - 1
+}
+
+// estimatePiece approximates a piece's token count without a vocabulary: common ASCII
+// words are single tokens and longer runs split every eight bytes or so, while most
+// non-ASCII characters (CJK in particular) cost about a token each
+func estimatePiece(piece string) int {
+	ascii, other := 0, 0
+	for _, r := range piece {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+
+	tokens := other + (ascii+7)/8
+	if tokens == 0 {
+		tokens = 1
+	}
+	return tokens
+}
+
+// split pre-tokenizes text like cl100k_base's pattern:
+//
+//	(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+
+//
+// Go's regexp lacks the lookahead, hence the hand-written scanner.
+func split(text string) []string {
+	pieces := make([]string, 0, len(text)/4+1)
+	for i := 0; i < len(text); {
+		n := nextPiece(text[i:])
+		pieces = append(pieces, text[i:i+n])
+		i += n
+	}
+	return pieces
+}
+
+// nextPiece returns the byte length of the piece at the start of s
+func nextPiece(s string) int {
+	r, size := utf8.DecodeRuneInString(s)
+
+	// Contractions
+	if r == '\'' {
+		end := size + 2
+		if end > len(s) {
+			end = len(s)
+		}
+		lower := strings.ToLower(s[size:end])
+		for _, suffix := range []string{"re", "ve", "ll", "s", "t", "m", "d"} {
+			if strings.HasPrefix(lower, suffix) {
+				return size + len(suffix)
+			}
+		}
+	}
+
+	// Letters, optionally led by one non-letter, non-digit, non-newline character
+	if isLetter(r) {
+		return size + spanOf(s[size:], isLetter)
+	}
+	if r != '\r' && r != '\n' && !isNumber(r) {
+		if next, nextSize := utf8.DecodeRuneInString(s[size:]); isLetter(next) {
+			return size + nextSize + spanOf(s[size+nextSize:], isLetter)
+		}
+	}
+
+	// Up to three digits
+	if isNumber(r) {
+		n := size
+		for digits := 1; digits < 3 && n < len(s); digits++ {
+			next, nextSize := utf8.DecodeRuneInString(s[n:])
+			if !isNumber(next) {
+				break
+			}
+			n += nextSize
+		}
+		return n
+	}
+
+	// Punctuation, optionally led by a space and followed by newlines
+	start := 0
+	if r == ' ' {
+		start = size
+	}
+	if punct := spanOf(s[start:], isPunct); punct > 0 {
+		n := start + punct
+		return n + spanOf(s[n:], func(r rune) bool { return r == '\r' || r == '\n' })
+	}
+
+	// Whitespace: up to the last newline of the run; otherwise all but the last character
+	// when more text follows, so the space leads the next word
+	space := spanOf(s, unicode.IsSpace)
+	if lastNewline := strings.LastIndexAny(s[:space], "\r\n"); lastNewline >= 0 {
+		return lastNewline + 1
+	}
+	if space < len(s) {
+		_, lastSize := utf8.DecodeLastRuneInString(s[:space])
+		if space > lastSize {
+			return space - lastSize
+		}
+	}
+	return space
+}
+
+// spanOf returns the byte length of the prefix of s whose runes all satisfy f
+func spanOf(s string, f func(rune) bool) int {
+	for i, r := range s {
+		if !f(r) {
+			return i
+		}
+	}
+	return len(s)
+}
+
+func isLetter(r rune) bool {
+	return unicode.IsLetter(r)
+}
+
+func isNumber(r rune) bool {
+	return unicode.IsNumber(r)
+}
+
+func isPunct(r rune) bool {
+	return !unicode.IsSpace(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
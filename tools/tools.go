@@ -0,0 +1,314 @@
+// Package tools defines the memory tools offered to LLM agents and runs their calls. The
+// MCP server and the OpenAI function-calling endpoints both serve these tools.
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/models"
+	"github.com/Fairy-nn/MemoryCacheAI/services"
+)
+
+// ErrUnknownTool is returned for a call to a tool that does not exist
+var ErrUnknownTool = errors.New("unknown tool")
+
+// defaultSessionID holds the memories saved by calls that name no session
+const defaultSessionID = "mcp"
+
+// defaultSessionMessages is how many of a session's latest messages get_session returns
+const defaultSessionMessages = 50
+
+// Tool describes one tool; Parameters is the JSON Schema of its arguments
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}
+}
+
+// List returns the tools. With userParam the tools take a user_id argument; without it
+// the caller fixes the user of every call, so the model cannot choose whose memories it
+// reads.
+func List(userParam bool) []Tool {
+	user := func(properties map[string]interface{}, description string) map[string]interface{} {
+		if userParam {
+			properties["user_id"] = stringProperty(description)
+		}
+		return properties
+	}
+
+	return []Tool{
+		{
+			Name:        "save_memory",
+			Description: "Save a message or fact to the user's long-term memory, so it can be recalled in later conversations.",
+			Parameters: objectSchema(user(map[string]interface{}{
+				"content":    stringProperty("What to remember, written so it makes sense on its own"),
+				"session_id": stringProperty(fmt.Sprintf("Conversation the memory was said in; defaults to %q", defaultSessionID)),
+				"role": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"user", "assistant"},
+					"description": "Who said it; defaults to user",
+				},
+				"tags": stringArrayProperty("Categories such as preferences or travel"),
+			}, "User the memory belongs to"), "content"),
+		},
+		{
+			Name:        "query_memory",
+			Description: "Search the user's long-term memory for what is relevant to a question, best match first. Facts the question names, such as a birthday, are returned as exact facts instead.",
+			Parameters: objectSchema(user(map[string]interface{}{
+				"query": stringProperty("What to look for, such as a question or a topic"),
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"minimum":     1,
+					"description": "Most memories to return; defaults to 10",
+				},
+				"min_score": map[string]interface{}{
+					"type":        "number",
+					"minimum":     0,
+					"maximum":     1,
+					"description": "Lowest similarity returned; defaults to 0.5",
+				},
+				"tags": stringArrayProperty("Only return memories with any of these tags"),
+				"dedupe": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string", "enum": []string{models.DedupeDocument, models.DedupeSession, models.DedupeContent, models.DedupeTurn}},
+					"description": "Keep only the best memory per document, per session, among near-identical ones or per question and answer",
+				},
+			}, "User whose memories are searched"), "query"),
+		},
+		{
+			Name:        "get_session",
+			Description: "Read the latest messages of a conversation session.",
+			Parameters: objectSchema(map[string]interface{}{
+				"session_id": stringProperty("Session to read"),
+				"last": map[string]interface{}{
+					"type":        "integer",
+					"minimum":     1,
+					"description": fmt.Sprintf("How many of the latest messages to return; defaults to %d", defaultSessionMessages),
+				},
+			}, "session_id"),
+		},
+	}
+}
+
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+func stringProperty(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+func stringArrayProperty(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"items":       map[string]interface{}{"type": "string"},
+		"description": description,
+	}
+}
+
+// Call is one tool call
+type Call struct {
+	Name      string
+	Arguments json.RawMessage // a JSON object; empty means no arguments
+	TenantID  string
+	// UserID fixes the user the call acts for, whatever its arguments say
+	UserID string
+	// DefaultUserID is the user when neither UserID nor the arguments name one
+	DefaultUserID string
+}
+
+// user returns the user a call acts for
+func (c Call) user(given string) (string, error) {
+	switch {
+	case c.UserID != "":
+		return c.UserID, nil
+	case given != "":
+		return given, nil
+	case c.DefaultUserID != "":
+		return c.DefaultUserID, nil
+	}
+	return "", errors.New("user_id is required")
+}
+
+// Invoke runs a call on the memory service and returns its output, ready to be encoded
+// as JSON for the model. Errors, including invalid arguments, are meant for the model too.
+func Invoke(memory *services.MemoryService, call Call) (interface{}, error) {
+	arguments := call.Arguments
+	if len(arguments) == 0 || string(arguments) == "null" {
+		arguments = json.RawMessage("{}")
+	}
+
+	switch call.Name {
+	case "save_memory":
+		return saveMemory(memory, call, arguments)
+	case "query_memory":
+		return queryMemory(memory, call, arguments)
+	case "get_session":
+		return getSession(memory, call, arguments)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownTool, call.Name)
+}
+
+func decodeArguments(arguments json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(arguments, v); err != nil {
+		return fmt.Errorf("invalid arguments: %v", err)
+	}
+	return nil
+}
+
+func saveMemory(memory *services.MemoryService, call Call, arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		Content   string   `json:"content"`
+		UserID    string   `json:"user_id"`
+		SessionID string   `json:"session_id"`
+		Role      string   `json:"role"`
+		Tags      []string `json:"tags"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Content == "" {
+		return nil, errors.New("content is required")
+	}
+	user, err := call.user(args.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if args.SessionID == "" {
+		args.SessionID = defaultSessionID
+	}
+	if args.Role == "" {
+		args.Role = "user"
+	}
+
+	return memory.SaveMemory(models.SaveMemoryRequest{
+		TenantID:  call.TenantID,
+		UserID:    user,
+		SessionID: args.SessionID,
+		Content:   args.Content,
+		Role:      args.Role,
+		Tags:      args.Tags,
+	})
+}
+
+// memoryHit is a query result as shown to the model, without internal metadata
+type memoryHit struct {
+	ID        string    `json:"id"`
+	Content   string    `json:"content"`
+	Score     float64   `json:"score"`
+	SessionID string    `json:"session_id,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// Turn holds the question a remembered answer replied to, and the other way round
+	Turn *models.ConversationTurn `json:"turn,omitempty"`
+}
+
+func queryMemory(memory *services.MemoryService, call Call, arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		Query    string   `json:"query"`
+		UserID   string   `json:"user_id"`
+		Limit    int      `json:"limit"`
+		MinScore float64  `json:"min_score"`
+		Tags     []string `json:"tags"`
+		Dedupe   []string `json:"dedupe"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Query == "" {
+		return nil, errors.New("query is required")
+	}
+	user, err := call.user(args.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := memory.QueryMemory(models.QueryMemoryRequest{
+		TenantID: call.TenantID,
+		UserID:   user,
+		Query:    args.Query,
+		Limit:    args.Limit,
+		MinScore: args.MinScore,
+		Tags:     args.Tags,
+		Dedupe:   args.Dedupe,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]memoryHit, len(response.Results))
+	for i, result := range response.Results {
+		hits[i] = memoryHit{
+			ID:        result.ID,
+			Content:   result.Content,
+			Score:     result.Score,
+			Timestamp: result.Timestamp,
+			Turn:      result.Turn,
+		}
+		hits[i].SessionID, _ = result.Metadata["session_id"].(string)
+		hits[i].Tags = metadataList(result.Metadata["tags"])
+	}
+	output := map[string]interface{}{"memories": hits}
+	if len(response.Facts) > 0 {
+		output["facts"] = response.Facts
+	}
+	if response.Degraded {
+		output["degraded_reason"] = response.DegradedReason
+	}
+	return output, nil
+}
+
+func getSession(memory *services.MemoryService, call Call, arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		SessionID string `json:"session_id"`
+		Last      int    `json:"last"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.SessionID == "" {
+		return nil, errors.New("session_id is required")
+	}
+	if args.Last <= 0 {
+		args.Last = defaultSessionMessages
+	}
+
+	session, err := memory.ForTenant(call.TenantID).GetSession(args.SessionID, nil)
+	if err != nil {
+		return nil, err
+	}
+	messages := session.Messages
+	if len(messages) > args.Last {
+		messages = messages[len(messages)-args.Last:]
+	}
+	return map[string]interface{}{
+		"session_id":    session.SessionID,
+		"user_id":       session.UserID,
+		"messages":      messages,
+		"message_count": len(session.Messages),
+		"last_activity": session.LastActivity,
+	}, nil
+}
+
+// metadataList reads a list of strings from decoded metadata
+func metadataList(value interface{}) []string {
+	var values []string
+	switch items := value.(type) {
+	case []interface{}:
+		for _, item := range items {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	case []string:
+		values = append(values, items...)
+	}
+	return values
+}
@@ -0,0 +1,244 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+	"github.com/Fairy-nn/MemoryCacheAI/metrics"
+)
+
+const (
+	// exportQueueSize bounds the finished spans waiting for export; spans beyond it are dropped
+	exportQueueSize = 4096
+	// exportBatchSize is the most spans sent in one request
+	exportBatchSize = 512
+	// exportInterval is how long a partial batch waits before it is sent
+	exportInterval = 5 * time.Second
+	// exportTimeout bounds one export request
+	exportTimeout = 10 * time.Second
+	// scopeName identifies the instrumentation in exported spans
+	scopeName = "github.com/Fairy-nn/MemoryCacheAI"
+)
+
+// exporter batches finished spans and posts them to the OTLP traces endpoint
+type exporter struct {
+	startOnce sync.Once
+	stopOnce  sync.Once
+	queue     chan otlpSpan
+	stop      chan struct{}
+	done      chan struct{}
+	client    *http.Client
+}
+
+var defaultExporter = &exporter{
+	queue:  make(chan otlpSpan, exportQueueSize),
+	stop:   make(chan struct{}),
+	done:   make(chan struct{}),
+	client: &http.Client{Timeout: exportTimeout},
+}
+
+// Shutdown exports the spans still queued and stops the exporter. Spans ended
+// afterwards are dropped.
+func Shutdown() {
+	defaultExporter.stopOnce.Do(func() {
+		close(defaultExporter.stop)
+		// Without a started exporter there is nothing to flush
+		defaultExporter.startOnce.Do(func() { close(defaultExporter.done) })
+		<-defaultExporter.done
+	})
+}
+
+func (e *exporter) enqueue(span otlpSpan) {
+	e.startOnce.Do(func() { go e.run() })
+
+	select {
+	case <-e.stop:
+		return
+	default:
+	}
+	select {
+	case e.queue <- span:
+	default:
+		metrics.AddCounter("memorycache_trace_spans_dropped_total", "Spans dropped because the export queue was full", nil, 1)
+	}
+}
+
+func (e *exporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]otlpSpan, 0, exportBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.send(batch); err != nil {
+			log.Printf("Warning: failed to export %d spans: %v", len(batch), err)
+			metrics.AddCounter("memorycache_trace_spans_failed_total", "Spans lost because the collector could not be reached", nil, float64(len(batch)))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case span := <-e.queue:
+			batch = append(batch, span)
+			if len(batch) >= exportBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.stop:
+			for {
+				select {
+				case span := <-e.queue:
+					batch = append(batch, span)
+					if len(batch) >= exportBatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// send posts one batch as an OTLP ExportTraceServiceRequest
+func (e *exporter) send(spans []otlpSpan) error {
+	request := otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			attribute("service.name", config.AppConfig.TracingServiceName),
+		}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: scopeName},
+			Spans: spans,
+		}},
+	}}}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal spans: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, config.AppConfig.TracingEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range config.AppConfig.TracingHeaders {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// The OTLP/JSON encoding: IDs are hex, timestamps and integers are strings
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              Kind            `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"` // 2 is STATUS_CODE_ERROR
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+func attribute(key string, value interface{}) otlpAttribute {
+	var encoded map[string]interface{}
+	switch v := value.(type) {
+	case string:
+		encoded = map[string]interface{}{"stringValue": v}
+	case bool:
+		encoded = map[string]interface{}{"boolValue": v}
+	case int:
+		encoded = map[string]interface{}{"intValue": strconv.Itoa(v)}
+	case int64:
+		encoded = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		encoded = map[string]interface{}{"doubleValue": v}
+	default:
+		encoded = map[string]interface{}{"stringValue": fmt.Sprint(v)}
+	}
+	return otlpAttribute{Key: key, Value: encoded}
+}
+
+// export converts the span to its OTLP form; the caller holds s.mu
+func (s *Span) export(end time.Time) otlpSpan {
+	span := otlpSpan{
+		TraceID:           hex.EncodeToString(s.traceID[:]),
+		SpanID:            hex.EncodeToString(s.spanID[:]),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+	}
+	if s.parentID != [8]byte{} {
+		span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+
+	keys := make([]string, 0, len(s.attrs))
+	for key := range s.attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		span.Attributes = append(span.Attributes, attribute(key, s.attrs[key]))
+	}
+	if s.failed {
+		span.Status = &otlpStatus{Code: 2, Message: s.errorMsg}
+	}
+	return span
+}
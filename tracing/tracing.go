@@ -0,0 +1,198 @@
+// Package tracing records request spans and exports them to an OpenTelemetry collector
+// over OTLP/HTTP in its JSON encoding. Trace IDs follow W3C Trace Context, so a trace
+// started by a caller that sends a traceparent header continues through the server.
+//
+// A server span is started per request by the handlers' middleware and carried in the
+// request context; clients bound to that context record a child span per upstream call.
+// Without a span in the context nothing is recorded, so background work costs nothing.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fairy-nn/MemoryCacheAI/config"
+)
+
+// Kind is the OTLP span kind
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+// Span is one timed operation of a trace. A nil *Span is valid and records nothing.
+type Span struct {
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte // zero for the root of a trace
+	name     string
+	kind     Kind
+	start    time.Time
+
+	mu       sync.Mutex
+	attrs    map[string]interface{}
+	errorMsg string
+	failed   bool
+	ended    bool
+}
+
+type spanKey struct{}
+
+// Enabled reports whether OTEL_EXPORTER_OTLP_ENDPOINT configures an exporter
+func Enabled() bool {
+	return config.AppConfig != nil && config.AppConfig.TracingEndpoint != ""
+}
+
+// StartServer starts the span of an incoming request and returns the context carrying
+// it. A valid traceparent header continues the caller's trace and its sampling decision;
+// otherwise a new trace is sampled at OTEL_TRACES_SAMPLER_ARG. Unsampled requests get
+// ctx back unchanged and a nil span.
+func StartServer(ctx context.Context, name string, traceparent string) (context.Context, *Span) {
+	if !Enabled() {
+		return ctx, nil
+	}
+
+	span := &Span{name: name, kind: KindServer, start: time.Now()}
+	if traceID, parentID, sampled, ok := parseTraceparent(traceparent); ok {
+		if !sampled {
+			return ctx, nil
+		}
+		span.traceID = traceID
+		span.parentID = parentID
+	} else {
+		if mathrand.Float64() >= config.AppConfig.TracingSampleRate {
+			return ctx, nil
+		}
+		rand.Read(span.traceID[:])
+	}
+	rand.Read(span.spanID[:])
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Start starts a span of the given kind under the span carried by ctx. It returns nil
+// when ctx carries no span, as for untraced requests and background work.
+func Start(ctx context.Context, name string, kind Kind) *Span {
+	parent := FromContext(ctx)
+	if parent == nil {
+		return nil
+	}
+
+	span := &Span{
+		traceID:  parent.traceID,
+		parentID: parent.spanID,
+		name:     name,
+		kind:     kind,
+		start:    time.Now(),
+	}
+	rand.Read(span.spanID[:])
+	return span
+}
+
+// FromContext returns the span carried by ctx, nil when there is none
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// TraceID returns the hex trace ID, empty for a nil span
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+// Traceparent returns the W3C traceparent header naming the span as the parent of
+// downstream work
+func (s *Span) Traceparent() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", s.TraceID(), hex.EncodeToString(s.spanID[:]))
+}
+
+// SetAttribute attaches a string, integer, float or boolean attribute to the span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attrs == nil {
+		s.attrs = make(map[string]interface{})
+	}
+	s.attrs[key] = value
+}
+
+// SetError marks the span as failed with err; a nil err leaves it unchanged
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = true
+	s.errorMsg = err.Error()
+}
+
+// Fail marks the span as failed with a message, for failures that are not Go errors
+// such as 5xx responses
+func (s *Span) Fail(message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = true
+	s.errorMsg = message
+}
+
+// End finishes the span and queues it for export. Later calls are ignored.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	end := time.Now()
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	exported := s.export(end)
+	s.mu.Unlock()
+
+	defaultExporter.enqueue(exported)
+}
+
+// parseTraceparent reads a version 00 W3C traceparent header
+func parseTraceparent(header string) (traceID [16]byte, parentID [8]byte, sampled bool, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || parts[0] != "00" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return traceID, parentID, false, false
+	}
+	if _, err := hex.Decode(traceID[:], []byte(parts[1])); err != nil || traceID == [16]byte{} {
+		return traceID, parentID, false, false
+	}
+	if _, err := hex.Decode(parentID[:], []byte(parts[2])); err != nil || parentID == [8]byte{} {
+		return traceID, parentID, false, false
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return traceID, parentID, false, false
+	}
+	return traceID, parentID, flags[0]&1 == 1, true
+}